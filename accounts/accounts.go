@@ -0,0 +1,149 @@
+// Package accounts commits account updates, as the transfers of a block
+// change their senders and recipients, against backends that store accounts
+package accounts
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// gwei is the unit of the amounts, which go far beyond 64 bits of wei
+var gwei = uint256.NewInt(1_000_000_000)
+
+// Config describes an account update workload
+type Config struct {
+	DB            string        // name of the backend, for the results
+	Accounts      int64         // the updated accounts are drawn from
+	PerCommit     int           // accounts updated by every commit
+	Commits       int64         // commits measured after the warm-up
+	WarmUpCommits int64         // commits made first and left out of the results
+	Seed          int64         // of the accounts and amounts drawn
+	Progress      time.Duration // how often progress is logged; 0 disables it
+	Log           io.Writer     // receives progress messages; nil discards them
+}
+
+// Address returns the address of account i of the workload
+func Address(i int64) [20]byte {
+	hash := sha256.Sum256([]byte("godb-bench account " + strconv.FormatInt(i, 10)))
+	var address [20]byte
+	copy(address[:], hash[len(hash)-len(address):])
+	return address
+}
+
+// update is the change to one account: its nonce goes up by one and its
+// balance down by amount if it covers it and debit is set, otherwise up
+type update struct {
+	amount uint256.Int
+	debit  bool
+}
+
+// apply makes u to account
+func (u *update) apply(account *godbdb.Account) {
+	account.Nonce++
+	if u.debit && !account.Balance.Lt(&u.amount) {
+		account.Balance.Sub(account.Balance, &u.amount)
+	} else {
+		account.Balance.Add(account.Balance, &u.amount)
+	}
+}
+
+// Run commits cfg.WarmUpCommits and then cfg.Commits updates of
+// cfg.PerCommit distinct accounts each against db, and summarizes the
+// measured commits. Half the accounts of a commit are debited and half
+// credited, as senders and recipients. Cancelling ctx ends the workload
+// early with the commits made so far.
+func Run(ctx context.Context, db ycsb.DB, cfg Config) (*metrics.AccountUpdates, error) {
+	w, ok := db.(godbdb.AccountWriter)
+	if !ok {
+		return nil, fmt.Errorf("%s does not store accounts", cfg.DB)
+	}
+	if cfg.PerCommit < 1 || int64(cfg.PerCommit) > cfg.Accounts {
+		return nil, fmt.Errorf("cannot update %d of %d accounts per commit", cfg.PerCommit, cfg.Accounts)
+	}
+	log := cfg.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	addresses := make([][20]byte, cfg.PerCommit)
+	updates := make([]update, cfg.PerCommit)
+	apply := func(i int, account *godbdb.Account) { updates[i].apply(account) }
+
+	var latencies []time.Duration
+	var warmedUp int64
+	var start time.Time
+	lastProgress := time.Now()
+	for int64(len(latencies)) < cfg.Commits {
+		if ctx.Err() != nil {
+			fmt.Fprintln(log, "Account updates cancelled")
+			break
+		}
+		for i, account := range draw(rng, cfg.Accounts, cfg.PerCommit) {
+			addresses[i] = Address(account)
+			updates[i].amount.Mul(uint256.NewInt(rng.Uint64()), gwei)
+			updates[i].debit = i%2 == 0
+		}
+
+		t := time.Now()
+		if err := w.UpdateAccounts(ctx, addresses, apply); err != nil {
+			if ctx.Err() != nil {
+				fmt.Fprintln(log, "Account updates cancelled")
+				break
+			}
+			return nil, fmt.Errorf("commit %d: %w", warmedUp+int64(len(latencies))+1, err)
+		}
+		latency := time.Since(t)
+
+		if warmedUp < cfg.WarmUpCommits {
+			warmedUp++
+			if warmedUp == cfg.WarmUpCommits {
+				fmt.Fprintf(log, "Warm-up finished after %d commits\n", warmedUp)
+			}
+			continue
+		}
+		if start.IsZero() {
+			start = t
+		}
+		latencies = append(latencies, latency)
+
+		if cfg.Progress > 0 && time.Since(lastProgress) >= cfg.Progress {
+			lastProgress = time.Now()
+			fmt.Fprintf(log, "%s: %d commits\n", time.Since(start).Round(time.Second), len(latencies))
+		}
+	}
+
+	var elapsed time.Duration
+	if !start.IsZero() {
+		elapsed = time.Since(start)
+	}
+	return metrics.NewAccountUpdates(cfg.DB, cfg.Accounts, cfg.PerCommit, warmedUp, latencies, elapsed), nil
+}
+
+// draw returns n distinct accounts of accounts, in random order (Floyd's
+// algorithm, which needs no more than n draws)
+func draw(rng *rand.Rand, accounts int64, n int) []int64 {
+	drawn := make([]int64, 0, n)
+	seen := make(map[int64]bool, n)
+	for j := accounts - int64(n); j < accounts; j++ {
+		a := rng.Int63n(j + 1)
+		if seen[a] {
+			a = j
+		}
+		seen[a] = true
+		drawn = append(drawn, a)
+	}
+	rng.Shuffle(len(drawn), func(i, j int) { drawn[i], drawn[j] = drawn[j], drawn[i] })
+	return drawn
+}
@@ -0,0 +1,102 @@
+package accounts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// SelfDestructConfig describes an account deletion benchmark
+type SelfDestructConfig struct {
+	DB       string       // name of the backend, for the results
+	Accounts int          // created and then deleted at every storage size
+	Slots    []int        // storage sizes, in slots per account, smallest first
+	Seed     int64        // of the values stored
+	Size     func() int64 // bytes the backend takes on disk
+	Log      io.Writer    // receives progress messages; nil discards them
+}
+
+// contract returns the address of account i of the accounts with slots
+// storage slots, apart from those of every other size and of Address
+func contract(slots, i int) [20]byte {
+	hash := sha256.Sum256([]byte("godb-bench contract " + strconv.Itoa(slots) + " " + strconv.Itoa(i)))
+	var address [20]byte
+	copy(address[:], hash[len(hash)-len(address):])
+	return address
+}
+
+// SelfDestruct creates cfg.Accounts accounts of every storage size of
+// cfg.Slots, one commit each, then deletes them with all their storage, one
+// commit each, as SELFDESTRUCT clears contracts, and reports the deletion
+// latencies and the space reclaimed at every size. Cancelling ctx ends the
+// benchmark early with the sizes completed so far.
+func SelfDestruct(ctx context.Context, db ycsb.DB, cfg SelfDestructConfig) (*metrics.SelfDestructs, error) {
+	d, ok := db.(godbdb.AccountDestroyer)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot delete accounts", cfg.DB)
+	}
+	log := cfg.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	result := &metrics.SelfDestructs{DB: cfg.DB}
+	for _, slots := range cfg.Slots {
+		fmt.Fprintf(log, "Creating %d accounts of %d storage slots...\n", cfg.Accounts, slots)
+		keys := make([][32]byte, slots)
+		values := make([][32]byte, slots)
+		start := time.Now()
+		for i := 0; i < cfg.Accounts; i++ {
+			address := contract(slots, i)
+			for j := range keys {
+				keys[j] = storageSlot(address, j)
+				rng.Read(values[j][:])
+			}
+			if err := d.CreateAccount(ctx, address, keys, values); err != nil {
+				if ctx.Err() != nil {
+					fmt.Fprintln(log, "Account deletion cancelled")
+					return result, nil
+				}
+				return nil, fmt.Errorf("create account %d of %d slots: %w", i, slots, err)
+			}
+		}
+		create := time.Since(start)
+
+		fmt.Fprintf(log, "Deleting %d accounts of %d storage slots...\n", cfg.Accounts, slots)
+		sizeBefore := cfg.Size()
+		latencies := make([]time.Duration, 0, cfg.Accounts)
+		for i := 0; i < cfg.Accounts; i++ {
+			t := time.Now()
+			if err := d.DestroyAccount(ctx, contract(slots, i)); err != nil {
+				if ctx.Err() != nil {
+					fmt.Fprintln(log, "Account deletion cancelled")
+					return result, nil
+				}
+				return nil, fmt.Errorf("delete account %d of %d slots: %w", i, slots, err)
+			}
+			latencies = append(latencies, time.Since(t))
+		}
+		result.Levels = append(result.Levels, metrics.NewSelfDestructLevel(slots, latencies, create, sizeBefore, cfg.Size()))
+	}
+	return result, nil
+}
+
+// storageSlot returns slot j of the storage of address, hashed as the slots
+// of a mapping are
+func storageSlot(address [20]byte, j int) [32]byte {
+	var b [28]byte
+	copy(b[:], address[:])
+	binary.BigEndian.PutUint64(b[20:], uint64(j))
+	return sha256.Sum256(b[:])
+}
@@ -0,0 +1,301 @@
+package bench
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+)
+
+// cgroupIOLimits maps the IO limit properties to their io.max keys
+var cgroupIOLimits = []struct{ property, key string }{
+	{CgroupReadBpsProperty, "rbps"},
+	{CgroupWriteBpsProperty, "wbps"},
+	{CgroupReadIOPSProperty, "riops"},
+	{CgroupWriteIOPSProperty, "wiops"},
+}
+
+// deviceNumber matches a block device number, MAJ:MIN
+var deviceNumber = regexp.MustCompile(`^\d+:\d+$`)
+
+// cgroupLimits are the resource caps of a run
+type cgroupLimits struct {
+	memory int64    // memory.max, 0 for none
+	io     []string // key=value pairs of io.max
+	device string   // MAJ:MIN the IO limits apply to
+}
+
+// CheckCgroup checks the resource cap properties without applying them
+func CheckCgroup(props *properties.Properties) error {
+	_, err := parseCgroupLimits(props)
+	return err
+}
+
+// parseCgroupLimits returns the resource caps props asks for, or nil for none
+func parseCgroupLimits(props *properties.Properties) (*cgroupLimits, error) {
+	var limits cgroupLimits
+	positive := func(name string) (int64, error) {
+		v := props.GetString(name, "")
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid %s %q: expected a positive integer", name, v)
+		}
+		return n, nil
+	}
+
+	var err error
+	if limits.memory, err = positive(CgroupMemoryProperty); err != nil {
+		return nil, err
+	}
+	for _, l := range cgroupIOLimits {
+		n, err := positive(l.property)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			limits.io = append(limits.io, fmt.Sprintf("%s=%d", l.key, n))
+		}
+	}
+	limits.device = props.GetString(CgroupDeviceProperty, "")
+	if limits.device != "" && !deviceNumber.MatchString(limits.device) {
+		return nil, fmt.Errorf("invalid %s %q: expected MAJ:MIN, e.g. 259:0", CgroupDeviceProperty, limits.device)
+	}
+
+	if limits.memory == 0 && len(limits.io) == 0 {
+		return nil, nil
+	}
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("resource caps need Linux cgroups, not available on %s", runtime.GOOS)
+	}
+	return &limits, nil
+}
+
+// enterCgroup moves the process into a new cgroup with the resource caps of
+// props, if any. IO limits apply to the disk holding datadir unless a device
+// is given. The function it returns moves the process back and removes the
+// cgroup.
+func enterCgroup(props *properties.Properties, datadir string, log io.Writer) (func(), error) {
+	limits, err := parseCgroupLimits(props)
+	if err != nil || limits == nil {
+		return func() {}, err
+	}
+	if len(limits.io) > 0 && limits.device == "" {
+		if limits.device, err = diskOf(datadir); err != nil {
+			return nil, fmt.Errorf("IO limits: %w; set %s", err, CgroupDeviceProperty)
+		}
+	}
+
+	root, err := cgroup2Mount()
+	if err != nil {
+		return nil, err
+	}
+	previous, err := currentCgroup()
+	if err != nil {
+		return nil, err
+	}
+
+	// Controllers can only be enabled for the children of a cgroup without
+	// processes of its own, which the root is exempt from
+	var controllers []string
+	if limits.memory > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if len(limits.io) > 0 {
+		controllers = append(controllers, "io")
+	}
+	available, err := os.ReadFile(filepath.Join(root, "cgroup.controllers"))
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range controllers {
+		if !slices.Contains(strings.Fields(string(available)), c) {
+			return nil, fmt.Errorf("the %s controller is not available in the cgroup v2 hierarchy at %s (is it used by cgroup v1?)", c, root)
+		}
+		controllers[i] = "+" + c
+	}
+	if err := writeCgroupFile(filepath.Join(root, "cgroup.subtree_control"), strings.Join(controllers, " ")); err != nil {
+		return nil, fmt.Errorf("failed to enable the %s cgroup controllers: %w", strings.Join(controllers, " "), err)
+	}
+
+	dir := filepath.Join(root, fmt.Sprintf("godb-bench-%d", os.Getpid()))
+	if err := os.Mkdir(dir, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	remove := func() {
+		if err := os.Remove(dir); err != nil {
+			fmt.Fprintf(log, "Warning: failed to remove cgroup %s: %v\n", dir, err)
+		}
+	}
+
+	var applied []string
+	if limits.memory > 0 {
+		if err := writeCgroupFile(filepath.Join(dir, "memory.max"), strconv.FormatInt(limits.memory, 10)); err != nil {
+			remove()
+			return nil, err
+		}
+		applied = append(applied, fmt.Sprintf("memory.max=%d", limits.memory))
+		// Swapping would let the engine exceed the cap at disk speed instead
+		// of feeling it; kernels without swap accounting have no such file
+		err := writeCgroupFile(filepath.Join(dir, "memory.swap.max"), "0")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			remove()
+			return nil, err
+		}
+	}
+	if len(limits.io) > 0 {
+		line := limits.device + " " + strings.Join(limits.io, " ")
+		if err := writeCgroupFile(filepath.Join(dir, "io.max"), line); err != nil {
+			remove()
+			return nil, err
+		}
+		applied = append(applied, fmt.Sprintf("io.max=%q", line))
+	}
+
+	// Moving the process moves every thread of it
+	pid := strconv.Itoa(os.Getpid())
+	if err := writeCgroupFile(filepath.Join(dir, "cgroup.procs"), pid); err != nil {
+		remove()
+		return nil, err
+	}
+	fmt.Fprintf(log, "Running in cgroup %s (%s)\n", dir, strings.Join(applied, ", "))
+
+	return func() {
+		if err := writeCgroupFile(filepath.Join(root, previous, "cgroup.procs"), pid); err != nil {
+			fmt.Fprintf(log, "Warning: failed to leave cgroup %s: %v\n", dir, err)
+			return
+		}
+		remove()
+	}, nil
+}
+
+// writeCgroupFile writes one value to a cgroup interface file
+func writeCgroupFile(path, value string) error {
+	if err := os.WriteFile(path, []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to write %q to %s: %w", value, path, err)
+	}
+	return nil
+}
+
+// mount is one line of /proc/self/mountinfo
+type mount struct {
+	device string // MAJ:MIN
+	point  string
+	fstype string
+}
+
+// mounts returns the mounts the process sees
+func mounts() ([]mount, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Spaces and other special characters in paths are octal escapes
+	unescape := strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+	var ms []mount
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i, f := range fields {
+			if f == "-" {
+				sep = i
+				break
+			}
+		}
+		if len(fields) < 5 || sep < 0 || sep+1 >= len(fields) {
+			continue
+		}
+		ms = append(ms, mount{device: fields[2], point: unescape.Replace(fields[4]), fstype: fields[sep+1]})
+	}
+	return ms, scanner.Err()
+}
+
+// cgroup2Mount returns where the cgroup v2 hierarchy is mounted
+func cgroup2Mount() (string, error) {
+	ms, err := mounts()
+	if err != nil {
+		return "", fmt.Errorf("failed to read mounts: %w", err)
+	}
+	for _, m := range ms {
+		if m.fstype == "cgroup2" {
+			return m.point, nil
+		}
+	}
+	return "", fmt.Errorf("no cgroup v2 hierarchy is mounted; resource caps need cgroup v2")
+}
+
+// currentCgroup returns the cgroup v2 path of the process, relative to the
+// hierarchy's mount
+func currentCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("the process is in no cgroup v2 cgroup")
+}
+
+// diskOf returns the MAJ:MIN of the disk holding path, which need not exist
+// yet. io.max only takes whole disks, so a partition is mapped to its disk.
+func diskOf(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(abs); err == nil {
+			break
+		}
+		abs = filepath.Dir(abs)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	ms, err := mounts()
+	if err != nil {
+		return "", fmt.Errorf("failed to read mounts: %w", err)
+	}
+	var best mount
+	for _, m := range ms {
+		within := abs == m.point || m.point == "/" || strings.HasPrefix(abs, m.point+"/")
+		if within && len(m.point) >= len(best.point) {
+			best = m
+		}
+	}
+	if best.device == "" || strings.HasPrefix(best.device, "0:") {
+		return "", fmt.Errorf("%s is not on a block device (%s)", path, best.fstype)
+	}
+
+	sys, err := filepath.EvalSymlinks(filepath.Join("/sys/dev/block", best.device))
+	if err != nil {
+		return "", fmt.Errorf("unknown block device %s: %w", best.device, err)
+	}
+	if _, err := os.Stat(filepath.Join(sys, "partition")); err == nil {
+		dev, err := os.ReadFile(filepath.Join(filepath.Dir(sys), "dev"))
+		if err != nil {
+			return "", fmt.Errorf("failed to find the disk of partition %s: %w", best.device, err)
+		}
+		return strings.TrimSpace(string(dev)), nil
+	}
+	return best.device, nil
+}
@@ -0,0 +1,1767 @@
+// Package bench runs YCSB workloads against the registered backends and
+// returns their results, so Go programs and tests can benchmark without going
+// through the CLI
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/disk"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/perf"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/workload"
+)
+
+// DurationProperty bounds the transaction phase by time instead of operationcount.
+// Its value is a Go duration string such as "5m".
+const DurationProperty = "duration"
+
+// Target-throughput mode: operations are paced to a fixed-rate schedule so
+// latency is measured at a fixed offered load rather than in a closed loop,
+// both from each operation's actual and its intended start
+const (
+	TargetOpsProperty      = "target_ops"       // operations per second across all threads
+	TargetOpsBurstProperty = "target_ops_burst" // operations that may be issued back to back, default 1
+)
+
+// Bursts (on/off load): operations are only issued during the first part of
+// every period, at target_ops if set or as fast as the threads go, and none
+// in the idle rest of it, as transactions arrive with blocks. Latency right
+// after an idle period is reported apart from the rest of the burst.
+const (
+	BurstPeriodProperty    = "burst_period"     // of one burst and the idle time after it, as "12s"
+	BurstDutyCycleProperty = "burst_duty_cycle" // fraction of the period spent in the burst, default 0.5
+	BurstPostIdleProperty  = "burst_post_idle"  // how long into a burst operations count as post-idle, default a tenth of the burst
+)
+
+// Concurrency ramp: the run starts with the first thread count of the list
+// and lets more client threads in at every step, so throughput and p99 can
+// be charted against concurrency in one run. threadcount is set to the last
+// count and the duration, unless set, to one step per count.
+const (
+	RampThreadsProperty = "ramp_threads" // increasing thread counts, as "1,2,4,8"
+	RampStepProperty    = "ramp_step"    // how long each count is held, default DefaultRampStep
+)
+
+// DefaultRampStep is how long each thread count of a ramp is held
+const DefaultRampStep = 30 * time.Second
+
+// StatisticsProperty enables the criterion-style statistics with bootstrap
+// confidence intervals. Resampling is slow on large runs, so it is opt-in.
+const StatisticsProperty = "statistics"
+
+// PerThreadProperty enables the per-thread statistics
+const PerThreadProperty = "perthread"
+
+// ReservoirProperty caps the samples kept per operation for plots and
+// statistics with a uniform reservoir, so memory stays flat on huge runs.
+// 0, the default, keeps every sample.
+const ReservoirProperty = "reservoir"
+
+// Bootstrap settings of the statistics: fewer resamples trade precision for
+// speed, and the level sets the width of every confidence interval
+const (
+	ResamplesProperty       = "resamples"        // default metrics.DefaultBootstrapResamples
+	ConfidenceLevelProperty = "confidence_level" // default metrics.DefaultConfidenceLevel
+)
+
+// PercentilesProperty lists the percentile columns of the results tables,
+// e.g. "50,90,99.99"; default metrics.DefaultPercentiles
+const PercentilesProperty = "percentiles"
+
+// ExcludeErrorsProperty leaves failed operations out of the samples, plots,
+// statistics and streaming statistics. Failures are counted per operation and
+// error class either way.
+const ExcludeErrorsProperty = "exclude_errors"
+
+// DistributionProperty adds every operation's full latency distribution to
+// the results, at percentiles this many percent apart (e.g. 0.1); 0, the
+// default, leaves it out
+const DistributionProperty = "distribution"
+
+// SeriesIntervalProperty is the width of the intervals of the time series in
+// the results, a Go duration such as "1s"; "0" disables them. Default
+// metrics.DefaultSeriesInterval.
+const SeriesIntervalProperty = "series_interval"
+
+// Steady-state detection: the run is taken to have settled at the start of
+// the first window of steady_state_window series intervals over which the
+// coefficient of variation of both the throughput and the mean latency of all
+// operations is at most steady_state_cv, and what completed from then on is
+// reported apart from the full run, instead of guessing a warm-up. A
+// steady_state_cv of 0 disables it, as does disabling the series.
+const (
+	SteadyStateWindowProperty = "steady_state_window" // default metrics.DefaultSteadyStateWindow
+	SteadyStateCVProperty     = "steady_state_cv"     // default metrics.DefaultSteadyStateCV
+)
+
+// Adaptive run length: with target_precision set, transactions go on until
+// the bootstrap confidence interval of target_precision_stat over the
+// samples of target_precision_op is within that fraction of its estimate
+// either way, checked every second after the warm-up, and stop there. The
+// duration, metrics.DefaultPrecisionLimit unless set, bounds the run.
+const (
+	TargetPrecisionProperty     = "target_precision"      // relative half-width, e.g. 0.02 for ±2%
+	TargetPrecisionStatProperty = "target_precision_stat" // mean (default), median or a percentile such as p99
+	TargetPrecisionOpProperty   = "target_precision_op"   // default TOTAL, every operation
+)
+
+// Per-operation timeout and retry policy: every attempt of an operation is
+// abandoned after op_timeout, and attempts that failed other than for a
+// missing key are repeated up to op_retries times, op_retry_backoff after the
+// first and twice as long before each next, counted apart from the
+// operations. Both default to 0, none.
+const (
+	OpTimeoutProperty      = "op_timeout"       // a Go duration such as "500ms"
+	OpRetriesProperty      = "op_retries"       // attempts after the first, at most
+	OpRetryBackoffProperty = "op_retry_backoff" // default 10ms
+)
+
+// defaultRetryBackoff is the wait before the first retry
+const defaultRetryBackoff = 10 * time.Millisecond
+
+// MaxErrorRateProperty aborts the run, which still reports what it measured,
+// once more than this percentage of its operations have failed, judged every
+// second after metrics.MinErrorRateOperations. 0, the default, never aborts.
+const MaxErrorRateProperty = "max_error_rate"
+
+// ResourceIntervalProperty is how often the CPU, memory, file descriptors
+// and disk IO of the process are sampled during the run, a Go duration such
+// as "1s"; "0" disables sampling. Default metrics.DefaultResourceInterval.
+const ResourceIntervalProperty = "resource_interval"
+
+// Live statsd metrics: every operation's throughput and latencies are sent
+// to the statsd server at StatsdAddressProperty (host:port) once per interval
+// while the run is in progress
+const (
+	StatsdAddressProperty  = "statsd_address"
+	StatsdPrefixProperty   = "statsd_prefix"   // default "godb_bench.<db>"
+	StatsdIntervalProperty = "statsd_interval" // default metrics.DefaultStatsdInterval
+)
+
+// ProgressIntervalProperty is how often a progress line with the phase,
+// operations done, throughput and ETA is printed to the log, a Go duration
+// such as "5s"; "0" disables it. It replaces go-ycsb's periodic summaries and
+// defaults to go-ycsb's measurement.interval, in seconds, or
+// metrics.DefaultProgressInterval.
+const ProgressIntervalProperty = "progress_interval"
+
+// Resource caps (Linux, cgroup v2): the process is moved into a cgroup of
+// its own limited to these before the backend is opened, so engines are
+// measured under the footprint a production container gives them. Creating
+// the cgroup needs write access to the cgroup hierarchy, usually root.
+const (
+	CgroupMemoryProperty    = "cgroup.memory_max"    // bytes; swap is disabled along with it
+	CgroupReadBpsProperty   = "cgroup.io_read_bps"   // bytes per second read from the disk
+	CgroupWriteBpsProperty  = "cgroup.io_write_bps"  // bytes per second written to the disk
+	CgroupReadIOPSProperty  = "cgroup.io_read_iops"  // read requests per second
+	CgroupWriteIOPSProperty = "cgroup.io_write_iops" // write requests per second
+	CgroupDeviceProperty    = "cgroup.io_device"     // MAJ:MIN of the disk; default the one holding datadir
+)
+
+// Disk baseline: before the workload, the raw sequential and random IO and
+// fsync latency of the data directory's device are measured (see package
+// disk) and kept in the results, to normalize them against the hardware
+const (
+	DiskBaselineProperty         = "disk_baseline"          // true to measure it
+	DiskBaselineSizeProperty     = "disk_baseline_size"     // bytes of the test file, default disk.DefaultFileSize
+	DiskBaselineDurationProperty = "disk_baseline_duration" // of each random IO and fsync test, default disk.DefaultDuration
+)
+
+// CompactProperty fully compacts the backend once it is open, before the
+// workload, so a run after deleting keys can be measured both with their
+// tombstones and without. The time taken and the bytes on disk before and
+// after are kept in the results.
+const CompactProperty = "compact"
+
+// ReopenProperty closes and reopens the backend this many times once the
+// workload is done, measuring how long closing and opening take against the
+// size of the data directory, as a node restart would. A load phase with it
+// gives the open latency of a freshly loaded database.
+const ReopenProperty = "reopen"
+
+// CrashProperty copies what a crash of the process would leave on disk once
+// the workload is done, opens the copy, timing the recovery, and with
+// workload.VerifyProperty reads back every write the run had acknowledged,
+// counting those lost. Only backends that can take a crash image support it;
+// the others run without it.
+const CrashProperty = "crash"
+
+// PerfCountersProperty counts the CPU's cycles, instructions, cache and
+// branch misses over the measured window with perf_event_open (Linux only).
+// Where they cannot be counted the run goes on without them.
+const PerfCountersProperty = "perf_counters"
+
+// Operation traces: record_trace writes every operation the run issues, with
+// its keys and value sizes, to a file (gzipped if it ends in .gz), and
+// replay_trace re-issues such a file verbatim in place of the workload's own
+// operations, against any backend. The replay ends with the trace.
+const (
+	RecordTraceProperty = "record_trace"
+	ReplayTraceProperty = "replay_trace"
+)
+
+// DefaultChunkSize is the number of consecutive operations each side of an
+// interleaved run gets before the other takes over
+const DefaultChunkSize = 1000
+
+// WorkloadFileProperty names the workload in exported metrics. The CLI sets
+// it to the base name of the workload file unless it is given.
+const WorkloadFileProperty = "workload_file"
+
+// Config describes one benchmark run
+type Config struct {
+	DB         string                 // registered backend, e.g. "pebble"
+	Properties *properties.Properties // workload, DB and run properties; defaults are set in place
+
+	// KeepSamples retains every sample for plots and sample files. Memory
+	// then grows with the run; otherwise only constant-size streaming
+	// statistics are kept. Samples are always kept when StatisticsProperty,
+	// PerThreadProperty or TargetPrecisionProperty is set, as those are
+	// computed from them.
+	KeepSamples bool
+
+	// Log receives progress messages; nil discards them
+	Log io.Writer
+
+	// Dashboard, if set, is a terminal the live dashboard is redrawn on
+	// during the workload, in place of go-ycsb's periodic summaries
+	Dashboard io.Writer
+
+	// BeforeRun, if set, is called just before the workload starts with the
+	// warm-up that precedes the measured window. The function it returns, if
+	// any, is called once the workload finishes.
+	BeforeRun func(warmUp time.Duration) func()
+
+	// Interleave, if set, alternates chunks of the operations between DB, the
+	// A side, and a second backend, so both see the same drift in machine
+	// state over the run. Only transactions can be interleaved.
+	Interleave *Interleave
+}
+
+// Interleave is the B side of an interleaved run
+type Interleave struct {
+	DB         string                 // registered backend, e.g. "pebble"
+	Properties *properties.Properties // B's DB properties; nil uses those of the run
+	ChunkSize  int64                  // operations per chunk; default DefaultChunkSize
+}
+
+// Result is the outcome of one run
+type Result struct {
+	ID            string // random, identifies the run in exported metrics
+	DB            string
+	Properties    *properties.Properties
+	Environment   metrics.Environment
+	Start, End    time.Time                      // when the workload started and finished, warm-up included
+	Operations    []metrics.OperationMetrics     // the TOTAL row, if any, is last
+	Statistics    []metrics.OperationStatistics  // only with StatisticsProperty
+	Threads       []metrics.ThreadStatistics     // only with PerThreadProperty
+	Streaming     []metrics.StreamStatistics     // kept whether or not samples are retained
+	Series        []metrics.TimeSeries           // per-interval throughput and latency, unless disabled
+	Distributions []metrics.Distribution         // only with DistributionProperty
+	HotSet        []metrics.HotSetStatistics     // only with a hot set of keys
+	Ramp          []metrics.RampStatistics       // only with RampThreadsProperty
+	Precision     *metrics.Precision             // only with TargetPrecisionProperty
+	Aborted       *metrics.ErrorRateAbort        // only when MaxErrorRateProperty stopped the run
+	Retries       []metrics.RetryStatistics      // only with OpTimeoutProperty or OpRetriesProperty
+	SteadyState   *metrics.SteadyState           // unless disabled or without a time series
+	Bursts        []metrics.BurstStatistics      // only with BurstPeriodProperty
+	ScanLengths   []metrics.ScanLengthStatistics // only with scans
+	Resources     []metrics.ResourceSample       // process resource usage, unless disabled
+	Engine        []metrics.EngineSample         // backlog and write stalls, for backends that report them
+	WriteAmp      *metrics.WriteAmplification    // only for backends that count their writes
+	BlockCache    *metrics.BlockCache            // only for backends with a block cache
+	IOCalls       []metrics.IOCallStatistics     // only for backends counting their filesystem calls
+	Disk          *metrics.DiskBaseline          // only with DiskBaselineProperty
+	Compaction    *metrics.Compaction            // only with CompactProperty, for backends that compact
+	Reopens       []metrics.Reopen               // only with ReopenProperty
+	Crash         *metrics.CrashRecovery         // only with CrashProperty
+	Perf          *metrics.PerfCounters          // only with PerfCountersProperty, where available
+	Verification  *metrics.Verification          // only with workload.VerifyProperty
+	Layout        *metrics.StorageLayout         // how the backend stored the records
+	Capabilities  *metrics.Capabilities          // only for backends that register them
+	LSM           []metrics.LSMLevel             // at the end of the run, for LSM backends that report their levels
+	DBMetrics     string                         // backend-specific metrics, if the backend reports any
+
+	// Tracker holds the raw samples, for plots and sample files
+	Tracker *metrics.OperationTracker
+
+	// B is the B side of an interleaved run, measured like this one except
+	// for the resources of the process, which are only on A
+	B           *Result
+	Interleaved *metrics.InterleavedComparison // chunk by chunk, only for interleaved runs
+}
+
+// Results converts the result into its serializable form
+func (r *Result) Results(name string) metrics.Results {
+	return metrics.Results{
+		Name:          name,
+		ID:            r.ID,
+		DB:            r.DB,
+		Properties:    r.Properties.Map(),
+		Environment:   r.Environment,
+		Operations:    r.Operations,
+		Statistics:    r.Statistics,
+		Threads:       r.Threads,
+		Streaming:     r.Streaming,
+		Series:        r.Series,
+		Distributions: r.Distributions,
+		HotSet:        r.HotSet,
+		Ramp:          r.Ramp,
+		Precision:     r.Precision,
+		Aborted:       r.Aborted,
+		Retries:       r.Retries,
+		SteadyState:   r.SteadyState,
+		Bursts:        r.Bursts,
+		ScanLengths:   r.ScanLengths,
+		Resources:     r.Resources,
+		Engine:        r.Engine,
+		WriteAmp:      r.WriteAmp,
+		BlockCache:    r.BlockCache,
+		IOCalls:       r.IOCalls,
+		Disk:          r.Disk,
+		Compaction:    r.Compaction,
+		Reopens:       r.Reopens,
+		Crash:         r.Crash,
+		LSM:           r.LSM,
+		DBMetrics:     r.DBMetrics,
+		Verification:  r.Verification,
+		Layout:        r.Layout,
+		Capabilities:  r.Capabilities,
+		Perf:          r.Perf,
+		Samples:       r.Tracker.Samples(),
+		Bootstrap:     r.Tracker.Bootstrap(),
+	}
+}
+
+// Runner runs the benchmark described by its Config. go-ycsb keeps its
+// measurements in process-wide state, so runs must not overlap.
+type Runner struct {
+	config Config
+}
+
+// NewRunner creates a Runner for config
+func NewRunner(config Config) *Runner {
+	return &Runner{config: config}
+}
+
+// Run executes the workload against the backend and returns its results.
+// Cancelling ctx ends the workload early.
+func (r *Runner) Run(ctx context.Context) (*Result, error) {
+	dbName, props := r.config.DB, r.config.Properties
+	if props == nil {
+		return nil, fmt.Errorf("no properties given")
+	}
+	log := r.config.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	ApplyDefaults(dbName, props)
+	if err := ConfigureMetrics(props); err != nil {
+		return nil, err
+	}
+
+	if _, ok := props.Get(workload.SeedProperty); ok {
+		fmt.Fprintf(log, "Using seed %d\n", props.GetInt64(workload.SeedProperty, 0))
+	}
+
+	workloadName := props.GetString(prop.Workload, "core")
+	workloadCreator := ycsb.GetWorkloadCreator(workloadName)
+	if workloadCreator == nil {
+		return nil, fmt.Errorf("workload %s not found", workloadName)
+	}
+	wl, err := workloadCreator.Create(props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workload: %w", err)
+	}
+	defer wl.Close()
+	traceDone := workload.TraceDone(wl)
+	if traceDone != nil {
+		fmt.Fprintf(log, "Replaying operation trace %s\n", props.GetString(workload.TraceFileProperty, ""))
+	}
+
+	dbCreator := ycsb.GetDBCreator(dbName)
+	if dbCreator == nil {
+		return nil, fmt.Errorf("DB creator for %s not found", dbName)
+	}
+
+	// The caps cover opening the backend too, as its caches are sized then
+	datadir := props.GetString("datadir", "/tmp/"+dbName)
+	leaveCgroup, err := enterCgroup(props, datadir, log)
+	if err != nil {
+		return nil, err
+	}
+	defer leaveCgroup()
+
+	var baseline *metrics.DiskBaseline
+	if props.GetBool(DiskBaselineProperty, false) {
+		if baseline, err = measureDisk(props, datadir, log); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := godbdb.CheckValueSize(dbName, props); err != nil {
+		return nil, err
+	}
+	if err := godbdb.CheckReadOnly(dbName, props); err != nil {
+		return nil, err
+	}
+	if props.GetBool(CompactProperty, false) && props.GetBool(godbdb.ReadOnlyProperty, false) {
+		return nil, fmt.Errorf("%s cannot be combined with %s, which never writes to the database", CompactProperty, godbdb.ReadOnlyProperty)
+	}
+	// A trace records keys but not their table, so it would replay every
+	// operation against the first
+	if props.GetString(RecordTraceProperty, "") != "" && props.GetInt(godbdb.TableCountProperty, 1) > 1 {
+		return nil, fmt.Errorf("%s cannot be combined with %s above 1, as traces do not record tables", RecordTraceProperty, godbdb.TableCountProperty)
+	}
+	db, err := dbCreator.Create(props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DB: %w", err)
+	}
+	// Reopening replaces db
+	defer func() {
+		db.Close()
+	}()
+
+	var compaction *metrics.Compaction
+	if props.GetBool(CompactProperty, false) {
+		if compaction, err = compact(ctx, dbName, db, log); err != nil {
+			return nil, err
+		}
+	}
+
+	interleave := r.config.Interleave
+	var dbB ycsb.DB
+	var propsB *properties.Properties
+	chunk := int64(DefaultChunkSize)
+	if interleave != nil {
+		if interleave.ChunkSize > 0 {
+			chunk = interleave.ChunkSize
+		}
+		if !props.GetBool(prop.DoTransactions, true) {
+			return nil, fmt.Errorf("only transactions can be interleaved: load each side separately")
+		}
+		if props.GetBool(workload.VerifyProperty, false) {
+			return nil, fmt.Errorf("%s cannot check an interleaved run: verify each side separately", workload.VerifyProperty)
+		}
+		propsB = interleave.Properties
+		if propsB == nil {
+			propsB = cloneProperties(props)
+		}
+		ApplyDefaults(interleave.DB, propsB)
+		creator := ycsb.GetDBCreator(interleave.DB)
+		if creator == nil {
+			return nil, fmt.Errorf("DB creator for %s not found", interleave.DB)
+		}
+		if err := godbdb.CheckValueSize(interleave.DB, propsB); err != nil {
+			return nil, err
+		}
+		if err := godbdb.CheckReadOnly(interleave.DB, propsB); err != nil {
+			return nil, err
+		}
+		dbB, err = creator.Create(propsB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create DB B: %w", err)
+		}
+		defer dbB.Close()
+	}
+
+	env := metrics.CaptureEnvironment()
+
+	// Initialize YCSB measurement system
+	measurement.InitMeasure(props)
+
+	precisionTarget, precisionOp, precisionStat, err := TargetPrecision(props)
+	if err != nil {
+		return nil, err
+	}
+	if !props.GetBool(prop.DoTransactions, true) {
+		precisionTarget = 0
+	}
+	maxErrorRate, err := MaxErrorRate(props)
+	if err != nil {
+		return nil, err
+	}
+	retain := r.config.KeepSamples || props.GetBool(StatisticsProperty, false) || props.GetBool(PerThreadProperty, false) || precisionTarget > 0
+	if !retain {
+		fmt.Fprintln(log, "Samples not retained: keeping streaming statistics only")
+	}
+
+	// Wrap DB with measurement wrapper
+	tracker, err := newTracker(db, props, retain)
+	if err != nil {
+		return nil, err
+	}
+	resourceInterval, err := ResourceInterval(props)
+	if err != nil {
+		return nil, err
+	}
+	if size := props.GetInt(ReservoirProperty, 0); retain && size > 0 {
+		fmt.Fprintf(log, "Sampling at most %d samples per operation\n", size)
+	}
+	var measuredDB godbdb.BatchingDB = client.DbWrapper{DB: tracker}
+
+	// Retries go below the interleaving too, so they stay on their side
+	opTimeout, opRetries, opBackoff, err := OpPolicy(props)
+	if err != nil {
+		return nil, err
+	}
+	var retrying, retryingB *godbdb.RetryingDB
+	if opTimeout > 0 || opRetries > 0 {
+		retrying = godbdb.NewRetryingDB(measuredDB, opTimeout, opRetries, opBackoff)
+		measuredDB = retrying
+		fmt.Fprintf(log, "Operation timeout %s, up to %d retries %s apart and doubling\n",
+			formatTimeout(opTimeout), opRetries, opBackoff)
+	}
+
+	// Each side of an interleaved run is measured on its own, below the
+	// interleaving so neither is charged for the other's operations
+	var trackerB *metrics.OperationTracker
+	var interleaved *godbdb.InterleavedDB
+	if interleave != nil {
+		trackerB, err = newTracker(dbB, props, retain)
+		if err != nil {
+			return nil, err
+		}
+		var measuredB godbdb.BatchingDB = client.DbWrapper{DB: trackerB}
+		if retrying != nil {
+			retryingB = godbdb.NewRetryingDB(measuredB, opTimeout, opRetries, opBackoff)
+			measuredB = retryingB
+		}
+		interleaved = godbdb.NewInterleavedDB(measuredDB, measuredB, chunk)
+		measuredDB = interleaved
+		fmt.Fprintf(log, "Interleaving %s (A) and %s (B) every %d operations\n", dbName, interleave.DB, chunk)
+	}
+	var wrappedDB godbdb.BatchingDB = measuredDB
+
+	if keys := workload.HotKeys(wl); keys != nil {
+		tracker.SetHotKeys(keys)
+		if trackerB != nil {
+			trackerB.SetHotKeys(keys)
+		}
+		fmt.Fprintf(log, "Hot set: %d keys take %g%% of the operations on existing keys\n",
+			len(keys), 100*props.GetFloat64(workload.HotSetFractionProperty, 0))
+	}
+
+	if count := props.GetInt64(workload.PrefixCountProperty, 0); count > 0 {
+		fmt.Fprintf(log, "Keys under %d prefixes, %d each when loaded\n",
+			count, props.GetInt64(prop.RecordCount, 0)/count)
+	}
+	if props.GetFloat64(workload.PruneFractionProperty, 0) > 0 {
+		if props.GetBool(prop.DoTransactions, true) {
+			fmt.Fprintf(log, "Pruned: running on the keys from %d on\n", props.GetInt64(prop.InsertStart, 0))
+		} else {
+			fmt.Fprintf(log, "Pruning: deleting %d keys from %d on\n",
+				props.GetInt64(prop.InsertCount, 0), props.GetInt64(prop.InsertStart, 0))
+		}
+	}
+
+	// go-ycsb only warms up before transactions, never before a load
+	var warmUp time.Duration
+	if props.GetBool(prop.DoTransactions, true) {
+		warmUp = time.Duration(props.GetInt64(prop.WarmUpTime, 0)) * time.Second
+	}
+
+	// Pace requests outside of every measurement so waiting for a token is
+	// not counted as operation latency
+	period, on, postIdle, err := Bursts(props)
+	if err != nil {
+		return nil, err
+	}
+	rampThreads, rampStep, err := Ramp(props)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := SteadyState(props); err != nil {
+		return nil, err
+	}
+	if targetOps := props.GetFloat64(TargetOpsProperty, 0); targetOps > 0 || period > 0 || rampThreads != nil {
+		burst := props.GetInt(TargetOpsBurstProperty, 1)
+		throttled := godbdb.NewThrottledDB(measuredDB, targetOps, burst)
+		wrappedDB = throttled
+		if targetOps > 0 {
+			tracker.SetIntendedStart(godbdb.IntendedStart)
+			if trackerB != nil {
+				trackerB.SetIntendedStart(godbdb.IntendedStart)
+			}
+			fmt.Fprintf(log, "Target throughput: %.0f ops/sec (burst %d)\n", targetOps, burst)
+		}
+		if period > 0 {
+			throttled.SetBursts(period, on)
+			tracker.SetBursts(godbdb.BurstOffset, postIdle)
+			if trackerB != nil {
+				trackerB.SetBursts(godbdb.BurstOffset, postIdle)
+			}
+			fmt.Fprintf(log, "Bursts: %s of every %s, post-idle for the first %s\n", on, period, postIdle)
+		}
+		if rampThreads != nil {
+			throttled.SetRamp(rampThreads, rampStep, warmUp)
+			tracker.SetRamp(godbdb.RampStep, rampThreads, rampStep)
+			if trackerB != nil {
+				trackerB.SetRamp(godbdb.RampStep, rampThreads, rampStep)
+			}
+			fmt.Fprintf(log, "Ramping up through %s threads, %s each\n", joinInts(rampThreads), rampStep)
+		}
+	}
+
+	// Recording is outermost, so it captures operations as the client issues
+	// them and costs none of their measured latency
+	var recorder *workload.TraceWriter
+	if path := props.GetString(RecordTraceProperty, ""); path != "" {
+		if recorder, err = workload.CreateTrace(path); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if recorder != nil {
+				recorder.Close()
+			}
+		}()
+		wrappedDB = godbdb.NewRecordingDB(wrappedDB, recorder)
+		fmt.Fprintf(log, "Recording operations to %s\n", path)
+	}
+
+	runDuration, err := RunDuration(props)
+	if err != nil {
+		return nil, err
+	}
+	if precisionTarget > 0 && runDuration == 0 {
+		runDuration = metrics.DefaultPrecisionLimit
+	}
+
+	progressInterval, err := ProgressInterval(props)
+	if err != nil {
+		return nil, err
+	}
+
+	// Progress lines and the dashboard replace go-ycsb's periodic summaries
+	clientProps := cloneProperties(props)
+	clientProps.Set(prop.LogInterval, strconv.Itoa(math.MaxInt32))
+	if runDuration > 0 {
+		// The workload keeps the configured operationcount (it sizes the key
+		// space from it); only the client is told to run until cancelled.
+		clientProps.Set(prop.OperationCount, strconv.FormatInt(math.MaxInt64/2, 10))
+
+		// The deadline covers the warm-up plus the requested measured window
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, warmUp+runDuration)
+		defer cancel()
+	}
+	_, grouped := db.(godbdb.BatchedDB)
+	if txnSize := props.GetInt64(workload.TxnSizeProperty, 1); txnSize > 1 && props.GetBool(prop.DoTransactions, true) {
+		// The client counts groups, the workload issues txnSize operations each
+		if runDuration == 0 {
+			ops := props.GetInt64(prop.OperationCount, 0)
+			clientProps.Set(prop.OperationCount, strconv.FormatInt((ops+txnSize-1)/txnSize, 10))
+		}
+		fmt.Fprintf(log, "Grouping every %d operations into one batch\n", txnSize)
+		if !grouped {
+			fmt.Fprintf(log, "Warning: %s cannot group operations: issuing them one at a time\n", dbName)
+		}
+	}
+	if traceDone != nil {
+		// The trace decides how many operations there are, warm-up included
+		clientProps.Set(prop.OperationCount, strconv.FormatInt(math.MaxInt64/2, 10))
+		clientProps.Set(prop.InsertCount, strconv.FormatInt(math.MaxInt64/2, 10))
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-traceDone:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	c := client.NewClient(clientProps, wl, wrappedDB)
+
+	if precisionTarget > 0 {
+		fmt.Fprintf(log, "Running workload until the %s of %s is within ±%g%% (at most %s)...\n",
+			precisionStat, precisionOp, 100*precisionTarget, runDuration)
+	} else if runDuration > 0 {
+		fmt.Fprintf(log, "Running workload for %s...\n", runDuration)
+	} else {
+		fmt.Fprintln(log, "Running workload...")
+	}
+
+	var after func()
+	if r.config.BeforeRun != nil {
+		after = r.config.BeforeRun(warmUp)
+	}
+
+	// Write amplification covers the measured window only, so the counters
+	// are read once the warm-up is over
+	writes := startWriteCounting(db, warmUp)
+	cache := startCacheCounting(db, warmUp)
+	ioCalls := startIOCounting(db, warmUp)
+	stopPerf := startPerfCounting(props, warmUp, log)
+	writesB := func() *metrics.WriteAmplification { return nil }
+	ioCallsB := func() []metrics.IOCallStatistics { return nil }
+	if dbB != nil {
+		writesB = startWriteCounting(dbB, warmUp)
+		ioCallsB = startIOCounting(dbB, warmUp)
+	}
+
+	// Live views cover both sides of an interleaved run
+	watch := tracker.Watch
+	if trackerB != nil {
+		watch = func() *metrics.LiveWindow {
+			w := tracker.Watch()
+			trackerB.Share(w)
+			return w
+		}
+	}
+
+	statsd, err := startStatsd(dbName, props, watch)
+	if err != nil {
+		return nil, err
+	}
+	if statsd != nil {
+		fmt.Fprintf(log, "Sending live metrics to statsd at %s\n", props.GetString(StatsdAddressProperty, ""))
+	}
+
+	var dashboard *metrics.Dashboard
+	var progress *metrics.Progress
+	if r.config.Dashboard != nil {
+		dashboard = metrics.NewDashboard(r.config.Dashboard, dbName+" "+props.GetString(WorkloadFileProperty, workloadName),
+			watch(), engineActivity(db), metrics.DefaultDashboardInterval, warmUp)
+		dashboard.Start()
+	} else if progressInterval > 0 {
+		phase, total := progressTotal(props, grouped)
+		progress = metrics.NewProgress(log, watch(), progressInterval, phase, total, warmUp, runDuration)
+		progress.Start()
+	}
+
+	tracker.StartResourceMonitor(resourceInterval)
+	tracker.StartEngineMonitor(resourceInterval, engineActivity(db))
+	var precision func() *metrics.Precision
+	if precisionTarget > 0 {
+		ctx, precision = watchPrecision(ctx, tracker, precisionTarget, precisionOp, precisionStat, warmUp, log)
+	}
+	var aborted func() *metrics.ErrorRateAbort
+	if maxErrorRate > 0 {
+		ctx, aborted = watchErrorRate(ctx, watch(), maxErrorRate, log)
+	}
+	start := time.Now()
+	c.Run(ctx)
+	end := time.Now()
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			fmt.Fprintf(log, "Warning: %v\n", err)
+		}
+		recorder = nil
+	}
+	perfCounters := stopPerf()
+	resources := tracker.StopResourceMonitor()
+	engine := tracker.StopEngineMonitor()
+	if statsd != nil {
+		statsd.Stop()
+	}
+	if dashboard != nil {
+		dashboard.Stop()
+	}
+	if progress != nil {
+		progress.Stop()
+	}
+
+	if after != nil {
+		after()
+	}
+
+	fmt.Fprintln(log, "Workload completed. Generating metrics...")
+
+	var reached *metrics.Precision
+	if precision != nil {
+		reached = precision()
+	}
+	var abort *metrics.ErrorRateAbort
+	if aborted != nil {
+		abort = aborted()
+	}
+
+	result := &Result{
+		ID:          metrics.NewRunID(),
+		DB:          dbName,
+		Properties:  props,
+		Environment: env,
+		Start:       start,
+		End:         end,
+		Operations:  metrics.CollectMetrics(tracker),
+		Streaming:   tracker.StreamStatistics(),
+		Series:      tracker.Series(),
+		HotSet:      tracker.HotSetStatistics(),
+		Ramp:        tracker.RampStatistics(),
+		Bursts:      tracker.BurstStatistics(),
+		ScanLengths: tracker.ScanLengthStatistics(),
+		Resources:   resources,
+		Engine:      engine,
+		WriteAmp:    writes(),
+		BlockCache:  cache(),
+		IOCalls:     ioCalls(),
+		Disk:        baseline,
+		Compaction:  compaction,
+		Precision:   reached,
+		Aborted:     abort,
+		Retries:     retryStatistics(retrying),
+		Tracker:     tracker,
+	}
+	result.Layout, result.Capabilities = describeBackend(dbName, props)
+	result.Perf = perfCounters(result.Operations)
+
+	if result.Verification, err = verify(wl, db, log); err != nil {
+		return nil, err
+	}
+
+	// The table comes from the tracker's own histograms; go-ycsb's output is
+	// only needed for the raw and percentile exports it writes to files
+	if props.GetString(prop.MeasurementRawOutputFile, "") != "" ||
+		props.GetBool(prop.MeasurementHistogramPercentileExport, false) {
+		measurement.Output()
+	}
+
+	analyze(result, props, db)
+
+	if props.GetBool(CrashProperty, false) {
+		if result.Crash, err = crash(dbName, dbCreator, props, wl, db, datadir, log); err != nil {
+			return nil, err
+		}
+	}
+
+	if times := props.GetInt(ReopenProperty, 0); times > 0 {
+		if db, result.Reopens, err = reopen(dbName, dbCreator, props, db, times, datadir, log); err != nil {
+			return nil, err
+		}
+	}
+
+	if interleave != nil {
+		result.B = &Result{
+			ID:          metrics.NewRunID(),
+			DB:          interleave.DB,
+			Properties:  propsB,
+			Environment: env,
+			Start:       start,
+			End:         end,
+			Operations:  metrics.CollectMetrics(trackerB),
+			Streaming:   trackerB.StreamStatistics(),
+			Series:      trackerB.Series(),
+			HotSet:      trackerB.HotSetStatistics(),
+			Ramp:        trackerB.RampStatistics(),
+			Bursts:      trackerB.BurstStatistics(),
+			ScanLengths: trackerB.ScanLengthStatistics(),
+			WriteAmp:    writesB(),
+			IOCalls:     ioCallsB(),
+			Retries:     retryStatistics(retryingB),
+			Tracker:     trackerB,
+		}
+		result.B.Layout, result.B.Capabilities = describeBackend(interleave.DB, propsB)
+		analyze(result.B, props, dbB)
+
+		var chunks []metrics.InterleavedChunk
+		for _, c := range interleaved.Chunks() {
+			chunks = append(chunks, metrics.InterleavedChunk{
+				Index:   c.Index,
+				Ops:     c.Ops,
+				Errors:  c.Errors,
+				Latency: c.Latency,
+				Start:   c.Start,
+				End:     c.End,
+			})
+		}
+		result.Interleaved = metrics.CompareInterleaved("a", "b", chunk, chunks)
+	}
+
+	return result, nil
+}
+
+// describeBackend returns how the named backend stores the records of a run
+// with props, and its capabilities if it registered them
+func describeBackend(dbName string, props *properties.Properties) (*metrics.StorageLayout, *metrics.Capabilities) {
+	c, ok := godbdb.BackendCapabilities(dbName, props)
+	layout := &metrics.StorageLayout{KeyEncoding: c.KeyEncoding, MaxValueBytes: c.MaxValueSize}
+	layout.ValueBytes = godbdb.ValueSize(props)
+	if !ok {
+		return layout, nil
+	}
+	return layout, &metrics.Capabilities{
+		Scan:         c.Scan,
+		PrefixScan:   c.PrefixScan,
+		PartialRead:  c.PartialRead,
+		Sync:         c.Sync,
+		Transactions: c.Transactions,
+		Batching:     c.Batching,
+	}
+}
+
+// verify reads back the keys wl wrote and returns the anomalies found over
+// the run, or nil if wl does not verify its values
+func verify(wl ycsb.Workload, db ycsb.DB, log io.Writer) (*metrics.Verification, error) {
+	a, ok, err := workload.Verify(context.Background(), wl, db)
+	if !ok {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read back the keys written: %w", err)
+	}
+	v := &metrics.Verification{
+		Reads:    a.Reads,
+		Writes:   a.Writes,
+		Keys:     a.Keys,
+		Corrupt:  a.Corrupt,
+		Stale:    a.Stale,
+		Missing:  a.Missing,
+		Lost:     a.Lost,
+		Examples: a.Examples,
+	}
+	if n := v.Anomalies(); n > 0 {
+		fmt.Fprintf(log, "Warning: verification found %d anomalies\n", n)
+	}
+	return v, nil
+}
+
+// measureDisk measures the raw performance of the device holding datadir,
+// which the backend may not have created yet
+func measureDisk(props *properties.Properties, datadir string, log io.Writer) (*metrics.DiskBaseline, error) {
+	cfg := disk.Config{FileSize: props.GetInt64(DiskBaselineSizeProperty, disk.DefaultFileSize)}
+	var err error
+	if cfg.Duration, err = DiskBaselineDuration(props); err != nil {
+		return nil, err
+	}
+	if cfg.Dir, err = disk.ExistingDir(datadir); err != nil {
+		return nil, fmt.Errorf("disk baseline: %w", err)
+	}
+	fmt.Fprintf(log, "Measuring the disk baseline in %s...\n", cfg.Dir)
+	baseline, err := disk.Measure(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("disk baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// compact fully compacts db, or warns if it cannot be compacted
+func compact(ctx context.Context, dbName string, db ycsb.DB, log io.Writer) (*metrics.Compaction, error) {
+	c, ok := db.(godbdb.Compactor)
+	if !ok {
+		fmt.Fprintf(log, "Warning: %s cannot be compacted: running without compacting\n", dbName)
+		return nil, nil
+	}
+	fmt.Fprintln(log, "Compacting...")
+	if err := c.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush: %w", err)
+	}
+	before := c.DiskUsage()
+	start := time.Now()
+	if err := c.Compact(ctx); err != nil {
+		return nil, fmt.Errorf("failed to compact: %w", err)
+	}
+	compaction := metrics.NewCompaction(time.Since(start), before, c.DiskUsage())
+	fmt.Fprintf(log, "Compaction %s\n", compaction)
+	return compaction, nil
+}
+
+// reopen closes db and opens it again, times times, measuring both, and
+// returns the instance open at the end. A failed open leaves a closed DB.
+func reopen(dbName string, creator ycsb.DBCreator, props *properties.Properties, db ycsb.DB, times int, datadir string, log io.Writer) (ycsb.DB, []metrics.Reopen, error) {
+	// Whatever the run asked for, the data written must be kept
+	props = cloneProperties(props)
+	props.Set(dbName+".use_existing", "true")
+
+	fmt.Fprintf(log, "Reopening %s %d times...\n", dbName, times)
+	var reopens []metrics.Reopen
+	for i := 0; i < times; i++ {
+		start := time.Now()
+		if err := db.Close(); err != nil {
+			return closedDB{}, nil, fmt.Errorf("failed to close DB: %w", err)
+		}
+		closed := time.Since(start)
+		size := DirSize(datadir)
+
+		start = time.Now()
+		var err error
+		if db, err = creator.Create(props); err != nil {
+			return closedDB{}, nil, fmt.Errorf("failed to reopen DB: %w", err)
+		}
+		reopens = append(reopens, metrics.Reopen{
+			Bytes: size,
+			Close: float64(closed.Microseconds()),
+			Open:  float64(time.Since(start).Microseconds()),
+		})
+	}
+	return db, reopens, nil
+}
+
+// crash takes a crash image of db next to datadir, opens it as the backend
+// would after a crash and reads back what wl acknowledged, then removes it
+func crash(dbName string, creator ycsb.DBCreator, props *properties.Properties, wl ycsb.Workload, db ycsb.DB, datadir string, log io.Writer) (*metrics.CrashRecovery, error) {
+	imager, ok := db.(godbdb.CrashImager)
+	if !ok {
+		fmt.Fprintf(log, "Warning: %s cannot take a crash image: running without crash recovery\n", dbName)
+		return nil, nil
+	}
+	dir := filepath.Clean(datadir) + "-crash"
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to remove old crash image: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Fprintf(log, "Taking a crash image of %s in %s...\n", dbName, dir)
+	if err := imager.CrashImage(dir); err != nil {
+		return nil, fmt.Errorf("failed to take crash image: %w", err)
+	}
+	c := &metrics.CrashRecovery{Bytes: DirSize(dir)}
+
+	props = cloneProperties(props)
+	props.Set("datadir", dir)
+	props.Set(dbName+".use_existing", "true")
+	start := time.Now()
+	recovered, err := creator.Create(props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover crash image: %w", err)
+	}
+	defer recovered.Close()
+	c.Open = float64(time.Since(start).Microseconds())
+
+	a, ok, err := workload.VerifyRecovered(context.Background(), wl, recovered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read back the crash image: %w", err)
+	}
+	if ok {
+		c.Checked = true
+		c.Writes, c.Keys, c.Corrupt, c.Lost, c.Examples = a.Writes, a.Keys, a.Corrupt, a.Lost, a.Examples
+		if c.Lost > 0 || c.Corrupt > 0 {
+			fmt.Fprintf(log, "Warning: the crash lost %d writes and corrupted %d values\n", c.Lost, c.Corrupt)
+		}
+	}
+	return c, nil
+}
+
+// closedDB stands in for a DB that failed to close or reopen, so closing it
+// again is harmless
+type closedDB struct {
+	ycsb.DB
+}
+
+func (closedDB) Close() error {
+	return nil
+}
+
+// DirSize returns the bytes of the files under dir, or what could be read
+// of them
+func DirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// newTracker wraps db in a tracker set up as props asks
+func newTracker(db ycsb.DB, props *properties.Properties, retain bool) (*metrics.OperationTracker, error) {
+	tracker := metrics.NewOperationTracker(db, retain)
+	tracker.SetErrorClassifier(godbdb.ErrorClass)
+	tracker.SetExcludeErrors(props.GetBool(ExcludeErrorsProperty, false))
+	seriesInterval, err := SeriesInterval(props)
+	if err != nil {
+		return nil, err
+	}
+	tracker.SetSeriesInterval(seriesInterval)
+	bootstrap, err := BootstrapOf(props)
+	if err != nil {
+		return nil, err
+	}
+	tracker.SetBootstrap(bootstrap)
+	if size := props.GetInt(ReservoirProperty, 0); retain && size > 0 {
+		tracker.SetReservoir(size)
+	}
+	return tracker, nil
+}
+
+// BootstrapOf returns the bootstrap settings of props, which a run's tracker
+// and any samples merged from runs compute their intervals with. The
+// workload's seed, if any, seeds the resampling too.
+func BootstrapOf(props *properties.Properties) (metrics.Bootstrap, error) {
+	b := metrics.Bootstrap{
+		Resamples: props.GetInt(ResamplesProperty, metrics.DefaultBootstrapResamples),
+		Level:     props.GetFloat64(ConfidenceLevelProperty, metrics.DefaultConfidenceLevel),
+	}
+	if _, ok := props.Get(workload.SeedProperty); ok {
+		b.Seed, b.Seeded = props.GetInt64(workload.SeedProperty, 0), true
+	}
+	return b, b.Validate()
+}
+
+// analyze adds the statistics props asks for, computed from the samples of
+// result's tracker, and the internal metrics of db to result
+func analyze(result *Result, props *properties.Properties, db ycsb.DB) {
+	tracker := result.Tracker
+	if props.GetBool(StatisticsProperty, false) {
+		result.Statistics = tracker.ComputeStatistics()
+	}
+	if props.GetBool(PerThreadProperty, false) {
+		result.Threads = tracker.ThreadStatistics()
+	}
+	if step := props.GetFloat64(DistributionProperty, 0); step > 0 {
+		result.Distributions = tracker.Distributions(step)
+	}
+	if window, threshold, err := SteadyState(props); err == nil && threshold > 0 {
+		result.SteadyState = tracker.SteadyState(result.Series, result.Operations, window, threshold)
+	}
+
+	// Backends such as PebbleDB report their own internal metrics
+	type metricsProvider interface {
+		Metrics() fmt.Stringer
+	}
+	if pdb, ok := db.(metricsProvider); ok {
+		result.DBMetrics = pdb.Metrics().String()
+	}
+	result.LSM = lsmLevels(db)
+}
+
+// lsmLevels returns the levels of db's LSM tree now, or nil for backends
+// that do not report them
+func lsmLevels(db ycsb.DB) []metrics.LSMLevel {
+	reporter, ok := db.(godbdb.LSMReporter)
+	if !ok {
+		return nil
+	}
+	var levels []metrics.LSMLevel
+	for _, l := range reporter.LSMLevels() {
+		levels = append(levels, metrics.LSMLevel{
+			Level:         l.Level,
+			Sublevels:     l.Sublevels,
+			Files:         l.Files,
+			Size:          l.Size,
+			Score:         l.Score,
+			BytesIn:       l.BytesIn,
+			BytesIngested: l.BytesIngested,
+			BytesRead:     l.BytesRead,
+			BytesWritten:  l.BytesWritten,
+		})
+	}
+	return levels
+}
+
+// ConfigureMetrics applies the table percentiles of props to the metrics
+// package, and checks the bootstrap settings and distribution step. Run does
+// it for every run; programs reporting results of their own, such as merged
+// ones, call it first.
+func ConfigureMetrics(props *properties.Properties) error {
+	if _, err := BootstrapOf(props); err != nil {
+		return err
+	}
+	if step := props.GetFloat64(DistributionProperty, 0); step != 0 {
+		if err := metrics.ValidateDistributionStep(step); err != nil {
+			return err
+		}
+	}
+	percentiles, err := Percentiles(props)
+	if err != nil {
+		return err
+	}
+	return metrics.SetPercentiles(percentiles)
+}
+
+// afterWarmUp calls f once warmUp has elapsed. The function it returns
+// waits for f to finish and reports whether it ran, which it has not if the
+// run ended during the warm-up.
+func afterWarmUp(warmUp time.Duration, f func()) func() bool {
+	done := make(chan struct{})
+	timer := time.AfterFunc(warmUp, func() {
+		f()
+		close(done)
+	})
+	return func() bool {
+		if timer.Stop() {
+			return false
+		}
+		<-done
+		return true
+	}
+}
+
+// startWriteCounting reads the write counters of db, if it has any, once
+// warmUp has elapsed. The function it returns reads them again and returns
+// the write amplification in between, or nil for backends without counters.
+func startWriteCounting(db ycsb.DB, warmUp time.Duration) func() *metrics.WriteAmplification {
+	counter, ok := db.(godbdb.WriteCounter)
+	if !ok {
+		return func() *metrics.WriteAmplification { return nil }
+	}
+	var before godbdb.WriteCounters
+	measured := afterWarmUp(warmUp, func() { before = counter.WriteCounters() })
+	return func() *metrics.WriteAmplification {
+		after := counter.WriteCounters()
+		start := after // the run ended during the warm-up
+		if measured() {
+			start = before
+		}
+		return metrics.NewWriteAmplification(
+			after.UserBytes-start.UserBytes,
+			after.WALBytes-start.WALBytes,
+			after.FlushedBytes-start.FlushedBytes,
+			after.CompactedBytes-start.CompactedBytes)
+	}
+}
+
+// startCacheCounting takes the block cache counters of db once warmUp has
+// elapsed. The function it returns gives the hit rate since, or nil for
+// backends without a block cache.
+func startCacheCounting(db ycsb.DB, warmUp time.Duration) func() *metrics.BlockCache {
+	counter, ok := db.(godbdb.CacheCounter)
+	if !ok {
+		return func() *metrics.BlockCache { return nil }
+	}
+	var before godbdb.CacheCounters
+	measured := afterWarmUp(warmUp, func() { before = counter.CacheCounters() })
+	return func() *metrics.BlockCache {
+		after := counter.CacheCounters()
+		start := after // the run ended during the warm-up
+		if measured() {
+			start = before
+		}
+		return metrics.NewBlockCache(after.Hits-start.Hits, after.Misses-start.Misses, after.Bytes)
+	}
+}
+
+// startIOCounting restarts the filesystem call counts of db, if it keeps
+// any, once warmUp has elapsed. The function it returns summarizes the calls
+// made since, or returns nil for backends that do not count them.
+func startIOCounting(db ycsb.DB, warmUp time.Duration) func() []metrics.IOCallStatistics {
+	counter, ok := db.(godbdb.IOCounter)
+	if !ok {
+		return func() []metrics.IOCallStatistics { return nil }
+	}
+	measured := afterWarmUp(warmUp, counter.ResetIOCalls)
+	return func() []metrics.IOCallStatistics {
+		if !measured() {
+			return nil
+		}
+		var stats []metrics.IOCallStatistics
+		for _, c := range counter.IOCalls() {
+			stats = append(stats, metrics.NewIOCallStatistics(c.Name, c.Count, c.Bytes, c.Latency))
+		}
+		return stats
+	}
+}
+
+// startPerfCounting starts counting hardware events once warmUp has elapsed,
+// if PerfCountersProperty is set. The function it returns stops counting; the
+// one that returns gives the counts over the operations of rows, or nil where
+// they could not be counted. Warnings go to log.
+func startPerfCounting(props *properties.Properties, warmUp time.Duration, log io.Writer) func() func(rows []metrics.OperationMetrics) *metrics.PerfCounters {
+	none := func([]metrics.OperationMetrics) *metrics.PerfCounters { return nil }
+	if !props.GetBool(PerfCountersProperty, false) {
+		return func() func([]metrics.OperationMetrics) *metrics.PerfCounters { return none }
+	}
+	phase := metrics.PhaseRun
+	if !props.GetBool(prop.DoTransactions, true) {
+		phase = metrics.PhaseLoad
+	}
+
+	var counters *perf.Counters
+	var startErr error
+	measured := afterWarmUp(warmUp, func() { counters, startErr = perf.Start() })
+	return func() func([]metrics.OperationMetrics) *metrics.PerfCounters {
+		if !measured() {
+			return none
+		}
+		if startErr != nil {
+			fmt.Fprintf(log, "Warning: hardware counters unavailable: %v\n", startErr)
+			return none
+		}
+		values, err := counters.Stop()
+		if err != nil {
+			fmt.Fprintf(log, "Warning: failed to read hardware counters: %v\n", err)
+		}
+		return func(rows []metrics.OperationMetrics) *metrics.PerfCounters {
+			var ops int64
+			if total, ok := metrics.FindMetrics(rows, "TOTAL"); ok {
+				ops = total.Count + total.Errors
+			}
+			return metrics.NewPerfCounters(phase, values.UserOnly, values.Counts, ops)
+		}
+	}
+}
+
+// progressTotal returns the phase of the run and the operations go-ycsb ends
+// it after: operationcount for transactions, insertcount or recordcount for
+// a load. Batches count once, as the tracker sees them, and so do groups of
+// txnsize operations if the backend commits them grouped.
+func progressTotal(props *properties.Properties, grouped bool) (string, int64) {
+	phase, total := metrics.PhaseRun, props.GetInt64(prop.OperationCount, 0)
+	if !props.GetBool(prop.DoTransactions, true) {
+		phase = metrics.PhaseLoad
+		total = props.GetInt64(prop.InsertCount, props.GetInt64(prop.RecordCount, 0))
+	}
+	if batch := props.GetInt64(prop.BatchSize, 1); batch > 1 {
+		total = (total + batch - 1) / batch
+	}
+	if txn := props.GetInt64(workload.TxnSizeProperty, 1); grouped && txn > 1 && phase == metrics.PhaseRun {
+		total = (total + txn - 1) / txn
+	}
+	return phase, total
+}
+
+// engineActivity returns a function reading the background activity of db,
+// or nil for backends that do not report it
+func engineActivity(db ycsb.DB) func() metrics.EngineActivity {
+	reporter, ok := db.(godbdb.EngineReporter)
+	if !ok {
+		return nil
+	}
+	return func() metrics.EngineActivity {
+		a := reporter.EngineActivity()
+		return metrics.EngineActivity{
+			Flushes:               a.Flushes,
+			FlushesInProgress:     a.FlushesInProgress,
+			Compactions:           a.Compactions,
+			CompactionsInProgress: a.CompactionsInProgress,
+			CompactionDebt:        a.CompactionDebt,
+			L0Files:               a.L0Files,
+			L0Sublevels:           a.L0Sublevels,
+			L0StopWrites:          a.L0StopWrites,
+			ReadAmp:               a.ReadAmp,
+			MemtableBytes:         a.MemtableBytes,
+			WriteStalls:           a.WriteStalls,
+			WriteStallTime:        a.WriteStallTime,
+			L0WriteStalls:         a.L0WriteStalls,
+			L0WriteStallTime:      a.L0WriteStallTime,
+		}
+	}
+}
+
+// startStatsd starts sending the live metrics of a window from watch to the
+// statsd server set by StatsdAddressProperty, if any
+func startStatsd(dbName string, props *properties.Properties, watch func() *metrics.LiveWindow) (*metrics.StatsdEmitter, error) {
+	address := props.GetString(StatsdAddressProperty, "")
+	if address == "" {
+		return nil, nil
+	}
+	interval, err := StatsdInterval(props)
+	if err != nil {
+		return nil, err
+	}
+	prefix := props.GetString(StatsdPrefixProperty, "godb_bench."+dbName)
+	emitter, err := metrics.NewStatsdEmitter(address, prefix, watch(), interval)
+	if err != nil {
+		return nil, err
+	}
+	emitter.Start()
+	return emitter, nil
+}
+
+// ApplyDefaults sets the DB name and the defaults every run relies on
+func ApplyDefaults(dbName string, props *properties.Properties) {
+	props.Set(prop.DB, dbName)
+
+	// Enable measurement output if not already set
+	if props.GetString(prop.MeasurementType, "") == "" {
+		props.Set(prop.MeasurementType, "histogram")
+	}
+
+	// Make sure we do transactions (not just load) unless a load phase was requested
+	if props.GetString(prop.DoTransactions, "") == "" {
+		props.Set(prop.DoTransactions, "true")
+	}
+
+	// Keys per prefix size the key space when given
+	if keys := props.GetInt64(workload.PrefixKeysProperty, 0); keys > 0 {
+		count := props.GetInt64(workload.PrefixCountProperty, 0)
+		props.Set(prop.RecordCount, strconv.FormatInt(count*keys, 10))
+	}
+
+	// Pruning deletes the first keys loaded, and runs after it go to the rest
+	if fraction := props.GetFloat64(workload.PruneFractionProperty, 0); fraction > 0 {
+		pruned := int64(fraction * float64(props.GetInt64(prop.RecordCount, prop.RecordCountDefault)))
+		key := prop.InsertStart
+		if !props.GetBool(prop.DoTransactions, true) {
+			key = prop.InsertCount
+		}
+		if props.GetString(key, "") == "" {
+			props.Set(key, strconv.FormatInt(pruned, 10))
+		}
+	}
+
+	// A ramp ends on its highest thread count, and lasts a step per count
+	if threads, step, err := Ramp(props); err == nil && threads != nil {
+		props.Set(prop.ThreadCount, strconv.Itoa(threads[len(threads)-1]))
+		if props.GetString(DurationProperty, "") == "" && props.GetString(prop.MaxExecutiontime, "") == "" {
+			props.Set(DurationProperty, (time.Duration(len(threads)) * step).String())
+		}
+	}
+
+	// Values fill a storage slot on every backend
+	if props.GetBool(godbdb.ValueParityProperty, false) {
+		props.Set(prop.FieldCount, "1")
+		props.Set(prop.FieldLength, strconv.Itoa(godbdb.SlotSize))
+		props.Set(prop.FieldLengthDistribution, "constant")
+	}
+
+	// A replayed trace replaces the workload's own operations
+	if path := props.GetString(ReplayTraceProperty, ""); path != "" {
+		props.Set(prop.Workload, workload.TraceWorkload)
+		props.Set(workload.TraceFileProperty, path)
+	}
+}
+
+// RunDuration returns the time bound for the run, if any. The duration
+// property takes precedence over go-ycsb's maxexecutiontime (in seconds).
+func RunDuration(props *properties.Properties) (time.Duration, error) {
+	if v := props.GetString(DurationProperty, ""); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %w", DurationProperty, v, err)
+		}
+		return d, nil
+	}
+	return time.Duration(props.GetInt64(prop.MaxExecutiontime, 0)) * time.Second, nil
+}
+
+// SeriesInterval returns the width of the time series intervals set by
+// SeriesIntervalProperty, or the default; 0 disables the series
+func SeriesInterval(props *properties.Properties) (time.Duration, error) {
+	return intervalProperty(props, SeriesIntervalProperty, metrics.DefaultSeriesInterval)
+}
+
+// TargetPrecision returns the relative precision target of the run, 0 for
+// none, and the operation and statistic it is set on
+func TargetPrecision(props *properties.Properties) (target float64, op, stat string, err error) {
+	target = props.GetFloat64(TargetPrecisionProperty, 0)
+	if target < 0 || target >= 1 {
+		return 0, "", "", fmt.Errorf("invalid %s %v: expected a fraction such as 0.02", TargetPrecisionProperty, target)
+	}
+	op = props.GetString(TargetPrecisionOpProperty, "TOTAL")
+	stat = props.GetString(TargetPrecisionStatProperty, metrics.PrecisionMean)
+	if err := metrics.ValidatePrecisionStatistic(stat); err != nil {
+		return 0, "", "", fmt.Errorf("invalid %s: %w", TargetPrecisionStatProperty, err)
+	}
+	return target, op, stat, nil
+}
+
+// watchPrecision checks the precision of the run every second once warmUp
+// has elapsed and cancels the returned context when it reaches target. The
+// function it returns stops watching and returns the precision the run
+// stopped at.
+func watchPrecision(ctx context.Context, tracker *metrics.OperationTracker, target float64, op, stat string, warmUp time.Duration, log io.Writer) (context.Context, func() *metrics.Precision) {
+	ctx, cancel := context.WithCancel(ctx)
+	measured := time.Now().Add(warmUp)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	var last *metrics.Precision
+	check := func() bool {
+		p, ok := tracker.Precision(op, stat, target, measured)
+		if !ok {
+			return false
+		}
+		p.Elapsed = max(time.Since(measured), 0).Seconds()
+		last = &p
+		return p.Reached
+	}
+
+	go func() {
+		defer close(done)
+		select {
+		case <-time.After(warmUp):
+		case <-stop:
+			return
+		}
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if check() {
+					fmt.Fprintf(log, "Precision target reached: %s\n", last)
+					cancel()
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return ctx, func() *metrics.Precision {
+		close(stop)
+		<-done
+		cancel()
+		if last == nil || !last.Reached {
+			// The run ended on its own; tell how far it got
+			check()
+		}
+		if last == nil {
+			last = &metrics.Precision{Operation: op, Statistic: stat, Target: target}
+		}
+		return last
+	}
+}
+
+// OpPolicy returns the timeout of every attempt of an operation, 0 for none,
+// how many times a failed one is retried and the wait before the first retry
+func OpPolicy(props *properties.Properties) (timeout time.Duration, retries int, backoff time.Duration, err error) {
+	if timeout, err = intervalProperty(props, OpTimeoutProperty, 0); err != nil {
+		return 0, 0, 0, err
+	}
+	retries = props.GetInt(OpRetriesProperty, 0)
+	if retries < 0 {
+		return 0, 0, 0, fmt.Errorf("invalid %s %d: expected 0 or more retries", OpRetriesProperty, retries)
+	}
+	if backoff, err = intervalProperty(props, OpRetryBackoffProperty, defaultRetryBackoff); err != nil {
+		return 0, 0, 0, err
+	}
+	return timeout, retries, backoff, nil
+}
+
+// formatTimeout names an operation timeout, 0 being none
+func formatTimeout(timeout time.Duration) string {
+	if timeout == 0 {
+		return "none"
+	}
+	return timeout.String()
+}
+
+// retryStatistics converts the counts of retrying, nil when there is no
+// retry policy
+func retryStatistics(retrying *godbdb.RetryingDB) []metrics.RetryStatistics {
+	if retrying == nil {
+		return nil
+	}
+	var stats []metrics.RetryStatistics
+	for _, c := range retrying.RetryCounts() {
+		stats = append(stats, metrics.RetryStatistics{
+			Operation: c.Op,
+			Retries:   c.Retries,
+			Timeouts:  c.Timeouts,
+			Recovered: c.Recovered,
+			Exhausted: c.Exhausted,
+		})
+	}
+	return stats
+}
+
+// MaxErrorRate returns the percentage of failed operations the run is
+// aborted beyond, 0 for none
+func MaxErrorRate(props *properties.Properties) (float64, error) {
+	limit := props.GetFloat64(MaxErrorRateProperty, 0)
+	if limit < 0 || limit >= 100 {
+		return 0, fmt.Errorf("invalid %s %v: expected a percentage such as 5", MaxErrorRateProperty, limit)
+	}
+	return limit, nil
+}
+
+// watchErrorRate reads window every second and cancels the returned context
+// once more than limit percent of the operations since the start have
+// failed. The function it returns stops watching and returns why the run was
+// aborted, or nil when it was not.
+func watchErrorRate(ctx context.Context, window *metrics.LiveWindow, limit float64, log io.Writer) (context.Context, func() *metrics.ErrorRateAbort) {
+	ctx, cancel := context.WithCancel(ctx)
+	start := time.Now()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	var abort *metrics.ErrorRateAbort
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		var errors, operations int64
+		var lastError string
+		for {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				// Operations cut short at the end of the run fail too;
+				// they say nothing about the database
+				return
+			}
+			snapshot := window.Take()
+			e, n := metrics.ErrorRate(snapshot)
+			errors, operations = errors+e, operations+n
+			if snapshot.LastError != "" {
+				lastError = snapshot.LastError
+			}
+			rate := 100 * float64(errors) / float64(max(operations, 1))
+			if operations >= metrics.MinErrorRateOperations && rate > limit {
+				abort = &metrics.ErrorRateAbort{
+					Limit:      limit,
+					Rate:       rate,
+					Operations: operations,
+					Errors:     errors,
+					Elapsed:    time.Since(start).Seconds(),
+					LastError:  lastError,
+				}
+				fmt.Fprintf(log, "Aborting: %s\n", abort)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return ctx, func() *metrics.ErrorRateAbort {
+		close(stop)
+		<-done
+		cancel()
+		return abort
+	}
+}
+
+// SteadyState returns the window, in series intervals, and the coefficient
+// of variation threshold of steady-state detection; a threshold of 0 means
+// no detection
+func SteadyState(props *properties.Properties) (window int, threshold float64, err error) {
+	window = props.GetInt(SteadyStateWindowProperty, metrics.DefaultSteadyStateWindow)
+	if window < 2 {
+		return 0, 0, fmt.Errorf("invalid %s %d: expected at least 2 intervals", SteadyStateWindowProperty, window)
+	}
+	threshold = props.GetFloat64(SteadyStateCVProperty, metrics.DefaultSteadyStateCV)
+	if threshold < 0 {
+		return 0, 0, fmt.Errorf("invalid %s %v: expected a non-negative fraction such as 0.1", SteadyStateCVProperty, threshold)
+	}
+	return window, threshold, nil
+}
+
+// Bursts returns the period and the length of the bursts set by
+// BurstPeriodProperty and BurstDutyCycleProperty, and how long into a burst
+// operations count as post-idle; a period of 0 means no bursts
+func Bursts(props *properties.Properties) (period, on, postIdle time.Duration, err error) {
+	period, err = intervalProperty(props, BurstPeriodProperty, 0)
+	if err != nil || period == 0 {
+		return 0, 0, 0, err
+	}
+	duty := props.GetFloat64(BurstDutyCycleProperty, 0.5)
+	if duty <= 0 || duty > 1 {
+		return 0, 0, 0, fmt.Errorf("invalid %s %v: expected a fraction above 0 and at most 1", BurstDutyCycleProperty, duty)
+	}
+	on = time.Duration(duty * float64(period))
+	if postIdle, err = intervalProperty(props, BurstPostIdleProperty, on/10); err != nil {
+		return 0, 0, 0, err
+	}
+	return period, on, postIdle, nil
+}
+
+// Ramp returns the thread counts of a concurrency ramp set by
+// RampThreadsProperty and how long each is held, or nil without a ramp
+func Ramp(props *properties.Properties) ([]int, time.Duration, error) {
+	v := props.GetString(RampThreadsProperty, "")
+	if v == "" {
+		return nil, 0, nil
+	}
+	var threads []int
+	for _, s := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n < 1 || (len(threads) > 0 && n <= threads[len(threads)-1]) {
+			return nil, 0, fmt.Errorf("invalid %s %q: expected increasing thread counts such as 1,2,4,8", RampThreadsProperty, v)
+		}
+		threads = append(threads, n)
+	}
+	step, err := intervalProperty(props, RampStepProperty, DefaultRampStep)
+	if err != nil {
+		return nil, 0, err
+	}
+	if step == 0 {
+		return nil, 0, fmt.Errorf("invalid %s %q: expected a duration above 0", RampStepProperty, props.GetString(RampStepProperty, ""))
+	}
+	return threads, step, nil
+}
+
+// joinInts formats ns as a comma-separated list
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// ResourceInterval returns how often resource usage is sampled, set by
+// ResourceIntervalProperty, or the default; 0 disables sampling
+func ResourceInterval(props *properties.Properties) (time.Duration, error) {
+	return intervalProperty(props, ResourceIntervalProperty, metrics.DefaultResourceInterval)
+}
+
+// ProgressInterval returns how often progress is printed, set by
+// ProgressIntervalProperty, go-ycsb's measurement.interval, or the default; 0
+// disables progress
+func ProgressInterval(props *properties.Properties) (time.Duration, error) {
+	def := metrics.DefaultProgressInterval
+	if _, ok := props.Get(prop.LogInterval); ok {
+		def = time.Duration(props.GetInt64(prop.LogInterval, 0)) * time.Second
+	}
+	return intervalProperty(props, ProgressIntervalProperty, def)
+}
+
+// DiskBaselineDuration returns how long each random IO and fsync test of the
+// disk baseline runs, set by DiskBaselineDurationProperty, or the default
+func DiskBaselineDuration(props *properties.Properties) (time.Duration, error) {
+	return intervalProperty(props, DiskBaselineDurationProperty, disk.DefaultDuration)
+}
+
+// StatsdInterval returns how often live metrics are sent to statsd, set by
+// StatsdIntervalProperty, or the default
+func StatsdInterval(props *properties.Properties) (time.Duration, error) {
+	d, err := intervalProperty(props, StatsdIntervalProperty, metrics.DefaultStatsdInterval)
+	if err == nil && d == 0 {
+		err = fmt.Errorf("invalid %s %q: must be positive", StatsdIntervalProperty, props.GetString(StatsdIntervalProperty, ""))
+	}
+	return d, err
+}
+
+// intervalProperty returns the non-negative duration property name, or def
+// when it is unset
+func intervalProperty(props *properties.Properties, name string, def time.Duration) (time.Duration, error) {
+	v := props.GetString(name, "")
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a duration such as 1s", name, v)
+	}
+	return d, nil
+}
+
+// Percentiles returns the percentile columns set by PercentilesProperty, or
+// the default ones
+func Percentiles(props *properties.Properties) ([]float64, error) {
+	v := props.GetString(PercentilesProperty, "")
+	if v == "" {
+		return metrics.DefaultPercentiles, nil
+	}
+	percentiles, err := metrics.ParsePercentiles(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", PercentilesProperty, v, err)
+	}
+	return percentiles, nil
+}
+
+// cloneProperties returns an independent copy of props
+func cloneProperties(props *properties.Properties) *properties.Properties {
+	c := properties.NewProperties()
+	c.Merge(props)
+	return c
+}
@@ -0,0 +1,90 @@
+// Package cluster runs one workload on several machines at once: workers
+// serve benchmark runs over gRPC, and a coordinator dispatches the same
+// workload to all of them and merges their histograms and samples, to offer
+// more load than one client machine can
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// DefaultPort is the port workers listen on unless told otherwise
+const DefaultPort = 7070
+
+// maxMessageSize bounds the responses workers send, which carry every
+// sample of the run when the coordinator keeps samples
+const maxMessageSize = 1 << 30
+
+// RunRequest asks a worker to run a workload
+type RunRequest struct {
+	DB          string            `json:"db"`
+	Properties  map[string]string `json:"properties"`
+	KeepSamples bool              `json:"keep_samples"` // send every sample back, for plots and statistics
+
+	// StartAt is when the workload starts, so that all workers start
+	// together; zero starts it at once
+	StartAt time.Time `json:"start_at,omitzero"`
+}
+
+// RunResponse is the outcome of a worker's run
+type RunResponse struct {
+	Host       string                    `json:"host"`
+	Results    metrics.Results           `json:"results"`
+	Start      time.Time                 `json:"start"`
+	End        time.Time                 `json:"end"`
+	Collector  metrics.CollectorSnapshot `json:"collector"`
+	DBMetrics  string                    `json:"db_metrics,omitempty"`
+	SamplesAt  time.Time                 `json:"samples_at"`        // when the worker began tracking; sample times are relative to it
+	SampleData []byte                    `json:"samples,omitempty"` // every sample in the samples file format, if requested
+}
+
+// workerService is the gRPC service workers serve
+type workerService interface {
+	Run(ctx context.Context, req *RunRequest) (*RunResponse, error)
+}
+
+// workerServiceDesc describes the service without generated protobuf code:
+// messages are the JSON encoding of the structs above
+var workerServiceDesc = grpc.ServiceDesc{
+	ServiceName: "godbbench.Worker",
+	HandlerType: (*workerService)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Run",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(RunRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return srv.(workerService).Run(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: runMethod}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return srv.(workerService).Run(ctx, req.(*RunRequest))
+			})
+		},
+	}},
+	Metadata: "godb-bench",
+}
+
+// runMethod is the full name of the Run method
+const runMethod = "/godbbench.Worker/Run"
+
+// jsonCodec encodes messages as JSON. Workers and coordinators select it
+// through the content subtype, leaving the default protobuf codec alone.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                       { return "json" }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
@@ -0,0 +1,190 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/workload"
+)
+
+// DefaultStartDelay is how long after dispatching a workload the workers
+// start it, enough for each to open its backend
+const DefaultStartDelay = 2 * time.Second
+
+// Coordinator dispatches one workload to several workers and waits for all
+// of them
+type Coordinator struct {
+	Workers []string // host:port of every worker; the port defaults to DefaultPort
+
+	// StartDelay is how long after dispatching the workers start the
+	// workload together
+	StartDelay time.Duration
+
+	// Partition splits the load's key range, operationcount and target_ops
+	// across the workers, for a backend they share. Otherwise every worker
+	// runs the whole workload against its own backend.
+	Partition bool
+}
+
+// WorkerAddress adds the default port to a worker address without one
+func WorkerAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(address, strconv.Itoa(DefaultPort))
+}
+
+// Properties returns the properties the worker at index runs with
+func (c *Coordinator) Properties(props *properties.Properties, index int) *properties.Properties {
+	p := properties.NewProperties()
+	p.Merge(props)
+	if !c.Partition || len(c.Workers) < 2 {
+		return p
+	}
+	count := int64(len(c.Workers))
+
+	// share returns the worker's part of total: where it starts and its size
+	share := func(total int64) (int64, int64) {
+		n, extra := total/count, total%count
+		start := int64(index)*n + min(int64(index), extra)
+		if int64(index) < extra {
+			n++
+		}
+		return start, n
+	}
+
+	if p.GetBool(prop.DoTransactions, true) {
+		_, n := share(p.GetInt64(prop.OperationCount, 0))
+		p.Set(prop.OperationCount, strconv.FormatInt(n, 10))
+	} else {
+		insertStart := p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+		insertCount := p.GetInt64(prop.InsertCount, p.GetInt64(prop.RecordCount, 0)-insertStart)
+		start, n := share(insertCount)
+		p.Set(prop.InsertStart, strconv.FormatInt(insertStart+start, 10))
+		p.Set(prop.InsertCount, strconv.FormatInt(n, 10))
+	}
+	if targetOps := p.GetFloat64(bench.TargetOpsProperty, 0); targetOps > 0 {
+		p.Set(bench.TargetOpsProperty, strconv.FormatFloat(targetOps/float64(count), 'f', -1, 64))
+	}
+	// Workers sharing a backend must not all issue the same stream
+	if _, ok := p.Get(workload.SeedProperty); ok {
+		p.Set(workload.SeedProperty, strconv.FormatInt(p.GetInt64(workload.SeedProperty, 0)+int64(index), 10))
+	}
+	return p
+}
+
+// Run dispatches the workload to every worker and returns their outcomes in
+// the order of Workers. A worker that fails cancels the others.
+func (c *Coordinator) Run(ctx context.Context, dbName string, props *properties.Properties, keepSamples bool) ([]*RunResponse, error) {
+	if len(c.Workers) == 0 {
+		return nil, fmt.Errorf("no workers given")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	startAt := time.Now().Add(c.StartDelay)
+	responses := make([]*RunResponse, len(c.Workers))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error // the others are cancellations it caused
+	)
+	for i, address := range c.Workers {
+		req := &RunRequest{
+			DB:          dbName,
+			Properties:  c.Properties(props, i).Map(),
+			KeepSamples: keepSamples,
+			StartAt:     startAt,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := dispatch(ctx, WorkerAddress(address), req)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("worker %s: %w", address, err)
+				}
+				mu.Unlock()
+				cancel()
+				return
+			}
+			responses[i] = resp
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return responses, nil
+}
+
+// dispatch runs req on the worker at address
+func dispatch(ctx context.Context, address string, req *RunRequest) (*RunResponse, error) {
+	conn, err := grpc.DialContext(ctx, address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.CallContentSubtype(jsonCodec{}.Name()),
+			grpc.MaxCallRecvMsgSize(maxMessageSize),
+		))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	resp := new(RunResponse)
+	if err := conn.Invoke(ctx, runMethod, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Merged is the outcome of a distributed run as a whole
+type Merged struct {
+	Collector  *metrics.Collector      // the histograms and failures of every worker
+	Samples    *metrics.BenchmarkPlots // the samples of every worker, nil unless kept
+	Start, End time.Time               // when the first worker started and the last finished
+}
+
+// Merge combines the outcomes of every worker
+func Merge(responses []*RunResponse) (*Merged, error) {
+	merged := &Merged{Collector: metrics.NewCollector()}
+	var parts []*metrics.BenchmarkPlots
+	var starts []time.Time
+	for i, resp := range responses {
+		if err := merged.Collector.Merge(resp.Collector); err != nil {
+			return nil, fmt.Errorf("worker %s: %w", resp.Host, err)
+		}
+		if i == 0 || resp.Start.Before(merged.Start) {
+			merged.Start = resp.Start
+		}
+		if resp.End.After(merged.End) {
+			merged.End = resp.End
+		}
+		if resp.SampleData != nil {
+			part, err := metrics.DecodeSamples(bytes.NewReader(resp.SampleData))
+			if err != nil {
+				return nil, fmt.Errorf("worker %s: samples: %w", resp.Host, err)
+			}
+			parts = append(parts, part)
+			starts = append(starts, resp.SamplesAt)
+		}
+	}
+	if len(parts) > 0 {
+		merged.Samples = metrics.MergeSamples(parts, starts)
+	}
+	return merged, nil
+}
@@ -0,0 +1,155 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/workload"
+)
+
+// pathProperties name files and directories on the worker. Only its own
+// overrides may set them: a fresh datadir is removed before the run, so a
+// coordinator setting one could have any directory on the worker deleted.
+var pathProperties = []string{
+	"datadir",
+	"pebble.config",
+	bench.RecordTraceProperty,
+	bench.ReplayTraceProperty,
+	workload.TraceFileProperty,
+	prop.MeasurementRawOutputFile,
+	prop.FieldLengthHistogramFile,
+}
+
+// Worker serves benchmark runs to coordinators. go-ycsb keeps its
+// measurements in process-wide state, so a worker runs one workload at a
+// time and turns requests away while busy.
+type Worker struct {
+	// Overrides are applied on top of every request's properties, for
+	// settings local to the worker such as its datadir
+	Overrides *properties.Properties
+
+	// Log receives progress messages; nil discards them
+	Log io.Writer
+
+	// Finished, if set, is called with every completed run before its
+	// results are sent back
+	Finished func(*bench.Result)
+
+	mu sync.Mutex
+}
+
+// Serve accepts coordinators on lis until ctx is done, which also cancels
+// the run in progress
+func (w *Worker) Serve(ctx context.Context, lis net.Listener) error {
+	server := grpc.NewServer(grpc.MaxSendMsgSize(maxMessageSize))
+	server.RegisterService(&workerServiceDesc, w)
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			server.Stop()
+		case <-stopped:
+		}
+	}()
+
+	if err := server.Serve(lis); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// overridden reports whether the worker's own overrides set property k
+func (w *Worker) overridden(k string) bool {
+	if w.Overrides == nil {
+		return false
+	}
+	_, ok := w.Overrides.Get(k)
+	return ok
+}
+
+// Run executes one workload and returns everything the coordinator merges
+func (w *Worker) Run(ctx context.Context, req *RunRequest) (*RunResponse, error) {
+	if !w.mu.TryLock() {
+		return nil, status.Error(codes.Unavailable, "worker is busy with another run")
+	}
+	defer w.mu.Unlock()
+
+	log := w.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	props := properties.NewProperties()
+	for k, v := range req.Properties {
+		props.Set(k, v)
+	}
+	for _, k := range pathProperties {
+		if _, ok := props.Get(k); ok && !w.overridden(k) {
+			return nil, status.Errorf(codes.InvalidArgument, "%s names a path on the worker: set it with the worker's -p instead", k)
+		}
+	}
+	if w.Overrides != nil {
+		props.Merge(w.Overrides)
+	}
+	fmt.Fprintf(log, "Running %s workload for a coordinator\n", req.DB)
+
+	runner := bench.NewRunner(bench.Config{
+		DB:          req.DB,
+		Properties:  props,
+		KeepSamples: req.KeepSamples,
+		Log:         log,
+		BeforeRun: func(time.Duration) func() {
+			// The backend is open by now, so every worker starts the
+			// workload itself at the same time
+			if wait := time.Until(req.StartAt); wait > 0 {
+				fmt.Fprintf(log, "Starting with the other workers in %s\n", wait.Round(time.Millisecond))
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+				}
+			}
+			return nil
+		},
+	})
+	result, err := runner.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if w.Finished != nil {
+		w.Finished(result)
+	}
+
+	host, _ := os.Hostname()
+	resp := &RunResponse{
+		Host:      host,
+		Results:   result.Results(host),
+		Start:     result.Start,
+		End:       result.End,
+		Collector: result.Tracker.Snapshot(),
+		DBMetrics: result.DBMetrics,
+		SamplesAt: result.Tracker.SamplesStart(),
+	}
+	if req.KeepSamples {
+		var buf bytes.Buffer
+		if err := result.Tracker.EncodeSamples(&buf); err != nil {
+			return nil, fmt.Errorf("failed to encode samples: %w", err)
+		}
+		resp.SampleData = buf.Bytes()
+	}
+	return resp, nil
+}
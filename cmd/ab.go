@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	abWorkloadFile   string
+	abPropertyFile   string
+	abPropertyValues []string
+	abDB             string
+	abDBB            string
+	abAValues        []string
+	abBValues        []string
+	abChunk          int64
+	abLoad           bool
+	abOutputFormat   string
+	abOutputPath     string
+	abPlots          plotOptions
+)
+
+// abSides names the sides of an interleaved run
+var abSides = []string{"a", "b"}
+
+var abCmd = &cobra.Command{
+	Use:   "ab",
+	Short: "Compare two engine instances with their operations interleaved in one run",
+	Long: `Run one workload against two engine instances, A and B, alternating chunks
+of its operations between them (A, B, A, B ...) so thermal drift, background
+noise and the page cache affect both sides alike. The sides are compared
+overall and chunk by chunk, each A chunk against the B chunk right after it.
+
+Each side has its own data directory, under <datadir>/a and <datadir>/b
+(default /tmp/godb-bench-ab), and is loaded separately first unless
+--load=false. Records inserted during the run only exist on the side that
+inserted them.
+
+  godb-bench ab -w workloads/mixed.spec -b pebble.cache_size=1073741824 --chunk 1000`,
+	Run: func(cmd *cobra.Command, args []string) {
+		base, err := loadYCSBProperties(abWorkloadFile, abPropertyFile, abPropertyValues)
+		if err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		dbB := abDBB
+		if dbB == "" {
+			dbB = abDB
+		}
+		propsA, err := abProperties(base, abSides[0], abAValues)
+		if err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		propsB, err := abProperties(base, abSides[1], abBValues)
+		if err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		var chunkErr error
+		if abChunk < 1 {
+			chunkErr = fmt.Errorf("invalid --chunk %d: must be positive", abChunk)
+		}
+
+		if dryRun {
+			runDryRun([]dryRunTarget{
+				{label: "a (" + abDB + ")", dbName: abDB, props: propsA},
+				{label: "b (" + dbB + ")", dbName: dbB, props: propsB},
+			}, metrics.ValidateFormat(abOutputFormat), abPlots.validate(), chunkErr)
+			return
+		}
+		if err := metrics.ValidateFormat(abOutputFormat); err != nil {
+			fmt.Printf("Invalid output format: %v\n", err)
+			os.Exit(1)
+		}
+		if chunkErr != nil {
+			fmt.Printf("Invalid options: %v\n", chunkErr)
+			os.Exit(1)
+		}
+		if err := abPlots.validate(); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+
+		if abLoad {
+			for i, side := range []struct {
+				dbName string
+				props  *properties.Properties
+			}{{abDB, propsA}, {dbB, propsB}} {
+				if err := loadFresh(abSides[i], side.dbName, side.props); err != nil {
+					fmt.Printf("Benchmark failed: %v\n", err)
+					notifyFailure(err)
+					os.Exit(1)
+				}
+			}
+		}
+
+		if err := runAB(abDB, propsA, dbB, propsB); err != nil {
+			fmt.Printf("Benchmark failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// abProperties returns the properties of one side: the workload's, with the
+// side's own data directory and its overrides
+func abProperties(base *properties.Properties, side string, values []string) (*properties.Properties, error) {
+	props := cloneProperties(base)
+	props.Set("datadir", filepath.Join(base.GetString("datadir", "/tmp/godb-bench-ab"), side))
+	if err := applyPropertyOverrides(props, values); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// loadFresh loads the records of the workload into a fresh data directory,
+// naming it label in what it prints, such as a side of an A/B run
+func loadFresh(label, dbName string, props *properties.Properties) error {
+	datadir := props.GetString("datadir", "")
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("Loading %s (%s) into %s\n", label, dbName, datadir)
+	fmt.Println(strings.Repeat("=", 80))
+	if err := os.RemoveAll(datadir); err != nil {
+		return fmt.Errorf("failed to clean data directory %s: %w", datadir, err)
+	}
+
+	// read_only is for the runs over what is loaded, not the load itself
+	load := cloneProperties(props)
+	load.Set(prop.DoTransactions, "false")
+	load.Delete(db.ReadOnlyProperty)
+	ctx, stop := interruptContext()
+	defer stop()
+	result, err := bench.NewRunner(bench.Config{DB: dbName, Properties: load, Log: os.Stdout}).Run(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: load: %w", label, err)
+	}
+	metrics.PrintMetricsTable(result.Operations)
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: load: interrupted", label)
+	}
+	return nil
+}
+
+// runAB runs the workload with its operations interleaved between both
+// sides, prints their comparison and writes their plots and results
+func runAB(dbA string, propsA *properties.Properties, dbB string, propsB *properties.Properties) error {
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("Interleaving a (%s) and b (%s)\n", dbA, dbB)
+	fmt.Println(strings.Repeat("=", 80))
+
+	ctx, stop := interruptContext()
+	defer stop()
+	plots := abPlots
+	props := cloneProperties(propsA)
+	props.Set(prop.DoTransactions, "true")
+	result, err := bench.NewRunner(bench.Config{
+		DB:          dbA,
+		Properties:  props,
+		KeepSamples: plots.retainSamples(),
+		Log:         os.Stdout,
+		Dashboard:   dashboardOutput(),
+		Interleave:  &bench.Interleave{DB: dbB, Properties: propsB, ChunkSize: abChunk},
+	}).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	sides := []*bench.Result{result, result.B}
+	runs := make([]*ycsbRun, len(sides))
+	backends := make([]metrics.BackendResults, len(sides))
+	for i, side := range sides {
+		runs[i] = &ycsbRun{Result: side}
+		backends[i] = metrics.BackendResults{Name: abSides[i], Results: side.Operations, WriteAmp: side.WriteAmp, Layout: side.Layout, Capabilities: side.Capabilities}
+	}
+	metrics.PrintComparisonTable(backends)
+	metrics.PrintInterleavedComparison(result.Interleaved)
+	metrics.PrintErrorRateAbort(result.Aborted)
+	runs[0].interrupted = ctx.Err() != nil
+	if err := runs[0].abortError(); err != nil {
+		return err
+	}
+
+	for i, run := range runs {
+		if plots.enabled {
+			dir := plots.in(abSides[i]).dir
+			style, err := plots.style()
+			if err == nil {
+				run.Tracker.SetPlotStyle(style)
+				run.plots, err = run.Tracker.GeneratePlots(dir, plots.formats)
+			}
+			if err != nil {
+				fmt.Printf("Warning: failed to generate plots: %v\n", err)
+			} else {
+				fmt.Printf("Plots generated successfully in %s\n", dir)
+			}
+		}
+		if plots.samples {
+			dir := plots.in(abSides[i]).dir
+			path := filepath.Join(dir, metrics.SamplesFile)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				fmt.Printf("Warning: failed to create %s: %v\n", dir, err)
+			} else if err := run.Tracker.WriteSamples(path); err != nil {
+				fmt.Printf("Warning: failed to save samples: %v\n", err)
+			} else {
+				fmt.Printf("Samples saved to %s\n", path)
+			}
+		}
+	}
+	writeOverlayPlots(runs, abSides, plots.in("comparison"))
+
+	report := &metrics.Report{Interleaved: result.Interleaved}
+	for i, run := range runs {
+		recordHistory(abSides[i], run)
+		exportOTLP(abSides[i], run)
+		reportInflux(abSides[i], run)
+		notifyRun(abSides[i], run)
+		report.Runs = append(report.Runs, run.toResults(abSides[i]))
+	}
+	out := resultsOutput{format: abOutputFormat, path: abOutputPath}
+	return out.write("ab", report)
+}
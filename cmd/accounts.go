@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/accounts"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/readers"
+)
+
+var (
+	accountsPropertyFile   string
+	accountsPropertyValues []string
+	accountsCount          int64
+	accountsPerCommit      int
+	accountsCommits        int64
+	accountsWarmUpCommits  int64
+	accountsSeed           int64
+	accountsFresh          bool
+	accountsProgress       time.Duration
+	accountsOutputFormat   string
+	accountsOutputPath     string
+)
+
+var triedbBenchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Run TrieDB workloads beyond YCSB",
+}
+
+var triedbAccountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "Commit account nonce and balance updates, a fixed number of accounts per commit",
+	Long: `Commit updates of accounts, not storage slots, against TrieDB: every commit
+draws --accounts-per-commit distinct accounts out of --accounts, increments
+each one's nonce and debits or credits its balance with 256-bit arithmetic,
+half of them as senders and half as recipients, and writes them back in one
+transaction, as a node applies the transfers of a block. YCSB only writes
+storage slots of a single account, so it never takes this path.
+
+Accounts that do not exist yet are created by their first update. The data
+directory (default /tmp/godb-bench-accounts/triedb) is emptied first unless
+--fresh=false.
+
+  godb-bench triedb bench accounts --accounts 1000000 --accounts-per-commit 200 --commits 5000`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if accountsOutputFormat != metrics.FormatTable && accountsOutputFormat != metrics.FormatJSON {
+			fmt.Printf("Invalid output format: account update results are written as %s or %s, not %q\n",
+				metrics.FormatTable, metrics.FormatJSON, accountsOutputFormat)
+			os.Exit(1)
+		}
+		if accountsCount < 1 || accountsPerCommit < 1 || int64(accountsPerCommit) > accountsCount {
+			fmt.Println("Invalid options: --accounts-per-commit must be between 1 and --accounts")
+			os.Exit(1)
+		}
+		if accountsCommits < 1 || accountsWarmUpCommits < 0 {
+			fmt.Println("Invalid options: --commits must be positive and --warmup-commits not negative")
+			os.Exit(1)
+		}
+
+		props := properties.NewProperties()
+		if accountsPropertyFile != "" {
+			p, err := loadPropertyFile(accountsPropertyFile)
+			if err != nil {
+				fmt.Printf("Failed to load properties: %v\n", err)
+				os.Exit(1)
+			}
+			props.Merge(p)
+		}
+		if err := applyPropertyOverrides(props, accountsPropertyValues); err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		if _, ok := props.Get("datadir"); !ok {
+			props.Set("datadir", "/tmp/godb-bench-accounts/triedb")
+		}
+		bench.ApplyDefaults("triedb", props)
+
+		result, err := runAccounts(props)
+		if err != nil {
+			fmt.Printf("Account updates failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+		metrics.PrintAccountUpdates(result)
+
+		out := resultsOutput{format: accountsOutputFormat, path: accountsOutputPath}
+		if err := out.write("accounts", &metrics.Report{AccountUpdates: result}); err != nil {
+			fmt.Printf("Failed to write results: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// runAccounts opens TrieDB and commits the account updates against it.
+// Interrupting it keeps the commits made so far.
+func runAccounts(props *properties.Properties) (*metrics.AccountUpdates, error) {
+	datadir := props.GetString("datadir", "")
+	if accountsFresh {
+		if err := os.RemoveAll(datadir); err != nil {
+			return nil, fmt.Errorf("failed to clean data directory %s: %w", datadir, err)
+		}
+	}
+
+	creator := ycsb.GetDBCreator("triedb")
+	if creator == nil {
+		return nil, fmt.Errorf("DB creator for triedb not found")
+	}
+	db, err := creator.Create(props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DB: %w", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Updating %d of %d accounts per commit in %s...\n", accountsPerCommit, accountsCount, datadir)
+	return accounts.Run(ctx, db, accounts.Config{
+		DB:            "triedb",
+		Accounts:      accountsCount,
+		PerCommit:     accountsPerCommit,
+		Commits:       accountsCommits,
+		WarmUpCommits: accountsWarmUpCommits,
+		Seed:          accountsSeed,
+		Progress:      accountsProgress,
+		Log:           os.Stdout,
+	})
+}
+
+var (
+	selfDestructPropertyFile   string
+	selfDestructPropertyValues []string
+	selfDestructAccounts       int
+	selfDestructSlots          []int
+	selfDestructSeed           int64
+	selfDestructFresh          bool
+	selfDestructOutputFormat   string
+	selfDestructOutputPath     string
+)
+
+var triedbSelfDestructCmd = &cobra.Command{
+	Use:   "selfdestruct",
+	Short: "Delete whole accounts with their storage and measure the latency and the space reclaimed by storage size",
+	Long: `Create --accounts accounts of every storage size of --slots, one commit per
+account, then delete them with all of their storage, one commit per account,
+as SELFDESTRUCT clears a contract. Clearing a large storage trie at once is a
+known worst case for trie databases: the results have the deletion latency
+distribution, the storage slots deleted per second and the space the
+deletions reclaimed from the data directory at every size.
+
+The data directory (default /tmp/godb-bench-selfdestruct/triedb) is emptied
+first unless --fresh=false.
+
+  godb-bench triedb bench selfdestruct --accounts 50 --slots 10,1000,100000`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if selfDestructOutputFormat != metrics.FormatTable && selfDestructOutputFormat != metrics.FormatJSON {
+			fmt.Printf("Invalid output format: self-destruct results are written as %s or %s, not %q\n",
+				metrics.FormatTable, metrics.FormatJSON, selfDestructOutputFormat)
+			os.Exit(1)
+		}
+		if selfDestructAccounts < 1 || len(selfDestructSlots) == 0 || slices.Min(selfDestructSlots) < 0 {
+			fmt.Println("Invalid options: --accounts must be positive and --slots list storage sizes of 0 or more")
+			os.Exit(1)
+		}
+
+		props := properties.NewProperties()
+		if selfDestructPropertyFile != "" {
+			p, err := loadPropertyFile(selfDestructPropertyFile)
+			if err != nil {
+				fmt.Printf("Failed to load properties: %v\n", err)
+				os.Exit(1)
+			}
+			props.Merge(p)
+		}
+		if err := applyPropertyOverrides(props, selfDestructPropertyValues); err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		if _, ok := props.Get("datadir"); !ok {
+			props.Set("datadir", "/tmp/godb-bench-selfdestruct/triedb")
+		}
+		bench.ApplyDefaults("triedb", props)
+
+		result, err := runSelfDestruct(props)
+		if err != nil {
+			fmt.Printf("Self-destruct benchmark failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+		metrics.PrintSelfDestructs(result)
+
+		out := resultsOutput{format: selfDestructOutputFormat, path: selfDestructOutputPath}
+		if err := out.write("selfdestruct", &metrics.Report{SelfDestructs: result}); err != nil {
+			fmt.Printf("Failed to write results: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// runSelfDestruct opens TrieDB and creates and deletes the accounts of every
+// storage size against it
+func runSelfDestruct(props *properties.Properties) (*metrics.SelfDestructs, error) {
+	datadir := props.GetString("datadir", "")
+	if selfDestructFresh {
+		if err := os.RemoveAll(datadir); err != nil {
+			return nil, fmt.Errorf("failed to clean data directory %s: %w", datadir, err)
+		}
+	}
+
+	creator := ycsb.GetDBCreator("triedb")
+	if creator == nil {
+		return nil, fmt.Errorf("DB creator for triedb not found")
+	}
+	db, err := creator.Create(props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DB: %w", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	slots := slices.Clone(selfDestructSlots)
+	slices.Sort(slots)
+	return accounts.SelfDestruct(ctx, db, accounts.SelfDestructConfig{
+		DB:       "triedb",
+		Accounts: selfDestructAccounts,
+		Slots:    slots,
+		Seed:     selfDestructSeed,
+		Size:     func() int64 { return bench.DirSize(datadir) },
+		Log:      os.Stdout,
+	})
+}
+
+// defaultReaderStep is how long every step of a reader scaling run lasts
+const defaultReaderStep = 10 * time.Second
+
+var (
+	readersPropertyFile   string
+	readersPropertyValues []string
+	readersCounts         []int
+	readersKeys           int64
+	readersKeysPerRead    int
+	readersKeysPerCommit  int
+	readersStep           time.Duration
+	readersSeed           int64
+	readersFresh          bool
+	readersOutputFormat   string
+	readersOutputPath     string
+	readersPlots          plotOptions
+)
+
+var triedbReadersCmd = &cobra.Command{
+	Use:   "readers",
+	Short: "Measure read throughput and commit latency of one writer as concurrent read transactions are added",
+	Long: `Load --keys keys, then run a step per count of --readers: one writer commits
+--keys-per-commit random keys at a time without pause while that many readers
+each read --keys-per-read random keys per read-only transaction, for --step.
+The results have the read throughput and the writer's commit latency at every
+step, and plots draw them against the number of readers, which shows how
+readers and the writer interfere.
+
+The data directory (default /tmp/godb-bench-readers/triedb) is emptied first
+unless --fresh=false.
+
+  godb-bench triedb bench readers --readers 0,1,2,4,8,16,32 --step 30s`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if readersOutputFormat != metrics.FormatTable && readersOutputFormat != metrics.FormatJSON {
+			fmt.Printf("Invalid output format: reader scaling results are written as %s or %s, not %q\n",
+				metrics.FormatTable, metrics.FormatJSON, readersOutputFormat)
+			os.Exit(1)
+		}
+		if len(readersCounts) == 0 || slices.Min(readersCounts) < 0 {
+			fmt.Println("Invalid options: --readers must list reader counts of 0 or more")
+			os.Exit(1)
+		}
+		if readersKeys < 1 || readersKeysPerRead < 1 || readersKeysPerCommit < 1 || readersStep <= 0 {
+			fmt.Println("Invalid options: --keys, --keys-per-read, --keys-per-commit and --step must be positive")
+			os.Exit(1)
+		}
+		if err := readersPlots.validate(); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+
+		props := properties.NewProperties()
+		if readersPropertyFile != "" {
+			p, err := loadPropertyFile(readersPropertyFile)
+			if err != nil {
+				fmt.Printf("Failed to load properties: %v\n", err)
+				os.Exit(1)
+			}
+			props.Merge(p)
+		}
+		if err := applyPropertyOverrides(props, readersPropertyValues); err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		if _, ok := props.Get("datadir"); !ok {
+			props.Set("datadir", "/tmp/godb-bench-readers/triedb")
+		}
+		bench.ApplyDefaults("triedb", props)
+
+		result, err := runReaders(props)
+		if err != nil {
+			fmt.Printf("Reader scaling failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+		metrics.PrintReaderScaling(result)
+
+		if readersPlots.enabled {
+			style, err := readersPlots.style()
+			if err == nil {
+				_, err = metrics.GenerateReaderScalingPlots(result, readersPlots.dir, readersPlots.formats, style)
+			}
+			if err != nil {
+				fmt.Printf("Warning: failed to generate plots: %v\n", err)
+			} else {
+				fmt.Printf("Plots generated successfully in %s\n", readersPlots.dir)
+			}
+		}
+
+		out := resultsOutput{format: readersOutputFormat, path: readersOutputPath}
+		if err := out.write("readers", &metrics.Report{ReaderScaling: result}); err != nil {
+			fmt.Printf("Failed to write results: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// runReaders opens TrieDB and runs the steps of the reader scaling against
+// it
+func runReaders(props *properties.Properties) (*metrics.ReaderScaling, error) {
+	datadir := props.GetString("datadir", "")
+	if readersFresh {
+		if err := os.RemoveAll(datadir); err != nil {
+			return nil, fmt.Errorf("failed to clean data directory %s: %w", datadir, err)
+		}
+	}
+
+	creator := ycsb.GetDBCreator("triedb")
+	if creator == nil {
+		return nil, fmt.Errorf("DB creator for triedb not found")
+	}
+	db, err := creator.Create(props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DB: %w", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return readers.Run(ctx, db, readers.Config{
+		DB:            "triedb",
+		Keys:          readersKeys,
+		KeysPerRead:   readersKeysPerRead,
+		KeysPerCommit: readersKeysPerCommit,
+		Readers:       readersCounts,
+		Step:          readersStep,
+		Seed:          readersSeed,
+		Log:           os.Stdout,
+	})
+}
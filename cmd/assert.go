@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// parseAssertions parses the --assert flags
+func parseAssertions(exprs []string) ([]metrics.Assertion, error) {
+	assertions := make([]metrics.Assertion, 0, len(exprs))
+	for _, expr := range exprs {
+		a, err := metrics.ParseAssertion(expr)
+		if err != nil {
+			return nil, err
+		}
+		assertions = append(assertions, a)
+	}
+	return assertions, nil
+}
+
+// checkAssertions evaluates the assertions against every run, prints the
+// report and reports whether they all held
+func checkAssertions(assertions []metrics.Assertion, runs []*ycsbRun) bool {
+	if len(assertions) == 0 {
+		return true
+	}
+	var results []metrics.AssertionResult
+	for i, run := range runs {
+		results = append(results, metrics.CheckAssertions(fmt.Sprintf("run-%d", i+1), run.Operations, assertions)...)
+	}
+	return metrics.PrintAssertionResults(results)
+}
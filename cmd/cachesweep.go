@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	cacheSweepWorkload       string
+	cacheSweepPropertyFile   string
+	cacheSweepPropertyValues []string
+	cacheSweepSizes          []int64
+	cacheSweepFresh          bool
+	cacheSweepOutputFormat   string
+	cacheSweepOutputPath     string
+	cacheSweepPlots          plotOptions
+)
+
+// defaultCacheSweepSizes are the block cache sizes swept unless listed
+var defaultCacheSweepSizes = []int64{8 << 20, 64 << 20, 256 << 20, 1 << 30}
+
+var pebbleCacheSweepCmd = &cobra.Command{
+	Use:   "cache-sweep",
+	Short: "Run a read-heavy workload once per block cache size over the same loaded dataset",
+	Long: `Load the records of a workload once, then run its transactions against them
+once per size of --cache-sizes, reopening PebbleDB with pebble.cache_size set
+to that size every time. The results have the block cache hit rate and the
+read latency at every size, and plots draw both against the cache size,
+which shows the size past which a bigger cache stops paying off.
+
+Use a read-only or read-heavy workload: writes change the dataset that the
+later sizes run on. The data directory (default
+/tmp/godb-bench-cache-sweep/pebble) is emptied and loaded first unless
+--fresh=false, which sweeps the dataset already in it; with -p read_only=true
+it is left unchanged.
+
+  godb-bench pebble cache-sweep -w read.spec --cache-sizes 8388608,67108864,268435456,1073741824`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := metrics.ValidateFormat(cacheSweepOutputFormat); err != nil {
+			fmt.Printf("Invalid output format: %v\n", err)
+			os.Exit(1)
+		}
+		if len(cacheSweepSizes) == 0 {
+			fmt.Println("Invalid options: --cache-sizes must list at least one size")
+			os.Exit(1)
+		}
+		for _, size := range cacheSweepSizes {
+			if size < 1 {
+				fmt.Println("Invalid options: --cache-sizes must be positive")
+				os.Exit(1)
+			}
+		}
+		if err := cacheSweepPlots.validate(); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+
+		props, err := loadYCSBProperties(cacheSweepWorkload, cacheSweepPropertyFile, cacheSweepPropertyValues)
+		if err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		if _, ok := props.Get("datadir"); !ok {
+			props.Set("datadir", "/tmp/godb-bench-cache-sweep/pebble")
+		}
+		bench.ApplyDefaults("pebble", props)
+		if writes := props.GetFloat64(prop.UpdateProportion, prop.UpdateProportionDefault) +
+			props.GetFloat64(prop.InsertProportion, prop.InsertProportionDefault) +
+			props.GetFloat64(prop.ReadModifyWriteProportion, prop.ReadModifyWriteProportionDefault); writes > 0 {
+			fmt.Printf("Warning: %.0f%% of the operations write, so every cache size after the first runs on a dataset the earlier ones changed\n", 100*writes)
+		}
+
+		sweep, report, err := runCacheSweep(props)
+		if err != nil {
+			fmt.Printf("Cache sweep failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+		metrics.PrintCacheSweep(sweep)
+
+		if cacheSweepPlots.enabled {
+			style, err := cacheSweepPlots.style()
+			if err == nil {
+				_, err = metrics.GenerateCacheSweepPlots(sweep, cacheSweepPlots.dir, cacheSweepPlots.formats, style)
+			}
+			if err != nil {
+				fmt.Printf("Warning: failed to generate plots: %v\n", err)
+			} else {
+				fmt.Printf("Plots generated successfully in %s\n", cacheSweepPlots.dir)
+			}
+		}
+
+		out := resultsOutput{format: cacheSweepOutputFormat, path: cacheSweepOutputPath}
+		if err := out.write("cache_sweep", report); err != nil {
+			fmt.Printf("Failed to write results: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// runCacheSweep loads the dataset unless --fresh=false, then runs the
+// transactions once per cache size. An interrupted run ends the sweep with
+// the sizes completed before it.
+func runCacheSweep(props *properties.Properties) (*metrics.CacheSweep, *metrics.Report, error) {
+	datadir := props.GetString("datadir", "")
+	if cacheSweepFresh {
+		if err := loadFresh("the dataset", "pebble", props); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sweep := &metrics.CacheSweep{
+		DB:       "pebble",
+		Records:  props.GetInt64(prop.RecordCount, 0),
+		DiskSize: bench.DirSize(datadir),
+	}
+	report := &metrics.Report{CacheSweep: sweep}
+	for i, size := range cacheSweepSizes {
+		name := "cache-" + strconv.FormatInt(size, 10)
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Printf("Cache sweep run %d/%d: %s block cache\n", i+1, len(cacheSweepSizes), formatCacheSize(size))
+		fmt.Println(strings.Repeat("=", 80))
+
+		// The dataset must be kept, whatever the properties say
+		run := cloneProperties(props)
+		run.Set("pebble.cache_size", strconv.FormatInt(size, 10))
+		run.Set("pebble.use_existing", "true")
+		run.Set(prop.DoTransactions, "true")
+
+		ctx, stop := interruptContext()
+		result, err := bench.NewRunner(bench.Config{
+			DB:          "pebble",
+			Properties:  run,
+			KeepSamples: cacheSweepOutputFormat == metrics.FormatCriterion,
+			Log:         os.Stdout,
+		}).Run(ctx)
+		interrupted := ctx.Err() != nil
+		stop()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if interrupted {
+			fmt.Println("Cache sweep interrupted")
+			break
+		}
+		metrics.PrintMetricsTable(result.Operations)
+		metrics.PrintBlockCache(result.BlockCache)
+		if result.BlockCache == nil {
+			return nil, nil, fmt.Errorf("pebble does not report its block cache")
+		}
+
+		r := &ycsbRun{Result: result}
+		recordHistory(name, r)
+		report.Runs = append(report.Runs, r.toResults(name))
+		sweep.Points = append(sweep.Points, metrics.NewCacheSweepPoint(size, result.Operations, result.BlockCache))
+	}
+	return sweep, report, nil
+}
+
+// formatCacheSize formats bytes as MiB or GiB, as cache sizes usually are
+func formatCacheSize(bytes int64) string {
+	if bytes >= 1<<30 && bytes%(1<<30) == 0 {
+		return fmt.Sprintf("%d GiB", bytes>>30)
+	}
+	return fmt.Sprintf("%.0f MiB", float64(bytes)/(1<<20))
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+)
+
+var (
+	cleanDatadirs []string
+	cleanAll      bool
+	cleanDryRun   bool
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove benchmark databases and generated plots",
+	Long: `Remove benchmark data directories so the next run really starts from an
+empty database. Pass directories with --datadir, or --all to remove every
+default location used by the benchmark commands (/tmp/<db>, sweep and
+run-all datadirs, and the default plot directories). Use --dry-run to list
+what would be removed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		paths := append([]string{}, cleanDatadirs...)
+		if cleanAll {
+			paths = append(paths, defaultBenchmarkPaths()...)
+		}
+		if len(paths) == 0 {
+			fmt.Println("Nothing to clean: specify --datadir or --all")
+			os.Exit(1)
+		}
+
+		failed := false
+		for _, path := range paths {
+			if err := cleanPath(path, cleanDryRun); err != nil {
+				fmt.Printf("Failed to remove %s: %v\n", path, err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// defaultBenchmarkPaths returns the default data and plot directories of the
+// benchmark commands. Keep in sync with the defaults in the commands.
+func defaultBenchmarkPaths() []string {
+	var paths []string
+	for _, name := range db.Backends() {
+		paths = append(paths,
+			fmt.Sprintf("/tmp/%s", name),
+			fmt.Sprintf("/tmp/%s-sweep", name),
+			fmt.Sprintf("./%s_benchmark_plots", name))
+	}
+	paths = append(paths,
+		"/tmp/godb-bench-run-all",
+		"/tmp/godb-bench-ab",
+		"/tmp/godb-bench-replay",
+		"/tmp/godb-bench-cache-sweep",
+		"/tmp/godb-bench-readers",
+		"/tmp/godb-bench-selfdestruct",
+		"/tmp/godb-bench-accounts",
+		"./pebbledb_benchmark_plots",
+		"./triedb_benchmark_plots",
+		"./run_all_benchmark_plots",
+		"./ab_benchmark_plots",
+		"./replay_benchmark_plots",
+		"./readers_benchmark_plots",
+		"./coordinator_benchmark_plots",
+		"./sweep_results",
+		defaultResultsDir)
+
+	// Some defaults coincide, e.g. triedb's config and ycsb plot directories
+	seen := make(map[string]bool, len(paths))
+	unique := paths[:0]
+	for _, p := range paths {
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, p)
+		}
+	}
+	return unique
+}
+
+// cleanPath removes path after checking it is safe to do so. Missing paths
+// are skipped.
+func cleanPath(path string, dryRun bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if err := checkRemovable(abs); err != nil {
+		return err
+	}
+
+	size, err := diskUsage(abs)
+	if os.IsNotExist(err) {
+		if dryRun {
+			fmt.Printf("%-12s %s\n", "not found", path)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("%-12s %s (%s)\n", "would remove", path, formatBytes(size))
+		return nil
+	}
+	if err := os.RemoveAll(abs); err != nil {
+		return err
+	}
+	fmt.Printf("%-12s %s (%s)\n", "removed", path, formatBytes(size))
+	return nil
+}
+
+// checkRemovable refuses the filesystem root, the home directory and the
+// working directory or any of its parents
+func checkRemovable(abs string) error {
+	if abs == filepath.Dir(abs) {
+		return fmt.Errorf("refusing to remove the filesystem root")
+	}
+	if home, err := os.UserHomeDir(); err == nil && abs == filepath.Clean(home) {
+		return fmt.Errorf("refusing to remove the home directory")
+	}
+	if wd, err := os.Getwd(); err == nil {
+		rel, err := filepath.Rel(abs, wd)
+		if err == nil && (rel == "." || !strings.HasPrefix(rel, "..")) {
+			return fmt.Errorf("refusing to remove the working directory or one of its parents")
+		}
+	}
+	return nil
+}
+
+// diskUsage returns the total size of the files under path
+func diskUsage(path string) (int64, error) {
+	if _, err := os.Lstat(path); err != nil {
+		return 0, err
+	}
+
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// formatBytes formats a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gonum.org/v1/plot/vg"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// benchConfig is a declarative description of a benchmark, loaded from a
+// YAML (or JSON) file by the run command
+type benchConfig struct {
+	DB           string                   `yaml:"db"`
+	Workload     string                   `yaml:"workload"`
+	PropertyFile string                   `yaml:"property_file"`
+	Properties   map[string]propertyValue `yaml:"properties"`
+	Phases       []benchPhase             `yaml:"phases"`
+	Repetitions  int                      `yaml:"repetitions"`
+	FreshDatadir bool                     `yaml:"fresh_datadir"`
+	Output       benchOutput              `yaml:"output"`
+}
+
+// benchPhase is one step of a benchmark. Phases run in order against the same
+// data directory, so a load phase can prepare the dataset for a run phase.
+type benchPhase struct {
+	Name       string                   `yaml:"name"`
+	Load       bool                     `yaml:"load"` // insert recordcount records instead of running transactions
+	Properties map[string]propertyValue `yaml:"properties"`
+}
+
+// benchOutput controls the artifacts written by a run
+type benchOutput struct {
+	Plots       *bool           `yaml:"plots"` // defaults to true
+	PlotsDir    string          `yaml:"plots_dir"`
+	PlotFormats []string        `yaml:"plot_formats"` // defaults to [png]
+	PlotStyle   plotStyleConfig `yaml:"plot_style"`
+	Samples     *bool           `yaml:"samples"` // save raw samples for replot; defaults to true
+	Format      string          `yaml:"format"`  // table (default), json, csv, markdown or criterion
+	Path        string          `yaml:"path"`    // results file (json) or directory (csv)
+}
+
+// plotStyleConfig is the look of the plots; unset fields use the defaults of
+// the --plot-* flags
+type plotStyleConfig struct {
+	Width       float64 `yaml:"width"`        // inches
+	Height      float64 `yaml:"height"`       // inches
+	PointRadius float64 `yaml:"point_radius"` // points
+	Palette     string  `yaml:"palette"`      // palette name or list of #rrggbb colors
+	Title       string  `yaml:"title"`        // title template
+	Theme       string  `yaml:"theme"`        // light or dark
+	LogY        bool    `yaml:"log_y"`        // latency axis on a log scale
+	MaxPoints   *int    `yaml:"max_points"`   // scatter points per series; 0 draws all
+	Outliers    bool    `yaml:"outliers"`     // color Tukey outliers in the sample times plot
+}
+
+// loadBenchConfig reads, defaults and validates a benchmark config file
+func loadBenchConfig(path string) (*benchConfig, error) {
+	if path == "" {
+		return nil, fmt.Errorf("please specify a config file using -c or --config")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg benchConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if cfg.DB == "" {
+		return nil, fmt.Errorf("%s: db is required", path)
+	}
+	if cfg.Workload == "" {
+		return nil, fmt.Errorf("%s: workload is required", path)
+	}
+	if len(cfg.Phases) == 0 {
+		cfg.Phases = []benchPhase{{Name: "run"}}
+	}
+	for i := range cfg.Phases {
+		if cfg.Phases[i].Name == "" {
+			cfg.Phases[i].Name = fmt.Sprintf("phase%d", i+1)
+		}
+	}
+	if cfg.Repetitions <= 0 {
+		cfg.Repetitions = 1
+	}
+	if cfg.Output.PlotsDir == "" {
+		cfg.Output.PlotsDir = fmt.Sprintf("./%s_benchmark_plots", cfg.DB)
+	}
+	if len(cfg.Output.PlotFormats) == 0 {
+		cfg.Output.PlotFormats = []string{"png"}
+	}
+	cfg.Output.PlotStyle.setDefaults()
+	if cfg.Output.Format == "" {
+		cfg.Output.Format = metrics.FormatTable
+	}
+	if err := metrics.ValidateFormat(cfg.Output.Format); err != nil {
+		return nil, fmt.Errorf("%s: output: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// plotsEnabled reports whether plots should be generated
+func (o benchOutput) plotsEnabled() bool {
+	return o.Plots == nil || *o.Plots
+}
+
+// plotOptions returns the plot settings of the config
+func (o benchOutput) plotOptions() plotOptions {
+	s := o.PlotStyle
+	return plotOptions{
+		enabled:     o.plotsEnabled(),
+		dir:         o.PlotsDir,
+		formats:     o.PlotFormats,
+		samples:     o.Samples == nil || *o.Samples,
+		retain:      o.Format == metrics.FormatCriterion,
+		width:       s.Width,
+		height:      s.Height,
+		pointRadius: s.PointRadius,
+		palette:     s.Palette,
+		title:       s.Title,
+		theme:       s.Theme,
+		logY:        s.LogY,
+		maxPoints:   *s.MaxPoints,
+		outliers:    s.Outliers,
+	}
+}
+
+// setDefaults fills in the unset fields from the default plot style
+func (s *plotStyleConfig) setDefaults() {
+	def := metrics.DefaultPlotStyle()
+	if s.Width == 0 {
+		s.Width = float64(def.Width / vg.Inch)
+	}
+	if s.Height == 0 {
+		s.Height = float64(def.Height / vg.Inch)
+	}
+	if s.PointRadius == 0 {
+		s.PointRadius = float64(def.PointRadius)
+	}
+	if s.Palette == "" {
+		s.Palette = "default"
+	}
+	if s.Title == "" {
+		s.Title = def.Title
+	}
+	if s.Theme == "" {
+		s.Theme = def.Theme
+	}
+	if s.MaxPoints == nil {
+		s.MaxPoints = &def.MaxPoints
+	}
+}
+
+// propertyValue is a property value that may be written as a string or number
+type propertyValue string
+
+// UnmarshalJSON accepts both JSON strings and numbers, keeping numbers verbatim
+func (v *propertyValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = propertyValue(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("property value must be a string or number: %s", data)
+	}
+	*v = propertyValue(n.String())
+	return nil
+}
+
+// UnmarshalYAML accepts any scalar, keeping its literal text
+func (v *propertyValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: property value must be a scalar", node.Line)
+	}
+	*v = propertyValue(node.Value)
+	return nil
+}
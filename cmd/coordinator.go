@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/cluster"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	coordinatorWorkers        []string
+	coordinatorDB             string
+	coordinatorWorkloadFile   string
+	coordinatorPropertyFile   string
+	coordinatorPropertyValues []string
+	coordinatorPartition      bool
+	coordinatorStartDelay     time.Duration
+	coordinatorOutputFormat   string
+	coordinatorOutputPath     string
+	coordinatorPlots          plotOptions
+)
+
+var coordinatorCmd = &cobra.Command{
+	Use:   "coordinator",
+	Short: "Run one workload on several workers at once and merge their results",
+	Long: `Dispatch the same workload to every worker (see "worker") over gRPC, start it
+on all of them at the same time and merge what they measured: latencies from
+their full-resolution histograms, throughput over the span of the whole run,
+and their samples for plots and statistics. Use it to offer more load than
+one client machine can.
+
+By default every worker runs the whole workload against its own backend.
+With --partition the load's key range, operationcount and target_ops are
+split across the workers instead, for a backend they share.
+
+  godb-bench coordinator --workers bench1:7070,bench2:7070 --db pebble -w workloads/mixed.spec -o json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		props, err := loadYCSBProperties(coordinatorWorkloadFile, coordinatorPropertyFile, coordinatorPropertyValues)
+		if err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		if len(coordinatorWorkers) == 0 {
+			fmt.Println("Failed to start coordinator: please specify the workers using --workers")
+			os.Exit(1)
+		}
+		coordinator := &cluster.Coordinator{
+			Workers:    coordinatorWorkers,
+			StartDelay: coordinatorStartDelay,
+			Partition:  coordinatorPartition,
+		}
+
+		if dryRun {
+			targets := make([]dryRunTarget, len(coordinatorWorkers))
+			for i, address := range coordinatorWorkers {
+				targets[i] = dryRunTarget{
+					label:  "worker " + cluster.WorkerAddress(address),
+					dbName: coordinatorDB,
+					props:  coordinator.Properties(props, i),
+				}
+			}
+			runDryRun(targets, metrics.ValidateFormat(coordinatorOutputFormat), coordinatorPlots.validate())
+			return
+		}
+		if err := metrics.ValidateFormat(coordinatorOutputFormat); err != nil {
+			fmt.Printf("Invalid output format: %v\n", err)
+			os.Exit(1)
+		}
+		if err := coordinatorPlots.validate(); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := runCoordinated(coordinator, props); err != nil {
+			fmt.Printf("Benchmark failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// runCoordinated runs the workload on every worker, prints each worker's
+// and the merged results, and writes the merged plots and results
+func runCoordinated(coordinator *cluster.Coordinator, props *properties.Properties) error {
+	// The merged tables and statistics are computed here, as a run would
+	if err := bench.ConfigureMetrics(props); err != nil {
+		return err
+	}
+	plots := coordinatorPlots
+	keepSamples := plots.retainSamples() || coordinatorOutputFormat == metrics.FormatCriterion ||
+		props.GetBool(bench.StatisticsProperty, false) || props.GetBool(bench.PerThreadProperty, false)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Dispatching the workload to %d workers...\n", len(coordinator.Workers))
+	responses, err := coordinator.Run(ctx, coordinatorDB, props, keepSamples)
+	if err != nil {
+		return err
+	}
+	merged, err := cluster.Merge(responses)
+	if err != nil {
+		return err
+	}
+
+	report := &metrics.Report{}
+	workerRuns := make([][]metrics.OperationMetrics, len(responses))
+	for i, resp := range responses {
+		workerRuns[i] = resp.Results.Operations
+		results := resp.Results
+		results.Name = "worker/" + coordinator.Workers[i]
+		report.Runs = append(report.Runs, results)
+	}
+	metrics.PrintWorkersTable(coordinator.Workers, workerRuns)
+
+	results := metrics.Results{
+		Name:        "merged",
+		ID:          metrics.NewRunID(),
+		DB:          coordinatorDB,
+		Properties:  props.Map(),
+		Environment: responses[0].Results.Environment, // workers run the same build
+		Operations:  merged.Collector.Results(),
+	}
+	metrics.PrintMetricsTable(results.Operations)
+	if step := props.GetFloat64(bench.DistributionProperty, 0); step > 0 {
+		results.Distributions = merged.Collector.Distributions(step)
+	}
+
+	if samples := merged.Samples; samples != nil {
+		bootstrap, err := bench.BootstrapOf(props)
+		if err != nil {
+			return err
+		}
+		samples.SetBootstrap(bootstrap)
+		results.Samples = samples.Samples()
+		results.Bootstrap = bootstrap
+		if props.GetBool(bench.StatisticsProperty, false) {
+			results.Statistics = samples.ComputeStatistics()
+			metrics.PrintOperationStatistics(results.Statistics)
+		}
+		if props.GetBool(bench.PerThreadProperty, false) {
+			results.Threads = samples.ThreadStatistics()
+			metrics.PrintThreadStatistics(results.Threads)
+		}
+
+		if plots.enabled {
+			fmt.Printf("\nGenerating benchmark plots in %s...\n", plots.dir)
+			style, err := plots.style()
+			if err == nil {
+				samples.SetStyle(style)
+				results.Plots, err = samples.GeneratePlots(plots.dir, plots.formats)
+			}
+			if err != nil {
+				fmt.Printf("Warning: failed to generate plots: %v\n", err)
+			} else {
+				fmt.Printf("Plots generated successfully in %s\n", plots.dir)
+			}
+		}
+		if plots.samples {
+			path := filepath.Join(plots.dir, metrics.SamplesFile)
+			if err := os.MkdirAll(plots.dir, 0755); err != nil {
+				fmt.Printf("Warning: failed to create %s: %v\n", plots.dir, err)
+			} else if err := samples.WriteSamples(path); err != nil {
+				fmt.Printf("Warning: failed to save samples: %v\n", err)
+			} else {
+				fmt.Printf("Samples saved to %s\n", path)
+			}
+		}
+	}
+
+	recordResults(results)
+	exportOTLPResults(results, merged.Collector.Histograms, merged.Start, merged.End)
+	reportInfluxResults(results, merged.End)
+	notifyRunResults(results, merged.Start, merged.End)
+
+	report.Runs = append(report.Runs, results)
+	out := resultsOutput{format: coordinatorOutputFormat, path: coordinatorOutputPath}
+	return out.write(coordinatorDB, report)
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/spf13/cobra"
+)
+
+// daemonRunLayout names the directory of each execution of the suite, so
+// they sort by time
+const daemonRunLayout = "20060102-150405"
+
+var (
+	daemonConfigFiles    []string
+	daemonPropertyValues []string
+	daemonSchedule       string
+	daemonRoot           string
+	daemonKeep           int
+	daemonRunNow         bool
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run a benchmark suite on a schedule, unattended",
+	Long: `Run a suite of config files (see "run") on a cron schedule until stopped, for
+nightly performance tracking on dedicated hardware. Every run is recorded in
+the history database. Each execution of the suite gets its own directory
+under --root holding its data directories, plots and results files;
+only the newest --keep are kept.
+
+The schedule is a five-field cron expression in local time (minute hour
+day-of-month month day-of-week), a shorthand such as @daily or @hourly, or
+"@every <duration>". Config files are re-read at every execution, so the
+suite can be changed without restarting the daemon.
+
+  godb-bench daemon -c nightly-load.yaml -c nightly-mixed.yaml --schedule "0 2 * * *" --root /data/godb-bench --keep 7`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(daemonConfigFiles) == 0 {
+			fmt.Println("Failed to start daemon: please specify at least one config file using -c or --config")
+			os.Exit(1)
+		}
+		sched, err := parseSchedule(daemonSchedule)
+		if err != nil {
+			fmt.Printf("Failed to start daemon: %v\n", err)
+			os.Exit(1)
+		}
+		if daemonKeep < 1 {
+			fmt.Printf("Failed to start daemon: --keep must be at least 1, got %d\n", daemonKeep)
+			os.Exit(1)
+		}
+
+		if dryRun {
+			next := time.Now()
+			fmt.Println("Next executions:")
+			for range 5 {
+				next = sched.next(next)
+				fmt.Printf("  %s\n", next.Format(time.RFC1123))
+			}
+			var targets []dryRunTarget
+			for _, path := range daemonConfigFiles {
+				cfg, base, err := loadDaemonConfig(path, "")
+				if err != nil {
+					fmt.Printf("Failed to load config: %v\n", err)
+					os.Exit(1)
+				}
+				for _, phase := range cfg.Phases {
+					targets = append(targets, dryRunTarget{
+						label:  fmt.Sprintf("%s: %s phase %s", path, cfg.DB, phase.Name),
+						dbName: cfg.DB,
+						props:  phaseProperties(base, phase, defaultDatadir(cfg.DB, base)),
+					})
+				}
+			}
+			runDryRun(targets)
+			return
+		}
+
+		runDaemon(sched)
+	},
+}
+
+// runDaemon executes the suite whenever sched fires until interrupted
+func runDaemon(sched *schedule) {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	next := time.Now()
+	if !daemonRunNow {
+		next = sched.next(next)
+	}
+	for {
+		if next.IsZero() {
+			fmt.Println("Schedule never fires again; exiting")
+			return
+		}
+		fmt.Printf("\nNext suite execution at %s\n", next.Format(time.RFC1123))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case sig := <-stop:
+			timer.Stop()
+			fmt.Printf("Received %s; exiting\n", sig)
+			return
+		}
+
+		runSuite(time.Now())
+		// A suite that overruns its slot skips the executions it missed
+		next = sched.next(time.Now())
+	}
+}
+
+// runSuite executes every config of the suite in a fresh directory named
+// after started, after removing the oldest directories beyond --keep.
+// Failures are reported and the remaining configs still run.
+func runSuite(started time.Time) {
+	dir := filepath.Join(daemonRoot, started.Format(daemonRunLayout))
+	if err := rotateDaemonRuns(daemonRoot, daemonKeep-1); err != nil {
+		fmt.Printf("Warning: failed to rotate %s: %v\n", daemonRoot, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("Suite failed: %v\n", err)
+		notifyFailure(err)
+		return
+	}
+
+	fmt.Println("\n" + strings.Repeat("#", 80))
+	fmt.Printf("Suite execution %s in %s\n", started.Format(time.RFC1123), dir)
+	fmt.Println(strings.Repeat("#", 80))
+
+	failed := 0
+	for _, path := range daemonConfigFiles {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		cfg, base, err := loadDaemonConfig(path, filepath.Join(dir, name))
+		if err == nil {
+			err = runBenchConfig(cfg, base, profileOptions{})
+		}
+		if err != nil {
+			fmt.Printf("Warning: %s failed: %v\n", path, err)
+			notifyFailure(fmt.Errorf("%s: %w", path, err))
+			failed++
+		}
+	}
+	fmt.Printf("\nSuite execution finished in %s: %d of %d configs succeeded\n",
+		time.Since(started).Round(time.Second), len(daemonConfigFiles)-failed, len(daemonConfigFiles))
+}
+
+// loadDaemonConfig loads one config of the suite. With a directory, the
+// config's data directory and relative output paths are moved into it.
+func loadDaemonConfig(path, dir string) (*benchConfig, *properties.Properties, error) {
+	cfg, err := loadBenchConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	base, err := benchConfigProperties(cfg, daemonPropertyValues)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dir == "" {
+		return cfg, base, nil
+	}
+
+	base.Set("datadir", filepath.Join(dir, "data"))
+	cfg.Output.PlotsDir = inDir(dir, cfg.Output.PlotsDir)
+	if cfg.Output.Path == "" {
+		cfg.Output.Path = defaultResultsPath(cfg.DB, cfg.Output.Format)
+	}
+	cfg.Output.Path = inDir(dir, cfg.Output.Path)
+	return cfg, base, nil
+}
+
+// inDir resolves a relative path inside dir and leaves absolute ones alone
+func inDir(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// rotateDaemonRuns removes all but the newest keep execution directories
+// under root. Other files and directories are left alone.
+func rotateDaemonRuns(root string, keep int) error {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var runs []string
+	for _, e := range entries {
+		if _, err := time.Parse(daemonRunLayout, e.Name()); err == nil && e.IsDir() {
+			runs = append(runs, e.Name())
+		}
+	}
+	sort.Strings(runs)
+	for len(runs) > keep {
+		old := filepath.Join(root, runs[0])
+		if err := os.RemoveAll(old); err != nil {
+			return err
+		}
+		fmt.Printf("Removed old execution %s\n", old)
+		runs = runs[1:]
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// tui redraws a live dashboard during every run
+var tui bool
+
+// addDashboardFlag registers --tui. It is persistent so every benchmark
+// command shares it.
+func addDashboardFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&tui, "tui", false, "Show a live dashboard of throughput, latency and engine activity that refreshes in place during each run")
+}
+
+// dashboardOutput returns the terminal the dashboard is drawn on, or nil when
+// it is off or stdout is not a terminal, where redrawing in place would only
+// garble logs
+func dashboardOutput() io.Writer {
+	if !tui {
+		return nil
+	}
+	if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		fmt.Println("Warning: --tui needs a terminal; showing plain progress instead")
+		return nil
+	}
+	return os.Stdout
+}
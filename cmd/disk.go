@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/disk"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	diskDir          string
+	diskSize         int64
+	diskBlockSize    int
+	diskSeqBlockSize int
+	diskDuration     time.Duration
+	diskJSON         bool
+)
+
+var diskCmd = &cobra.Command{
+	Use:   "disk",
+	Short: "Measure the raw IO performance of the device under a data directory",
+	Long: `Measure the device under --dir without any engine, as a small fio would:
+sequential writes and reads of a test file, random reads and writes of single
+blocks, and the latency of appending a block and syncing it. IO bypasses the
+page cache where the filesystem allows it and is issued one request at a time.
+
+To keep the baseline with engine results, set -p disk_baseline=true on a run
+instead: its data directory's device is measured before the workload.
+
+  godb-bench disk --dir /nvme/godb-bench --size 1073741824`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := disk.ExistingDir(diskDir)
+		if err != nil {
+			fmt.Printf("Failed to measure disk: %v\n", err)
+			os.Exit(1)
+		}
+		if !diskJSON {
+			fmt.Printf("Measuring %s...\n", dir)
+		}
+		baseline, err := disk.Measure(disk.Config{
+			Dir:          dir,
+			FileSize:     diskSize,
+			BlockSize:    diskBlockSize,
+			SeqBlockSize: diskSeqBlockSize,
+			Duration:     diskDuration,
+		})
+		if err != nil {
+			fmt.Printf("Failed to measure disk: %v\n", err)
+			os.Exit(1)
+		}
+
+		if diskJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(baseline); err != nil {
+				fmt.Printf("Failed to encode results: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		metrics.PrintDiskBaseline(baseline)
+	},
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	gateBaseline      string
+	gateCurrent       string
+	gateMaxRegression string
+	gateTracked       []string
+	gateThresholds    []string
+	gateReportPath    string
+	gateAlpha         float64
+)
+
+var gateCmd = &cobra.Command{
+	Use:   "gate",
+	Short: "Fail when results regress beyond a threshold against a baseline",
+	Long: `Compare a results file against a baseline (both written with -o json) and
+exit with status 1 if any tracked metric regresses by more than its threshold.
+Cross-run aggregates are compared when both files have them (--runs > 1),
+otherwise runs are matched by name. With aggregates of at least two runs on
+each side, a change beyond its threshold only fails when a Mann-Whitney U test
+of the per-run values finds it significant at --alpha. A machine-readable
+report of every check is written to --report.
+
+  godb-bench gate --baseline main.json --current pr.json --max-regression 5% --threshold p99_us=10%`,
+	Run: func(cmd *cobra.Command, args []string) {
+		thresholds, err := gateThresholdsFromFlags()
+		if err != nil {
+			fmt.Printf("Invalid thresholds: %v\n", err)
+			os.Exit(1)
+		}
+
+		baseline, err := metrics.ReadReport(gateBaseline)
+		if err != nil {
+			fmt.Printf("Failed to read baseline: %v\n", err)
+			os.Exit(1)
+		}
+		current, err := metrics.ReadReport(gateCurrent)
+		if err != nil {
+			fmt.Printf("Failed to read current results: %v\n", err)
+			os.Exit(1)
+		}
+
+		report, err := metrics.ComparePerformance(baseline, current, thresholds, gateAlpha)
+		if err != nil {
+			fmt.Printf("Failed to compare results: %v\n", err)
+			os.Exit(1)
+		}
+		metrics.PrintGateReport(report)
+
+		if gateReportPath != "" {
+			if err := writeGateReport(report, gateReportPath); err != nil {
+				fmt.Printf("Failed to write gate report: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if !report.Passed {
+			os.Exit(1)
+		}
+	},
+}
+
+// gateThresholdsFromFlags applies --max-regression to every tracked metric,
+// then the per-metric --threshold overrides
+func gateThresholdsFromFlags() (map[string]float64, error) {
+	if gateBaseline == "" || gateCurrent == "" {
+		return nil, fmt.Errorf("--baseline and --current are required")
+	}
+
+	maxRegression, err := parsePercent(gateMaxRegression)
+	if err != nil {
+		return nil, fmt.Errorf("--max-regression: %w", err)
+	}
+	thresholds := make(map[string]float64, len(gateTracked))
+	for _, m := range gateTracked {
+		thresholds[m] = maxRegression
+	}
+
+	for _, t := range gateThresholds {
+		metric, value, ok := strings.Cut(t, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid threshold %q (expected metric=percent)", t)
+		}
+		v, err := parsePercent(value)
+		if err != nil {
+			return nil, fmt.Errorf("threshold for %s: %w", metric, err)
+		}
+		thresholds[metric] = v
+	}
+	return thresholds, nil
+}
+
+// parsePercent parses "5%" or "5" as 0.05
+func parsePercent(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	return v / 100, nil
+}
+
+// writeGateReport writes the report as JSON to path, or to stdout for "-"
+func writeGateReport(report *metrics.GateReport, path string) error {
+	w := io.Writer(os.Stdout)
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	if path != "-" {
+		fmt.Printf("Gate report written to %s\n", path)
+	}
+	return nil
+}
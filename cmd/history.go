@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/history"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	historyPath    string
+	historyDisable bool
+
+	// historyCommand is the command being run, recorded with every run
+	historyCommand string
+
+	historyDB       string
+	historyCmdName  string
+	historyRunName  string
+	historyRevision string
+	historySince    string
+	historyProps    []string
+	historyLimit    int
+)
+
+// addHistoryFlags registers the flags that control where runs are recorded.
+// They are persistent so every benchmark command shares them.
+func addHistoryFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&historyPath, "history-db", history.DefaultPath(), "SQLite database every run is recorded in")
+	cmd.PersistentFlags().BoolVar(&historyDisable, "no-history", false, "Do not record runs in the history database")
+}
+
+// recordHistory appends a finished run to the history database. Failures are
+// reported but never fail the benchmark.
+func recordHistory(name string, run *ycsbRun) {
+	recordResults(run.toResults(name))
+}
+
+// recordResults appends the results of a finished run to the history
+// database, like recordHistory
+func recordResults(results metrics.Results) {
+	if historyDisable {
+		return
+	}
+
+	store, err := history.Open(historyPath)
+	if err != nil {
+		fmt.Printf("Warning: failed to open history: %v\n", err)
+		return
+	}
+	defer store.Close()
+
+	id, err := store.Record(historyCommand, results)
+	if err != nil {
+		fmt.Printf("Warning: failed to record run in history: %v\n", err)
+		return
+	}
+	fmt.Printf("Recorded as run %d in %s\n", id, historyPath)
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List and filter past benchmark runs",
+	Long: `List runs recorded in the history database, most recent first. Every
+benchmark command records its runs unless --no-history is given. Use
+"history show <id>" for the full results of one run.
+
+  godb-bench history --db pebble --since 168h -p threadcount=16`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := historyFilter()
+		if err != nil {
+			fmt.Printf("Invalid filter: %v\n", err)
+			os.Exit(1)
+		}
+
+		store, err := history.Open(historyPath)
+		if err != nil {
+			fmt.Printf("Failed to open history: %v\n", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+
+		runs, err := store.List(filter)
+		if err != nil {
+			fmt.Printf("Failed to list runs: %v\n", err)
+			os.Exit(1)
+		}
+		printHistory(runs)
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the configuration, environment and results of a recorded run",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Printf("Invalid run ID %q\n", args[0])
+			os.Exit(1)
+		}
+
+		store, err := history.Open(historyPath)
+		if err != nil {
+			fmt.Printf("Failed to open history: %v\n", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+
+		run, err := store.Get(id)
+		if err != nil {
+			fmt.Printf("Failed to load run: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Run %d: %s (%s), recorded %s by %q\n", run.ID, run.Name, run.DB,
+			run.RecordedAt.Local().Format(time.DateTime), run.Command)
+		fmt.Println("\nProperties:")
+		keys := make([]string, 0, len(run.Properties))
+		for k := range run.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("  %-40s %s\n", k, run.Properties[k])
+		}
+		run.Environment.Print()
+		metrics.PrintMetricsTable(run.Operations)
+		metrics.PrintOperationStatistics(run.Statistics)
+	},
+}
+
+// historyFilter builds the list filter from the flags
+func historyFilter() (history.Filter, error) {
+	filter := history.Filter{
+		DB:       historyDB,
+		Command:  historyCmdName,
+		Name:     historyRunName,
+		Revision: historyRevision,
+		Limit:    historyLimit,
+	}
+
+	if historySince != "" {
+		if d, err := time.ParseDuration(historySince); err == nil {
+			filter.Since = time.Now().Add(-d)
+		} else if t, err := time.ParseInLocation(time.DateOnly, historySince, time.Local); err == nil {
+			filter.Since = t
+		} else {
+			return filter, fmt.Errorf("--since must be a duration such as 24h or a date such as 2006-01-02, got %q", historySince)
+		}
+	}
+
+	if len(historyProps) > 0 {
+		filter.Properties = make(map[string]string, len(historyProps))
+		for _, p := range historyProps {
+			k, v, ok := strings.Cut(p, "=")
+			if !ok {
+				return filter, fmt.Errorf("invalid property format: %s", p)
+			}
+			filter.Properties[k] = v
+		}
+	}
+
+	return filter, nil
+}
+
+// printHistory prints one line per run with its TOTAL metrics
+func printHistory(runs []history.Summary) {
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded")
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %6s │ %-16s │ %-16s │ %-14s │ %-8s │ %-12s │ %10s │ %8s │ %8s │ %9s │\n",
+		"ID", "Recorded", "Command", "Run", "DB", "Revision", "OPS", "Avg(µs)", "p99(µs)", "p99.9(µs)")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, r := range runs {
+		revision := r.VCSRevision
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		if revision == "" {
+			revision = "-"
+		}
+
+		ops, avg, p99, p999 := "-", "-", "-", "-"
+		if t := r.Total; t != nil {
+			ops = fmt.Sprintf("%.1f", t.OPS)
+			avg = strconv.FormatInt(t.Avg, 10)
+			p99 = strconv.FormatInt(t.P99, 10)
+			p999 = strconv.FormatInt(t.P999, 10)
+		}
+
+		fmt.Printf("│ %6d │ %-16s │ %-16s │ %-14s │ %-8s │ %-12s │ %10s │ %8s │ %8s │ %9s │\n",
+			r.ID, r.RecordedAt.Local().Format("2006-01-02 15:04"), truncate(r.Command, 16), truncate(r.Name, 14),
+			truncate(r.DB, 8), revision, ops, avg, p99, p999)
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
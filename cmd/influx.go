@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	influxURL    string
+	influxOrg    string
+	influxBucket string
+	influxToken  string
+)
+
+// addInfluxFlags registers the flags that write every run to InfluxDB. They
+// are persistent so every benchmark command shares them, and default to the
+// INFLUX_* environment variables the influx CLI reads.
+func addInfluxFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&influxURL, "influx-url", os.Getenv("INFLUX_HOST"), "InfluxDB server every run's per-interval and final metrics are written to, e.g. http://localhost:8086")
+	cmd.PersistentFlags().StringVar(&influxOrg, "influx-org", os.Getenv("INFLUX_ORG"), "InfluxDB organization")
+	cmd.PersistentFlags().StringVar(&influxBucket, "influx-bucket", os.Getenv("INFLUX_BUCKET"), "InfluxDB bucket (database/retention-policy on InfluxDB 1.8)")
+	cmd.PersistentFlags().StringVar(&influxToken, "influx-token", "", "InfluxDB API token (default $INFLUX_TOKEN)")
+}
+
+// reportInflux writes a finished run to InfluxDB, if a server is set.
+// Failures are reported but never fail the benchmark.
+func reportInflux(name string, run *ycsbRun) {
+	reportInfluxResults(run.toResults(name), run.End)
+}
+
+// reportInfluxResults writes the results of a run that finished at end, like
+// reportInflux
+func reportInfluxResults(results metrics.Results, end time.Time) {
+	if influxURL == "" {
+		return
+	}
+	if influxBucket == "" {
+		fmt.Println("Warning: failed to write run to InfluxDB: --influx-bucket is required")
+		return
+	}
+
+	token := influxToken
+	if token == "" {
+		token = os.Getenv("INFLUX_TOKEN")
+	}
+	reporter := metrics.NewInfluxReporter(influxURL, influxOrg, influxBucket, token)
+	if err := reporter.Report(context.Background(), results, end); err != nil {
+		fmt.Printf("Warning: failed to write run to InfluxDB: %v\n", err)
+		return
+	}
+	fmt.Printf("Written to InfluxDB bucket %s at %s\n", influxBucket, reporter.URL)
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// defaultResultsDir is the root of the run directories
+const defaultResultsDir = "./results"
+
+// runIDLayout starts every run ID, so the run directories of a workload sort
+// by time
+const runIDLayout = "20060102-150405"
+
+// Files of a run directory besides plots, samples and profiles
+const (
+	runLogFile    = "output.log"
+	runLatestLink = "latest"
+)
+
+// runLayout places the artifacts of one invocation under
+// <root>/<db>/<workload>/<run-id>/
+type runLayout struct {
+	id  string
+	dir string
+}
+
+// newRunID returns a run ID: the start time followed by a random suffix, so
+// invocations started within the same second still differ
+func newRunID() string {
+	return time.Now().Format(runIDLayout) + "-" + metrics.NewRunID()[:8]
+}
+
+// newRunLayout returns the layout of a new run of the workload in props
+// against dbName. Nothing is created until the run starts.
+func newRunLayout(root, dbName string, props *properties.Properties) runLayout {
+	id := newRunID()
+	return runLayout{id: id, dir: filepath.Join(root, dbName, workloadDirName(props), id)}
+}
+
+// workloadDirName names the workload's directory after its workload file,
+// without the extension
+func workloadDirName(props *properties.Properties) string {
+	name := filepath.Base(props.GetString(bench.WorkloadFileProperty, "workload"))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if name == "" || name == "." {
+		return "workload"
+	}
+	return name
+}
+
+// file returns the path of name in the run directory
+func (l runLayout) file(name string) string {
+	return filepath.Join(l.dir, name)
+}
+
+// start creates the run directory and returns the run's log, which writes to
+// stdout and to the log file in the directory until the returned function is
+// called
+func (l runLayout) start() (io.Writer, func(), error) {
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create run directory %s: %w", l.dir, err)
+	}
+	path := l.file(runLogFile)
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create log %s: %w", path, err)
+	}
+	log := io.MultiWriter(os.Stdout, f)
+	fmt.Fprintf(log, "Run ID: %s\n", l.id)
+	fmt.Fprintf(log, "Artifacts are written to %s\n", l.dir)
+	return log, func() { f.Close() }, nil
+}
+
+// linkLatest points the latest link next to the run directory at it. The
+// link is relative, so the results tree can be moved or archived as a whole.
+func (l runLayout) linkLatest() error {
+	link := filepath.Join(filepath.Dir(l.dir), runLatestLink)
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(l.id, tmp); err != nil {
+		return fmt.Errorf("failed to link %s: %w", link, err)
+	}
+	// Renaming over the old link replaces it atomically, so scripts never see
+	// it missing
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to link %s: %w", link, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	otlpEndpoint string
+	otlpHeaders  []string
+)
+
+// addOTLPFlags registers the flags that push every run to an OpenTelemetry
+// collector. They are persistent so every benchmark command shares them, and
+// default to the standard OTEL_EXPORTER_OTLP_* environment variables; the
+// headers are read from theirs only when exporting, so --help never prints
+// the credentials they usually carry.
+func addOTLPFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&otlpEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP collector every run's latency histograms and span are pushed to, e.g. http://localhost:4318")
+	cmd.PersistentFlags().StringArrayVar(&otlpHeaders, "otlp-header", nil, "Header sent to the OTLP collector, e.g. authorization=\"Bearer <token>\" (repeatable, default $OTEL_EXPORTER_OTLP_HEADERS)")
+}
+
+// exportOTLP pushes a finished run to the OTLP collector, if one is set.
+// Failures are reported but never fail the benchmark.
+func exportOTLP(name string, run *ycsbRun) {
+	exportOTLPResults(run.toResults(name), run.Tracker.Histograms, run.Start, run.End)
+}
+
+// exportOTLPResults pushes the results of a finished run, like exportOTLP.
+// histograms buckets its latencies.
+func exportOTLPResults(results metrics.Results, histograms func(bounds []float64) []metrics.LatencyHistogram, start, end time.Time) {
+	if otlpEndpoint == "" {
+		return
+	}
+
+	flags := otlpHeaders
+	if len(flags) == 0 {
+		if v := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); v != "" {
+			flags = []string{v}
+		}
+	}
+	headers, err := metrics.ParseOTLPHeaders(flags)
+	if err != nil {
+		fmt.Printf("Warning: failed to export run to OTLP: %v\n", err)
+		return
+	}
+	exporter := metrics.NewOTLPExporter(otlpEndpoint, headers)
+	if err := exporter.Export(context.Background(), results, histograms(exporter.Bounds), start, end); err != nil {
+		fmt.Printf("Warning: failed to export run to OTLP: %v\n", err)
+		return
+	}
+	fmt.Printf("Exported to OTLP collector %s\n", exporter.Endpoint)
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gonum.org/v1/plot/vg"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// plotOptions controls whether, where, in which formats and in which style
+// plots are written, and whether raw samples are saved next to them
+type plotOptions struct {
+	enabled bool
+	dir     string
+	formats []string
+	samples bool
+	retain  bool // keep samples for other outputs that need them, such as criterion results
+
+	width       float64 // inches
+	height      float64 // inches
+	pointRadius float64 // points
+	palette     string
+	title       string
+	theme       string
+	logY        bool
+	maxPoints   int
+	outliers    bool
+}
+
+// addPlotFlags registers --plots, --plots-dir, --plot-formats, --save-samples
+// and the plot style flags on cmd
+func addPlotFlags(cmd *cobra.Command, o *plotOptions, defaultDir string) {
+	cmd.Flags().BoolVar(&o.enabled, "plots", true, "Generate plots")
+	cmd.Flags().StringVar(&o.dir, "plots-dir", defaultDir, "Directory for plots")
+	cmd.Flags().StringSliceVar(&o.formats, "plot-formats", []string{"png"}, "Plot image formats (png, svg, pdf, eps, jpg, tiff)")
+	cmd.Flags().BoolVar(&o.samples, "save-samples", true, "Save raw samples to "+metrics.SamplesFile+" in the plots directory for replot")
+	addPlotStyleFlags(cmd, o)
+}
+
+// addPlotStyleFlags registers the --plot-* flags that control how plots look
+func addPlotStyleFlags(cmd *cobra.Command, o *plotOptions) {
+	def := metrics.DefaultPlotStyle()
+	cmd.Flags().Float64Var(&o.width, "plot-width", float64(def.Width/vg.Inch), "Plot width in inches")
+	cmd.Flags().Float64Var(&o.height, "plot-height", float64(def.Height/vg.Inch), "Plot height in inches")
+	cmd.Flags().Float64Var(&o.pointRadius, "plot-point-radius", float64(def.PointRadius), "Scatter point radius in points")
+	cmd.Flags().StringVar(&o.palette, "plot-palette", "default", "Series colors: "+strings.Join(metrics.PlotPaletteNames(), ", ")+", or a list of #rrggbb colors")
+	cmd.Flags().StringVar(&o.title, "plot-title", def.Title, "Plot title template; {op} and {chart} are replaced")
+	cmd.Flags().StringVar(&o.theme, "plot-theme", def.Theme, "Plot theme ("+strings.Join(metrics.PlotThemeNames(), " or ")+")")
+	cmd.Flags().BoolVar(&o.logY, "plot-log-y", false, "Draw the latency axis of scatter and line plots on a log scale")
+	cmd.Flags().BoolVar(&o.outliers, "plot-outliers", false, "Color mild and severe Tukey outliers in the sample times plot")
+	cmd.Flags().IntVar(&o.maxPoints, "plot-max-points", def.MaxPoints, "Most points drawn per scatter series; larger series keep each bucket's fastest and slowest sample (0 draws all)")
+}
+
+// validate checks the plot formats and style when plotting is enabled
+func (o plotOptions) validate() error {
+	if !o.enabled {
+		return nil
+	}
+	if err := metrics.ValidatePlotFormats(o.formats); err != nil {
+		return err
+	}
+	_, err := o.style()
+	return err
+}
+
+// style returns the plot style the options describe
+func (o plotOptions) style() (metrics.PlotStyle, error) {
+	palette, err := metrics.ParsePalette(o.palette)
+	if err != nil {
+		return metrics.PlotStyle{}, err
+	}
+	style := metrics.PlotStyle{
+		Width:       vg.Length(o.width) * vg.Inch,
+		Height:      vg.Length(o.height) * vg.Inch,
+		PointRadius: vg.Points(o.pointRadius),
+		Palette:     palette,
+		Title:       o.title,
+		Theme:       o.theme,
+		LogY:        o.logY,
+		MaxPoints:   o.maxPoints,
+		Outliers:    o.outliers,
+	}
+	return style, style.Validate()
+}
+
+// retainSamples reports whether runs must keep every sample rather than only
+// the streaming statistics
+func (o plotOptions) retainSamples() bool {
+	return o.enabled || o.samples || o.retain
+}
+
+// in returns the same options writing into a subdirectory of o.dir
+func (o plotOptions) in(elem ...string) plotOptions {
+	o.dir = filepath.Join(append([]string{o.dir}, elem...)...)
+	return o
+}
+
+// writeOverlayPlots draws the runs on shared charts, one series per run named
+// by names, when plotting is enabled and there is more than one run
+func writeOverlayPlots(runs []*ycsbRun, names []string, plots plotOptions) {
+	if !plots.enabled || len(runs) < 2 {
+		return
+	}
+
+	sets := make([]metrics.SampleSet, len(runs))
+	for i, run := range runs {
+		sets[i] = metrics.SampleSet{Name: names[i], Samples: run.Tracker.Samples()}
+	}
+
+	style, err := plots.style()
+	if err != nil {
+		fmt.Printf("Warning: failed to generate comparison plots: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nGenerating comparison plots in %s...\n", plots.dir)
+	if _, err := metrics.GenerateOverlayPlots(sets, plots.dir, plots.formats, style); err != nil {
+		fmt.Printf("Warning: failed to generate comparison plots: %v\n", err)
+	}
+}
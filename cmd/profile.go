@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// profileOptions selects the Go profiles captured during the measured window.
+// Empty paths disable the corresponding profile.
+type profileOptions struct {
+	cpu        string
+	mem        string
+	mutex      string
+	flamegraph bool // render the CPU profile as a flame graph next to the plots
+}
+
+// addProfileFlags registers --cpuprofile, --memprofile, --mutexprofile and
+// --flamegraph on cmd
+func addProfileFlags(cmd *cobra.Command, o *profileOptions) {
+	cmd.Flags().StringVar(&o.cpu, "cpuprofile", "", "Write a CPU profile of the measured window to this file")
+	cmd.Flags().StringVar(&o.mem, "memprofile", "", "Write a heap profile taken at the end of the measured window to this file")
+	cmd.Flags().StringVar(&o.mutex, "mutexprofile", "", "Write a mutex contention profile of the measured window to this file")
+	cmd.Flags().BoolVar(&o.flamegraph, "flamegraph", false, "Render a CPU profile of the measured window as folded stacks and an SVG flame graph in the plot directory")
+}
+
+// enabled reports whether any profile was requested
+func (o profileOptions) enabled() bool {
+	return o.cpu != "" || o.mem != "" || o.mutex != "" || o.flamegraph
+}
+
+// labeled returns the options with label inserted before each file extension,
+// e.g. cpu.pprof becomes cpu.run-2.pprof, so repeated runs keep their profiles
+func (o profileOptions) labeled(label string) profileOptions {
+	label = strings.ReplaceAll(label, string(filepath.Separator), "-")
+	relabel := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		ext := filepath.Ext(path)
+		return strings.TrimSuffix(path, ext) + "." + label + ext
+	}
+	return profileOptions{cpu: relabel(o.cpu), mem: relabel(o.mem), mutex: relabel(o.mutex), flamegraph: o.flamegraph}
+}
+
+// in returns the options with relative profile paths placed in dir
+func (o profileOptions) in(dir string) profileOptions {
+	place := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	return profileOptions{cpu: place(o.cpu), mem: place(o.mem), mutex: place(o.mutex), flamegraph: o.flamegraph}
+}
+
+// inPlots returns the options with the CPU profile a flame graph needs kept
+// in the plot directory, unless --cpuprofile places it elsewhere
+func (o profileOptions) inPlots(plots plotOptions) profileOptions {
+	if o.flamegraph && o.cpu == "" {
+		o.cpu = filepath.Join(plots.dir, "cpu.pprof")
+	}
+	return o
+}
+
+// profileWindow captures profiles between the end of warm-up and stop
+type profileWindow struct {
+	opts    profileOptions
+	timer   *time.Timer
+	mu      sync.Mutex
+	started bool
+	cpuFile *os.File
+	err     error
+}
+
+// startProfileWindow begins profiling once delay (the warm-up) has elapsed
+func startProfileWindow(opts profileOptions, delay time.Duration) *profileWindow {
+	w := &profileWindow{opts: opts}
+	if delay <= 0 {
+		w.begin()
+	} else {
+		w.timer = time.AfterFunc(delay, w.begin)
+	}
+	return w
+}
+
+// begin starts the CPU profile and mutex sampling
+func (w *profileWindow) begin() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.started = true
+	if w.opts.cpu != "" {
+		f, err := createProfileFile(w.opts.cpu)
+		if err != nil {
+			w.err = err
+			return
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			w.err = fmt.Errorf("failed to start CPU profile: %w", err)
+			return
+		}
+		w.cpuFile = f
+	}
+	if w.opts.mutex != "" {
+		// Sampling starts here, so the profile only covers the measured window
+		runtime.SetMutexProfileFraction(1)
+	}
+}
+
+// stop ends the window and writes every requested profile
+func (w *profileWindow) stop() error {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.started {
+		return fmt.Errorf("run ended before warm-up finished, no profiles written")
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.cpuFile != nil {
+		pprof.StopCPUProfile()
+		if err := w.cpuFile.Close(); err != nil {
+			return fmt.Errorf("failed to write CPU profile: %w", err)
+		}
+		fmt.Printf("CPU profile written to %s\n", w.opts.cpu)
+	}
+
+	if w.opts.mutex != "" {
+		err := writeProfile("mutex", w.opts.mutex)
+		runtime.SetMutexProfileFraction(0)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.opts.mem != "" {
+		// Get up-to-date statistics for the in-use heap
+		runtime.GC()
+		if err := writeProfile("heap", w.opts.mem); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeProfile writes the named runtime profile to path
+func writeProfile(name, path string) error {
+	f, err := createProfileFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := pprof.Lookup(name).WriteTo(f, 0); err != nil {
+		return fmt.Errorf("failed to write %s profile: %w", name, err)
+	}
+	fmt.Printf("%s profile written to %s\n", strings.ToUpper(name[:1])+name[1:], path)
+	return nil
+}
+
+// createProfileFile creates path and its parent directory
+func createProfileFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create profile %s: %w", path, err)
+	}
+	return f, nil
+}
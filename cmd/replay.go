@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/replay"
+)
+
+var (
+	replayTrace          string
+	replayDB             string
+	replayPropertyFile   string
+	replayPropertyValues []string
+	replayBlocks         int64
+	replayWarmUpBlocks   int64
+	replayFresh          bool
+	replayProgress       time.Duration
+	replayOutputFormat   string
+	replayOutputPath     string
+	replayPlots          plotOptions
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay the state writes of real blocks against a backend, one commit per block",
+	Long: `Replay the state writes of blocks exported to a trace file against a backend,
+committing each block before the next as a node importing the chain does, and
+report the distribution of per-block commit latencies and the throughput in
+MGas/s: the gas of the replayed blocks per second spent committing their state.
+Execution is not replayed, so it is the rate the storage could keep up with.
+
+The trace has one JSON object per block, in commit order, optionally gzipped.
+Keys and values are hex; a write without a value deletes its key:
+
+  {"number":19000000,"gas_used":14932207,"writes":[{"key":"0x..","value":"0x.."},{"key":"0x.."}]}
+
+Such traces can be exported from a node's state diffs, e.g. those of the
+prestateTracer in diff mode, with one key per account field and storage slot.
+
+The data directory (default /tmp/godb-bench-replay/<db>) is emptied first
+unless --fresh=false, so a trace can continue the state of a previous replay.
+
+  godb-bench replay --db pebble --trace blocks.jsonl.gz --warmup-blocks 1000`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if replayTrace == "" {
+			fmt.Println("Please specify a trace file using --trace")
+			os.Exit(1)
+		}
+		if replayOutputFormat != metrics.FormatTable && replayOutputFormat != metrics.FormatJSON {
+			fmt.Printf("Invalid output format: replay results are written as %s or %s, not %q\n",
+				metrics.FormatTable, metrics.FormatJSON, replayOutputFormat)
+			os.Exit(1)
+		}
+		if replayBlocks < 0 || replayWarmUpBlocks < 0 {
+			fmt.Println("Invalid options: --blocks and --warmup-blocks must not be negative")
+			os.Exit(1)
+		}
+		if err := replayPlots.validate(); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+
+		props := properties.NewProperties()
+		if replayPropertyFile != "" {
+			p, err := loadPropertyFile(replayPropertyFile)
+			if err != nil {
+				fmt.Printf("Failed to load properties: %v\n", err)
+				os.Exit(1)
+			}
+			props.Merge(p)
+		}
+		if err := applyPropertyOverrides(props, replayPropertyValues); err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		if _, ok := props.Get("datadir"); !ok {
+			props.Set("datadir", filepath.Join("/tmp/godb-bench-replay", replayDB))
+		}
+		bench.ApplyDefaults(replayDB, props)
+
+		result, err := runReplay(replayDB, props)
+		if err != nil {
+			fmt.Printf("Replay failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+		metrics.PrintBlockReplay(result)
+
+		if replayPlots.enabled {
+			style, err := replayPlots.style()
+			if err == nil {
+				_, err = metrics.GenerateBlockReplayPlot(result, replayPlots.dir, replayPlots.formats, style)
+			}
+			if err != nil {
+				fmt.Printf("Warning: failed to generate plots: %v\n", err)
+			} else {
+				fmt.Printf("Plots generated successfully in %s\n", replayPlots.dir)
+			}
+		}
+
+		out := resultsOutput{format: replayOutputFormat, path: replayOutputPath}
+		if err := out.write("replay", &metrics.Report{Replay: result}); err != nil {
+			fmt.Printf("Failed to write results: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// runReplay opens the backend and replays the trace against it. Interrupting
+// the replay keeps the blocks committed so far.
+func runReplay(dbName string, props *properties.Properties) (*metrics.BlockReplay, error) {
+	datadir := props.GetString("datadir", "")
+	if replayFresh {
+		if err := os.RemoveAll(datadir); err != nil {
+			return nil, fmt.Errorf("failed to clean data directory %s: %w", datadir, err)
+		}
+	}
+
+	creator := ycsb.GetDBCreator(dbName)
+	if creator == nil {
+		return nil, fmt.Errorf("DB creator for %s not found", dbName)
+	}
+	db, err := creator.Create(props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DB: %w", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Replaying %s against %s in %s...\n", replayTrace, dbName, datadir)
+	return replay.Run(ctx, db, replay.Config{
+		Trace:        replayTrace,
+		DB:           dbName,
+		Blocks:       replayBlocks,
+		WarmUpBlocks: replayWarmUpBlocks,
+		Progress:     replayProgress,
+		Log:          os.Stdout,
+	})
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	replotPlots      plotOptions
+	replotKinds      []string
+	replotStatistics bool
+	replotPerThread  bool
+	replotSeed       int64
+
+	replotResamples       int
+	replotConfidenceLevel float64
+)
+
+var replotCmd = &cobra.Command{
+	Use:   "replot <run-dir>",
+	Short: "Regenerate plots and statistics from the samples saved by a run",
+	Long: `Regenerate plots and statistics tables offline from the raw samples a run
+saved to ` + metrics.SamplesFile + ` in its plots directory, so plot styles and
+analysis options can be changed without running the benchmark again.
+
+  godb-bench replot ./pebbledb_benchmark_plots --plot-log-y --kinds pdf,summary
+  godb-bench replot ./pebbledb_benchmark_plots --plots=false --statistics`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runDir := args[0]
+		if !cmd.Flags().Changed("plots-dir") {
+			replotPlots.dir = runDir
+		}
+		if err := replotPlots.validate(); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+		if err := metrics.ValidatePlotKinds(replotKinds); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+		bootstrap := metrics.Bootstrap{
+			Resamples: replotResamples,
+			Level:     replotConfidenceLevel,
+			Seed:      replotSeed,
+			Seeded:    cmd.Flags().Changed("seed"),
+		}
+		if err := bootstrap.Validate(); err != nil {
+			fmt.Printf("Invalid statistics options: %v\n", err)
+			os.Exit(1)
+		}
+
+		path := filepath.Join(runDir, metrics.SamplesFile)
+		bp, err := metrics.ReadSamples(path)
+		if err != nil {
+			fmt.Printf("Failed to load samples: %v\n", err)
+			os.Exit(1)
+		}
+		bp.SetBootstrap(bootstrap)
+		total := 0
+		for _, samples := range bp.Samples() {
+			total += len(samples)
+		}
+		fmt.Printf("Loaded %d samples from %s\n", total, path)
+
+		if replotStatistics {
+			metrics.PrintOperationStatistics(bp.ComputeStatistics())
+		}
+		if replotPerThread {
+			metrics.PrintThreadStatistics(bp.ThreadStatistics())
+		}
+
+		if !replotPlots.enabled {
+			return
+		}
+		style, err := replotPlots.style()
+		if err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+		bp.SetStyle(style)
+		bp.SetKinds(replotKinds)
+
+		fmt.Printf("\nGenerating benchmark plots in %s...\n", replotPlots.dir)
+		if _, err := bp.GeneratePlots(replotPlots.dir, replotPlots.formats); err != nil {
+			fmt.Printf("Failed to generate plots: %v\n", err)
+			os.Exit(1)
+		}
+		if replotPerThread {
+			if _, err := bp.GenerateThreadPlots(replotPlots.dir, replotPlots.formats); err != nil {
+				fmt.Printf("Failed to generate per-thread plots: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("Plots generated successfully in %s\n", replotPlots.dir)
+	},
+}
@@ -3,8 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/cluster"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/disk"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
 )
 
 var RootCmd = &cobra.Command{
@@ -23,18 +29,240 @@ func Execute() {
 
 func initCommands() {
 	RootCmd.CompletionOptions.DisableDefaultCmd = true
+	addHistoryFlags(RootCmd)
+	addOTLPFlags(RootCmd)
+	addInfluxFlags(RootCmd)
+	addWebhookFlags(RootCmd)
+	addDashboardFlag(RootCmd)
+	RootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		historyCommand = strings.TrimPrefix(cmd.CommandPath(), RootCmd.Name()+" ")
+	}
 
-	// Add pebble command and its subcommands
+	// Add ycsb command, and the per-backend commands it keeps working
+	RootCmd.AddCommand(newYCSBCmd())
 	RootCmd.AddCommand(pebbleCmd)
-	pebbleCmd.AddCommand(ycsbCmd)
-	ycsbCmd.Flags().StringVarP(&workloadFile, "workload", "w", "", "Path to the YCSB workload file")
-	ycsbCmd.Flags().StringVarP(&propertyFile, "property_file", "P", "", "Path to the YCSB property file")
-	ycsbCmd.Flags().StringArrayVarP(&propertyValues, "prop", "p", nil, "YCSB property (e.g. -p key=value)")
-
-	// Add triedb command and its subcommands
+	pebbleCmd.AddCommand(newBackendYCSBCmd("pebble", "Run the YCSB benchmark on PebbleDB (ycsb --db pebble)", "./pebbledb_benchmark_plots"))
+	pebbleCmd.AddCommand(pebbleCacheSweepCmd)
+	pebbleCacheSweepCmd.Flags().StringVarP(&cacheSweepWorkload, "workload", "w", "", "Path to the workload file")
+	pebbleCacheSweepCmd.Flags().StringVarP(&cacheSweepPropertyFile, "property_file", "P", "", "Path to a property file")
+	pebbleCacheSweepCmd.Flags().StringArrayVarP(&cacheSweepPropertyValues, "prop", "p", nil, "YCSB property applied to every run (e.g. -p recordcount=1000000)")
+	pebbleCacheSweepCmd.Flags().Int64SliceVar(&cacheSweepSizes, "cache-sizes", defaultCacheSweepSizes, "Block cache sizes in bytes, one run each")
+	pebbleCacheSweepCmd.Flags().BoolVar(&cacheSweepFresh, "fresh", true, "Empty the data directory and load the records first")
+	pebbleCacheSweepCmd.Flags().StringVarP(&cacheSweepOutputFormat, "output", "o", "table", "Results format: table, json, csv, markdown or criterion")
+	pebbleCacheSweepCmd.Flags().StringVar(&cacheSweepOutputPath, "output-path", "", "File (directory for csv) for the results (default ./cache_sweep_results.<ext>)")
+	pebbleCacheSweepCmd.Flags().BoolVar(&cacheSweepPlots.enabled, "plots", true, "Generate plots")
+	pebbleCacheSweepCmd.Flags().StringVar(&cacheSweepPlots.dir, "plots-dir", "./cache_sweep_plots", "Directory for plots")
+	pebbleCacheSweepCmd.Flags().StringSliceVar(&cacheSweepPlots.formats, "plot-formats", []string{"png"}, "Plot image formats (png, svg, pdf, eps, jpg, tiff)")
+	addPlotStyleFlags(pebbleCacheSweepCmd, &cacheSweepPlots)
 	RootCmd.AddCommand(triedbCmd)
-	triedbCmd.AddCommand(triedbYcsbCmd)
-	triedbYcsbCmd.Flags().StringVarP(&triedbWorkloadFile, "workload", "w", "", "Path to the YCSB workload file")
-	triedbYcsbCmd.Flags().StringVarP(&triedbPropertyFile, "property_file", "P", "", "Path to the YCSB property file")
-	triedbYcsbCmd.Flags().StringArrayVarP(&triedbPropertyValues, "prop", "p", nil, "YCSB property (e.g. -p key=value)")
+	triedbCmd.AddCommand(newBackendYCSBCmd("triedb", "Run the YCSB benchmark on TrieDB (ycsb --db triedb)", "./triedb_benchmark_plots"))
+
+	// Add config-driven run command
+	RootCmd.AddCommand(runCmd)
+	runCmd.Flags().StringVarP(&runConfigFile, "config", "c", "", "Path to the benchmark config file (YAML or JSON)")
+	runCmd.Flags().StringArrayVarP(&runPropertyValues, "prop", "p", nil, "YCSB property applied to every phase (e.g. -p key=value)")
+	addPlotFlags(runCmd, &runPlots, "./<db>_benchmark_plots")
+	addProfileFlags(runCmd, &runProfiles)
+	addDryRunFlag(runCmd)
+
+	// Add run-all command
+	RootCmd.AddCommand(runAllCmd)
+	runAllCmd.Flags().StringVarP(&runAllWorkloadFile, "workload", "w", "", "Path to the YCSB workload file")
+	runAllCmd.Flags().StringVarP(&runAllPropertyFile, "property_file", "P", "", "Path to the YCSB property file")
+	runAllCmd.Flags().StringArrayVarP(&runAllPropertyValues, "prop", "p", nil, "YCSB property (e.g. -p key=value)")
+	runAllCmd.Flags().StringSliceVar(&runAllBackends, "dbs", nil, "Backends to run, in order; the first is the baseline (default: all registered)")
+	addPlotFlags(runAllCmd, &runAllPlots, "./run_all_benchmark_plots")
+	addProfileFlags(runAllCmd, &runAllProfiles)
+	addDryRunFlag(runAllCmd)
+
+	// Add daemon command
+	RootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().StringArrayVarP(&daemonConfigFiles, "config", "c", nil, "Config file of the suite, run in order (repeatable)")
+	daemonCmd.Flags().StringArrayVarP(&daemonPropertyValues, "prop", "p", nil, "YCSB property applied to every phase of every config (e.g. -p key=value)")
+	daemonCmd.Flags().StringVar(&daemonSchedule, "schedule", "@daily", "When to run the suite: a cron expression (e.g. \"0 2 * * *\"), @daily, @hourly or \"@every 6h\"")
+	daemonCmd.Flags().StringVar(&daemonRoot, "root", "./godb-bench-daemon", "Directory holding a subdirectory per execution of the suite")
+	daemonCmd.Flags().IntVar(&daemonKeep, "keep", 7, "Number of execution directories to keep; older ones are removed")
+	daemonCmd.Flags().BoolVar(&daemonRunNow, "run-now", false, "Run the suite once immediately, then follow the schedule")
+	addDryRunFlag(daemonCmd)
+
+	// Add distributed worker and coordinator commands
+	RootCmd.AddCommand(workerCmd)
+	workerCmd.Flags().StringVar(&workerListen, "listen", fmt.Sprintf("127.0.0.1:%d", cluster.DefaultPort), "Address to serve coordinators on, e.g. :7070 for every interface")
+	workerCmd.Flags().StringArrayVarP(&workerPropertyValues, "prop", "p", nil, "YCSB property overriding those of every dispatched workload (e.g. -p datadir=/nvme/bench)")
+	RootCmd.AddCommand(coordinatorCmd)
+	coordinatorCmd.Flags().StringSliceVar(&coordinatorWorkers, "workers", nil, fmt.Sprintf("Worker addresses, host[:port] (port default %d)", cluster.DefaultPort))
+	coordinatorCmd.Flags().StringVar(&coordinatorDB, "db", "pebble", "Backend the workers run the workload against")
+	coordinatorCmd.Flags().StringVarP(&coordinatorWorkloadFile, "workload", "w", "", "Path to the YCSB workload file")
+	coordinatorCmd.Flags().StringVarP(&coordinatorPropertyFile, "property_file", "P", "", "Path to the YCSB property file")
+	coordinatorCmd.Flags().StringArrayVarP(&coordinatorPropertyValues, "prop", "p", nil, "YCSB property (e.g. -p key=value)")
+	coordinatorCmd.Flags().BoolVar(&coordinatorPartition, "partition", false, "Split the load's key range, operationcount and target_ops across the workers, for a backend they share")
+	coordinatorCmd.Flags().DurationVar(&coordinatorStartDelay, "start-delay", cluster.DefaultStartDelay, "How long after dispatching the workers start the workload together")
+	coordinatorCmd.Flags().StringVarP(&coordinatorOutputFormat, "output", "o", "table", "Results format: table, json, csv, markdown or criterion")
+	coordinatorCmd.Flags().StringVar(&coordinatorOutputPath, "output-path", "", "File (json, markdown) or directory (csv, criterion) for results (default depends on the format)")
+	addPlotFlags(coordinatorCmd, &coordinatorPlots, "./coordinator_benchmark_plots")
+	addDryRunFlag(coordinatorCmd)
+
+	// Add interleaved A/B command
+	RootCmd.AddCommand(abCmd)
+	abCmd.Flags().StringVarP(&abWorkloadFile, "workload", "w", "", "Path to the YCSB workload file")
+	abCmd.Flags().StringVarP(&abPropertyFile, "property_file", "P", "", "Path to the YCSB property file")
+	abCmd.Flags().StringArrayVarP(&abPropertyValues, "prop", "p", nil, "YCSB property of both sides (e.g. -p key=value)")
+	abCmd.Flags().StringVar(&abDB, "db", "pebble", "Backend of side A")
+	abCmd.Flags().StringVar(&abDBB, "db-b", "", "Backend of side B (default the one of side A)")
+	abCmd.Flags().StringArrayVarP(&abAValues, "a-prop", "a", nil, "YCSB property of side A only (e.g. -a pebble.cache_size=1073741824)")
+	abCmd.Flags().StringArrayVarP(&abBValues, "b-prop", "b", nil, "YCSB property of side B only")
+	abCmd.Flags().Int64Var(&abChunk, "chunk", bench.DefaultChunkSize, "Consecutive operations each side runs before the other takes over")
+	abCmd.Flags().BoolVar(&abLoad, "load", true, "Load each side into a fresh data directory before the run")
+	abCmd.Flags().StringVarP(&abOutputFormat, "output", "o", "table", "Results format: table, json, csv, markdown or criterion")
+	abCmd.Flags().StringVar(&abOutputPath, "output-path", "", "File (json, markdown) or directory (csv, criterion) for results (default depends on the format)")
+	addPlotFlags(abCmd, &abPlots, "./ab_benchmark_plots")
+	addDryRunFlag(abCmd)
+
+	// Add block replay command
+	RootCmd.AddCommand(replayCmd)
+
+	// Add TrieDB account update workload
+	triedbCmd.AddCommand(triedbBenchCmd)
+	triedbBenchCmd.AddCommand(triedbAccountsCmd)
+	triedbAccountsCmd.Flags().StringVarP(&accountsPropertyFile, "property_file", "P", "", "Path to a property file of the backend")
+	triedbAccountsCmd.Flags().StringArrayVarP(&accountsPropertyValues, "prop", "p", nil, "Backend property (e.g. -p datadir=/data/triedb)")
+	triedbAccountsCmd.Flags().Int64Var(&accountsCount, "accounts", 100000, "Accounts the updated ones are drawn from")
+	triedbAccountsCmd.Flags().IntVar(&accountsPerCommit, "accounts-per-commit", 100, "Distinct accounts updated by every commit")
+	triedbAccountsCmd.Flags().Int64Var(&accountsCommits, "commits", 1000, "Commits to measure after the warm-up")
+	triedbAccountsCmd.Flags().Int64Var(&accountsWarmUpCommits, "warmup-commits", 0, "Commits made before the measured ones and left out of the results")
+	triedbAccountsCmd.Flags().Int64Var(&accountsSeed, "seed", 1, "Seed of the accounts and amounts drawn")
+	triedbAccountsCmd.Flags().BoolVar(&accountsFresh, "fresh", true, "Empty the data directory first")
+	triedbAccountsCmd.Flags().DurationVar(&accountsProgress, "progress-interval", metrics.DefaultProgressInterval, "How often a progress line is printed (0 disables it)")
+	triedbAccountsCmd.Flags().StringVarP(&accountsOutputFormat, "output", "o", "table", "Results format: table or json")
+	triedbAccountsCmd.Flags().StringVar(&accountsOutputPath, "output-path", "", "File for json results (default ./accounts_results.json)")
+	triedbBenchCmd.AddCommand(triedbSelfDestructCmd)
+	triedbSelfDestructCmd.Flags().StringVarP(&selfDestructPropertyFile, "property_file", "P", "", "Path to a property file of the backend")
+	triedbSelfDestructCmd.Flags().StringArrayVarP(&selfDestructPropertyValues, "prop", "p", nil, "Backend property (e.g. -p datadir=/data/triedb)")
+	triedbSelfDestructCmd.Flags().IntVar(&selfDestructAccounts, "accounts", 100, "Accounts created and deleted at every storage size")
+	triedbSelfDestructCmd.Flags().IntSliceVar(&selfDestructSlots, "slots", []int{10, 100, 1000, 10000}, "Storage sizes, in slots per account")
+	triedbSelfDestructCmd.Flags().Int64Var(&selfDestructSeed, "seed", 1, "Seed of the values stored")
+	triedbSelfDestructCmd.Flags().BoolVar(&selfDestructFresh, "fresh", true, "Empty the data directory first")
+	triedbSelfDestructCmd.Flags().StringVarP(&selfDestructOutputFormat, "output", "o", "table", "Results format: table or json")
+	triedbSelfDestructCmd.Flags().StringVar(&selfDestructOutputPath, "output-path", "", "File for json results (default ./selfdestruct_results.json)")
+	triedbBenchCmd.AddCommand(triedbReadersCmd)
+	triedbReadersCmd.Flags().StringVarP(&readersPropertyFile, "property_file", "P", "", "Path to a property file of the backend")
+	triedbReadersCmd.Flags().StringArrayVarP(&readersPropertyValues, "prop", "p", nil, "Backend property (e.g. -p datadir=/data/triedb)")
+	triedbReadersCmd.Flags().IntSliceVar(&readersCounts, "readers", []int{0, 1, 2, 4, 8, 16}, "Concurrent readers of every step")
+	triedbReadersCmd.Flags().Int64Var(&readersKeys, "keys", 100000, "Keys loaded first, then read and overwritten at random")
+	triedbReadersCmd.Flags().IntVar(&readersKeysPerRead, "keys-per-read", 10, "Keys read by every read transaction")
+	triedbReadersCmd.Flags().IntVar(&readersKeysPerCommit, "keys-per-commit", 100, "Keys written by every commit of the writer")
+	triedbReadersCmd.Flags().DurationVar(&readersStep, "step", defaultReaderStep, "How long every step runs")
+	triedbReadersCmd.Flags().Int64Var(&readersSeed, "seed", 1, "Seed of the keys and values drawn")
+	triedbReadersCmd.Flags().BoolVar(&readersFresh, "fresh", true, "Empty the data directory first")
+	triedbReadersCmd.Flags().StringVarP(&readersOutputFormat, "output", "o", "table", "Results format: table or json")
+	triedbReadersCmd.Flags().StringVar(&readersOutputPath, "output-path", "", "File for json results (default ./readers_results.json)")
+	triedbReadersCmd.Flags().BoolVar(&readersPlots.enabled, "plots", true, "Generate plots")
+	triedbReadersCmd.Flags().StringVar(&readersPlots.dir, "plots-dir", "./readers_benchmark_plots", "Directory for plots")
+	triedbReadersCmd.Flags().StringSliceVar(&readersPlots.formats, "plot-formats", []string{"png"}, "Plot image formats (png, svg, pdf, eps, jpg, tiff)")
+	addPlotStyleFlags(triedbReadersCmd, &readersPlots)
+	replayCmd.Flags().StringVar(&replayTrace, "trace", "", "Trace file of the blocks' state writes (JSON lines, optionally gzipped)")
+	replayCmd.Flags().StringVar(&replayDB, "db", "pebble", "Backend to replay against")
+	replayCmd.Flags().StringVarP(&replayPropertyFile, "property_file", "P", "", "Path to a property file of the backend")
+	replayCmd.Flags().StringArrayVarP(&replayPropertyValues, "prop", "p", nil, "Backend property (e.g. -p pebble.cache_size=1073741824)")
+	replayCmd.Flags().Int64Var(&replayBlocks, "blocks", 0, "Blocks to measure after the warm-up (0 for the rest of the trace)")
+	replayCmd.Flags().Int64Var(&replayWarmUpBlocks, "warmup-blocks", 0, "Blocks committed before the measured ones and left out of the results")
+	replayCmd.Flags().BoolVar(&replayFresh, "fresh", true, "Empty the data directory before replaying")
+	replayCmd.Flags().DurationVar(&replayProgress, "progress-interval", metrics.DefaultProgressInterval, "How often a progress line is printed (0 disables it)")
+	replayCmd.Flags().StringVarP(&replayOutputFormat, "output", "o", "table", "Results format: table or json")
+	replayCmd.Flags().StringVar(&replayOutputPath, "output-path", "", "File for json results (default ./replay_results.json)")
+	replayCmd.Flags().BoolVar(&replayPlots.enabled, "plots", true, "Generate plots")
+	replayCmd.Flags().StringVar(&replayPlots.dir, "plots-dir", "./replay_benchmark_plots", "Directory for plots")
+	replayCmd.Flags().StringSliceVar(&replayPlots.formats, "plot-formats", []string{"png"}, "Plot image formats (png, svg, pdf, eps, jpg, tiff)")
+	addPlotStyleFlags(replayCmd, &replayPlots)
+
+	// Add disk baseline command
+	RootCmd.AddCommand(diskCmd)
+	diskCmd.Flags().StringVar(&diskDir, "dir", "/tmp", "Directory on the device to measure (its nearest existing parent if it does not exist)")
+	diskCmd.Flags().Int64Var(&diskSize, "size", disk.DefaultFileSize, "Size of the test file in bytes")
+	diskCmd.Flags().IntVar(&diskBlockSize, "block-size", disk.DefaultBlockSize, "Block size of random IO and fsynced writes in bytes")
+	diskCmd.Flags().IntVar(&diskSeqBlockSize, "seq-block-size", disk.DefaultSeqBlockSize, "Block size of sequential IO in bytes")
+	diskCmd.Flags().DurationVar(&diskDuration, "duration", disk.DefaultDuration, "How long each random IO and fsync test runs")
+	diskCmd.Flags().BoolVar(&diskJSON, "json", false, "Print the results as JSON")
+
+	// Add version command
+	RootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Print the environment as JSON")
+
+	// Add workload command and its subcommands
+	RootCmd.AddCommand(workloadCmd)
+	workloadCmd.AddCommand(workloadGenCmd)
+	workloadGenCmd.Flags().StringVar(&workloadGenPreset, "preset", "", "Start from a named preset (see --list-presets)")
+	workloadGenCmd.Flags().BoolVar(&workloadGenListPresets, "list-presets", false, "List the available presets and exit")
+	workloadGenCmd.Flags().StringVarP(&workloadGenOutput, "output", "o", "", "Write the workload to this file instead of stdout")
+	workloadGenCmd.Flags().Int64("recordcount", 1000, "Number of records to load")
+	workloadGenCmd.Flags().Int64("operationcount", 1000, "Number of operations to perform")
+	workloadGenCmd.Flags().Int64("fieldcount", 10, "Fields per record")
+	workloadGenCmd.Flags().Int64("fieldlength", 100, "Bytes per field")
+	workloadGenCmd.Flags().Float64("read", 0.5, "Read proportion")
+	workloadGenCmd.Flags().Float64("update", 0.5, "Update proportion")
+	workloadGenCmd.Flags().Float64("insert", 0, "Insert proportion")
+	workloadGenCmd.Flags().Float64("scan", 0, "Scan proportion")
+	workloadGenCmd.Flags().Float64("rmw", 0, "Read-modify-write proportion")
+	workloadGenCmd.Flags().Float64("partial-read", 0, "Proportion of reads of part of a value")
+	workloadGenCmd.Flags().Int64("partial-read-length", 4096, "Bytes read by each partial read")
+	workloadGenCmd.Flags().Float64("prefix-scan", 0, "Proportion of scans of every key under one prefix (needs --prefixes)")
+	workloadGenCmd.Flags().String("distribution", "uniform", "Request distribution: uniform, zipfian, latest, hotspot, sequential or exponential")
+	workloadGenCmd.Flags().Int64("max-scan-length", 1000, "Maximum records per scan")
+	workloadGenCmd.Flags().String("scan-length-distribution", "uniform", "Distribution of scan lengths up to the maximum: uniform or zipfian (mostly short)")
+	workloadGenCmd.Flags().String("insert-order", "hashed", "Key order of inserts: hashed, ordered or monotonic")
+	workloadGenCmd.Flags().Int64("hotset-keys", 0, "Keys of the hot set")
+	workloadGenCmd.Flags().Float64("hotset-fraction", 0, "Fraction of the operations on existing keys that go to the hot set")
+	workloadGenCmd.Flags().Int64("prefixes", 0, "Lay keys out under this many shared prefixes, as storage slots under accounts")
+	workloadGenCmd.Flags().Int64("prefix-keys", 0, "Keys per prefix; sets the record count to prefixes times this")
+	workloadGenCmd.Flags().Int64("tables", 1, "Spread the records over this many tables, each a namespace of its own")
+	workloadGenCmd.Flags().Float64("compression-ratio", 0, "Make values compress to about 1/ratio of their size; 1 is incompressible (default random letters)")
+
+	// Add clean command
+	RootCmd.AddCommand(cleanCmd)
+	cleanCmd.Flags().StringSliceVar(&cleanDatadirs, "datadir", nil, "Directory to remove (repeatable)")
+	cleanCmd.Flags().BoolVar(&cleanAll, "all", false, "Remove every default data and plot directory")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "List what would be removed without removing anything")
+
+	// Add history command and its subcommands
+	RootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyShowCmd)
+	historyCmd.Flags().StringVar(&historyDB, "db", "", "Only runs against this backend")
+	historyCmd.Flags().StringVar(&historyCmdName, "command", "", "Only runs of this command (e.g. \"pebble ycsb\", run, sweep)")
+	historyCmd.Flags().StringVar(&historyRunName, "run", "", "Only runs with this name (e.g. run-1, load, or a sweep tag)")
+	historyCmd.Flags().StringVar(&historyRevision, "revision", "", "Only runs built from a VCS revision starting with this")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only runs recorded within this duration (e.g. 24h) or since this date (2006-01-02)")
+	historyCmd.Flags().StringArrayVarP(&historyProps, "prop", "p", nil, "Only runs with this property value (e.g. -p threadcount=16)")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Show at most this many runs (0 for all)")
+
+	// Add gate command
+	RootCmd.AddCommand(gateCmd)
+	gateCmd.Flags().StringVar(&gateBaseline, "baseline", "", "Baseline results file (-o json)")
+	gateCmd.Flags().StringVar(&gateCurrent, "current", "", "Results file to check against the baseline (-o json)")
+	gateCmd.Flags().StringVar(&gateMaxRegression, "max-regression", "5%", "Largest allowed regression of every tracked metric")
+	gateCmd.Flags().StringSliceVar(&gateTracked, "metrics", []string{"ops", "p99_us"}, "Metrics to track: "+strings.Join(metrics.GateMetricNames(), ", "))
+	gateCmd.Flags().StringArrayVar(&gateThresholds, "threshold", nil, "Per-metric threshold overriding --max-regression (e.g. --threshold p99_us=10%); also tracks the metric")
+	gateCmd.Flags().Float64Var(&gateAlpha, "alpha", 0.05, "Significance level of the Mann-Whitney U test of per-run values (0 disables the test)")
+	gateCmd.Flags().StringVar(&gateReportPath, "report", "./gate_report.json", "Write the machine-readable report here (- for stdout, empty to skip)")
+
+	// Add replot command
+	RootCmd.AddCommand(replotCmd)
+	replotCmd.Flags().BoolVar(&replotPlots.enabled, "plots", true, "Generate plots")
+	replotCmd.Flags().StringVar(&replotPlots.dir, "plots-dir", "", "Directory for plots (default the run directory)")
+	replotCmd.Flags().StringSliceVar(&replotPlots.formats, "plot-formats", []string{"png"}, "Plot image formats (png, svg, pdf, eps, jpg, tiff)")
+	addPlotStyleFlags(replotCmd, &replotPlots)
+	replotCmd.Flags().StringSliceVar(&replotKinds, "kinds", nil, "Plots to draw: "+strings.Join(metrics.PlotKinds, ", ")+" (default all)")
+	replotCmd.Flags().BoolVar(&replotStatistics, "statistics", false, "Print criterion-style statistics with bootstrap confidence intervals")
+	replotCmd.Flags().BoolVar(&replotPerThread, "per-thread", false, "Print per-thread statistics and plot every thread's samples")
+	replotCmd.Flags().Int64Var(&replotSeed, "seed", 0, "Seed bootstrap resampling")
+	replotCmd.Flags().IntVar(&replotResamples, "resamples", metrics.DefaultBootstrapResamples, "Bootstrap resamples behind each confidence interval; fewer is faster and less precise")
+	replotCmd.Flags().Float64Var(&replotConfidenceLevel, "confidence-level", metrics.DefaultConfidenceLevel, "Confidence level of the bootstrap intervals (e.g. 0.99)")
+
+	// Add sweep command
+	RootCmd.AddCommand(sweepCmd)
+	sweepCmd.Flags().StringVarP(&sweepConfigFile, "config", "c", "", "Path to the sweep config file (JSON)")
+	sweepCmd.Flags().StringVarP(&sweepOutputDir, "output-dir", "o", "./sweep_results", "Directory for per-run plots, the combined report and curves")
+	sweepCmd.Flags().StringArrayVarP(&sweepPropertyValues, "prop", "p", nil, "YCSB property applied to every run (e.g. -p key=value)")
+	addPlotFlags(sweepCmd, &sweepPlots, "./sweep_results")
+	addProfileFlags(sweepCmd, &sweepProfiles)
+	addDryRunFlag(sweepCmd)
 }
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	runConfigFile     string
+	runPropertyValues []string
+	runPlots          plotOptions
+	runProfiles       profileOptions
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run a benchmark described by a YAML config file",
+	Long: `Run a benchmark described by a YAML (or JSON) config file, so complex setups
+can be checked in and reviewed.
+
+Example bench.yaml:
+
+  db: pebble
+  workload: workload.spec
+  properties:
+    recordcount: 100000
+    pebble.cache_size: 134217728
+  phases:
+    - name: load
+      load: true
+    - name: run
+      properties:
+        operationcount: 100000
+  repetitions: 3
+  fresh_datadir: true
+  output:
+    plots: true
+    plots_dir: ./bench_plots
+    plot_formats: [png, svg]
+    plot_style:
+      width: 10
+      height: 5
+      theme: dark
+    format: json
+    path: ./bench_results.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadBenchConfig(runConfigFile)
+		if err != nil {
+			fmt.Printf("Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Plot flags given on the command line win over the config file
+		if cmd.Flags().Changed("plots") {
+			cfg.Output.Plots = &runPlots.enabled
+		}
+		if cmd.Flags().Changed("plots-dir") {
+			cfg.Output.PlotsDir = runPlots.dir
+		}
+		if cmd.Flags().Changed("plot-formats") {
+			cfg.Output.PlotFormats = runPlots.formats
+		}
+		if cmd.Flags().Changed("save-samples") {
+			cfg.Output.Samples = &runPlots.samples
+		}
+		style := &cfg.Output.PlotStyle
+		if cmd.Flags().Changed("plot-width") {
+			style.Width = runPlots.width
+		}
+		if cmd.Flags().Changed("plot-height") {
+			style.Height = runPlots.height
+		}
+		if cmd.Flags().Changed("plot-point-radius") {
+			style.PointRadius = runPlots.pointRadius
+		}
+		if cmd.Flags().Changed("plot-palette") {
+			style.Palette = runPlots.palette
+		}
+		if cmd.Flags().Changed("plot-title") {
+			style.Title = runPlots.title
+		}
+		if cmd.Flags().Changed("plot-theme") {
+			style.Theme = runPlots.theme
+		}
+		if cmd.Flags().Changed("plot-log-y") {
+			style.LogY = runPlots.logY
+		}
+		if cmd.Flags().Changed("plot-max-points") {
+			style.MaxPoints = &runPlots.maxPoints
+		}
+		if cmd.Flags().Changed("plot-outliers") {
+			style.Outliers = runPlots.outliers
+		}
+		if err := cfg.Output.plotOptions().validate(); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+
+		base, err := benchConfigProperties(cfg, runPropertyValues)
+		if err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+
+		if dryRun {
+			datadir := defaultDatadir(cfg.DB, base)
+			var targets []dryRunTarget
+			for _, phase := range cfg.Phases {
+				targets = append(targets, dryRunTarget{
+					label:  fmt.Sprintf("%s phase %s", cfg.DB, phase.Name),
+					dbName: cfg.DB,
+					props:  phaseProperties(base, phase, datadir),
+				})
+			}
+			runDryRun(targets)
+			return
+		}
+
+		if err := runBenchConfig(cfg, base, runProfiles); err != nil {
+			fmt.Printf("Benchmark failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// benchConfigProperties returns the properties shared by every phase of cfg:
+// its workload and property files, its properties, and the command-line
+// overrides, which win over the config file
+func benchConfigProperties(cfg *benchConfig, overrides []string) (*properties.Properties, error) {
+	base, err := loadYCSBProperties(cfg.Workload, cfg.PropertyFile, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cfg.Properties {
+		base.Set(k, string(v))
+	}
+	if err := applyPropertyOverrides(base, overrides); err != nil {
+		return nil, err
+	}
+	return base, nil
+}
+
+// runBenchConfig executes every phase of the config, repeated as configured
+func runBenchConfig(cfg *benchConfig, base *properties.Properties, profiles profileOptions) error {
+	datadir := defaultDatadir(cfg.DB, base)
+	phaseRuns := make([][]*ycsbRun, len(cfg.Phases))
+	report := &metrics.Report{}
+
+	for rep := 1; rep <= cfg.Repetitions; rep++ {
+		if cfg.FreshDatadir {
+			if err := os.RemoveAll(datadir); err != nil {
+				return fmt.Errorf("failed to clean data directory %s: %w", datadir, err)
+			}
+		}
+
+		for i, phase := range cfg.Phases {
+			fmt.Println("\n" + strings.Repeat("=", 80))
+			if cfg.Repetitions > 1 {
+				fmt.Printf("Phase %s (repetition %d/%d)\n", phase.Name, rep, cfg.Repetitions)
+			} else {
+				fmt.Printf("Phase %s\n", phase.Name)
+			}
+			fmt.Println(strings.Repeat("=", 80))
+
+			props := phaseProperties(base, phase, datadir)
+			name := phase.Name
+			plots := cfg.Output.plotOptions().in(phase.Name)
+			if cfg.Repetitions > 1 {
+				name = fmt.Sprintf("%s/rep-%d", phase.Name, rep)
+				plots = plots.in(fmt.Sprintf("rep-%d", rep))
+			}
+
+			run, err := runYCSB(cfg.DB, props, plots, profiles.labeled(name), os.Stdout)
+			if err != nil {
+				return fmt.Errorf("phase %s: %w", phase.Name, err)
+			}
+			if err := run.abortError(); err != nil {
+				return fmt.Errorf("phase %s: %w", phase.Name, err)
+			}
+			phaseRuns[i] = append(phaseRuns[i], run)
+			recordHistory(name, run)
+			exportOTLP(name, run)
+			reportInflux(name, run)
+			notifyRun(name, run)
+
+			report.Runs = append(report.Runs, run.toResults(name))
+		}
+	}
+
+	if cfg.Repetitions > 1 {
+		for i, phase := range cfg.Phases {
+			fmt.Printf("\nPhase %s across %d repetitions:\n", phase.Name, cfg.Repetitions)
+			metrics.PrintRunsReport(runResults(phaseRuns[i]))
+			report.AddAggregate(phase.Name, runResults(phaseRuns[i]))
+		}
+	}
+
+	out := resultsOutput{format: cfg.Output.Format, path: cfg.Output.Path}
+	return out.write(cfg.DB, report)
+}
+
+// phaseProperties returns the properties of one phase: the shared base plus
+// the phase's own overrides, run against datadir
+func phaseProperties(base *properties.Properties, phase benchPhase, datadir string) *properties.Properties {
+	props := cloneProperties(base)
+	for k, v := range phase.Properties {
+		props.Set(k, string(v))
+	}
+	props.Set("datadir", datadir)
+	props.Set(prop.DoTransactions, fmt.Sprint(!phase.Load))
+	return props
+}
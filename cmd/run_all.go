@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	runAllWorkloadFile   string
+	runAllPropertyFile   string
+	runAllPropertyValues []string
+	runAllBackends       []string
+	runAllPlots          plotOptions
+	runAllProfiles       profileOptions
+)
+
+var runAllCmd = &cobra.Command{
+	Use:   "run-all",
+	Short: "Run the same YCSB workload on every registered backend and compare",
+	Run: func(cmd *cobra.Command, args []string) {
+		base, err := loadYCSBProperties(runAllWorkloadFile, runAllPropertyFile, runAllPropertyValues)
+		if err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := runAllPlots.validate(); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+
+		backends := runAllBackends
+		if len(backends) == 0 {
+			backends = db.Backends()
+		}
+
+		if dryRun {
+			targets := make([]dryRunTarget, len(backends))
+			for i, name := range backends {
+				targets[i] = dryRunTarget{label: name, dbName: name, props: runAllProperties(base, name)}
+			}
+			runDryRun(targets, runAllPlots.validate())
+			return
+		}
+
+		runs, err := runAllBackendsWith(backends, base, runAllPlots, runAllProfiles)
+		if err != nil {
+			fmt.Printf("Benchmark failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+
+		results := make([]metrics.BackendResults, len(runs))
+		for i, run := range runs {
+			results[i] = metrics.BackendResults{Name: backends[i], Results: run.Operations, WriteAmp: run.WriteAmp, Layout: run.Layout, Capabilities: run.Capabilities}
+		}
+		metrics.PrintComparisonTable(results)
+		writeOverlayPlots(runs, backends, runAllPlots.in("comparison"))
+	},
+}
+
+// runAllBackendsWith runs the workload on each backend in turn, each against
+// a fresh data directory under the configured datadir
+func runAllBackendsWith(backends []string, base *properties.Properties, plots plotOptions, profiles profileOptions) ([]*ycsbRun, error) {
+	results := make([]*ycsbRun, 0, len(backends))
+	for i, name := range backends {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Printf("Backend %d/%d: %s\n", i+1, len(backends), name)
+		fmt.Println(strings.Repeat("=", 80))
+
+		props := runAllProperties(base, name)
+		datadir := props.GetString("datadir", "")
+		if err := os.RemoveAll(datadir); err != nil {
+			return nil, fmt.Errorf("failed to clean data directory %s: %w", datadir, err)
+		}
+
+		run, err := runYCSB(name, props, plots.in(name), profiles.labeled(name), os.Stdout)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := run.abortError(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		recordHistory(name, run)
+		exportOTLP(name, run)
+		reportInflux(name, run)
+		notifyRun(name, run)
+		results = append(results, run)
+	}
+
+	return results, nil
+}
+
+// runAllProperties returns the properties for one backend, with its own data
+// directory under the configured datadir
+func runAllProperties(base *properties.Properties, name string) *properties.Properties {
+	props := cloneProperties(base)
+	props.Set("datadir", filepath.Join(base.GetString("datadir", "/tmp/godb-bench-run-all"), name))
+	return props
+}
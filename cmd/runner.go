@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// ycsbRun is the outcome of a single YCSB run against one backend
+type ycsbRun struct {
+	*bench.Result
+	plots       []string // plot files written for this run
+	interrupted bool     // by SIGINT or SIGTERM, so its results are partial
+}
+
+// toResults converts the run into its serializable form
+func (r *ycsbRun) toResults(name string) metrics.Results {
+	results := r.Results(name)
+	results.Plots = r.plots
+	return results
+}
+
+// abortError returns why the run was aborted early, or nil when it was not
+func (r *ycsbRun) abortError() error {
+	switch {
+	case r.interrupted:
+		return errors.New("interrupted")
+	case r.Aborted != nil:
+		return fmt.Errorf("aborted: %s", r.Aborted)
+	}
+	return nil
+}
+
+// interruptContext returns a context canceled by the first SIGINT or SIGTERM,
+// which stops the run in progress so its partial results are still reported.
+// A second signal exits at once.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			fmt.Println("\nInterrupted: stopping the run (interrupt again to exit at once)")
+			signal.Stop(signals)
+			cancel()
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		close(done)
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// resultsOutput selects whether and where machine-readable results are written
+type resultsOutput struct {
+	format string    // one of the metrics.Format* constants
+	path   string    // file for json and markdown, directory for csv and criterion
+	runDir string    // run directory, which always gets results.json and holds the default paths
+	log    io.Writer // receives the runs' progress messages; stdout if nil
+}
+
+// write stores report in the selected format. The table format writes nothing
+// but to the run directory.
+func (o resultsOutput) write(dbName string, report *metrics.Report) error {
+	if o.runDir != "" {
+		path := filepath.Join(o.runDir, "results.json")
+		if err := report.Write(metrics.FormatJSON, path); err != nil {
+			return fmt.Errorf("failed to write json results to %s: %w", path, err)
+		}
+		fmt.Printf("\nResults written to %s\n", path)
+		if o.format == metrics.FormatJSON && (o.path == "" || filepath.Clean(o.path) == path) {
+			return nil
+		}
+	}
+	if o.format == metrics.FormatTable {
+		return nil
+	}
+
+	path := o.path
+	if path == "" && o.runDir != "" && o.format != metrics.FormatCriterion {
+		path = resultsFile(filepath.Join(o.runDir, "results"), o.format)
+	}
+	if path == "" {
+		path = defaultResultsPath(dbName, o.format)
+	}
+	if err := report.Write(o.format, path); err != nil {
+		return fmt.Errorf("failed to write %s results to %s: %w", o.format, path, err)
+	}
+	fmt.Printf("\nResults written to %s\n", path)
+	return nil
+}
+
+// defaultResultsPath returns where results in format are written unless a
+// path is given
+func defaultResultsPath(dbName, format string) string {
+	if format == metrics.FormatCriterion {
+		// Where cargo keeps criterion.rs results, which its tooling expects
+		return "./target/criterion"
+	}
+	return resultsFile(fmt.Sprintf("./%s_results", dbName), format)
+}
+
+// resultsFile returns base with the extension of format; csv results are a
+// directory named base
+func resultsFile(base, format string) string {
+	switch format {
+	case metrics.FormatJSON:
+		return base + ".json"
+	case metrics.FormatMarkdown:
+		return base + ".md"
+	}
+	return base
+}
+
+// loadPropertyFile reads a YCSB property (or workload) file
+func loadPropertyFile(path string) (*properties.Properties, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open property file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read properties from %s: %w", path, err)
+	}
+
+	p := properties.NewProperties()
+	if err := p.Load(data, properties.UTF8); err != nil {
+		return nil, fmt.Errorf("failed to load properties from %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// loadYCSBProperties merges the workload file, the optional property file and
+// the command-line overrides (in that order of precedence, lowest first)
+func loadYCSBProperties(workloadFile, propertyFile string, propertyValues []string) (*properties.Properties, error) {
+	if workloadFile == "" {
+		return nil, fmt.Errorf("please specify a workload file using -w or --workload")
+	}
+
+	// The workload file should be loaded as a property file.
+	// See https://github.com/pingcap/go-ycsb/blob/master/cmd/go-ycsb/main.go
+	props, err := loadPropertyFile(workloadFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := props.Get(bench.WorkloadFileProperty); !ok {
+		props.Set(bench.WorkloadFileProperty, filepath.Base(workloadFile))
+	}
+
+	if propertyFile != "" {
+		p, err := loadPropertyFile(propertyFile)
+		if err != nil {
+			return nil, err
+		}
+		props.Merge(p)
+	}
+
+	if err := applyPropertyOverrides(props, propertyValues); err != nil {
+		return nil, err
+	}
+
+	return props, nil
+}
+
+// applyPropertyOverrides sets key=value pairs given on the command line
+func applyPropertyOverrides(props *properties.Properties, propertyValues []string) error {
+	for _, p := range propertyValues {
+		parts := strings.SplitN(p, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid property format: %s", p)
+		}
+		props.Set(parts[0], parts[1])
+	}
+	return nil
+}
+
+// cloneProperties returns an independent copy of props
+func cloneProperties(props *properties.Properties) *properties.Properties {
+	c := properties.NewProperties()
+	c.Merge(props)
+	return c
+}
+
+// runYCSB executes the workload described by props against the named DB,
+// logging its progress to log, prints the results table and writes plots and
+// profiles as configured
+func runYCSB(dbName string, props *properties.Properties, plots plotOptions, profiles profileOptions, log io.Writer) (*ycsbRun, error) {
+	profiles = profiles.inPlots(plots)
+	var flameFiles []string
+	runner := bench.NewRunner(bench.Config{
+		DB:          dbName,
+		Properties:  props,
+		KeepSamples: plots.retainSamples(),
+		Log:         log,
+		Dashboard:   dashboardOutput(),
+		BeforeRun: func(warmUp time.Duration) func() {
+			// Profiles cover only the measured window: transactions after warm-up
+			if !profiles.enabled() {
+				return nil
+			}
+			if !props.GetBool(prop.DoTransactions, true) {
+				fmt.Println("Load phase: skipping profiles")
+				return nil
+			}
+			window := startProfileWindow(profiles, warmUp)
+			return func() {
+				if err := window.stop(); err != nil {
+					fmt.Printf("Warning: failed to write profiles: %v\n", err)
+					return
+				}
+				if profiles.flamegraph {
+					files, err := metrics.GenerateFlameGraph(profiles.cpu, plots.dir)
+					if err != nil {
+						fmt.Printf("Warning: failed to generate flame graph: %v\n", err)
+						return
+					}
+					flameFiles = files
+					fmt.Printf("Flame graph written to %s\n", files[len(files)-1])
+				}
+			}
+		},
+	})
+	ctx, stop := interruptContext()
+	defer stop()
+	result, err := runner.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	interrupted := ctx.Err() != nil
+	tracker := result.Tracker
+
+	// Print YCSB metrics in table format
+	metrics.PrintMetricsTable(result.Operations)
+	metrics.PrintErrorRateAbort(result.Aborted)
+	metrics.PrintRetries(result.Retries)
+	metrics.PrintPrecision(result.Precision)
+	metrics.PrintWriteAmplification(result.WriteAmp)
+	metrics.PrintBlockCache(result.BlockCache)
+	metrics.PrintIOCalls(result.IOCalls)
+	metrics.PrintDiskBaseline(result.Disk)
+	metrics.PrintCompaction(result.Compaction)
+	metrics.PrintReopens(result.Reopens)
+	metrics.PrintPerfCounters(result.Perf)
+	result.Environment.Print()
+	metrics.PrintResources(result.Resources)
+	metrics.PrintEngine(result.Engine)
+	metrics.PrintLSM(result.LSM)
+	metrics.PrintVerification(result.Verification)
+	metrics.PrintCrashRecovery(result.Crash)
+
+	// Print additional statistics (criterion-style)
+	if result.Statistics != nil {
+		metrics.PrintOperationStatistics(result.Statistics)
+	}
+	perThread := props.GetBool(bench.PerThreadProperty, false)
+	if perThread {
+		metrics.PrintThreadStatistics(result.Threads)
+	}
+	if !tracker.RetainsSamples() {
+		metrics.PrintStreamStatistics(result.Streaming)
+	}
+	metrics.PrintHotSetStatistics(result.HotSet)
+	metrics.PrintBurstStatistics(result.Bursts)
+	metrics.PrintRampStatistics(result.Ramp)
+	metrics.PrintSteadyState(result.SteadyState)
+	metrics.PrintScanLengthStatistics(result.ScanLengths)
+
+	// Generate criterion-style plots
+	var plotFiles []string
+	if plots.enabled {
+		fmt.Printf("\nGenerating benchmark plots in %s...\n", plots.dir)
+		style, err := plots.style()
+		if err == nil {
+			tracker.SetPlotStyle(style)
+			plotFiles, err = tracker.GeneratePlots(plots.dir, plots.formats)
+		}
+		if err == nil && perThread {
+			var threadFiles []string
+			threadFiles, err = tracker.GenerateThreadPlots(plots.dir, plots.formats)
+			plotFiles = append(plotFiles, threadFiles...)
+		}
+		if err != nil {
+			fmt.Printf("Warning: failed to generate plots: %v\n", err)
+		} else {
+			fmt.Printf("Plots generated successfully in %s\n", plots.dir)
+		}
+	}
+
+	// Keep the environment and raw samples next to the plots so they can be
+	// interpreted and replotted later
+	if plots.enabled || plots.samples {
+		if err := os.MkdirAll(plots.dir, 0755); err != nil {
+			fmt.Printf("Warning: failed to create %s: %v\n", plots.dir, err)
+		}
+		if err := result.Environment.WriteJSON(filepath.Join(plots.dir, "environment.json")); err != nil {
+			fmt.Printf("Warning: failed to write environment: %v\n", err)
+		}
+	}
+	if plots.samples {
+		path := filepath.Join(plots.dir, metrics.SamplesFile)
+		if err := tracker.WriteSamples(path); err != nil {
+			fmt.Printf("Warning: failed to save samples: %v\n", err)
+		} else {
+			fmt.Printf("Samples saved to %s\n", path)
+		}
+	}
+
+	// The backend's own dump, unless its levels are in the LSM table already;
+	// JSON results keep it either way
+	if result.DBMetrics != "" && len(result.LSM) == 0 {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("Backend Metrics:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(result.DBMetrics)
+	}
+
+	return &ycsbRun{Result: result, plots: append(plotFiles, flameFiles...), interrupted: interrupted}, nil
+}
+
+// defaultDatadir returns the data directory the named DB will use
+func defaultDatadir(dbName string, props *properties.Properties) string {
+	return props.GetString("datadir", fmt.Sprintf("/tmp/%s", dbName))
+}
+
+// runYCSBRepeated runs the workload the given number of times, optionally
+// wiping the data directory before each run, prints per-run results and
+// cross-run aggregates when there is more than one run, and writes the
+// results in the requested output format
+func runYCSBRepeated(dbName string, props *properties.Properties, plots plotOptions, profiles profileOptions, runs int, freshDatadir bool, out resultsOutput, runID string) ([]*ycsbRun, error) {
+	if runs < 1 {
+		return nil, fmt.Errorf("runs must be at least 1, got %d", runs)
+	}
+	if err := metrics.ValidateFormat(out.format); err != nil {
+		return nil, err
+	}
+	if err := plots.validate(); err != nil {
+		return nil, err
+	}
+	if out.format == metrics.FormatCriterion {
+		plots.retain = true
+	}
+
+	log := out.log
+	if log == nil {
+		log = os.Stdout
+	}
+	datadir := defaultDatadir(dbName, props)
+	var results []*ycsbRun
+	var aborted error
+	for i := 1; i <= runs; i++ {
+		if runs > 1 {
+			fmt.Println("\n" + strings.Repeat("=", 80))
+			fmt.Printf("Run %d/%d\n", i, runs)
+			fmt.Println(strings.Repeat("=", 80))
+		}
+
+		if freshDatadir {
+			if err := os.RemoveAll(datadir); err != nil {
+				return nil, fmt.Errorf("failed to clean data directory %s: %w", datadir, err)
+			}
+		}
+
+		runPlots, runProfiles := plots, profiles
+		if runs > 1 {
+			label := fmt.Sprintf("run-%d", i)
+			runPlots = plots.in(label)
+			runProfiles = profiles.labeled(label)
+		}
+
+		run, err := runYCSB(dbName, cloneProperties(props), runPlots, runProfiles, log)
+		if err != nil {
+			return nil, fmt.Errorf("run %d: %w", i, err)
+		}
+		results = append(results, run)
+		if err := run.abortError(); err != nil {
+			// The partial results are still written, but kept out of the
+			// history and exports, where their latencies would mislead
+			aborted = fmt.Errorf("run %d: %w", i, err)
+			break
+		}
+		recordHistory(fmt.Sprintf("run-%d", i), run)
+		exportOTLP(fmt.Sprintf("run-%d", i), run)
+		reportInflux(fmt.Sprintf("run-%d", i), run)
+		notifyRun(fmt.Sprintf("run-%d", i), run)
+	}
+
+	report := &metrics.Report{RunID: runID}
+	for i, run := range results {
+		report.Runs = append(report.Runs, run.toResults(fmt.Sprintf("run-%d", i+1)))
+	}
+	if runs > 1 && aborted == nil {
+		metrics.PrintRunsReport(runResults(results))
+		report.AddAggregate(dbName, runResults(results))
+
+		names := make([]string, len(results))
+		for i := range results {
+			names[i] = fmt.Sprintf("run-%d", i+1)
+		}
+		writeOverlayPlots(results, names, plots.in("comparison"))
+	}
+
+	if err := out.write(dbName, report); err != nil {
+		return nil, err
+	}
+
+	return results, aborted
+}
+
+// runResults extracts the metrics tables of several runs
+func runResults(runs []*ycsbRun) [][]metrics.OperationMetrics {
+	results := make([][]metrics.OperationMetrics, len(runs))
+	for i, r := range runs {
+		results[i] = r.Operations
+	}
+	return results
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule is when the daemon runs its suite: a five-field cron expression
+// (minute hour day-of-month month day-of-week) in local time, or a fixed
+// interval from "@every <duration>"
+type schedule struct {
+	every time.Duration // set for @every; the cron fields are unused then
+
+	minutes, hours, days, months, weekdays []bool
+	anyDay, anyWeekday                     bool // the day fields were "*"
+}
+
+// scheduleAliases are the cron shorthands, as most cron implementations
+// accept them
+var scheduleAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseSchedule parses a cron expression such as "0 2 * * 1-5", a shorthand
+// such as @daily, or "@every 6h"
+func parseSchedule(expr string) (*schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := strings.CutPrefix(expr, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || every <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: expected @every followed by a positive duration such as 6h", expr)
+		}
+		return &schedule{every: every}, nil
+	}
+	if alias, ok := scheduleAliases[expr]; ok {
+		expr = alias
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	s := &schedule{anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	specs := []struct {
+		field    *[]bool
+		name     string
+		min, max int
+	}{
+		{&s.minutes, "minute", 0, 59},
+		{&s.hours, "hour", 0, 23},
+		{&s.days, "day-of-month", 1, 31},
+		{&s.months, "month", 1, 12},
+		{&s.weekdays, "day-of-week", 0, 7},
+	}
+	for i, spec := range specs {
+		set, err := parseScheduleField(fields[i], spec.min, spec.max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %s: %w", expr, spec.name, err)
+		}
+		*spec.field = set
+	}
+	s.weekdays[0] = s.weekdays[0] || s.weekdays[7] // 7 is also Sunday
+	return s, nil
+}
+
+// parseScheduleField parses one cron field: "*", a value, a range "a-b", a
+// step "*/n" or "a-b/n", or a comma-separated list of those
+func parseScheduleField(field string, lo, hi int) ([]bool, error) {
+	set := make([]bool, hi+1)
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q", stepText)
+			}
+			step = n
+		}
+
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return nil, fmt.Errorf("invalid value %q", a)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return nil, fmt.Errorf("invalid value %q", b)
+				}
+			} else if hasStep {
+				to = hi // "a/n" runs from a to the end
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return nil, fmt.Errorf("%q is outside %d-%d", part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// next returns the first time after t the schedule fires
+func (s *schedule) next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Every combination repeats within a few years; stop there rather than
+	// loop forever on a date that never exists, such as February 30
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.months[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's rule for the two day fields: when both are
+// restricted, either may match
+func (s *schedule) dayMatches(t time.Time) bool {
+	day, weekday := s.days[t.Day()], s.weekdays[t.Weekday()]
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/spf13/cobra"
+	"gonum.org/v1/plot/plotter"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	sweepConfigFile     string
+	sweepOutputDir      string
+	sweepPropertyValues []string
+	sweepPlots          plotOptions
+	sweepProfiles       profileOptions
+)
+
+// sweepConfig describes a parameter sweep: a base benchmark plus a list of
+// property dimensions whose cartesian product is executed
+type sweepConfig struct {
+	DB         string                   `json:"db"`
+	Workload   string                   `json:"workload"`
+	Properties map[string]propertyValue `json:"properties"`
+	Dimensions []sweepDimension         `json:"dimensions"`
+}
+
+// sweepDimension is a single property and the values it takes in the sweep
+type sweepDimension struct {
+	Property string          `json:"property"`
+	Values   []propertyValue `json:"values"`
+}
+
+// sweepPoint is one combination of dimension values and its results
+type sweepPoint struct {
+	values  []string // one value per dimension, in config order
+	results []metrics.OperationMetrics
+}
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep",
+	Short: "Run a workload over the cartesian product of parameter values",
+	Long: `Run the same workload for every combination of the dimensions listed in a
+sweep config file, then print a combined report and plot each metric against
+each swept parameter.
+
+Example config:
+
+  {
+    "db": "pebble",
+    "workload": "workload.spec",
+    "properties": {"operationcount": 100000},
+    "dimensions": [
+      {"property": "threadcount", "values": [1, 4, 16]},
+      {"property": "pebble.cache_size", "values": [8388608, 134217728]}
+    ]
+  }`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadSweepConfig(sweepConfigFile)
+		if err != nil {
+			fmt.Printf("Failed to load sweep config: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Plots live in the output directory unless placed elsewhere explicitly
+		if !cmd.Flags().Changed("plots-dir") {
+			sweepPlots.dir = sweepOutputDir
+		}
+		if err := sweepPlots.validate(); err != nil {
+			fmt.Printf("Invalid plot options: %v\n", err)
+			os.Exit(1)
+		}
+
+		base, err := loadYCSBProperties(cfg.Workload, "", nil)
+		if err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+		for k, v := range cfg.Properties {
+			base.Set(k, string(v))
+		}
+		// Command-line overrides win over the config file
+		if err := applyPropertyOverrides(base, sweepPropertyValues); err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+
+		if dryRun {
+			var targets []dryRunTarget
+			for _, values := range sweepCombinations(cfg.Dimensions) {
+				targets = append(targets, dryRunTarget{
+					label:  fmt.Sprintf("%s sweep %s", cfg.DB, sweepTag(cfg.Dimensions, values)),
+					dbName: cfg.DB,
+					props:  sweepProperties(cfg, base, values),
+				})
+			}
+			runDryRun(targets, sweepPlots.validate())
+			return
+		}
+
+		points, err := runSweep(cfg, base, sweepPlots, sweepProfiles)
+		if err != nil {
+			fmt.Printf("Sweep failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+
+		printSweepReport(cfg, points)
+
+		envFile := filepath.Join(sweepOutputDir, "environment.json")
+		if err := metrics.CaptureEnvironment().WriteJSON(envFile); err != nil {
+			fmt.Printf("Warning: failed to write environment: %v\n", err)
+		}
+
+		reportFile := filepath.Join(sweepOutputDir, "sweep_report.csv")
+		if err := writeSweepReport(cfg, points, reportFile); err != nil {
+			fmt.Printf("Warning: failed to write sweep report: %v\n", err)
+		} else {
+			fmt.Printf("\nSweep report written to %s\n", reportFile)
+		}
+
+		if sweepPlots.enabled {
+			if err := generateSweepPlots(cfg, points, sweepPlots.in("curves")); err != nil {
+				fmt.Printf("Warning: failed to generate sweep plots: %v\n", err)
+			}
+		}
+	},
+}
+
+// loadSweepConfig reads and validates a sweep config file
+func loadSweepConfig(path string) (*sweepConfig, error) {
+	if path == "" {
+		return nil, fmt.Errorf("please specify a sweep config using -c or --config")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg sweepConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if cfg.DB == "" {
+		return nil, fmt.Errorf("%s: db is required", path)
+	}
+	if cfg.Workload == "" {
+		return nil, fmt.Errorf("%s: workload is required", path)
+	}
+	if len(cfg.Dimensions) == 0 {
+		return nil, fmt.Errorf("%s: at least one dimension is required", path)
+	}
+	for _, d := range cfg.Dimensions {
+		if d.Property == "" || len(d.Values) == 0 {
+			return nil, fmt.Errorf("%s: every dimension needs a property and at least one value", path)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// sweepCombinations returns the cartesian product of all dimension values
+func sweepCombinations(dims []sweepDimension) [][]string {
+	combos := [][]string{{}}
+	for _, d := range dims {
+		var next [][]string
+		for _, combo := range combos {
+			for _, v := range d.Values {
+				c := make([]string, len(combo), len(combo)+1)
+				copy(c, combo)
+				next = append(next, append(c, string(v)))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// sweepTag returns a filesystem-safe label for a combination, e.g.
+// "threadcount-4_fieldlength-100"
+func sweepTag(dims []sweepDimension, values []string) string {
+	parts := make([]string, len(dims))
+	for i, d := range dims {
+		parts[i] = fmt.Sprintf("%s-%s", d.Property, values[i])
+	}
+	return strings.Join(parts, "_")
+}
+
+// runSweep executes every combination with a fresh data directory
+func runSweep(cfg *sweepConfig, base *properties.Properties, plots plotOptions, profiles profileOptions) ([]sweepPoint, error) {
+	combos := sweepCombinations(cfg.Dimensions)
+
+	points := make([]sweepPoint, 0, len(combos))
+	for i, values := range combos {
+		tag := sweepTag(cfg.Dimensions, values)
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Printf("Sweep run %d/%d: %s\n", i+1, len(combos), tag)
+		fmt.Println(strings.Repeat("=", 80))
+
+		// Every run starts from an empty database so results are comparable
+		props := sweepProperties(cfg, base, values)
+		datadir := props.GetString("datadir", "")
+		if err := os.RemoveAll(datadir); err != nil {
+			return nil, fmt.Errorf("failed to clean data directory %s: %w", datadir, err)
+		}
+
+		run, err := runYCSB(cfg.DB, props, plots.in(tag, "plots"), profiles.labeled(tag), os.Stdout)
+		if err != nil {
+			return nil, fmt.Errorf("run %s: %w", tag, err)
+		}
+		if err := run.abortError(); err != nil {
+			return nil, fmt.Errorf("run %s: %w", tag, err)
+		}
+		recordHistory(tag, run)
+		exportOTLP(tag, run)
+		reportInflux(tag, run)
+		notifyRun(tag, run)
+		points = append(points, sweepPoint{values: values, results: run.Operations})
+	}
+
+	return points, nil
+}
+
+// sweepProperties returns the properties of one combination, with its own
+// data directory under the configured datadir
+func sweepProperties(cfg *sweepConfig, base *properties.Properties, values []string) *properties.Properties {
+	baseDatadir := base.GetString("datadir", fmt.Sprintf("/tmp/%s-sweep", cfg.DB))
+
+	props := cloneProperties(base)
+	for j, d := range cfg.Dimensions {
+		props.Set(d.Property, values[j])
+	}
+	props.Set("datadir", filepath.Join(baseDatadir, sweepTag(cfg.Dimensions, values)))
+	return props
+}
+
+// printSweepReport prints every run's per-operation results in one table
+func printSweepReport(cfg *sweepConfig, points []sweepPoint) {
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "SWEEP RESULTS"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	fmt.Printf("│ %-44s │ %-12s │ %10s │ %10s │ %9s │ %9s │ %9s │\n",
+		"Run", "Operation", "Count", "OPS", "Avg(µs)", "p99(µs)", "p99.9(µs)")
+	fmt.Println(strings.Repeat("─", tableWidth))
+
+	for _, pt := range points {
+		tag := sweepTag(cfg.Dimensions, pt.values)
+		for _, r := range pt.results {
+			fmt.Printf("│ %-44s │ %-12s │ %10d │ %10.1f │ %9d │ %9d │ %9d │\n",
+				tag, r.Operation, r.Count, r.OPS, r.Avg, r.P99, r.P999)
+			tag = ""
+		}
+	}
+
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
+
+// writeSweepReport writes the combined results as CSV, one row per run and operation
+func writeSweepReport(cfg *sweepConfig, points []sweepPoint, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := make([]string, 0, len(cfg.Dimensions)+10)
+	for _, d := range cfg.Dimensions {
+		header = append(header, d.Property)
+	}
+	header = append(header, "operation", "count", "ops", "avg_us", "min_us", "max_us", "p50_us", "p95_us", "p99_us", "p999_us")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, pt := range points {
+		for _, r := range pt.results {
+			row := append([]string{}, pt.values...)
+			row = append(row, r.Operation,
+				strconv.FormatInt(r.Count, 10),
+				strconv.FormatFloat(r.OPS, 'f', 1, 64),
+				strconv.FormatInt(r.Avg, 10),
+				strconv.FormatInt(r.Min, 10),
+				strconv.FormatInt(r.Max, 10),
+				strconv.FormatInt(r.P50, 10),
+				strconv.FormatInt(r.P95, 10),
+				strconv.FormatInt(r.P99, 10),
+				strconv.FormatInt(r.P999, 10))
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// generateSweepPlots plots throughput and p99 latency against each swept
+// parameter. Runs that share the values of every other dimension form one line.
+func generateSweepPlots(cfg *sweepConfig, points []sweepPoint, plots plotOptions) error {
+	style, err := plots.style()
+	if err != nil {
+		return err
+	}
+
+	sweepMetrics := []struct {
+		name  string
+		label string
+		value func(metrics.OperationMetrics) float64
+	}{
+		{"ops", "Throughput (ops/s)", func(m metrics.OperationMetrics) float64 { return m.OPS }},
+		{"p99", "p99 latency (µs)", func(m metrics.OperationMetrics) float64 { return float64(m.P99) }},
+	}
+
+	for di, dim := range cfg.Dimensions {
+		// Map each value to an x coordinate; non-numeric values use their position
+		xs := make(map[string]float64, len(dim.Values))
+		for i, v := range dim.Values {
+			x, err := strconv.ParseFloat(string(v), 64)
+			if err != nil {
+				x = float64(i)
+			}
+			xs[string(v)] = x
+		}
+
+		for _, op := range sweepOperations(points) {
+			for _, m := range sweepMetrics {
+				lines := make(map[string]plotter.XYs)
+				var names []string
+				for _, pt := range points {
+					row, ok := metrics.FindMetrics(pt.results, op)
+					if !ok {
+						continue
+					}
+					name := sweepSeriesName(cfg.Dimensions, pt.values, di)
+					if _, exists := lines[name]; !exists {
+						names = append(names, name)
+					}
+					lines[name] = append(lines[name], plotter.XY{X: xs[pt.values[di]], Y: m.value(row)})
+				}
+
+				series := make([]metrics.CurveSeries, 0, len(names))
+				for _, name := range names {
+					pts := lines[name]
+					sort.Slice(pts, func(i, j int) bool { return pts[i].X < pts[j].X })
+					series = append(series, metrics.CurveSeries{Name: name, Points: pts})
+				}
+
+				chart := fmt.Sprintf("%s vs %s", m.label, dim.Property)
+				base := filepath.Join(plots.dir, fmt.Sprintf("%s_%s_vs_%s", op, m.name, dim.Property))
+				if err := metrics.GenerateCurvePlot(op, chart, dim.Property, m.label, series, base, plots.formats, style); err != nil {
+					fmt.Printf("Warning: failed to generate plot %s: %v\n", base, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// sweepSeriesName labels a line by the values of every dimension except skip
+func sweepSeriesName(dims []sweepDimension, values []string, skip int) string {
+	var parts []string
+	for i, d := range dims {
+		if i != skip {
+			parts = append(parts, fmt.Sprintf("%s=%s", d.Property, values[i]))
+		}
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sweepOperations returns the operations seen in any run, in first-seen order
+func sweepOperations(points []sweepPoint) []string {
+	seen := make(map[string]bool)
+	var ops []string
+	for _, pt := range points {
+		for _, r := range pt.results {
+			if !seen[r.Operation] {
+				seen[r.Operation] = true
+				ops = append(ops, r.Operation)
+			}
+		}
+	}
+	return ops
+}
@@ -0,0 +1,413 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/workload"
+)
+
+// dryRun makes the benchmark commands validate their configuration and exit
+// instead of running. Only one command runs per process, so they share it.
+var dryRun bool
+
+// addDryRunFlag registers --dry-run on cmd
+func addDryRunFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Validate and print the effective configuration without running or touching disk")
+}
+
+// commonProperties are read by go-ycsb, the core workload or the runner for
+// every backend
+var commonProperties = []string{
+	prop.Workload, prop.DB, prop.RecordCount, prop.OperationCount, prop.InsertStart, prop.InsertCount,
+	prop.ThreadCount, prop.Target, prop.MaxExecutiontime, prop.WarmUpTime, prop.DoTransactions,
+	prop.BatchSize, prop.TableName, prop.FieldCount, prop.FieldLength, prop.FieldLengthDistribution,
+	prop.FieldLengthHistogramFile, prop.ReadAllFields, prop.WriteAllFields, prop.DataIntegrity,
+	prop.ReadProportion, prop.UpdateProportion, prop.InsertProportion, prop.ScanProportion,
+	prop.ReadModifyWriteProportion, prop.RequestDistribution, prop.ZeroPadding, prop.MaxScanLength,
+	prop.ScanLengthDistribution, prop.InsertOrder, prop.HotspotDataFraction, prop.HotspotOpnFraction,
+	prop.InsertionRetryLimit, prop.InsertionRetryInterval, prop.ExponentialPercentile, prop.ExponentialFrac,
+	prop.KeyPrefix, prop.LogInterval, prop.MeasurementType, prop.MeasurementRawOutputFile, prop.OutputStyle,
+	prop.MeasurementHistogramPercentileExport, prop.MeasurementHistogramPercentileExportFilepath,
+	prop.Verbose, prop.Silence, prop.DropData,
+	workload.SeedProperty, workload.TxnSizeProperty, workload.CompressionRatioProperty, workload.HotSetKeysProperty,
+	workload.HotSetFractionProperty, workload.PruneFractionProperty, workload.PrefixScanProportionProperty,
+	workload.PrefixCountProperty, workload.PrefixKeysProperty, workload.PartialReadProportionProperty,
+	workload.PartialReadLengthProperty, workload.VerifyProperty, db.TableCountProperty, db.KeyEncodingProperty, db.ValueParityProperty,
+	db.ReadOnlyProperty,
+	bench.DurationProperty, bench.TargetOpsProperty, bench.TargetOpsBurstProperty,
+	bench.BurstPeriodProperty, bench.BurstDutyCycleProperty, bench.BurstPostIdleProperty, bench.RampThreadsProperty,
+	bench.RampStepProperty, bench.StatisticsProperty,
+	bench.PerThreadProperty, bench.ReservoirProperty, bench.ResamplesProperty, bench.ConfidenceLevelProperty,
+	bench.PercentilesProperty, bench.ExcludeErrorsProperty, bench.SeriesIntervalProperty,
+	bench.SteadyStateWindowProperty, bench.SteadyStateCVProperty,
+	bench.TargetPrecisionProperty, bench.TargetPrecisionStatProperty, bench.TargetPrecisionOpProperty,
+	bench.MaxErrorRateProperty, bench.OpTimeoutProperty, bench.OpRetriesProperty, bench.OpRetryBackoffProperty,
+	bench.DistributionProperty, bench.ResourceIntervalProperty, bench.WorkloadFileProperty,
+	bench.StatsdAddressProperty, bench.StatsdPrefixProperty, bench.StatsdIntervalProperty,
+	bench.ProgressIntervalProperty, bench.CgroupMemoryProperty, bench.CgroupReadBpsProperty, bench.CgroupWriteBpsProperty,
+	bench.CgroupReadIOPSProperty, bench.CgroupWriteIOPSProperty, bench.CgroupDeviceProperty,
+	bench.DiskBaselineProperty, bench.DiskBaselineSizeProperty, bench.DiskBaselineDurationProperty,
+	bench.CompactProperty, bench.ReopenProperty, bench.CrashProperty, bench.PerfCountersProperty, bench.RecordTraceProperty, bench.ReplayTraceProperty, workload.TraceFileProperty,
+}
+
+// integerProperties and floatProperties must parse as numbers; go-ycsb
+// silently falls back to the default when they do not
+var (
+	integerProperties = []string{
+		prop.RecordCount, prop.OperationCount, prop.InsertStart, prop.InsertCount, prop.ThreadCount,
+		prop.Target, prop.MaxExecutiontime, prop.WarmUpTime, prop.BatchSize, prop.FieldCount,
+		prop.FieldLength, prop.MaxScanLength, bench.ReopenProperty, workload.SeedProperty, workload.TxnSizeProperty, workload.HotSetKeysProperty,
+		workload.PrefixCountProperty, workload.PrefixKeysProperty, workload.PartialReadLengthProperty,
+		db.TableCountProperty, bench.TargetOpsBurstProperty,
+		bench.ReservoirProperty, bench.ResamplesProperty, bench.DiskBaselineSizeProperty,
+	}
+	floatProperties = []string{
+		prop.ReadProportion, prop.UpdateProportion, prop.InsertProportion, prop.ScanProportion,
+		prop.ReadModifyWriteProportion, prop.HotspotDataFraction, prop.HotspotOpnFraction, bench.TargetOpsProperty,
+		bench.BurstDutyCycleProperty,
+		bench.ConfidenceLevelProperty, bench.DistributionProperty, workload.CompressionRatioProperty,
+		workload.HotSetFractionProperty, workload.PruneFractionProperty, workload.PrefixScanProportionProperty,
+		workload.PartialReadProportionProperty,
+	}
+)
+
+// dryRunTarget is one run a command would execute
+type dryRunTarget struct {
+	label  string
+	dbName string
+	props  *properties.Properties
+}
+
+// runDryRun prints the effective configuration of every target along with
+// any problems found, then exits: with status 1 if there were problems.
+// Nothing is written to disk.
+func runDryRun(targets []dryRunTarget, flagErrs ...error) {
+	failed := false
+	for _, err := range flagErrs {
+		if err != nil {
+			fmt.Printf("Invalid flags: %v\n", err)
+			failed = true
+		}
+	}
+
+	for _, t := range targets {
+		props := cloneProperties(t.props)
+		bench.ApplyDefaults(t.dbName, props)
+
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Printf("Dry run: %s\n", t.label)
+		fmt.Println(strings.Repeat("=", 80))
+		printEffectiveConfig(props)
+
+		problems, notes := validateYCSB(t.dbName, props)
+		for _, n := range notes {
+			fmt.Printf("Note: %s\n", n)
+		}
+		if len(problems) == 0 {
+			fmt.Println("OK")
+			continue
+		}
+		failed = true
+		fmt.Printf("%d problem(s):\n", len(problems))
+		for _, p := range problems {
+			fmt.Printf("  - %s\n", p)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// printEffectiveConfig prints every property, sorted by name
+func printEffectiveConfig(props *properties.Properties) {
+	keys := props.Keys()
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, _ := props.Get(k)
+		fmt.Printf("  %-40s %s\n", k, v)
+	}
+}
+
+// validateYCSB checks that the workload and DB resolve and that every
+// property is known and well-formed. Notes are informational.
+func validateYCSB(dbName string, props *properties.Properties) (problems, notes []string) {
+	if ycsb.GetDBCreator(dbName) == nil {
+		problems = append(problems, fmt.Sprintf("unknown db %q (available: %s)", dbName, strings.Join(db.Backends(), ", ")))
+	}
+
+	// The core workload exits the process on an unknown distribution, so
+	// check those before creating it
+	distributions := []struct {
+		property string
+		value    string
+		allowed  []string
+	}{
+		{prop.RequestDistribution, props.GetString(prop.RequestDistribution, prop.RequestDistributionDefault),
+			[]string{"uniform", "sequential", "zipfian", "latest", "hotspot", "exponential"}},
+		{prop.FieldLengthDistribution, strings.ToLower(props.GetString(prop.FieldLengthDistribution, prop.FieldLengthDistributionDefault)),
+			[]string{"constant", "uniform", "zipfian", "histogram"}},
+		{prop.ScanLengthDistribution, props.GetString(prop.ScanLengthDistribution, prop.ScanLengthDistributionDefault),
+			[]string{"uniform", "zipfian"}},
+		{prop.InsertOrder, props.GetString(prop.InsertOrder, prop.InsertOrderDefault),
+			[]string{"hashed", "ordered", workload.InsertOrderMonotonic}},
+	}
+	distributionsOK := true
+	for _, d := range distributions {
+		if !slices.Contains(d.allowed, d.value) {
+			problems = append(problems, fmt.Sprintf("unknown %s %q (expected one of %s)", d.property, d.value, strings.Join(d.allowed, ", ")))
+			distributionsOK = false
+		}
+	}
+
+	workloadName := props.GetString(prop.Workload, "core")
+	creator := ycsb.GetWorkloadCreator(workloadName)
+	switch {
+	case creator == nil:
+		problems = append(problems, fmt.Sprintf("unknown workload %q", workloadName))
+	case distributionsOK:
+		wl, err := creator.Create(props)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("invalid workload: %v", err))
+		} else {
+			wl.Close()
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, k := range commonProperties {
+		known[k] = true
+	}
+	for _, k := range db.Properties(dbName) {
+		known[k] = true
+	}
+	otherBackends := make(map[string][]string)
+	for _, name := range db.Backends() {
+		if name == dbName {
+			continue
+		}
+		for _, k := range db.Properties(name) {
+			if !known[k] {
+				otherBackends[k] = append(otherBackends[k], name)
+			}
+		}
+	}
+
+	keys := props.Keys()
+	sort.Strings(keys)
+	for _, k := range keys {
+		if known[k] {
+			continue
+		}
+		if users, ok := otherBackends[k]; ok {
+			notes = append(notes, fmt.Sprintf("%s is only used by %s", k, strings.Join(users, ", ")))
+			continue
+		}
+		msg := fmt.Sprintf("unknown property %q", k)
+		if s := closestProperty(k, known); s != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", s)
+		}
+		problems = append(problems, msg)
+	}
+
+	for _, k := range integerProperties {
+		if v, ok := props.Get(k); ok {
+			if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+				problems = append(problems, fmt.Sprintf("%s must be an integer, got %q", k, v))
+			}
+		}
+	}
+	for _, k := range floatProperties {
+		if v, ok := props.Get(k); ok {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				problems = append(problems, fmt.Sprintf("%s must be a number, got %q", k, v))
+			}
+		}
+	}
+
+	if _, err := bench.RunDuration(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	err := metrics.ValidateBootstrap(props.GetInt(bench.ResamplesProperty, metrics.DefaultBootstrapResamples),
+		props.GetFloat64(bench.ConfidenceLevelProperty, metrics.DefaultConfidenceLevel))
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if step := props.GetFloat64(bench.DistributionProperty, 0); step != 0 {
+		if err := metrics.ValidateDistributionStep(step); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if _, err := bench.SeriesInterval(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := bench.ResourceInterval(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, _, _, err := bench.Bursts(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, _, err := bench.Ramp(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, _, err := bench.SteadyState(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, _, _, err := bench.TargetPrecision(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := bench.MaxErrorRate(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, _, _, err := bench.OpPolicy(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := bench.StatsdInterval(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := bench.ProgressInterval(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := bench.DiskBaselineDuration(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := bench.CheckCgroup(props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if percentiles, err := bench.Percentiles(props); err != nil {
+		problems = append(problems, err.Error())
+	} else if err := metrics.ValidatePercentiles(percentiles); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	// go-ycsb's batches take over from groups when both are asked for
+	if txnSize := props.GetInt64(workload.TxnSizeProperty, 1); txnSize < 1 {
+		problems = append(problems, fmt.Sprintf("%s must be at least 1, not %d", workload.TxnSizeProperty, txnSize))
+	} else if txnSize > 1 && props.GetInt64(prop.BatchSize, 1) > 1 {
+		problems = append(problems, fmt.Sprintf("%s and %s cannot both be above 1", workload.TxnSizeProperty, prop.BatchSize))
+	}
+
+	if ratio := props.GetFloat64(workload.CompressionRatioProperty, 0); ratio != 0 && ratio < 1 {
+		problems = append(problems, fmt.Sprintf("%s must be at least 1, not %g", workload.CompressionRatioProperty, ratio))
+	} else if ratio != 0 && props.GetBool(prop.DataIntegrity, prop.DataIntegrityDefault) {
+		notes = append(notes, fmt.Sprintf("%s has no effect with %s, whose values are deterministic", workload.CompressionRatioProperty, prop.DataIntegrity))
+	}
+
+	if fraction := props.GetFloat64(workload.HotSetFractionProperty, 0); fraction < 0 || fraction > 1 {
+		problems = append(problems, fmt.Sprintf("%s must be between 0 and 1, not %g", workload.HotSetFractionProperty, fraction))
+	}
+	if keys := props.GetInt64(workload.HotSetKeysProperty, 0); keys < 0 || keys > props.GetInt64(prop.RecordCount, 0) {
+		problems = append(problems, fmt.Sprintf("%s must be between 0 and %s, not %d", workload.HotSetKeysProperty, prop.RecordCount, keys))
+	}
+
+	if fraction := props.GetFloat64(workload.PruneFractionProperty, 0); fraction < 0 || fraction > 1 {
+		problems = append(problems, fmt.Sprintf("%s must be between 0 and 1, not %g", workload.PruneFractionProperty, fraction))
+	}
+
+	if count := props.GetInt64(workload.PrefixCountProperty, 0); count < 0 {
+		problems = append(problems, fmt.Sprintf("%s must not be negative, not %d", workload.PrefixCountProperty, count))
+	} else if count == 0 && props.GetFloat64(workload.PrefixScanProportionProperty, 0) > 0 {
+		problems = append(problems, fmt.Sprintf("%s needs %s", workload.PrefixScanProportionProperty, workload.PrefixCountProperty))
+	} else if count > props.GetInt64(prop.RecordCount, 0) {
+		notes = append(notes, fmt.Sprintf("%s is above %s, so some prefixes start empty", workload.PrefixCountProperty, prop.RecordCount))
+	}
+	if err := db.CheckValueSize(dbName, props); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := db.CheckReadOnly(dbName, props); err != nil {
+		problems = append(problems, err.Error())
+	} else if props.GetBool(db.ReadOnlyProperty, false) {
+		if props.GetBool(bench.CompactProperty, false) {
+			problems = append(problems, fmt.Sprintf("%s cannot be combined with %s, which never writes to the database", bench.CompactProperty, db.ReadOnlyProperty))
+		}
+		if _, err := os.Stat(defaultDatadir(dbName, props)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s needs a loaded data directory, and %s has none", db.ReadOnlyProperty, defaultDatadir(dbName, props)))
+		}
+	}
+	if encoding, ok := props.Get(db.KeyEncodingProperty); ok && !slices.Contains(db.KeyEncodings, encoding) {
+		problems = append(problems, fmt.Sprintf("unknown %s %q (expected one of %s)", db.KeyEncodingProperty, encoding, strings.Join(db.KeyEncodings, ", ")))
+	} else if encoding == db.KeyEncodingSHA256 && props.GetFloat64(workload.PrefixScanProportionProperty, 0) > 0 {
+		problems = append(problems, fmt.Sprintf("%s cannot be combined with %s=%s, as hashed keys share no prefixes", workload.PrefixScanProportionProperty, db.KeyEncodingProperty, encoding))
+	}
+	if tables := props.GetInt64(db.TableCountProperty, 1); tables < 1 {
+		problems = append(problems, fmt.Sprintf("%s must be at least 1, not %d", db.TableCountProperty, tables))
+	} else if tables > 1 && props.GetInt64(prop.BatchSize, 1) > 1 {
+		problems = append(problems, fmt.Sprintf("%s cannot be combined with %s above 1", db.TableCountProperty, prop.BatchSize))
+	} else if tables > 1 && props.GetString(bench.RecordTraceProperty, "") != "" {
+		problems = append(problems, fmt.Sprintf("%s cannot be combined with %s above 1, as traces do not record tables", bench.RecordTraceProperty, db.TableCountProperty))
+	}
+	if length := props.GetInt64(workload.PartialReadLengthProperty, 4096); length < 1 {
+		problems = append(problems, fmt.Sprintf("%s must be at least 1, not %d", workload.PartialReadLengthProperty, length))
+	}
+	if keys := props.GetInt64(workload.PrefixKeysProperty, 0); keys < 0 {
+		problems = append(problems, fmt.Sprintf("%s must not be negative, not %d", workload.PrefixKeysProperty, keys))
+	}
+
+	// A replayed trace brings its own mix of operations
+	if props.GetBool(prop.DoTransactions, true) && workloadName != workload.TraceWorkload {
+		sum := props.GetFloat64(prop.ReadProportion, prop.ReadProportionDefault) +
+			props.GetFloat64(prop.UpdateProportion, prop.UpdateProportionDefault) +
+			props.GetFloat64(prop.InsertProportion, prop.InsertProportionDefault) +
+			props.GetFloat64(prop.ScanProportion, prop.ScanProportionDefault) +
+			props.GetFloat64(prop.ReadModifyWriteProportion, prop.ReadModifyWriteProportionDefault) +
+			props.GetFloat64(workload.PrefixScanProportionProperty, 0) +
+			props.GetFloat64(workload.PartialReadProportionProperty, 0)
+		if math.Abs(sum-1) > 1e-9 {
+			problems = append(problems, fmt.Sprintf("operation proportions sum to %g, not 1", sum))
+		}
+	}
+
+	datadir := defaultDatadir(dbName, props)
+	if _, err := os.Stat(datadir); err == nil {
+		notes = append(notes, fmt.Sprintf("data directory %s already exists and may be reused", datadir))
+	}
+
+	return problems, notes
+}
+
+// closestProperty returns the known property nearest to name by edit
+// distance, if it is close enough to be a likely typo
+func closestProperty(name string, known map[string]bool) string {
+	best, bestDist := "", len(name)/3+2
+	for k := range known {
+		if d := editDistance(name, k); d < bestDist || (d == bestDist && best != "" && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
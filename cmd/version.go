@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var versionJSON bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print build information and the benchmark environment",
+	Run: func(cmd *cobra.Command, args []string) {
+		env := metrics.CaptureEnvironment()
+		if versionJSON {
+			if err := env.EncodeJSON(os.Stdout); err != nil {
+				fmt.Printf("Failed to encode environment: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		env.Print()
+	},
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	webhookURL    string
+	webhookFormat string
+)
+
+// addWebhookFlags registers the flags that post every finished run, and
+// every failed benchmark, to a webhook. They are persistent so every
+// benchmark command shares them. The URL usually embeds a secret, so its
+// environment variable is read only when notifying, not printed by --help.
+func addWebhookFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&webhookURL, "webhook-url", "", "URL a summary of every finished run, and of a failed benchmark, is posted to (default $GODB_BENCH_WEBHOOK_URL)")
+	cmd.PersistentFlags().StringVar(&webhookFormat, "webhook-format", metrics.WebhookJSON, "Webhook payload: json (the summary itself) or slack (an incoming-webhook message)")
+}
+
+// notifyRun posts a summary of a finished run to the webhook, if one is set.
+// Failures are reported but never fail the benchmark.
+func notifyRun(name string, run *ycsbRun) {
+	notifyRunResults(run.toResults(name), run.Start, run.End)
+}
+
+// notifyRunResults posts the results of a run from start to end, like
+// notifyRun
+func notifyRunResults(results metrics.Results, start, end time.Time) {
+	notifyWebhook(metrics.CompletedEvent(historyCommand, results, start, end))
+}
+
+// notifyFailure posts that the benchmark failed with err, if a webhook is
+// set, so unattended runs do not go unnoticed
+func notifyFailure(err error) {
+	host, _ := os.Hostname()
+	notifyWebhook(metrics.FailedEvent(historyCommand, host, err))
+}
+
+// notifyWebhook posts event to the webhook, if one is set
+func notifyWebhook(event metrics.WebhookEvent) {
+	url := webhookURL
+	if url == "" {
+		url = os.Getenv("GODB_BENCH_WEBHOOK_URL")
+	}
+	if url == "" {
+		return
+	}
+	if err := metrics.ValidateWebhookFormat(webhookFormat); err != nil {
+		fmt.Printf("Warning: failed to notify webhook: %v\n", err)
+		return
+	}
+	if err := metrics.NewWebhook(url, webhookFormat).Notify(context.Background(), event); err != nil {
+		fmt.Printf("Warning: failed to notify webhook: %v\n", err)
+		return
+	}
+	fmt.Printf("Webhook notified: %s %s\n", event.Command, event.Status)
+}
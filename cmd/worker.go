@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/magiconair/properties"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/cluster"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+var (
+	workerListen         string
+	workerPropertyValues []string
+)
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Serve benchmark runs to a coordinator over gRPC",
+	Long: `Wait for a coordinator (see "coordinator") to dispatch workloads, run each
+against a local backend and send back its histograms and samples. A worker
+runs one workload at a time. -p properties override those of every workload,
+for settings local to this machine such as the data directory; only they
+may set paths on it, so coordinators cannot have its directories removed.
+
+It listens on localhost unless --listen says otherwise. The service is
+unauthenticated plain gRPC: only listen on a trusted network.
+
+  godb-bench worker --listen :7070 -p datadir=/nvme/godb-bench`,
+	Run: func(cmd *cobra.Command, args []string) {
+		overrides := properties.NewProperties()
+		if err := applyPropertyOverrides(overrides, workerPropertyValues); err != nil {
+			fmt.Printf("Failed to load properties: %v\n", err)
+			os.Exit(1)
+		}
+
+		lis, err := net.Listen("tcp", workerListen)
+		if err != nil {
+			fmt.Printf("Failed to start worker: %v\n", err)
+			os.Exit(1)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		worker := &cluster.Worker{
+			Overrides: overrides,
+			Log:       os.Stdout,
+			Finished: func(result *bench.Result) {
+				metrics.PrintMetricsTable(result.Operations)
+			},
+		}
+		fmt.Printf("Worker listening on %s\n", lis.Addr())
+		if err := worker.Serve(ctx, lis); err != nil {
+			fmt.Printf("Worker failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/workload"
+)
+
+var (
+	workloadGenPreset      string
+	workloadGenOutput      string
+	workloadGenListPresets bool
+)
+
+// workloadGenFlags maps generator flags to the workload properties they set.
+// The order is also the order properties are written in.
+var workloadGenFlags = []struct {
+	flag     string
+	property string
+}{
+	{"recordcount", prop.RecordCount},
+	{"operationcount", prop.OperationCount},
+	{"fieldcount", prop.FieldCount},
+	{"fieldlength", prop.FieldLength},
+	{"read", prop.ReadProportion},
+	{"update", prop.UpdateProportion},
+	{"insert", prop.InsertProportion},
+	{"scan", prop.ScanProportion},
+	{"rmw", prop.ReadModifyWriteProportion},
+	{"prefix-scan", workload.PrefixScanProportionProperty},
+	{"partial-read", workload.PartialReadProportionProperty},
+	{"distribution", prop.RequestDistribution},
+	{"insert-order", prop.InsertOrder},
+	{"max-scan-length", prop.MaxScanLength},
+	{"scan-length-distribution", prop.ScanLengthDistribution},
+	{"compression-ratio", workload.CompressionRatioProperty},
+	{"hotset-keys", workload.HotSetKeysProperty},
+	{"hotset-fraction", workload.HotSetFractionProperty},
+	{"prefixes", workload.PrefixCountProperty},
+	{"prefix-keys", workload.PrefixKeysProperty},
+	{"tables", db.TableCountProperty},
+	{"partial-read-length", workload.PartialReadLengthProperty},
+}
+
+// workloadGenDefaults are written unless a preset or flag overrides them
+var workloadGenDefaults = map[string]string{
+	prop.Workload:                  "core",
+	prop.RecordCount:               "1000",
+	prop.OperationCount:            "1000",
+	prop.ReadAllFields:             "true",
+	prop.ReadProportion:            "0.5",
+	prop.UpdateProportion:          "0.5",
+	prop.InsertProportion:          "0",
+	prop.ScanProportion:            "0",
+	prop.ReadModifyWriteProportion: "0",
+	prop.RequestDistribution:       "uniform",
+}
+
+// workloadProportions are the operation mix properties that must sum to 1
+var workloadProportions = []string{
+	prop.ReadProportion,
+	prop.UpdateProportion,
+	prop.InsertProportion,
+	prop.ScanProportion,
+	prop.ReadModifyWriteProportion,
+	workload.PrefixScanProportionProperty,
+	workload.PartialReadProportionProperty,
+}
+
+var workloadCmd = &cobra.Command{
+	Use:   "workload",
+	Short: "Work with YCSB workload files",
+}
+
+var workloadGenCmd = &cobra.Command{
+	Use:   "gen",
+	Short: "Generate a YCSB workload file from flags or a preset",
+	Long: `Generate a YCSB workload file. Start from a preset (--preset, see
+--list-presets) and/or set individual properties with flags; flags win over
+the preset. The file is written to stdout unless --output is given.
+
+  godb-bench workload gen --preset ethereum-state-like --recordcount 100000 -o state.spec`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if workloadGenListPresets {
+			printWorkloadPresets(os.Stdout)
+			return
+		}
+
+		props, err := generateWorkload(cmd)
+		if err != nil {
+			fmt.Printf("Failed to generate workload: %v\n", err)
+			os.Exit(1)
+		}
+
+		w := io.Writer(os.Stdout)
+		if workloadGenOutput != "" {
+			f, err := os.Create(workloadGenOutput)
+			if err != nil {
+				fmt.Printf("Failed to create %s: %v\n", workloadGenOutput, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err := writeWorkloadFile(w, props, os.Args[1:]); err != nil {
+			fmt.Printf("Failed to write workload: %v\n", err)
+			os.Exit(1)
+		}
+		if workloadGenOutput != "" {
+			fmt.Printf("Workload written to %s\n", workloadGenOutput)
+		}
+	},
+}
+
+// generateWorkload combines the defaults, the preset and the changed flags,
+// then checks that the operation mix is valid
+func generateWorkload(cmd *cobra.Command) (map[string]string, error) {
+	props := make(map[string]string, len(workloadGenDefaults))
+	for k, v := range workloadGenDefaults {
+		props[k] = v
+	}
+
+	if workloadGenPreset != "" {
+		preset, ok := workload.FindPreset(workloadGenPreset)
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q (see --list-presets)", workloadGenPreset)
+		}
+		for k, v := range preset.Properties {
+			props[k] = v
+		}
+	}
+
+	for _, f := range workloadGenFlags {
+		if cmd.Flags().Changed(f.flag) {
+			props[f.property] = cmd.Flags().Lookup(f.flag).Value.String()
+		}
+	}
+
+	var sum float64
+	for _, p := range workloadProportions {
+		if _, ok := props[p]; !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(props[p], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", p, props[p], err)
+		}
+		if v < 0 {
+			return nil, fmt.Errorf("%s must not be negative, got %s", p, props[p])
+		}
+		sum += v
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		return nil, fmt.Errorf("operation proportions must sum to 1, got %g", sum)
+	}
+
+	if v, ok := props[workload.PrefixScanProportionProperty]; ok && v != "0" && props[workload.PrefixCountProperty] == "" {
+		return nil, fmt.Errorf("prefix scans need keys laid out under prefixes (--prefixes)")
+	}
+	// The runner sizes the key space from the prefixes too; this keeps the
+	// file telling the truth
+	if count, keys := props[workload.PrefixCountProperty], props[workload.PrefixKeysProperty]; count != "" && keys != "" {
+		c, _ := strconv.ParseInt(count, 10, 64)
+		k, _ := strconv.ParseInt(keys, 10, 64)
+		if k > 0 {
+			props[prop.RecordCount] = strconv.FormatInt(c*k, 10)
+		}
+	}
+
+	switch props[prop.RequestDistribution] {
+	case "uniform", "zipfian", "latest", "hotspot", "sequential", "exponential":
+	default:
+		return nil, fmt.Errorf("unknown request distribution %q", props[prop.RequestDistribution])
+	}
+	switch d := props[prop.ScanLengthDistribution]; d {
+	case "", "uniform", "zipfian":
+	default:
+		return nil, fmt.Errorf("unknown scan length distribution %q", d)
+	}
+	switch order := props[prop.InsertOrder]; order {
+	case "", "hashed", "ordered", workload.InsertOrderMonotonic:
+	default:
+		return nil, fmt.Errorf("unknown insert order %q", order)
+	}
+
+	return props, nil
+}
+
+// writeWorkloadFile writes props as a property file, known keys first in a
+// stable order followed by any others sorted by name
+func writeWorkloadFile(w io.Writer, props map[string]string, args []string) error {
+	order := []string{prop.Workload}
+	for _, f := range workloadGenFlags {
+		order = append(order, f.property)
+	}
+	order = append(order, prop.ReadAllFields)
+
+	written := make(map[string]bool, len(props))
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by: godb-bench %s\n\n", strings.Join(args, " "))
+	for _, k := range order {
+		if v, ok := props[k]; ok && !written[k] {
+			fmt.Fprintf(&b, "%s=%s\n", k, v)
+			written[k] = true
+		}
+	}
+
+	var rest []string
+	for k := range props {
+		if !written[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	for _, k := range rest {
+		fmt.Fprintf(&b, "%s=%s\n", k, props[k])
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// printWorkloadPresets lists the available presets
+func printWorkloadPresets(w io.Writer) {
+	for _, p := range workload.Presets {
+		fmt.Fprintf(w, "%-22s %s\n", p.Name, p.Description)
+	}
+}
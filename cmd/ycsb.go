@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/bench"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/workload"
+)
+
+// ycsbOptions are the flags of a ycsb command
+type ycsbOptions struct {
+	db               string
+	propertyFile     string
+	propertyValues   []string
+	workloadFile     string
+	runs             int
+	freshDatadir     bool
+	duration         time.Duration
+	targetOps        float64
+	maxErrorRate     float64
+	opTimeout        time.Duration
+	opRetries        int
+	seed             int64
+	outputFormat     string
+	outputPath       string
+	resultsDir       string
+	flatPlotsDir     string // plots directory when there is no results directory
+	statistics       bool
+	perThread        bool
+	reservoir        int
+	resamples        int
+	confidenceLevel  float64
+	percentiles      string
+	excludeErrors    bool
+	seriesInterval   time.Duration
+	resourceInterval time.Duration
+	progressInterval time.Duration
+	assertExprs      []string
+	distribution     float64
+	recordTraceFile  string
+	replayTraceFile  string
+	plots            plotOptions
+	profiles         profileOptions
+}
+
+// newYCSBCmd returns the ycsb command running any registered backend, chosen
+// by --db. Every backend so has the same flags and runs through
+// runYCSBRepeated, with the same operation tracking, statistics, plots and
+// results files, as soon as it is registered.
+func newYCSBCmd() *cobra.Command {
+	o := &ycsbOptions{}
+	cmd := &cobra.Command{
+		Use:   "ycsb",
+		Short: "Run the YCSB benchmark on a backend",
+		Long: `Run the YCSB benchmark on the backend given by --db, any registered one.
+Every invocation gets a run ID, and its results.json, raw samples, plots,
+profiles and output.log go to results/<db>/<workload>/<run-id>/, next to a
+latest link to the newest run of the workload.`,
+		Example: `  godb-bench ycsb --db pebble -w workload.spec
+  godb-bench ycsb --db triedb -w workload.spec -p value_parity=true`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !slices.Contains(db.Backends(), o.db) {
+				fmt.Printf("Unknown db %q (available: %s)\n", o.db, strings.Join(db.Backends(), ", "))
+				os.Exit(1)
+			}
+			o.flatPlotsDir = fmt.Sprintf("./%s_benchmark_plots", o.db)
+			o.run(cmd)
+		},
+	}
+	cmd.Flags().StringVar(&o.db, "db", "pebble", "Backend to run: "+strings.Join(db.Backends(), ", "))
+	addYCSBFlags(cmd, o, "./<db>_benchmark_plots")
+	return cmd
+}
+
+// newBackendYCSBCmd returns the ycsb subcommand of a backend's own command,
+// the same as ycsb --db dbName but for its plots directory without
+// --results-dir
+func newBackendYCSBCmd(dbName, short, flatPlotsDir string) *cobra.Command {
+	o := &ycsbOptions{db: dbName, flatPlotsDir: flatPlotsDir}
+	cmd := &cobra.Command{
+		Use:   "ycsb",
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.run(cmd)
+		},
+	}
+	addYCSBFlags(cmd, o, flatPlotsDir)
+	return cmd
+}
+
+// addYCSBFlags registers the flags of a ycsb command on cmd
+func addYCSBFlags(cmd *cobra.Command, o *ycsbOptions, flatPlotsDir string) {
+	cmd.Flags().StringVarP(&o.workloadFile, "workload", "w", "", "Path to the YCSB workload file")
+	cmd.Flags().StringVarP(&o.propertyFile, "property_file", "P", "", "Path to the YCSB property file")
+	cmd.Flags().StringArrayVarP(&o.propertyValues, "prop", "p", nil, "YCSB property (e.g. -p key=value)")
+	cmd.Flags().IntVar(&o.runs, "runs", 1, "Number of times to run the workload; reports cross-run aggregates when > 1")
+	cmd.Flags().BoolVar(&o.freshDatadir, "fresh-datadir", false, "Remove the data directory before each run")
+	cmd.Flags().DurationVar(&o.duration, "duration", 0, "Run the workload for this long (after warm-up) instead of until operationcount")
+	cmd.Flags().Int64Var(&o.seed, "seed", 0, "Seed the workload generators and bootstrap resampling for a reproducible operation stream")
+	cmd.Flags().Float64Var(&o.targetOps, "target-ops", 0, "Pace requests to this many operations per second across all threads (0 = unlimited)")
+	cmd.Flags().Float64Var(&o.maxErrorRate, "max-error-rate", 0, "Abort the run, reporting what it measured, once more than this percentage of operations has failed (0 = never)")
+	cmd.Flags().DurationVar(&o.opTimeout, "op-timeout", 0, "Abandon any attempt of an operation that takes longer than this (0 = never)")
+	cmd.Flags().IntVar(&o.opRetries, "op-retries", 0, "Retry a failed or timed-out operation up to this many times, with doubling backoff (missing keys are not retried)")
+	cmd.Flags().BoolVar(&o.statistics, "statistics", false, "Compute criterion-style statistics with bootstrap confidence intervals (slow on large runs)")
+	cmd.Flags().BoolVar(&o.perThread, "per-thread", false, "Print per-thread statistics and plot every thread's samples")
+	cmd.Flags().IntVar(&o.reservoir, "reservoir", 0, "Keep at most this many uniformly sampled points per operation for plots and statistics (0 = all)")
+	cmd.Flags().IntVar(&o.resamples, "resamples", metrics.DefaultBootstrapResamples, "Bootstrap resamples behind each confidence interval; fewer is faster and less precise")
+	cmd.Flags().Float64Var(&o.confidenceLevel, "confidence-level", metrics.DefaultConfidenceLevel, "Confidence level of the bootstrap intervals (e.g. 0.99)")
+	cmd.Flags().StringVar(&o.percentiles, "percentiles", "", "Comma-separated percentile columns of the results table, e.g. 50,90,99.99 (default 50,95,99,99.9)")
+	cmd.Flags().BoolVar(&o.excludeErrors, "exclude-errors", false, "Leave failed operations out of plots and statistics (they are always counted in the results table)")
+	cmd.Flags().DurationVar(&o.seriesInterval, "series-interval", metrics.DefaultSeriesInterval, "Interval of the throughput and latency time series in json and csv results (0 disables them)")
+	cmd.Flags().DurationVar(&o.resourceInterval, "resource-interval", metrics.DefaultResourceInterval, "How often CPU, RSS, open files and disk IO are sampled (0 disables it)")
+	cmd.Flags().DurationVar(&o.progressInterval, "progress-interval", metrics.DefaultProgressInterval, "How often a progress line with the phase, operations done, throughput and ETA is printed (0 disables it)")
+	cmd.Flags().Float64Var(&o.distribution, "distribution", 0, "Add each operation's latency at every percentile this many percent apart (e.g. 0.1) to json and csv results")
+	cmd.Flags().StringVar(&o.recordTraceFile, "record-trace", "", "Record every operation (op, key, value size) to this file, gzipped if it ends in .gz")
+	cmd.Flags().StringVar(&o.replayTraceFile, "replay-trace", "", "Re-issue the operations recorded in this file instead of the workload's own")
+	cmd.Flags().StringArrayVar(&o.assertExprs, "assert", nil, "Fail with a non-zero exit when a metric misses its bound after the run, e.g. p99.READ<2ms or throughput.TOTAL>50000 (repeatable)")
+	cmd.Flags().StringVarP(&o.outputFormat, "output", "o", "table", "Results format: table, json, csv, markdown or criterion")
+	addPlotFlags(cmd, &o.plots, "")
+	cmd.Flags().Lookup("plots-dir").Usage = "Directory for plots (default the run directory, or " + flatPlotsDir + " with --results-dir=\"\")"
+	addProfileFlags(cmd, &o.profiles)
+	addDryRunFlag(cmd)
+	cmd.Flags().StringVar(&o.outputPath, "output-path", "", "File (json, markdown) or directory (csv, criterion) for results (default results.<format> in the run directory)")
+	cmd.Flags().StringVar(&o.resultsDir, "results-dir", defaultResultsDir, "Root of the run directories <db>/<workload>/<run-id>/ holding every artifact of a run (empty writes to the working directory as before)")
+}
+
+// run runs the workload of the flags against backend o.db
+func (o *ycsbOptions) run(cmd *cobra.Command) {
+	dbName := o.db
+	props, err := loadYCSBProperties(o.workloadFile, o.propertyFile, o.propertyValues)
+	if err != nil {
+		fmt.Printf("Failed to load properties: %v\n", err)
+		os.Exit(1)
+	}
+	if o.duration > 0 {
+		props.Set(bench.DurationProperty, o.duration.String())
+	}
+	if o.targetOps > 0 {
+		props.Set(bench.TargetOpsProperty, strconv.FormatFloat(o.targetOps, 'f', -1, 64))
+	}
+	if o.maxErrorRate > 0 {
+		props.Set(bench.MaxErrorRateProperty, strconv.FormatFloat(o.maxErrorRate, 'f', -1, 64))
+	}
+	if o.opTimeout > 0 {
+		props.Set(bench.OpTimeoutProperty, o.opTimeout.String())
+	}
+	if o.opRetries > 0 {
+		props.Set(bench.OpRetriesProperty, strconv.Itoa(o.opRetries))
+	}
+	if o.statistics {
+		props.Set(bench.StatisticsProperty, "true")
+	}
+	if o.perThread {
+		props.Set(bench.PerThreadProperty, "true")
+	}
+	if o.reservoir > 0 {
+		props.Set(bench.ReservoirProperty, strconv.Itoa(o.reservoir))
+	}
+	if cmd.Flags().Changed("resamples") {
+		props.Set(bench.ResamplesProperty, strconv.Itoa(o.resamples))
+	}
+	if cmd.Flags().Changed("confidence-level") {
+		props.Set(bench.ConfidenceLevelProperty, strconv.FormatFloat(o.confidenceLevel, 'f', -1, 64))
+	}
+	if o.percentiles != "" {
+		props.Set(bench.PercentilesProperty, o.percentiles)
+	}
+	if o.excludeErrors {
+		props.Set(bench.ExcludeErrorsProperty, "true")
+	}
+	if cmd.Flags().Changed("series-interval") {
+		props.Set(bench.SeriesIntervalProperty, o.seriesInterval.String())
+	}
+	if cmd.Flags().Changed("resource-interval") {
+		props.Set(bench.ResourceIntervalProperty, o.resourceInterval.String())
+	}
+	if cmd.Flags().Changed("progress-interval") {
+		props.Set(bench.ProgressIntervalProperty, o.progressInterval.String())
+	}
+	if o.distribution > 0 {
+		props.Set(bench.DistributionProperty, strconv.FormatFloat(o.distribution, 'f', -1, 64))
+	}
+	if o.recordTraceFile != "" {
+		props.Set(bench.RecordTraceProperty, o.recordTraceFile)
+	}
+	if o.replayTraceFile != "" {
+		props.Set(bench.ReplayTraceProperty, o.replayTraceFile)
+	}
+	if cmd.Flags().Changed("seed") {
+		props.Set(workload.SeedProperty, strconv.FormatInt(o.seed, 10))
+	}
+
+	assertions, assertErr := parseAssertions(o.assertExprs)
+	if dryRun {
+		runDryRun([]dryRunTarget{{label: dbName, dbName: dbName, props: props}},
+			metrics.ValidateFormat(o.outputFormat), o.plots.validate(), assertErr)
+		return
+	}
+	if assertErr != nil {
+		fmt.Printf("Invalid assertion: %v\n", assertErr)
+		os.Exit(1)
+	}
+
+	// Every artifact of the run goes to its run directory, unless
+	// --results-dir is empty
+	out := resultsOutput{format: o.outputFormat, path: o.outputPath}
+	var layout runLayout
+	stopLog := func() {}
+	if o.resultsDir != "" {
+		layout = newRunLayout(o.resultsDir, dbName, props)
+		if o.plots.dir == "" {
+			o.plots.dir = layout.dir
+		}
+		o.profiles = o.profiles.in(layout.dir)
+		out.runDir = layout.dir
+		if out.log, stopLog, err = layout.start(); err != nil {
+			fmt.Printf("Benchmark failed: %v\n", err)
+			notifyFailure(err)
+			os.Exit(1)
+		}
+	} else if o.plots.dir == "" {
+		o.plots.dir = o.flatPlotsDir
+	}
+
+	results, err := runYCSBRepeated(dbName, props, o.plots, o.profiles, o.runs, o.freshDatadir, out, layout.id)
+	passed := err == nil && checkAssertions(assertions, results)
+	if err != nil {
+		fmt.Printf("Benchmark failed: %v\n", err)
+		notifyFailure(err)
+	}
+	stopLog()
+	if err == nil && o.resultsDir != "" {
+		if err := layout.linkLatest(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	if !passed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+
+	"github.com/holiman/uint256"
+)
+
+// Account is the part of an account an account update changes
+type Account struct {
+	Nonce   uint64
+	Balance *uint256.Int
+}
+
+// AccountWriter is implemented by backends that store accounts, not only
+// key-value pairs: it reads every account of addresses, an account that does
+// not exist yet as a zero one, lets update change account i in place, and
+// writes them all back in one commit, as a node applies a block's transfers
+type AccountWriter interface {
+	UpdateAccounts(ctx context.Context, addresses [][20]byte, update func(i int, account *Account)) error
+}
+
+// AccountDestroyer is implemented by backends that keep the storage of every
+// account apart, so a whole account can be deleted at once, as SELFDESTRUCT
+// clears a contract
+type AccountDestroyer interface {
+	// CreateAccount creates the account at address with values[i] in storage
+	// slot slots[i], in one commit
+	CreateAccount(ctx context.Context, address [20]byte, slots, values [][32]byte) error
+
+	// DestroyAccount deletes the account at address with all of its storage,
+	// in one commit
+	DestroyAccount(ctx context.Context, address [20]byte) error
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// Batch groups operations of any kind into one batch or transaction: writes
+// are applied together by Commit, and reads see the batch's earlier writes
+type Batch interface {
+	Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error)
+	Update(ctx context.Context, table string, key string, values map[string][]byte) error
+	Insert(ctx context.Context, table string, key string, values map[string][]byte) error
+	Delete(ctx context.Context, table string, key string) error
+	ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error
+	Commit(ctx context.Context) error
+	Rollback()
+}
+
+// BatchedDB is implemented by backends and wrappers that can group the
+// operations of a client into a Batch of size operations
+type BatchedDB interface {
+	NewBatch(ctx context.Context, size int) (Batch, error)
+}
+
+// NewBatch starts a batch of size operations on db, or, if db cannot group
+// operations, returns one issuing each operation on its own as it comes.
+// go-ycsb's wrapper is looked through, as it would hide the method.
+func NewBatch(ctx context.Context, db ycsb.DB, size int) (Batch, error) {
+	if w, ok := db.(client.DbWrapper); ok {
+		db = w.DB
+	}
+	if b, ok := db.(BatchedDB); ok {
+		return b.NewBatch(ctx, size)
+	}
+	return Unbatched(db), nil
+}
+
+// Unbatched returns a Batch issuing every operation on db on its own
+func Unbatched(db ycsb.DB) Batch {
+	return unbatched{db}
+}
+
+// unbatched is the Batch of backends without one: every operation is applied
+// when issued and Commit has nothing left to do
+type unbatched struct {
+	ycsb.DB
+}
+
+func (u unbatched) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	return ReadModifyWrite(ctx, u.DB, table, key, fields, modify)
+}
+
+func (u unbatched) Commit(ctx context.Context) error {
+	return nil
+}
+
+func (u unbatched) Rollback() {
+}
+
+// InBatch returns db with its reads and writes issued through b. Scans still
+// go to db directly, as batches cannot scan.
+func InBatch(db ycsb.DB, b Batch) ycsb.DB {
+	return batchView{DB: db, batch: b}
+}
+
+// batchView is the ycsb.DB InBatch returns
+type batchView struct {
+	ycsb.DB
+	batch Batch
+}
+
+func (v batchView) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	return v.batch.Read(ctx, table, key, fields)
+}
+
+func (v batchView) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return v.batch.Update(ctx, table, key, values)
+}
+
+func (v batchView) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return v.batch.Insert(ctx, table, key, values)
+}
+
+func (v batchView) Delete(ctx context.Context, table string, key string) error {
+	return v.batch.Delete(ctx, table, key)
+}
+
+// PartialRead reads the whole record through the batch, so it sees the
+// batch's writes
+func (v batchView) PartialRead(ctx context.Context, table string, key string, field string, offset int, length int) ([]byte, error) {
+	values, err := v.batch.Read(ctx, table, key, []string{field})
+	if err != nil {
+		return nil, err
+	}
+	return valueRange(values[field], offset, length), nil
+}
+
+func (v batchView) PrefixScan(ctx context.Context, table string, prefix string, fields []string) ([]map[string][]byte, error) {
+	return PrefixScan(ctx, v.DB, table, prefix, fields)
+}
+
+func (v batchView) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	return v.batch.ReadModifyWrite(ctx, table, key, fields, modify)
+}
@@ -0,0 +1,10 @@
+package db
+
+import "context"
+
+// BlockWriter is implemented by backends that can commit the state writes of
+// a block atomically, as a node commits a block: values[i] is the new value
+// of keys[i], or nil to delete it
+type BlockWriter interface {
+	WriteBlock(ctx context.Context, keys []string, values [][]byte) error
+}
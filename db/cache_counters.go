@@ -0,0 +1,15 @@
+package db
+
+// CacheCounters are a block cache's cumulative lookups and the bytes it
+// holds now
+type CacheCounters struct {
+	Hits   int64
+	Misses int64
+	Bytes  int64
+}
+
+// CacheCounter is implemented by backends with a block cache that count
+// its lookups
+type CacheCounter interface {
+	CacheCounters() CacheCounters
+}
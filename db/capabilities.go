@@ -0,0 +1,80 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// SlotSize is the bytes of a TrieDB storage slot, all it keeps of a value
+const SlotSize = 32
+
+// ValueParityProperty makes every value of the core workload SlotSize bytes,
+// in a single field of constant length, so every backend stores the whole
+// of the same values, as TrieDB's storage slots hold
+const ValueParityProperty = "value_parity"
+
+// Capabilities are what a backend can do and how it does it, for reports
+// comparing backends to tell when they ran semantically different work
+type Capabilities struct {
+	Scan         bool   // scans records in key order
+	PrefixScan   bool   // scans every record under a key prefix
+	PartialRead  bool   // reads part of a value without the rest
+	Sync         string // when writes are made durable
+	Transactions string // what a read-modify-write and a txnsize group run in
+	Batching     string // how the batches of batch.size are committed
+	KeyEncoding  string // see KeyEncodingProperty; empty if not known
+	MaxValueSize int    // bytes kept of each value, 0 for all of them
+	ReadOnly     bool   // opens read-only, see ReadOnlyProperty
+}
+
+// backendCapabilities lists the capabilities each backend registered
+var backendCapabilities = make(map[string]Capabilities)
+
+// registerCapabilities records the capabilities of the named backend, with
+// the key encoding it uses by default
+func registerCapabilities(name string, c Capabilities) {
+	backendCapabilities[name] = c
+}
+
+// BackendCapabilities returns the capabilities of the named backend in a run
+// with properties p, or false if it did not register them
+func BackendCapabilities(name string, p *properties.Properties) (Capabilities, bool) {
+	c, ok := backendCapabilities[name]
+	if encoding := p.GetString(KeyEncodingProperty, ""); ok && encoding != "" {
+		c.KeyEncoding = encoding
+	}
+	if name == "pebble" && p.GetBool(PebbleDisableWALProperty, false) {
+		c.Sync = "none: WAL disabled, durable once the memtable is flushed"
+	}
+	if c.KeyEncoding == KeyEncodingSHA256 {
+		// Hashed keys share no prefixes
+		c.PrefixScan = false
+	}
+	return c, ok
+}
+
+// ValueSize returns the longest value the core workload writes with
+// properties p, all of its fields together, or 0 for other workloads
+func ValueSize(p *properties.Properties) int64 {
+	if p.GetString(prop.Workload, "core") != "core" {
+		return 0
+	}
+	return p.GetInt64(prop.FieldCount, prop.FieldCountDefault) * p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+}
+
+// CheckValueSize fails if the named backend would keep only part of the
+// values the core workload writes with properties p, rather than silently
+// benchmark smaller values than the other backends. A value is all of its
+// fields, of which such a backend keeps only one.
+func CheckValueSize(name string, p *properties.Properties) error {
+	c, _ := BackendCapabilities(name, p)
+	if size := ValueSize(p); c.MaxValueSize > 0 && size > int64(c.MaxValueSize) {
+		return fmt.Errorf("%s keeps only %d bytes of each value, not the %d of %s=%d fields of %s=%d: set %s=true, or %s=1 and %s to at most %d",
+			name, c.MaxValueSize, size, prop.FieldCount, p.GetInt64(prop.FieldCount, prop.FieldCountDefault),
+			prop.FieldLength, p.GetInt64(prop.FieldLength, prop.FieldLengthDefault),
+			ValueParityProperty, prop.FieldCount, prop.FieldLength, c.MaxValueSize)
+	}
+	return nil
+}
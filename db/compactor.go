@@ -0,0 +1,11 @@
+package db
+
+import "context"
+
+// Compactor is implemented by backends that can compact their whole key
+// space on demand, rewriting it without the tombstones of deleted keys
+type Compactor interface {
+	Flush() error // writes out what is only in memory and the log
+	Compact(ctx context.Context) error
+	DiskUsage() uint64 // bytes of the engine's live data files
+}
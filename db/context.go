@@ -0,0 +1,11 @@
+package db
+
+// Backends honor the context of every operation: one canceled or past its
+// deadline, at the end of a timed run, on an interrupt or at an operation
+// timeout, fails with the context's error before touching the engine, and
+// scans, batches and compactions stop between records. Calls into the engine
+// itself cannot be interrupted.
+
+// checkEvery is how many records a scan or batch goes through between checks
+// of its context
+const checkEvery = 64
@@ -0,0 +1,8 @@
+package db
+
+// CrashImager is implemented by backends that can copy to dir what a crash
+// of the process would leave on disk now, so recovery from it can be
+// measured and checked without crashing the run
+type CrashImager interface {
+	CrashImage(dir string) error
+}
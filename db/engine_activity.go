@@ -0,0 +1,27 @@
+package db
+
+import "time"
+
+// EngineActivity is a point-in-time view of an engine's background work, for
+// watching flushes and compactions keep up while a run is in progress
+type EngineActivity struct {
+	Flushes               int64 // completed since the engine opened
+	FlushesInProgress     int64
+	Compactions           int64 // completed since the engine opened
+	CompactionsInProgress int64
+	CompactionDebt        uint64 // estimated bytes left to compact
+	L0Files               int64
+	L0Sublevels           int
+	L0StopWrites          int // sublevels at which writes stall until L0 is compacted; 0 if unknown
+	ReadAmp               int
+	MemtableBytes         uint64
+	WriteStalls           int64         // begun since the engine opened
+	WriteStallTime        time.Duration // spent stalled since the engine opened, an ongoing stall included
+	L0WriteStalls         int64         // of WriteStalls, those on too many L0 sublevels
+	L0WriteStallTime      time.Duration // of WriteStallTime, spent in them
+}
+
+// EngineReporter is implemented by backends that expose their EngineActivity
+type EngineReporter interface {
+	EngineActivity() EngineActivity
+}
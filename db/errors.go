@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned, wrapped, by every backend when a read finds no
+// value for its key. A miss is expected in many workloads, unlike the other
+// failures.
+var ErrNotFound = errors.New("key not found")
+
+// Error classes reported by ErrorClass
+const (
+	ErrorClassNotFound = "not_found" // the key does not exist
+	ErrorClassCanceled = "canceled"  // the run ended while the operation was in flight
+	ErrorClassTimeout  = "timeout"   // the operation outlived its timeout
+	ErrorClassInternal = "internal"  // any other failure of the backend
+)
+
+// ErrorClass classifies an error returned by any backend, so failures can be
+// counted by cause
+func ErrorClass(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return ErrorClassNotFound
+	case errors.Is(err, ErrTimeout):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassCanceled
+	default:
+		return ErrorClassInternal
+	}
+}
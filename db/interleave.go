@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+// InterleavedDB alternates chunks of the operation stream between two
+// backends, A then B then A again, so both see the same drift in machine
+// state over a run: thermal throttling, background noise and the page cache
+// warming up. Operations are numbered in the order the client threads issue
+// them; a batch counts as one operation.
+//
+// Each side keeps its own data, so a key inserted through one side is only
+// found through that one. Both should be loaded with the same records first.
+type InterleavedDB struct {
+	sides [2]BatchingDB
+	chunk int64
+	seq   atomic.Int64
+
+	mu     sync.Mutex
+	chunks []InterleavedChunk // indexed by chunk, measured ones only
+}
+
+// InterleavedChunk is what one chunk of operations did on its side
+type InterleavedChunk struct {
+	Index      int64 // even chunks run on A, odd ones on B
+	Ops        int64 // failed ones included
+	Errors     int64
+	Latency    time.Duration // total of every operation
+	Start, End time.Time     // when its first operation started and its last finished
+}
+
+// Side returns 0 if the chunk ran on A, 1 if on B
+func (c InterleavedChunk) Side() int {
+	return int(c.Index % 2)
+}
+
+// NewInterleavedDB alternates between a and b every chunk operations
+func NewInterleavedDB(a, b BatchingDB, chunk int64) *InterleavedDB {
+	if chunk < 1 {
+		chunk = 1
+	}
+	return &InterleavedDB{sides: [2]BatchingDB{a, b}, chunk: chunk}
+}
+
+// Chunks returns every chunk measured after the warm-up, in order. The
+// first and last may be partial.
+func (i *InterleavedDB) Chunks() []InterleavedChunk {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var chunks []InterleavedChunk
+	for _, c := range i.chunks {
+		if c.Ops > 0 {
+			chunks = append(chunks, c)
+		}
+	}
+	return chunks
+}
+
+// next assigns the next operation to a chunk and returns the chunk and the
+// side it runs on
+func (i *InterleavedDB) next() (int64, BatchingDB) {
+	index := (i.seq.Add(1) - 1) / i.chunk
+	return index, i.sides[index%2]
+}
+
+// done records one operation of chunk index that started at start
+func (i *InterleavedDB) done(index int64, start time.Time, err error) {
+	if !measurement.IsWarmUpFinished() {
+		return
+	}
+	end := time.Now()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for int64(len(i.chunks)) <= index {
+		i.chunks = append(i.chunks, InterleavedChunk{Index: int64(len(i.chunks))})
+	}
+	c := &i.chunks[index]
+	if c.Ops == 0 || start.Before(c.Start) {
+		c.Start = start
+	}
+	if end.After(c.End) {
+		c.End = end
+	}
+	c.Ops++
+	if err != nil {
+		c.Errors++
+	}
+	c.Latency += end.Sub(start)
+}
+
+// InitThread initializes the thread on both sides
+func (i *InterleavedDB) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	ctx = i.sides[0].InitThread(ctx, threadID, threadCount)
+	return i.sides[1].InitThread(ctx, threadID, threadCount)
+}
+
+// CleanupThread cleans the thread up on both sides
+func (i *InterleavedDB) CleanupThread(ctx context.Context) {
+	i.sides[0].CleanupThread(ctx)
+	i.sides[1].CleanupThread(ctx)
+}
+
+// Close does nothing: the sides are closed by whoever opened them
+func (i *InterleavedDB) Close() error {
+	return nil
+}
+
+func (i *InterleavedDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	index, db := i.next()
+	start := time.Now()
+	values, err := db.Read(ctx, table, key, fields)
+	i.done(index, start, err)
+	return values, err
+}
+
+func (i *InterleavedDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	index, db := i.next()
+	start := time.Now()
+	values, err := db.Scan(ctx, table, startKey, count, fields)
+	i.done(index, start, err)
+	return values, err
+}
+
+func (i *InterleavedDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	index, db := i.next()
+	start := time.Now()
+	err := db.Update(ctx, table, key, values)
+	i.done(index, start, err)
+	return err
+}
+
+func (i *InterleavedDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	index, db := i.next()
+	start := time.Now()
+	err := db.Insert(ctx, table, key, values)
+	i.done(index, start, err)
+	return err
+}
+
+func (i *InterleavedDB) Delete(ctx context.Context, table string, key string) error {
+	index, db := i.next()
+	start := time.Now()
+	err := db.Delete(ctx, table, key)
+	i.done(index, start, err)
+	return err
+}
+
+func (i *InterleavedDB) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	index, db := i.next()
+	start := time.Now()
+	err := ReadModifyWrite(ctx, db, table, key, fields, modify)
+	i.done(index, start, err)
+	return err
+}
+
+func (i *InterleavedDB) PartialRead(ctx context.Context, table string, key string, field string, offset int, length int) ([]byte, error) {
+	index, db := i.next()
+	start := time.Now()
+	value, err := PartialRead(ctx, db, table, key, field, offset, length)
+	i.done(index, start, err)
+	return value, err
+}
+
+func (i *InterleavedDB) PrefixScan(ctx context.Context, table string, prefix string, fields []string) ([]map[string][]byte, error) {
+	index, db := i.next()
+	start := time.Now()
+	values, err := PrefixScan(ctx, db, table, prefix, fields)
+	i.done(index, start, err)
+	return values, err
+}
+
+// NewBatch starts a batch on the side of the next operation; the batch
+// counts as one operation
+func (i *InterleavedDB) NewBatch(ctx context.Context, size int) (Batch, error) {
+	index, db := i.next()
+	start := time.Now()
+	b, err := NewBatch(ctx, db, size)
+	if err != nil {
+		i.done(index, start, err)
+		return nil, err
+	}
+	return &interleavedBatch{Batch: b, i: i, index: index, start: start}, nil
+}
+
+// interleavedBatch is a batch on one side, recorded in its chunk when it
+// commits
+type interleavedBatch struct {
+	Batch
+	i     *InterleavedDB
+	index int64
+	start time.Time
+}
+
+func (b *interleavedBatch) Commit(ctx context.Context) error {
+	err := b.Batch.Commit(ctx)
+	b.i.done(b.index, b.start, err)
+	return err
+}
+
+func (i *InterleavedDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	index, db := i.next()
+	start := time.Now()
+	err := db.BatchInsert(ctx, table, keys, values)
+	i.done(index, start, err)
+	return err
+}
+
+func (i *InterleavedDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	index, db := i.next()
+	start := time.Now()
+	values, err := db.BatchRead(ctx, table, keys, fields)
+	i.done(index, start, err)
+	return values, err
+}
+
+func (i *InterleavedDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	index, db := i.next()
+	start := time.Now()
+	err := db.BatchUpdate(ctx, table, keys, values)
+	i.done(index, start, err)
+	return err
+}
+
+func (i *InterleavedDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	index, db := i.next()
+	start := time.Now()
+	err := db.BatchDelete(ctx, table, keys)
+	i.done(index, start, err)
+	return err
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/magiconair/properties"
+)
+
+// KeyEncodingProperty sets how every backend stores keys, so backends compare
+// on equal terms: KeyEncodingRaw stores the keys as they are and
+// KeyEncodingSHA256 their 32-byte hashes, as Ethereum storage slots are.
+// Unset, each backend keeps its own: PebbleDB raw keys and TrieDB hashes.
+const KeyEncodingProperty = "key_encoding"
+
+// The key encodings of KeyEncodingProperty
+const (
+	KeyEncodingRaw    = "raw"
+	KeyEncodingSHA256 = "sha256"
+)
+
+// KeyEncodings lists the values KeyEncodingProperty can take
+var KeyEncodings = []string{KeyEncodingRaw, KeyEncodingSHA256}
+
+// keyEncoding returns the key encoding set by KeyEncodingProperty, or def, the
+// backend's own, when unset
+func keyEncoding(p *properties.Properties, def string) (string, error) {
+	switch encoding := p.GetString(KeyEncodingProperty, def); encoding {
+	case KeyEncodingRaw, KeyEncodingSHA256:
+		return encoding, nil
+	default:
+		return "", fmt.Errorf("unknown %s %q: expected %s or %s", KeyEncodingProperty, encoding, KeyEncodingRaw, KeyEncodingSHA256)
+	}
+}
+
+// encodeKey returns key as stored under encoding
+func encodeKey(encoding, key string) string {
+	if encoding == KeyEncodingSHA256 {
+		hash := sha256.Sum256([]byte(key))
+		return string(hash[:])
+	}
+	return key
+}
@@ -0,0 +1,20 @@
+package db
+
+// LSMLevel is one level of an LSM tree as the engine reports it, with the
+// bytes moved in and out of it since the engine opened
+type LSMLevel struct {
+	Level         int
+	Sublevels     int // of L0; 0 for the other levels
+	Files         int64
+	Size          int64   // bytes of the level's tables
+	Score         float64 // compaction score; levels above 1 are compacted first
+	BytesIn       uint64  // flushed or compacted into the level from above
+	BytesIngested uint64
+	BytesRead     uint64 // read by compactions into the level
+	BytesWritten  uint64 // written to the level's tables by flushes and compactions
+}
+
+// LSMReporter is implemented by LSM backends that expose their levels
+type LSMReporter interface {
+	LSMLevels() []LSMLevel
+}
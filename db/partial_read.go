@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// PartialReader is implemented by backends and wrappers that can read part
+// of a large value, length bytes from offset, without handing back the whole
+// of it
+type PartialReader interface {
+	PartialRead(ctx context.Context, table string, key string, field string, offset int, length int) ([]byte, error)
+}
+
+// PartialRead reads length bytes from offset of field of key through db,
+// natively when db can, otherwise by reading the whole record. The range is
+// cut short at the end of the value. go-ycsb's wrapper is looked through, as
+// it would hide the method.
+func PartialRead(ctx context.Context, db ycsb.DB, table string, key string, field string, offset int, length int) ([]byte, error) {
+	if w, ok := db.(client.DbWrapper); ok {
+		db = w.DB
+	}
+	if pr, ok := db.(PartialReader); ok {
+		return pr.PartialRead(ctx, table, key, field, offset, length)
+	}
+	values, err := db.Read(ctx, table, key, []string{field})
+	if err != nil {
+		return nil, err
+	}
+	return valueRange(values[field], offset, length), nil
+}
+
+// valueRange returns length bytes of value from offset, or fewer at its end
+func valueRange(value []byte, offset int, length int) []byte {
+	if offset >= len(value) {
+		return nil
+	}
+	return value[offset:min(offset+length, len(value))]
+}
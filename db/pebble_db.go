@@ -1,24 +1,131 @@
 package db
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
 type pebbleDB struct {
-	db *pebble.DB
+	db       *pebble.DB
+	table    string      // the first table of the run, whose keys are stored as they are
+	encoding string      // of the keys, see KeyEncodingProperty
+	fs       *countingFS // nil unless pebble.vfs_stats is set
+	stalls   *writeStalls
+
+	// L0 sublevels at which Pebble stops writes
+	l0StopWrites int
+
+	// Writes are synced to the WAL unless pebble.disable_wal is set, which
+	// leaves them in the memtable until it is flushed
+	writeOpts *pebble.WriteOptions
+	noWAL     bool
+
+	// Opened with read_only, so there is nothing to flush
+	readOnly bool
+
+	// Bytes of the batches committed, as Pebble counts them in the WAL only
+	// while it has one
+	userBytes atomic.Uint64
 }
 
+// writeStalls counts the write stalls Pebble reports and the time spent in
+// them, in all and those that began on too many L0 sublevels; the rest began
+// waiting for memtables to flush. Pebble reports a stall once, so one that
+// goes on waiting for L0 counts by the cause it began on.
+type writeStalls struct {
+	mu      sync.Mutex
+	count   int64
+	total   time.Duration // of the stalls that ended
+	l0Count int64
+	l0Total time.Duration
+	since   time.Time // start of the ongoing stall; zero if none
+	l0      bool      // whether the ongoing stall is on L0
+}
+
+// l0StallReason is the reason Pebble gives for stalling writes until L0 is
+// compacted
+const l0StallReason = "L0 file count limit exceeded"
+
+func (s *writeStalls) begin(info pebble.WriteStallBeginInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.since.IsZero() {
+		s.count++
+		s.since = time.Now()
+		s.l0 = info.Reason == l0StallReason
+		if s.l0 {
+			s.l0Count++
+		}
+	}
+}
+
+func (s *writeStalls) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.since.IsZero() {
+		d := time.Since(s.since)
+		s.total += d
+		if s.l0 {
+			s.l0Total += d
+		}
+		s.since = time.Time{}
+	}
+}
+
+// read adds to a the stalls begun so far and the time spent in them,
+// counting an ongoing stall up to now
+func (s *writeStalls) read(a *EngineActivity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.WriteStalls, a.WriteStallTime = s.count, s.total
+	a.L0WriteStalls, a.L0WriteStallTime = s.l0Count, s.l0Total
+	if !s.since.IsZero() {
+		d := time.Since(s.since)
+		a.WriteStallTime += d
+		if s.l0 {
+			a.L0WriteStallTime += d
+		}
+	}
+}
+
+// key returns key of table as stored
+func (p *pebbleDB) key(table, key string) []byte {
+	return tableKey(p.table, table, encodeKey(p.encoding, key))
+}
+
+// Close flushes the memtable first when the WAL is disabled, as Pebble does
+// not, so a clean close loses nothing
 func (p *pebbleDB) Close() error {
+	if p.noWAL && !p.readOnly {
+		if err := p.db.Flush(); err != nil {
+			p.db.Close()
+			return fmt.Errorf("failed to flush memtable: %w", err)
+		}
+	}
 	return p.db.Close()
 }
 
+// CrashImage copies to dir what a crash of the process would leave on disk:
+// the tables and the WAL as written so far, but neither the memtable nor
+// what is still buffered for the WAL
+func (p *pebbleDB) CrashImage(dir string) error {
+	return p.db.Checkpoint(dir)
+}
+
 func (p *pebbleDB) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
 	return ctx
 }
@@ -26,8 +133,21 @@ func (p *pebbleDB) InitThread(ctx context.Context, threadID int, threadCount int
 func (p *pebbleDB) CleanupThread(ctx context.Context) {
 }
 
+// get reads the value stored under k, the encoding of key, from r, reporting
+// a missing key as ErrNotFound
+func get(r pebble.Reader, k []byte, key string) ([]byte, io.Closer, error) {
+	value, closer, err := r.Get(k)
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return value, closer, err
+}
+
 func (p *pebbleDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
-	value, closer, err := p.db.Get([]byte(key))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	value, closer, err := get(p.db, p.key(table, key), key)
 	if err != nil {
 		return nil, err
 	}
@@ -38,8 +158,82 @@ func (p *pebbleDB) Read(ctx context.Context, table string, key string, fields []
 	return data, nil
 }
 
+// PartialRead copies only length bytes from offset out of the value, not the
+// whole of it
+func (p *pebbleDB) PartialRead(ctx context.Context, table string, key string, field string, offset int, length int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	value, closer, err := get(p.db, p.key(table, key), key)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	return append([]byte(nil), valueRange(value, offset, length)...), nil
+}
+
+// Scan reads up to count records of table in key order from startKey on,
+// which is the order of their hashes when keys are hashed
 func (p *pebbleDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
-	return nil, fmt.Errorf("scan is not supported")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	iter, err := p.db.NewIter(&pebble.IterOptions{
+		LowerBound: p.key(table, startKey),
+		UpperBound: tableEnd(p.table, table),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	result := make([]map[string][]byte, 0, count)
+	for valid := iter.First(); valid && len(result) < count; valid = iter.Next() {
+		if len(result)%checkEvery == checkEvery-1 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		value := append([]byte(nil), iter.Value()...)
+		result = append(result, map[string][]byte{fields[0]: value})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PrefixScan reads every record whose key starts with prefix, bounding the
+// iterator so it stops at the end of the prefix. Hashed keys share no
+// prefixes.
+func (p *pebbleDB) PrefixScan(ctx context.Context, table string, prefix string, fields []string) ([]map[string][]byte, error) {
+	if p.encoding != KeyEncodingRaw {
+		return nil, fmt.Errorf("prefix scan is not supported with %s=%s", KeyEncodingProperty, p.encoding)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := p.key(table, prefix)
+	iter, err := p.db.NewIter(&pebble.IterOptions{LowerBound: start, UpperBound: prefixEnd(start)})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var result []map[string][]byte
+	for valid := iter.First(); valid; valid = iter.Next() {
+		if len(result)%checkEvery == checkEvery-1 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		value := append([]byte(nil), iter.Value()...)
+		result = append(result, map[string][]byte{fields[0]: value})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 func (p *pebbleDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
@@ -47,15 +241,41 @@ func (p *pebbleDB) Update(ctx context.Context, table string, key string, values
 }
 
 func (p *pebbleDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	// In YCSB, there is only one field.
 	for _, value := range values {
-		return p.db.Set([]byte(key), value, pebble.Sync)
+		batch := p.db.NewBatch()
+		defer batch.Close()
+		if err := batch.Set(p.key(table, key), value, nil); err != nil {
+			return err
+		}
+		return p.commit(batch)
 	}
 	return nil
 }
 
 func (p *pebbleDB) Delete(ctx context.Context, table string, key string) error {
-	return p.db.Delete([]byte(key), pebble.Sync)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	batch := p.db.NewBatch()
+	defer batch.Close()
+	if err := batch.Delete(p.key(table, key), nil); err != nil {
+		return err
+	}
+	return p.commit(batch)
+}
+
+// commit commits batch and counts its bytes as written by the application,
+// as DB.Set and DB.Delete would through a batch of their own
+func (p *pebbleDB) commit(batch *pebble.Batch) error {
+	if err := batch.Commit(p.writeOpts); err != nil {
+		return err
+	}
+	p.userBytes.Add(uint64(len(batch.Repr())))
+	return nil
 }
 
 // BatchInsert inserts multiple records in a single batch
@@ -68,16 +288,21 @@ func (p *pebbleDB) BatchInsert(ctx context.Context, table string, keys []string,
 	defer batch.Close()
 
 	for i, key := range keys {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
 		// In YCSB, there is only one field per record
 		for _, value := range values[i] {
-			if err := batch.Set([]byte(key), value, pebble.Sync); err != nil {
+			if err := batch.Set(p.key(table, key), value, p.writeOpts); err != nil {
 				return fmt.Errorf("failed to add key %s to batch: %w", key, err)
 			}
 			break // Only one field in YCSB
 		}
 	}
 
-	if err := batch.Commit(pebble.Sync); err != nil {
+	if err := p.commit(batch); err != nil {
 		return fmt.Errorf("failed to commit batch: %w", err)
 	}
 	return nil
@@ -96,7 +321,12 @@ func (p *pebbleDB) BatchRead(ctx context.Context, table string, keys []string, f
 
 	results := make([]map[string][]byte, len(keys))
 	for i, key := range keys {
-		value, closer, err := p.db.Get([]byte(key))
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		value, closer, err := get(p.db, p.key(table, key), key)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read key %s in batch: %w", key, err)
 		}
@@ -119,28 +349,315 @@ func (p *pebbleDB) BatchDelete(ctx context.Context, table string, keys []string)
 	batch := p.db.NewBatch()
 	defer batch.Close()
 
-	for _, key := range keys {
-		if err := batch.Delete([]byte(key), pebble.Sync); err != nil {
+	for i, key := range keys {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if err := batch.Delete(p.key(table, key), p.writeOpts); err != nil {
 			return fmt.Errorf("failed to add key %s to delete batch: %w", key, err)
 		}
 	}
 
-	if err := batch.Commit(pebble.Sync); err != nil {
+	if err := p.commit(batch); err != nil {
 		return fmt.Errorf("failed to commit delete batch: %w", err)
 	}
 	return nil
 }
 
-// Metrics returns the PebbleDB metrics
-func (p *pebbleDB) Metrics() *pebble.Metrics {
+// WriteBlock commits the writes and deletes of a block in a single batch
+func (p *pebbleDB) WriteBlock(ctx context.Context, keys []string, values [][]byte) error {
+	batch := p.db.NewBatch()
+	defer batch.Close()
+
+	for i, key := range keys {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		var err error
+		if values[i] == nil {
+			err = batch.Delete([]byte(encodeKey(p.encoding, key)), nil)
+		} else {
+			err = batch.Set([]byte(encodeKey(p.encoding, key)), values[i], nil)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add key %x to block batch: %w", key, err)
+		}
+	}
+
+	if err := p.commit(batch); err != nil {
+		return fmt.Errorf("failed to commit block batch: %w", err)
+	}
+	return nil
+}
+
+// NewBatch starts an indexed batch, so reads see the batch's own writes,
+// committed with a single sync
+func (p *pebbleDB) NewBatch(ctx context.Context, size int) (Batch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &pebbleBatch{batch: p.db.NewIndexedBatch(), p: p}, nil
+}
+
+// pebbleBatch is a Batch of PebbleDB
+type pebbleBatch struct {
+	batch *pebble.Batch
+	p     *pebbleDB // for the layout of its keys
+}
+
+func (b *pebbleBatch) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	value, closer, err := get(b.batch, b.p.key(table, key), key)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	// The value is only valid until the batch changes
+	data := make(map[string][]byte)
+	data[fields[0]] = append([]byte(nil), value...)
+	return data, nil
+}
+
+func (b *pebbleBatch) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return b.Insert(ctx, table, key, values)
+}
+
+func (b *pebbleBatch) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// In YCSB, there is only one field.
+	for _, value := range values {
+		return b.batch.Set(b.p.key(table, key), value, nil)
+	}
+	return nil
+}
+
+func (b *pebbleBatch) Delete(ctx context.Context, table string, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.batch.Delete(b.p.key(table, key), nil)
+}
+
+func (b *pebbleBatch) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	values, err := b.Read(ctx, table, key, fields)
+	if err != nil {
+		return err
+	}
+	return b.Update(ctx, table, key, modify(values))
+}
+
+func (b *pebbleBatch) Commit(ctx context.Context) error {
+	defer b.batch.Close()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := b.p.commit(b.batch); err != nil {
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+	return nil
+}
+
+func (b *pebbleBatch) Rollback() {
+	b.batch.Close()
+}
+
+// Compact compacts every key from the first to the last one, so the
+// tombstones of deleted keys are dropped along with what they shadow. The
+// key space is compacted in up to compactChunks ranges split at table
+// boundaries, so a canceled ctx stops it after the range in progress.
+func (p *pebbleDB) Compact(ctx context.Context) error {
+	iter, err := p.db.NewIter(nil)
+	if err != nil {
+		return err
+	}
+	var first, last []byte
+	if iter.First() {
+		first = append(first, iter.Key()...)
+	}
+	if iter.Last() {
+		last = append(last, iter.Key()...)
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	if first == nil {
+		return nil
+	}
+	// The end of the range is exclusive
+	bounds, err := p.compactBounds(first, append(last, 0))
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(bounds); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.db.Compact(bounds[i-1], bounds[i], true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactChunks is how many ranges Compact splits the key space into at most
+const compactChunks = 16
+
+// compactBounds splits the range from start to end into up to compactChunks
+// ranges of about as many tables each, returning their bounds in order
+func (p *pebbleDB) compactBounds(start, end []byte) ([][]byte, error) {
+	levels, err := p.db.SSTables()
+	if err != nil {
+		return nil, err
+	}
+	cmp := bytes.Compare // Pebble's default comparer, which every run uses
+	var keys [][]byte
+	for _, tables := range levels {
+		for _, t := range tables {
+			if k := t.Smallest.UserKey; cmp(k, start) > 0 && cmp(k, end) < 0 {
+				keys = append(keys, k)
+			}
+		}
+	}
+	slices.SortFunc(keys, cmp)
+	keys = slices.CompactFunc(keys, func(a, b []byte) bool { return cmp(a, b) == 0 })
+
+	bounds := [][]byte{start}
+	for i := 1; i < compactChunks && len(keys) > 0; i++ {
+		k := keys[i*len(keys)/compactChunks]
+		if cmp(k, bounds[len(bounds)-1]) > 0 {
+			bounds = append(bounds, k)
+		}
+	}
+	return append(bounds, end), nil
+}
+
+// Flush writes the memtables out to tables
+func (p *pebbleDB) Flush() error {
+	return p.db.Flush()
+}
+
+// DiskUsage returns the bytes of Pebble's live tables, leaving out the WAL
+// and the obsolete tables not deleted yet
+func (p *pebbleDB) DiskUsage() uint64 {
+	var size uint64
+	for _, l := range p.db.Metrics().Levels {
+		size += uint64(l.Size)
+	}
+	return size
+}
+
+// Metrics returns the PebbleDB metrics, which print as Pebble's own dump
+func (p *pebbleDB) Metrics() fmt.Stringer {
 	return p.db.Metrics()
 }
 
+// LSMLevels returns the shape of every level of Pebble's LSM tree and the
+// bytes flushed, compacted and ingested into it
+func (p *pebbleDB) LSMLevels() []LSMLevel {
+	m := p.db.Metrics()
+	levels := make([]LSMLevel, len(m.Levels))
+	for i, l := range m.Levels {
+		levels[i] = LSMLevel{
+			Level:         i,
+			Files:         l.NumFiles,
+			Size:          l.Size,
+			Score:         l.Score,
+			BytesIn:       l.BytesIn,
+			BytesIngested: l.BytesIngested,
+			BytesRead:     l.BytesRead,
+			BytesWritten:  l.BytesFlushed + l.BytesCompacted,
+		}
+	}
+	levels[0].Sublevels = int(m.Levels[0].Sublevels)
+	return levels
+}
+
+// CacheCounters returns the hits and misses of Pebble's block cache so far
+// and the bytes it holds
+func (p *pebbleDB) CacheCounters() CacheCounters {
+	m := p.db.Metrics()
+	return CacheCounters{Hits: m.BlockCache.Hits, Misses: m.BlockCache.Misses, Bytes: m.BlockCache.Size}
+}
+
+// WriteCounters returns the bytes written to the WAL and the tree so far,
+// counting ingested tables as both user and flushed bytes as Pebble does.
+// User bytes are the committed batches, as the WAL counts them, so they are
+// the same with the WAL disabled.
+func (p *pebbleDB) WriteCounters() WriteCounters {
+	m := p.db.Metrics()
+	var c WriteCounters
+	for _, l := range m.Levels {
+		c.FlushedBytes += l.BytesFlushed + l.BytesIngested
+		c.CompactedBytes += l.BytesCompacted
+		c.UserBytes += l.BytesIngested
+	}
+	c.UserBytes += p.userBytes.Load()
+	// Pebble estimates the WAL's bytes from what was flushed, even without one
+	if !p.noWAL {
+		c.WALBytes = m.WAL.BytesWritten
+	}
+	return c
+}
+
+// EngineActivity returns Pebble's flush and compaction counts, its write
+// stalls and the shape of L0 and the memtables now
+func (p *pebbleDB) EngineActivity() EngineActivity {
+	m := p.db.Metrics()
+	a := EngineActivity{
+		Flushes:               m.Flush.Count,
+		FlushesInProgress:     m.Flush.NumInProgress,
+		Compactions:           m.Compact.Count,
+		CompactionsInProgress: m.Compact.NumInProgress,
+		CompactionDebt:        m.Compact.EstimatedDebt,
+		L0Files:               m.Levels[0].NumFiles,
+		L0Sublevels:           int(m.Levels[0].Sublevels),
+		L0StopWrites:          p.l0StopWrites,
+		ReadAmp:               m.ReadAmp(),
+		MemtableBytes:         m.MemTable.Size,
+	}
+	p.stalls.read(&a)
+	return a
+}
+
+// IOCalls returns the filesystem calls Pebble made, if pebble.vfs_stats is set
+func (p *pebbleDB) IOCalls() []IOCall {
+	if p.fs == nil {
+		return nil
+	}
+	return p.fs.IOCalls()
+}
+
+// ResetIOCalls starts counting filesystem calls again from zero
+func (p *pebbleDB) ResetIOCalls() {
+	if p.fs != nil {
+		p.fs.ResetIOCalls()
+	}
+}
+
+// PebbleDisableWALProperty turns Pebble's write-ahead log off: writes are no
+// longer synced, and those not yet flushed from the memtable are lost in a
+// crash
+const PebbleDisableWALProperty = "pebble.disable_wal"
+
 type pebbleCreator struct{}
 
 func (c pebbleCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 	path := p.GetString("datadir", "/tmp/pebble")
 
+	encoding, err := keyEncoding(p, KeyEncodingRaw)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if we should use an existing database or create new
 	useExisting := p.GetBool("pebble.use_existing", true)
 
@@ -181,10 +698,55 @@ func (c pebbleCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 		opts.MaxOpenFiles = int(p.GetInt("pebble.max_open_files", 1000))
 	}
 
+	// Without a WAL writes cannot be synced, and are lost in a crash until
+	// their memtable is flushed
+	writeOpts := pebble.Sync
+	noWAL := p.GetBool(PebbleDisableWALProperty, false)
+	if noWAL {
+		opts.DisableWAL = true
+		writeOpts = pebble.NoSync
+	}
+
+	// Read-only, Pebble replays the WAL into memory and runs no flushes or
+	// compactions. The dataset must already be there: it is never created or
+	// cleaned up, even when it cannot be opened.
+	readOnly := p.GetBool(ReadOnlyProperty, false)
+	if readOnly {
+		if !useExisting {
+			return nil, fmt.Errorf("%s needs pebble.use_existing=true, as it never creates a database", ReadOnlyProperty)
+		}
+		opts.ReadOnly = true
+	}
+
+	// Count and time every filesystem call for durability cost analysis
+	var fs *countingFS
+	if p.GetBool("pebble.vfs_stats", false) {
+		if opts.FS == nil {
+			opts.FS = vfs.Default
+		}
+		fs = newCountingFS(opts.FS)
+		opts.FS = fs
+	}
+
+	// Write stalls are only reported as events, not in the metrics
+	stalls := &writeStalls{}
+	opts.AddEventListener(pebble.EventListener{
+		WriteStallBegin: stalls.begin,
+		WriteStallEnd:   stalls.end,
+	})
+
 	var db *pebble.DB
-	var err error
 
-	if useExisting {
+	if readOnly {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("no database to open read-only at %s: %w", path, err)
+		}
+		db, err = pebble.Open(path, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database read-only at %s: %w", path, err)
+		}
+		fmt.Printf("Using existing database at %s, read-only\n", path)
+	} else if useExisting {
 		// Check if the database directory exists before trying to open it.
 		_, statErr := os.Stat(path)
 		dbExists := !os.IsNotExist(statErr)
@@ -220,9 +782,33 @@ func (c pebbleCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 		}
 	}
 
-	return &pebbleDB{db: db}, nil
+	// Open fills in Pebble's defaults on a copy of opts
+	l0StopWrites := opts.Clone().EnsureDefaults().L0StopWritesThreshold
+	return &pebbleDB{db: db, table: Tables(p)[0], encoding: encoding, fs: fs, stalls: stalls, l0StopWrites: l0StopWrites,
+		writeOpts: writeOpts, noWAL: noWAL, readOnly: readOnly}, nil
 }
 
 func init() {
-	ycsb.RegisterDBCreator("pebble", pebbleCreator{})
+	registerDBCreator("pebble", pebbleCreator{},
+		"datadir",
+		"pebble.use_existing",
+		"pebble.config",
+		"pebble.cache_size",
+		"pebble.memtable_size",
+		"pebble.max_open_files",
+		"pebble.vfs_stats",
+		PebbleDisableWALProperty,
+		KeyEncodingProperty,
+		ReadOnlyProperty,
+	)
+	registerCapabilities("pebble", Capabilities{
+		Scan:         true,
+		PrefixScan:   true,
+		PartialRead:  true,
+		Sync:         "fsync of the WAL per write",
+		Transactions: "read, then write; txnsize: indexed batch",
+		Batching:     "one batch, one fsync",
+		KeyEncoding:  KeyEncodingRaw,
+		ReadOnly:     true,
+	})
 }
@@ -0,0 +1,238 @@
+package db
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// Filesystem calls counted by the instrumented Pebble filesystem
+const (
+	IOCallCreate        = "create"
+	IOCallOpen          = "open"
+	IOCallOpenDir       = "open_dir"
+	IOCallReuseForWrite = "reuse_for_write"
+	IOCallRemove        = "remove"
+	IOCallRename        = "rename"
+	IOCallLink          = "link"
+	IOCallRead          = "read"
+	IOCallWrite         = "write"
+	IOCallSync          = "sync" // Sync, SyncData and full SyncTo: every fsync
+	IOCallSyncTo        = "sync_to"
+	IOCallPreallocate   = "preallocate"
+	IOCallClose         = "close"
+)
+
+// IOCall is the count, bytes and latency distribution of one kind of
+// filesystem call
+type IOCall struct {
+	Name    string
+	Count   int64
+	Bytes   int64                   // read or written; 0 for calls without data
+	Latency *hdrhistogram.Histogram // nanoseconds
+}
+
+// IOCounter is implemented by backends that can count their filesystem calls
+type IOCounter interface {
+	// IOCalls returns a snapshot of every kind of call made so far, by name,
+	// or nil if counting is not enabled
+	IOCalls() []IOCall
+	// ResetIOCalls starts counting again from zero
+	ResetIOCalls()
+}
+
+// ioCall accumulates one kind of call
+type ioCall struct {
+	mu      sync.Mutex
+	count   int64
+	bytes   int64
+	latency *hdrhistogram.Histogram
+}
+
+// countingFS wraps a Pebble filesystem and times every call made through it
+// and the files it opens, so fsyncs and IO can be attributed to the engine
+type countingFS struct {
+	vfs.FS
+	mu    sync.Mutex
+	calls map[string]*ioCall
+}
+
+var _ vfs.FS = (*countingFS)(nil)
+
+func newCountingFS(fs vfs.FS) *countingFS {
+	return &countingFS{FS: fs, calls: make(map[string]*ioCall)}
+}
+
+// Unwrap returns the wrapped filesystem, for vfs.Root
+func (fs *countingFS) Unwrap() vfs.FS {
+	return fs.FS
+}
+
+// record counts one call of kind name that started at start and moved n bytes
+func (fs *countingFS) record(name string, start time.Time, n int) {
+	elapsed := time.Since(start)
+	fs.mu.Lock()
+	c, ok := fs.calls[name]
+	if !ok {
+		c = &ioCall{latency: hdrhistogram.New(1, int64(time.Hour), 3)}
+		fs.calls[name] = c
+	}
+	fs.mu.Unlock()
+
+	c.mu.Lock()
+	c.count++
+	c.bytes += int64(n)
+	_ = c.latency.RecordValue(max(1, elapsed.Nanoseconds()))
+	c.mu.Unlock()
+}
+
+// IOCalls returns a snapshot of every kind of call made so far, by name
+func (fs *countingFS) IOCalls() []IOCall {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	calls := make([]IOCall, 0, len(fs.calls))
+	for name, c := range fs.calls {
+		c.mu.Lock()
+		calls = append(calls, IOCall{
+			Name:    name,
+			Count:   c.count,
+			Bytes:   c.bytes,
+			Latency: hdrhistogram.Import(c.latency.Export()),
+		})
+		c.mu.Unlock()
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Name < calls[j].Name })
+	return calls
+}
+
+// ResetIOCalls starts counting again from zero
+func (fs *countingFS) ResetIOCalls() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.calls = make(map[string]*ioCall)
+}
+
+// file wraps f, if it was opened, so its calls are counted too
+func (fs *countingFS) file(f vfs.File, err error) (vfs.File, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &countingFile{File: f, fs: fs}, nil
+}
+
+func (fs *countingFS) Create(name string) (vfs.File, error) {
+	defer fs.record(IOCallCreate, time.Now(), 0)
+	return fs.file(fs.FS.Create(name))
+}
+
+func (fs *countingFS) Open(name string, opts ...vfs.OpenOption) (vfs.File, error) {
+	defer fs.record(IOCallOpen, time.Now(), 0)
+	return fs.file(fs.FS.Open(name, opts...))
+}
+
+func (fs *countingFS) OpenReadWrite(name string, opts ...vfs.OpenOption) (vfs.File, error) {
+	defer fs.record(IOCallOpen, time.Now(), 0)
+	return fs.file(fs.FS.OpenReadWrite(name, opts...))
+}
+
+func (fs *countingFS) OpenDir(name string) (vfs.File, error) {
+	defer fs.record(IOCallOpenDir, time.Now(), 0)
+	return fs.file(fs.FS.OpenDir(name))
+}
+
+func (fs *countingFS) ReuseForWrite(oldname, newname string) (vfs.File, error) {
+	defer fs.record(IOCallReuseForWrite, time.Now(), 0)
+	return fs.file(fs.FS.ReuseForWrite(oldname, newname))
+}
+
+func (fs *countingFS) Remove(name string) error {
+	defer fs.record(IOCallRemove, time.Now(), 0)
+	return fs.FS.Remove(name)
+}
+
+func (fs *countingFS) RemoveAll(name string) error {
+	defer fs.record(IOCallRemove, time.Now(), 0)
+	return fs.FS.RemoveAll(name)
+}
+
+func (fs *countingFS) Rename(oldname, newname string) error {
+	defer fs.record(IOCallRename, time.Now(), 0)
+	return fs.FS.Rename(oldname, newname)
+}
+
+func (fs *countingFS) Link(oldname, newname string) error {
+	defer fs.record(IOCallLink, time.Now(), 0)
+	return fs.FS.Link(oldname, newname)
+}
+
+// countingFile counts the calls made on a file of a countingFS
+type countingFile struct {
+	vfs.File
+	fs *countingFS
+}
+
+var _ vfs.File = (*countingFile)(nil)
+
+func (f *countingFile) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := f.File.Read(p)
+	f.fs.record(IOCallRead, start, n)
+	return n, err
+}
+
+func (f *countingFile) ReadAt(p []byte, off int64) (int, error) {
+	start := time.Now()
+	n, err := f.File.ReadAt(p, off)
+	f.fs.record(IOCallRead, start, n)
+	return n, err
+}
+
+func (f *countingFile) Write(p []byte) (int, error) {
+	// The length is taken first as Write may modify p
+	start, size := time.Now(), len(p)
+	n, err := f.File.Write(p)
+	f.fs.record(IOCallWrite, start, min(n, size))
+	return n, err
+}
+
+func (f *countingFile) WriteAt(p []byte, off int64) (int, error) {
+	start, size := time.Now(), len(p)
+	n, err := f.File.WriteAt(p, off)
+	f.fs.record(IOCallWrite, start, min(n, size))
+	return n, err
+}
+
+func (f *countingFile) Sync() error {
+	defer f.fs.record(IOCallSync, time.Now(), 0)
+	return f.File.Sync()
+}
+
+func (f *countingFile) SyncData() error {
+	defer f.fs.record(IOCallSync, time.Now(), 0)
+	return f.File.SyncData()
+}
+
+func (f *countingFile) SyncTo(length int64) (bool, error) {
+	start := time.Now()
+	fullSync, err := f.File.SyncTo(length)
+	if fullSync {
+		f.fs.record(IOCallSync, start, 0)
+	} else {
+		f.fs.record(IOCallSyncTo, start, 0)
+	}
+	return fullSync, err
+}
+
+func (f *countingFile) Preallocate(offset, length int64) error {
+	defer f.fs.record(IOCallPreallocate, time.Now(), 0)
+	return f.File.Preallocate(offset, length)
+}
+
+func (f *countingFile) Close() error {
+	defer f.fs.record(IOCallClose, time.Now(), 0)
+	return f.File.Close()
+}
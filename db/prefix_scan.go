@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// PrefixScanner is implemented by backends and wrappers that can read every
+// record whose key starts with prefix, in key order, as iterating over one
+// account's storage does
+type PrefixScanner interface {
+	PrefixScan(ctx context.Context, table string, prefix string, fields []string) ([]map[string][]byte, error)
+}
+
+// PrefixScan reads every record under prefix through db, or fails if db
+// cannot scan by prefix. go-ycsb's wrapper is looked through, as it would
+// hide the method.
+func PrefixScan(ctx context.Context, db ycsb.DB, table string, prefix string, fields []string) ([]map[string][]byte, error) {
+	if w, ok := db.(client.DbWrapper); ok {
+		db = w.DB
+	}
+	if ps, ok := db.(PrefixScanner); ok {
+		return ps.PrefixScan(ctx, table, prefix, fields)
+	}
+	return nil, fmt.Errorf("prefix scan is not supported")
+}
+
+// prefixEnd returns the first key after every key starting with prefix, or
+// nil if there is none
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
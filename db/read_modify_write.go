@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// ReadModifyWriter is implemented by backends and wrappers that handle a
+// read-modify-write as one operation: the fields of key are read, passed to
+// modify, and what it returns is written back. Backends with transactions
+// do it in one, so no other write to key lands in between.
+type ReadModifyWriter interface {
+	ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error
+}
+
+// ReadModifyWrite reads, modifies and writes back key through db as one
+// operation when db handles them, otherwise as a read and an update. go-ycsb's
+// wrapper is looked through, as it would hide the method.
+func ReadModifyWrite(ctx context.Context, db ycsb.DB, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	if w, ok := db.(client.DbWrapper); ok {
+		db = w.DB
+	}
+	if rmw, ok := db.(ReadModifyWriter); ok {
+		return rmw.ReadModifyWrite(ctx, table, key, fields, modify)
+	}
+	values, err := db.Read(ctx, table, key, fields)
+	if err != nil {
+		return err
+	}
+	return db.Update(ctx, table, key, modify(values))
+}
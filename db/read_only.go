@@ -0,0 +1,48 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// ReadOnlyProperty opens the backend read-only, for pure-read workloads over
+// a prepared dataset: nothing the run does can change the data directory,
+// and the backend runs no flushes or compactions of its own to disturb the
+// read latencies. Only backends whose capabilities have ReadOnly support it,
+// and only runs that write nothing: no load phase and no operation that
+// updates, inserts or read-modify-writes.
+const ReadOnlyProperty = "read_only"
+
+// CheckReadOnly fails if properties p open the named backend read-only but
+// the backend cannot be opened that way or the run would write, rather than
+// fail on the first write or quietly open it read-write
+func CheckReadOnly(name string, p *properties.Properties) error {
+	if !p.GetBool(ReadOnlyProperty, false) {
+		return nil
+	}
+	if c, _ := BackendCapabilities(name, p); !c.ReadOnly {
+		return fmt.Errorf("%s cannot be opened read-only: unset %s", name, ReadOnlyProperty)
+	}
+	if !p.GetBool(prop.DoTransactions, true) {
+		return fmt.Errorf("%s cannot load records: load the dataset first without it", ReadOnlyProperty)
+	}
+	if p.GetString(prop.Workload, "core") != "core" {
+		return nil
+	}
+	writes := []struct {
+		property string
+		def      float64
+	}{
+		{prop.UpdateProportion, prop.UpdateProportionDefault},
+		{prop.InsertProportion, prop.InsertProportionDefault},
+		{prop.ReadModifyWriteProportion, prop.ReadModifyWriteProportionDefault},
+	}
+	for _, w := range writes {
+		if p.GetFloat64(w.property, w.def) > 0 {
+			return fmt.Errorf("%s cannot run writes: set %s to 0", ReadOnlyProperty, w.property)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// OpRecorder receives every operation a RecordingDB passes on: its name as
+// in the results table, its keys and, per key, the bytes written or the
+// records a scan asks for
+type OpRecorder interface {
+	Record(op string, keys []string, sizes []int)
+}
+
+// RecordingDB passes every operation to a recorder before issuing it, so
+// the exact operation stream of a run can be replayed later. Recording is
+// not part of the operation: wrap this around the measured DB, not inside it.
+type RecordingDB struct {
+	ycsb.DB
+	batch    ycsb.BatchDB
+	recorder OpRecorder
+}
+
+// NewRecordingDB records the operations issued to db with recorder
+func NewRecordingDB(db BatchingDB, recorder OpRecorder) *RecordingDB {
+	return &RecordingDB{DB: db, batch: db, recorder: recorder}
+}
+
+// valueSize returns the bytes of all fields of values
+func valueSize(values map[string][]byte) int {
+	n := 0
+	for _, v := range values {
+		n += len(v)
+	}
+	return n
+}
+
+// valueSizes returns the value size of every record of a batch
+func valueSizes(values []map[string][]byte) []int {
+	sizes := make([]int, len(values))
+	for i, v := range values {
+		sizes[i] = valueSize(v)
+	}
+	return sizes
+}
+
+func (r *RecordingDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	r.recorder.Record("READ", []string{key}, nil)
+	return r.DB.Read(ctx, table, key, fields)
+}
+
+func (r *RecordingDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	r.recorder.Record("SCAN", []string{startKey}, []int{count})
+	return r.DB.Scan(ctx, table, startKey, count, fields)
+}
+
+func (r *RecordingDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	r.recorder.Record("UPDATE", []string{key}, []int{valueSize(values)})
+	return r.DB.Update(ctx, table, key, values)
+}
+
+func (r *RecordingDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	r.recorder.Record("INSERT", []string{key}, []int{valueSize(values)})
+	return r.DB.Insert(ctx, table, key, values)
+}
+
+func (r *RecordingDB) Delete(ctx context.Context, table string, key string) error {
+	r.recorder.Record("DELETE", []string{key}, nil)
+	return r.DB.Delete(ctx, table, key)
+}
+
+// ReadModifyWrite records the operation with the size of the values written
+// back, once they are known, so after issuing it
+func (r *RecordingDB) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	size := 0
+	err := ReadModifyWrite(ctx, r.DB, table, key, fields, func(values map[string][]byte) map[string][]byte {
+		values = modify(values)
+		size = valueSize(values)
+		return values
+	})
+	r.recorder.Record("RMW", []string{key}, []int{size})
+	return err
+}
+
+// PartialRead records the operation with the bytes asked for; the offset is
+// not kept
+func (r *RecordingDB) PartialRead(ctx context.Context, table string, key string, field string, offset int, length int) ([]byte, error) {
+	r.recorder.Record("PARTIAL_READ", []string{key}, []int{length})
+	return PartialRead(ctx, r.DB, table, key, field, offset, length)
+}
+
+func (r *RecordingDB) PrefixScan(ctx context.Context, table string, prefix string, fields []string) ([]map[string][]byte, error) {
+	r.recorder.Record("PREFIX_SCAN", []string{prefix}, nil)
+	return PrefixScan(ctx, r.DB, table, prefix, fields)
+}
+
+// NewBatch starts a batch whose operations are recorded one by one as they
+// are issued
+func (r *RecordingDB) NewBatch(ctx context.Context, size int) (Batch, error) {
+	b, err := NewBatch(ctx, r.DB, size)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingBatch{Batch: b, recorder: r.recorder}, nil
+}
+
+// recordingBatch records the operations of a batch
+type recordingBatch struct {
+	Batch
+	recorder OpRecorder
+}
+
+func (b *recordingBatch) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	b.recorder.Record("READ", []string{key}, nil)
+	return b.Batch.Read(ctx, table, key, fields)
+}
+
+func (b *recordingBatch) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	b.recorder.Record("UPDATE", []string{key}, []int{valueSize(values)})
+	return b.Batch.Update(ctx, table, key, values)
+}
+
+func (b *recordingBatch) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	b.recorder.Record("INSERT", []string{key}, []int{valueSize(values)})
+	return b.Batch.Insert(ctx, table, key, values)
+}
+
+func (b *recordingBatch) Delete(ctx context.Context, table string, key string) error {
+	b.recorder.Record("DELETE", []string{key}, nil)
+	return b.Batch.Delete(ctx, table, key)
+}
+
+func (b *recordingBatch) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	size := 0
+	err := b.Batch.ReadModifyWrite(ctx, table, key, fields, func(values map[string][]byte) map[string][]byte {
+		values = modify(values)
+		size = valueSize(values)
+		return values
+	})
+	b.recorder.Record("RMW", []string{key}, []int{size})
+	return err
+}
+
+// BatchInsert records the batch as one operation, then inserts it
+func (r *RecordingDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	r.recorder.Record("BATCH_INSERT", keys, valueSizes(values))
+	return r.batch.BatchInsert(ctx, table, keys, values)
+}
+
+// BatchRead records the batch as one operation, then reads it
+func (r *RecordingDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	r.recorder.Record("BATCH_READ", keys, nil)
+	return r.batch.BatchRead(ctx, table, keys, fields)
+}
+
+// BatchUpdate records the batch as one operation, then updates it
+func (r *RecordingDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	r.recorder.Record("BATCH_UPDATE", keys, valueSizes(values))
+	return r.batch.BatchUpdate(ctx, table, keys, values)
+}
+
+// BatchDelete records the batch as one operation, then deletes it
+func (r *RecordingDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	r.recorder.Record("BATCH_DELETE", keys, nil)
+	return r.batch.BatchDelete(ctx, table, keys)
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// backends lists the registered DB names in registration order
+var backends []string
+
+// backendProperties lists the properties each backend reads
+var backendProperties = make(map[string][]string)
+
+// registerDBCreator registers a creator with go-ycsb and records its name and
+// the properties it reads, so commands can enumerate every available backend
+// and flag unknown properties
+func registerDBCreator(name string, creator ycsb.DBCreator, properties ...string) {
+	ycsb.RegisterDBCreator(name, creator)
+	backends = append(backends, name)
+	backendProperties[name] = properties
+}
+
+// Backends returns the names of all registered benchmark backends
+func Backends() []string {
+	names := make([]string, len(backends))
+	copy(names, backends)
+	return names
+}
+
+// Properties returns the properties read by the named backend
+func Properties(name string) []string {
+	props := make([]string, len(backendProperties[name]))
+	copy(props, backendProperties[name])
+	return props
+}
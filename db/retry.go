@@ -0,0 +1,257 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// ErrTimeout is returned, wrapped, when an operation did not finish within
+// the timeout of a RetryingDB
+var ErrTimeout = errors.New("operation timed out")
+
+// maxRetryBackoff caps the doubling wait between retries
+const maxRetryBackoff = time.Second
+
+// RetryCount is how often the operations named Op were retried by a
+// RetryingDB and how that turned out
+type RetryCount struct {
+	Op        string
+	Retries   int64 // attempts after the first
+	Timeouts  int64 // attempts abandoned at the timeout
+	Recovered int64 // operations that succeeded on a retry
+	Exhausted int64 // operations that still failed after the last retry
+}
+
+// RetryingDB gives every operation a timeout and retries the ones that fail
+// for any reason but a missing key or the end of the run, up to a bound,
+// waiting twice as long before each retry. Every attempt is measured on its
+// own: wrap this around the measured DB, not inside it.
+//
+// The timeout reaches the backend as the context's deadline, but as most
+// engine calls never look at their context, an attempt still running at the
+// deadline is abandoned to finish in the background, so a hung call cannot
+// hold its client thread. Writes must be idempotent to be retried safely,
+// which YCSB's are. Batches started by NewBatch are passed through, as their
+// operations cannot be retried on their own.
+type RetryingDB struct {
+	ycsb.DB
+	batch   ycsb.BatchDB
+	timeout time.Duration // of every attempt; 0 for none
+	retries int           // at most, after the first attempt
+	backoff time.Duration // before the first retry, doubling after
+
+	mu     sync.Mutex
+	counts map[string]*RetryCount
+}
+
+// NewRetryingDB limits every attempt of an operation on db to timeout, 0 for
+// none, and makes up to retries more attempts, the first after backoff
+func NewRetryingDB(db BatchingDB, timeout time.Duration, retries int, backoff time.Duration) *RetryingDB {
+	return &RetryingDB{
+		DB:      db,
+		batch:   db,
+		timeout: timeout,
+		retries: max(retries, 0),
+		backoff: backoff,
+		counts:  make(map[string]*RetryCount),
+	}
+}
+
+// RetryCounts returns the counts of every operation that was retried or
+// timed out, by name
+func (r *RetryingDB) RetryCounts() []RetryCount {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make([]RetryCount, 0, len(r.counts))
+	for _, c := range r.counts {
+		counts = append(counts, *c)
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Op < counts[j].Op })
+	return counts
+}
+
+// count applies update to the counts of op
+func (r *RetryingDB) count(op string, update func(c *RetryCount)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.counts[op]
+	if !ok {
+		c = &RetryCount{Op: op}
+		r.counts[op] = c
+	}
+	update(c)
+}
+
+// retryable reports whether an attempt that failed with err is worth
+// repeating: a missing key stays missing, and a canceled run is over
+func retryable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && ErrorClass(err) != ErrorClassNotFound
+}
+
+// retry runs attempt on r, named op as in the results table, under its
+// timeout and retry policy
+func retry[T any](ctx context.Context, r *RetryingDB, op string, attempt func(ctx context.Context) (T, error)) (T, error) {
+	backoff := r.backoff
+	for i := 0; ; i++ {
+		result, err := timed(ctx, r, op, attempt)
+		if err == nil {
+			if i > 0 {
+				r.count(op, func(c *RetryCount) { c.Recovered++ })
+			}
+			return result, nil
+		}
+		if !retryable(ctx, err) {
+			return result, err
+		}
+		if i == r.retries {
+			if i > 0 {
+				r.count(op, func(c *RetryCount) { c.Exhausted++ })
+			}
+			return result, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return result, err
+		}
+		backoff = min(2*backoff, maxRetryBackoff)
+		r.count(op, func(c *RetryCount) { c.Retries++ })
+	}
+}
+
+// timed runs attempt once, giving up on it at the timeout of r. An abandoned
+// attempt hands its result to nobody.
+func timed[T any](ctx context.Context, r *RetryingDB, op string, attempt func(ctx context.Context) (T, error)) (T, error) {
+	if r.timeout <= 0 {
+		return attempt(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	type outcome struct {
+		result T
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := attempt(ctx)
+		done <- outcome{result, err}
+	}()
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		var zero T
+		if ctx.Err() != context.DeadlineExceeded {
+			return zero, ctx.Err()
+		}
+		r.count(op, func(c *RetryCount) { c.Timeouts++ })
+		return zero, fmt.Errorf("%s: %w after %s", op, ErrTimeout, r.timeout)
+	}
+}
+
+// none adapts an operation without a result to retry
+func none(f func(ctx context.Context) error) func(ctx context.Context) (struct{}, error) {
+	return func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, f(ctx)
+	}
+}
+
+func (r *RetryingDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	return retry(ctx, r, "READ", func(ctx context.Context) (map[string][]byte, error) {
+		return r.DB.Read(ctx, table, key, fields)
+	})
+}
+
+func (r *RetryingDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	return retry(ctx, r, "SCAN", func(ctx context.Context) ([]map[string][]byte, error) {
+		return r.DB.Scan(ctx, table, startKey, count, fields)
+	})
+}
+
+func (r *RetryingDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	_, err := retry(ctx, r, "UPDATE", none(func(ctx context.Context) error {
+		return r.DB.Update(ctx, table, key, values)
+	}))
+	return err
+}
+
+func (r *RetryingDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	_, err := retry(ctx, r, "INSERT", none(func(ctx context.Context) error {
+		return r.DB.Insert(ctx, table, key, values)
+	}))
+	return err
+}
+
+func (r *RetryingDB) Delete(ctx context.Context, table string, key string) error {
+	_, err := retry(ctx, r, "DELETE", none(func(ctx context.Context) error {
+		return r.DB.Delete(ctx, table, key)
+	}))
+	return err
+}
+
+// ReadModifyWrite retries the read, modification and write back as a whole
+func (r *RetryingDB) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	_, err := retry(ctx, r, "RMW", none(func(ctx context.Context) error {
+		return ReadModifyWrite(ctx, r.DB, table, key, fields, modify)
+	}))
+	return err
+}
+
+// PartialRead reads part of a value under the timeout and retry policy
+func (r *RetryingDB) PartialRead(ctx context.Context, table string, key string, field string, offset int, length int) ([]byte, error) {
+	return retry(ctx, r, "PARTIAL_READ", func(ctx context.Context) ([]byte, error) {
+		return PartialRead(ctx, r.DB, table, key, field, offset, length)
+	})
+}
+
+// PrefixScan reads every record under prefix under the timeout and retry
+// policy
+func (r *RetryingDB) PrefixScan(ctx context.Context, table string, prefix string, fields []string) ([]map[string][]byte, error) {
+	return retry(ctx, r, "PREFIX_SCAN", func(ctx context.Context) ([]map[string][]byte, error) {
+		return PrefixScan(ctx, r.DB, table, prefix, fields)
+	})
+}
+
+// NewBatch starts a batch on the wrapped DB, without a timeout or retries
+func (r *RetryingDB) NewBatch(ctx context.Context, size int) (Batch, error) {
+	return NewBatch(ctx, r.DB, size)
+}
+
+// BatchInsert retries the batch as a whole
+func (r *RetryingDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	_, err := retry(ctx, r, "BATCH_INSERT", none(func(ctx context.Context) error {
+		return r.batch.BatchInsert(ctx, table, keys, values)
+	}))
+	return err
+}
+
+// BatchRead retries the batch as a whole
+func (r *RetryingDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	return retry(ctx, r, "BATCH_READ", func(ctx context.Context) ([]map[string][]byte, error) {
+		return r.batch.BatchRead(ctx, table, keys, fields)
+	})
+}
+
+// BatchUpdate retries the batch as a whole
+func (r *RetryingDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	_, err := retry(ctx, r, "BATCH_UPDATE", none(func(ctx context.Context) error {
+		return r.batch.BatchUpdate(ctx, table, keys, values)
+	}))
+	return err
+}
+
+// BatchDelete retries the batch as a whole
+func (r *RetryingDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	_, err := retry(ctx, r, "BATCH_DELETE", none(func(ctx context.Context) error {
+		return r.batch.BatchDelete(ctx, table, keys)
+	}))
+	return err
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"strconv"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// TableCountProperty spreads the records of the core workload over that many
+// tables, as a node keeps state, receipts and headers in separate
+// namespaces. The first is the table property itself and the others are it
+// numbered from 1, e.g. usertable, usertable1, usertable2. Backends keep the
+// first where a single table always was, so databases loaded before stay
+// readable, and give every other its own key space.
+const TableCountProperty = "tablecount"
+
+// Tables returns the names of the tables of a run, the first being the
+// table property
+func Tables(p *properties.Properties) []string {
+	table := p.GetString(prop.TableName, prop.TableNameDefault)
+	tables := []string{table}
+	for i := 1; i < p.GetInt(TableCountProperty, 1); i++ {
+		tables = append(tables, table+strconv.Itoa(i))
+	}
+	return tables
+}
+
+// tableKey returns key as stored in table: unchanged in the first table of
+// the run, otherwise after the table's name between zero bytes. Keys of
+// other tables so sort before those of the first, whose scans cannot reach
+// them, and each table's keys are contiguous.
+func tableKey(first, table, key string) []byte {
+	if table == first {
+		return []byte(key)
+	}
+	b := make([]byte, 0, len(table)+len(key)+2)
+	b = append(b, 0)
+	b = append(b, table...)
+	b = append(b, 0)
+	return append(b, key...)
+}
+
+// tableEnd returns the first key after every key of table, or nil for no
+// bound in the first table of the run
+func tableEnd(first, table string) []byte {
+	if table == first {
+		return nil
+	}
+	return prefixEnd(tableKey(first, table, ""))
+}
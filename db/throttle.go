@@ -0,0 +1,312 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// ThrottledDB paces operations to a fixed-rate schedule shared by all client
+// threads, so the benchmark runs at a fixed offered load instead of as fast
+// as the closed loop allows. Time spent waiting for a slot is not part of the
+// operation: wrap this around the measured DB, not inside it.
+//
+// Every operation has an intended start on the schedule. One that falls
+// behind, because the backend stalled every thread, runs at once instead of
+// giving up its slot, so the run catches up afterwards and latency measured
+// from the intended start (see IntendedStart) includes the time it spent
+// queued behind the stall: the coordinated omission correction of wrk2 and
+// HdrHistogram.
+//
+// With bursts (see SetBursts) the schedule only has slots during the first
+// part of every period, and operations due in the idle part wait for the next
+// burst, as transactions arrive with blocks rather than evenly. With a ramp
+// (see SetRamp) only some of the client threads issue operations at first,
+// and more join at every step.
+type ThrottledDB struct {
+	ycsb.DB
+	batch    ycsb.BatchDB
+	interval time.Duration // between consecutive intended starts; 0 leaves operations unpaced
+	ahead    time.Duration // how early an operation may start before its slot
+	period   time.Duration // of one burst and the idle time after it; 0 without bursts
+	on       time.Duration // of the burst at the start of each period
+	threads  []int         // active threads at every step of the ramp, nil without one
+	step     time.Duration // of every step of the ramp
+	lead     time.Duration // added to the first step of the ramp
+
+	mu        sync.Mutex
+	origin    time.Time // start of the first burst
+	next      time.Time // intended start of the next operation
+	rampStart time.Time // end of the lead, when the first step of the ramp counts from
+}
+
+// BatchingDB is a DB that also supports batch operations, such as
+// go-ycsb's client.DbWrapper
+type BatchingDB interface {
+	ycsb.DB
+	ycsb.BatchDB
+}
+
+// NewThrottledDB limits db to opsPerSec operations per second. burst is the
+// number of operations that may be issued back to back, starting up to
+// burst-1 slots ahead of schedule. opsPerSec 0 leaves operations unpaced,
+// for bursts alone.
+func NewThrottledDB(db BatchingDB, opsPerSec float64, burst int) *ThrottledDB {
+	if burst < 1 {
+		burst = 1
+	}
+	var interval time.Duration
+	if opsPerSec > 0 {
+		interval = time.Duration(float64(time.Second) / opsPerSec)
+	}
+	return &ThrottledDB{
+		DB:       db,
+		batch:    db,
+		interval: interval,
+		ahead:    time.Duration(burst-1) * interval,
+	}
+}
+
+// SetBursts issues operations only during the first on of every period and
+// none for the rest of it. It must be called before the run starts.
+func (t *ThrottledDB) SetBursts(period, on time.Duration) {
+	t.period, t.on = period, on
+}
+
+// SetRamp lets only the first threads[0] client threads issue operations
+// for lead plus one step, then the first threads[1] for the next step, and
+// so on, the last level holding to the end of the run. threads must be
+// increasing. It must be called before the run starts.
+func (t *ThrottledDB) SetRamp(threads []int, step, lead time.Duration) {
+	t.threads, t.step, t.lead = threads, step, lead
+}
+
+// threadKey is the context key of the client thread ID
+type threadKey struct{}
+
+// InitThread records the client thread ID, which decides when a ramp lets
+// the thread in
+func (t *ThrottledDB) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	ctx = t.DB.InitThread(ctx, threadID, threadCount)
+	return context.WithValue(ctx, threadKey{}, threadID)
+}
+
+// intendedKey is the context key of an operation's intended start
+type intendedKey struct{}
+
+// rampKey is the context key of the step of the ramp an operation started in
+type rampKey struct{}
+
+// burstKey is the context key of how far into its burst an operation was
+// meant to start
+type burstKey struct{}
+
+// IntendedStart returns the time the schedule of a ThrottledDB intended the
+// operation running with ctx to start, if it was paced by one
+func IntendedStart(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(intendedKey{}).(time.Time)
+	return t, ok
+}
+
+// RampStep returns the step of the ramp the operation running with ctx
+// started in, if it was paced by a ThrottledDB with a ramp
+func RampStep(ctx context.Context) (int, bool) {
+	step, ok := ctx.Value(rampKey{}).(int)
+	return step, ok
+}
+
+// rampWait blocks the thread running with ctx until the ramp lets it in or
+// ctx is done. It returns ctx with the step of the ramp.
+func (t *ThrottledDB) rampWait(ctx context.Context) (context.Context, error) {
+	t.mu.Lock()
+	if t.rampStart.IsZero() {
+		t.rampStart = time.Now().Add(t.lead)
+	}
+	start := t.rampStart
+	t.mu.Unlock()
+
+	id, _ := ctx.Value(threadKey{}).(int)
+	first := sort.Search(len(t.threads), func(i int) bool { return t.threads[i] > id })
+	if d := time.Until(start.Add(time.Duration(first) * t.step)); first > 0 && d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	step := min(int(max(time.Since(start), 0)/t.step), len(t.threads)-1)
+	return context.WithValue(ctx, rampKey{}, step), nil
+}
+
+// BurstOffset returns how long after the start of its burst the operation
+// running with ctx was meant to start, if it was paced by a ThrottledDB with
+// bursts
+func BurstOffset(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(burstKey{}).(time.Duration)
+	return d, ok
+}
+
+// wait claims the next n slots of the schedule, one per record, and blocks
+// until the first is due or ctx is done. It returns ctx with the intended
+// start of the operation and, with bursts, how far into its burst that is.
+// With a ramp, the thread first waits for its step.
+func (t *ThrottledDB) wait(ctx context.Context, n int) (context.Context, error) {
+	if t.threads != nil {
+		var err error
+		if ctx, err = t.rampWait(ctx); err != nil {
+			return ctx, err
+		}
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.IsZero() {
+		t.origin, t.next = now, now
+	}
+	if t.interval == 0 && t.next.Before(now) {
+		t.next = now // unpaced, so there is no schedule to fall behind
+	}
+	intended := t.next
+	var offset time.Duration
+	if t.period > 0 {
+		offset = intended.Sub(t.origin) % t.period
+		if offset >= t.on {
+			intended = intended.Add(t.period - offset)
+			offset = 0
+		}
+	}
+	t.next = intended.Add(time.Duration(n) * t.interval)
+	t.mu.Unlock()
+
+	if d := time.Until(intended.Add(-t.ahead)); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if t.period > 0 {
+		ctx = context.WithValue(ctx, burstKey{}, offset)
+	}
+	return context.WithValue(ctx, intendedKey{}, intended), nil
+}
+
+func (t *ThrottledDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	ctx, err := t.wait(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return t.DB.Read(ctx, table, key, fields)
+}
+
+func (t *ThrottledDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	ctx, err := t.wait(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return t.DB.Scan(ctx, table, startKey, count, fields)
+}
+
+func (t *ThrottledDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	ctx, err := t.wait(ctx, 1)
+	if err != nil {
+		return err
+	}
+	return t.DB.Update(ctx, table, key, values)
+}
+
+func (t *ThrottledDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	ctx, err := t.wait(ctx, 1)
+	if err != nil {
+		return err
+	}
+	return t.DB.Insert(ctx, table, key, values)
+}
+
+func (t *ThrottledDB) Delete(ctx context.Context, table string, key string) error {
+	ctx, err := t.wait(ctx, 1)
+	if err != nil {
+		return err
+	}
+	return t.DB.Delete(ctx, table, key)
+}
+
+// ReadModifyWrite waits for one token, then reads, modifies and writes back
+// the record
+func (t *ThrottledDB) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	ctx, err := t.wait(ctx, 1)
+	if err != nil {
+		return err
+	}
+	return ReadModifyWrite(ctx, t.DB, table, key, fields, modify)
+}
+
+// PartialRead waits for one token, then reads part of a value
+func (t *ThrottledDB) PartialRead(ctx context.Context, table string, key string, field string, offset int, length int) ([]byte, error) {
+	ctx, err := t.wait(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return PartialRead(ctx, t.DB, table, key, field, offset, length)
+}
+
+// PrefixScan waits for one token, then reads every record under prefix
+func (t *ThrottledDB) PrefixScan(ctx context.Context, table string, prefix string, fields []string) ([]map[string][]byte, error) {
+	ctx, err := t.wait(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return PrefixScan(ctx, t.DB, table, prefix, fields)
+}
+
+// NewBatch waits for one token per operation of the batch, then starts it
+func (t *ThrottledDB) NewBatch(ctx context.Context, size int) (Batch, error) {
+	ctx, err := t.wait(ctx, size)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatch(ctx, t.DB, size)
+}
+
+// BatchInsert waits for one token per record, then inserts the batch
+func (t *ThrottledDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	ctx, err := t.wait(ctx, len(keys))
+	if err != nil {
+		return err
+	}
+	return t.batch.BatchInsert(ctx, table, keys, values)
+}
+
+// BatchRead waits for one token per record, then reads the batch
+func (t *ThrottledDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	ctx, err := t.wait(ctx, len(keys))
+	if err != nil {
+		return nil, err
+	}
+	return t.batch.BatchRead(ctx, table, keys, fields)
+}
+
+// BatchUpdate waits for one token per record, then updates the batch
+func (t *ThrottledDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	ctx, err := t.wait(ctx, len(keys))
+	if err != nil {
+		return err
+	}
+	return t.batch.BatchUpdate(ctx, table, keys, values)
+}
+
+// BatchDelete waits for one token per record, then deletes the batch
+func (t *ThrottledDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	ctx, err := t.wait(ctx, len(keys))
+	if err != nil {
+		return err
+	}
+	return t.batch.BatchDelete(ctx, table, keys)
+}
@@ -12,8 +12,10 @@ import (
 )
 
 type trieDB struct {
-	db      *triedb.Database
-	account triedb.Address // Single account to use for all storage
+	db       *triedb.Database
+	table    string                    // the first table of the run
+	accounts map[string]triedb.Address // holding the storage of each table
+	encoding string                    // of the keys, see KeyEncodingProperty
 }
 
 func (t *trieDB) Close() error {
@@ -33,6 +35,48 @@ func keyToSlot(key string) triedb.Hash {
 	return hash
 }
 
+// slot returns the storage slot of key: its hash, or with raw keys the key
+// itself, zero-padded, as long as it fits
+func (t *trieDB) slot(key string) (triedb.Hash, error) {
+	if t.encoding == KeyEncodingSHA256 {
+		return keyToSlot(key), nil
+	}
+	var slot triedb.Hash
+	if len(key) > len(slot) {
+		return slot, fmt.Errorf("key %q is longer than a %d-byte slot: use %s=%s", key, len(slot), KeyEncodingProperty, KeyEncodingSHA256)
+	}
+	copy(slot[:], key)
+	return slot, nil
+}
+
+// locate returns the account and the slot holding key of table
+func (t *trieDB) locate(table, key string) (triedb.Address, triedb.Hash, error) {
+	account, err := t.account(table)
+	if err != nil {
+		return account, triedb.Hash{}, err
+	}
+	slot, err := t.slot(key)
+	return account, slot, err
+}
+
+// tableAddress returns the account holding the storage of a table other
+// than the first of the run
+func tableAddress(table string) triedb.Address {
+	hash := sha256.Sum256([]byte("YCSB table " + table))
+	var account triedb.Address
+	copy(account[:], hash[len(hash)-len(account):])
+	return account
+}
+
+// account returns the account holding the storage of table
+func (t *trieDB) account(table string) (triedb.Address, error) {
+	account, ok := t.accounts[table]
+	if !ok {
+		return triedb.Address{}, fmt.Errorf("unknown table %s: set %s to cover it", table, TableCountProperty)
+	}
+	return account, nil
+}
+
 // bytesToHash converts a byte slice to a 32-byte hash
 func bytesToHash(data []byte) triedb.Hash {
 	var hash triedb.Hash
@@ -45,20 +89,26 @@ func bytesToHash(data []byte) triedb.Hash {
 }
 
 func (t *trieDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	account, slot, err := t.locate(table, key)
+	if err != nil {
+		return nil, err
+	}
 	tx, err := t.db.BeginRO()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin read transaction: %w", err)
 	}
 	defer tx.Commit()
 
-	slot := keyToSlot(key)
-	value, err := tx.GetStorage(t.account, slot)
+	value, err := tx.GetStorage(account, slot)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read key %s: %w", key, err)
 	}
 
 	if value == nil {
-		return nil, fmt.Errorf("key not found: %s", key)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
 	}
 
 	data := make(map[string][]byte)
@@ -75,17 +125,28 @@ func (t *trieDB) Update(ctx context.Context, table string, key string, values ma
 }
 
 func (t *trieDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	account, slot, err := t.locate(table, key)
+	if err != nil {
+		return err
+	}
 	tx, err := t.db.BeginRW()
 	if err != nil {
 		return fmt.Errorf("failed to begin write transaction: %w", err)
 	}
+	if err := ctx.Err(); err != nil {
+		// Beginning waits for any other writer, which may take past the end
+		tx.Rollback()
+		return err
+	}
 
 	// In YCSB, there is only one field.
 	for _, value := range values {
-		slot := keyToSlot(key)
 		hash := bytesToHash(value)
 
-		if err := tx.SetStorage(t.account, slot, &hash); err != nil {
+		if err := tx.SetStorage(account, slot, &hash); err != nil {
 			tx.Rollback()
 			return fmt.Errorf("failed to write key %s: %w", key, err)
 		}
@@ -99,13 +160,23 @@ func (t *trieDB) Insert(ctx context.Context, table string, key string, values ma
 }
 
 func (t *trieDB) Delete(ctx context.Context, table string, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	account, slot, err := t.locate(table, key)
+	if err != nil {
+		return err
+	}
 	tx, err := t.db.BeginRW()
 	if err != nil {
 		return fmt.Errorf("failed to begin write transaction: %w", err)
 	}
+	if err := ctx.Err(); err != nil {
+		tx.Rollback()
+		return err
+	}
 
-	slot := keyToSlot(key)
-	if err := tx.SetStorage(t.account, slot, nil); err != nil {
+	if err := tx.SetStorage(account, slot, nil); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to delete key %s: %w", key, err)
 	}
@@ -116,24 +187,186 @@ func (t *trieDB) Delete(ctx context.Context, table string, key string) error {
 	return nil
 }
 
+// ReadModifyWrite reads the slot of key, modifies it and writes it back in a
+// single transaction
+func (t *trieDB) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	account, slot, err := t.locate(table, key)
+	if err != nil {
+		return err
+	}
+	tx, err := t.db.BeginRW()
+	if err != nil {
+		return fmt.Errorf("failed to begin write transaction: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	value, err := tx.GetStorage(account, slot)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to read key %s: %w", key, err)
+	}
+	if value == nil {
+		tx.Rollback()
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	// In YCSB, there is only one field.
+	for _, v := range modify(map[string][]byte{fields[0]: value[:]}) {
+		hash := bytesToHash(v)
+		if err := tx.SetStorage(account, slot, &hash); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to write key %s: %w", key, err)
+		}
+		break
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// NewBatch begins a write transaction the batch's operations run in
+func (t *trieDB) NewBatch(ctx context.Context, size int) (Batch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tx, err := t.db.BeginRW()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin write transaction: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &trieBatch{tx: tx, t: t}, nil
+}
+
+// trieBatch is a Batch of TrieDB, one write transaction
+type trieBatch struct {
+	tx *triedb.Tx
+	t  *trieDB
+}
+
+func (b *trieBatch) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	account, slot, err := b.t.locate(table, key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := b.tx.GetStorage(account, slot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", key, err)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	data := make(map[string][]byte)
+	data[fields[0]] = value[:]
+	return data, nil
+}
+
+func (b *trieBatch) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return b.Insert(ctx, table, key, values)
+}
+
+func (b *trieBatch) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	account, slot, err := b.t.locate(table, key)
+	if err != nil {
+		return err
+	}
+	// In YCSB, there is only one field.
+	for _, value := range values {
+		hash := bytesToHash(value)
+		if err := b.tx.SetStorage(account, slot, &hash); err != nil {
+			return fmt.Errorf("failed to write key %s: %w", key, err)
+		}
+		return nil
+	}
+	return nil
+}
+
+func (b *trieBatch) Delete(ctx context.Context, table string, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	account, slot, err := b.t.locate(table, key)
+	if err != nil {
+		return err
+	}
+	if err := b.tx.SetStorage(account, slot, nil); err != nil {
+		return fmt.Errorf("failed to delete key %s: %w", key, err)
+	}
+	return nil
+}
+
+func (b *trieBatch) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	values, err := b.Read(ctx, table, key, fields)
+	if err != nil {
+		return err
+	}
+	return b.Update(ctx, table, key, modify(values))
+}
+
+func (b *trieBatch) Commit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	if err := b.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (b *trieBatch) Rollback() {
+	b.tx.Rollback()
+}
+
 // BatchInsert inserts multiple records in a single transaction
 func (t *trieDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
 	if len(keys) == 0 {
 		return nil
 	}
 
+	account, err := t.account(table)
+	if err != nil {
+		return err
+	}
 	tx, err := t.db.BeginRW()
 	if err != nil {
 		return fmt.Errorf("failed to begin write transaction: %w", err)
 	}
 
 	for i, key := range keys {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
 		// In YCSB, there is only one field per record
 		for _, value := range values[i] {
-			slot := keyToSlot(key)
+			slot, err := t.slot(key)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
 			hash := bytesToHash(value)
 
-			if err := tx.SetStorage(t.account, slot, &hash); err != nil {
+			if err := tx.SetStorage(account, slot, &hash); err != nil {
 				tx.Rollback()
 				return fmt.Errorf("failed to write key %s in batch: %w", key, err)
 			}
@@ -158,6 +391,10 @@ func (t *trieDB) BatchRead(ctx context.Context, table string, keys []string, fie
 		return nil, nil
 	}
 
+	account, err := t.account(table)
+	if err != nil {
+		return nil, err
+	}
 	tx, err := t.db.BeginRO()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin read transaction: %w", err)
@@ -166,14 +403,22 @@ func (t *trieDB) BatchRead(ctx context.Context, table string, keys []string, fie
 
 	results := make([]map[string][]byte, len(keys))
 	for i, key := range keys {
-		slot := keyToSlot(key)
-		value, err := tx.GetStorage(t.account, slot)
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		slot, err := t.slot(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := tx.GetStorage(account, slot)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read key %s in batch: %w", key, err)
 		}
 
 		if value == nil {
-			return nil, fmt.Errorf("key not found in batch: %s", key)
+			return nil, fmt.Errorf("%w in batch: %s", ErrNotFound, key)
 		}
 
 		data := make(map[string][]byte)
@@ -190,14 +435,28 @@ func (t *trieDB) BatchDelete(ctx context.Context, table string, keys []string) e
 		return nil
 	}
 
+	account, err := t.account(table)
+	if err != nil {
+		return err
+	}
 	tx, err := t.db.BeginRW()
 	if err != nil {
 		return fmt.Errorf("failed to begin write transaction: %w", err)
 	}
 
-	for _, key := range keys {
-		slot := keyToSlot(key)
-		if err := tx.SetStorage(t.account, slot, nil); err != nil {
+	for i, key := range keys {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		slot, err := t.slot(key)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.SetStorage(account, slot, nil); err != nil {
 			tx.Rollback()
 			return fmt.Errorf("failed to delete key %s in batch: %w", key, err)
 		}
@@ -209,16 +468,155 @@ func (t *trieDB) BatchDelete(ctx context.Context, table string, keys []string) e
 	return nil
 }
 
+// WriteBlock commits the writes and deletes of a block in a single
+// transaction, as storage slots of the benchmark account
+func (t *trieDB) WriteBlock(ctx context.Context, keys []string, values [][]byte) error {
+	account := t.accounts[t.table]
+	tx, err := t.db.BeginRW()
+	if err != nil {
+		return fmt.Errorf("failed to begin write transaction: %w", err)
+	}
+
+	for i, key := range keys {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		var value *triedb.Hash
+		if values[i] != nil {
+			hash := bytesToHash(values[i])
+			value = &hash
+		}
+		slot, err := t.slot(key)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.SetStorage(account, slot, value); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to write key %x in block: %w", key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit block transaction: %w", err)
+	}
+	return nil
+}
+
+// UpdateAccounts changes the nonce and balance of accounts in a single
+// transaction, keeping their storage and code
+func (t *trieDB) UpdateAccounts(ctx context.Context, addresses [][20]byte, update func(i int, account *Account)) error {
+	tx, err := t.db.BeginRW()
+	if err != nil {
+		return fmt.Errorf("failed to begin write transaction: %w", err)
+	}
+
+	for i, address := range addresses {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		stored, err := tx.GetAccount(address)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to read account %x: %w", address, err)
+		}
+		if stored == nil {
+			stored = &triedb.Account{Balance: uint256.NewInt(0), CodeHash: make([]byte, 32)}
+		}
+		account := Account{Nonce: stored.Nonce, Balance: new(uint256.Int)}
+		if stored.Balance != nil {
+			account.Balance.Set(stored.Balance)
+		}
+		update(i, &account)
+
+		updated := *stored
+		updated.Nonce, updated.Balance = account.Nonce, account.Balance
+		if err := tx.SetAccount(address, &updated); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to write account %x: %w", address, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateAccount creates an account with the given storage in a single
+// transaction
+func (t *trieDB) CreateAccount(ctx context.Context, address [20]byte, slots, values [][32]byte) error {
+	tx, err := t.db.BeginRW()
+	if err != nil {
+		return fmt.Errorf("failed to begin write transaction: %w", err)
+	}
+
+	account := &triedb.Account{Nonce: 1, Balance: uint256.NewInt(0), CodeHash: make([]byte, 32)}
+	if err := tx.SetAccount(address, account); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to create account %x: %w", address, err)
+	}
+	for i, slot := range slots {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		value := triedb.Hash(values[i])
+		if err := tx.SetStorage(address, slot, &value); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to write slot %x of account %x: %w", slot, address, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account creation: %w", err)
+	}
+	return nil
+}
+
+// DestroyAccount deletes an account in a single transaction. Setting an
+// account to nil deletes it with its storage trie, as setting a slot to nil
+// deletes the slot.
+func (t *trieDB) DestroyAccount(ctx context.Context, address [20]byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tx, err := t.db.BeginRW()
+	if err != nil {
+		return fmt.Errorf("failed to begin write transaction: %w", err)
+	}
+	if err := tx.SetAccount(address, nil); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete account %x: %w", address, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+	return nil
+}
+
 type triedbCreator struct{}
 
 func (c triedbCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 	path := p.GetString("datadir", "/tmp/triedb")
 
+	encoding, err := keyEncoding(p, KeyEncodingSHA256)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if we should use an existing database or create new
 	useExisting := p.GetBool("triedb.use_existing", true)
 
 	var db *triedb.Database
-	var err error
 
 	if useExisting {
 		// Try to open existing database first
@@ -238,38 +636,52 @@ func (c triedbCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 		}
 	}
 
-	// Use a fixed account address for all storage operations
+	// Use a fixed account address for the storage of the first table
 	// This is a dummy account since YCSB is just key-value, not account-based
-	var account triedb.Address
-	copy(account[:], []byte("YCSB_BENCHMARK_ACCOUNT__"))
+	// Every other table gets an account of its own
+	tables := Tables(p)
+	accounts := make(map[string]triedb.Address, len(tables))
+	for i, table := range tables {
+		if i == 0 {
+			var account triedb.Address
+			copy(account[:], []byte("YCSB_BENCHMARK_ACCOUNT__"))
+			accounts[table] = account
+		} else {
+			accounts[table] = tableAddress(table)
+		}
+	}
 
-	// Ensure the account exists with initial values
+	// Ensure the accounts exist with initial values
 	tx, err := db.BeginRW()
 	if err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
-	// Check if account exists, if not create it
-	existingAccount, err := tx.GetAccount(account)
-	if err != nil {
-		tx.Rollback()
-		db.Close()
-		return nil, fmt.Errorf("failed to check account: %w", err)
-	}
+	for _, table := range tables {
+		account := accounts[table]
 
-	if existingAccount == nil {
-		// Create account with initial values
-		newAccount := &triedb.Account{
-			Nonce:       0,
-			Balance:     uint256.NewInt(0),
-			StorageRoot: triedb.Hash{},
-			CodeHash:    make([]byte, 32),
-		}
-		if err := tx.SetAccount(account, newAccount); err != nil {
+		// Check if account exists, if not create it
+		existingAccount, err := tx.GetAccount(account)
+		if err != nil {
 			tx.Rollback()
 			db.Close()
-			return nil, fmt.Errorf("failed to create account: %w", err)
+			return nil, fmt.Errorf("failed to check account of table %s: %w", table, err)
+		}
+
+		if existingAccount == nil {
+			// Create account with initial values
+			newAccount := &triedb.Account{
+				Nonce:       0,
+				Balance:     uint256.NewInt(0),
+				StorageRoot: triedb.Hash{},
+				CodeHash:    make([]byte, 32),
+			}
+			if err := tx.SetAccount(account, newAccount); err != nil {
+				tx.Rollback()
+				db.Close()
+				return nil, fmt.Errorf("failed to create account of table %s: %w", table, err)
+			}
 		}
 	}
 
@@ -278,9 +690,20 @@ func (c triedbCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 		return nil, fmt.Errorf("failed to commit account creation: %w", err)
 	}
 
-	return &trieDB{db: db, account: account}, nil
+	return &trieDB{db: db, table: tables[0], accounts: accounts, encoding: encoding}, nil
 }
 
 func init() {
-	ycsb.RegisterDBCreator("triedb", triedbCreator{})
+	registerDBCreator("triedb", triedbCreator{},
+		"datadir",
+		"triedb.use_existing",
+		KeyEncodingProperty,
+	)
+	registerCapabilities("triedb", Capabilities{
+		Sync:         "commit per write transaction",
+		Transactions: "one read-write transaction",
+		Batching:     "one read-write transaction",
+		KeyEncoding:  KeyEncodingSHA256,
+		MaxValueSize: SlotSize,
+	})
 }
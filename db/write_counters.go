@@ -0,0 +1,16 @@
+package db
+
+// WriteCounters are an engine's cumulative byte counts of what the
+// application wrote and what the engine wrote to storage on its behalf, so
+// write amplification can be compared across backends
+type WriteCounters struct {
+	UserBytes      uint64 // logical bytes written by the application
+	WALBytes       uint64 // bytes written to the write-ahead log
+	FlushedBytes   uint64 // bytes written by flushes and ingestions
+	CompactedBytes uint64 // bytes rewritten by compactions
+}
+
+// WriteCounter is implemented by backends that expose WriteCounters
+type WriteCounter interface {
+	WriteCounters() WriteCounters
+}
@@ -0,0 +1,6 @@
+package disk
+
+import "syscall"
+
+// directFlag opens files for IO that bypasses the page cache
+const directFlag = syscall.O_DIRECT
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package disk
+
+// directFlag is 0 where direct IO is not available through open(2)
+const directFlag = 0
@@ -0,0 +1,257 @@
+// Package disk measures the raw performance of the device under a directory
+// in the manner of a small fio: sequential and random reads and writes and
+// fsync latency, without any engine in between
+package disk
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+	"unsafe"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// Defaults of Config
+const (
+	DefaultFileSize     = 256 << 20
+	DefaultBlockSize    = 4 << 10
+	DefaultSeqBlockSize = 1 << 20
+	DefaultDuration     = 3 * time.Second
+)
+
+// alignment is the buffer and offset alignment direct IO needs
+const alignment = 4096
+
+// Config describes a baseline measurement
+type Config struct {
+	Dir          string        // where the test file is created; it must exist
+	FileSize     int64         // size of the test file; default DefaultFileSize
+	BlockSize    int           // of random IO and fsynced writes; default DefaultBlockSize
+	SeqBlockSize int           // of sequential IO; default DefaultSeqBlockSize
+	Duration     time.Duration // of each random IO and fsync test; default DefaultDuration
+}
+
+// Measure runs every test in a temporary file under Dir and removes it.
+// Tests run one IO at a time, so latencies are those of the device rather
+// than of a queue in front of it.
+func Measure(cfg Config) (*metrics.DiskBaseline, error) {
+	if cfg.FileSize <= 0 {
+		cfg.FileSize = DefaultFileSize
+	}
+	if cfg.BlockSize <= 0 {
+		cfg.BlockSize = DefaultBlockSize
+	}
+	if cfg.SeqBlockSize <= 0 {
+		cfg.SeqBlockSize = DefaultSeqBlockSize
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = DefaultDuration
+	}
+	if cfg.BlockSize%alignment != 0 || cfg.SeqBlockSize%alignment != 0 {
+		return nil, fmt.Errorf("block sizes must be multiples of %d bytes", alignment)
+	}
+	// Whole sequential blocks only
+	cfg.FileSize -= cfg.FileSize % int64(cfg.SeqBlockSize)
+	if cfg.FileSize < int64(cfg.SeqBlockSize) {
+		return nil, fmt.Errorf("file size must be at least the sequential block size, %d bytes", cfg.SeqBlockSize)
+	}
+
+	f, err := os.CreateTemp(cfg.Dir, ".godb-bench-disk-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create test file: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	// Filesystems such as tmpfs refuse direct IO; measure through the page
+	// cache there rather than not at all
+	direct := directFlag != 0
+	if direct {
+		f, err := os.OpenFile(path, os.O_RDWR|directFlag, 0)
+		if err != nil {
+			direct = false
+		} else {
+			f.Close()
+		}
+	}
+	flags := os.O_RDWR
+	if direct {
+		flags |= directFlag
+	}
+
+	dir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		dir = cfg.Dir
+	}
+	baseline := &metrics.DiskBaseline{Dir: dir, FileSize: cfg.FileSize, DirectIO: direct}
+	tests := []struct {
+		name string
+		run  func(f *os.File, cfg Config) (metrics.DiskTest, error)
+	}{
+		{"seq_write", seqWrite},
+		{"seq_read", seqRead},
+		{"rand_read", randRead},
+		{"rand_write", randWrite},
+	}
+	for _, t := range tests {
+		f, err := os.OpenFile(path, flags, 0)
+		if err != nil {
+			return nil, err
+		}
+		result, err := t.run(f, cfg)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.name, err)
+		}
+		baseline.Tests = append(baseline.Tests, result)
+	}
+
+	// Write-ahead logs append and sync, through the page cache
+	f, err = os.OpenFile(path, os.O_RDWR|os.O_TRUNC, 0)
+	if err != nil {
+		return nil, err
+	}
+	result, err := fsyncWrite(f, cfg)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("fsync: %w", err)
+	}
+	baseline.Tests = append(baseline.Tests, result)
+	return baseline, nil
+}
+
+// alignedBuffer returns a buffer of size bytes aligned for direct IO, filled
+// with random data so compressing devices cannot shortcut it
+func alignedBuffer(size int) []byte {
+	buf := make([]byte, size+alignment)
+	offset := 0
+	if rem := int(uintptr(unsafe.Pointer(&buf[0])) % alignment); rem != 0 {
+		offset = alignment - rem
+	}
+	buf = buf[offset : offset+size]
+	rand.Read(buf)
+	return buf
+}
+
+// newLatencyHistogram records latencies from 1ns to a minute
+func newLatencyHistogram() *hdrhistogram.Histogram {
+	return hdrhistogram.New(1, int64(time.Minute), 3)
+}
+
+// seqWrite writes the whole file in sequential blocks and syncs it, which
+// the throughput includes
+func seqWrite(f *os.File, cfg Config) (metrics.DiskTest, error) {
+	buf := alignedBuffer(cfg.SeqBlockSize)
+	latency := newLatencyHistogram()
+	var ops int64
+	start := time.Now()
+	for off := int64(0); off < cfg.FileSize; off += int64(len(buf)) {
+		t := time.Now()
+		if _, err := f.WriteAt(buf, off); err != nil {
+			return metrics.DiskTest{}, err
+		}
+		latency.RecordValue(int64(time.Since(t)))
+		ops++
+	}
+	if err := f.Sync(); err != nil {
+		return metrics.DiskTest{}, err
+	}
+	return metrics.NewDiskTest("seq_write", cfg.SeqBlockSize, ops, time.Since(start), latency), nil
+}
+
+// seqRead reads the whole file in sequential blocks
+func seqRead(f *os.File, cfg Config) (metrics.DiskTest, error) {
+	buf := alignedBuffer(cfg.SeqBlockSize)
+	latency := newLatencyHistogram()
+	var ops int64
+	start := time.Now()
+	for off := int64(0); off < cfg.FileSize; off += int64(len(buf)) {
+		t := time.Now()
+		if _, err := f.ReadAt(buf, off); err != nil {
+			return metrics.DiskTest{}, err
+		}
+		latency.RecordValue(int64(time.Since(t)))
+		ops++
+	}
+	return metrics.NewDiskTest("seq_read", cfg.SeqBlockSize, ops, time.Since(start), latency), nil
+}
+
+// randRead reads blocks at random offsets for the configured duration
+func randRead(f *os.File, cfg Config) (metrics.DiskTest, error) {
+	return randomIO(f, cfg, "rand_read", f.ReadAt)
+}
+
+// randWrite writes blocks at random offsets for the configured duration
+func randWrite(f *os.File, cfg Config) (metrics.DiskTest, error) {
+	return randomIO(f, cfg, "rand_write", f.WriteAt)
+}
+
+// randomIO issues io on random block-aligned offsets of the file until the
+// configured duration has elapsed
+func randomIO(f *os.File, cfg Config, name string, io func([]byte, int64) (int, error)) (metrics.DiskTest, error) {
+	buf := alignedBuffer(cfg.BlockSize)
+	blocks := cfg.FileSize / int64(cfg.BlockSize)
+	latency := newLatencyHistogram()
+	var ops int64
+	start := time.Now()
+	for time.Since(start) < cfg.Duration {
+		off := rand.Int63n(blocks) * int64(cfg.BlockSize)
+		t := time.Now()
+		if _, err := io(buf, off); err != nil {
+			return metrics.DiskTest{}, err
+		}
+		latency.RecordValue(int64(time.Since(t)))
+		ops++
+	}
+	return metrics.NewDiskTest(name, cfg.BlockSize, ops, time.Since(start), latency), nil
+}
+
+// fsyncWrite appends a block and syncs the file, as a write-ahead log
+// commits, until the configured duration has elapsed. Latencies cover both.
+func fsyncWrite(f *os.File, cfg Config) (metrics.DiskTest, error) {
+	buf := alignedBuffer(cfg.BlockSize)
+	latency := newLatencyHistogram()
+	var ops int64
+	start := time.Now()
+	for time.Since(start) < cfg.Duration {
+		t := time.Now()
+		if _, err := f.Write(buf); err != nil {
+			return metrics.DiskTest{}, err
+		}
+		if err := f.Sync(); err != nil {
+			return metrics.DiskTest{}, err
+		}
+		latency.RecordValue(int64(time.Since(t)))
+		ops++
+	}
+	return metrics.NewDiskTest("fsync", cfg.BlockSize, ops, time.Since(start), latency), nil
+}
+
+// ExistingDir returns dir, or its nearest ancestor that exists, so the
+// device of a data directory can be measured before the engine creates it
+func ExistingDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		info, err := os.Stat(abs)
+		if err == nil {
+			if !info.IsDir() {
+				return "", fmt.Errorf("%s is not a directory", abs)
+			}
+			return abs, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) || abs == filepath.Dir(abs) {
+			return "", err
+		}
+		abs = filepath.Dir(abs)
+	}
+}
@@ -0,0 +1,369 @@
+// Package history keeps the results of past benchmark runs in a local SQLite
+// database so they can be listed and compared later
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// timeLayout has a fixed width so recorded_at sorts and compares as text
+const timeLayout = "2006-01-02T15:04:05.000000Z"
+
+// schemaVersion is stored in PRAGMA user_version; bump it with a migration
+// whenever the schema changes
+const schemaVersion = 1
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	recorded_at  TEXT NOT NULL,
+	command      TEXT NOT NULL,
+	name         TEXT NOT NULL,
+	db           TEXT NOT NULL,
+	version      TEXT NOT NULL,
+	vcs_revision TEXT NOT NULL,
+	hostname     TEXT NOT NULL,
+	properties   TEXT NOT NULL,
+	environment  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS runs_recorded_at ON runs (recorded_at);
+
+CREATE TABLE IF NOT EXISTS operations (
+	run_id        INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+	operation     TEXT NOT NULL,
+	total_time_ns INTEGER NOT NULL,
+	count         INTEGER NOT NULL,
+	ops           REAL NOT NULL,
+	avg_us        INTEGER NOT NULL,
+	min_us        INTEGER NOT NULL,
+	max_us        INTEGER NOT NULL,
+	p50_us        INTEGER NOT NULL,
+	p90_us        INTEGER NOT NULL,
+	p95_us        INTEGER NOT NULL,
+	p99_us        INTEGER NOT NULL,
+	p999_us       INTEGER NOT NULL,
+	PRIMARY KEY (run_id, operation)
+);
+
+CREATE TABLE IF NOT EXISTS statistics (
+	run_id    INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+	operation TEXT NOT NULL,
+	statistic TEXT NOT NULL,
+	lower     REAL NOT NULL,
+	estimate  REAL NOT NULL,
+	upper     REAL NOT NULL,
+	std_error REAL NOT NULL,
+	PRIMARY KEY (run_id, operation, statistic)
+);
+`
+
+// DefaultPath returns the history database used when none is configured
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "godb-bench-history.db"
+	}
+	return filepath.Join(home, ".godb-bench", "history.db")
+}
+
+// Store is a history database
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the history database at path, creating it if needed
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	s := &Store{db: db}
+	if err := s.init(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// init creates the schema and checks its version
+func (s *Store) init() error {
+	if _, err := s.db.Exec("PRAGMA foreign_keys = ON; PRAGMA busy_timeout = 5000"); err != nil {
+		return err
+	}
+
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("schema version %d is newer than this build supports (%d)", version, schemaVersion)
+	}
+
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion))
+	return err
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Run is one recorded run
+type Run struct {
+	ID         int64
+	RecordedAt time.Time
+	Command    string
+	metrics.Results
+}
+
+// Record appends a run with its configuration, environment, metrics and
+// statistics, and returns its ID
+func (s *Store) Record(command string, r metrics.Results) (int64, error) {
+	properties, err := json.Marshal(r.Properties)
+	if err != nil {
+		return 0, err
+	}
+	environment, err := json.Marshal(r.Environment)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO runs (recorded_at, command, name, db, version, vcs_revision, hostname, properties, environment)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		time.Now().UTC().Format(timeLayout), command, r.Name, r.DB,
+		r.Environment.Version, r.Environment.VCSRevision, r.Environment.Hostname,
+		string(properties), string(environment))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range r.Operations {
+		_, err := tx.Exec(`INSERT INTO operations (run_id, operation, total_time_ns, count, ops, avg_us, min_us, max_us, p50_us, p90_us, p95_us, p99_us, p999_us)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, m.Operation, m.TotalTime.Nanoseconds(), m.Count, m.OPS, m.Avg, m.Min, m.Max, m.P50, m.P90, m.P95, m.P99, m.P999)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, st := range r.Statistics {
+		for name, ci := range statisticIntervals(&st) {
+			_, err := tx.Exec(`INSERT INTO statistics (run_id, operation, statistic, lower, estimate, upper, std_error)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				id, st.Operation, name, ci.LowerBound, ci.Estimate, ci.UpperBound, ci.StdError)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+// statisticIntervals maps the statistic names stored in the database to the
+// intervals of s. Names match the csv output format.
+func statisticIntervals(s *metrics.OperationStatistics) map[string]*metrics.ConfidenceInterval {
+	return map[string]*metrics.ConfidenceInterval{
+		"throughput": &s.Throughput,
+		"r2":         &s.R2,
+		"mean_us":    &s.Mean,
+		"stddev_us":  &s.StdDev,
+		"median_us":  &s.Median,
+		"mad_us":     &s.MAD,
+	}
+}
+
+// Filter selects recorded runs. Zero fields match everything.
+type Filter struct {
+	DB         string
+	Command    string
+	Name       string
+	Revision   string            // prefix of the VCS revision
+	Since      time.Time         // recorded at or after
+	Properties map[string]string // exact property values
+	Limit      int               // most recent runs first; 0 for all
+}
+
+// Summary is a recorded run as listed, with its TOTAL row if it has one
+type Summary struct {
+	ID          int64
+	RecordedAt  time.Time
+	Command     string
+	Name        string
+	DB          string
+	Version     string
+	VCSRevision string
+	Total       *metrics.OperationMetrics
+}
+
+// List returns the runs matching f, most recent first
+func (s *Store) List(f Filter) ([]Summary, error) {
+	query := `SELECT r.id, r.recorded_at, r.command, r.name, r.db, r.version, r.vcs_revision,
+		o.count, o.ops, o.avg_us, o.p50_us, o.p99_us, o.p999_us
+		FROM runs r LEFT JOIN operations o ON o.run_id = r.id AND o.operation = 'TOTAL'`
+
+	var where []string
+	var args []any
+	if f.DB != "" {
+		where, args = append(where, "r.db = ?"), append(args, f.DB)
+	}
+	if f.Command != "" {
+		where, args = append(where, "r.command = ?"), append(args, f.Command)
+	}
+	if f.Name != "" {
+		where, args = append(where, "r.name = ?"), append(args, f.Name)
+	}
+	if f.Revision != "" {
+		where, args = append(where, "r.vcs_revision LIKE ? || '%'"), append(args, f.Revision)
+	}
+	if !f.Since.IsZero() {
+		where, args = append(where, "r.recorded_at >= ?"), append(args, f.Since.UTC().Format(timeLayout))
+	}
+	keys := make([]string, 0, len(f.Properties))
+	for k := range f.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		where = append(where, "json_extract(r.properties, ?) = ?")
+		args = append(args, `$."`+k+`"`, f.Properties[k])
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY r.id DESC"
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Summary
+	for rows.Next() {
+		var sm Summary
+		var recordedAt string
+		var count, avg, p50, p99, p999 sql.NullInt64
+		var ops sql.NullFloat64
+		if err := rows.Scan(&sm.ID, &recordedAt, &sm.Command, &sm.Name, &sm.DB, &sm.Version, &sm.VCSRevision,
+			&count, &ops, &avg, &p50, &p99, &p999); err != nil {
+			return nil, err
+		}
+		if sm.RecordedAt, err = time.Parse(timeLayout, recordedAt); err != nil {
+			return nil, err
+		}
+		if count.Valid {
+			sm.Total = &metrics.OperationMetrics{
+				Operation: "TOTAL",
+				Count:     count.Int64,
+				OPS:       ops.Float64,
+				Avg:       avg.Int64,
+				P50:       p50.Int64,
+				P99:       p99.Int64,
+				P999:      p999.Int64,
+			}
+		}
+		result = append(result, sm)
+	}
+	return result, rows.Err()
+}
+
+// Get returns the run with the given ID, including its metrics and statistics
+func (s *Store) Get(id int64) (*Run, error) {
+	run := &Run{ID: id}
+	var recordedAt, properties, environment string
+	err := s.db.QueryRow(`SELECT recorded_at, command, name, db, properties, environment FROM runs WHERE id = ?`, id).
+		Scan(&recordedAt, &run.Command, &run.Name, &run.DB, &properties, &environment)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no run with ID %d", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if run.RecordedAt, err = time.Parse(timeLayout, recordedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(properties), &run.Properties); err != nil {
+		return nil, fmt.Errorf("invalid properties: %w", err)
+	}
+	if err := json.Unmarshal([]byte(environment), &run.Environment); err != nil {
+		return nil, fmt.Errorf("invalid environment: %w", err)
+	}
+
+	// Keep the TOTAL row last, as CollectMetrics does
+	rows, err := s.db.Query(`SELECT operation, total_time_ns, count, ops, avg_us, min_us, max_us, p50_us, p90_us, p95_us, p99_us, p999_us
+		FROM operations WHERE run_id = ? ORDER BY operation = 'TOTAL', operation`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m metrics.OperationMetrics
+		var totalNs int64
+		if err := rows.Scan(&m.Operation, &totalNs, &m.Count, &m.OPS, &m.Avg, &m.Min, &m.Max,
+			&m.P50, &m.P90, &m.P95, &m.P99, &m.P999); err != nil {
+			return nil, err
+		}
+		m.TotalTime = time.Duration(totalNs)
+		run.Operations = append(run.Operations, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statRows, err := s.db.Query(`SELECT operation, statistic, lower, estimate, upper, std_error
+		FROM statistics WHERE run_id = ? ORDER BY operation`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer statRows.Close()
+	for statRows.Next() {
+		var operation, name string
+		var ci metrics.ConfidenceInterval
+		if err := statRows.Scan(&operation, &name, &ci.LowerBound, &ci.Estimate, &ci.UpperBound, &ci.StdError); err != nil {
+			return nil, err
+		}
+		n := len(run.Statistics)
+		if n == 0 || run.Statistics[n-1].Operation != operation {
+			run.Statistics = append(run.Statistics, metrics.OperationStatistics{Operation: operation})
+			n++
+		}
+		if target, ok := statisticIntervals(&run.Statistics[n-1])[name]; ok {
+			*target = ci
+		}
+	}
+	return run, statRows.Err()
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// AccountUpdates is the outcome of committing account updates, a fixed
+// number of accounts per commit, each getting its nonce incremented and its
+// balance adjusted
+type AccountUpdates struct {
+	DB            string  `json:"db"`
+	Accounts      int64   `json:"accounts"` // the updated accounts are drawn from
+	PerCommit     int     `json:"accounts_per_commit"`
+	Commits       int64   `json:"commits"`
+	WarmUpCommits int64   `json:"warmup_commits"` // committed first and left out of every figure
+	Updates       int64   `json:"updates"`
+	Elapsed       float64 `json:"elapsed_sec"` // wall time, drawing the accounts included
+	CommitTime    float64 `json:"commit_sec"`  // time spent in commits
+
+	// Throughputs over the commit time
+	CommitsPerSec float64 `json:"commits_per_sec"`
+	UpdatesPerSec float64 `json:"updates_per_sec"`
+
+	// Commit latency distribution, in microseconds
+	Mean float64 `json:"commit_mean_us"`
+	P50  float64 `json:"commit_p50_us"`
+	P90  float64 `json:"commit_p90_us"`
+	P99  float64 `json:"commit_p99_us"`
+	P999 float64 `json:"commit_p999_us"`
+	Max  float64 `json:"commit_max_us"`
+}
+
+// NewAccountUpdates summarizes the measured commits of perCommit accounts
+// each, drawn from accounts, against db in elapsed after warmUpCommits others
+func NewAccountUpdates(db string, accounts int64, perCommit int, warmUpCommits int64, latencies []time.Duration, elapsed time.Duration) *AccountUpdates {
+	r := &AccountUpdates{
+		DB:            db,
+		Accounts:      accounts,
+		PerCommit:     perCommit,
+		Commits:       int64(len(latencies)),
+		WarmUpCommits: warmUpCommits,
+		Updates:       int64(len(latencies)) * int64(perCommit),
+		Elapsed:       elapsed.Seconds(),
+	}
+	if len(latencies) == 0 {
+		return r
+	}
+
+	latency := hdrhistogram.New(1, int64(time.Hour), 3)
+	var commit time.Duration
+	for _, l := range latencies {
+		commit += l
+		latency.RecordValue(max(int64(l), 1))
+	}
+	r.CommitTime = commit.Seconds()
+	if r.CommitTime > 0 {
+		r.CommitsPerSec = float64(r.Commits) / r.CommitTime
+		r.UpdatesPerSec = float64(r.Updates) / r.CommitTime
+	}
+	us := func(ns int64) float64 { return float64(ns) / 1000 }
+	r.Mean = latency.Mean() / 1000
+	r.P50 = us(latency.ValueAtPercentile(50))
+	r.P90 = us(latency.ValueAtPercentile(90))
+	r.P99 = us(latency.ValueAtPercentile(99))
+	r.P999 = us(latency.ValueAtPercentile(99.9))
+	r.Max = us(latency.Max())
+	return r
+}
+
+// PrintAccountUpdates prints the throughput and commit latencies of account
+// updates
+func PrintAccountUpdates(r *AccountUpdates) {
+	if r == nil {
+		return
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("Account Updates (%s):\n", r.DB)
+	fmt.Println(strings.Repeat("=", 80))
+	if r.Commits == 0 {
+		fmt.Println("No commits measured")
+		return
+	}
+	rows := [][2]string{
+		{"Commits", fmt.Sprintf("%d of %d accounts each, %d warm-up commits before", r.Commits, r.PerCommit, r.WarmUpCommits)},
+		{"Account updates", fmt.Sprintf("%d, drawn from %d accounts", r.Updates, r.Accounts)},
+		{"Time", fmt.Sprintf("%.2f s committing, %.2f s elapsed", r.CommitTime, r.Elapsed)},
+		{"Throughput", fmt.Sprintf("%.1f commits/s, %.0f updates/s", r.CommitsPerSec, r.UpdatesPerSec)},
+		{"Commit latency (mean)", formatDuration(r.Mean)},
+		{"Commit latency (p50/p90)", formatDuration(r.P50) + " / " + formatDuration(r.P90)},
+		{"Commit latency (p99/p99.9)", formatDuration(r.P99) + " / " + formatDuration(r.P999)},
+		{"Commit latency (max)", formatDuration(r.Max)},
+	}
+	for _, row := range rows {
+		fmt.Printf("%-30s %s\n", row[0], row[1])
+	}
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MetricSummary summarizes one metric across repeated runs
+type MetricSummary struct {
+	Mean   float64   `json:"mean"`
+	StdDev float64   `json:"stddev"` // Sample standard deviation (n-1), 0 for a single run
+	Min    float64   `json:"min"`
+	Max    float64   `json:"max"`
+	Values []float64 `json:"values,omitempty"` // every run's value, for significance tests
+}
+
+// RunAggregate holds cross-run summaries for one operation
+type RunAggregate struct {
+	Operation string        `json:"operation"`
+	Runs      int           `json:"runs"` // Number of runs that reported this operation
+	OPS       MetricSummary `json:"ops"`
+	Avg       MetricSummary `json:"avg_us"`
+	P50       MetricSummary `json:"p50_us"`
+	P99       MetricSummary `json:"p99_us"`
+	P999      MetricSummary `json:"p999_us"`
+}
+
+// summarize computes mean, sample standard deviation, min and max
+func summarize(values []float64) MetricSummary {
+	if len(values) == 0 {
+		return MetricSummary{}
+	}
+
+	s := MetricSummary{Min: math.MaxFloat64, Max: -math.MaxFloat64, Values: append([]float64(nil), values...)}
+	var sum float64
+	for _, v := range values {
+		sum += v
+		s.Min = math.Min(s.Min, v)
+		s.Max = math.Max(s.Max, v)
+	}
+	s.Mean = sum / float64(len(values))
+
+	if len(values) > 1 {
+		var varianceSum float64
+		for _, v := range values {
+			diff := v - s.Mean
+			varianceSum += diff * diff
+		}
+		s.StdDev = math.Sqrt(varianceSum / float64(len(values)-1))
+	}
+
+	return s
+}
+
+// AggregateRuns summarizes each operation's metrics across repeated runs.
+// Operations are returned in first-seen order with TOTAL last.
+func AggregateRuns(runs [][]OperationMetrics) []RunAggregate {
+	backends := make([]BackendResults, len(runs))
+	for i, r := range runs {
+		backends[i] = BackendResults{Results: r}
+	}
+
+	var aggregates []RunAggregate
+	for _, op := range comparisonOperations(backends) {
+		var ops, avg, p50, p99, p999 []float64
+		for _, r := range runs {
+			row, ok := FindMetrics(r, op)
+			if !ok {
+				continue
+			}
+			ops = append(ops, row.OPS)
+			avg = append(avg, float64(row.Avg))
+			p50 = append(p50, float64(row.P50))
+			p99 = append(p99, float64(row.P99))
+			p999 = append(p999, float64(row.P999))
+		}
+
+		aggregates = append(aggregates, RunAggregate{
+			Operation: op,
+			Runs:      len(ops),
+			OPS:       summarize(ops),
+			Avg:       summarize(avg),
+			P50:       summarize(p50),
+			P99:       summarize(p99),
+			P999:      summarize(p999),
+		})
+	}
+
+	return aggregates
+}
+
+// PrintRunsReport prints a compact per-run table followed by the cross-run
+// mean, standard deviation, min and max of every operation's key metrics
+func PrintRunsReport(runs [][]OperationMetrics) {
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := fmt.Sprintf("PER-RUN RESULTS (%d runs)", len(runs))
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	fmt.Printf("│ %-5s │ %-12s │ %10s │ %10s │ %9s │ %9s │ %9s │ %9s │\n",
+		"Run", "Operation", "Count", "OPS", "Avg(µs)", "p50(µs)", "p99(µs)", "p99.9(µs)")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for i, run := range runs {
+		label := fmt.Sprintf("%d", i+1)
+		for _, r := range run {
+			fmt.Printf("│ %-5s │ %-12s │ %10d │ %10.1f │ %9d │ %9d │ %9d │ %9d │\n",
+				label, r.Operation, r.Count, r.OPS, r.Avg, r.P50, r.P99, r.P999)
+			label = ""
+		}
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title = "CROSS-RUN AGGREGATE"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	fmt.Printf("│ %-12s │ %-10s │ %12s │ %12s │ %12s │ %12s │ %8s │\n",
+		"Operation", "Metric", "Mean", "Std. Dev.", "Min", "Max", "CV")
+	for _, agg := range AggregateRuns(runs) {
+		fmt.Println(strings.Repeat("─", tableWidth))
+		rows := []struct {
+			name string
+			s    MetricSummary
+		}{
+			{"OPS", agg.OPS},
+			{"Avg(µs)", agg.Avg},
+			{"p50(µs)", agg.P50},
+			{"p99(µs)", agg.P99},
+			{"p99.9(µs)", agg.P999},
+		}
+		op := agg.Operation
+		for _, row := range rows {
+			cv := "-"
+			if row.s.Mean != 0 {
+				cv = fmt.Sprintf("%.2f%%", 100*row.s.StdDev/row.s.Mean)
+			}
+			fmt.Printf("│ %-12s │ %-10s │ %12.1f │ %12.1f │ %12.1f │ %12.1f │ %8s │\n",
+				op, row.name, row.s.Mean, row.s.StdDev, row.s.Min, row.s.Max, cv)
+			op = ""
+		}
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import "fmt"
+
+// WriteAmplification is what a backend wrote to storage per byte the
+// application wrote during the measured window, from the engine's own
+// counters before and after it
+type WriteAmplification struct {
+	UserBytes      uint64  `json:"user_bytes"`
+	WALBytes       uint64  `json:"wal_bytes"`
+	FlushedBytes   uint64  `json:"flushed_bytes"`
+	CompactedBytes uint64  `json:"compacted_bytes"`
+	Factor         float64 `json:"write_amp"` // 0 when the application wrote nothing
+}
+
+// NewWriteAmplification computes the write amplification of the given byte
+// counts: everything written to the WAL, by flushes and by compactions over
+// the user bytes
+func NewWriteAmplification(user, wal, flushed, compacted uint64) *WriteAmplification {
+	w := &WriteAmplification{UserBytes: user, WALBytes: wal, FlushedBytes: flushed, CompactedBytes: compacted}
+	if user > 0 {
+		w.Factor = float64(w.StorageBytes()) / float64(user)
+	}
+	return w
+}
+
+// StorageBytes returns the bytes the engine wrote to storage
+func (w *WriteAmplification) StorageBytes() uint64 {
+	return w.WALBytes + w.FlushedBytes + w.CompactedBytes
+}
+
+// String summarizes the write amplification, e.g. "3.42x (12.0 MiB written
+// for 3.5 MiB of user data: ...)"
+func (w *WriteAmplification) String() string {
+	return fmt.Sprintf("%.2fx (%s written for %s of user data: %s WAL, %s flushed, %s compacted)",
+		w.Factor, formatBytes(int64(w.StorageBytes())), formatBytes(int64(w.UserBytes)),
+		formatBytes(int64(w.WALBytes)), formatBytes(int64(w.FlushedBytes)), formatBytes(int64(w.CompactedBytes)))
+}
+
+// PrintWriteAmplification prints the write amplification of a run, if the
+// backend reports it
+func PrintWriteAmplification(w *WriteAmplification) {
+	if w == nil {
+		return
+	}
+	fmt.Printf("\nWrite amplification: %s\n", w)
+}
@@ -0,0 +1,241 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Assertion is a bound on one metric of one operation that a run must meet,
+// written as metric.OPERATION<bound, e.g. "p99.READ<2ms" or
+// "throughput.TOTAL>50000"
+type Assertion struct {
+	Expr       string  `json:"expr"`
+	Metric     string  `json:"metric"`
+	Operation  string  `json:"operation"`
+	Comparator string  `json:"comparator"`
+	Bound      float64 `json:"bound"` // µs for latencies, ops/sec for throughput, a fraction for error_rate
+}
+
+// assertionComparators are the comparators an assertion may use, longest first
+// so "<=" is not read as "<"
+var assertionComparators = []string{"<=", ">=", "<", ">"}
+
+// AssertionMetrics are the metrics an assertion may bound besides percentiles
+// such as p99 or p99.9
+var AssertionMetrics = []string{"avg", "min", "max", "throughput", "count", "errors", "error_rate"}
+
+// ParseAssertion parses an assertion such as "p99.READ<2ms". Latency bounds
+// take a Go duration or a number of microseconds, error_rate a fraction or a
+// percentage.
+func ParseAssertion(expr string) (Assertion, error) {
+	a := Assertion{Expr: strings.TrimSpace(expr)}
+
+	var lhs, rhs string
+	for _, c := range assertionComparators {
+		if i := strings.Index(a.Expr, c); i >= 0 {
+			a.Comparator = c
+			lhs, rhs = strings.TrimSpace(a.Expr[:i]), strings.TrimSpace(a.Expr[i+len(c):])
+			break
+		}
+	}
+	if a.Comparator == "" {
+		return a, fmt.Errorf("invalid assertion %q: expected metric.OPERATION<bound, e.g. p99.READ<2ms", expr)
+	}
+
+	dot := strings.LastIndexByte(lhs, '.')
+	if dot <= 0 || dot == len(lhs)-1 {
+		return a, fmt.Errorf("invalid assertion %q: expected metric.OPERATION on the left, e.g. p99.READ", expr)
+	}
+	a.Metric, a.Operation = strings.ToLower(lhs[:dot]), strings.ToUpper(lhs[dot+1:])
+	if a.Metric == "ops" {
+		a.Metric = "throughput"
+	}
+
+	var err error
+	switch {
+	case a.latency():
+		if strings.HasPrefix(a.Metric, "p") {
+			p, perr := strconv.ParseFloat(a.Metric[1:], 64)
+			if perr != nil || p <= 0 || p >= 100 {
+				return a, fmt.Errorf("invalid assertion %q: unknown percentile %q", expr, a.Metric)
+			}
+		}
+		a.Bound, err = parseLatencyBound(rhs)
+	case a.Metric == "error_rate":
+		a.Bound, err = parseRate(rhs)
+	case a.Metric == "throughput" || a.Metric == "count" || a.Metric == "errors":
+		a.Bound, err = strconv.ParseFloat(rhs, 64)
+	default:
+		return a, fmt.Errorf("invalid assertion %q: unknown metric %q (expected a percentile such as p99 or one of %s)",
+			expr, a.Metric, strings.Join(AssertionMetrics, ", "))
+	}
+	if err != nil {
+		return a, fmt.Errorf("invalid assertion %q: invalid bound %q", expr, rhs)
+	}
+	return a, nil
+}
+
+// latency reports whether the assertion bounds a latency
+func (a Assertion) latency() bool {
+	switch a.Metric {
+	case "avg", "min", "max":
+		return true
+	}
+	return strings.HasPrefix(a.Metric, "p")
+}
+
+// parseLatencyBound parses a duration such as "2ms" or a number of
+// microseconds into microseconds
+func parseLatencyBound(s string) (float64, error) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return float64(d.Nanoseconds()) / 1000, nil
+}
+
+// parseRate parses "0.01" or "1%" as 0.01
+func parseRate(s string) (float64, error) {
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		v, err := strconv.ParseFloat(p, 64)
+		return v / 100, err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// value returns the asserted metric of m, if m has it
+func (a Assertion) value(m OperationMetrics) (float64, bool) {
+	switch a.Metric {
+	case "avg":
+		return float64(m.Avg), true
+	case "min":
+		return float64(m.Min), true
+	case "max":
+		return float64(m.Max), true
+	case "throughput":
+		return m.OPS, true
+	case "count":
+		return float64(m.Count), true
+	case "errors":
+		return float64(m.Errors), true
+	case "error_rate":
+		return m.ErrorRate, true
+	}
+	p, _ := strconv.ParseFloat(a.Metric[1:], 64)
+	v, ok := m.Percentile(p)
+	return float64(v), ok
+}
+
+// holds reports whether v meets the bound
+func (a Assertion) holds(v float64) bool {
+	switch a.Comparator {
+	case "<":
+		return v < a.Bound
+	case "<=":
+		return v <= a.Bound
+	case ">":
+		return v > a.Bound
+	default:
+		return v >= a.Bound
+	}
+}
+
+// format formats a value of the asserted metric
+func (a Assertion) format(v float64) string {
+	switch {
+	case a.latency():
+		return formatDuration(v)
+	case a.Metric == "throughput":
+		return fmt.Sprintf("%.1f ops/s", v)
+	case a.Metric == "error_rate":
+		return fmt.Sprintf("%.4f%%", 100*v)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Assertion statuses. An assertion on an operation or percentile the run did
+// not record is missing, which fails like a violation.
+const (
+	AssertionPass     = "pass"
+	AssertionViolated = "violated"
+	AssertionMissing  = "missing"
+)
+
+// AssertionResult is the outcome of one assertion against one run
+type AssertionResult struct {
+	Run string `json:"run"`
+	Assertion
+	Actual float64 `json:"actual"`
+	Status string  `json:"status"`
+}
+
+// CheckAssertions evaluates every assertion against the metrics of the named
+// run
+func CheckAssertions(run string, rows []OperationMetrics, assertions []Assertion) []AssertionResult {
+	results := make([]AssertionResult, len(assertions))
+	for i, a := range assertions {
+		r := AssertionResult{Run: run, Assertion: a, Status: AssertionMissing}
+		if m, ok := FindMetrics(rows, a.Operation); ok {
+			if v, ok := a.value(m); ok {
+				r.Actual, r.Status = v, AssertionPass
+				if !a.holds(v) {
+					r.Status = AssertionViolated
+				}
+			}
+		}
+		results[i] = r
+	}
+	return results
+}
+
+// PrintAssertionResults prints every assertion, violations first, and
+// reports whether all of them passed
+func PrintAssertionResults(results []AssertionResult) bool {
+	if len(results) == 0 {
+		return true
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "ASSERTIONS"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-12s │ %-63s │ %24s │ %-14s │\n", "Run", "Assertion", "Actual", "Status")
+	fmt.Println(strings.Repeat("─", tableWidth))
+
+	failed := 0
+	for _, pass := range []bool{false, true} {
+		for _, r := range results {
+			if (r.Status == AssertionPass) != pass {
+				continue
+			}
+			actual := "-"
+			if r.Status != AssertionMissing {
+				actual = r.format(r.Actual)
+			}
+			if !pass {
+				failed++
+			}
+			fmt.Printf("│ %-12s │ %-63s │ %24s │ %-14s │\n", r.Run, r.Expr, actual, strings.ToUpper(r.Status))
+		}
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	if failed > 0 {
+		fmt.Printf("FAILED: %d of %d assertions violated or missing\n", failed, len(results))
+		for _, r := range results {
+			if r.Status == AssertionMissing && strings.HasPrefix(r.Metric, "p") {
+				fmt.Println("Percentiles outside p50/p90/p95/p99/p99.9 must also be given to --percentiles")
+				break
+			}
+		}
+		return false
+	}
+	fmt.Printf("PASSED: %d assertions hold\n", len(results))
+	return true
+}
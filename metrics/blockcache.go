@@ -0,0 +1,37 @@
+package metrics
+
+import "fmt"
+
+// BlockCache is how a backend's block cache served the reads of a run: the
+// lookups over the measured window and the bytes held at the end of it
+type BlockCache struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // hits per lookup, 0 without lookups
+	Bytes   int64   `json:"bytes"`
+}
+
+// NewBlockCache summarizes hits and misses of a block cache holding bytes
+func NewBlockCache(hits, misses, bytes int64) *BlockCache {
+	c := &BlockCache{Hits: hits, Misses: misses, Bytes: bytes}
+	if lookups := hits + misses; lookups > 0 {
+		c.HitRate = float64(hits) / float64(lookups)
+	}
+	return c
+}
+
+// String summarizes the block cache, e.g. "93.12% hit rate (1204511 hits,
+// 88950 misses), 63.9 MiB held"
+func (c *BlockCache) String() string {
+	return fmt.Sprintf("%.2f%% hit rate (%d hits, %d misses), %s held",
+		100*c.HitRate, c.Hits, c.Misses, formatBytes(c.Bytes))
+}
+
+// PrintBlockCache prints the block cache hit rate of a run, if the backend
+// reports it
+func PrintBlockCache(c *BlockCache) {
+	if c == nil {
+		return
+	}
+	fmt.Printf("Block cache: %s\n", c)
+}
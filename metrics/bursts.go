@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+// Phases of BurstStatistics
+const (
+	BurstPostIdle = "post-idle"
+	BurstSteady   = "steady"
+)
+
+// BurstStatistics summarizes the successful operations of one kind meant to
+// start right after an idle period or later in their burst
+type BurstStatistics struct {
+	Operation string  `json:"operation"`
+	Phase     string  `json:"phase"` // BurstPostIdle or BurstSteady
+	Count     int64   `json:"count"`
+	Share     float64 `json:"share"` // fraction of the operation's count
+	Mean      float64 `json:"mean_us"`
+	P50       float64 `json:"p50_us"`
+	P99       float64 `json:"p99_us"`
+	Max       float64 `json:"max_us"`
+}
+
+// bursts splits the latencies of operations by whether they were meant to
+// start within postIdle of the start of their burst, after the warm-up
+type bursts struct {
+	offset   func(ctx context.Context) (time.Duration, bool)
+	postIdle time.Duration
+
+	mu         sync.Mutex
+	histograms map[string]*[2]*hdrhistogram.Histogram // operation -> post-idle, steady; microseconds
+}
+
+// record adds one successful operation running with ctx
+func (b *bursts) record(ctx context.Context, op string, latency time.Duration) {
+	if !measurement.IsWarmUpFinished() {
+		return
+	}
+	offset, ok := b.offset(ctx)
+	if !ok {
+		return
+	}
+	phase := 0
+	if offset >= b.postIdle {
+		phase = 1
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hists, ok := b.histograms[op]
+	if !ok {
+		hists = new([2]*hdrhistogram.Histogram)
+		b.histograms[op] = hists
+	}
+	if hists[phase] == nil {
+		hists[phase] = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	}
+	hists[phase].RecordValue(max(latency.Microseconds(), 1))
+}
+
+// statistics returns the post-idle and steady rows of every operation,
+// ordered by operation
+func (b *bursts) statistics() []BurstStatistics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	operations := make([]string, 0, len(b.histograms))
+	for op := range b.histograms {
+		operations = append(operations, op)
+	}
+	sort.Strings(operations)
+
+	var result []BurstStatistics
+	for _, op := range operations {
+		hists := b.histograms[op]
+		var total int64
+		for _, hist := range hists {
+			if hist != nil {
+				total += hist.TotalCount()
+			}
+		}
+		for phase, name := range []string{BurstPostIdle, BurstSteady} {
+			hist := hists[phase]
+			if hist == nil {
+				continue
+			}
+			result = append(result, BurstStatistics{
+				Operation: op,
+				Phase:     name,
+				Count:     hist.TotalCount(),
+				Share:     float64(hist.TotalCount()) / float64(total),
+				Mean:      hist.Mean(),
+				P50:       float64(hist.ValueAtPercentile(50)),
+				P99:       float64(hist.ValueAtPercentile(99)),
+				Max:       float64(hist.Max()),
+			})
+		}
+	}
+	return result
+}
+
+// SetBursts splits the latencies of operations meant to start within
+// postIdle of the start of their burst from the rest, see BurstStatistics.
+// offset returns how far into its burst an operation was meant to start,
+// given its context. It must be called before the run starts.
+func (ot *OperationTracker) SetBursts(offset func(ctx context.Context) (time.Duration, bool), postIdle time.Duration) {
+	ot.bursts = &bursts{offset: offset, postIdle: postIdle, histograms: make(map[string]*[2]*hdrhistogram.Histogram)}
+}
+
+// BurstStatistics returns the latencies of operations right after an idle
+// period and later in their burst, or nil without bursts
+func (ot *OperationTracker) BurstStatistics() []BurstStatistics {
+	if ot.bursts == nil {
+		return nil
+	}
+	return ot.bursts.statistics()
+}
+
+// PrintBurstStatistics prints the post-idle and steady rows of every
+// operation
+func PrintBurstStatistics(all []BurstStatistics) {
+	if len(all) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "POST-IDLE VS STEADY BURST"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-16s │ %9s │ %11s │ %8s │ %14s │ %14s │ %14s │ %14s │\n",
+		"Operation", "Phase", "Count", "Share", "Mean", "p50", "p99", "Max")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for i, s := range all {
+		if i > 0 && all[i-1].Operation != s.Operation {
+			fmt.Println(strings.Repeat("─", tableWidth))
+		}
+		fmt.Printf("│ %-16s │ %9s │ %11d │ %7.2f%% │ %14s │ %14s │ %14s │ %14s │\n",
+			s.Operation, s.Phase, s.Count, 100*s.Share,
+			formatDuration(s.Mean), formatDuration(s.P50), formatDuration(s.P99), formatDuration(s.Max))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+// CacheSweepPoint is one run of a block cache sweep: the hit rate of the
+// cache at one size and the latency of the reads it served
+type CacheSweepPoint struct {
+	CacheSize  int64   `json:"cache_size_bytes"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	HitRate    float64 `json:"hit_rate"`
+	CacheBytes int64   `json:"cache_bytes"` // held at the end of the run
+	OPS        float64 `json:"ops"`         // of all operations
+
+	// Latency of the reads, in microseconds; zero without reads
+	Reads   int64 `json:"reads"`
+	ReadP50 int64 `json:"read_p50_us"`
+	ReadP99 int64 `json:"read_p99_us"`
+	ReadMax int64 `json:"read_max_us"`
+}
+
+// CacheSweep is how the block cache hit rate and read latency change with
+// the size of the cache, over the same loaded dataset
+type CacheSweep struct {
+	DB       string            `json:"db"`
+	Records  int64             `json:"records"`
+	DiskSize int64             `json:"disk_bytes"` // of the dataset when the sweep started
+	Points   []CacheSweepPoint `json:"points"`
+}
+
+// NewCacheSweepPoint summarizes a run with a block cache of cacheSize bytes
+// from its operations and how its cache served them
+func NewCacheSweepPoint(cacheSize int64, operations []OperationMetrics, cache *BlockCache) CacheSweepPoint {
+	p := CacheSweepPoint{CacheSize: cacheSize}
+	if cache != nil {
+		p.Hits, p.Misses, p.HitRate, p.CacheBytes = cache.Hits, cache.Misses, cache.HitRate, cache.Bytes
+	}
+	if total, ok := FindMetrics(operations, "TOTAL"); ok {
+		p.OPS = total.OPS
+	}
+	if read, ok := FindMetrics(operations, "READ"); ok {
+		p.Reads, p.ReadP50, p.ReadP99, p.ReadMax = read.Count, read.P50, read.P99, read.Max
+	}
+	return p
+}
+
+// PrintCacheSweep prints the hit rate and read latency at every cache size
+func PrintCacheSweep(s *CacheSweep) {
+	if s == nil {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := fmt.Sprintf("BLOCK CACHE SWEEP (%s)", s.DB)
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	if len(s.Points) == 0 {
+		fmt.Println("No cache sizes completed")
+		return
+	}
+	fmt.Printf("│ %12s │ %9s │ %13s │ %13s │ %12s │ %12s │ %11s │ %11s │ %11s │\n",
+		"Cache size", "Hit rate", "Hits", "Misses", "Cache held", "OPS", "Read p50", "Read p99", "Read max")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, p := range s.Points {
+		p50, p99, readMax := "-", "-", "-"
+		if p.Reads > 0 {
+			p50, p99, readMax = formatDuration(float64(p.ReadP50)), formatDuration(float64(p.ReadP99)), formatDuration(float64(p.ReadMax))
+		}
+		fmt.Printf("│ %12s │ %8.2f%% │ %13d │ %13d │ %12s │ %12.1f │ %11s │ %11s │ %11s │\n",
+			formatBytes(p.CacheSize), 100*p.HitRate, p.Hits, p.Misses, formatBytes(p.CacheBytes), p.OPS, p50, p99, readMax)
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("Over %d records, %s on disk\n", s.Records, formatBytes(s.DiskSize))
+}
+
+// GenerateCacheSweepPlots draws the hit rate and the read latency against
+// the cache size, as cache_hit_rate and cache_read_latency.<format> in
+// outputDir, and returns the files
+func GenerateCacheSweepPlots(s *CacheSweep, outputDir string, formats []string, style PlotStyle) ([]string, error) {
+	if s == nil || len(s.Points) == 0 {
+		return nil, nil
+	}
+	hitRate := make(plotter.XYs, len(s.Points))
+	var p50, p99 plotter.XYs
+	for i, p := range s.Points {
+		x := float64(p.CacheSize) / (1 << 20)
+		hitRate[i] = plotter.XY{X: x, Y: 100 * p.HitRate}
+		if p.Reads > 0 {
+			p50 = append(p50, plotter.XY{X: x, Y: float64(p.ReadP50)})
+			p99 = append(p99, plotter.XY{X: x, Y: float64(p.ReadP99)})
+		}
+	}
+
+	type curve struct {
+		name, chart, yLabel string
+		series              []CurveSeries
+	}
+	plots := []curve{{"cache_hit_rate", "Hit Rate", "Hit rate (%)", []CurveSeries{{Name: s.DB, Points: hitRate}}}}
+	if len(p99) > 0 {
+		plots = append(plots, curve{"cache_read_latency", "Read Latency", "Read latency (µs)",
+			[]CurveSeries{{Name: "p50", Points: p50}, {Name: "p99", Points: p99}}})
+	}
+	var files []string
+	for _, p := range plots {
+		base := filepath.Join(outputDir, p.name)
+		if err := GenerateCurvePlot("Block cache sweep", p.chart, "Cache size (MiB)", p.yLabel, p.series, base, formats, style); err != nil {
+			return nil, err
+		}
+		for _, format := range formats {
+			files = append(files, base+"."+format)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,364 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+)
+
+// Collector keeps a latency histogram per operation, recorded the way
+// go-ycsb's measurement package records them: nothing during warm-up, failed
+// operations as <OP>_ERROR, and every successful operation also as TOTAL.
+// Results are read from the histograms directly instead of from go-ycsb's
+// printed summary. Failures are also counted per operation and error class.
+// Each thread can record into a shard of its own, folded into the collector
+// when results are read, so threads do not share histograms.
+type Collector struct {
+	mu sync.Mutex
+	opCounts
+	shards   []*collectorShard
+	classify func(error) string
+
+	// Time series of every operation, in intervals from origin, set once by
+	// the first operation measured; seriesEnd is the latest completion
+	interval  time.Duration
+	origin    atomic.Pointer[time.Time]
+	seriesEnd time.Time
+	series    map[string]*opSeries
+}
+
+// opCounts are the histograms and failure counts of operations
+type opCounts struct {
+	histograms map[string]*opHistogram
+	corrected  map[string]*opHistogram     // latencies from the intended start, in paced runs
+	errors     map[string]map[string]int64 // operation -> error class -> count
+}
+
+func newOpCounts() opCounts {
+	return opCounts{
+		histograms: make(map[string]*opHistogram),
+		corrected:  make(map[string]*opHistogram),
+		errors:     make(map[string]map[string]int64),
+	}
+}
+
+// collectorShard holds the histograms and failure counts of one thread's
+// operations since they were last folded into the collector, and those not
+// yet handed over to the time series. Only its thread records into it, so
+// its lock is uncontended but while results are read.
+type collectorShard struct {
+	mu sync.Mutex
+	opCounts
+	classify func(error) string
+	interval time.Duration // of the time series, copied from the collector
+	series   []seriesEntry // not added to the time series yet
+	spare    []seriesEntry // emptied by the last hand-over, for the next
+}
+
+// opHistogram is the histogram of one operation. Throughput is measured from
+// the first sample, as go-ycsb does.
+type opHistogram struct {
+	hist  *hdrhistogram.Histogram // microseconds
+	start time.Time
+	end   time.Time     // set on histograms merged from snapshots, which no longer grow
+	total time.Duration // sum of the latencies at full precision
+}
+
+// NewCollector creates an empty Collector
+func NewCollector() *Collector {
+	return &Collector{
+		opCounts: newOpCounts(),
+		classify: defaultErrorClass,
+		interval: DefaultSeriesInterval,
+		series:   make(map[string]*opSeries),
+	}
+}
+
+// defaultErrorClass tells cancellation at the end of a run apart from other
+// failures
+func defaultErrorClass(err error) string {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return "canceled"
+	}
+	return "error"
+}
+
+// SetErrorClassifier sets the function that names the class of each failure,
+// e.g. "not_found"
+func (c *Collector) SetErrorClassifier(classify func(error) string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.classify = classify
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.classify = classify
+		s.mu.Unlock()
+	}
+}
+
+// Measure records one operation that started at start, took latency and
+// returned err
+func (c *Collector) Measure(op string, start time.Time, latency time.Duration, err error) {
+	c.measure(op, start, latency, err, nil)
+}
+
+// MeasureIntended records one operation like Measure, and also its latency
+// from intended, the time a rate-limited run meant to start it. Latencies
+// from the intended start include the time an operation was queued behind a
+// stall, which the ones from the actual start omit.
+func (c *Collector) MeasureIntended(op string, intended, start time.Time, latency time.Duration, err error) {
+	c.measure(op, start, latency, err, &intended)
+}
+
+// measure records one operation, and its latency from intended if known
+func (c *Collector) measure(op string, start time.Time, latency time.Duration, err error, intended *time.Time) {
+	c.measureOn(nil, op, start, latency, err, intended)
+}
+
+// newShard returns a shard for one thread to measure its operations into
+func (c *Collector) newShard() *collectorShard {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &collectorShard{opCounts: newOpCounts(), classify: c.classify, interval: c.interval}
+	c.shards = append(c.shards, s)
+	return s
+}
+
+// measureOn records one operation like measure, in shard s, or in the
+// collector itself when s is nil. A shard hands its operations over to the
+// time series once per interval or seriesBatch operations, so threads only
+// wait on the collector's lock that often.
+func (c *Collector) measureOn(s *collectorShard, op string, start time.Time, latency time.Duration, err error, intended *time.Time) {
+	if !measurement.IsWarmUpFinished() {
+		return
+	}
+
+	if s == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.interval > 0 {
+			c.addSeries([]seriesEntry{c.seriesEntry(op, start, latency, err != nil, c.interval)})
+		}
+		c.add(op, start, latency, err, intended, c.classify)
+		return
+	}
+
+	s.mu.Lock()
+	s.add(op, start, latency, err, intended, s.classify)
+	var handOver []seriesEntry
+	if s.interval > 0 {
+		e := c.seriesEntry(op, start, latency, err != nil, s.interval)
+		if n := len(s.series); n >= seriesBatch || (n > 0 && s.series[0].index != e.index) {
+			handOver, s.series, s.spare = s.series, s.spare, nil
+		}
+		s.series = append(s.series, e)
+	}
+	s.mu.Unlock()
+	if handOver == nil {
+		return
+	}
+
+	// Not under the shard's lock: results are read holding the collector's
+	// lock, then the shards'
+	c.mu.Lock()
+	c.addSeries(handOver)
+	c.mu.Unlock()
+	s.mu.Lock()
+	s.spare = handOver[:0]
+	s.mu.Unlock()
+}
+
+// add records one operation, naming the class of its failure with classify
+func (oc *opCounts) add(op string, start time.Time, latency time.Duration, err error, intended *time.Time, classify func(error) string) {
+	if err != nil {
+		recordHistogram(oc.histograms, op+"_ERROR", latency)
+		classes, ok := oc.errors[op]
+		if !ok {
+			classes = make(map[string]int64)
+			oc.errors[op] = classes
+		}
+		classes[classify(err)]++
+		return
+	}
+	recordHistogram(oc.histograms, op, latency)
+	recordHistogram(oc.histograms, "TOTAL", latency)
+
+	if intended != nil {
+		// An operation started early by a burst is not credited the difference
+		corrected := latency + max(0, start.Sub(*intended))
+		recordHistogram(oc.corrected, op, corrected)
+		recordHistogram(oc.corrected, "TOTAL", corrected)
+	}
+}
+
+// fold adds the operations of every shard to the collector's own histograms
+// and failure counts and empties the shards, so results read next cover
+// every operation measured so far. Callers hold the lock.
+func (c *Collector) fold() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		foldHistograms(c.histograms, s.histograms)
+		foldHistograms(c.corrected, s.corrected)
+		for op, classes := range s.errors {
+			merged, ok := c.errors[op]
+			if !ok {
+				merged = make(map[string]int64)
+				c.errors[op] = merged
+			}
+			for class, n := range classes {
+				merged[class] += n
+			}
+			delete(s.errors, op)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// foldHistograms adds the histograms of from to those of into and resets
+// them, keeping them for the operations to come
+func foldHistograms(into, from map[string]*opHistogram) {
+	for op, h := range from {
+		if h.hist.TotalCount() == 0 {
+			continue
+		}
+		merged, ok := into[op]
+		if !ok {
+			merged = &opHistogram{hist: hdrhistogram.New(1, 24*60*60*1000*1000, 3), start: h.start}
+			into[op] = merged
+		}
+		merged.hist.Merge(h.hist)
+		merged.total += h.total
+		if h.start.Before(merged.start) {
+			merged.start = h.start
+		}
+		h.hist.Reset()
+		h.total = 0
+	}
+}
+
+// recordHistogram adds latency to the histogram of op in histograms
+func recordHistogram(histograms map[string]*opHistogram, op string, latency time.Duration) {
+	h, ok := histograms[op]
+	if !ok {
+		// Same range and precision as go-ycsb: 1µs to a day, 3 significant digits
+		h = &opHistogram{hist: hdrhistogram.New(1, 24*60*60*1000*1000, 3), start: time.Now()}
+		histograms[op] = h
+	}
+	h.hist.RecordValue(latency.Microseconds())
+	h.total += latency
+}
+
+// Results returns the metrics of every operation ordered by name, with the
+// TOTAL row, if any, last. Each operation's row carries its failures, which
+// also have their own <OP>_ERROR row; an operation that only failed gets a row
+// with a count of 0.
+func (c *Collector) Results() []OperationMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fold()
+
+	seen := make(map[string]bool)
+	for op := range c.histograms {
+		seen[op] = true
+	}
+	for op := range c.errors {
+		seen[op] = true
+	}
+	operations := make([]string, 0, len(seen))
+	for op := range seen {
+		if op != "TOTAL" {
+			operations = append(operations, op)
+		}
+	}
+	sort.Strings(operations)
+	if len(operations) > 0 {
+		operations = append(operations, "TOTAL")
+	}
+
+	now := time.Now()
+	rows := make([]OperationMetrics, 0, len(operations))
+	for _, op := range operations {
+		h, ok := c.histograms[op]
+		if !ok {
+			row := OperationMetrics{Operation: op}
+			c.addErrors(&row)
+			rows = append(rows, row)
+			continue
+		}
+		row := h.metrics(op, now)
+		if ch, ok := c.corrected[op]; ok {
+			corrected := ch.metrics(op, now)
+			row.Corrected = &corrected
+		}
+		c.addErrors(&row)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// metrics returns the row of op as of now
+func (h *opHistogram) metrics(op string, now time.Time) OperationMetrics {
+	if !h.end.IsZero() {
+		now = h.end
+	}
+	count := h.hist.TotalCount()
+	var ops float64
+	if elapsed := now.Sub(h.start).Seconds(); elapsed > 0 {
+		ops = float64(count) / elapsed
+	}
+	row := OperationMetrics{
+		Operation: op,
+		TotalTime: h.total,
+		Count:     count,
+		OPS:       ops,
+		Avg:       int64(h.hist.Mean()),
+		Min:       h.hist.Min(),
+		Max:       h.hist.Max(),
+		P50:       h.hist.ValueAtPercentile(50),
+		P90:       h.hist.ValueAtPercentile(90),
+		P95:       h.hist.ValueAtPercentile(95),
+		P99:       h.hist.ValueAtPercentile(99),
+		P999:      h.hist.ValueAtPercentile(99.9),
+	}
+	for _, p := range tablePercentiles {
+		if _, fixed := row.Percentile(p); fixed {
+			continue
+		}
+		if row.Percentiles == nil {
+			row.Percentiles = make(map[string]int64)
+		}
+		row.Percentiles[percentileLabel(p)] = h.hist.ValueAtPercentile(p)
+	}
+	return row
+}
+
+// addErrors sets the failure counts of row: those of its operation, or of
+// every operation for TOTAL
+func (c *Collector) addErrors(row *OperationMetrics) {
+	for op, classes := range c.errors {
+		if op != row.Operation && row.Operation != "TOTAL" {
+			continue
+		}
+		for class, n := range classes {
+			if row.ErrorClasses == nil {
+				row.ErrorClasses = make(map[string]int64)
+			}
+			row.ErrorClasses[class] += n
+			row.Errors += n
+			if class == godbdb.ErrorClassNotFound {
+				row.Misses += n
+			}
+		}
+	}
+	if row.Errors > 0 {
+		row.ErrorRate = float64(row.Errors) / float64(row.Count+row.Errors)
+	}
+}
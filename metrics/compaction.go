@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// Compaction is a full compaction of the backend done before the workload,
+// with the bytes of its live data files before and after it
+type Compaction struct {
+	Duration    float64 `json:"duration_sec"`
+	BytesBefore uint64  `json:"bytes_before"`
+	BytesAfter  uint64  `json:"bytes_after"`
+}
+
+// NewCompaction describes a compaction that took d
+func NewCompaction(d time.Duration, before, after uint64) *Compaction {
+	return &Compaction{Duration: d.Seconds(), BytesBefore: before, BytesAfter: after}
+}
+
+// String summarizes the compaction, e.g. "took 2.1s, 24.0 MiB -> 9.6 MiB on
+// disk"
+func (c *Compaction) String() string {
+	return fmt.Sprintf("took %s, %s -> %s on disk",
+		time.Duration(c.Duration*float64(time.Second)).Round(time.Millisecond),
+		formatBytes(int64(c.BytesBefore)), formatBytes(int64(c.BytesAfter)))
+}
+
+// PrintCompaction prints the compaction done before a run, if any
+func PrintCompaction(c *Compaction) {
+	if c == nil {
+		return
+	}
+	fmt.Printf("\nCompaction before the run: %s\n", c)
+}
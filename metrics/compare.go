@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// BackendResults pairs a backend name with its per-operation metrics
+type BackendResults struct {
+	Name         string
+	Results      []OperationMetrics
+	WriteAmp     *WriteAmplification // nil if the backend does not report it
+	Layout       *StorageLayout      // nil if not known
+	Capabilities *Capabilities       // nil if the backend did not register them
+}
+
+// PrintComparisonTable prints the metrics of several backends side by side.
+// The first backend is the baseline: speedups are its throughput and p99
+// latency relative to each other backend's, so values above 1.00x are faster.
+func PrintComparisonTable(backends []BackendResults) {
+	if len(backends) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+
+	title := fmt.Sprintf("BACKEND COMPARISON (baseline: %s)", backends[0].Name)
+	padding := (tableWidth - len(title)) / 2
+	fmt.Println(strings.Repeat(" ", padding) + title)
+
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	fmt.Printf("│ %-12s │ %-10s │ %10s │ %10s │ %9s │ %9s │ %9s │ %12s │ %12s │\n",
+		"Operation", "Backend", "Count", "OPS", "Avg(µs)", "p99(µs)", "p99.9(µs)", "OPS speedup", "p99 speedup")
+	fmt.Println(strings.Repeat("─", tableWidth))
+
+	for i, op := range comparisonOperations(backends) {
+		if i > 0 {
+			fmt.Println(strings.Repeat("─", tableWidth))
+		}
+
+		baseline, hasBaseline := FindMetrics(backends[0].Results, op)
+		for _, b := range backends {
+			row, ok := FindMetrics(b.Results, op)
+			if !ok {
+				fmt.Printf("│ %-12s │ %-10s │ %10s │ %10s │ %9s │ %9s │ %9s │ %12s │ %12s │\n",
+					op, b.Name, "-", "-", "-", "-", "-", "-", "-")
+				continue
+			}
+
+			opsSpeedup, p99Speedup := "-", "-"
+			if hasBaseline {
+				opsSpeedup = formatRatio(row.OPS, baseline.OPS)
+				p99Speedup = formatRatio(float64(baseline.P99), float64(row.P99))
+			}
+
+			fmt.Printf("│ %-12s │ %-10s │ %10d │ %10.1f │ %9d │ %9d │ %9d │ %12s │ %12s │\n",
+				op, b.Name, row.Count, row.OPS, row.Avg, row.P99, row.P999, opsSpeedup, p99Speedup)
+		}
+	}
+
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	if slices.ContainsFunc(backends, func(b BackendResults) bool { return b.WriteAmp != nil }) {
+		fmt.Println("Write amplification:")
+		for _, b := range backends {
+			amp := "not reported"
+			if b.WriteAmp != nil {
+				amp = b.WriteAmp.String()
+			}
+			fmt.Printf("  %-10s %s\n", b.Name, amp)
+		}
+	}
+	printFairnessAudit(backends)
+}
+
+// comparisonOperations returns every operation seen across backends, in
+// first-seen order with TOTAL last
+func comparisonOperations(backends []BackendResults) []string {
+	seen := make(map[string]bool)
+	var ops []string
+	hasTotal := false
+	for _, b := range backends {
+		for _, r := range b.Results {
+			if r.Operation == "TOTAL" {
+				hasTotal = true
+				continue
+			}
+			if !seen[r.Operation] {
+				seen[r.Operation] = true
+				ops = append(ops, r.Operation)
+			}
+		}
+	}
+	if hasTotal {
+		ops = append(ops, "TOTAL")
+	}
+	return ops
+}
+
+// FindMetrics returns the metrics row for op, if present
+func FindMetrics(rows []OperationMetrics, op string) (OperationMetrics, bool) {
+	for _, r := range rows {
+		if r.Operation == op {
+			return r, true
+		}
+	}
+	return OperationMetrics{}, false
+}
+
+// formatRatio formats num/den as a speedup, e.g. "1.25x"
+func formatRatio(num, den float64) string {
+	if den == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2fx", num/den)
+}
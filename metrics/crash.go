@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CrashRecovery is what a copy of the database as a crash would have left it
+// at the end of a run held once recovered: how long opening it took, and the
+// writes acknowledged by the run but lost or corrupt there
+type CrashRecovery struct {
+	Bytes    int64    `json:"bytes"`   // of the image left on disk
+	Open     float64  `json:"open_us"` // recovery, opening the image
+	Checked  bool     `json:"checked"` // whether the keys were read back, only with verify
+	Writes   int64    `json:"writes"`
+	Keys     int64    `json:"keys"`
+	Corrupt  int64    `json:"corrupt"`
+	Lost     int64    `json:"lost"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+// PrintCrashRecovery prints the recovery of a crash image, if one was taken
+func PrintCrashRecovery(c *CrashRecovery) {
+	if c == nil {
+		return
+	}
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("Crash Recovery:")
+	fmt.Println(strings.Repeat("=", 80))
+
+	rows := [][2]string{
+		{"Image on disk", formatBytes(c.Bytes)},
+		{"Recovery (open)", formatDuration(c.Open)},
+	}
+	if c.Checked {
+		lost := fmt.Sprintf("%d", c.Lost)
+		if c.Keys > 0 {
+			lost = fmt.Sprintf("%d (%.2f%% of keys written)", c.Lost, float64(c.Lost)/float64(c.Keys)*100)
+		}
+		rows = append(rows,
+			[2]string{"Checked", fmt.Sprintf("%d writes, %d keys read back", c.Writes, c.Keys)},
+			[2]string{"Corrupt values", fmt.Sprintf("%d", c.Corrupt)},
+			[2]string{"Lost writes", lost},
+		)
+	} else {
+		rows = append(rows, [2]string{"Checked", "no, set verify=true to read the keys back"})
+	}
+	for _, row := range rows {
+		fmt.Printf("%-30s %s\n", row[0], row[1])
+	}
+	if len(c.Examples) > 0 {
+		fmt.Println("\nFirst anomalies:")
+		for _, e := range c.Examples {
+			fmt.Printf("  %s\n", e)
+		}
+	}
+}
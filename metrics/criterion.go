@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CriterionBaseline is the baseline directory criterion.rs keeps the latest
+// measurement in
+const CriterionBaseline = "new"
+
+// criterionMADScale makes the MAD a consistent estimator of the standard
+// deviation, as criterion.rs reports it
+const criterionMADScale = 1.4826
+
+// criterionBenchmark is criterion.rs' benchmark.json
+type criterionBenchmark struct {
+	GroupID       string            `json:"group_id"`
+	FunctionID    *string           `json:"function_id"`
+	ValueStr      *string           `json:"value_str"`
+	Throughput    map[string]uint64 `json:"throughput"`
+	FullID        string            `json:"full_id"`
+	DirectoryName string            `json:"directory_name"`
+	Title         string            `json:"title"`
+}
+
+// criterionEstimate is one entry of criterion.rs' estimates.json
+type criterionEstimate struct {
+	ConfidenceInterval struct {
+		ConfidenceLevel float64 `json:"confidence_level"`
+		LowerBound      float64 `json:"lower_bound"`
+		UpperBound      float64 `json:"upper_bound"`
+	} `json:"confidence_interval"`
+	PointEstimate float64 `json:"point_estimate"`
+	StandardError float64 `json:"standard_error"`
+}
+
+// criterionEstimates is criterion.rs' estimates.json. Slope is only estimated
+// for linear sampling, so it is always null here.
+type criterionEstimates struct {
+	Mean         criterionEstimate  `json:"mean"`
+	Median       criterionEstimate  `json:"median"`
+	MedianAbsDev criterionEstimate  `json:"median_abs_dev"`
+	Slope        *criterionEstimate `json:"slope"`
+	StdDev       criterionEstimate  `json:"std_dev"`
+}
+
+// criterionSample is criterion.rs' sample.json. Every sample is a single
+// operation, which criterion calls flat sampling.
+type criterionSample struct {
+	SamplingMode string    `json:"sampling_mode"`
+	Iters        []float64 `json:"iters"`
+	Times        []float64 `json:"times"`
+}
+
+// WriteCriterion writes every run in criterion.rs' directory layout under
+// dir, so criterion tooling such as critcmp can read Go and Rust results
+// alike: <dir>/<db>/<operation>[/<run>]/new/{benchmark,estimates,sample,tukey}.json.
+// The run is only part of the ID when the report has more than one run.
+// Times are in nanoseconds. Statistics are computed for runs without them,
+// with the run's bootstrap settings.
+func (r *Report) WriteCriterion(dir string) error {
+	for _, run := range r.Runs {
+		var value *string
+		if len(r.Runs) > 1 {
+			v := run.Name
+			value = &v
+		}
+
+		stats := make(map[string]OperationStatistics, len(run.Statistics))
+		for _, s := range run.Statistics {
+			stats[s.Operation] = s
+		}
+
+		operations := make([]string, 0, len(run.Samples))
+		for operation, samples := range run.Samples {
+			if len(samples) > 0 {
+				operations = append(operations, operation)
+			}
+		}
+		sort.Strings(operations)
+
+		for _, operation := range operations {
+			s, ok := stats[operation]
+			if !ok {
+				samples := make([]SampleData, len(run.Samples[operation]))
+				for i, t := range run.Samples[operation] {
+					samples[i] = SampleData{SampleIndex: int64(i + 1), TotalTime: t}
+				}
+				b := run.Bootstrap
+				if b.Resamples == 0 {
+					b = DefaultBootstrap()
+				}
+				s = b.operationStatistics(operation, samples)
+			}
+			if err := writeCriterionBenchmark(dir, run, operation, value, s); err != nil {
+				return fmt.Errorf("%s %s: %w", run.Name, operation, err)
+			}
+		}
+	}
+	return nil
+}
+
+// writeCriterionBenchmark writes the baseline directory of one operation
+func writeCriterionBenchmark(dir string, run Results, operation string, value *string, s OperationStatistics) error {
+	fullID := run.DB + "/" + operation
+	directory := criterionDirName(run.DB) + "/" + criterionDirName(operation)
+	if value != nil {
+		fullID += "/" + *value
+		directory += "/" + criterionDirName(*value)
+	}
+
+	function := operation
+	benchmark := criterionBenchmark{
+		GroupID:       run.DB,
+		FunctionID:    &function,
+		ValueStr:      value,
+		Throughput:    map[string]uint64{"Elements": 1},
+		FullID:        fullID,
+		DirectoryName: directory,
+		Title:         fullID,
+	}
+
+	estimates := criterionEstimates{
+		Mean:         criterionEstimateFrom(s.Mean, s.level(), 1),
+		Median:       criterionEstimateFrom(s.Median, s.level(), 1),
+		MedianAbsDev: criterionEstimateFrom(s.MAD, s.level(), criterionMADScale),
+		StdDev:       criterionEstimateFrom(s.StdDev, s.level(), 1),
+	}
+
+	times := run.Samples[operation]
+	sample := criterionSample{
+		SamplingMode: "Flat",
+		Iters:        make([]float64, len(times)),
+		Times:        make([]float64, len(times)),
+	}
+	for i, t := range times {
+		sample.Iters[i] = 1
+		sample.Times[i] = float64(t.Nanoseconds())
+	}
+
+	out := filepath.Join(dir, filepath.FromSlash(directory), CriterionBaseline)
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"benchmark.json", benchmark},
+		{"estimates.json", estimates},
+		{"sample.json", sample},
+		{"tukey.json", tukeyFences(sample.Times)},
+	}
+	for _, f := range files {
+		data, err := json.Marshal(f.v)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(out, f.name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// criterionEstimateFrom converts a confidence interval in microseconds at the
+// given level to a criterion estimate in nanoseconds
+func criterionEstimateFrom(ci ConfidenceInterval, level, scale float64) criterionEstimate {
+	scale *= 1000
+	var e criterionEstimate
+	e.ConfidenceInterval.ConfidenceLevel = level
+	e.ConfidenceInterval.LowerBound = ci.LowerBound * scale
+	e.ConfidenceInterval.UpperBound = ci.UpperBound * scale
+	e.PointEstimate = ci.Estimate * scale
+	e.StandardError = ci.StdError * scale
+	return e
+}
+
+// tukeyFences returns the low severe, low mild, high mild and high severe
+// outlier fences of times, as criterion.rs stores them in tukey.json
+func tukeyFences(times []float64) [4]float64 {
+	sorted := append([]float64(nil), times...)
+	sort.Float64s(sorted)
+	q1, q3 := percentile(sorted, 25), percentile(sorted, 75)
+	iqr := q3 - q1
+	return [4]float64{q1 - 3*iqr, q1 - 1.5*iqr, q3 + 1.5*iqr, q3 + 3*iqr}
+}
+
+// percentile linearly interpolates the p-th percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(rank)
+	if lo+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (rank-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// criterionDirName replaces the characters criterion.rs does not allow in
+// directory names
+func criterionDirName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '?', '"', '/', '\\', '*', '<', '>', ':', '|', '^':
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultDashboardInterval is how often the live dashboard is redrawn
+const DefaultDashboardInterval = time.Second
+
+// dashboardHistory is the number of refreshes the throughput sparkline spans
+const dashboardHistory = 60
+
+// sparkBars are the heights of the sparkline, lowest first
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// EngineActivity is a backend's background work at one point of the run
+type EngineActivity struct {
+	Flushes               int64
+	FlushesInProgress     int64
+	Compactions           int64
+	CompactionsInProgress int64
+	CompactionDebt        uint64
+	L0Files               int64
+	L0Sublevels           int
+	L0StopWrites          int // sublevels at which writes stop; 0 if unknown
+	ReadAmp               int
+	MemtableBytes         uint64
+	WriteStalls           int64
+	WriteStallTime        time.Duration
+	L0WriteStalls         int64 // of WriteStalls, those on too many L0 sublevels
+	L0WriteStallTime      time.Duration
+}
+
+// dashboardTotals are the counts of one operation since the run started
+type dashboardTotals struct {
+	count, errors int64
+}
+
+// Dashboard redraws a live view of the run in place on a terminal: every
+// operation's throughput and latency over the last refresh, its counts so
+// far, a sparkline of the total throughput and, for backends that report
+// it, the engine's flush and compaction activity
+type Dashboard struct {
+	out      io.Writer
+	title    string
+	window   *LiveWindow
+	engine   func() EngineActivity // nil for backends without engine activity
+	interval time.Duration
+	start    time.Time
+	warmUp   time.Duration
+
+	totals     map[string]*dashboardTotals
+	throughput []float64
+	engineLast *EngineActivity
+	engineAt   time.Time
+	lines      int // lines of the last frame, to move back over
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// NewDashboard creates a dashboard of the operations in window, drawn on out
+// every interval. engine, if not nil, reads the backend's activity.
+func NewDashboard(out io.Writer, title string, window *LiveWindow, engine func() EngineActivity, interval time.Duration, warmUp time.Duration) *Dashboard {
+	return &Dashboard{
+		out:      out,
+		title:    title,
+		window:   window,
+		engine:   engine,
+		interval: interval,
+		warmUp:   warmUp,
+		totals:   make(map[string]*dashboardTotals),
+	}
+}
+
+// Start draws the first frame and keeps redrawing in the background
+func (d *Dashboard) Start() {
+	d.start = time.Now()
+	d.window.Take() // the first refresh starts now
+	if d.engine != nil {
+		e := d.engine()
+		d.engineLast, d.engineAt = &e, d.start
+	}
+	fmt.Fprint(d.out, "\033[?25l") // hide the cursor while redrawing
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.draw()
+			case <-d.stop:
+				d.draw() // the final state stays on screen
+				return
+			}
+		}
+	}()
+}
+
+// Stop draws the last frame and gives the terminal back
+func (d *Dashboard) Stop() {
+	if d.stop == nil {
+		return
+	}
+	close(d.stop)
+	<-d.done
+	d.stop = nil
+	fmt.Fprint(d.out, "\033[?25h")
+}
+
+// draw replaces the previous frame with the current one
+func (d *Dashboard) draw() {
+	frame := d.frame(d.window.Take())
+	var b strings.Builder
+	if d.lines > 0 {
+		fmt.Fprintf(&b, "\033[%dA", d.lines) // back to the top of the last frame
+	}
+	for _, line := range frame {
+		b.WriteString("\033[2K" + line + "\n")
+	}
+	b.WriteString("\033[J") // anything left of a longer previous frame
+	fmt.Fprint(d.out, b.String())
+	d.lines = len(frame)
+}
+
+// frame renders one refresh
+func (d *Dashboard) frame(s LiveSnapshot) []string {
+	const tableWidth = 126
+	elapsed := s.End.Sub(d.start)
+	phase := "run"
+	if elapsed < d.warmUp {
+		phase = fmt.Sprintf("warm-up, %s left", (d.warmUp - elapsed).Truncate(time.Second))
+	}
+	title := fmt.Sprintf("%s   %s elapsed (%s)", d.title, elapsed.Truncate(time.Second), phase)
+
+	lines := []string{
+		strings.Repeat("═", tableWidth),
+		strings.Repeat(" ", max(0, (tableWidth-len([]rune(title)))/2)) + title,
+		strings.Repeat("═", tableWidth),
+		fmt.Sprintf("│ %-16s │ %14s │ %14s │ %12s │ %14s │ %14s │ %14s │", "Operation", "Ops/sec", "Count", "Errors", "p50 (last)", "p99 (last)", "Max (last)"),
+		strings.Repeat("─", tableWidth),
+	}
+
+	var total float64
+	for _, op := range s.Operations {
+		t, ok := d.totals[op.Operation]
+		if !ok {
+			t = &dashboardTotals{}
+			d.totals[op.Operation] = t
+		}
+		t.count += op.Count
+		t.errors += op.Errors
+
+		p50, p99, maxLatency := "-", "-", "-"
+		if op.Count > 0 {
+			p50, p99, maxLatency = formatDuration(float64(op.P50)), formatDuration(float64(op.P99)), formatDuration(float64(op.Max))
+		}
+		lines = append(lines, fmt.Sprintf("│ %-16s │ %14.1f │ %14d │ %12d │ %14s │ %14s │ %14s │",
+			op.Operation, op.OPS, t.count, t.errors, p50, p99, maxLatency))
+		if op.Operation == "TOTAL" {
+			total = op.OPS
+		}
+	}
+	if len(s.Operations) == 0 {
+		lines = append(lines, "│ waiting for the first operation...")
+	}
+	lines = append(lines, strings.Repeat("═", tableWidth))
+
+	d.throughput = append(d.throughput, total)
+	if len(d.throughput) > dashboardHistory {
+		d.throughput = d.throughput[1:]
+	}
+	peak := 0.0
+	for _, v := range d.throughput {
+		peak = max(peak, v)
+	}
+	lines = append(lines, fmt.Sprintf("Throughput  %s  %.0f ops/s now, %.0f peak over the last %d refreshes",
+		sparkline(d.throughput, peak), total, peak, len(d.throughput)))
+
+	if d.engine != nil {
+		e := d.engine()
+		seconds := s.End.Sub(d.engineAt).Seconds()
+		var flushRate, compactionRate float64
+		if seconds > 0 {
+			flushRate = float64(e.Flushes-d.engineLast.Flushes) / seconds
+			compactionRate = float64(e.Compactions-d.engineLast.Compactions) / seconds
+		}
+		d.engineLast, d.engineAt = &e, s.End
+		stopAt := ""
+		if e.L0StopWrites > 0 {
+			stopAt = fmt.Sprintf(" (writes stop at %d)", e.L0StopWrites)
+		}
+		lines = append(lines,
+			fmt.Sprintf("Flushes     %d done (%.1f/s), %d running; memtables %s",
+				e.Flushes, flushRate, e.FlushesInProgress, formatBytes(int64(e.MemtableBytes))),
+			fmt.Sprintf("Compactions %d done (%.1f/s), %d running; debt %s",
+				e.Compactions, compactionRate, e.CompactionsInProgress, formatBytes(int64(e.CompactionDebt))),
+			fmt.Sprintf("LSM         L0 %d files in %d sublevels%s; read amplification %d",
+				e.L0Files, e.L0Sublevels, stopAt, e.ReadAmp),
+			fmt.Sprintf("Stalls      %d write stalls, %.1fs stalled; %d on L0, %.1fs",
+				e.WriteStalls, e.WriteStallTime.Seconds(), e.L0WriteStalls, e.L0WriteStallTime.Seconds()))
+	}
+	return lines
+}
+
+// sparkline draws values scaled to peak as one bar each
+func sparkline(values []float64, peak float64) string {
+	bars := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if peak > 0 {
+			level = int(math.Round(v / peak * float64(len(sparkBars)-1)))
+		}
+		bars[i] = sparkBars[level]
+	}
+	return string(bars)
+}
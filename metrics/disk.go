@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// DiskBaseline is the raw performance of the device under a data directory,
+// measured without any engine, so engine results can be normalized against
+// the hardware they ran on
+type DiskBaseline struct {
+	Dir      string     `json:"dir"`
+	FileSize int64      `json:"file_size"`
+	DirectIO bool       `json:"direct_io"` // false where the filesystem cannot bypass the page cache
+	Tests    []DiskTest `json:"tests"`
+}
+
+// DiskTest is the outcome of one test of a DiskBaseline
+type DiskTest struct {
+	Test       string  `json:"test"`
+	BlockSize  int     `json:"block_size"`
+	Ops        int64   `json:"ops"`
+	Bytes      int64   `json:"bytes"`
+	Throughput float64 `json:"bytes_per_sec"`
+	IOPS       float64 `json:"iops"`
+	Mean       float64 `json:"mean_us"`
+	P50        float64 `json:"p50_us"`
+	P99        float64 `json:"p99_us"`
+	Max        float64 `json:"max_us"`
+}
+
+// NewDiskTest summarizes ops IOs of blockSize bytes that took elapsed, with
+// their latency histogram in nanoseconds
+func NewDiskTest(test string, blockSize int, ops int64, elapsed time.Duration, latency *hdrhistogram.Histogram) DiskTest {
+	us := func(ns int64) float64 { return float64(ns) / 1000 }
+	t := DiskTest{
+		Test:      test,
+		BlockSize: blockSize,
+		Ops:       ops,
+		Bytes:     ops * int64(blockSize),
+		Mean:      latency.Mean() / 1000,
+		P50:       us(latency.ValueAtPercentile(50)),
+		P99:       us(latency.ValueAtPercentile(99)),
+		Max:       us(latency.Max()),
+	}
+	if s := elapsed.Seconds(); s > 0 {
+		t.Throughput = float64(t.Bytes) / s
+		t.IOPS = float64(ops) / s
+	}
+	return t
+}
+
+// PrintDiskBaseline prints the raw device performance measured for a run
+func PrintDiskBaseline(b *DiskBaseline) {
+	if b == nil {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := fmt.Sprintf("DISK BASELINE (%s)", b.Dir)
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-12s │ %10s │ %10s │ %12s │ %12s │ %11s │ %11s │ %11s │ %11s │\n",
+		"Test", "Block", "Ops", "Throughput", "IOPS", "Mean", "p50", "p99", "Max")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, t := range b.Tests {
+		fmt.Printf("│ %-12s │ %10s │ %10d │ %12s │ %12.0f │ %11s │ %11s │ %11s │ %11s │\n",
+			t.Test, formatBytes(int64(t.BlockSize)), t.Ops, formatBytes(int64(t.Throughput))+"/s", t.IOPS,
+			formatDuration(t.Mean), formatDuration(t.P50), formatDuration(t.P99), formatDuration(t.Max))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+	if !b.DirectIO {
+		fmt.Println("Note: the filesystem does not support direct IO, so reads may have been served from the page cache")
+	}
+}
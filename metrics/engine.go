@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EngineSample is the backlog of a backend's background work at one point of
+// the run. Counts and stall time are over the interval ending at the sample.
+type EngineSample struct {
+	Elapsed        float64 `json:"elapsed_s"`  // seconds since tracking began, as the sample start times
+	Interval       float64 `json:"interval_s"` // seconds since the previous sample
+	CompactionDebt uint64  `json:"compaction_debt_bytes"`
+	L0Files        int64   `json:"l0_files"`
+	L0Sublevels    int     `json:"l0_sublevels"`
+	L0StopWrites   int     `json:"l0_stop_writes,omitempty"` // sublevels at which writes stop
+	ReadAmp        int     `json:"read_amp"`
+	MemtableBytes  uint64  `json:"memtable_bytes"`
+	Flushes        int64   `json:"flushes"`
+	Compactions    int64   `json:"compactions"`
+	WriteStalls    int64   `json:"write_stalls"` // begun in the interval
+	Stalled        float64 `json:"stalled_s"`    // seconds of the interval writes were stalled
+
+	// Of the stalls and time stalled, those that began on too many L0
+	// sublevels; the rest began waiting for memtables to flush
+	L0WriteStalls int64   `json:"l0_write_stalls"`
+	L0Stalled     float64 `json:"l0_stalled_s"`
+}
+
+// EngineMonitor samples a backend's compaction debt, L0 and write stalls in
+// the background, so the point where background work stops keeping up with
+// the offered load shows on the latency timeline
+type EngineMonitor struct {
+	start    time.Time
+	interval time.Duration
+	read     func() EngineActivity
+	stop     chan struct{}
+	done     chan struct{}
+	last     EngineActivity
+	lastAt   time.Time
+	samples  []EngineSample
+}
+
+// NewEngineMonitor creates a monitor sampling read every interval, with
+// sample times relative to start
+func NewEngineMonitor(start time.Time, interval time.Duration, read func() EngineActivity) *EngineMonitor {
+	return &EngineMonitor{start: start, interval: interval, read: read}
+}
+
+// Start begins sampling. It does nothing without a backend to read.
+func (m *EngineMonitor) Start() {
+	if m.read == nil || m.interval <= 0 {
+		return
+	}
+	m.last, m.lastAt = m.read(), time.Now()
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.record()
+			case <-m.stop:
+				m.record() // the partial interval at the end
+				return
+			}
+		}
+	}()
+}
+
+// record adds a sample covering the time since the previous one
+func (m *EngineMonitor) record() {
+	e, at := m.read(), time.Now()
+	width := at.Sub(m.lastAt).Seconds()
+	if width <= 0 {
+		return
+	}
+	m.samples = append(m.samples, EngineSample{
+		Elapsed:        at.Sub(m.start).Seconds(),
+		Interval:       width,
+		CompactionDebt: e.CompactionDebt,
+		L0Files:        e.L0Files,
+		L0Sublevels:    e.L0Sublevels,
+		L0StopWrites:   e.L0StopWrites,
+		ReadAmp:        e.ReadAmp,
+		MemtableBytes:  e.MemtableBytes,
+		Flushes:        e.Flushes - m.last.Flushes,
+		Compactions:    e.Compactions - m.last.Compactions,
+		WriteStalls:    e.WriteStalls - m.last.WriteStalls,
+		Stalled:        (e.WriteStallTime - m.last.WriteStallTime).Seconds(),
+		L0WriteStalls:  e.L0WriteStalls - m.last.L0WriteStalls,
+		L0Stalled:      (e.L0WriteStallTime - m.last.L0WriteStallTime).Seconds(),
+	})
+	m.last, m.lastAt = e, at
+}
+
+// Stop ends sampling and returns the samples taken
+func (m *EngineMonitor) Stop() []EngineSample {
+	if m.stop != nil {
+		close(m.stop)
+		<-m.done
+		m.stop = nil
+	}
+	return m.samples
+}
+
+// EngineSummary condenses the engine samples of a run
+type EngineSummary struct {
+	Seconds         float64 // monitored
+	PeakDebt        uint64
+	FinalDebt       uint64
+	PeakL0Files     int64
+	PeakL0Sublevels int
+	L0StopWrites    int // 0 if unknown
+	PeakReadAmp     int
+	Flushes         int64
+	Compactions     int64
+	WriteStalls     int64
+	Stalled         float64 // seconds
+	L0WriteStalls   int64   // of WriteStalls, on too many L0 sublevels
+	L0Stalled       float64 // of Stalled, seconds
+	FirstStall      float64 // elapsed seconds at the end of the first interval with a stall; 0 without stalls
+}
+
+// SummarizeEngine returns the peaks and totals over samples
+func SummarizeEngine(samples []EngineSample) EngineSummary {
+	var s EngineSummary
+	for _, e := range samples {
+		s.Seconds += e.Interval
+		s.PeakDebt = max(s.PeakDebt, e.CompactionDebt)
+		s.PeakL0Files = max(s.PeakL0Files, e.L0Files)
+		s.PeakL0Sublevels = max(s.PeakL0Sublevels, e.L0Sublevels)
+		s.L0StopWrites = max(s.L0StopWrites, e.L0StopWrites)
+		s.PeakReadAmp = max(s.PeakReadAmp, e.ReadAmp)
+		s.Flushes += e.Flushes
+		s.Compactions += e.Compactions
+		s.WriteStalls += e.WriteStalls
+		s.Stalled += e.Stalled
+		s.L0WriteStalls += e.L0WriteStalls
+		s.L0Stalled += e.L0Stalled
+		if s.FirstStall == 0 && (e.WriteStalls > 0 || e.Stalled > 0) {
+			s.FirstStall = e.Elapsed
+		}
+	}
+	if len(samples) > 0 {
+		s.FinalDebt = samples[len(samples)-1].CompactionDebt
+	}
+	return s
+}
+
+// PrintEngine prints a summary of the backlog and write stalls of a run
+func PrintEngine(samples []EngineSample) {
+	if len(samples) == 0 {
+		return
+	}
+	s := SummarizeEngine(samples)
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("Engine Backlog:")
+	fmt.Println(strings.Repeat("=", 80))
+
+	stalls := "none"
+	if s.WriteStalls > 0 || s.Stalled > 0 {
+		var share float64
+		if s.Seconds > 0 {
+			share = 100 * s.Stalled / s.Seconds
+		}
+		stalls = fmt.Sprintf("%d, %.1fs stalled (%.1f%% of the run), first by %.0fs",
+			s.WriteStalls, s.Stalled, share, s.FirstStall)
+	}
+	l0 := fmt.Sprintf("%d files peak, %d sublevels peak", s.PeakL0Files, s.PeakL0Sublevels)
+	if s.L0StopWrites > 0 {
+		l0 += fmt.Sprintf(" (writes stop at %d)", s.L0StopWrites)
+	}
+	rows := [][2]string{
+		{"Compaction debt", formatBytes(int64(s.PeakDebt)) + " peak, " + formatBytes(int64(s.FinalDebt)) + " at the end"},
+		{"L0", l0},
+		{"Read amplification", fmt.Sprintf("%d peak", s.PeakReadAmp)},
+		{"Background work", fmt.Sprintf("%d flushes, %d compactions", s.Flushes, s.Compactions)},
+		{"Write stalls", stalls},
+	}
+	if s.WriteStalls > 0 || s.Stalled > 0 {
+		rows = append(rows, [2]string{"Stalls by first cause", fmt.Sprintf("%d on L0 sublevels (%.1fs), %d on memtables (%.1fs)",
+			s.L0WriteStalls, s.L0Stalled, s.WriteStalls-s.L0WriteStalls, s.Stalled-s.L0Stalled)})
+	}
+	for _, row := range rows {
+		fmt.Printf("%-30s %s\n", row[0], row[1])
+	}
+}
+
+// generateEnginePlot draws the mean latency of every operation per engine
+// sample above the compaction debt, L0 and the share of time writes were
+// stalled on the same time axis, so latency spikes can be matched to the
+// backlog behind them
+func (bp *BenchmarkPlots) generateEnginePlot(operations []string, outputDir string, formats []string) ([]string, error) {
+	if len(bp.engine) == 0 {
+		return nil, nil
+	}
+	n := len(bp.engine)
+	ends, widths := make([]float64, n), make([]float64, n)
+	debt, files, sublevels, stopWrites := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	stalled, l0Stalled := make([]float64, n), make([]float64, n)
+	for i, e := range bp.engine {
+		ends[i], widths[i] = e.Elapsed, e.Interval
+		debt[i] = float64(e.CompactionDebt) / (1 << 20)
+		files[i], sublevels[i], stopWrites[i] = float64(e.L0Files), float64(e.L0Sublevels), float64(e.L0StopWrites)
+		stalled[i] = 100 * e.Stalled / e.Interval
+		l0Stalled[i] = 100 * e.L0Stalled / e.Interval
+	}
+	l0 := []timelineLine{{"Files", files}, {"Sublevels", sublevels}}
+	if SummarizeEngine(bp.engine).L0StopWrites > 0 {
+		l0 = append(l0, timelineLine{"Stop writes", stopWrites})
+	}
+	panels := []timelinePanel{
+		{"Compaction debt (MiB)", []timelineLine{{"", debt}}},
+		{"L0", l0},
+		{"Stalled (%)", []timelineLine{{"All", stalled}, {"On L0", l0Stalled}}},
+	}
+	return bp.generateTimelinePlot("Engine Backlog", "engine", operations, ends, widths, panels, outputDir, formats)
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// trackedModules are the dependencies whose versions matter when comparing results
+var trackedModules = []string{
+	"github.com/cockroachdb/pebble",
+	"github.com/cffls/triedb-go",
+	"github.com/pingcap/go-ycsb",
+}
+
+// Environment describes the build and machine a benchmark ran on
+type Environment struct {
+	Version     string            `json:"version"`
+	VCSRevision string            `json:"vcs_revision,omitempty"`
+	VCSTime     string            `json:"vcs_time,omitempty"`
+	VCSModified bool              `json:"vcs_modified,omitempty"`
+	GoVersion   string            `json:"go_version"`
+	Modules     map[string]string `json:"modules"`
+	OS          string            `json:"os"`
+	Arch        string            `json:"arch"`
+	Kernel      string            `json:"kernel,omitempty"`
+	CPUModel    string            `json:"cpu_model,omitempty"`
+	NumCPU      int               `json:"num_cpu"`
+	GOMAXPROCS  int               `json:"gomaxprocs"`
+	Hostname    string            `json:"hostname,omitempty"`
+	CapturedAt  time.Time         `json:"captured_at"`
+}
+
+// CaptureEnvironment collects build information and host details.
+// Fields that cannot be determined on the current platform are left empty.
+func CaptureEnvironment() Environment {
+	env := Environment{
+		Version:    "(unknown)",
+		GoVersion:  runtime.Version(),
+		Modules:    make(map[string]string),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		CapturedAt: time.Now(),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		env.Version = info.Main.Version
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				env.VCSRevision = s.Value
+			case "vcs.time":
+				env.VCSTime = s.Value
+			case "vcs.modified":
+				env.VCSModified = s.Value == "true"
+			}
+		}
+		for _, dep := range info.Deps {
+			for _, path := range trackedModules {
+				if dep.Path != path {
+					continue
+				}
+				version := dep.Version
+				if dep.Replace != nil {
+					version = fmt.Sprintf("%s => %s %s", dep.Version, dep.Replace.Path, dep.Replace.Version)
+				}
+				env.Modules[path] = strings.TrimSpace(version)
+			}
+		}
+	}
+
+	if data, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		env.Kernel = strings.TrimSpace(string(data))
+	}
+	env.CPUModel = readCPUModel()
+	env.Hostname, _ = os.Hostname()
+
+	return env
+}
+
+// readCPUModel returns the first "model name" entry from /proc/cpuinfo
+func readCPUModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if found && strings.TrimSpace(key) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// Print outputs the environment as an aligned key/value block
+func (e Environment) Print() {
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("Environment:")
+	fmt.Println(strings.Repeat("=", 80))
+
+	revision := e.VCSRevision
+	if revision != "" && e.VCSModified {
+		revision += " (modified)"
+	}
+
+	rows := [][2]string{
+		{"godb-bench", e.Version},
+		{"Revision", revision},
+		{"Go", e.GoVersion},
+		{"OS/Arch", e.OS + "/" + e.Arch},
+		{"Kernel", e.Kernel},
+		{"CPU", e.CPUModel},
+		{"CPUs", fmt.Sprintf("%d (GOMAXPROCS=%d)", e.NumCPU, e.GOMAXPROCS)},
+		{"Host", e.Hostname},
+	}
+
+	modules := make([]string, 0, len(e.Modules))
+	for path := range e.Modules {
+		modules = append(modules, path)
+	}
+	sort.Strings(modules)
+	for _, path := range modules {
+		rows = append(rows, [2]string{path, e.Modules[path]})
+	}
+
+	for _, row := range rows {
+		if row[1] != "" {
+			fmt.Printf("%-30s %s\n", row[0], row[1])
+		}
+	}
+}
+
+// EncodeJSON writes the environment to w as indented JSON
+func (e Environment) EncodeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// WriteJSON writes the environment to path as indented JSON
+func (e Environment) WriteJSON(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return e.EncodeJSON(f)
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import "fmt"
+
+// MinErrorRateOperations is how many operations a run completes before its
+// error rate is judged, so a single early failure does not abort it
+const MinErrorRateOperations = 100
+
+// ErrorRateAbort is why a run was stopped early: more than Limit percent of
+// the operations it had completed by then, warm-up included, failed for any
+// reason but a missing key
+type ErrorRateAbort struct {
+	Limit      float64 `json:"limit_pct"`
+	Rate       float64 `json:"rate_pct"`
+	Operations int64   `json:"operations"`
+	Errors     int64   `json:"errors"`
+	Elapsed    float64 `json:"elapsed_s"`
+	LastError  string  `json:"last_error,omitempty"`
+}
+
+// ErrorRate sums the failures and operations of the TOTAL row of snapshot.
+// Reads of a missing key count as operations, not failures: many workloads
+// expect them.
+func ErrorRate(snapshot LiveSnapshot) (errors, operations int64) {
+	for _, o := range snapshot.Operations {
+		if o.Operation == "TOTAL" {
+			return o.Errors - o.Misses, o.Count + o.Errors
+		}
+	}
+	return 0, 0
+}
+
+// String describes the abort, e.g. "612 of 1000 operations (61.2%) failed
+// in 3.2s, over the 5% limit"
+func (a ErrorRateAbort) String() string {
+	return fmt.Sprintf("%d of %d operations (%.1f%%) failed in %.1fs, over the %g%% limit",
+		a.Errors, a.Operations, a.Rate, a.Elapsed, a.Limit)
+}
+
+// PrintErrorRateAbort prints why the run was aborted, below its partial
+// results
+func PrintErrorRateAbort(a *ErrorRateAbort) {
+	if a == nil {
+		return
+	}
+	fmt.Printf("\nRun aborted: %s\n", a)
+	if a.LastError != "" {
+		fmt.Printf("Last error: %s\n", a.LastError)
+	}
+	fmt.Println("The results above cover only the operations completed until then; see the errors by class")
+}
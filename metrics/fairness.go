@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// StorageLayout is how a backend stored the records of a run
+type StorageLayout struct {
+	KeyEncoding   string `json:"key_encoding,omitempty"`    // empty if not known
+	ValueBytes    int64  `json:"value_bytes,omitempty"`     // longest value written, all fields; 0 if not known
+	MaxValueBytes int    `json:"max_value_bytes,omitempty"` // bytes kept of each value, 0 for all
+}
+
+// Truncated is whether the backend kept only part of the values
+func (l *StorageLayout) Truncated() bool {
+	return l.MaxValueBytes > 0 && l.ValueBytes > int64(l.MaxValueBytes)
+}
+
+// values describes the values stored, e.g. "100 B kept whole"
+func (l *StorageLayout) values() string {
+	switch {
+	case l.ValueBytes == 0 && l.MaxValueBytes > 0:
+		return "first " + formatBytes(int64(l.MaxValueBytes)) + " kept"
+	case l.ValueBytes == 0:
+		return "kept whole"
+	case l.Truncated():
+		return formatBytes(l.ValueBytes) + " cut to " + formatBytes(int64(l.MaxValueBytes))
+	}
+	return formatBytes(l.ValueBytes) + " kept whole"
+}
+
+// Capabilities are what a backend can do and how it does it, as registered
+// by its driver
+type Capabilities struct {
+	Scan         bool   `json:"scan"`
+	PrefixScan   bool   `json:"prefix_scan"`
+	PartialRead  bool   `json:"partial_read"`
+	Sync         string `json:"sync,omitempty"`
+	Transactions string `json:"transactions,omitempty"`
+	Batching     string `json:"batching,omitempty"`
+}
+
+// capabilityRow is one row of the capability matrix: its value for each
+// backend, and whether the operations of the run depend on it
+type capabilityRow struct {
+	name   string
+	values []string
+	used   bool
+}
+
+// capabilityRows builds the capability matrix of backends
+func capabilityRows(backends []BackendResults) []capabilityRow {
+	ran := func(match func(op string) bool) bool {
+		for _, b := range backends {
+			for _, m := range b.Results {
+				if match(m.Operation) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	is := func(ops ...string) func(string) bool {
+		return func(op string) bool { return slices.Contains(ops, op) }
+	}
+	yesNo := func(v bool) string {
+		if v {
+			return "yes"
+		}
+		return "no"
+	}
+	capability := func(f func(c *Capabilities) string) []string {
+		values := make([]string, len(backends))
+		for i, b := range backends {
+			values[i] = "unknown"
+			if b.Capabilities != nil {
+				values[i] = f(b.Capabilities)
+			}
+		}
+		return values
+	}
+	layout := func(f func(l *StorageLayout) string) []string {
+		values := make([]string, len(backends))
+		for i, b := range backends {
+			values[i] = "unknown"
+			if b.Layout != nil {
+				values[i] = f(b.Layout)
+			}
+		}
+		return values
+	}
+
+	return []capabilityRow{
+		{"Range scans", capability(func(c *Capabilities) string { return yesNo(c.Scan) }), ran(is("SCAN"))},
+		{"Prefix scans", capability(func(c *Capabilities) string { return yesNo(c.PrefixScan) }), ran(is("PREFIX_SCAN"))},
+		{"Partial reads", capability(func(c *Capabilities) string {
+			if c.PartialRead {
+				return "yes"
+			}
+			return "no, whole value"
+		}), ran(is("PARTIAL_READ"))},
+		{"Sync", capability(func(c *Capabilities) string { return c.Sync }), true},
+		{"Transactions", capability(func(c *Capabilities) string { return c.Transactions }), ran(is("RMW", "TXN"))},
+		{"Batching", capability(func(c *Capabilities) string { return c.Batching }),
+			ran(func(op string) bool { return strings.HasPrefix(op, "BATCH_") })},
+		{"Keys", layout(func(l *StorageLayout) string {
+			if l.KeyEncoding == "" {
+				return "unknown"
+			}
+			return l.KeyEncoding
+		}), true},
+		{"Values", layout((*StorageLayout).values), true},
+	}
+}
+
+// printFairnessAudit prints what each backend can do and how it stored the
+// records, and warns where they differ in what the run used, so a
+// comparison of semantically different work does not go unnoticed
+func printFairnessAudit(backends []BackendResults) {
+	if !slices.ContainsFunc(backends, func(b BackendResults) bool { return b.Capabilities != nil || b.Layout != nil }) {
+		return
+	}
+	rows := capabilityRows(backends)
+
+	widths := make([]int, len(backends))
+	for i, b := range backends {
+		widths[i] = utf8.RuneCountInString(b.Name)
+		for _, row := range rows {
+			widths[i] = max(widths[i], utf8.RuneCountInString(row.values[i]))
+		}
+	}
+	tableWidth := 2 + 13 + 3 + 1
+	for _, w := range widths {
+		tableWidth += w + 3
+	}
+
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "BACKEND CAPABILITIES"
+	fmt.Println(strings.Repeat(" ", max(0, (tableWidth-len(title))/2)) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	line := func(name string, values []string, mark string) {
+		fmt.Printf("│ %-13s │", name)
+		for i, v := range values {
+			fmt.Printf(" %-*s │", widths[i], v)
+		}
+		fmt.Println(mark)
+	}
+	names := make([]string, len(backends))
+	for i, b := range backends {
+		names[i] = b.Name
+	}
+	line("Capability", names, "")
+	fmt.Println(strings.Repeat("─", tableWidth))
+
+	var differ []string
+	for _, row := range rows {
+		mark := ""
+		if row.used && slices.ContainsFunc(row.values, func(v string) bool { return v != row.values[0] }) {
+			mark = " ≠"
+			differ = append(differ, strings.ToLower(row.name))
+		}
+		line(row.name, row.values, mark)
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	if len(differ) == 0 {
+		fmt.Println("Like for like: the backends agree on everything this run used")
+		return
+	}
+	fmt.Printf("Warning: the backends differ in %s (≠), which this run used: they did not run the same work\n",
+		strings.Join(differ, ", "))
+	if slices.Contains(differ, "keys") {
+		fmt.Println("  Set key_encoding to store keys alike")
+	}
+	for _, b := range backends {
+		if b.Layout != nil && b.Layout.Truncated() {
+			fmt.Printf("  %s keeps only part of each value: set value_parity=true for values it keeps whole\n", b.Name)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/pprof/profile"
+)
+
+// Files GenerateFlameGraph writes
+const (
+	FoldedStacksFile = "cpu.folded"
+	FlameGraphFile   = "flamegraph.svg"
+)
+
+// Layout of the flame graph, in pixels
+const (
+	flameWidth       = 1200
+	flameFrameHeight = 16
+	flamePadTop      = 40
+	flamePadSide     = 10
+	flameMinWidth    = 0.1 // narrower frames are left out
+	flameCharWidth   = 7   // of the 12px monospace labels
+)
+
+// flameNode is a frame of the merged call tree
+type flameNode struct {
+	name     string
+	value    int64 // samples in this frame and below
+	children map[string]*flameNode
+}
+
+// child returns the child frame named name, creating it if needed
+func (n *flameNode) child(name string) *flameNode {
+	if c, ok := n.children[name]; ok {
+		return c
+	}
+	if n.children == nil {
+		n.children = make(map[string]*flameNode)
+	}
+	c := &flameNode{name: name}
+	n.children[name] = c
+	return c
+}
+
+// sorted returns the children in name order, as flamegraph.pl lays them out
+func (n *flameNode) sorted() []*flameNode {
+	children := make([]*flameNode, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].name < children[j].name })
+	return children
+}
+
+// depth returns the number of frames on the deepest stack below n
+func (n *flameNode) depth() int {
+	d := 0
+	for _, c := range n.children {
+		d = max(d, c.depth()+1)
+	}
+	return d
+}
+
+// GenerateFlameGraph reads the CPU profile at profilePath and writes its
+// stacks to outputDir, folded (one "root;...;leaf count" line per stack, the
+// input of flamegraph.pl, speedscope and inferno) and as a self-contained SVG
+// flame graph. It returns the files written.
+func GenerateFlameGraph(profilePath, outputDir string) ([]string, error) {
+	f, err := os.Open(profilePath)
+	if err != nil {
+		return nil, err
+	}
+	p, err := profile.Parse(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", profilePath, err)
+	}
+	folded := foldStacks(p)
+	if len(folded) == 0 {
+		return nil, fmt.Errorf("%s has no samples", profilePath)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+	foldedPath := filepath.Join(outputDir, FoldedStacksFile)
+	if err := writeFoldedStacks(foldedPath, folded); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", foldedPath, err)
+	}
+	svgPath := filepath.Join(outputDir, FlameGraphFile)
+	if err := writeFlameGraphSVG(svgPath, folded); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", svgPath, err)
+	}
+	return []string{foldedPath, svgPath}, nil
+}
+
+// foldStacks returns the sample count of each distinct stack of p, keyed by
+// its frames from the root to the leaf separated by semicolons. Inlined
+// calls are frames of their own.
+func foldStacks(p *profile.Profile) map[string]int64 {
+	// CPU profiles count samples first, then CPU time
+	index := 0
+	for i, st := range p.SampleType {
+		if st.Type == "samples" {
+			index = i
+			break
+		}
+	}
+
+	folded := make(map[string]int64)
+	var frames []string
+	for _, s := range p.Sample {
+		if index >= len(s.Value) || s.Value[index] == 0 {
+			continue
+		}
+		frames = frames[:0]
+		// Locations run from the leaf up, and so do the lines of inlined
+		// calls within a location
+		for i := len(s.Location) - 1; i >= 0; i-- {
+			loc := s.Location[i]
+			if len(loc.Line) == 0 {
+				frames = append(frames, fmt.Sprintf("0x%x", loc.Address))
+				continue
+			}
+			for j := len(loc.Line) - 1; j >= 0; j-- {
+				name := "?"
+				if fn := loc.Line[j].Function; fn != nil {
+					name = fn.Name
+				}
+				// Semicolons separate frames in the folded format
+				frames = append(frames, strings.ReplaceAll(name, ";", ":"))
+			}
+		}
+		if len(frames) > 0 {
+			folded[strings.Join(frames, ";")] += s.Value[index]
+		}
+	}
+	return folded
+}
+
+// writeFoldedStacks writes one "stack count" line per stack, in stack order
+func writeFoldedStacks(path string, folded map[string]int64) error {
+	stacks := make([]string, 0, len(folded))
+	for stack := range folded {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, stack := range stacks {
+		fmt.Fprintf(w, "%s %d\n", stack, folded[stack])
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeFlameGraphSVG draws the stacks as a flame graph: the root at the
+// bottom, callees above their callers and each frame as wide as its share of
+// the samples. Hovering a frame shows its name and samples.
+func writeFlameGraphSVG(path string, folded map[string]int64) error {
+	root := &flameNode{name: "all"}
+	for stack, count := range folded {
+		root.value += count
+		n := root
+		for _, frame := range strings.Split(stack, ";") {
+			n = n.child(frame)
+			n.value += count
+		}
+	}
+
+	depth := root.depth() + 1
+	height := flamePadTop + depth*flameFrameHeight + flamePadSide
+	scale := float64(flameWidth-2*flamePadSide) / float64(root.value)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" standalone="no"?>`+"\n")
+	fmt.Fprintf(&b, `<svg version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`+"\n",
+		flameWidth, height, flameWidth, height)
+	fmt.Fprintf(&b, `<style>text { font-family: monospace; font-size: 12px; fill: #000; } rect:hover { stroke: #000; stroke-width: 0.5; }</style>`+"\n")
+	fmt.Fprintf(&b, `<rect x="0" y="0" width="100%%" height="100%%" fill="#f8f8f8"/>`+"\n")
+	fmt.Fprintf(&b, `<text x="%d" y="24" text-anchor="middle" style="font-size: 17px">CPU Flame Graph (measured window, %d samples)</text>`+"\n",
+		flameWidth/2, root.value)
+
+	var draw func(n *flameNode, x float64, level int)
+	draw = func(n *flameNode, x float64, level int) {
+		w := float64(n.value) * scale
+		if w < flameMinWidth {
+			return
+		}
+		y := height - flamePadSide - (level+1)*flameFrameHeight
+		name := html.EscapeString(n.name)
+		fmt.Fprintf(&b, `<g><title>%s (%d samples, %.2f%%)</title>`, name, n.value, 100*float64(n.value)/float64(root.value))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" rx="2"/>`,
+			x, y, w, flameFrameHeight-1, flameColor(n.name))
+		// Labels are cut to the frame, as long as a few characters fit
+		if chars := int(w-6) / flameCharWidth; chars >= 3 {
+			label := n.name
+			if len(label) > chars {
+				label = label[:chars-2] + ".."
+			}
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d">%s</text>`, x+3, y+flameFrameHeight-4, html.EscapeString(label))
+		}
+		b.WriteString("</g>\n")
+		for _, c := range n.sorted() {
+			draw(c, x, level+1)
+			x += float64(c.value) * scale
+		}
+	}
+	draw(root, flamePadSide, 0)
+	b.WriteString("</svg>\n")
+
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// flameColor returns a warm color derived from the frame name, so the same
+// function has the same color in every flame graph
+func flameColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	v := h.Sum32()
+	r := 205 + v%50
+	g := 80 + (v>>8)%150
+	bl := 40 + (v>>16)%50
+	return fmt.Sprintf("rgb(%d,%d,%d)", r, g, bl)
+}
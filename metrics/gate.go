@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// gateMetric is a metric the regression gate can track
+type gateMetric struct {
+	higherIsBetter bool
+	run            func(OperationMetrics) float64
+	aggregate      func(RunAggregate) MetricSummary
+}
+
+// gateMetrics are the trackable metrics, named as in the json and csv output
+var gateMetrics = map[string]gateMetric{
+	"ops":     {true, func(m OperationMetrics) float64 { return m.OPS }, func(a RunAggregate) MetricSummary { return a.OPS }},
+	"avg_us":  {false, func(m OperationMetrics) float64 { return float64(m.Avg) }, func(a RunAggregate) MetricSummary { return a.Avg }},
+	"p50_us":  {false, func(m OperationMetrics) float64 { return float64(m.P50) }, func(a RunAggregate) MetricSummary { return a.P50 }},
+	"p99_us":  {false, func(m OperationMetrics) float64 { return float64(m.P99) }, func(a RunAggregate) MetricSummary { return a.P99 }},
+	"p999_us": {false, func(m OperationMetrics) float64 { return float64(m.P999) }, func(a RunAggregate) MetricSummary { return a.P999 }},
+}
+
+// GateMetricNames returns the metrics the regression gate can track, sorted
+func GateMetricNames() []string {
+	names := make([]string, 0, len(gateMetrics))
+	for name := range gateMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Gate check statuses. A change beyond the threshold that the significance
+// test cannot tell from run-to-run noise is insignificant and passes.
+const (
+	GatePass          = "pass"
+	GateRegressed     = "regressed"
+	GateMissing       = "missing"
+	GateInsignificant = "insignificant"
+)
+
+// failed reports whether the check fails the gate
+func (c GateCheck) failed() bool {
+	return c.Status == GateRegressed || c.Status == GateMissing
+}
+
+// GateCheck is the comparison of one metric of one operation
+type GateCheck struct {
+	Group     string   `json:"group"` // run or aggregate name
+	Operation string   `json:"operation"`
+	Metric    string   `json:"metric"`
+	Baseline  float64  `json:"baseline"`
+	Current   float64  `json:"current"`
+	Change    float64  `json:"change"`            // relative regression; negative is an improvement
+	Threshold float64  `json:"threshold"`         // largest allowed change
+	PValue    *float64 `json:"p_value,omitempty"` // one-sided Mann-Whitney U p-value of a regression, when tested
+	Status    string   `json:"status"`
+}
+
+// GateReport is the outcome of comparing a run against a baseline
+type GateReport struct {
+	Passed   bool        `json:"passed"`
+	Source   string      `json:"source"` // "aggregates" or "runs"
+	Checks   []GateCheck `json:"checks"`
+	Warnings []string    `json:"warnings,omitempty"`
+}
+
+// Regressions returns the checks that failed
+func (g *GateReport) Regressions() []GateCheck {
+	var failed []GateCheck
+	for _, c := range g.Checks {
+		if c.failed() {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// ComparePerformance checks every tracked metric of current against baseline.
+// thresholds maps metric names to the largest allowed relative regression,
+// e.g. 0.05 for 5%. Cross-run aggregates are compared when both reports have
+// them, otherwise runs are matched by name. Operations missing from current
+// fail the gate. When alpha is positive and both aggregates hold enough runs
+// for the test to ever reach it, a change beyond the threshold only fails if
+// a one-sided Mann-Whitney U test of the per-run values finds it significant
+// at level alpha. With fewer runs it fails on the threshold alone, with a
+// warning.
+func ComparePerformance(baseline, current *Report, thresholds map[string]float64, alpha float64) (*GateReport, error) {
+	metricNames := make([]string, 0, len(thresholds))
+	for name := range thresholds {
+		if _, ok := gateMetrics[name]; !ok {
+			return nil, fmt.Errorf("unknown metric %q (expected one of %s)", name, strings.Join(GateMetricNames(), ", "))
+		}
+		metricNames = append(metricNames, name)
+	}
+	sort.Strings(metricNames)
+
+	report := &GateReport{Passed: true}
+	check := func(group, operation, metric string, base, cur MetricSummary, found bool) {
+		c := GateCheck{
+			Group:     group,
+			Operation: operation,
+			Metric:    metric,
+			Baseline:  base.Mean,
+			Current:   cur.Mean,
+			Threshold: thresholds[metric],
+			Status:    GatePass,
+		}
+		switch {
+		case !found:
+			c.Status = GateMissing
+		case base.Mean != 0:
+			c.Change = (cur.Mean - base.Mean) / base.Mean
+			if gateMetrics[metric].higherIsBetter {
+				c.Change = (base.Mean - cur.Mean) / base.Mean
+			}
+			if c.Change > c.Threshold {
+				c.Status = GateRegressed
+				n1, n2 := len(cur.Values), len(base.Values)
+				if alpha > 0 && n1 > 1 && n2 > 1 && !mannWhitneyCanReach(n1, n2, alpha) {
+					warning := fmt.Sprintf("too few runs for significance at alpha %g: %d current and %d baseline runs give p of at least %.4f, so regressions fail on their threshold alone",
+						alpha, n1, n2, 1/binomial(n1+n2, n1))
+					if !slices.Contains(report.Warnings, warning) {
+						report.Warnings = append(report.Warnings, warning)
+					}
+				} else if alpha > 0 && n1 > 1 && n2 > 1 {
+					// Regressed means current tends to be worse than baseline
+					alternative := Greater
+					if gateMetrics[metric].higherIsBetter {
+						alternative = Less
+					}
+					p := MannWhitneyU(cur.Values, base.Values, alternative).P
+					c.PValue = &p
+					if p > alpha {
+						c.Status = GateInsignificant
+					}
+				}
+			}
+		}
+		if c.failed() {
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, c)
+	}
+
+	if len(baseline.Aggregates) > 0 && len(current.Aggregates) > 0 {
+		report.Source = "aggregates"
+		for _, group := range baseline.Aggregates {
+			var cur []RunAggregate
+			for _, g := range current.Aggregates {
+				if g.Name == group.Name {
+					cur = g.Operations
+				}
+			}
+			for _, base := range group.Operations {
+				var match *RunAggregate
+				for i := range cur {
+					if cur[i].Operation == base.Operation {
+						match = &cur[i]
+					}
+				}
+				for _, metric := range metricNames {
+					var value MetricSummary
+					if match != nil {
+						value = gateMetrics[metric].aggregate(*match)
+					}
+					check(group.Name, base.Operation, metric, gateMetrics[metric].aggregate(base), value, match != nil)
+				}
+			}
+		}
+		return report, nil
+	}
+
+	report.Source = "runs"
+	for _, run := range baseline.Runs {
+		var cur []OperationMetrics
+		for _, r := range current.Runs {
+			if r.Name == run.Name && r.DB == run.DB {
+				cur = r.Operations
+			}
+		}
+		for _, base := range run.Operations {
+			match, found := FindMetrics(cur, base.Operation)
+			for _, metric := range metricNames {
+				var value MetricSummary
+				if found {
+					value.Mean = gateMetrics[metric].run(match)
+				}
+				check(run.Name, base.Operation, metric, MetricSummary{Mean: gateMetrics[metric].run(base)}, value, found)
+			}
+		}
+	}
+	return report, nil
+}
+
+// PrintGateReport prints every check, regressions first
+func PrintGateReport(g *GateReport) {
+	checks := append([]GateCheck(nil), g.Checks...)
+	sort.SliceStable(checks, func(i, j int) bool {
+		return checks[i].failed() && !checks[j].failed()
+	})
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := fmt.Sprintf("REGRESSION GATE (comparing %s)", g.Source)
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-16s │ %-12s │ %-8s │ %12s │ %12s │ %9s │ %9s │ %7s │ %-13s │\n",
+		"Run", "Operation", "Metric", "Baseline", "Current", "Change", "Threshold", "p", "Status")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, c := range checks {
+		current, change := fmt.Sprintf("%.1f", c.Current), fmt.Sprintf("%+.2f%%", 100*c.Change)
+		if c.Status == GateMissing {
+			current, change = "-", "-"
+		}
+		p := "-"
+		if c.PValue != nil {
+			p = fmt.Sprintf("%.4f", *c.PValue)
+		}
+		fmt.Printf("│ %-16s │ %-12s │ %-8s │ %12.1f │ %12s │ %9s │ %8.2f%% │ %7s │ %-13s │\n",
+			c.Group, c.Operation, c.Metric, c.Baseline, current, change, 100*c.Threshold, p, strings.ToUpper(c.Status))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	insignificant := 0
+	for _, c := range g.Checks {
+		if c.Status == GateInsignificant {
+			insignificant++
+		}
+	}
+	if insignificant > 0 {
+		fmt.Printf("%d changes beyond their threshold were not statistically significant\n", insignificant)
+	}
+	for _, w := range g.Warnings {
+		fmt.Printf("Warning: %s\n", w)
+	}
+	if g.Passed {
+		fmt.Printf("PASSED: %d checks within thresholds\n", len(g.Checks))
+	} else {
+		fmt.Printf("FAILED: %d of %d checks regressed or missing\n", len(g.Regressions()), len(g.Checks))
+	}
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import "testing"
+
+// gateReport returns a report aggregating one READ run per throughput
+func gateReport(ops ...float64) *Report {
+	var runs [][]OperationMetrics
+	for _, v := range ops {
+		runs = append(runs, []OperationMetrics{{Operation: "READ", OPS: v}})
+	}
+	r := &Report{}
+	r.AddAggregate("run", runs)
+	return r
+}
+
+func TestComparePerformanceTooFewRuns(t *testing.T) {
+	g, err := ComparePerformance(gateReport(1000, 1010), gateReport(800, 810), map[string]float64{"ops": 0.05}, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Passed || len(g.Checks) != 1 || g.Checks[0].Status != GateRegressed {
+		t.Fatalf("got %+v, want a failed gate with one regressed check", g)
+	}
+	if g.Checks[0].PValue != nil {
+		t.Errorf("p = %g, want no test with two runs a side", *g.Checks[0].PValue)
+	}
+	if len(g.Warnings) != 1 {
+		t.Errorf("warnings = %q, want one", g.Warnings)
+	}
+}
+
+func TestComparePerformanceSignificant(t *testing.T) {
+	g, err := ComparePerformance(gateReport(1000, 1010, 1020), gateReport(800, 810, 820), map[string]float64{"ops": 0.05}, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := g.Checks[0]
+	if g.Passed || c.Status != GateRegressed || c.PValue == nil || *c.PValue > 0.05 {
+		t.Fatalf("got %+v, want a regression significant at 0.05", c)
+	}
+	if len(g.Warnings) != 0 {
+		t.Errorf("warnings = %q, want none", g.Warnings)
+	}
+}
+
+func TestComparePerformanceInsignificant(t *testing.T) {
+	// The means are over 5% apart, but the runs overlap
+	g, err := ComparePerformance(gateReport(1000, 700, 1300), gateReport(900, 600, 1200), map[string]float64{"ops": 0.05}, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := g.Checks[0]; !g.Passed || c.Status != GateInsignificant {
+		t.Fatalf("got %+v, want an insignificant change that passes", c)
+	}
+}
+
+func TestComparePerformanceMissing(t *testing.T) {
+	current := &Report{}
+	current.AddAggregate("run", [][]OperationMetrics{{{Operation: "UPDATE", OPS: 1000}}})
+	g, err := ComparePerformance(gateReport(1000), current, map[string]float64{"ops": 0.05}, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Passed || g.Checks[0].Status != GateMissing {
+		t.Fatalf("got %+v, want a missing operation to fail", g.Checks[0])
+	}
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+// Key sets of HotSetStatistics
+const (
+	HotKeys  = "hot"
+	ColdKeys = "cold"
+)
+
+// HotSetStatistics summarizes the successful operations of one kind on the
+// hot set or on the other, cold keys
+type HotSetStatistics struct {
+	Operation string  `json:"operation"`
+	Keys      string  `json:"keys"` // HotKeys or ColdKeys
+	Count     int64   `json:"count"`
+	Share     float64 `json:"share"` // fraction of the operation's count
+	Mean      float64 `json:"mean_us"`
+	P50       float64 `json:"p50_us"`
+	P99       float64 `json:"p99_us"`
+	Max       float64 `json:"max_us"`
+}
+
+// hotSet splits the latencies of single-key operations by whether their key
+// is one of the hot ones, after the warm-up
+type hotSet struct {
+	keys map[string]bool
+
+	mu         sync.Mutex
+	histograms map[string]*[2]*hdrhistogram.Histogram // operation -> cold, hot; microseconds
+}
+
+func newHotSet(keys []string) *hotSet {
+	h := &hotSet{keys: make(map[string]bool, len(keys)), histograms: make(map[string]*[2]*hdrhistogram.Histogram)}
+	for _, key := range keys {
+		h.keys[key] = true
+	}
+	return h
+}
+
+// record adds one successful operation on key
+func (h *hotSet) record(op string, key string, latency time.Duration) {
+	if !measurement.IsWarmUpFinished() {
+		return
+	}
+	set := 0
+	if h.keys[key] {
+		set = 1
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hists, ok := h.histograms[op]
+	if !ok {
+		hists = new([2]*hdrhistogram.Histogram)
+		h.histograms[op] = hists
+	}
+	if hists[set] == nil {
+		hists[set] = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	}
+	hists[set].RecordValue(max(latency.Microseconds(), 1))
+}
+
+// statistics returns the hot and cold rows of every operation, ordered by
+// operation
+func (h *hotSet) statistics() []HotSetStatistics {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	operations := make([]string, 0, len(h.histograms))
+	for op := range h.histograms {
+		operations = append(operations, op)
+	}
+	sort.Strings(operations)
+
+	var result []HotSetStatistics
+	for _, op := range operations {
+		hists := h.histograms[op]
+		var total int64
+		for _, hist := range hists {
+			if hist != nil {
+				total += hist.TotalCount()
+			}
+		}
+		for set, name := range []string{ColdKeys, HotKeys} {
+			hist := hists[set]
+			if hist == nil {
+				continue
+			}
+			result = append(result, HotSetStatistics{
+				Operation: op,
+				Keys:      name,
+				Count:     hist.TotalCount(),
+				Share:     float64(hist.TotalCount()) / float64(total),
+				Mean:      hist.Mean(),
+				P50:       float64(hist.ValueAtPercentile(50)),
+				P99:       float64(hist.ValueAtPercentile(99)),
+				Max:       float64(hist.Max()),
+			})
+		}
+	}
+	return result
+}
+
+// SetHotKeys splits the latencies of operations on keys from those on other
+// keys, see HotSetStatistics. It must be called before the run starts.
+func (ot *OperationTracker) SetHotKeys(keys []string) {
+	ot.hotSet = newHotSet(keys)
+}
+
+// HotSetStatistics returns the latencies of operations on the hot keys and
+// on the others, or nil without hot keys
+func (ot *OperationTracker) HotSetStatistics() []HotSetStatistics {
+	if ot.hotSet == nil {
+		return nil
+	}
+	return ot.hotSet.statistics()
+}
+
+// PrintHotSetStatistics prints the hot and cold rows of every operation
+func PrintHotSetStatistics(all []HotSetStatistics) {
+	if len(all) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "HOT VS COLD KEYS"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-16s │ %8s │ %12s │ %8s │ %14s │ %14s │ %14s │ %14s │\n",
+		"Operation", "Keys", "Count", "Share", "Mean", "p50", "p99", "Max")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for i, s := range all {
+		if i > 0 && all[i-1].Operation != s.Operation {
+			fmt.Println(strings.Repeat("─", tableWidth))
+		}
+		fmt.Printf("│ %-16s │ %8s │ %12d │ %7.2f%% │ %14s │ %14s │ %14s │ %14s │\n",
+			s.Operation, s.Keys, s.Count, 100*s.Share,
+			formatDuration(s.Mean), formatDuration(s.P50), formatDuration(s.P99), formatDuration(s.Max))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
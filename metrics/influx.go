@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Measurements the InfluxDB reporter writes
+const (
+	InfluxIntervalMeasurement = "godb_bench_interval" // one point per operation and series interval
+	InfluxResultMeasurement   = "godb_bench_result"   // one point per operation at the end of the run
+)
+
+// influxBatchSize is the number of lines per write request, as InfluxDB
+// recommends
+const influxBatchSize = 5000
+
+// InfluxReporter writes the results of a run to InfluxDB in line protocol
+// through the /api/v2/write endpoint, which InfluxDB 2 and 3 serve and 1.8
+// emulates (with the bucket as database/retention-policy and the token as
+// user:password). Every point is tagged with the run ID and name, the
+// backend, the workload and the operation.
+type InfluxReporter struct {
+	URL    string // base URL of the server, e.g. http://localhost:8086
+	Org    string
+	Bucket string
+	Token  string
+	Client *http.Client
+}
+
+// NewInfluxReporter creates a reporter writing to bucket of org on the server
+// at serverURL. A URL without a scheme is taken as plain HTTP.
+func NewInfluxReporter(serverURL, org, bucket, token string) *InfluxReporter {
+	if !strings.Contains(serverURL, "://") {
+		serverURL = "http://" + serverURL
+	}
+	return &InfluxReporter{
+		URL:    strings.TrimSuffix(serverURL, "/"),
+		Org:    org,
+		Bucket: bucket,
+		Token:  token,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// influxTagEscaper escapes tag keys and values; measurement names only need
+// commas and spaces escaped, which this also covers
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+
+// influxLine builds one line of line protocol
+type influxLine struct {
+	b      strings.Builder
+	fields int
+}
+
+// newInfluxLine starts a line of measurement with tags, leaving out empty
+// ones, in key order as InfluxDB prefers
+func newInfluxLine(measurement string, tags map[string]string) *influxLine {
+	l := &influxLine{}
+	l.b.WriteString(influxTagEscaper.Replace(measurement))
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		l.b.WriteString("," + influxTagEscaper.Replace(k) + "=" + influxTagEscaper.Replace(tags[k]))
+	}
+	return l
+}
+
+func (l *influxLine) field(key, value string) *influxLine {
+	if l.fields == 0 {
+		l.b.WriteByte(' ')
+	} else {
+		l.b.WriteByte(',')
+	}
+	l.fields++
+	l.b.WriteString(influxTagEscaper.Replace(key) + "=" + value)
+	return l
+}
+
+func (l *influxLine) int(key string, v int64) *influxLine {
+	return l.field(key, strconv.FormatInt(v, 10)+"i")
+}
+
+func (l *influxLine) float(key string, v float64) *influxLine {
+	return l.field(key, strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// end finishes the line with its timestamp in nanoseconds
+func (l *influxLine) end(t time.Time) string {
+	return l.b.String() + " " + strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// influxTags returns the tags of every point of run
+func influxTags(run Results, operation string) map[string]string {
+	return map[string]string{
+		"run_id":    run.ID,
+		"run":       run.Name,
+		"db":        run.DB,
+		"workload":  workloadOf(run),
+		"host":      run.Environment.Hostname,
+		"operation": operation,
+	}
+}
+
+// InfluxLines returns the line protocol of run, which finished at end: a
+// point per operation and series interval, timestamped at the interval's
+// start, and a point per operation with its final metrics at end
+func InfluxLines(run Results, end time.Time) []string {
+	var lines []string
+	for _, s := range run.Series {
+		if s.Start.IsZero() {
+			continue
+		}
+		tags := influxTags(run, s.Operation)
+		for _, p := range s.Points {
+			at := s.Start.Add(time.Duration(p.Start * float64(time.Second)))
+			lines = append(lines, newInfluxLine(InfluxIntervalMeasurement, tags).
+				int("count", p.Count).int("errors", p.Errors).float("ops", p.OPS).
+				float("mean_us", p.Mean).int("p50_us", p.P50).int("p90_us", p.P90).
+				int("p99_us", p.P99).int("max_us", p.Max).end(at))
+		}
+	}
+
+	for _, m := range run.Operations {
+		if strings.HasSuffix(m.Operation, "_ERROR") {
+			continue // failures are counted on the operation
+		}
+		lines = append(lines, influxResultLine(influxTags(run, m.Operation), m).end(end))
+		if m.Corrected != nil {
+			tags := influxTags(run, m.Operation)
+			tags["corrected"] = "true"
+			lines = append(lines, influxResultLine(tags, *m.Corrected).end(end))
+		}
+	}
+	return lines
+}
+
+// influxResultLine returns the final metrics of one operation, without a
+// timestamp
+func influxResultLine(tags map[string]string, m OperationMetrics) *influxLine {
+	l := newInfluxLine(InfluxResultMeasurement, tags).
+		int("count", m.Count).float("ops", m.OPS).int("avg_us", m.Avg).
+		int("min_us", m.Min).int("max_us", m.Max).int("p50_us", m.P50).
+		int("p90_us", m.P90).int("p95_us", m.P95).int("p99_us", m.P99).
+		int("p999_us", m.P999).int("errors", m.Errors).float("error_rate", m.ErrorRate)
+	for _, label := range sortedKeys(m.Percentiles) {
+		l.int(label+"_us", m.Percentiles[label])
+	}
+	return l
+}
+
+// Report writes the series and final metrics of run, which finished at end
+func (r *InfluxReporter) Report(ctx context.Context, run Results, end time.Time) error {
+	lines := InfluxLines(run, end)
+	for len(lines) > 0 {
+		n := min(len(lines), influxBatchSize)
+		if err := r.write(ctx, lines[:n]); err != nil {
+			return err
+		}
+		lines = lines[n:]
+	}
+	return nil
+}
+
+// write sends one batch of lines
+func (r *InfluxReporter) write(ctx context.Context, lines []string) error {
+	query := url.Values{"bucket": {r.Bucket}, "precision": {"ns"}}
+	if r.Org != "" {
+		query.Set("org", r.Org)
+	}
+	endpoint := r.URL + "/api/v2/write?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBufferString(strings.Join(lines, "\n")))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Token "+r.Token)
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to %s: %w", r.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to write to %s: %s: %s", r.URL, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
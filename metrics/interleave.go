@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InterleavedChunk is one chunk of consecutive operations of an interleaved
+// A/B run, all of which ran on one side
+type InterleavedChunk struct {
+	Index      int64 // even chunks ran on A, odd ones on B
+	Ops        int64 // failed ones included
+	Errors     int64
+	Latency    time.Duration // total of every operation
+	Start, End time.Time
+}
+
+// InterleavedSide summarizes the chunks one side ran
+type InterleavedSide struct {
+	Name   string        `json:"name"`
+	Chunks int           `json:"chunks"`
+	Errors int64         `json:"errors"`
+	OPS    MetricSummary `json:"ops"`    // throughput of each chunk
+	Avg    MetricSummary `json:"avg_us"` // mean latency of each chunk
+}
+
+// InterleavedComparison compares the sides of an interleaved run chunk by
+// chunk. Only full chunks count, and ratios pair every A chunk with the B
+// chunk right after it, so both ran under nearly the same conditions.
+type InterleavedComparison struct {
+	ChunkSize   int64           `json:"chunk_size"`
+	A           InterleavedSide `json:"a"`
+	B           InterleavedSide `json:"b"`
+	Pairs       int             `json:"pairs"`
+	OPSRatio    float64         `json:"ops_ratio"`    // median B/A throughput of the pairs
+	AvgRatio    float64         `json:"avg_ratio"`    // median B/A mean latency of the pairs
+	OPSTest     MannWhitney     `json:"ops_test"`     // B's chunk throughput against A's
+	LatencyTest MannWhitney     `json:"latency_test"` // B's chunk mean latency against A's
+}
+
+// CompareInterleaved compares the chunks of an interleaved run of chunkSize
+// operations per chunk, named a and b
+func CompareInterleaved(a, b string, chunkSize int64, chunks []InterleavedChunk) *InterleavedComparison {
+	c := &InterleavedComparison{ChunkSize: chunkSize}
+
+	full := make(map[int64]InterleavedChunk)
+	var ops, avg [2][]float64
+	var errors [2]int64
+	for _, chunk := range chunks {
+		span := chunk.End.Sub(chunk.Start)
+		if chunk.Ops != chunkSize || span <= 0 {
+			continue
+		}
+		full[chunk.Index] = chunk
+		side := chunk.Index % 2
+		ops[side] = append(ops[side], float64(chunk.Ops-chunk.Errors)/span.Seconds())
+		avg[side] = append(avg[side], float64(chunk.Latency.Microseconds())/float64(chunk.Ops))
+		errors[side] += chunk.Errors
+	}
+	c.A = InterleavedSide{Name: a, Chunks: len(ops[0]), Errors: errors[0], OPS: summarize(ops[0]), Avg: summarize(avg[0])}
+	c.B = InterleavedSide{Name: b, Chunks: len(ops[1]), Errors: errors[1], OPS: summarize(ops[1]), Avg: summarize(avg[1])}
+
+	var opsRatios, avgRatios []float64
+	for index, x := range full {
+		y, ok := full[index+1]
+		if index%2 != 0 || !ok {
+			continue
+		}
+		xOPS := float64(x.Ops-x.Errors) / x.End.Sub(x.Start).Seconds()
+		yOPS := float64(y.Ops-y.Errors) / y.End.Sub(y.Start).Seconds()
+		if xOPS > 0 {
+			opsRatios = append(opsRatios, yOPS/xOPS)
+		}
+		if x.Latency > 0 {
+			avgRatios = append(avgRatios, float64(y.Latency)/float64(x.Latency))
+		}
+	}
+	c.Pairs = len(opsRatios)
+	c.OPSRatio = median(opsRatios)
+	c.AvgRatio = median(avgRatios)
+	c.OPSTest = MannWhitneyU(ops[1], ops[0], TwoSided)
+	c.LatencyTest = MannWhitneyU(avg[1], avg[0], TwoSided)
+	return c
+}
+
+// median returns the median of values, or 0 if there are none
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return percentile(sorted, 50)
+}
+
+// PrintInterleavedComparison prints the chunk-by-chunk comparison of an
+// interleaved run
+func PrintInterleavedComparison(c *InterleavedComparison) {
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := fmt.Sprintf("INTERLEAVED COMPARISON (%d operations per chunk)", c.ChunkSize)
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	fmt.Printf("│ %-10s │ %-10s │ %8s │ %10s │ %12s │ %12s │ %12s │ %12s │ %10s │\n",
+		"Side", "Metric", "Chunks", "Errors", "Mean", "Std. Dev.", "Min", "Max", "CV")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for i, side := range []InterleavedSide{c.A, c.B} {
+		if i > 0 {
+			fmt.Println(strings.Repeat("─", tableWidth))
+		}
+		name := side.Name
+		for _, row := range []struct {
+			name string
+			s    MetricSummary
+		}{
+			{"OPS", side.OPS},
+			{"Avg(µs)", side.Avg},
+		} {
+			cv := "-"
+			if row.s.Mean != 0 {
+				cv = fmt.Sprintf("%.2f%%", 100*row.s.StdDev/row.s.Mean)
+			}
+			fmt.Printf("│ %-10s │ %-10s │ %8d │ %10d │ %12.1f │ %12.1f │ %12.1f │ %12.1f │ %10s │\n",
+				name, row.name, side.Chunks, side.Errors, row.s.Mean, row.s.StdDev, row.s.Min, row.s.Max, cv)
+			name = ""
+		}
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	if c.Pairs == 0 {
+		fmt.Println("No adjacent pair of full chunks: use a smaller chunk or a longer run")
+		return
+	}
+	fmt.Printf("Over %d adjacent chunk pairs, %s relative to %s:\n", c.Pairs, c.B.Name, c.A.Name)
+	fmt.Printf("  throughput    median %.3fx  (Mann-Whitney p=%.4f)\n", c.OPSRatio, c.OPSTest.P)
+	fmt.Printf("  mean latency  median %.3fx  (Mann-Whitney p=%.4f)\n", c.AvgRatio, c.LatencyTest.P)
+}
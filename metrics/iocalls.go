@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// IOCallStatistics summarizes one kind of filesystem call a backend made
+// during the measured window, such as its fsyncs
+type IOCallStatistics struct {
+	Call  string  `json:"call"`
+	Count int64   `json:"count"`
+	Bytes int64   `json:"bytes,omitempty"`
+	Mean  float64 `json:"mean_us"`
+	P50   float64 `json:"p50_us"`
+	P90   float64 `json:"p90_us"`
+	P99   float64 `json:"p99_us"`
+	P999  float64 `json:"p999_us"`
+	Max   float64 `json:"max_us"`
+}
+
+// NewIOCallStatistics summarizes count calls that moved bytes, with their
+// latency histogram in nanoseconds
+func NewIOCallStatistics(call string, count, bytes int64, latency *hdrhistogram.Histogram) IOCallStatistics {
+	us := func(ns int64) float64 { return float64(ns) / 1000 }
+	return IOCallStatistics{
+		Call:  call,
+		Count: count,
+		Bytes: bytes,
+		Mean:  latency.Mean() / 1000,
+		P50:   us(latency.ValueAtPercentile(50)),
+		P90:   us(latency.ValueAtPercentile(90)),
+		P99:   us(latency.ValueAtPercentile(99)),
+		P999:  us(latency.ValueAtPercentile(99.9)),
+		Max:   us(latency.Max()),
+	}
+}
+
+// PrintIOCalls prints the filesystem calls of a run
+func PrintIOCalls(calls []IOCallStatistics) {
+	if len(calls) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "FILESYSTEM CALLS"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-16s │ %11s │ %11s │ %10s │ %10s │ %10s │ %10s │ %10s │ %10s │\n",
+		"Call", "Count", "Bytes", "Mean", "p50", "p90", "p99", "p99.9", "Max")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, c := range calls {
+		bytes := "-"
+		if c.Bytes > 0 {
+			bytes = formatBytes(c.Bytes)
+		}
+		fmt.Printf("│ %-16s │ %11d │ %11s │ %10s │ %10s │ %10s │ %10s │ %10s │ %10s │\n",
+			c.Call, c.Count, bytes, formatDuration(c.Mean), formatDuration(c.P50), formatDuration(c.P90),
+			formatDuration(c.P99), formatDuration(c.P999), formatDuration(c.Max))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
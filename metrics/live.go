@@ -0,0 +1,209 @@
+package metrics
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+)
+
+// LiveWindow aggregates the operations completed since it was last read, for
+// reporting while the run is in progress. Unlike the Collector it also counts
+// the warm-up, so live views show the run from its first operation. Each
+// thread records into a shard of its own, folded into the window when it is
+// read, so threads do not wait on each other for every window.
+type LiveWindow struct {
+	mu    sync.Mutex
+	since time.Time
+	liveCounts
+	shards []*liveShard
+}
+
+// liveShard is the part of a window one thread records into
+type liveShard struct {
+	mu sync.Mutex
+	liveCounts
+}
+
+// liveCounts are the operations of a window or of a shard of it
+type liveCounts struct {
+	ops       map[string]*liveOp
+	lastErr   error
+	lastErrAt time.Time
+}
+
+// liveOp is the current window of one operation
+type liveOp struct {
+	hist     *hdrhistogram.Histogram // microseconds
+	sum      time.Duration
+	errors   int64
+	misses   int64
+	measured int64
+}
+
+// LiveOperation summarizes one operation over a window
+type LiveOperation struct {
+	Operation string
+	Count     int64
+	Errors    int64
+	Misses    int64   // of the errors, reads of a missing key
+	Measured  int64   // operations, failed ones included, after the warm-up, which go-ycsb counts
+	OPS       float64 // successful operations per second
+	Mean      float64 // microseconds
+	P50       int64
+	P99       int64
+	Max       int64
+}
+
+// LiveSnapshot is every operation over the window from Start to End, with the
+// TOTAL row, if any, last
+type LiveSnapshot struct {
+	Start, End time.Time
+	Operations []LiveOperation
+	LastError  string // the most recent failure of the window but a miss, if any
+}
+
+// NewLiveWindow creates a window starting now
+func NewLiveWindow() *LiveWindow {
+	return &LiveWindow{since: time.Now(), liveCounts: liveCounts{ops: make(map[string]*liveOp)}}
+}
+
+// newShard returns a shard of the window for one thread to record into
+func (w *LiveWindow) newShard() *liveShard {
+	s := &liveShard{liveCounts: liveCounts{ops: make(map[string]*liveOp)}}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.shards = append(w.shards, s)
+	return s
+}
+
+// record adds one operation to op and TOTAL of the window
+func (w *LiveWindow) record(op string, latency time.Duration, err error) {
+	measured := measurement.IsWarmUpFinished()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.add(op, latency, err, measured)
+}
+
+// record adds one operation to op and TOTAL of the shard
+func (s *liveShard) record(op string, latency time.Duration, err error) {
+	measured := measurement.IsWarmUpFinished()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(op, latency, err, measured)
+}
+
+// add counts one operation in op and TOTAL
+func (lc *liveCounts) add(op string, latency time.Duration, err error, measured bool) {
+	miss := errors.Is(err, godbdb.ErrNotFound)
+	if err != nil && !miss {
+		lc.lastErr, lc.lastErrAt = err, time.Now()
+	}
+	for _, name := range []string{op, "TOTAL"} {
+		o := lc.op(name)
+		if measured {
+			o.measured++
+		}
+		if err != nil {
+			o.errors++
+			if miss {
+				o.misses++
+			}
+			continue
+		}
+		o.hist.RecordValue(latency.Microseconds())
+		o.sum += latency
+	}
+}
+
+// op returns the counts of op, creating them on first use
+func (lc *liveCounts) op(op string) *liveOp {
+	o, ok := lc.ops[op]
+	if !ok {
+		o = &liveOp{hist: hdrhistogram.New(1, 24*60*60*1000*1000, 3)}
+		lc.ops[op] = o
+	}
+	return o
+}
+
+// fold adds the operations of every shard to the window and empties the
+// shards. Callers hold the lock.
+func (w *LiveWindow) fold() {
+	for _, s := range w.shards {
+		s.mu.Lock()
+		for name, from := range s.ops {
+			if from.hist.TotalCount() == 0 && from.errors == 0 && from.measured == 0 {
+				continue
+			}
+			o := w.op(name)
+			o.hist.Merge(from.hist)
+			o.sum += from.sum
+			o.errors += from.errors
+			o.misses += from.misses
+			o.measured += from.measured
+			from.reset()
+		}
+		if s.lastErr != nil && s.lastErrAt.After(w.lastErrAt) {
+			w.lastErr, w.lastErrAt = s.lastErr, s.lastErrAt
+		}
+		s.lastErr = nil
+		s.mu.Unlock()
+	}
+}
+
+// reset empties the counts for the next window
+func (o *liveOp) reset() {
+	o.hist.Reset()
+	o.sum, o.errors, o.misses, o.measured = 0, 0, 0, 0
+}
+
+// Take returns the operations since the previous Take, or since the window
+// was created, and starts a new window. Operations seen before stay in the
+// snapshot with a count of 0 when idle, so stalls show.
+func (w *LiveWindow) Take() LiveSnapshot {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.fold()
+
+	now := time.Now()
+	snapshot := LiveSnapshot{Start: w.since, End: now}
+	if w.lastErr != nil {
+		snapshot.LastError = w.lastErr.Error()
+	}
+	seconds := now.Sub(w.since).Seconds()
+	w.since, w.lastErr = now, nil
+
+	names := make([]string, 0, len(w.ops))
+	for op := range w.ops {
+		if op != "TOTAL" {
+			names = append(names, op)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := w.ops["TOTAL"]; ok {
+		names = append(names, "TOTAL")
+	}
+
+	for _, name := range names {
+		o := w.ops[name]
+		count := o.hist.TotalCount()
+		lo := LiveOperation{Operation: name, Count: count, Errors: o.errors, Misses: o.misses, Measured: o.measured}
+		if count > 0 {
+			lo.Mean = float64(o.sum.Nanoseconds()) / 1000 / float64(count)
+			lo.P50 = o.hist.ValueAtPercentile(50)
+			lo.P99 = o.hist.ValueAtPercentile(99)
+			lo.Max = o.hist.Max()
+		}
+		if seconds > 0 {
+			lo.OPS = float64(count) / seconds
+		}
+		snapshot.Operations = append(snapshot.Operations, lo)
+		o.reset()
+	}
+	return snapshot
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LSMLevel is one level of a backend's LSM tree at the end of a run, with
+// the bytes moved into it since the backend opened
+type LSMLevel struct {
+	Level         int     `json:"level"`
+	Sublevels     int     `json:"sublevels,omitempty"` // of L0
+	Files         int64   `json:"files"`
+	Size          int64   `json:"size_bytes"`
+	Score         float64 `json:"compaction_score"`
+	BytesIn       uint64  `json:"bytes_in"` // flushed or compacted into the level from above
+	BytesIngested uint64  `json:"bytes_ingested"`
+	BytesRead     uint64  `json:"bytes_read"`    // by compactions into the level
+	BytesWritten  uint64  `json:"bytes_written"` // by flushes and compactions
+}
+
+// WriteAmp is the bytes written to the level per byte that came into it, or
+// 0 if none did
+func (l LSMLevel) WriteAmp() float64 {
+	if l.BytesIn == 0 {
+		return 0
+	}
+	return float64(l.BytesWritten) / float64(l.BytesIn)
+}
+
+// Name returns the level's name, with the sublevels of L0
+func (l LSMLevel) Name() string {
+	name := fmt.Sprintf("L%d", l.Level)
+	switch {
+	case l.Sublevels == 1:
+		name += " (1 sublevel)"
+	case l.Sublevels > 1:
+		name += fmt.Sprintf(" (%d sublevels)", l.Sublevels)
+	}
+	return name
+}
+
+// PrintLSM prints the files, size, compaction score and bytes moved of every
+// level, and their totals
+func PrintLSM(levels []LSMLevel) {
+	if len(levels) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "LSM LEVELS"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-21s │ %8s │ %11s │ %7s │ %11s │ %11s │ %11s │ %11s │ %7s │\n",
+		"Level", "Files", "Size", "Score", "Bytes in", "Ingested", "Read", "Written", "W-Amp")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	var total LSMLevel
+	for _, l := range levels {
+		wamp := "-"
+		if l.BytesIn > 0 {
+			wamp = fmt.Sprintf("%.2f", l.WriteAmp())
+		}
+		fmt.Printf("│ %-21s │ %8d │ %11s │ %7.2f │ %11s │ %11s │ %11s │ %11s │ %7s │\n",
+			l.Name(), l.Files, formatBytes(l.Size), l.Score, formatBytes(int64(l.BytesIn)), formatBytes(int64(l.BytesIngested)),
+			formatBytes(int64(l.BytesRead)), formatBytes(int64(l.BytesWritten)), wamp)
+		total.Files += l.Files
+		total.Size += l.Size
+		total.BytesIngested += l.BytesIngested
+		total.BytesRead += l.BytesRead
+		total.BytesWritten += l.BytesWritten
+	}
+	fmt.Println(strings.Repeat("─", tableWidth))
+	fmt.Printf("│ %-21s │ %8d │ %11s │ %7s │ %11s │ %11s │ %11s │ %11s │ %7s │\n",
+		"Total", total.Files, formatBytes(total.Size), "-", "-", formatBytes(int64(total.BytesIngested)),
+		formatBytes(int64(total.BytesRead)), formatBytes(int64(total.BytesWritten)), "-")
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
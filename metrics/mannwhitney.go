@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Alternative hypotheses of a Mann-Whitney U test
+const (
+	TwoSided = iota // the samples come from different distributions
+	Less            // the first sample tends to be smaller
+	Greater         // the first sample tends to be larger
+)
+
+// mannWhitneyExactLimit is the largest sample size for which the exact
+// distribution of U is used when there are no ties; above it the normal
+// approximation is accurate
+const mannWhitneyExactLimit = 20
+
+// MannWhitney is the outcome of a Mann-Whitney U test. It compares ranks
+// rather than means, so unlike a t-test it makes no assumption about the
+// shape of the distributions and a few extreme latencies cannot dominate it.
+type MannWhitney struct {
+	U      float64 `json:"u"`       // U statistic of the first sample
+	P      float64 `json:"p_value"` // probability of a U at least this extreme under the null hypothesis
+	Effect float64 `json:"effect"`  // P(x > y) + P(x = y)/2; 0.5 is no effect
+}
+
+// MannWhitneyU tests whether x and y come from the same distribution against
+// the alternative (TwoSided, Less or Greater). Small samples without ties use
+// the exact distribution of U, others the normal approximation with tie and
+// continuity corrections. Either sample being empty gives a P of 1.
+func MannWhitneyU(x, y []float64, alternative int) MannWhitney {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return MannWhitney{P: 1, Effect: 0.5}
+	}
+
+	// Rank the pooled samples, giving tied values their mean rank
+	type value struct {
+		v     float64
+		first bool
+	}
+	pooled := make([]value, 0, n1+n2)
+	for _, v := range x {
+		pooled = append(pooled, value{v, true})
+	}
+	for _, v := range y {
+		pooled = append(pooled, value{v, false})
+	}
+	sort.Slice(pooled, func(i, j int) bool { return pooled[i].v < pooled[j].v })
+
+	var rankSum, tieTerm float64
+	ties := false
+	for i := 0; i < len(pooled); {
+		j := i + 1
+		for j < len(pooled) && pooled[j].v == pooled[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // mean of the 1-based ranks i+1..j
+		for k := i; k < j; k++ {
+			if pooled[k].first {
+				rankSum += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+
+	m1, m2 := float64(n1), float64(n2)
+	u := rankSum - m1*(m1+1)/2
+	result := MannWhitney{U: u, Effect: u / (m1 * m2)}
+
+	if !ties && n1 <= mannWhitneyExactLimit && n2 <= mannWhitneyExactLimit {
+		result.P = mannWhitneyExactP(n1, n2, u, alternative)
+		return result
+	}
+
+	n := m1 + m2
+	mean := m1 * m2 / 2
+	sigma := math.Sqrt(m1 * m2 / 12 * ((n + 1) - tieTerm/(n*(n-1))))
+	if sigma == 0 {
+		result.P = 1
+		return result
+	}
+	normal := func(z float64) float64 { return 0.5 * math.Erfc(-z/math.Sqrt2) }
+	switch alternative {
+	case Less:
+		result.P = normal((u - mean + 0.5) / sigma)
+	case Greater:
+		result.P = 1 - normal((u-mean-0.5)/sigma)
+	default:
+		z := (math.Abs(u-mean) - 0.5) / sigma
+		result.P = math.Min(1, 2*(1-normal(z)))
+	}
+	return result
+}
+
+// mannWhitneyCanReach reports whether a one-sided test of samples of n1 and
+// n2 runs can give a p-value of alpha or less at all: the most extreme U has
+// p = 1/C(n1+n2, n1), e.g. 1/6 for two runs a side
+func mannWhitneyCanReach(n1, n2 int, alpha float64) bool {
+	return binomial(n1+n2, n1)*alpha >= 1-1e-9 // alpha is rarely exact in binary
+}
+
+// binomial returns C(n, k)
+func binomial(n, k int) float64 {
+	c := 1.0
+	for i := 1; i <= k; i++ {
+		c = c * float64(n-k+i) / float64(i)
+	}
+	return c
+}
+
+// mannWhitneyExactP returns the p-value of u from the exact distribution of
+// U for samples of n1 and n2 distinct values
+func mannWhitneyExactP(n1, n2 int, u float64, alternative int) float64 {
+	// counts[m][n][k] is the number of orderings of m first-sample and n
+	// second-sample values with U = k; built up one value at a time
+	counts := make([][][]float64, n1+1)
+	for m := range counts {
+		counts[m] = make([][]float64, n2+1)
+		for n := range counts[m] {
+			counts[m][n] = make([]float64, m*n+1)
+			if m == 0 || n == 0 {
+				counts[m][n][0] = 1
+				continue
+			}
+			// The largest value belongs to the first sample, adding n to U,
+			// or to the second, adding nothing
+			for k := range counts[m][n] {
+				if k >= n && k-n < len(counts[m-1][n]) {
+					counts[m][n][k] += counts[m-1][n][k-n]
+				}
+				if k < len(counts[m][n-1]) {
+					counts[m][n][k] += counts[m][n-1][k]
+				}
+			}
+		}
+	}
+
+	dist := counts[n1][n2]
+	var total, below, above float64
+	k := int(math.Round(u))
+	for i, c := range dist {
+		total += c
+		if i <= k {
+			below += c
+		}
+		if i >= k {
+			above += c
+		}
+	}
+	switch alternative {
+	case Less:
+		return below / total
+	case Greater:
+		return above / total
+	default:
+		return math.Min(1, 2*math.Min(below, above)/total)
+	}
+}
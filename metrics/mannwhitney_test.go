@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitneyUExact(t *testing.T) {
+	x, y := []float64{1, 2, 3}, []float64{4, 5, 6}
+	tests := []struct {
+		alternative int
+		p           float64
+	}{
+		{Less, 1.0 / 20},
+		{Greater, 1},
+		{TwoSided, 2.0 / 20},
+	}
+	for _, tt := range tests {
+		r := MannWhitneyU(x, y, tt.alternative)
+		if r.U != 0 || r.Effect != 0 {
+			t.Errorf("alternative %d: U = %g, effect = %g, want 0 and 0", tt.alternative, r.U, r.Effect)
+		}
+		if math.Abs(r.P-tt.p) > 1e-12 {
+			t.Errorf("alternative %d: p = %g, want %g", tt.alternative, r.P, tt.p)
+		}
+	}
+}
+
+func TestMannWhitneyUInterleaved(t *testing.T) {
+	// U = 2 of C(4,2) = 6 orderings: U <= 2 in 4 of them (0, 1, 2, 2)
+	r := MannWhitneyU([]float64{1, 4}, []float64{2, 3}, Less)
+	if r.U != 2 || math.Abs(r.P-4.0/6) > 1e-12 {
+		t.Errorf("U = %g, p = %g, want 2 and %g", r.U, r.P, 4.0/6)
+	}
+}
+
+func TestMannWhitneyUNormal(t *testing.T) {
+	var x, y []float64
+	for i := 0; i < 25; i++ {
+		x = append(x, float64(i))
+		y = append(y, float64(i+25))
+	}
+	if p := MannWhitneyU(x, y, Less).P; p > 1e-6 {
+		t.Errorf("separated samples: p = %g, want below 1e-6", p)
+	}
+	if p := MannWhitneyU(x, x, TwoSided).P; p < 0.99 {
+		t.Errorf("identical samples: p = %g, want about 1", p)
+	}
+}
+
+func TestMannWhitneyUTies(t *testing.T) {
+	x, y := []float64{1, 1, 2, 2}, []float64{2, 3, 3, 3}
+	less, greater := MannWhitneyU(x, y, Less), MannWhitneyU(x, y, Greater)
+	if less.U != 1 || less.Effect != 1.0/16 {
+		t.Errorf("U = %g, effect = %g, want 1 and %g", less.U, less.Effect, 1.0/16)
+	}
+	if less.P >= 0.05 || greater.P <= 0.95 {
+		t.Errorf("p = %g less, %g greater, want below 0.05 and above 0.95", less.P, greater.P)
+	}
+}
+
+func TestMannWhitneyUEmpty(t *testing.T) {
+	if r := MannWhitneyU(nil, []float64{1}, TwoSided); r.P != 1 || r.Effect != 0.5 {
+		t.Errorf("p = %g, effect = %g, want 1 and 0.5", r.P, r.Effect)
+	}
+}
+
+func TestMannWhitneyCanReach(t *testing.T) {
+	tests := []struct {
+		n1, n2 int
+		alpha  float64
+		want   bool
+	}{
+		{2, 2, 0.05, false}, // 1/6
+		{3, 3, 0.05, true},  // 1/20
+		{2, 3, 0.05, false}, // 1/10
+		{2, 2, 0.2, true},
+		{4, 4, 0.01, false}, // 1/70
+		{5, 5, 0.01, true},  // 1/252
+	}
+	for _, tt := range tests {
+		if got := mannWhitneyCanReach(tt.n1, tt.n2, tt.alpha); got != tt.want {
+			t.Errorf("mannWhitneyCanReach(%d, %d, %g) = %v, want %v", tt.n1, tt.n2, tt.alpha, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown writes the report to path as Markdown. Plot links are made
+// relative to the directory of path so the file can be moved with its plots.
+func (r *Report) WriteMarkdown(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.EncodeMarkdown(f, filepath.Dir(path)); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// EncodeMarkdown renders the report as Markdown suitable for PRs and issues.
+// Plot links are relative to linkBase.
+func (r *Report) EncodeMarkdown(w io.Writer, linkBase string) error {
+	var b strings.Builder
+
+	b.WriteString("# Benchmark Results\n")
+	for _, run := range r.Runs {
+		writeMarkdownRun(&b, run, linkBase)
+	}
+	for _, agg := range r.Aggregates {
+		writeMarkdownAggregate(&b, agg)
+	}
+	if r.Interleaved != nil || comparesBackends(r.Runs) {
+		writeMarkdownCapabilities(&b, r.Runs)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownRun renders one run: environment, metrics, statistics and plots
+func writeMarkdownRun(b *strings.Builder, run Results, linkBase string) {
+	fmt.Fprintf(b, "\n## %s (%s)\n\n", run.Name, run.DB)
+
+	env := run.Environment
+	revision := env.VCSRevision
+	if revision != "" && env.VCSModified {
+		revision += " (modified)"
+	}
+	fmt.Fprintf(b, "godb-bench %s", env.Version)
+	if revision != "" {
+		fmt.Fprintf(b, " @ `%s`", revision)
+	}
+	fmt.Fprintf(b, ", %s, %s/%s, %d CPUs", env.GoVersion, env.OS, env.Arch, env.NumCPU)
+	if env.CPUModel != "" {
+		fmt.Fprintf(b, " (%s)", env.CPUModel)
+	}
+	b.WriteString("\n")
+
+	if run.WriteAmp != nil {
+		fmt.Fprintf(b, "\nWrite amplification: %s\n", run.WriteAmp)
+	}
+	if run.BlockCache != nil {
+		fmt.Fprintf(b, "\nBlock cache: %s\n", run.BlockCache)
+	}
+
+	if len(run.Resources) > 0 {
+		s := SummarizeResources(run.Resources)
+		fmt.Fprintf(b, "\nResources: %.1f%% CPU mean (%.1f%% peak), %s peak RSS, %d open files peak, %s read, %s written\n",
+			s.MeanCPU, s.PeakCPU, formatBytes(s.PeakRSS), s.PeakFDs, formatBytes(s.ReadBytes), formatBytes(s.WriteBytes))
+	}
+
+	if len(run.Engine) > 0 {
+		s := SummarizeEngine(run.Engine)
+		fmt.Fprintf(b, "\nEngine: %s peak compaction debt, %d L0 files peak, %d write stalls (%.1fs stalled)\n",
+			formatBytes(int64(s.PeakDebt)), s.PeakL0Files, s.WriteStalls, s.Stalled)
+	}
+
+	if len(run.Properties) > 0 {
+		keys := make([]string, 0, len(run.Properties))
+		for k := range run.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("\n<details><summary>Properties</summary>\n\n")
+		b.WriteString("| Property | Value |\n|---|---|\n")
+		for _, k := range keys {
+			fmt.Fprintf(b, "| `%s` | `%s` |\n", k, escapeMarkdownCell(run.Properties[k]))
+		}
+		b.WriteString("\n</details>\n")
+	}
+
+	b.WriteString("\n### YCSB Results\n\n")
+	b.WriteString("| Operation | Total (ms) | Count | OPS | Avg (µs) |")
+	for _, p := range tablePercentiles {
+		fmt.Fprintf(b, " %s (µs) |", percentileLabel(p))
+	}
+	failed := slices.ContainsFunc(run.Operations, func(m OperationMetrics) bool { return m.Errors > 0 })
+	b.WriteString(" Max (µs) |")
+	if failed {
+		b.WriteString(" Errors |")
+	}
+	b.WriteString("\n|---|--:|--:|--:|--:|" + strings.Repeat("--:|", len(tablePercentiles)) + "--:|")
+	if failed {
+		b.WriteString("--:|")
+	}
+	b.WriteString("\n")
+	for _, m := range run.Operations {
+		totalMs := "N/A"
+		if m.TotalTime > 0 {
+			totalMs = fmt.Sprintf("%.3f", float64(m.TotalTime.Microseconds())/1000.0)
+		}
+		fmt.Fprintf(b, "| %s | %s | %d | %.1f | %d |", m.Operation, totalMs, m.Count, m.OPS, m.Avg)
+		for _, p := range tablePercentiles {
+			fmt.Fprintf(b, " %s |", formatPercentile(m, p))
+		}
+		fmt.Fprintf(b, " %d |", m.Max)
+		if failed {
+			fmt.Fprintf(b, " %s |", formatErrors(m))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(run.IOCalls) > 0 {
+		b.WriteString("\n### Filesystem Calls\n\n")
+		b.WriteString("| Call | Count | Bytes | Mean | p50 | p90 | p99 | p99.9 | Max |\n")
+		b.WriteString("|---|--:|--:|--:|--:|--:|--:|--:|--:|\n")
+		for _, c := range run.IOCalls {
+			bytes := "-"
+			if c.Bytes > 0 {
+				bytes = formatBytes(c.Bytes)
+			}
+			fmt.Fprintf(b, "| %s | %d | %s | %s | %s | %s | %s | %s | %s |\n",
+				c.Call, c.Count, bytes, formatDuration(c.Mean), formatDuration(c.P50),
+				formatDuration(c.P90), formatDuration(c.P99), formatDuration(c.P999), formatDuration(c.Max))
+		}
+	}
+
+	if len(run.LSM) > 0 {
+		b.WriteString("\n### LSM Levels\n\n")
+		b.WriteString("| Level | Files | Size | Score | Bytes in | Ingested | Read | Written | W-Amp |\n")
+		b.WriteString("|---|--:|--:|--:|--:|--:|--:|--:|--:|\n")
+		for _, l := range run.LSM {
+			fmt.Fprintf(b, "| %s | %d | %s | %.2f | %s | %s | %s | %s | %.2f |\n",
+				l.Name(), l.Files, formatBytes(l.Size), l.Score, formatBytes(int64(l.BytesIn)), formatBytes(int64(l.BytesIngested)),
+				formatBytes(int64(l.BytesRead)), formatBytes(int64(l.BytesWritten)), l.WriteAmp())
+		}
+	}
+
+	if len(run.Statistics) > 0 {
+		fmt.Fprintf(b, "\n### Statistics (%s confidence intervals)\n\n", formatLevel(run.Statistics[0].level()))
+		b.WriteString("| Operation | Statistic | Lower bound | Estimate | Upper bound |\n")
+		b.WriteString("|---|---|--:|--:|--:|\n")
+		for _, s := range run.Statistics {
+			rows := []struct {
+				name   string
+				ci     ConfidenceInterval
+				format func(float64) string
+			}{
+				{"Throughput", s.Throughput, formatThroughput},
+				{"R²", s.R2, func(v float64) string { return fmt.Sprintf("%.7f", v) }},
+				{"Mean", s.Mean, formatDuration},
+				{"Std. Dev.", s.StdDev, formatDuration},
+				{"Median", s.Median, formatDuration},
+				{"MAD", s.MAD, formatDuration},
+				{"Slope", s.Slope, formatDuration},
+			}
+			op := s.Operation
+			for _, row := range rows {
+				fmt.Fprintf(b, "| %s | %s | %s | %s | %s |\n",
+					op, row.name, row.format(row.ci.LowerBound), row.format(row.ci.Estimate), row.format(row.ci.UpperBound))
+				op = ""
+			}
+		}
+
+		var outliers []string
+		for _, s := range run.Statistics {
+			o, count := s.Statistics.Outliers, s.Statistics.Count
+			if o.Total() > 0 && count > 0 {
+				outliers = append(outliers, fmt.Sprintf("- %s: %d outliers among %d measurements (%.2f%%): %d low severe, %d low mild, %d high mild, %d high severe\n",
+					s.Operation, o.Total(), count, 100*float64(o.Total())/float64(count), o.LowSevere, o.LowMild, o.HighMild, o.HighSevere))
+			}
+		}
+		if len(outliers) > 0 {
+			b.WriteString("\n#### Outliers (Tukey fences)\n\n")
+			b.WriteString(strings.Join(outliers, ""))
+		}
+	}
+
+	if len(run.Plots) > 0 {
+		b.WriteString("\n### Plots\n\n")
+		for _, plot := range run.Plots {
+			link := plot
+			if rel, err := filepath.Rel(linkBase, plot); err == nil {
+				link = rel
+			}
+			link = filepath.ToSlash(link)
+			fmt.Fprintf(b, "- [%s](%s)\n", filepath.Base(plot), link)
+		}
+	}
+}
+
+// writeMarkdownAggregate renders the cross-run summary of a group of runs
+func writeMarkdownAggregate(b *strings.Builder, agg ReportAggregate) {
+	fmt.Fprintf(b, "\n## Cross-Run Aggregate: %s\n\n", agg.Name)
+	b.WriteString("| Operation | Runs | Metric | Mean | Std. Dev. | Min | Max | CV |\n")
+	b.WriteString("|---|--:|---|--:|--:|--:|--:|--:|\n")
+	for _, a := range agg.Operations {
+		rows := []struct {
+			name string
+			s    MetricSummary
+		}{
+			{"OPS", a.OPS},
+			{"Avg (µs)", a.Avg},
+			{"p50 (µs)", a.P50},
+			{"p99 (µs)", a.P99},
+			{"p99.9 (µs)", a.P999},
+		}
+		op, runs := a.Operation, fmt.Sprint(a.Runs)
+		for _, row := range rows {
+			cv := "-"
+			if row.s.Mean != 0 {
+				cv = fmt.Sprintf("%.2f%%", 100*row.s.StdDev/row.s.Mean)
+			}
+			fmt.Fprintf(b, "| %s | %s | %s | %.1f | %.1f | %.1f | %.1f | %s |\n",
+				op, runs, row.name, row.s.Mean, row.s.StdDev, row.s.Min, row.s.Max, cv)
+			op, runs = "", ""
+		}
+	}
+}
+
+// comparesBackends is whether runs are of more than one backend
+func comparesBackends(runs []Results) bool {
+	return slices.ContainsFunc(runs, func(run Results) bool { return run.DB != runs[0].DB })
+}
+
+// writeMarkdownCapabilities renders the capability matrix of the backends of
+// runs, marking what they differ in and the run used
+func writeMarkdownCapabilities(b *strings.Builder, runs []Results) {
+	backends := make([]BackendResults, len(runs))
+	for i, run := range runs {
+		backends[i] = BackendResults{Name: run.Name, Results: run.Operations, Layout: run.Layout, Capabilities: run.Capabilities}
+	}
+
+	b.WriteString("\n## Backend Capabilities\n\n| Capability |")
+	for _, run := range runs {
+		fmt.Fprintf(b, " %s (%s) |", escapeMarkdownCell(run.Name), escapeMarkdownCell(run.DB))
+	}
+	b.WriteString("\n|---|" + strings.Repeat("---|", len(runs)) + "\n")
+	var differ []string
+	for _, row := range capabilityRows(backends) {
+		name := row.name
+		if row.used && slices.ContainsFunc(row.values, func(v string) bool { return v != row.values[0] }) {
+			name += " ≠"
+			differ = append(differ, strings.ToLower(row.name))
+		}
+		fmt.Fprintf(b, "| %s |", name)
+		for _, v := range row.values {
+			fmt.Fprintf(b, " %s |", escapeMarkdownCell(v))
+		}
+		b.WriteString("\n")
+	}
+	if len(differ) > 0 {
+		fmt.Fprintf(b, "\n**Warning:** the backends differ in %s (≠), which the runs used: they did not run the same work.\n",
+			strings.Join(differ, ", "))
+	}
+}
+
+// escapeMarkdownCell keeps a value from breaking a table row
+func escapeMarkdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "`", "'").Replace(s)
+}
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// CollectorSnapshot is the state of a Collector's histograms and failure
+// counts at full resolution, from which the collectors of several processes
+// running the same workload are merged
+type CollectorSnapshot struct {
+	Histograms []HistogramSnapshot         `json:"histograms"`
+	Errors     map[string]map[string]int64 `json:"errors,omitempty"` // operation -> error class -> count
+}
+
+// HistogramSnapshot is the histogram of one operation
+type HistogramSnapshot struct {
+	Operation string                 `json:"operation"`
+	Corrected bool                   `json:"corrected,omitempty"` // measured from the intended start
+	Start     time.Time              `json:"start"`               // first sample
+	End       time.Time              `json:"end"`                 // when the snapshot was taken
+	Total     time.Duration          `json:"total_ns"`            // sum of the latencies at full precision
+	Counts    *hdrhistogram.Snapshot `json:"counts"`
+}
+
+// Snapshot returns the histograms and failure counts of every operation
+func (c *Collector) Snapshot() CollectorSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fold()
+
+	now := time.Now()
+	var s CollectorSnapshot
+	for _, set := range []struct {
+		histograms map[string]*opHistogram
+		corrected  bool
+	}{{c.histograms, false}, {c.corrected, true}} {
+		for _, op := range sortedKeys(set.histograms) {
+			h := set.histograms[op]
+			end := h.end
+			if end.IsZero() {
+				end = now
+			}
+			s.Histograms = append(s.Histograms, HistogramSnapshot{
+				Operation: op,
+				Corrected: set.corrected,
+				Start:     h.start,
+				End:       end,
+				Total:     h.total,
+				Counts:    h.hist.Export(),
+			})
+		}
+	}
+	for op, classes := range c.errors {
+		if s.Errors == nil {
+			s.Errors = make(map[string]map[string]int64)
+		}
+		s.Errors[op] = make(map[string]int64, len(classes))
+		for class, n := range classes {
+			s.Errors[op][class] = n
+		}
+	}
+	return s
+}
+
+// Merge adds the operations of a snapshot, taken by another process running
+// the same workload at the same time, to the collector. A merged operation's
+// throughput is its combined count over the span from the earliest first
+// sample to the latest snapshot, so the processes' clocks should be in sync.
+func (c *Collector) Merge(s CollectorSnapshot) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, hs := range s.Histograms {
+		if hs.Counts == nil {
+			return fmt.Errorf("histogram of %s has no counts", hs.Operation)
+		}
+		histograms := c.histograms
+		if hs.Corrected {
+			histograms = c.corrected
+		}
+
+		imported := hdrhistogram.Import(hs.Counts)
+		h, ok := histograms[hs.Operation]
+		if !ok {
+			histograms[hs.Operation] = &opHistogram{hist: imported, start: hs.Start, end: hs.End, total: hs.Total}
+			continue
+		}
+		if dropped := h.hist.Merge(imported); dropped > 0 {
+			return fmt.Errorf("histogram of %s: %d latencies out of range", hs.Operation, dropped)
+		}
+		h.total += hs.Total
+		if hs.Start.Before(h.start) {
+			h.start = hs.Start
+		}
+		if hs.End.After(h.end) {
+			h.end = hs.End
+		}
+	}
+
+	for op, classes := range s.Errors {
+		merged, ok := c.errors[op]
+		if !ok {
+			merged = make(map[string]int64)
+			c.errors[op] = merged
+		}
+		for class, n := range classes {
+			merged[class] += n
+		}
+	}
+	return nil
+}
+
+// MergeSamples combines the samples of runs that took place at the same
+// time, such as the workers of a distributed run, on one timeline. starts
+// holds when each part began tracking; the threads of each part are numbered
+// after those of the parts before it.
+func MergeSamples(parts []*BenchmarkPlots, starts []time.Time) *BenchmarkPlots {
+	merged := NewBenchmarkPlots()
+	for i, start := range starts {
+		if i == 0 || start.Before(merged.start) {
+			merged.start = start
+		}
+	}
+
+	threads := 0
+	for i, part := range parts {
+		part.order()
+		offset := starts[i].Sub(merged.start)
+		partThreads := 0
+		for op, samples := range part.samples {
+			for _, s := range samples {
+				s.Elapsed += offset
+				s.Thread += threads
+				partThreads = max(partThreads, s.Thread-threads+1)
+				merged.samples[op] = append(merged.samples[op], s)
+			}
+		}
+		threads += partThreads
+	}
+
+	// Samples are numbered again in the order they started across parts
+	for op, samples := range merged.samples {
+		sort.SliceStable(samples, func(i, j int) bool { return samples[i].Elapsed < samples[j].Elapsed })
+		for i := range samples {
+			samples[i].SampleIndex = int64(i + 1)
+		}
+		merged.sampleCounters[op] = int64(len(samples))
+	}
+	return merged
+}
+
+// PrintWorkersTable prints the key metrics of every worker of a distributed
+// run, so an overloaded or slow worker stands out before the merged results
+func PrintWorkersTable(workers []string, runs [][]OperationMetrics) {
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := fmt.Sprintf("PER-WORKER RESULTS (%d workers)", len(workers))
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+
+	fmt.Printf("│ %-28s │ %-12s │ %10s │ %10s │ %9s │ %9s │ %9s │ %9s │\n",
+		"Worker", "Operation", "Count", "OPS", "Avg(µs)", "p50(µs)", "p99(µs)", "p99.9(µs)")
+	for i, run := range runs {
+		fmt.Println(strings.Repeat("─", tableWidth))
+		label := workers[i]
+		for _, r := range run {
+			fmt.Printf("│ %-28s │ %-12s │ %10d │ %10.1f │ %9d │ %9d │ %9d │ %9d │\n",
+				label, r.Operation, r.Count, r.OPS, r.Avg, r.P50, r.P99, r.P999)
+			label = ""
+		}
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
@@ -1,91 +1,216 @@
 package metrics
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"os"
-	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
-	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
 )
 
 type OperationTracker struct {
 	ycsb.DB
-	mu      sync.Mutex
-	timings map[string]*OperationTiming
-	plots   *BenchmarkPlots
-}
-
-type OperationTiming struct {
-	Count     int64
-	TotalTime time.Duration
-	StartTime time.Time
+	mu          sync.Mutex // guards the streaming statistics and samples
+	shardsMu    sync.Mutex
+	shards      []*threadShard // samples of each thread not merged yet
+	collector   *Collector
+	streams     streams
+	retain      bool // keep every sample, not just the streaming statistics
+	noErrors    bool // leave failed operations out of the samples and streaming statistics
+	plots       *BenchmarkPlots
+	resources   *ResourceMonitor
+	engine      *EngineMonitor
+	live        []*LiveWindow // windows read while the run is in progress
+	hotSet      *hotSet       // nil without hot keys
+	bursts      *bursts       // nil without bursts
+	ramp        *ramp         // nil without a concurrency ramp
+	scanLengths scanLengths
+
+	// intendedStart returns when a rate-limited run meant to start the
+	// operation running with a context
+	intendedStart func(ctx context.Context) (time.Time, bool)
 }
 
-func NewOperationTracker(db ycsb.DB) *OperationTracker {
+// NewOperationTracker wraps db. Every operation updates the streaming
+// statistics; the samples plots and bootstrap statistics need are only kept
+// when retainSamples is set, as they grow with the length of the run.
+func NewOperationTracker(db ycsb.DB, retainSamples bool) *OperationTracker {
 	return &OperationTracker{
-		DB:      db,
-		timings: make(map[string]*OperationTiming),
-		plots:   NewBenchmarkPlots(),
+		DB:        db,
+		collector: NewCollector(),
+		streams:   make(streams),
+		retain:    retainSamples,
+		plots:     NewBenchmarkPlots(),
 	}
 }
 
-func (ot *OperationTracker) track(op string, start time.Time) {
+// threadKey is the context key of the YCSB thread ID
+type threadKey struct{}
+
+// InitThread records the YCSB thread ID in the thread's context so samples
+// can be attributed to it, along with the shard its samples go to
+func (ot *OperationTracker) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	ctx = ot.DB.InitThread(ctx, threadID, threadCount)
+	return ot.newShard(context.WithValue(ctx, threadKey{}, threadID), threadID)
+}
+
+// threadOf returns the YCSB thread ID recorded by InitThread, or 0
+func threadOf(ctx context.Context) int {
+	id, _ := ctx.Value(threadKey{}).(int)
+	return id
+}
+
+// track records an operation that started at start and returns the latency
+// recorded for it
+func (ot *OperationTracker) track(ctx context.Context, op string, start time.Time, err error) time.Duration {
 	elapsed := time.Since(start)
+	ot.measure(ctx, op, start, elapsed, err)
+	ot.sample(ctx, op, start, elapsed, err)
+	return elapsed
+}
 
-	ot.mu.Lock()
-	defer ot.mu.Unlock()
+// trackKey tracks an operation on a single key, which is also split by
+// whether the key is hot when there are hot keys
+func (ot *OperationTracker) trackKey(ctx context.Context, op string, key string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	ot.measure(ctx, op, start, elapsed, err)
+	ot.sample(ctx, op, start, elapsed, err)
+	if ot.hotSet != nil && err == nil {
+		ot.hotSet.record(op, key, elapsed)
+	}
+}
 
-	if _, exists := ot.timings[op]; !exists {
-		ot.timings[op] = &OperationTiming{StartTime: start}
+// measure records one operation in the collector and the live windows, in
+// the shards of its thread, along with its latency from the intended start
+// when the run is rate limited
+func (ot *OperationTracker) measure(ctx context.Context, op string, start time.Time, elapsed time.Duration, err error) {
+	var shard *collectorShard
+	if s := ot.shardOf(ctx); s != nil {
+		shard = s.measures
+		for _, l := range s.live {
+			l.record(op, elapsed, err)
+		}
+	} else {
+		for _, w := range ot.live {
+			w.record(op, elapsed, err)
+		}
+	}
+	if ot.bursts != nil && err == nil {
+		ot.bursts.record(ctx, op, elapsed)
+	}
+	if ot.ramp != nil && err == nil {
+		ot.ramp.record(ctx, elapsed)
+	}
+	if ot.intendedStart != nil {
+		if intended, ok := ot.intendedStart(ctx); ok {
+			ot.collector.measureOn(shard, op, start, elapsed, err, &intended)
+			return
+		}
 	}
+	ot.collector.measureOn(shard, op, start, elapsed, err, nil)
+}
 
-	ot.timings[op].Count++
-	ot.timings[op].TotalTime += elapsed
+// Watch returns a window of the operations completed since it was last read,
+// for live reporting. Windows must be added before the run starts.
+func (ot *OperationTracker) Watch() *LiveWindow {
+	w := NewLiveWindow()
+	ot.live = append(ot.live, w)
+	return w
+}
 
-	// Record sample for plotting (sample index auto-increments)
-	ot.plots.AddSample(op, elapsed)
+// Share adds the operations of this tracker to w, a window of another
+// tracker, so live views cover both. Like Watch, it must be called before the
+// run starts.
+func (ot *OperationTracker) Share(w *LiveWindow) {
+	ot.live = append(ot.live, w)
+}
+
+// sample records one sample of op in the streaming statistics and, when
+// samples are retained, for plotting. The sample goes to the shard of its
+// thread, which is merged once full; samples of other contexts are merged
+// at once. Failed operations are skipped when errors are excluded.
+func (ot *OperationTracker) sample(ctx context.Context, op string, start time.Time, elapsed time.Duration, err error) {
+	s := ot.shardOf(ctx)
+	if s == nil {
+		ot.merge([]pendingSample{{op: op, thread: threadOf(ctx), start: start, elapsed: elapsed, err: err}})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, pendingSample{op: op, thread: s.thread, start: start, elapsed: elapsed, err: err})
+	if len(s.samples) >= shardSize {
+		ot.merge(s.samples)
+		s.samples = s.samples[:0]
+	}
 }
 
 func (ot *OperationTracker) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
 	start := time.Now()
 	err := ot.DB.Insert(ctx, table, key, values)
-	ot.track("INSERT", start)
+	ot.trackKey(ctx, "INSERT", key, start, err)
 	return err
 }
 
 func (ot *OperationTracker) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
 	start := time.Now()
 	err := ot.DB.Update(ctx, table, key, values)
-	ot.track("UPDATE", start)
+	ot.trackKey(ctx, "UPDATE", key, start, err)
 	return err
 }
 
 func (ot *OperationTracker) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
 	start := time.Now()
 	result, err := ot.DB.Read(ctx, table, key, fields)
-	ot.track("READ", start)
+	ot.trackKey(ctx, "READ", key, start, err)
 	return result, err
 }
 
 func (ot *OperationTracker) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
 	start := time.Now()
 	result, err := ot.DB.Scan(ctx, table, startKey, count, fields)
-	ot.track("SCAN", start)
+	elapsed := ot.track(ctx, "SCAN", start, err)
+	if err == nil {
+		ot.scanLengths.record(count, len(result), elapsed)
+	}
 	return result, err
 }
 
 func (ot *OperationTracker) Delete(ctx context.Context, table string, key string) error {
 	start := time.Now()
 	err := ot.DB.Delete(ctx, table, key)
-	ot.track("DELETE", start)
+	ot.trackKey(ctx, "DELETE", key, start, err)
+	return err
+}
+
+func (ot *OperationTracker) PartialRead(ctx context.Context, table string, key string, field string, offset int, length int) ([]byte, error) {
+	start := time.Now()
+	value, err := godbdb.PartialRead(ctx, ot.DB, table, key, field, offset, length)
+	ot.trackKey(ctx, "PARTIAL_READ", key, start, err)
+	return value, err
+}
+
+func (ot *OperationTracker) PrefixScan(ctx context.Context, table string, prefix string, fields []string) ([]map[string][]byte, error) {
+	start := time.Now()
+	result, err := godbdb.PrefixScan(ctx, ot.DB, table, prefix, fields)
+	ot.track(ctx, "PREFIX_SCAN", start, err)
+	return result, err
+}
+
+// ReadModifyWrite measures a read-modify-write as one RMW operation rather
+// than a READ and an UPDATE
+func (ot *OperationTracker) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	start := time.Now()
+	err := godbdb.ReadModifyWrite(ctx, ot.DB, table, key, fields, modify)
+	ot.trackKey(ctx, "RMW", key, start, err)
 	return err
 }
 
@@ -101,17 +226,12 @@ func (ot *OperationTracker) BatchInsert(ctx context.Context, table string, keys
 		elapsed := time.Since(start)
 		perOpTime := elapsed / time.Duration(len(keys))
 
-		ot.mu.Lock()
-		if _, exists := ot.timings["INSERT"]; !exists {
-			ot.timings["INSERT"] = &OperationTiming{StartTime: start}
-		}
-		ot.timings["INSERT"].Count += int64(len(keys))
-		ot.timings["INSERT"].TotalTime += elapsed
+		// go-ycsb measures a batch as one BATCH_<OP> operation
+		ot.measure(ctx, "BATCH_INSERT", start, elapsed, err)
 
 		// Record ONE sample per batch (not per operation in the batch)
 		// This keeps sample index aligned with actual batch calls
-		ot.plots.AddSample("INSERT", perOpTime)
-		ot.mu.Unlock()
+		ot.sample(ctx, "INSERT", start, perOpTime, err)
 
 		return err
 	}
@@ -120,7 +240,7 @@ func (ot *OperationTracker) BatchInsert(ctx context.Context, table string, keys
 	for i, key := range keys {
 		opStart := time.Now()
 		err := ot.DB.Insert(ctx, table, key, values[i])
-		ot.track("INSERT", opStart)
+		ot.trackKey(ctx, "INSERT", key, opStart, err)
 		if err != nil {
 			return err
 		}
@@ -136,16 +256,11 @@ func (ot *OperationTracker) BatchUpdate(ctx context.Context, table string, keys
 		elapsed := time.Since(start)
 		perOpTime := elapsed / time.Duration(len(keys))
 
-		ot.mu.Lock()
-		if _, exists := ot.timings["UPDATE"]; !exists {
-			ot.timings["UPDATE"] = &OperationTiming{StartTime: start}
-		}
-		ot.timings["UPDATE"].Count += int64(len(keys))
-		ot.timings["UPDATE"].TotalTime += elapsed
+		// go-ycsb measures a batch as one BATCH_<OP> operation
+		ot.measure(ctx, "BATCH_UPDATE", start, elapsed, err)
 
 		// Record ONE sample per batch (not per operation in the batch)
-		ot.plots.AddSample("UPDATE", perOpTime)
-		ot.mu.Unlock()
+		ot.sample(ctx, "UPDATE", start, perOpTime, err)
 
 		return err
 	}
@@ -153,7 +268,7 @@ func (ot *OperationTracker) BatchUpdate(ctx context.Context, table string, keys
 	for i, key := range keys {
 		opStart := time.Now()
 		err := ot.DB.Update(ctx, table, key, values[i])
-		ot.track("UPDATE", opStart)
+		ot.trackKey(ctx, "UPDATE", key, opStart, err)
 		if err != nil {
 			return err
 		}
@@ -169,17 +284,11 @@ func (ot *OperationTracker) BatchRead(ctx context.Context, table string, keys []
 		elapsed := time.Since(start)
 		perOpTime := elapsed / time.Duration(len(keys))
 
-		ot.mu.Lock()
-		if _, exists := ot.timings["READ"]; !exists {
-			ot.timings["READ"] = &OperationTiming{StartTime: start}
-		}
-		// Count all attempted reads, regardless of individual key errors
-		ot.timings["READ"].Count += int64(len(keys))
-		ot.timings["READ"].TotalTime += elapsed
+		// go-ycsb measures a batch as one BATCH_<OP> operation
+		ot.measure(ctx, "BATCH_READ", start, elapsed, err)
 
 		// Record ONE sample per batch (not per key)
-		ot.plots.AddSample("READ", perOpTime)
-		ot.mu.Unlock()
+		ot.sample(ctx, "READ", start, perOpTime, err)
 
 		// Note: BatchRead may return partial results with err != nil
 		// Don't treat the entire batch as an error
@@ -190,7 +299,7 @@ func (ot *OperationTracker) BatchRead(ctx context.Context, table string, keys []
 	for i, key := range keys {
 		opStart := time.Now()
 		result, err := ot.DB.Read(ctx, table, key, fields)
-		ot.track("READ", opStart)
+		ot.trackKey(ctx, "READ", key, opStart, err)
 		if err != nil {
 			return nil, err
 		}
@@ -207,16 +316,11 @@ func (ot *OperationTracker) BatchDelete(ctx context.Context, table string, keys
 		elapsed := time.Since(start)
 		perOpTime := elapsed / time.Duration(len(keys))
 
-		ot.mu.Lock()
-		if _, exists := ot.timings["DELETE"]; !exists {
-			ot.timings["DELETE"] = &OperationTiming{StartTime: start}
-		}
-		ot.timings["DELETE"].Count += int64(len(keys))
-		ot.timings["DELETE"].TotalTime += elapsed
+		// go-ycsb measures a batch as one BATCH_<OP> operation
+		ot.measure(ctx, "BATCH_DELETE", start, elapsed, err)
 
 		// Record ONE sample per batch (not per operation in the batch)
-		ot.plots.AddSample("DELETE", perOpTime)
-		ot.mu.Unlock()
+		ot.sample(ctx, "DELETE", start, perOpTime, err)
 
 		return err
 	}
@@ -224,7 +328,7 @@ func (ot *OperationTracker) BatchDelete(ctx context.Context, table string, keys
 	for _, key := range keys {
 		opStart := time.Now()
 		err := ot.DB.Delete(ctx, table, key)
-		ot.track("DELETE", opStart)
+		ot.trackKey(ctx, "DELETE", key, opStart, err)
 		if err != nil {
 			return err
 		}
@@ -232,125 +336,483 @@ func (ot *OperationTracker) BatchDelete(ctx context.Context, table string, keys
 	return nil
 }
 
-// FormatMetricsTable captures YCSB output and formats it as a table
-func FormatMetricsTable(tracker *OperationTracker) {
-	// Capture stdout
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+// NewBatch starts a batch measured as one TXN operation, from its start to
+// its commit, whose time is also shared out as one sample per operation of
+// the batch. Backends that cannot group operations have them issued and
+// measured one at a time.
+func (ot *OperationTracker) NewBatch(ctx context.Context, size int) (godbdb.Batch, error) {
+	if _, ok := ot.DB.(godbdb.BatchedDB); !ok {
+		return godbdb.Unbatched(ot), nil
+	}
+	start := time.Now()
+	b, err := godbdb.NewBatch(ctx, ot.DB, size)
+	if err != nil {
+		ot.track(ctx, "TXN", start, err)
+		return nil, err
+	}
+	return &trackedBatch{Batch: b, ot: ot, ctx: ctx, start: start}, nil
+}
+
+// trackedBatch is a batch of the tracked backend. Operations that fail are
+// measured on their own when they do; the others when the batch commits.
+type trackedBatch struct {
+	godbdb.Batch
+	ot    *OperationTracker
+	ctx   context.Context
+	start time.Time
+	ops   []batchOp // the operations that succeeded, in order
+}
+
+// batchOp is one operation of a batch
+type batchOp struct {
+	op  string
+	key string
+}
 
-	// Get the output
-	measurement.Output()
+// done notes the outcome of op on key, which started at start
+func (b *trackedBatch) done(ctx context.Context, op string, key string, start time.Time, err error) {
+	if err != nil {
+		b.ot.trackKey(ctx, op, key, start, err)
+		return
+	}
+	b.ops = append(b.ops, batchOp{op: op, key: key})
+}
 
-	// Restore stdout
-	w.Close()
-	os.Stdout = oldStdout
+func (b *trackedBatch) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	start := time.Now()
+	result, err := b.Batch.Read(ctx, table, key, fields)
+	b.done(ctx, "READ", key, start, err)
+	return result, err
+}
 
-	// Read captured output
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	output := buf.String()
+func (b *trackedBatch) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	start := time.Now()
+	err := b.Batch.Update(ctx, table, key, values)
+	b.done(ctx, "UPDATE", key, start, err)
+	return err
+}
 
-	// Get timing data from tracker
-	tracker.mu.Lock()
-	timingData := make(map[string]*OperationTiming)
-	for op, timing := range tracker.timings {
-		timingData[op] = timing
+func (b *trackedBatch) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	start := time.Now()
+	err := b.Batch.Insert(ctx, table, key, values)
+	b.done(ctx, "INSERT", key, start, err)
+	return err
+}
+
+func (b *trackedBatch) Delete(ctx context.Context, table string, key string) error {
+	start := time.Now()
+	err := b.Batch.Delete(ctx, table, key)
+	b.done(ctx, "DELETE", key, start, err)
+	return err
+}
+
+func (b *trackedBatch) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	start := time.Now()
+	err := b.Batch.ReadModifyWrite(ctx, table, key, fields, modify)
+	b.done(ctx, "RMW", key, start, err)
+	return err
+}
+
+// Commit commits the batch and measures it
+func (b *trackedBatch) Commit(ctx context.Context) error {
+	err := b.Batch.Commit(ctx)
+	elapsed := time.Since(b.start)
+	b.ot.measure(b.ctx, "TXN", b.start, elapsed, err)
+	if len(b.ops) > 0 {
+		perOpTime := elapsed / time.Duration(len(b.ops))
+		for _, op := range b.ops {
+			b.ot.sample(b.ctx, op.op, b.start, perOpTime, err)
+			if b.ot.hotSet != nil && err == nil {
+				b.ot.hotSet.record(op.op, op.key, perOpTime)
+			}
+		}
+	}
+	return err
+}
+
+// OperationMetrics holds the YCSB summary for a single operation.
+// Latencies are in microseconds.
+type OperationMetrics struct {
+	Operation string        `json:"operation"`
+	TotalTime time.Duration `json:"total_time_ns"` // Sum of the operation's latencies
+	Count     int64         `json:"count"`
+	OPS       float64       `json:"ops"`
+	Avg       int64         `json:"avg_us"`
+	Min       int64         `json:"min_us"`
+	Max       int64         `json:"max_us"`
+	P50       int64         `json:"p50_us"`
+	P90       int64         `json:"p90_us"`
+	P95       int64         `json:"p95_us"`
+	P99       int64         `json:"p99_us"`
+	P999      int64         `json:"p999_us"`
+
+	// Percentiles holds the configured percentiles that are not among the
+	// fixed ones above, keyed by label such as "p99.99"
+	Percentiles map[string]int64 `json:"percentiles_us,omitempty"`
+
+	// Failed operations, which are not part of the latencies above: their
+	// number, their share of all attempts, and their number per error class.
+	// Misses are the failures that read a missing key, which many workloads
+	// expect; the rest are failures of the engine.
+	Errors       int64            `json:"errors,omitempty"`
+	ErrorRate    float64          `json:"error_rate,omitempty"`
+	ErrorClasses map[string]int64 `json:"error_classes,omitempty"`
+	Misses       int64            `json:"misses,omitempty"`
+
+	// Corrected holds the latencies measured from each operation's intended
+	// start in rate-limited runs, which are corrected for coordinated omission
+	Corrected *OperationMetrics `json:"corrected,omitempty"`
+}
+
+// CollectMetrics returns the per-operation metrics read directly from the
+// tracker's histograms. The TOTAL row, if present, is always the last element.
+func CollectMetrics(tracker *OperationTracker) []OperationMetrics {
+	return tracker.collector.Results()
+}
+
+// PrintMetricsTable prints per-operation metrics as a table, followed by
+// their latencies from the intended start in rate-limited runs
+func PrintMetricsTable(rows []OperationMetrics) {
+	printMetricsTable("YCSB BENCHMARK RESULTS", rows)
+
+	var corrected []OperationMetrics
+	for _, row := range rows {
+		if row.Corrected != nil {
+			corrected = append(corrected, *row.Corrected)
+		}
+	}
+	if len(corrected) > 0 {
+		printMetricsTable("LATENCY FROM INTENDED START (CORRECTED FOR COORDINATED OMISSION)", corrected)
+	}
+}
+
+// printMetricsTable prints rows as a table under title
+func printMetricsTable(title string, rows []OperationMetrics) {
+	// One column per configured percentile, each wide enough for its label;
+	// with the default percentiles the table is 126 wide
+	labels := make([]string, len(tablePercentiles))
+	widths := make([]int, len(tablePercentiles))
+	tableWidth := 12 + 10 + 10 + 9 + 9 + 9 + 6*3 + 1
+	for i, p := range tablePercentiles {
+		labels[i] = percentileLabel(p) + "(µs)"
+		widths[i] = max(9, utf8.RuneCountInString(labels[i]))
+		tableWidth += widths[i] + 3
+	}
+
+	// The Errors column only appears when an operation failed
+	failed := slices.ContainsFunc(rows, func(row OperationMetrics) bool { return row.Errors > 0 })
+	if failed {
+		tableWidth += 16 + 3
 	}
-	tracker.mu.Unlock()
 
-	// Parse and format as table
-	const tableWidth = 126
 	fmt.Println("\n" + strings.Repeat("═", tableWidth))
 
 	// Center the title
-	title := "YCSB BENCHMARK RESULTS"
 	padding := (tableWidth - len(title)) / 2
 	fmt.Println(strings.Repeat(" ", padding) + title)
 
 	fmt.Println(strings.Repeat("═", tableWidth))
 
 	// Table header - replaced Takes(s) with Total(ms)
-	fmt.Printf("│ %-12s │ %10s │ %10s │ %9s │ %9s │ %9s │ %9s │ %9s │ %9s │ %9s │\n",
-		"Operation", "Total(ms)", "Count", "OPS", "Avg(µs)", "p50(µs)", "p95(µs)", "p99(µs)", "p99.9(µs)", "Max(µs)")
+	fmt.Printf("│ %-12s │ %10s │ %10s │ %9s │ %9s │", "Operation", "Total(ms)", "Count", "OPS", "Avg(µs)")
+	for i, label := range labels {
+		fmt.Printf(" %*s │", widths[i], label)
+	}
+	fmt.Printf(" %9s │", "Max(µs)")
+	if failed {
+		fmt.Printf(" %16s │", "Errors")
+	}
+	fmt.Println()
 	fmt.Println(strings.Repeat("─", tableWidth))
 
-	// Parse each line
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	re := regexp.MustCompile(`^(\S+)\s+-\s+Takes\(s\):\s+([\d.]+),\s+Count:\s+(\d+),\s+OPS:\s+([\d.]+),\s+Avg\(us\):\s+(\d+),\s+Min\(us\):\s+(\d+),\s+Max\(us\):\s+(\d+),\s+50th\(us\):\s+(\d+),\s+90th\(us\):\s+(\d+),\s+95th\(us\):\s+(\d+),\s+99th\(us\):\s+(\d+),\s+99\.9th\(us\):\s+(\d+)`)
-
-	// Store rows to print, with TOTAL row separate
-	var rows []string
-	var totalRow string
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		matches := re.FindStringSubmatch(line)
-		if len(matches) > 0 {
-			op := matches[1]
-			count := matches[3]
-			ops := matches[4]
-			avg := matches[5]
-			p50 := matches[8]
-			p95 := matches[10]
-			p99 := matches[11]
-			p999 := matches[12]
-			max := matches[7]
-
-			// Get actual timing from tracker with higher precision
-			totalMs := "N/A"
-			if op == "TOTAL" {
-				// Sum up all operation times for TOTAL row
-				var totalTime time.Duration
-				for _, timing := range timingData {
-					totalTime += timing.TotalTime
-				}
-				totalMs = fmt.Sprintf("%.3f", float64(totalTime.Microseconds())/1000.0)
-			} else if timing, exists := timingData[op]; exists {
-				totalMs = fmt.Sprintf("%.3f", float64(timing.TotalTime.Microseconds())/1000.0)
-			}
+	for _, row := range rows {
+		totalMs := "N/A"
+		if row.TotalTime > 0 {
+			totalMs = fmt.Sprintf("%.3f", float64(row.TotalTime.Microseconds())/1000.0)
+		}
+		fmt.Printf("│ %-12s │ %10s │ %10d │ %9.1f │ %9d │", row.Operation, totalMs, row.Count, row.OPS, row.Avg)
+		for i, p := range tablePercentiles {
+			fmt.Printf(" %*s │", widths[i], formatPercentile(row, p))
+		}
+		fmt.Printf(" %9d │", row.Max)
+		if failed {
+			fmt.Printf(" %16s │", formatErrors(row))
+		}
+		fmt.Println()
+	}
 
-			rowStr := fmt.Sprintf("│ %-12s │ %10s │ %10s │ %9s │ %9s │ %9s │ %9s │ %9s │ %9s │ %9s │\n",
-				op, totalMs, count, ops, avg, p50, p95, p99, p999, max)
+	fmt.Println(strings.Repeat("═", tableWidth))
 
-			// Separate TOTAL row
-			if op == "TOTAL" {
-				totalRow = rowStr
-			} else {
-				rows = append(rows, rowStr)
+	if failed {
+		fmt.Println("Errors by class (not_found is a read of a missing key, the others are failures of the engine):")
+		for _, row := range rows {
+			if row.Errors > 0 && row.Operation != "TOTAL" {
+				fmt.Printf("  %-12s %s\n", row.Operation, formatErrorClasses(row.ErrorClasses))
 			}
 		}
 	}
+}
 
-	// Print all rows except TOTAL
-	for _, row := range rows {
-		fmt.Print(row)
+// formatErrors formats the failures of row with their rate, e.g. "614 (64.0%)",
+// leaving <OP>_ERROR rows, which are failures themselves, blank
+func formatErrors(row OperationMetrics) string {
+	if strings.HasSuffix(row.Operation, "_ERROR") {
+		return ""
+	}
+	if row.Errors == 0 {
+		return "0"
 	}
+	return fmt.Sprintf("%d (%.1f%%)", row.Errors, 100*row.ErrorRate)
+}
 
-	// Print TOTAL row last
-	if totalRow != "" {
-		fmt.Print(totalRow)
+// formatErrorClasses lists error classes with their counts, most frequent
+// first, e.g. "not_found 610, internal 4"
+func formatErrorClasses(classes map[string]int64) string {
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if classes[names[i]] != classes[names[j]] {
+			return classes[names[i]] > classes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, classes[name])
 	}
+	return strings.Join(parts, ", ")
+}
 
-	fmt.Println(strings.Repeat("═", tableWidth))
+// FormatMetricsTable collects the tracker's metrics and prints them as a table
+func FormatMetricsTable(tracker *OperationTracker) []OperationMetrics {
+	rows := CollectMetrics(tracker)
+	PrintMetricsTable(rows)
+	return rows
+}
+
+// SetPlotStyle sets the size, colors, title template and theme of the plots
+func (ot *OperationTracker) SetPlotStyle(style PlotStyle) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	ot.plots.SetStyle(style)
 }
 
 // GeneratePlots creates criterion-style scatter plots for the tracked operations
-func (ot *OperationTracker) GeneratePlots(outputDir string) error {
+// and returns the files written
+func (ot *OperationTracker) GeneratePlots(outputDir string, formats []string) ([]string, error) {
+	files, err := ot.snapshot().GeneratePlots(outputDir, formats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate plots: %w", err)
+	}
+
+	return files, nil
+}
+
+// snapshot returns a copy of the samples taken so far, which plots are drawn
+// from without holding the lock
+func (ot *OperationTracker) snapshot() *BenchmarkPlots {
+	ot.flush()
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	return ot.plots.snapshot()
+}
+
+// ComputeStatistics returns criterion-style statistics for the tracked operations
+func (ot *OperationTracker) ComputeStatistics() []OperationStatistics {
+	ot.flush()
 	ot.mu.Lock()
 	defer ot.mu.Unlock()
 
-	if err := ot.plots.GeneratePlots(outputDir); err != nil {
-		return fmt.Errorf("failed to generate plots: %w", err)
+	return ot.plots.ComputeStatistics()
+}
+
+// SetReservoir keeps at most size uniformly chosen samples per operation for
+// plots and statistics; 0 keeps every sample
+func (ot *OperationTracker) SetReservoir(size int) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	ot.plots.SetReservoir(size)
+}
+
+// SetBootstrap sets the resampling behind the confidence intervals of the
+// statistics and plots
+func (ot *OperationTracker) SetBootstrap(b Bootstrap) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	ot.plots.SetBootstrap(b)
+}
+
+// Bootstrap returns the resampling behind the confidence intervals
+func (ot *OperationTracker) Bootstrap() Bootstrap {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	return ot.plots.bootstrap
+}
+
+// SetErrorClassifier sets the function that names the class of each failed
+// operation, e.g. "not_found"
+func (ot *OperationTracker) SetErrorClassifier(classify func(error) string) {
+	ot.collector.SetErrorClassifier(classify)
+}
+
+// SetIntendedStart sets the function that returns when a rate-limited run
+// meant to start an operation, given its context. Operations that have one
+// are also measured from it, correcting for coordinated omission. It must be
+// set before the run starts.
+func (ot *OperationTracker) SetIntendedStart(intendedStart func(ctx context.Context) (time.Time, bool)) {
+	ot.intendedStart = intendedStart
+}
+
+// SetSeriesInterval sets the width of the intervals of the time series; 0
+// disables them
+func (ot *OperationTracker) SetSeriesInterval(interval time.Duration) {
+	ot.collector.SetSeriesInterval(interval)
+}
+
+// StartResourceMonitor samples the resource usage of the process every
+// interval until StopResourceMonitor, on the same clock as the samples
+func (ot *OperationTracker) StartResourceMonitor(interval time.Duration) {
+	ot.resources = NewResourceMonitor(ot.plots.start, interval)
+	ot.resources.Start()
+}
+
+// StopResourceMonitor ends resource sampling and returns the samples taken,
+// which the resources plot then draws
+func (ot *OperationTracker) StopResourceMonitor() []ResourceSample {
+	if ot.resources == nil {
+		return nil
 	}
+	samples := ot.resources.Stop()
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	ot.plots.SetResources(samples)
+	return samples
+}
 
-	return nil
+// StartEngineMonitor samples the backend's background work from read every
+// interval until StopEngineMonitor, on the same clock as the samples
+func (ot *OperationTracker) StartEngineMonitor(interval time.Duration, read func() EngineActivity) {
+	ot.engine = NewEngineMonitor(ot.plots.start, interval, read)
+	ot.engine.Start()
 }
 
-// PrintStatistics prints criterion-style additional statistics
-func (ot *OperationTracker) PrintStatistics() {
+// StopEngineMonitor ends engine sampling and returns the samples taken,
+// which the engine plot then draws
+func (ot *OperationTracker) StopEngineMonitor() []EngineSample {
+	if ot.engine == nil {
+		return nil
+	}
+	samples := ot.engine.Stop()
 	ot.mu.Lock()
 	defer ot.mu.Unlock()
+	ot.plots.SetEngine(samples)
+	return samples
+}
+
+// Distributions returns the latency of every operation at percentiles step
+// percent apart
+func (ot *OperationTracker) Distributions(step float64) []Distribution {
+	return ot.collector.Distributions(step)
+}
 
-	ot.plots.PrintStatistics()
+// Histograms returns the latencies of every operation counted into buckets
+// with the given upper bounds in microseconds
+func (ot *OperationTracker) Histograms(bounds []float64) []LatencyHistogram {
+	return ot.collector.Histograms(bounds)
+}
+
+// Series returns the throughput and latency of every operation per interval
+func (ot *OperationTracker) Series() []TimeSeries {
+	return ot.collector.Series()
+}
+
+// SetExcludeErrors leaves failed operations out of the samples and streaming
+// statistics, so plots and statistics describe successful operations only.
+// The results table always keeps them apart.
+func (ot *OperationTracker) SetExcludeErrors(exclude bool) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	ot.noErrors = exclude
+}
+
+// StreamStatistics returns the streaming statistics of the tracked operations,
+// which are kept whether or not samples are retained
+func (ot *OperationTracker) StreamStatistics() []StreamStatistics {
+	ot.flush()
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	return ot.streams.statistics()
+}
+
+// RetainsSamples reports whether the tracker keeps every sample
+func (ot *OperationTracker) RetainsSamples() bool {
+	return ot.retain
+}
+
+// ThreadStatistics returns the per-thread statistics of the tracked operations
+func (ot *OperationTracker) ThreadStatistics() []ThreadStatistics {
+	ot.flush()
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	return ot.plots.ThreadStatistics()
+}
+
+// GenerateThreadPlots creates per-thread scatter plots for the tracked
+// operations and returns the files written
+func (ot *OperationTracker) GenerateThreadPlots(outputDir string, formats []string) ([]string, error) {
+	files, err := ot.snapshot().GenerateThreadPlots(outputDir, formats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate per-thread plots: %w", err)
+	}
+
+	return files, nil
+}
+
+// WriteSamples writes every recorded sample to path for later replotting
+func (ot *OperationTracker) WriteSamples(path string) error {
+	ot.flush()
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	return ot.plots.WriteSamples(path)
+}
+
+// EncodeSamples writes every recorded sample to w in the format of
+// WriteSamples
+func (ot *OperationTracker) EncodeSamples(w io.Writer) error {
+	ot.flush()
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	return ot.plots.EncodeSamples(w)
+}
+
+// SamplesStart returns when tracking began, which the times of the samples
+// are relative to
+func (ot *OperationTracker) SamplesStart() time.Time {
+	return ot.plots.start
+}
+
+// Snapshot returns the histograms and failure counts of every operation, to
+// merge with those of other processes
+func (ot *OperationTracker) Snapshot() CollectorSnapshot {
+	return ot.collector.Snapshot()
+}
+
+// Samples returns the recorded latency of every tracked operation
+func (ot *OperationTracker) Samples() map[string][]time.Duration {
+	ot.flush()
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	return ot.plots.Samples()
+}
+
+// PrintStatistics prints criterion-style additional statistics
+func (ot *OperationTracker) PrintStatistics() {
+	PrintOperationStatistics(ot.ComputeStatistics())
 }
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// nopDB answers every operation at once, so benchmarks of the tracker
+// measure only what it adds
+type nopDB struct{}
+
+func (nopDB) Close() error { return nil }
+func (nopDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+func (nopDB) CleanupThread(context.Context) {}
+func (nopDB) Read(context.Context, string, string, []string) (map[string][]byte, error) {
+	return nil, nil
+}
+func (nopDB) Scan(context.Context, string, string, int, []string) ([]map[string][]byte, error) {
+	return nil, nil
+}
+func (nopDB) Update(context.Context, string, string, map[string][]byte) error { return nil }
+func (nopDB) Insert(context.Context, string, string, map[string][]byte) error { return nil }
+func (nopDB) Delete(context.Context, string, string) error                    { return nil }
+
+// BenchmarkTrack measures the collection overhead per operation: the
+// collector's histograms and time series, two live windows and the streaming
+// statistics, from 1 and 64 threads at once
+func BenchmarkTrack(b *testing.B) {
+	for _, threads := range []int{1, 64} {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			ot := NewOperationTracker(nopDB{}, false)
+			ot.Watch()
+			ot.Watch()
+			ctxs := make([]context.Context, threads)
+			for i := range ctxs {
+				ctxs[i] = ot.InitThread(context.Background(), i, threads)
+			}
+
+			b.ResetTimer()
+			var wg sync.WaitGroup
+			for i, ctx := range ctxs {
+				n := b.N / threads
+				if i < b.N%threads {
+					n++
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < n; j++ {
+						ot.Read(ctx, "usertable", "user1", nil)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otlpScope is the instrumentation scope every exported metric and span
+// belongs to
+const otlpScope = "github.com/jihwankim/polygon-benchmarks/godb-bench"
+
+// OTLPExporter pushes the results of a run to an OpenTelemetry collector over
+// OTLP/HTTP with JSON encoding: every operation's latency histogram,
+// throughput and failures as metrics, and the run itself as one span. Both
+// carry the run's backend, name and environment as resource attributes, so
+// runs can be found and compared next to the services they benchmark.
+type OTLPExporter struct {
+	Endpoint string            // base URL of the collector, e.g. http://localhost:4318
+	Headers  map[string]string // sent with every request, e.g. for authentication
+	Bounds   []float64         // histogram bucket bounds in µs to export with, default DefaultHistogramBounds
+	Client   *http.Client
+}
+
+// NewOTLPExporter creates an exporter for the collector at endpoint. An
+// endpoint without a scheme is taken as plain HTTP.
+func NewOTLPExporter(endpoint string, headers map[string]string) *OTLPExporter {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	return &OTLPExporter{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Headers:  headers,
+		Bounds:   DefaultHistogramBounds,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ParseOTLPHeaders parses headers given as key=value pairs, each of which
+// may hold several comma-separated pairs as OTEL_EXPORTER_OTLP_HEADERS does
+func ParseOTLPHeaders(values []string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, v := range values {
+		for _, pair := range strings.Split(v, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				return nil, fmt.Errorf("invalid header %q: expected key=value", pair)
+			}
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return headers, nil
+}
+
+// OTLP JSON encoding. 64-bit integers are strings and trace and span IDs are
+// hex, as the protobuf JSON mapping requires.
+type (
+	otlpAnyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeInfo struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+
+	otlpMetricsRequest struct {
+		ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+	}
+	otlpResourceMetrics struct {
+		Resource     otlpResource       `json:"resource"`
+		ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+	}
+	otlpScopeMetrics struct {
+		Scope   otlpScopeInfo `json:"scope"`
+		Metrics []otlpMetric  `json:"metrics"`
+	}
+	otlpMetric struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		Unit        string         `json:"unit,omitempty"`
+		Histogram   *otlpHistogram `json:"histogram,omitempty"`
+		Gauge       *otlpGauge     `json:"gauge,omitempty"`
+		Sum         *otlpSum       `json:"sum,omitempty"`
+	}
+	otlpHistogram struct {
+		DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+		AggregationTemporality int                  `json:"aggregationTemporality"`
+	}
+	otlpHistogramPoint struct {
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		TimeUnixNano      string         `json:"timeUnixNano"`
+		Count             string         `json:"count"`
+		Sum               float64        `json:"sum"`
+		BucketCounts      []string       `json:"bucketCounts"`
+		ExplicitBounds    []float64      `json:"explicitBounds"`
+		Min               float64        `json:"min"`
+		Max               float64        `json:"max"`
+	}
+	otlpGauge struct {
+		DataPoints []otlpNumberPoint `json:"dataPoints"`
+	}
+	otlpSum struct {
+		DataPoints             []otlpNumberPoint `json:"dataPoints"`
+		AggregationTemporality int               `json:"aggregationTemporality"`
+		IsMonotonic            bool              `json:"isMonotonic"`
+	}
+	otlpNumberPoint struct {
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano,omitempty"`
+		TimeUnixNano      string         `json:"timeUnixNano"`
+		AsDouble          *float64       `json:"asDouble,omitempty"`
+		AsInt             *string        `json:"asInt,omitempty"`
+	}
+
+	otlpTracesRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScopeInfo `json:"scope"`
+		Spans []otlpSpan    `json:"spans"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	}
+)
+
+// OTLP enum values
+const (
+	otlpCumulative   = 2 // AGGREGATION_TEMPORALITY_CUMULATIVE
+	otlpSpanInternal = 1 // SPAN_KIND_INTERNAL
+)
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int64) otlpKeyValue {
+	s := strconv.FormatInt(value, 10)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &s}}
+}
+
+func otlpBool(key string, value bool) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{BoolValue: &value}}
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// otlpRunResource returns the resource attributes identifying run: the
+// benchmark, its build and host, and the backend and workload it ran
+func otlpRunResource(run Results, runID string) otlpResource {
+	env := run.Environment
+	attrs := []otlpKeyValue{
+		otlpString("service.name", "godb-bench"),
+		otlpString("service.version", env.Version),
+		otlpString("service.instance.id", runID),
+		otlpString("host.name", env.Hostname),
+		otlpString("os.type", env.OS),
+		otlpString("host.arch", env.Arch),
+		otlpString("godb_bench.run", run.Name),
+		otlpString("godb_bench.db", run.DB),
+	}
+	if env.VCSRevision != "" {
+		attrs = append(attrs, otlpString("vcs.revision", env.VCSRevision))
+	}
+	for _, key := range []string{"workload", "recordcount", "operationcount", "threadcount"} {
+		if v, ok := run.Properties[key]; ok {
+			attrs = append(attrs, otlpString("godb_bench.ycsb."+key, v))
+		}
+	}
+	return otlpResource{Attributes: attrs}
+}
+
+// otlpOperation returns the attributes of the data points of one operation
+func otlpOperation(op string, corrected bool) []otlpKeyValue {
+	attrs := []otlpKeyValue{otlpString("operation", op)}
+	if corrected {
+		attrs = append(attrs, otlpBool("corrected", true))
+	}
+	return attrs
+}
+
+// otlpMetrics converts the results of a run into OTLP metrics covering start
+// to end
+func otlpMetrics(run Results, histograms []LatencyHistogram, start, end time.Time) []otlpMetric {
+	startNano, endNano := otlpTime(start), otlpTime(end)
+
+	latency := &otlpHistogram{AggregationTemporality: otlpCumulative}
+	for _, h := range histograms {
+		counts := make([]string, len(h.Counts))
+		for i, n := range h.Counts {
+			counts[i] = strconv.FormatUint(n, 10)
+		}
+		latency.DataPoints = append(latency.DataPoints, otlpHistogramPoint{
+			Attributes:        otlpOperation(h.Operation, h.Corrected),
+			StartTimeUnixNano: startNano,
+			TimeUnixNano:      endNano,
+			Count:             strconv.FormatUint(h.Count, 10),
+			Sum:               h.Sum,
+			BucketCounts:      counts,
+			ExplicitBounds:    h.Bounds,
+			Min:               float64(h.Min),
+			Max:               float64(h.Max),
+		})
+	}
+
+	throughput := &otlpGauge{}
+	failures := &otlpSum{AggregationTemporality: otlpCumulative, IsMonotonic: true}
+	for _, m := range run.Operations {
+		if strings.HasSuffix(m.Operation, "_ERROR") {
+			continue // failures are counted on the operation
+		}
+		ops := m.OPS
+		throughput.DataPoints = append(throughput.DataPoints, otlpNumberPoint{
+			Attributes:   otlpOperation(m.Operation, false),
+			TimeUnixNano: endNano,
+			AsDouble:     &ops,
+		})
+		if m.Operation == "TOTAL" {
+			continue
+		}
+		for _, class := range sortedKeys(m.ErrorClasses) {
+			n := strconv.FormatInt(m.ErrorClasses[class], 10)
+			failures.DataPoints = append(failures.DataPoints, otlpNumberPoint{
+				Attributes:        append(otlpOperation(m.Operation, false), otlpString("error.type", class)),
+				StartTimeUnixNano: startNano,
+				TimeUnixNano:      endNano,
+				AsInt:             &n,
+			})
+		}
+	}
+
+	result := []otlpMetric{
+		{Name: "godb_bench.operation.duration", Description: "Latency of the operations in the measured window", Unit: "us", Histogram: latency},
+		{Name: "godb_bench.operation.throughput", Description: "Operations per second over the run", Unit: "{operation}/s", Gauge: throughput},
+	}
+	if len(failures.DataPoints) > 0 {
+		result = append(result, otlpMetric{Name: "godb_bench.operation.errors", Description: "Failed operations by error class", Unit: "{operation}", Sum: failures})
+	}
+	return result
+}
+
+// otlpRunSpan returns the span of the whole run, carrying every run property
+// and the headline results
+func otlpRunSpan(run Results, traceID, spanID string, start, end time.Time) otlpSpan {
+	attrs := []otlpKeyValue{
+		otlpString("godb_bench.db", run.DB),
+		otlpString("godb_bench.run", run.Name),
+	}
+	for _, key := range sortedKeys(run.Properties) {
+		attrs = append(attrs, otlpString("godb_bench.property."+key, run.Properties[key]))
+	}
+	if total, ok := FindMetrics(run.Operations, "TOTAL"); ok {
+		attrs = append(attrs,
+			otlpInt("godb_bench.total.count", total.Count),
+			otlpInt("godb_bench.total.errors", total.Errors),
+			otlpKeyValue{Key: "godb_bench.total.throughput", Value: otlpAnyValue{DoubleValue: &total.OPS}},
+			otlpInt("godb_bench.total.p99_us", total.P99))
+	}
+	return otlpSpan{
+		TraceID:           traceID,
+		SpanID:            spanID,
+		Name:              fmt.Sprintf("godb-bench %s %s", run.DB, run.Name),
+		Kind:              otlpSpanInternal,
+		StartTimeUnixNano: otlpTime(start),
+		EndTimeUnixNano:   otlpTime(end),
+		Attributes:        attrs,
+	}
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// randomID returns n random bytes in hex
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Export pushes the metrics and the span of run, which ran from start to end
+// with the given latency histograms. The run ID is the span's trace ID and
+// the metrics' service.instance.id, so the two can be joined in the backend.
+func (e *OTLPExporter) Export(ctx context.Context, run Results, histograms []LatencyHistogram, start, end time.Time) error {
+	traceID := run.ID
+	if len(traceID) != 32 {
+		traceID = randomID(16)
+	}
+	resource := otlpRunResource(run, traceID)
+	scope := otlpScopeInfo{Name: otlpScope, Version: run.Environment.Version}
+
+	metricsReq := otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     resource,
+		ScopeMetrics: []otlpScopeMetrics{{Scope: scope, Metrics: otlpMetrics(run, histograms, start, end)}},
+	}}}
+	if err := e.post(ctx, "/v1/metrics", metricsReq); err != nil {
+		return err
+	}
+
+	tracesReq := otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   resource,
+		ScopeSpans: []otlpScopeSpans{{Scope: scope, Spans: []otlpSpan{otlpRunSpan(run, traceID, randomID(8), start, end)}}},
+	}}}
+	return e.post(ctx, "/v1/traces", tracesReq)
+}
+
+// post sends one OTLP request to path under the endpoint
+func (e *OTLPExporter) post(ctx context.Context, path string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	url := e.Endpoint + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to export to %s: %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// SampleSet is the per-operation samples of one run or backend, as returned
+// by OperationTracker.Samples
+type SampleSet struct {
+	Name    string
+	Samples map[string][]time.Duration
+}
+
+// GenerateOverlayPlots draws the sample sets on shared charts, one series per
+// set, for every operation at least two sets have: a sample-times scatter and
+// a density plot. Returns the files written, ordered by operation.
+func GenerateOverlayPlots(sets []SampleSet, outputDir string, formats []string, style PlotStyle) ([]string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	seen := make(map[string]int)
+	for _, set := range sets {
+		for operation, samples := range set.Samples {
+			if len(samples) > 1 {
+				seen[operation]++
+			}
+		}
+	}
+	var operations []string
+	for operation, n := range seen {
+		if n > 1 {
+			operations = append(operations, operation)
+		}
+	}
+	sort.Strings(operations)
+
+	var files []string
+	for _, operation := range operations {
+		var names []string
+		var series [][]SampleData
+		for _, set := range sets {
+			times := set.Samples[operation]
+			if len(times) < 2 {
+				continue
+			}
+			samples := make([]SampleData, len(times))
+			for i, t := range times {
+				samples[i] = SampleData{SampleIndex: int64(i + 1), TotalTime: t}
+			}
+			names = append(names, set.Name)
+			series = append(series, samples)
+		}
+
+		base := filepath.Join(outputDir, operation+"_")
+		written, err := generateOverlaySampleTimesPlot(operation, names, series, base+"sample_times", formats, style)
+		files = append(files, written...)
+		if err != nil {
+			fmt.Printf("Warning: failed to generate overlay plot for %s: %v\n", operation, err)
+		}
+		written, err = generateOverlayPDFPlot(operation, names, series, base+"pdf", formats, style)
+		files = append(files, written...)
+		if err != nil {
+			fmt.Printf("Warning: failed to generate overlay density plot for %s: %v\n", operation, err)
+		}
+	}
+	return files, nil
+}
+
+// generateOverlaySampleTimesPlot scatters each series' sample times in its
+// own color
+func generateOverlaySampleTimesPlot(operation string, names []string, series [][]SampleData, base string, formats []string, style PlotStyle) ([]string, error) {
+	p, err := style.newPlot(operation, "Sample Times")
+	if err != nil {
+		return nil, err
+	}
+
+	p.X.Label.Text = "Sample Index"
+	p.Y.Label.Text = "Time (µs)"
+	p.Legend.Top = true
+	style.latencyAxis(&p.Y)
+
+	for i, samples := range series {
+		pts := make(plotter.XYs, len(samples))
+		for j, sample := range samples {
+			pts[j].X = float64(sample.SampleIndex)
+			pts[j].Y = style.latency(float64(sample.TotalTime.Nanoseconds()) / 1000)
+		}
+		pts = decimate(pts, style.MaxPoints)
+		scatter, err := plotter.NewScatter(pts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create scatter plot: %w", err)
+		}
+		scatter.GlyphStyle.Color = style.color(i)
+		scatter.GlyphStyle.Radius = style.PointRadius
+		p.Add(scatter)
+		p.Legend.Add(names[i], scatter)
+	}
+
+	p.Add(style.grid())
+	return style.save(p, base, formats)
+}
+
+// generateOverlayPDFPlot draws each series' kernel density estimate over a
+// shared range, with a dashed median marker in the series' color
+func generateOverlayPDFPlot(operation string, names []string, series [][]SampleData, base string, formats []string, style PlotStyle) ([]string, error) {
+	dists := make([]latencyDistribution, len(series))
+	lo, hi := math.MaxFloat64, 0.0
+	for i, samples := range series {
+		dists[i] = newLatencyDistribution(samples)
+		l, h := dists[i].densityRange()
+		lo, hi = math.Min(lo, l), math.Max(hi, h)
+	}
+
+	p, err := style.newPlot(operation, "Probability Density")
+	if err != nil {
+		return nil, err
+	}
+
+	p.X.Label.Text = "Time (µs)"
+	p.Y.Label.Text = "Density (a.u.)"
+	p.Legend.Top = true
+
+	for i, dist := range dists {
+		density := kernelDensity(dist.sorted, dist.bandwidth, lo, hi)
+		var peak float64
+		for _, pt := range density {
+			peak = math.Max(peak, pt.Y)
+		}
+
+		line, err := plotter.NewLine(density)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create density line: %w", err)
+		}
+		line.LineStyle.Color = style.color(i)
+		line.LineStyle.Width = vg.Points(1.5)
+
+		marker, err := plotter.NewLine(plotter.XYs{{X: dist.median, Y: 0}, {X: dist.median, Y: peak}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create median marker: %w", err)
+		}
+		marker.LineStyle.Color = style.color(i)
+		marker.LineStyle.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+
+		p.Add(line, marker)
+		p.Legend.Add(fmt.Sprintf("%s (median %.2f µs)", names[i], dist.median), line)
+	}
+
+	p.Add(style.grid())
+	return style.save(p, base, formats)
+}
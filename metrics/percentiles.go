@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultPercentiles are the percentile columns of the results tables
+var DefaultPercentiles = []float64{50, 95, 99, 99.9}
+
+// tablePercentiles are the percentile columns, set via SetPercentiles
+var tablePercentiles = DefaultPercentiles
+
+// ParsePercentiles parses a comma-separated list of percentiles such as
+// "50,90,99.99"
+func ParsePercentiles(s string) ([]float64, error) {
+	var percentiles []float64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(strings.TrimPrefix(field, "p"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percentile %q", field)
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles, nil
+}
+
+// ValidatePercentiles returns an error if percentiles cannot be used as
+// table columns
+func ValidatePercentiles(percentiles []float64) error {
+	if len(percentiles) == 0 {
+		return fmt.Errorf("no percentiles given")
+	}
+	for _, p := range percentiles {
+		if p <= 0 || p >= 100 {
+			return fmt.Errorf("percentile %g is not between 0 and 100", p)
+		}
+	}
+	return nil
+}
+
+// SetPercentiles sets the percentile columns of the results tables and the
+// percentiles the collector reports, in ascending order
+func SetPercentiles(percentiles []float64) error {
+	if err := ValidatePercentiles(percentiles); err != nil {
+		return err
+	}
+	percentiles = slices.Clone(percentiles)
+	slices.Sort(percentiles)
+	tablePercentiles = slices.Compact(percentiles)
+	return nil
+}
+
+// percentileLabel names percentile p, e.g. "p99.99"
+func percentileLabel(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// Percentile returns the latency at percentile p in microseconds, if the
+// row has it: either one of the fixed percentiles or one configured when it
+// was collected
+func (m OperationMetrics) Percentile(p float64) (int64, bool) {
+	if v, ok := m.Percentiles[percentileLabel(p)]; ok {
+		return v, true
+	}
+	switch p {
+	case 50:
+		return m.P50, true
+	case 90:
+		return m.P90, true
+	case 95:
+		return m.P95, true
+	case 99:
+		return m.P99, true
+	case 99.9:
+		return m.P999, true
+	}
+	return 0, false
+}
+
+// formatPercentile formats the latency of m at percentile p, or "-" if the
+// row does not have it
+func formatPercentile(m OperationMetrics, p float64) string {
+	if v, ok := m.Percentile(p); ok {
+		return strconv.FormatInt(v, 10)
+	}
+	return "-"
+}
+
+// Distribution is the latency of an operation at every percentile of a fine
+// grid, the whole curve rather than a few columns, e.g. for SLO modeling
+type Distribution struct {
+	Operation string              `json:"operation"`
+	Corrected bool                `json:"corrected,omitempty"` // measured from the intended start
+	Points    []DistributionPoint `json:"points"`
+}
+
+// DistributionPoint is the latency at one percentile
+type DistributionPoint struct {
+	Percentile float64 `json:"percentile"`
+	Latency    int64   `json:"latency_us"`
+}
+
+// ValidateDistributionStep returns an error if step, in percent, cannot be
+// used as the resolution of a distribution
+func ValidateDistributionStep(step float64) error {
+	if step <= 0 || step > 50 {
+		return fmt.Errorf("distribution step must be between 0 and 50 percent, got %g", step)
+	}
+	return nil
+}
+
+// distributionPercentiles returns 0 to 100 in steps of step percent, with the
+// tail refined to p99, p99.9, p99.99 and p99.999 where the grid is coarser
+func distributionPercentiles(step float64) []float64 {
+	n := int(math.Round(100 / step))
+	percentiles := make([]float64, 0, n+5)
+	for i := range n {
+		// Rounded so that 0.1 steps give 99.9 rather than 99.90000000000001
+		percentiles = append(percentiles, math.Round(float64(i)*step*1e6)/1e6)
+	}
+	for _, p := range []float64{99, 99.9, 99.99, 99.999} {
+		if p > percentiles[len(percentiles)-1] {
+			percentiles = append(percentiles, p)
+		}
+	}
+	return append(percentiles, 100)
+}
+
+// Distributions returns the latency distribution of every operation ordered
+// by name, with TOTAL last, at percentiles step percent apart. Paced runs
+// also get the distributions measured from the intended start.
+func (c *Collector) Distributions(step float64) []Distribution {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fold()
+
+	percentiles := distributionPercentiles(step)
+	var result []Distribution
+	for _, set := range []struct {
+		histograms map[string]*opHistogram
+		corrected  bool
+	}{{c.histograms, false}, {c.corrected, true}} {
+		operations := make([]string, 0, len(set.histograms))
+		for op := range set.histograms {
+			if op != "TOTAL" {
+				operations = append(operations, op)
+			}
+		}
+		sort.Strings(operations)
+		if _, ok := set.histograms["TOTAL"]; ok {
+			operations = append(operations, "TOTAL")
+		}
+
+		for _, op := range operations {
+			h := set.histograms[op].hist
+			d := Distribution{Operation: op, Corrected: set.corrected, Points: make([]DistributionPoint, len(percentiles))}
+			for i, p := range percentiles {
+				d.Points[i] = DistributionPoint{Percentile: p, Latency: h.ValueAtPercentile(p)}
+			}
+			d.Points[0].Latency = h.Min() // hdrhistogram gives 0 at p0
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// DefaultHistogramBounds are the upper bounds, in microseconds, of the
+// latency buckets handed to exporters: 1-2-5 steps from 1µs to 10s
+var DefaultHistogramBounds = []float64{
+	1, 2, 5, 10, 20, 50, 100, 200, 500,
+	1_000, 2_000, 5_000, 10_000, 20_000, 50_000, 100_000, 200_000, 500_000,
+	1_000_000, 2_000_000, 5_000_000, 10_000_000,
+}
+
+// LatencyHistogram is the latency distribution of one operation in explicit
+// buckets, the shape metrics backends such as OpenTelemetry store histograms
+// in. Counts[i] holds the latencies in (Bounds[i-1], Bounds[i]], and the last
+// count those above every bound.
+type LatencyHistogram struct {
+	Operation string
+	Corrected bool      // measured from the intended start
+	Bounds    []float64 // microseconds
+	Counts    []uint64
+	Count     uint64
+	Sum       float64 // microseconds
+	Min       int64   // microseconds
+	Max       int64   // microseconds
+}
+
+// Histograms returns the latencies of every operation counted into buckets
+// with the given upper bounds, in the same order as Distributions. Latencies
+// are placed by the lowest value hdrhistogram records them as, so one within
+// 0.1% above a bound may be counted below it.
+func (c *Collector) Histograms(bounds []float64) []LatencyHistogram {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fold()
+
+	var result []LatencyHistogram
+	for _, set := range []struct {
+		histograms map[string]*opHistogram
+		corrected  bool
+	}{{c.histograms, false}, {c.corrected, true}} {
+		operations := make([]string, 0, len(set.histograms))
+		for op := range set.histograms {
+			if op != "TOTAL" {
+				operations = append(operations, op)
+			}
+		}
+		sort.Strings(operations)
+		if _, ok := set.histograms["TOTAL"]; ok {
+			operations = append(operations, "TOTAL")
+		}
+
+		for _, op := range operations {
+			h := set.histograms[op]
+			lh := LatencyHistogram{
+				Operation: op,
+				Corrected: set.corrected,
+				Bounds:    bounds,
+				Counts:    make([]uint64, len(bounds)+1),
+				Count:     uint64(h.hist.TotalCount()),
+				Sum:       float64(h.total.Nanoseconds()) / 1000,
+				Min:       h.hist.Min(),
+				Max:       h.hist.Max(),
+			}
+			for _, bar := range h.hist.Distribution() {
+				if bar.Count > 0 {
+					lh.Counts[sort.SearchFloat64s(bounds, float64(bar.From))] += uint64(bar.Count)
+				}
+			}
+			result = append(result, lh)
+		}
+	}
+	return result
+}
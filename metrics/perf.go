@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PerfCounters are the CPU's hardware event counts over the measured window
+// of a run, for every thread of the process, and the rates derived from them.
+// Rates whose events could not be counted are 0.
+type PerfCounters struct {
+	Phase    string           `json:"phase"`     // PhaseLoad or PhaseRun
+	UserOnly bool             `json:"user_only"` // kernel time excluded, as perf_event_paranoid required
+	Counts   map[string]int64 `json:"counts"`    // by perf(1) event name, e.g. "cache-misses"
+
+	IPC               float64 `json:"ipc,omitempty"`              // instructions per cycle
+	CacheMissRate     float64 `json:"cache_miss_rate,omitempty"`  // share of cache references that missed
+	BranchMissRate    float64 `json:"branch_miss_rate,omitempty"` // share of branches mispredicted
+	CyclesPerOp       float64 `json:"cycles_per_op,omitempty"`
+	InstructionsPerOp float64 `json:"instructions_per_op,omitempty"`
+}
+
+// NewPerfCounters derives the rates of counts, taken over ops operations
+// of phase
+func NewPerfCounters(phase string, userOnly bool, counts map[string]int64, ops int64) *PerfCounters {
+	p := &PerfCounters{Phase: phase, UserOnly: userOnly, Counts: counts}
+	ratio := func(num, den string) float64 {
+		n, okN := counts[num]
+		d, okD := counts[den]
+		if !okN || !okD || d == 0 {
+			return 0
+		}
+		return float64(n) / float64(d)
+	}
+	p.IPC = ratio("instructions", "cycles")
+	p.CacheMissRate = ratio("cache-misses", "cache-references")
+	p.BranchMissRate = ratio("branch-misses", "branches")
+	if ops > 0 {
+		p.CyclesPerOp = float64(counts["cycles"]) / float64(ops)
+		p.InstructionsPerOp = float64(counts["instructions"]) / float64(ops)
+	}
+	return p
+}
+
+// PrintPerfCounters prints the hardware counters of a run
+func PrintPerfCounters(p *PerfCounters) {
+	if p == nil {
+		return
+	}
+	title := fmt.Sprintf("Hardware Counters (%s phase", p.Phase)
+	if p.UserOnly {
+		title += ", user space only"
+	}
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println(title + "):")
+	fmt.Println(strings.Repeat("=", 80))
+
+	var rows [][2]string
+	if p.IPC > 0 {
+		rows = append(rows, [2]string{"IPC", fmt.Sprintf("%.2f instructions per cycle", p.IPC)})
+	}
+	if p.CacheMissRate > 0 {
+		rows = append(rows, [2]string{"Cache misses", fmt.Sprintf("%.2f%% of cache references", 100*p.CacheMissRate)})
+	}
+	if p.BranchMissRate > 0 {
+		rows = append(rows, [2]string{"Branch misses", fmt.Sprintf("%.2f%% of branches", 100*p.BranchMissRate)})
+	}
+	if p.CyclesPerOp > 0 {
+		rows = append(rows, [2]string{"Per operation", fmt.Sprintf("%.0f cycles, %.0f instructions", p.CyclesPerOp, p.InstructionsPerOp)})
+	}
+	events := make([]string, 0, len(p.Counts))
+	for name := range p.Counts {
+		events = append(events, name)
+	}
+	sort.Strings(events)
+	for _, name := range events {
+		rows = append(rows, [2]string{name, fmt.Sprintf("%d", p.Counts[name])})
+	}
+	for _, row := range rows {
+		fmt.Printf("%-30s %s\n", row[0], row[1])
+	}
+}
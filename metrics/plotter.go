@@ -3,12 +3,22 @@ package metrics
 import (
 	"fmt"
 	"image/color"
+	"maps"
+	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette/moreland"
 	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
 )
 
@@ -16,12 +26,67 @@ import (
 type SampleData struct {
 	SampleIndex int64         // The sequential sample number for this operation
 	TotalTime   time.Duration // Time taken for this sample
+	Elapsed     time.Duration // When the sample started, relative to when tracking began
+	Thread      int           // YCSB thread that took the sample
 }
 
 // BenchmarkPlots contains data for generating criterion-style plots
 type BenchmarkPlots struct {
 	samples        map[string][]SampleData // operation -> samples
 	sampleCounters map[string]int64        // operation -> current sample count
+	start          time.Time               // when tracking began
+	style          PlotStyle
+	bootstrap      Bootstrap       // resampling of the statistics' and plots' intervals
+	kinds          map[string]bool // plots GeneratePlots draws; nil draws all
+	reservoir      int             // most samples kept per operation; 0 keeps all
+	rng            *rand.Rand      // picks the samples the reservoir replaces
+	unordered      bool            // the reservoir replaced samples out of order
+	resources      []ResourceSample
+	engine         []EngineSample
+	ramp           *ramp // nil without a concurrency ramp
+}
+
+// PlotKinds are the plots GeneratePlots can draw, named as in the file names
+var PlotKinds = []string{"sample_times", "heatmap", "rolling", "regression", "pdf", "summary", "resources", "engine", "ramp"}
+
+// ValidatePlotKinds returns an error if any kind is not in PlotKinds
+func ValidatePlotKinds(kinds []string) error {
+	for _, k := range kinds {
+		known := false
+		for _, kind := range PlotKinds {
+			if k == kind {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown plot kind %q (expected one of %s)", k, strings.Join(PlotKinds, ", "))
+		}
+	}
+	return nil
+}
+
+// PlotFormats are the image formats plots can be saved in
+var PlotFormats = []string{"png", "svg", "pdf", "eps", "jpg", "tiff"}
+
+// ValidatePlotFormats returns an error if any format is not in PlotFormats
+func ValidatePlotFormats(formats []string) error {
+	if len(formats) == 0 {
+		return fmt.Errorf("at least one plot format is required")
+	}
+	for _, f := range formats {
+		supported := false
+		for _, known := range PlotFormats {
+			if f == known {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("unknown plot format %q (expected one of %s)", f, strings.Join(PlotFormats, ", "))
+		}
+	}
+	return nil
 }
 
 // NewBenchmarkPlots creates a new BenchmarkPlots instance
@@ -29,82 +94,784 @@ func NewBenchmarkPlots() *BenchmarkPlots {
 	return &BenchmarkPlots{
 		samples:        make(map[string][]SampleData),
 		sampleCounters: make(map[string]int64),
+		start:          time.Now(),
+		style:          DefaultPlotStyle(),
+		bootstrap:      DefaultBootstrap(),
 	}
 }
 
-// AddSample records a sample for an operation
-// The sample index is automatically incremented for each operation
-func (bp *BenchmarkPlots) AddSample(operation string, totalTime time.Duration) {
+// SetBootstrap sets the resampling behind the confidence intervals of the
+// statistics and plots. Its seed also seeds the reservoir.
+func (bp *BenchmarkPlots) SetBootstrap(b Bootstrap) {
+	bp.bootstrap = b
+	if bp.rng != nil {
+		bp.rng = b.newRand()
+	}
+}
+
+// SetKinds limits GeneratePlots to the given PlotKinds; none draws all
+func (bp *BenchmarkPlots) SetKinds(kinds []string) {
+	bp.kinds = nil
+	if len(kinds) > 0 {
+		bp.kinds = make(map[string]bool, len(kinds))
+		for _, k := range kinds {
+			bp.kinds[k] = true
+		}
+	}
+}
+
+// drawn reports whether GeneratePlots draws plots of kind
+func (bp *BenchmarkPlots) drawn(kind string) bool {
+	return bp.kinds == nil || bp.kinds[kind]
+}
+
+// SetStyle sets the size, colors, title template and theme of the plots
+func (bp *BenchmarkPlots) SetStyle(style PlotStyle) {
+	bp.style = style
+}
+
+// SetResources sets the resource usage the resources plot draws against the
+// latency timeline
+func (bp *BenchmarkPlots) SetResources(samples []ResourceSample) {
+	bp.resources = samples
+}
+
+// SetEngine sets the engine samples the engine plot draws against the
+// latency timeline
+func (bp *BenchmarkPlots) SetEngine(samples []EngineSample) {
+	bp.engine = samples
+}
+
+// SetRamp sets the concurrency ramp the ramp plot draws the steps of
+func (bp *BenchmarkPlots) SetRamp(r *ramp) {
+	bp.ramp = r
+}
+
+// SetReservoir keeps at most size uniformly chosen samples per operation, so
+// memory stays flat however long the run; 0 keeps every sample
+func (bp *BenchmarkPlots) SetReservoir(size int) {
+	bp.reservoir = size
+	if size > 0 && bp.rng == nil {
+		bp.rng = bp.bootstrap.newRand()
+	}
+}
+
+// AddSample records a sample for an operation that thread started at start.
+// Threads hand their samples over in blocks, so they can come out of the
+// order they started in; samples are numbered in that order when read.
+func (bp *BenchmarkPlots) AddSample(operation string, thread int, start time.Time, totalTime time.Duration) {
 	bp.sampleCounters[operation]++
-	bp.samples[operation] = append(bp.samples[operation], SampleData{
+	sample := SampleData{
 		SampleIndex: bp.sampleCounters[operation],
 		TotalTime:   totalTime,
-	})
+		Elapsed:     start.Sub(bp.start),
+		Thread:      thread,
+	}
+
+	samples := bp.samples[operation]
+	if n := len(samples); n > 0 && sample.Elapsed < samples[n-1].Elapsed {
+		bp.unordered = true
+	}
+	if bp.reservoir <= 0 || len(samples) < bp.reservoir {
+		bp.samples[operation] = append(samples, sample)
+		return
+	}
+
+	// Algorithm R: the n-th sample replaces a kept one with probability
+	// reservoir/n, which leaves every sample equally likely to be kept
+	if j := bp.rng.Int63n(bp.sampleCounters[operation]); j < int64(bp.reservoir) {
+		samples[j] = sample
+		bp.unordered = true
+	}
+}
+
+// order sorts the samples by when they started, after blocks of samples of
+// several threads or the reservoir put them out of order, and numbers them
+// again in that order. Samples the reservoir kept are numbered evenly over
+// every sample taken, as it keeps them uniformly.
+func (bp *BenchmarkPlots) order() {
+	if !bp.unordered {
+		return
+	}
+	for operation, samples := range bp.samples {
+		sort.SliceStable(samples, func(i, j int) bool { return samples[i].Elapsed < samples[j].Elapsed })
+		taken := max(bp.sampleCounters[operation], int64(len(samples)))
+		for i := range samples {
+			samples[i].SampleIndex = int64(i+1) * taken / int64(len(samples))
+		}
+	}
+	bp.unordered = false
+}
+
+// snapshot returns a copy of bp whose samples, in the order they were taken,
+// are its own, so plots can be drawn from it while bp keeps recording
+func (bp *BenchmarkPlots) snapshot() *BenchmarkPlots {
+	bp.order()
+	c := *bp
+	c.samples = make(map[string][]SampleData, len(bp.samples))
+	for operation, samples := range bp.samples {
+		c.samples[operation] = slices.Clone(samples)
+	}
+	c.sampleCounters = maps.Clone(bp.sampleCounters)
+	c.rng = nil
+	return &c
+}
+
+// Samples returns a copy of the recorded sample times of every operation, in
+// the order they were taken
+func (bp *BenchmarkPlots) Samples() map[string][]time.Duration {
+	bp.order()
+
+	result := make(map[string][]time.Duration, len(bp.samples))
+	for operation, samples := range bp.samples {
+		times := make([]time.Duration, len(samples))
+		for i, s := range samples {
+			times[i] = s.TotalTime
+		}
+		result[operation] = times
+	}
+	return result
 }
 
 // GeneratePlots creates scatter plots for all operations showing progression over time
-// outputDir is the directory where plots will be saved
-func (bp *BenchmarkPlots) GeneratePlots(outputDir string) error {
+// outputDir is the directory where plots will be saved, once per format.
+// Returns the files written, ordered by operation.
+func (bp *BenchmarkPlots) GeneratePlots(outputDir string, formats []string) ([]string, error) {
+	bp.order()
+
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	operations := make([]string, 0, len(bp.samples))
 	for operation, samples := range bp.samples {
-		if len(samples) == 0 {
-			continue
+		if len(samples) > 0 {
+			operations = append(operations, operation)
 		}
+	}
+	sort.Strings(operations)
+
+	generators := []struct {
+		kind     string
+		name     string // used in warnings
+		generate func(string, []SampleData, string, []string) ([]string, error)
+	}{
+		{"sample_times", "plot", bp.generateSampleTimesPlot},
+		{"heatmap", "heatmap", bp.generateHeatmapPlot},
+		{"rolling", "rolling plot", bp.generateRollingPlot},
+		{"regression", "regression plot", bp.generateRegressionPlot},
+		{"pdf", "density plot", bp.generatePDFPlot},
+	}
 
-		// Generate sample times plot
-		if err := bp.generateSampleTimesPlot(operation, samples, outputDir); err != nil {
-			fmt.Printf("Warning: failed to generate plot for %s: %v\n", operation, err)
+	var jobs []plotJob
+	for _, operation := range operations {
+		for _, g := range generators {
+			if !bp.drawn(g.kind) {
+				continue
+			}
+			jobs = append(jobs, plotJob{g.name + " for " + operation, func() ([]string, error) {
+				return g.generate(operation, bp.samples[operation], outputDir, formats)
+			}})
 		}
 	}
 
-	return nil
+	// The all-operations summary
+	if bp.drawn("summary") {
+		jobs = append(jobs, plotJob{"summary plot", func() ([]string, error) {
+			return bp.generateSummaryPlot(operations, outputDir, formats)
+		}})
+	}
+
+	// The resource usage against the latency timeline
+	if bp.drawn("resources") {
+		jobs = append(jobs, plotJob{"resources plot", func() ([]string, error) {
+			return bp.generateResourcesPlot(operations, outputDir, formats)
+		}})
+	}
+
+	// The engine's backlog and write stalls against the latency timeline
+	if bp.drawn("engine") {
+		jobs = append(jobs, plotJob{"engine plot", func() ([]string, error) {
+			return bp.generateEnginePlot(operations, outputDir, formats)
+		}})
+	}
+
+	// The throughput and p99 of every step of a concurrency ramp
+	if bp.drawn("ramp") {
+		jobs = append(jobs, plotJob{"ramp plot", func() ([]string, error) {
+			return bp.generateRampPlot(outputDir, formats)
+		}})
+	}
+
+	return drawPlots(jobs), nil
+}
+
+// plotJob draws one plot
+type plotJob struct {
+	name string // used in warnings
+	draw func() ([]string, error)
+}
+
+// drawPlots draws jobs on as many goroutines as there are CPUs to run them
+// and returns the files written, in the order of the jobs. Jobs that fail are
+// reported and skipped.
+func drawPlots(jobs []plotJob) []string {
+	written := make([][]string, len(jobs))
+	errs := make([]error, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				written[i], errs[i] = jobs[i].draw()
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var files []string
+	for i, job := range jobs {
+		files = append(files, written[i]...)
+		if errs[i] != nil {
+			fmt.Printf("Warning: failed to generate %s: %v\n", job.name, errs[i])
+		}
+	}
+	return files
 }
 
 // generateSampleTimesPlot creates a scatter plot of sample time vs sample index
 // Each point represents one sample, showing the progression of operation times
-func (bp *BenchmarkPlots) generateSampleTimesPlot(operation string, samples []SampleData, outputDir string) error {
+func (bp *BenchmarkPlots) generateSampleTimesPlot(operation string, samples []SampleData, outputDir string, formats []string) ([]string, error) {
 	fmt.Printf("DEBUG: Generating plot for operation '%s' with %d samples.\n", operation, len(samples))
-	p, err := plot.New()
+	p, err := bp.style.newPlot(operation, "Sample Times")
 	if err != nil {
-		return fmt.Errorf("failed to create plot: %w", err)
+		return nil, err
 	}
 
-	p.Title.Text = fmt.Sprintf("%s: Sample Times", operation)
 	p.X.Label.Text = "Sample Index"
 	p.Y.Label.Text = "Time (µs)"
+	bp.style.latencyAxis(&p.Y)
 
 	// Create scatter plot data
 	pts := make(plotter.XYs, len(samples))
 	for i, sample := range samples {
 		pts[i].X = float64(sample.SampleIndex)
-		pts[i].Y = float64(sample.TotalTime.Microseconds())
+		pts[i].Y = bp.style.latency(float64(sample.TotalTime.Nanoseconds()) / 1000)
+	}
+	if shown := decimate(pts, bp.style.MaxPoints); len(shown) < len(pts) {
+		p.Title.Text = bp.style.title(operation, fmt.Sprintf("Sample Times (%d of %d samples shown)", len(shown), len(pts)))
+		pts = shown
 	}
 
-	// Create scatter plot
-	scatter, err := plotter.NewScatter(pts)
-	if err != nil {
-		return fmt.Errorf("failed to create scatter plot: %w", err)
+	// Split off the outliers so they can be drawn in their own colors
+	type sampleSeries struct {
+		name string
+		pts  plotter.XYs
+	}
+	series := []sampleSeries{{"Sample", pts}}
+	if bp.style.Outliers {
+		times := make([]float64, len(samples))
+		for i, sample := range samples {
+			times[i] = float64(sample.TotalTime.Nanoseconds()) / 1000
+		}
+		fences := tukeyFences(times)
+		var normal, mild, severe plotter.XYs
+		for _, pt := range pts {
+			switch {
+			case pt.Y < fences[0] || pt.Y > fences[3]:
+				severe = append(severe, pt)
+			case pt.Y < fences[1] || pt.Y > fences[2]:
+				mild = append(mild, pt)
+			default:
+				normal = append(normal, pt)
+			}
+		}
+		series = []sampleSeries{{"Sample", normal}, {"Mild outlier", mild}, {"Severe outlier", severe}}
+		p.Legend.Top = true
 	}
 
-	// Customize appearance
-	scatter.GlyphStyle.Color = color.RGBA{R: 70, G: 130, B: 180, A: 255} // Steel blue
-	scatter.GlyphStyle.Radius = vg.Points(1)
+	for i, s := range series {
+		if len(s.pts) == 0 {
+			continue
+		}
+		scatter, err := plotter.NewScatter(s.pts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create scatter plot: %w", err)
+		}
+
+		// Customize appearance
+		scatter.GlyphStyle.Color = bp.style.color(i)
+		scatter.GlyphStyle.Radius = bp.style.PointRadius
 
-	p.Add(scatter)
+		p.Add(scatter)
+		if len(series) > 1 {
+			p.Legend.Add(s.name, scatter)
+		}
+	}
 
 	// Add grid
-	p.Add(plotter.NewGrid())
+	p.Add(bp.style.grid())
 
 	// Save the plot
-	timestamp := time.Now().Format("20060102-150405")
-	filename := filepath.Join(outputDir, fmt.Sprintf("%s_%s_sample_times.png", operation, timestamp))
-	if err := p.Save(8*vg.Inch, 6*vg.Inch, filename); err != nil {
-		return fmt.Errorf("failed to save plot: %w", err)
+	base := filepath.Join(outputDir, operation+"_sample_times")
+	return bp.style.save(p, base, formats)
+}
+
+// decimate reduces pts to at most maxPoints by keeping the fastest and the
+// slowest point of each run of consecutive points, so outliers stay visible
+// while the bulk is thinned. maxPoints of 0 keeps every point.
+func decimate(pts plotter.XYs, maxPoints int) plotter.XYs {
+	if maxPoints <= 0 || len(pts) <= maxPoints {
+		return pts
 	}
 
-	fmt.Printf("Generated plot: %s\n", filename)
-	return nil
+	buckets := max(1, maxPoints/2)
+	size := (len(pts) + buckets - 1) / buckets
+	out := make(plotter.XYs, 0, 2*buckets)
+	for start := 0; start < len(pts); start += size {
+		end := min(start+size, len(pts))
+		lo, hi := start, start
+		for i := start + 1; i < end; i++ {
+			if pts[i].Y < pts[lo].Y {
+				lo = i
+			}
+			if pts[i].Y > pts[hi].Y {
+				hi = i
+			}
+		}
+		// Keep the two points in sample order
+		out = append(out, pts[min(lo, hi)])
+		if lo != hi {
+			out = append(out, pts[max(lo, hi)])
+		}
+	}
+	return out
+}
+
+// Heatmap resolution: time columns and logarithmic latency rows
+const (
+	heatmapColumns = 120
+	heatmapRows    = 60
+)
+
+// latencyHeatGrid is a GridXYZ of sample counts by elapsed time and latency.
+// Empty cells are NaN so they are left blank.
+type latencyHeatGrid struct {
+	xs, ys []float64   // column and row centers in seconds and µs
+	counts [][]float64 // [column][row]
+	max    float64
+}
+
+func (g *latencyHeatGrid) Dims() (c, r int)   { return len(g.xs), len(g.ys) }
+func (g *latencyHeatGrid) Z(c, r int) float64 { return g.counts[c][r] }
+func (g *latencyHeatGrid) X(c int) float64    { return g.xs[c] }
+func (g *latencyHeatGrid) Y(r int) float64    { return g.ys[r] }
+func (g *latencyHeatGrid) Min() float64       { return 0 }
+func (g *latencyHeatGrid) Max() float64       { return g.max }
+
+// generateHeatmapPlot creates an HdrHistogram-style heatmap: elapsed time on
+// the X axis, logarithmic latency buckets on the Y axis and the log of the
+// sample count as color. It shows how the tail evolves over a long run.
+func (bp *BenchmarkPlots) generateHeatmapPlot(operation string, samples []SampleData, outputDir string, formats []string) ([]string, error) {
+	if len(samples) < 2 {
+		return nil, nil
+	}
+
+	var maxElapsed time.Duration
+	minLatency, maxLatency := math.MaxFloat64, 0.0
+	for _, s := range samples {
+		maxElapsed = max(maxElapsed, s.Elapsed)
+		us := math.Max(float64(s.TotalTime.Nanoseconds())/1000, minLogLatency)
+		minLatency, maxLatency = math.Min(minLatency, us), math.Max(maxLatency, us)
+	}
+	if maxElapsed == 0 || maxLatency <= minLatency {
+		return nil, nil
+	}
+
+	logMin, logMax := math.Log10(minLatency), math.Log10(maxLatency)
+	colWidth := maxElapsed.Seconds() / heatmapColumns
+	rowHeight := (logMax - logMin) / heatmapRows
+
+	grid := &latencyHeatGrid{
+		xs:     make([]float64, heatmapColumns),
+		ys:     make([]float64, heatmapRows),
+		counts: make([][]float64, heatmapColumns),
+	}
+	for c := range grid.xs {
+		grid.xs[c] = (float64(c) + 0.5) * colWidth
+		grid.counts[c] = make([]float64, heatmapRows)
+	}
+	for r := range grid.ys {
+		grid.ys[r] = math.Pow(10, logMin+(float64(r)+0.5)*rowHeight)
+	}
+	for _, s := range samples {
+		us := math.Max(float64(s.TotalTime.Nanoseconds())/1000, minLogLatency)
+		c := min(int(s.Elapsed.Seconds()/colWidth), heatmapColumns-1)
+		r := min(int((math.Log10(us)-logMin)/rowHeight), heatmapRows-1)
+		grid.counts[c][r]++
+	}
+	for c := range grid.counts {
+		for r, n := range grid.counts[c] {
+			if n == 0 {
+				grid.counts[c][r] = math.NaN()
+				continue
+			}
+			grid.counts[c][r] = math.Log10(n + 1)
+			grid.max = math.Max(grid.max, grid.counts[c][r])
+		}
+	}
+
+	p, err := bp.style.newPlot(operation, "Latency Heatmap (color: log10 count)")
+	if err != nil {
+		return nil, err
+	}
+
+	p.X.Label.Text = "Elapsed (s)"
+	p.Y.Label.Text = "Time (µs)"
+	p.Y.Scale = plot.LogScale{}
+	p.Y.Tick.Marker = plot.LogTicks{}
+
+	heat := plotter.NewHeatMap(grid, moreland.ExtendedBlackBody().Palette(255))
+	heat.NaN = color.Transparent
+	p.Add(heat)
+
+	base := filepath.Join(outputDir, operation+"_heatmap")
+	return bp.style.save(p, base, formats)
+}
+
+// Rolling latency parameters: the window is a fraction of the run so the
+// lines are equally smooth for short and long runs
+const (
+	rollingWindowFraction = 100  // window is 1/rollingWindowFraction of the samples
+	rollingMinWindow      = 50   // but never fewer samples than this
+	rollingPlotPoints     = 2000 // points drawn per line
+)
+
+// generateRollingPlot creates a line plot of the rolling mean and median
+// latency over the sample index. Trends such as slowdown as the tree grows
+// are hard to see in the raw scatter.
+func (bp *BenchmarkPlots) generateRollingPlot(operation string, samples []SampleData, outputDir string, formats []string) ([]string, error) {
+	window := max(rollingMinWindow, len(samples)/rollingWindowFraction)
+	if len(samples) < window {
+		return nil, nil
+	}
+
+	times := make([]float64, len(samples))
+	prefix := make([]float64, len(samples)+1)
+	for i, sample := range samples {
+		times[i] = float64(sample.TotalTime.Nanoseconds()) / 1000
+		prefix[i+1] = prefix[i] + times[i]
+	}
+
+	// Each point summarizes the window of samples ending at it
+	stride := max(1, (len(samples)-window+1)/rollingPlotPoints)
+	var means, medians plotter.XYs
+	sorted := make([]float64, window)
+	for end := window; end <= len(samples); end += stride {
+		x := float64(samples[end-1].SampleIndex)
+		means = append(means, plotter.XY{X: x, Y: bp.style.latency((prefix[end] - prefix[end-window]) / float64(window))})
+
+		copy(sorted, times[end-window:end])
+		sort.Float64s(sorted)
+		medians = append(medians, plotter.XY{X: x, Y: bp.style.latency(calculateMedian(sorted))})
+	}
+
+	p, err := bp.style.newPlot(operation, fmt.Sprintf("Rolling Latency (window %d)", window))
+	if err != nil {
+		return nil, err
+	}
+
+	p.X.Label.Text = "Sample Index"
+	p.Y.Label.Text = "Time (µs)"
+	p.Legend.Top = true
+	bp.style.latencyAxis(&p.Y)
+
+	lines := []struct {
+		name  string
+		pts   plotter.XYs
+		color color.Color
+	}{
+		{"Rolling mean", means, bp.style.color(0)},
+		{"Rolling median", medians, bp.style.color(1)},
+	}
+	for _, l := range lines {
+		line, err := plotter.NewLine(l.pts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s line: %w", l.name, err)
+		}
+		line.LineStyle.Color = l.color
+		line.LineStyle.Width = vg.Points(1.5)
+		p.Add(line)
+		p.Legend.Add(l.name, line)
+	}
+
+	p.Add(bp.style.grid())
+
+	base := filepath.Join(outputDir, operation+"_rolling")
+	return bp.style.save(p, base, formats)
+}
+
+// regressionPlotPoints caps the points drawn in a regression plot; the fit
+// always uses every sample
+const regressionPlotPoints = 5000
+
+// generateRegressionPlot creates criterion's regression plot: the total time
+// after each sample against the number of iterations, with the line fitted
+// through the origin and its bootstrap confidence band. The slope is the
+// time per operation; a curved point cloud means the latency drifted.
+func (bp *BenchmarkPlots) generateRegressionPlot(operation string, samples []SampleData, outputDir string, formats []string) ([]string, error) {
+	if len(samples) < 2 {
+		return nil, nil
+	}
+
+	times := make([]float64, len(samples))
+	cumulativeSamples := make([]SampleData, len(samples))
+	var cumulative time.Duration
+	for i, sample := range samples {
+		times[i] = float64(sample.TotalTime.Nanoseconds()) / 1000
+		cumulative += sample.TotalTime
+		cumulativeSamples[i] = SampleData{SampleIndex: int64(i + 1), TotalTime: cumulative}
+	}
+	slope := bp.bootstrap.regressionSlope(times)
+	r2 := calculateR2(cumulativeSamples)
+
+	p, err := bp.style.newPlot(operation, "Linear Regression")
+	if err != nil {
+		return nil, err
+	}
+
+	p.X.Label.Text = "Iterations"
+	p.Y.Label.Text = "Total sample time (ms)"
+	p.Legend.Top = true
+	p.Legend.Left = true
+
+	stride := max(1, len(samples)/regressionPlotPoints)
+	var pts plotter.XYs
+	for i := stride - 1; i < len(cumulativeSamples); i += stride {
+		s := cumulativeSamples[i]
+		pts = append(pts, plotter.XY{X: float64(s.SampleIndex), Y: float64(s.TotalTime.Nanoseconds()) / 1e6})
+	}
+	scatter, err := plotter.NewScatter(pts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scatter plot: %w", err)
+	}
+	scatter.GlyphStyle.Color = bp.style.color(0)
+	scatter.GlyphStyle.Radius = bp.style.PointRadius
+
+	// Slopes are in µs per iteration; the y axis is in ms
+	n := float64(len(samples))
+	band, err := plotter.NewPolygon(plotter.XYs{
+		{X: 0, Y: 0},
+		{X: n, Y: slope.LowerBound * n / 1000},
+		{X: n, Y: slope.UpperBound * n / 1000},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create confidence band: %w", err)
+	}
+	band.Color = bp.style.fill(1, 50)
+	band.LineStyle.Width = 0
+
+	fit, err := plotter.NewLine(plotter.XYs{{X: 0, Y: 0}, {X: n, Y: slope.Estimate * n / 1000}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fit line: %w", err)
+	}
+	fit.LineStyle.Color = bp.style.color(1)
+
+	p.Add(band, scatter, fit, bp.style.grid())
+	p.Legend.Add("Sample", scatter)
+	p.Legend.Add(fmt.Sprintf("Linear regression (%.2f µs/iter, R² %.4f)", slope.Estimate, r2), fit)
+	p.Legend.Add(fmt.Sprintf("%s CI [%.2f, %.2f] µs/iter", formatLevel(bp.bootstrap.Level), slope.LowerBound, slope.UpperBound), band)
+
+	base := filepath.Join(outputDir, operation+"_regression")
+	return bp.style.save(p, base, formats)
+}
+
+// Density estimation parameters: samples are binned before the kernel is
+// applied so the cost does not grow with the number of samples
+const (
+	kdePoints = 500  // points the density is evaluated at
+	kdeBins   = 4096 // histogram bins the samples are reduced to
+)
+
+// generatePDFPlot creates a criterion-style probability density plot of the
+// sample times: a Gaussian kernel density estimate with mean and median
+// markers. The range is cut at p99.9 so rare stalls don't flatten the shape.
+func (bp *BenchmarkPlots) generatePDFPlot(operation string, samples []SampleData, outputDir string, formats []string) ([]string, error) {
+	if len(samples) < 2 {
+		return nil, nil
+	}
+
+	dist := newLatencyDistribution(samples)
+	mean, median := dist.mean, dist.median
+	lo, hi := dist.densityRange()
+
+	density := kernelDensity(dist.sorted, dist.bandwidth, lo, hi)
+	var peak float64
+	for _, pt := range density {
+		peak = math.Max(peak, pt.Y)
+	}
+
+	p, err := bp.style.newPlot(operation, "Probability Density")
+	if err != nil {
+		return nil, err
+	}
+
+	p.X.Label.Text = "Time (µs)"
+	p.Y.Label.Text = "Density (a.u.)"
+	p.Legend.Top = true
+
+	line, err := plotter.NewLine(density)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create density line: %w", err)
+	}
+	line.LineStyle.Color = bp.style.color(0)
+	line.FillColor = bp.style.fill(0, 60)
+	p.Add(line)
+	p.Legend.Add("PDF", line)
+
+	markers := []struct {
+		name  string
+		value float64
+		color color.Color
+	}{
+		{"Mean", mean, bp.style.color(1)},
+		{"Median", median, bp.style.color(2)},
+	}
+	for _, m := range markers {
+		marker, err := plotter.NewLine(plotter.XYs{{X: m.value, Y: 0}, {X: m.value, Y: peak}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s marker: %w", m.name, err)
+		}
+		marker.LineStyle.Color = m.color
+		marker.LineStyle.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+		p.Add(marker)
+		p.Legend.Add(fmt.Sprintf("%s (%.2f µs)", m.name, m.value), marker)
+	}
+
+	p.Add(bp.style.grid())
+
+	base := filepath.Join(outputDir, operation+"_pdf")
+	return bp.style.save(p, base, formats)
+}
+
+// latencyDistribution is the sorted sample times of one operation in
+// microseconds with the summary the density plots need
+type latencyDistribution struct {
+	sorted    []float64
+	mean      float64
+	median    float64
+	bandwidth float64 // kernel bandwidth
+}
+
+// newLatencyDistribution sorts samples and picks the kernel bandwidth with
+// Silverman's rule of thumb, as criterion does
+func newLatencyDistribution(samples []SampleData) latencyDistribution {
+	times := make([]float64, len(samples))
+	var sum float64
+	for i, sample := range samples {
+		times[i] = float64(sample.TotalTime.Nanoseconds()) / 1000
+		sum += times[i]
+	}
+	sort.Float64s(times)
+
+	bandwidth := 1.06 * standardDeviation(times) * math.Pow(float64(len(times)), -0.2)
+	if bandwidth == 0 {
+		bandwidth = 1
+	}
+	return latencyDistribution{
+		sorted:    times,
+		mean:      sum / float64(len(times)),
+		median:    calculateMedian(times),
+		bandwidth: bandwidth,
+	}
+}
+
+// densityRange is the interval the density is plotted over: from the fastest
+// sample to p99.9, padded by three bandwidths
+func (d latencyDistribution) densityRange() (lo, hi float64) {
+	lo = math.Max(0, d.sorted[0]-3*d.bandwidth)
+	hi = d.sorted[int(float64(len(d.sorted)-1)*0.999)] + 3*d.bandwidth
+	return lo, hi
+}
+
+// kernelDensity evaluates a Gaussian kernel density estimate of sorted
+// values at kdePoints points spanning [lo, hi]. The values are first binned
+// into kdeBins bins; the error this adds is far below the bandwidth.
+func kernelDensity(sorted []float64, bandwidth, lo, hi float64) plotter.XYs {
+	binLo, binHi := sorted[0], sorted[len(sorted)-1]
+	binWidth := (binHi - binLo) / kdeBins
+	counts := make([]float64, kdeBins)
+	for _, v := range sorted {
+		i := kdeBins - 1
+		if binWidth > 0 {
+			i = min(int((v-binLo)/binWidth), kdeBins-1)
+		}
+		counts[i]++
+	}
+
+	n := float64(len(sorted))
+	norm := 1 / (n * bandwidth * math.Sqrt(2*math.Pi))
+	step := (hi - lo) / (kdePoints - 1)
+	pts := make(plotter.XYs, kdePoints)
+	for i := range pts {
+		x := lo + float64(i)*step
+		var d float64
+		for b, c := range counts {
+			if c == 0 {
+				continue
+			}
+			u := (x - (binLo + (float64(b)+0.5)*binWidth)) / bandwidth
+			if u > -8 && u < 8 {
+				d += c * math.Exp(-0.5*u*u)
+			}
+		}
+		pts[i].X, pts[i].Y = x, d*norm
+	}
+	return pts
+}
+
+// CurveSeries is a named line in a metric-vs-parameter plot
+type CurveSeries struct {
+	Name   string
+	Points plotter.XYs
+}
+
+// GenerateCurvePlot renders one line per series showing how a metric changes
+// with a benchmark parameter, and saves it as base.<format> for each format.
+// The title is built from the style's template with operation and chart.
+func GenerateCurvePlot(operation, chart, xLabel, yLabel string, series []CurveSeries, base string, formats []string, style PlotStyle) error {
+	p, err := style.newPlot(operation, chart)
+	if err != nil {
+		return err
+	}
+
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	p.Legend.Top = true
+
+	for i, s := range series {
+		line, points, err := plotter.NewLinePoints(s.Points)
+		if err != nil {
+			return fmt.Errorf("failed to add lines: %w", err)
+		}
+		line.Color = style.color(i)
+		points.Color = style.color(i)
+		points.Shape = plotutil.Shape(i)
+		p.Add(line, points)
+		p.Legend.Add(s.Name, line, points)
+	}
+
+	p.Add(style.grid())
+
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	_, err = style.save(p, base, formats)
+	return err
 }
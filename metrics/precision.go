@@ -0,0 +1,196 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPrecisionLimit is how long a run with a precision target goes on
+// at most when no duration is set
+const DefaultPrecisionLimit = 10 * time.Minute
+
+// Statistics a precision target can be set on, besides percentiles such as
+// "p99"
+const (
+	PrecisionMean   = "mean"
+	PrecisionMedian = "median"
+)
+
+// Precision checks split the samples by when they were taken into
+// precisionBatches slices of equal time and bootstrap the statistic over the
+// slices, as criterion.rs does over its measurements: the estimate is the
+// statistic of each slice weighted by its operations, and the interval comes
+// from resampling whole slices, which also covers the drift and correlation
+// between neighboring operations that resampling single ones would miss.
+const (
+	precisionBatches   = 100
+	precisionResamples = 10000
+)
+
+// Precision is how precisely a run measured the statistic it had a target
+// for: the half-width of its bootstrap confidence interval relative to its
+// estimate, when the run stopped
+type Precision struct {
+	Operation  string  `json:"operation"`
+	Statistic  string  `json:"statistic"`
+	Target     float64 `json:"target"` // relative half-width to reach, e.g. 0.02
+	Level      float64 `json:"confidence_level"`
+	Reached    bool    `json:"reached"`
+	Estimate   float64 `json:"estimate_us"`
+	HalfWidth  float64 `json:"half_width"` // relative to the estimate
+	Operations int64   `json:"operations"` // of Operation measured when it stopped
+	Elapsed    float64 `json:"elapsed_s"`  // after the warm-up, when it stopped
+}
+
+// precisionStat returns the statistic named stat, mean, median or a
+// percentile such as p99 or p99.9, and the fewest operations a slice needs
+// for it: a percentile needs ten beyond it
+func precisionStat(stat string) (bootstrapStat, int, error) {
+	switch stat {
+	case PrecisionMean:
+		return func(times, _ []float64) float64 {
+			sum := 0.0
+			for _, t := range times {
+				sum += t
+			}
+			return sum / float64(len(times))
+		}, 10, nil
+	case PrecisionMedian:
+		return func(times, _ []float64) float64 {
+			return selectMedian(times)
+		}, 10, nil
+	}
+	if p, err := strconv.ParseFloat(strings.TrimPrefix(stat, "p"), 64); err == nil && strings.HasPrefix(stat, "p") && p > 0 && p < 100 {
+		return func(times, _ []float64) float64 {
+			k := min(int(math.Ceil(p/100*float64(len(times))))-1, len(times)-1)
+			return selectKth(times, max(k, 0))
+		}, int(math.Ceil(10 / (1 - p/100))), nil
+	}
+	return nil, 0, fmt.Errorf("unknown statistic %q (expected %s, %s or a percentile such as p99)", stat, PrecisionMean, PrecisionMedian)
+}
+
+// ValidatePrecisionStatistic returns an error if stat cannot be given a
+// precision target
+func ValidatePrecisionStatistic(stat string) error {
+	_, _, err := precisionStat(stat)
+	return err
+}
+
+// Precision bootstraps the confidence interval of stat over the samples of
+// op taken since from, or of every operation for TOTAL, and compares its
+// half-width with target. It returns false while there are too few samples
+// to tell, fewer than enough for stat in every slice. Samples must be
+// retained; the caller sets Elapsed.
+func (ot *OperationTracker) Precision(op, stat string, target float64, from time.Time) (Precision, bool) {
+	f, minBatch, err := precisionStat(stat)
+	if err != nil {
+		return Precision{}, false
+	}
+
+	ot.flush()
+	ot.mu.Lock()
+	b := ot.plots.bootstrap
+	cutoff := from.Sub(ot.plots.start)
+	first, last := time.Duration(math.MaxInt64), time.Duration(0)
+	var n int64
+	for name, samples := range ot.plots.samples {
+		if op != "TOTAL" && name != op {
+			continue
+		}
+		for _, s := range samples {
+			if s.Elapsed >= cutoff {
+				first, last = min(first, s.Elapsed), max(last, s.Elapsed)
+				n++
+			}
+		}
+	}
+	if n < int64(precisionBatches*minBatch) || last <= first {
+		ot.mu.Unlock()
+		return Precision{}, false
+	}
+	width := (last - first) / precisionBatches
+	batches := make([][]float64, precisionBatches)
+	for name, samples := range ot.plots.samples {
+		if op != "TOTAL" && name != op {
+			continue
+		}
+		for _, s := range samples {
+			if s.Elapsed >= cutoff {
+				i := min(int((s.Elapsed-first)/max(width, 1)), precisionBatches-1)
+				batches[i] = append(batches[i], float64(s.TotalTime.Nanoseconds())/1000)
+			}
+		}
+	}
+	ot.mu.Unlock()
+
+	// Each slice is reduced to its statistic and weight; slices without
+	// operations, such as stalls, carry none
+	values := make([]float64, 0, precisionBatches)
+	weights := make([]float64, 0, precisionBatches)
+	for _, batch := range batches {
+		if len(batch) > 0 {
+			values = append(values, f(batch, nil))
+			weights = append(weights, float64(len(batch)))
+		}
+	}
+	weighted := func(pick func(i int) int) float64 {
+		var sum, total float64
+		for i := range values {
+			j := pick(i)
+			sum += values[j] * weights[j]
+			total += weights[j]
+		}
+		return sum / total
+	}
+
+	estimate := weighted(func(i int) int { return i })
+	if estimate <= 0 {
+		return Precision{}, false
+	}
+	resampled := make([]float64, precisionResamples)
+	b.parallel(precisionResamples, func() func(int, *rand.Rand) {
+		return func(i int, rng *rand.Rand) {
+			resampled[i] = weighted(func(int) int { return rng.Intn(len(values)) })
+		}
+	})
+	ci := b.interval(estimate, resampled)
+
+	p := Precision{
+		Operation:  op,
+		Statistic:  stat,
+		Target:     target,
+		Level:      b.Level,
+		Estimate:   estimate,
+		HalfWidth:  (ci.UpperBound - ci.LowerBound) / 2 / estimate,
+		Operations: n,
+	}
+	p.Reached = p.HalfWidth <= target
+	return p, true
+}
+
+// String describes the precision reached, e.g. "mean of TOTAL 64.20 µs ±1.9%
+// (95% CI) after 120000 operations in 9.8s"
+func (p Precision) String() string {
+	return fmt.Sprintf("%s of %s %s ±%.2g%% (%s CI) after %d operations in %.1fs",
+		p.Statistic, p.Operation, formatDuration(p.Estimate), 100*p.HalfWidth, formatLevel(p.Level), p.Operations, p.Elapsed)
+}
+
+// PrintPrecision prints whether the run reached its precision target
+func PrintPrecision(p *Precision) {
+	if p == nil {
+		return
+	}
+	if p.Reached {
+		fmt.Printf("\nPrecision target ±%g%% reached: %s\n", 100*p.Target, p)
+		return
+	}
+	if p.Operations == 0 {
+		fmt.Printf("\nPrecision target ±%g%% not reached: too few samples of %s\n", 100*p.Target, p.Operation)
+		return
+	}
+	fmt.Printf("\nPrecision target ±%g%% not reached: %s\n", 100*p.Target, p)
+}
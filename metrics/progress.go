@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+// DefaultProgressInterval is how often a progress line is printed, as often
+// as go-ycsb printed its summaries
+const DefaultProgressInterval = 10 * time.Second
+
+// Phases of a run, as progress lines label them
+const (
+	PhaseLoad   = "load"
+	PhaseWarmUp = "warm-up"
+	PhaseRun    = "run"
+)
+
+// progressBarWidth is the number of cells of the progress bar
+const progressBarWidth = 20
+
+// Progress prints a line every interval with the phase of the run, the
+// operations done out of the total, the throughput over the interval and an
+// estimate of the time left. Operations during the warm-up do not count
+// towards the total, as go-ycsb does not count them either.
+type Progress struct {
+	out      io.Writer
+	window   *LiveWindow
+	interval time.Duration
+	phase    string        // PhaseLoad or PhaseRun once the warm-up is over
+	total    int64         // operations the phase ends after, 0 if unbounded
+	warmUp   time.Duration // before the run phase
+	duration time.Duration // the run phase's time bound, 0 if none
+
+	start    time.Time
+	runStart time.Time // end of the warm-up
+	done     int64     // operations of the phase so far
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewProgress creates a progress reporter for a phase that ends after total
+// operations or, if duration is set, once it has run that long after the
+// warm-up. window supplies the operations.
+func NewProgress(out io.Writer, window *LiveWindow, interval time.Duration, phase string, total int64, warmUp, duration time.Duration) *Progress {
+	return &Progress{
+		out:      out,
+		window:   window,
+		interval: interval,
+		phase:    phase,
+		total:    total,
+		warmUp:   warmUp,
+		duration: duration,
+	}
+}
+
+// Start begins printing in the background
+func (p *Progress) Start() {
+	p.start = time.Now()
+	p.runStart = p.start.Add(p.warmUp)
+	p.window.Take()
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprintln(p.out, p.line(p.window.Take(), false))
+			case <-p.stop:
+				fmt.Fprintln(p.out, p.line(p.window.Take(), true))
+				return
+			}
+		}
+	}()
+}
+
+// Stop prints the final line of the phase
+func (p *Progress) Stop() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.stopped
+	p.stop = nil
+}
+
+// line formats the progress as of the end of snapshot s
+func (p *Progress) line(s LiveSnapshot, final bool) string {
+	var count, errors int64
+	var ops float64
+	for _, op := range s.Operations {
+		if op.Operation == "TOTAL" {
+			count, errors = op.Count, op.Errors
+			p.done += op.Measured
+		}
+	}
+	if seconds := s.End.Sub(s.Start).Seconds(); seconds > 0 {
+		ops = float64(count+errors) / seconds // go-ycsb counts failed operations too
+	}
+
+	phase := p.phase
+	if !measurement.IsWarmUpFinished() {
+		phase = PhaseWarmUp
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", phase, formatElapsed(s.End.Sub(p.start)))
+	switch {
+	case phase == PhaseWarmUp:
+		fmt.Fprintf(&b, "  %.0f ops/s  warm-up ends in %s", ops, formatElapsed(p.runStart.Sub(s.End)))
+		return b.String()
+	case p.total > 0 && p.duration == 0:
+		fraction := min(1, float64(p.done)/float64(p.total))
+		fmt.Fprintf(&b, "  %d/%d ops (%5.1f%%) %s", p.done, p.total, 100*fraction, progressBar(fraction))
+	case p.duration > 0:
+		fraction := min(1, s.End.Sub(p.runStart).Seconds()/p.duration.Seconds())
+		fmt.Fprintf(&b, "  %d ops, %s of %s %s", p.done, formatElapsed(s.End.Sub(p.runStart)), formatElapsed(p.duration), progressBar(fraction))
+	default:
+		fmt.Fprintf(&b, "  %d ops", p.done)
+	}
+	fmt.Fprintf(&b, "  %.0f ops/s", ops)
+	if errors > 0 {
+		fmt.Fprintf(&b, "  %d errors", errors)
+	}
+	if final {
+		return b.String() + "  done"
+	}
+	if eta, ok := p.eta(s.End, ops); ok {
+		fmt.Fprintf(&b, "  ETA %s", formatElapsed(eta))
+	}
+	return b.String()
+}
+
+// eta estimates the time left from the throughput since the warm-up, or the
+// current throughput ops if the warm-up ended less than half an interval ago,
+// or from the time bound if there is one
+func (p *Progress) eta(now time.Time, ops float64) (time.Duration, bool) {
+	if p.duration > 0 {
+		return max(0, p.runStart.Add(p.duration).Sub(now)), true
+	}
+	rate := ops
+	if elapsed := now.Sub(p.runStart); elapsed >= p.interval/2 {
+		rate = float64(p.done) / elapsed.Seconds()
+	}
+	if p.total <= 0 || rate <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(max(0, p.total-p.done)) / rate * float64(time.Second)), true
+}
+
+// progressBar draws fraction as a bar of progressBarWidth cells
+func progressBar(fraction float64) string {
+	filled := int(fraction * progressBarWidth)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", progressBarWidth-filled) + "]"
+}
+
+// formatElapsed formats d as 1h02m03s, 2m03s or 3s
+func formatElapsed(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh%02dm%02ds", h, m, s)
+	case m > 0:
+		return fmt.Sprintf("%dm%02ds", m, s)
+	}
+	return fmt.Sprintf("%ds", s)
+}
@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+)
+
+// RampStatistics summarizes the successful operations of one step of a
+// concurrency ramp, all kinds together
+type RampStatistics struct {
+	Threads int     `json:"threads"`
+	Count   int64   `json:"count"`
+	OPS     float64 `json:"ops"`
+	Scaling float64 `json:"scaling"` // throughput gain over the previous step divided by its thread gain; 1 is linear, 0 on the first step
+	Mean    float64 `json:"mean_us"`
+	P50     float64 `json:"p50_us"`
+	P99     float64 `json:"p99_us"`
+	Max     float64 `json:"max_us"`
+}
+
+// ramp splits the latencies of operations by the step of the ramp they
+// started in, after the warm-up
+type ramp struct {
+	step    func(ctx context.Context) (int, bool)
+	threads []int
+	width   time.Duration // of every step
+
+	mu         sync.Mutex
+	histograms []*hdrhistogram.Histogram // one per step; microseconds
+}
+
+// record adds one successful operation running with ctx
+func (r *ramp) record(ctx context.Context, latency time.Duration) {
+	if !measurement.IsWarmUpFinished() {
+		return
+	}
+	step, ok := r.step(ctx)
+	if !ok || step >= len(r.histograms) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.histograms[step] == nil {
+		r.histograms[step] = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	}
+	r.histograms[step].RecordValue(max(latency.Microseconds(), 1))
+}
+
+// statistics returns a row for every step with operations, fewest threads
+// first
+func (r *ramp) statistics() []RampStatistics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []RampStatistics
+	for i, hist := range r.histograms {
+		if hist == nil {
+			continue
+		}
+		s := RampStatistics{
+			Threads: r.threads[i],
+			Count:   hist.TotalCount(),
+			OPS:     float64(hist.TotalCount()) / r.width.Seconds(),
+			Mean:    hist.Mean(),
+			P50:     float64(hist.ValueAtPercentile(50)),
+			P99:     float64(hist.ValueAtPercentile(99)),
+			Max:     float64(hist.Max()),
+		}
+		if len(result) > 0 {
+			prev := result[len(result)-1]
+			s.Scaling = (s.OPS / prev.OPS) / (float64(s.Threads) / float64(prev.Threads))
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// SetRamp splits the latencies of operations by the step of a concurrency
+// ramp they started in, see RampStatistics. step returns it given the
+// operation's context, threads are the client threads active at every step
+// and width is how long each step lasts. It must be called before the run
+// starts.
+func (ot *OperationTracker) SetRamp(step func(ctx context.Context) (int, bool), threads []int, width time.Duration) {
+	ot.ramp = &ramp{step: step, threads: threads, width: width, histograms: make([]*hdrhistogram.Histogram, len(threads))}
+	ot.plots.SetRamp(ot.ramp)
+}
+
+// RampStatistics returns the throughput and latencies of every step of the
+// concurrency ramp, or nil without one
+func (ot *OperationTracker) RampStatistics() []RampStatistics {
+	if ot.ramp == nil {
+		return nil
+	}
+	return ot.ramp.statistics()
+}
+
+// PrintRampStatistics prints a row for every step of the concurrency ramp
+// and the step with the highest throughput
+func PrintRampStatistics(all []RampStatistics) {
+	if len(all) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "CONCURRENCY RAMP"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-9s │ %12s │ %12s │ %8s │ %14s │ %14s │ %14s │ %14s │\n",
+		"Threads", "Count", "OPS", "Scaling", "Mean", "p50", "p99", "Max")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	peak := all[0]
+	for i, s := range all {
+		scaling := "-"
+		if i > 0 {
+			scaling = fmt.Sprintf("%.2f", s.Scaling)
+		}
+		fmt.Printf("│ %-9d │ %12d │ %12.1f │ %8s │ %14s │ %14s │ %14s │ %14s │\n",
+			s.Threads, s.Count, s.OPS, scaling,
+			formatDuration(s.Mean), formatDuration(s.P50), formatDuration(s.P99), formatDuration(s.Max))
+		if s.OPS > peak.OPS {
+			peak = s
+		}
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("Peak throughput: %.1f ops/s at %d threads", peak.OPS, peak.Threads)
+	if last := all[len(all)-1]; last.Threads != peak.Threads {
+		fmt.Printf(", %.1f%% lower at %d threads", 100*(1-last.OPS/peak.OPS), last.Threads)
+	}
+	fmt.Println()
+}
+
+// generateRampPlot draws the throughput and p99 latency of every step of the
+// concurrency ramp against its thread count, on a log axis
+func (bp *BenchmarkPlots) generateRampPlot(outputDir string, formats []string) ([]string, error) {
+	if bp.ramp == nil {
+		return nil, nil
+	}
+	stats := bp.ramp.statistics()
+	if len(stats) == 0 {
+		return nil, nil
+	}
+
+	var ticks []plot.Tick
+	for _, s := range stats {
+		ticks = append(ticks, plot.Tick{Value: float64(s.Threads), Label: fmt.Sprint(s.Threads)})
+	}
+	panels := []struct {
+		label string
+		value func(RampStatistics) float64
+	}{
+		{"Throughput (ops/s)", func(s RampStatistics) float64 { return s.OPS }},
+		{"p99 latency (µs)", func(s RampStatistics) float64 { return bp.style.latency(s.P99) }},
+	}
+	var plots []*plot.Plot
+	for i, panel := range panels {
+		chart := ""
+		if i == 0 {
+			chart = "Concurrency Ramp"
+		}
+		p, err := bp.style.newPlot("All Operations", chart)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			p.Y.Min = 0
+		} else {
+			p.Title.Text = ""
+			bp.style.latencyAxis(&p.Y)
+		}
+		p.Y.Label.Text = panel.label
+		p.X.Scale = plot.LogScale{}
+		p.X.Tick.Marker = plot.ConstantTicks(ticks)
+		p.Add(bp.style.grid())
+
+		pts := make(plotter.XYs, len(stats))
+		for j, s := range stats {
+			pts[j] = plotter.XY{X: float64(s.Threads), Y: panel.value(s)}
+		}
+		line, points, err := plotter.NewLinePoints(pts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s line: %w", panel.label, err)
+		}
+		line.Color = bp.style.color(0)
+		points.Color = bp.style.color(0)
+		points.Shape = plotutil.Shape(0)
+		p.Add(line, points)
+		plots = append(plots, p)
+	}
+	plots[len(plots)-1].X.Label.Text = "Threads"
+
+	base := filepath.Join(outputDir, "ALL_ramp")
+	return bp.style.saveStacked(plots, base, formats)
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"gonum.org/v1/plot/plotter"
+)
+
+// ReaderStep is one step of a reader scaling run: a writer committing
+// without pause alongside Readers concurrent read-only transactions
+type ReaderStep struct {
+	Readers int     `json:"readers"`
+	Elapsed float64 `json:"elapsed_sec"`
+
+	// Read transactions, and the keys they read, per second over all readers,
+	// and the latency of a whole read transaction in microseconds
+	ReadTxs      int64   `json:"read_txs"`
+	ReadTxPerSec float64 `json:"read_tx_per_sec"`
+	ReadsPerSec  float64 `json:"reads_per_sec"`
+	ReadP50      float64 `json:"read_tx_p50_us"`
+	ReadP99      float64 `json:"read_tx_p99_us"`
+
+	// Commits of the writer per second and their latency in microseconds
+	Commits       int64   `json:"commits"`
+	CommitsPerSec float64 `json:"commits_per_sec"`
+	CommitP50     float64 `json:"commit_p50_us"`
+	CommitP99     float64 `json:"commit_p99_us"`
+	CommitMax     float64 `json:"commit_max_us"`
+}
+
+// ReaderScaling is how read throughput and the writer's commit latency
+// change with the number of concurrent readers
+type ReaderScaling struct {
+	DB            string       `json:"db"`
+	Keys          int64        `json:"keys"` // loaded first, read and overwritten at random
+	KeysPerRead   int          `json:"keys_per_read_tx"`
+	KeysPerCommit int          `json:"keys_per_commit"`
+	Steps         []ReaderStep `json:"steps"`
+}
+
+// NewReaderStep summarizes a step of readers read transactions of
+// keysPerRead keys each, whose latencies in nanoseconds are in reads, and
+// the writer's commits in commits, over elapsed
+func NewReaderStep(readers, keysPerRead int, reads, commits *hdrhistogram.Histogram, elapsed time.Duration) ReaderStep {
+	us := func(ns int64) float64 { return float64(ns) / 1000 }
+	s := ReaderStep{
+		Readers:   readers,
+		Elapsed:   elapsed.Seconds(),
+		ReadTxs:   reads.TotalCount(),
+		ReadP50:   us(reads.ValueAtPercentile(50)),
+		ReadP99:   us(reads.ValueAtPercentile(99)),
+		Commits:   commits.TotalCount(),
+		CommitP50: us(commits.ValueAtPercentile(50)),
+		CommitP99: us(commits.ValueAtPercentile(99)),
+		CommitMax: us(commits.Max()),
+	}
+	if s.Elapsed > 0 {
+		s.ReadTxPerSec = float64(s.ReadTxs) / s.Elapsed
+		s.ReadsPerSec = s.ReadTxPerSec * float64(keysPerRead)
+		s.CommitsPerSec = float64(s.Commits) / s.Elapsed
+	}
+	return s
+}
+
+// PrintReaderScaling prints the read throughput and the writer's commit
+// latency of every step
+func PrintReaderScaling(r *ReaderScaling) {
+	if r == nil {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := fmt.Sprintf("CONCURRENT READERS VS ONE WRITER (%s)", r.DB)
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	if len(r.Steps) == 0 {
+		fmt.Println("No steps completed")
+		return
+	}
+	fmt.Printf("│ %9s │ %12s │ %12s │ %11s │ %11s │ %10s │ %11s │ %11s │ %11s │\n",
+		"Readers", "Read tx/s", "Reads/s", "Read p50", "Read p99", "Commits/s", "Commit p50", "Commit p99", "Commit max")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, s := range r.Steps {
+		readP50, readP99 := "-", "-"
+		if s.ReadTxs > 0 {
+			readP50, readP99 = formatDuration(s.ReadP50), formatDuration(s.ReadP99)
+		}
+		fmt.Printf("│ %9d │ %12.1f │ %12.0f │ %11s │ %11s │ %10.1f │ %11s │ %11s │ %11s │\n",
+			s.Readers, s.ReadTxPerSec, s.ReadsPerSec, readP50, readP99,
+			s.CommitsPerSec, formatDuration(s.CommitP50), formatDuration(s.CommitP99), formatDuration(s.CommitMax))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("Read transactions of %d keys and commits of %d keys, over %d keys\n", r.KeysPerRead, r.KeysPerCommit, r.Keys)
+}
+
+// GenerateReaderScalingPlots draws the read throughput and the writer's
+// commit latency against the number of readers, as readers_throughput and
+// readers_commit.<format> in outputDir, and returns the files
+func GenerateReaderScalingPlots(r *ReaderScaling, outputDir string, formats []string, style PlotStyle) ([]string, error) {
+	if r == nil || len(r.Steps) == 0 {
+		return nil, nil
+	}
+	throughput := make(plotter.XYs, len(r.Steps))
+	p50 := make(plotter.XYs, len(r.Steps))
+	p99 := make(plotter.XYs, len(r.Steps))
+	for i, s := range r.Steps {
+		x := float64(s.Readers)
+		throughput[i] = plotter.XY{X: x, Y: s.ReadTxPerSec}
+		p50[i] = plotter.XY{X: x, Y: s.CommitP50 / 1000}
+		p99[i] = plotter.XY{X: x, Y: s.CommitP99 / 1000}
+	}
+
+	var files []string
+	plots := []struct {
+		name, chart, yLabel string
+		series              []CurveSeries
+	}{
+		{"readers_throughput", "Read Throughput", "Read transactions/s", []CurveSeries{{Name: r.DB, Points: throughput}}},
+		{"readers_commit", "Commit Latency", "Commit latency (ms)", []CurveSeries{{Name: "p50", Points: p50}, {Name: "p99", Points: p99}}},
+	}
+	for _, p := range plots {
+		base := filepath.Join(outputDir, p.name)
+		if err := GenerateCurvePlot("Concurrent readers", p.chart, "Readers", p.yLabel, p.series, base, formats, style); err != nil {
+			return nil, err
+		}
+		for _, format := range formats {
+			files = append(files, base+"."+format)
+		}
+	}
+	return files, nil
+}
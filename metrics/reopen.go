@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reopen is one close and reopen of the backend after the workload, with
+// the size of its data directory then
+type Reopen struct {
+	Bytes int64   `json:"bytes"`
+	Close float64 `json:"close_us"`
+	Open  float64 `json:"open_us"`
+}
+
+// PrintReopens prints the close and open latency of every reopen
+func PrintReopens(all []Reopen) {
+	if len(all) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "REOPENS"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-16s │ %33s │ %32s │ %32s │\n", "Reopen", "Data directory", "Close", "Open")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for i, r := range all {
+		fmt.Printf("│ %-16d │ %33s │ %32s │ %32s │\n",
+			i+1, formatBytes(r.Bytes), formatDuration(r.Close), formatDuration(r.Open))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"gonum.org/v1/plot/plotter"
+)
+
+// BlockSample is the commit of one replayed block
+type BlockSample struct {
+	Number  uint64        `json:"number"`
+	Writes  int           `json:"writes"` // deletes included
+	Deletes int           `json:"deletes"`
+	Gas     uint64        `json:"gas_used"`
+	Latency time.Duration `json:"commit_ns"`
+}
+
+// BlockReplay is the outcome of replaying the state writes of a range of
+// blocks, one commit per block
+type BlockReplay struct {
+	Trace        string  `json:"trace"`
+	DB           string  `json:"db"`
+	FirstBlock   uint64  `json:"first_block"`
+	LastBlock    uint64  `json:"last_block"`
+	Blocks       int64   `json:"blocks"`
+	WarmUpBlocks int64   `json:"warmup_blocks"` // committed first and left out of every figure
+	Writes       int64   `json:"writes"`        // deletes included
+	Deletes      int64   `json:"deletes"`
+	Gas          uint64  `json:"gas_used"`
+	Elapsed      float64 `json:"elapsed_sec"` // wall time, reading the trace included
+	CommitTime   float64 `json:"commit_sec"`  // time spent in commits
+
+	// Throughputs over the commit time: MGas/s is the gas of the blocks the
+	// state writes could keep up with, execution aside
+	MGasPerSec   float64 `json:"mgas_per_sec"`
+	BlocksPerSec float64 `json:"blocks_per_sec"`
+	WritesPerSec float64 `json:"writes_per_sec"`
+
+	// Commit latency distribution, in microseconds
+	Mean float64 `json:"commit_mean_us"`
+	P50  float64 `json:"commit_p50_us"`
+	P90  float64 `json:"commit_p90_us"`
+	P99  float64 `json:"commit_p99_us"`
+	P999 float64 `json:"commit_p999_us"`
+	Max  float64 `json:"commit_max_us"`
+
+	Samples []BlockSample `json:"block_commits,omitempty"`
+}
+
+// NewBlockReplay summarizes the commits of the measured blocks of trace,
+// replayed against db in elapsed after warmUpBlocks others
+func NewBlockReplay(trace, db string, warmUpBlocks int64, samples []BlockSample, elapsed time.Duration) *BlockReplay {
+	r := &BlockReplay{
+		Trace:        trace,
+		DB:           db,
+		Blocks:       int64(len(samples)),
+		WarmUpBlocks: warmUpBlocks,
+		Elapsed:      elapsed.Seconds(),
+		Samples:      samples,
+	}
+	if len(samples) == 0 {
+		return r
+	}
+	r.FirstBlock, r.LastBlock = samples[0].Number, samples[len(samples)-1].Number
+
+	latency := hdrhistogram.New(1, int64(time.Hour), 3)
+	var commit time.Duration
+	for _, s := range samples {
+		r.Writes += int64(s.Writes)
+		r.Deletes += int64(s.Deletes)
+		r.Gas += s.Gas
+		commit += s.Latency
+		latency.RecordValue(max(int64(s.Latency), 1))
+	}
+	r.CommitTime = commit.Seconds()
+	if r.CommitTime > 0 {
+		r.MGasPerSec = float64(r.Gas) / 1e6 / r.CommitTime
+		r.BlocksPerSec = float64(r.Blocks) / r.CommitTime
+		r.WritesPerSec = float64(r.Writes) / r.CommitTime
+	}
+	us := func(ns int64) float64 { return float64(ns) / 1000 }
+	r.Mean = latency.Mean() / 1000
+	r.P50 = us(latency.ValueAtPercentile(50))
+	r.P90 = us(latency.ValueAtPercentile(90))
+	r.P99 = us(latency.ValueAtPercentile(99))
+	r.P999 = us(latency.ValueAtPercentile(99.9))
+	r.Max = us(latency.Max())
+	return r
+}
+
+// PrintBlockReplay prints the throughput and commit latencies of a replay
+func PrintBlockReplay(r *BlockReplay) {
+	if r == nil {
+		return
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Printf("Block Replay (%s):\n", r.DB)
+	fmt.Println(strings.Repeat("=", 80))
+	if r.Blocks == 0 {
+		fmt.Println("No blocks measured")
+		return
+	}
+	rows := [][2]string{
+		{"Blocks", fmt.Sprintf("%d (%d to %d), %d warm-up blocks before", r.Blocks, r.FirstBlock, r.LastBlock, r.WarmUpBlocks)},
+		{"State writes", fmt.Sprintf("%d, %d of them deletes", r.Writes, r.Deletes)},
+		{"Gas", fmt.Sprintf("%.1f MGas", float64(r.Gas)/1e6)},
+		{"Time", fmt.Sprintf("%.2f s committing, %.2f s elapsed", r.CommitTime, r.Elapsed)},
+		{"Throughput", fmt.Sprintf("%.1f MGas/s, %.1f blocks/s, %.0f writes/s", r.MGasPerSec, r.BlocksPerSec, r.WritesPerSec)},
+		{"Commit latency (mean)", formatDuration(r.Mean)},
+		{"Commit latency (p50/p90)", formatDuration(r.P50) + " / " + formatDuration(r.P90)},
+		{"Commit latency (p99/p99.9)", formatDuration(r.P99) + " / " + formatDuration(r.P999)},
+		{"Commit latency (max)", formatDuration(r.Max)},
+	}
+	for _, row := range rows {
+		fmt.Printf("%-30s %s\n", row[0], row[1])
+	}
+}
+
+// GenerateBlockReplayPlot draws every block's commit latency against its
+// number as block_commit.<format> in outputDir and returns the files
+func GenerateBlockReplayPlot(r *BlockReplay, outputDir string, formats []string, style PlotStyle) ([]string, error) {
+	if r == nil || len(r.Samples) == 0 {
+		return nil, nil
+	}
+	pts := make(plotter.XYs, len(r.Samples))
+	for i, s := range r.Samples {
+		pts[i].X = float64(s.Number)
+		pts[i].Y = float64(s.Latency) / float64(time.Millisecond)
+	}
+	pts = decimate(pts, style.MaxPoints)
+
+	base := filepath.Join(outputDir, "block_commit")
+	series := []CurveSeries{{Name: r.DB, Points: pts}}
+	if err := GenerateCurvePlot("Block replay", "Commit Latency", "Block", "Commit latency (ms)", series, base, formats, style); err != nil {
+		return nil, err
+	}
+	files := make([]string, len(formats))
+	for i, format := range formats {
+		files[i] = base + "." + format
+	}
+	return files, nil
+}
@@ -0,0 +1,365 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+)
+
+// DefaultResourceInterval is how often the resource monitor samples the
+// process
+const DefaultResourceInterval = time.Second
+
+// clockTicks is the kernel's USER_HZ, the unit of CPU times in /proc, which
+// is 100 on every Linux architecture Go supports
+const clockTicks = 100
+
+// ResourceSample is the resource usage of the benchmark process at one
+// point of the run. Rates and CPU are over the interval ending at the sample.
+type ResourceSample struct {
+	Elapsed    float64 `json:"elapsed_s"`   // seconds since tracking began, as the sample start times
+	Interval   float64 `json:"interval_s"`  // seconds since the previous sample
+	CPUPercent float64 `json:"cpu_percent"` // user and system CPU time; 100 per busy core
+	RSS        int64   `json:"rss_bytes"`
+	OpenFDs    int     `json:"open_fds"`
+	ReadBytes  int64   `json:"read_bytes"`  // read from storage since the process started
+	WriteBytes int64   `json:"write_bytes"` // written to storage since the process started
+	ReadRate   float64 `json:"read_bytes_per_s"`
+	WriteRate  float64 `json:"write_bytes_per_s"`
+}
+
+// procUsage is one reading of the process' counters in /proc
+type procUsage struct {
+	at         time.Time
+	cpu        time.Duration
+	rss        int64
+	fds        int
+	readBytes  int64
+	writeBytes int64
+}
+
+// readProcUsage reads the CPU time, RSS, open file descriptors and storage
+// IO of the process from /proc. Only the CPU time is required; counters the
+// kernel does not expose, such as /proc/self/io without task accounting, are
+// left at 0.
+func readProcUsage() (procUsage, error) {
+	u := procUsage{at: time.Now()}
+
+	stat, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return u, err
+	}
+	// The command name may contain spaces, so fields are counted from the
+	// parenthesis that ends it: state is field 3, utime and stime 14 and 15
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	if len(fields) < 13 {
+		return u, fmt.Errorf("unexpected /proc/self/stat format")
+	}
+	utime, err1 := strconv.ParseInt(fields[11], 10, 64)
+	stime, err2 := strconv.ParseInt(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return u, fmt.Errorf("unexpected /proc/self/stat format")
+	}
+	u.cpu = time.Duration(utime+stime) * time.Second / clockTicks
+
+	if statm, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if f := strings.Fields(string(statm)); len(f) > 1 {
+			pages, _ := strconv.ParseInt(f[1], 10, 64)
+			u.rss = pages * int64(os.Getpagesize())
+		}
+	}
+
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		u.fds = max(0, len(entries)-1) // less the one listing the directory
+	}
+
+	if f, err := os.Open("/proc/self/io"); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), ": ")
+			if !ok {
+				continue
+			}
+			n, _ := strconv.ParseInt(value, 10, 64)
+			switch key {
+			case "read_bytes":
+				u.readBytes = n
+			case "write_bytes":
+				u.writeBytes = n
+			}
+		}
+		f.Close()
+	}
+	return u, nil
+}
+
+// ResourceMonitor samples the resource usage of the process in the
+// background, so a run can be told apart as CPU-, memory- or IO-bound. It
+// reads /proc and records nothing on systems without it.
+type ResourceMonitor struct {
+	start    time.Time
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	last     procUsage
+	samples  []ResourceSample
+}
+
+// NewResourceMonitor creates a monitor sampling every interval, with sample
+// times relative to start
+func NewResourceMonitor(start time.Time, interval time.Duration) *ResourceMonitor {
+	return &ResourceMonitor{start: start, interval: interval}
+}
+
+// Start begins sampling. It does nothing if /proc cannot be read.
+func (m *ResourceMonitor) Start() {
+	last, err := readProcUsage()
+	if err != nil || m.interval <= 0 {
+		return
+	}
+	m.last = last
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.record()
+			case <-m.stop:
+				m.record() // the partial interval at the end
+				return
+			}
+		}
+	}()
+}
+
+// record adds a sample covering the time since the previous one
+func (m *ResourceMonitor) record() {
+	u, err := readProcUsage()
+	if err != nil {
+		return
+	}
+	width := u.at.Sub(m.last.at).Seconds()
+	if width <= 0 {
+		return
+	}
+	m.samples = append(m.samples, ResourceSample{
+		Elapsed:    u.at.Sub(m.start).Seconds(),
+		Interval:   width,
+		CPUPercent: 100 * (u.cpu - m.last.cpu).Seconds() / width,
+		RSS:        u.rss,
+		OpenFDs:    u.fds,
+		ReadBytes:  u.readBytes,
+		WriteBytes: u.writeBytes,
+		ReadRate:   float64(u.readBytes-m.last.readBytes) / width,
+		WriteRate:  float64(u.writeBytes-m.last.writeBytes) / width,
+	})
+	m.last = u
+}
+
+// Stop ends sampling and returns the samples taken
+func (m *ResourceMonitor) Stop() []ResourceSample {
+	if m.stop != nil {
+		close(m.stop)
+		<-m.done
+		m.stop = nil
+	}
+	return m.samples
+}
+
+// ResourceSummary condenses the resource samples of a run
+type ResourceSummary struct {
+	MeanCPU    float64 // percent
+	PeakCPU    float64 // percent
+	PeakRSS    int64
+	PeakFDs    int
+	ReadBytes  int64 // read from storage during the run
+	WriteBytes int64 // written to storage during the run
+}
+
+// SummarizeResources returns the mean and peak usage over samples
+func SummarizeResources(samples []ResourceSample) ResourceSummary {
+	var s ResourceSummary
+	var cpuSeconds, seconds, read, written float64
+	for _, r := range samples {
+		cpuSeconds += r.CPUPercent / 100 * r.Interval
+		seconds += r.Interval
+		read += r.ReadRate * r.Interval
+		written += r.WriteRate * r.Interval
+		s.PeakCPU = max(s.PeakCPU, r.CPUPercent)
+		s.PeakRSS = max(s.PeakRSS, r.RSS)
+		s.PeakFDs = max(s.PeakFDs, r.OpenFDs)
+	}
+	if seconds > 0 {
+		s.MeanCPU = 100 * cpuSeconds / seconds
+	}
+	s.ReadBytes, s.WriteBytes = int64(math.Round(read)), int64(math.Round(written))
+	return s
+}
+
+// PrintResources prints a summary of the resource usage of a run
+func PrintResources(samples []ResourceSample) {
+	if len(samples) == 0 {
+		return
+	}
+	s := SummarizeResources(samples)
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("Resource Usage:")
+	fmt.Println(strings.Repeat("=", 80))
+
+	rows := [][2]string{
+		{"CPU", fmt.Sprintf("%.1f%% mean, %.1f%% peak (100%% per core)", s.MeanCPU, s.PeakCPU)},
+		{"Memory", formatBytes(s.PeakRSS) + " peak RSS"},
+		{"Open files", fmt.Sprintf("%d peak", s.PeakFDs)},
+		{"Disk", formatBytes(s.ReadBytes) + " read, " + formatBytes(s.WriteBytes) + " written"},
+	}
+	for _, row := range rows {
+		fmt.Printf("%-30s %s\n", row[0], row[1])
+	}
+}
+
+// formatBytes formats n bytes in binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// timelineLine is one line of a panel of a timeline plot, a value per
+// sample; unnamed lines are left out of the legend
+type timelineLine struct {
+	name   string
+	values []float64
+}
+
+// timelinePanel is one panel of a timeline plot
+type timelinePanel struct {
+	label string
+	lines []timelineLine
+}
+
+// generateTimelinePlot draws the mean latency of every operation per sample
+// interval above panels of values sampled at the ends of the same intervals,
+// on one time axis. ends are the elapsed seconds of the samples and widths
+// the seconds each covers.
+func (bp *BenchmarkPlots) generateTimelinePlot(chart, name string, operations []string, ends, widths []float64, panels []timelinePanel, outputDir string, formats []string) ([]string, error) {
+	last := ends[len(ends)-1]
+
+	latency, err := bp.style.newPlot("All Operations", chart)
+	if err != nil {
+		return nil, err
+	}
+	latency.Y.Label.Text = "Mean time (µs)"
+	latency.Legend.Top = true
+	bp.style.latencyAxis(&latency.Y)
+	latency.Add(bp.style.grid())
+	for i, operation := range operations {
+		sums := make([]float64, len(ends))
+		counts := make([]int, len(ends))
+		for _, s := range bp.samples[operation] {
+			end := (s.Elapsed + s.TotalTime).Seconds()
+			j := sort.SearchFloat64s(ends, end)
+			if j == len(ends) || end <= ends[j]-widths[j] {
+				continue // outside the monitored window
+			}
+			sums[j] += float64(s.TotalTime.Nanoseconds()) / 1000
+			counts[j]++
+		}
+		var pts plotter.XYs
+		for j, n := range counts {
+			if n > 0 {
+				pts = append(pts, plotter.XY{X: ends[j], Y: bp.style.latency(sums[j] / float64(n))})
+			}
+		}
+		if len(pts) == 0 {
+			continue
+		}
+		line, err := plotter.NewLine(pts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s latency line: %w", operation, err)
+		}
+		line.LineStyle.Color = bp.style.color(i)
+		latency.Add(line)
+		latency.Legend.Add(operation, line)
+	}
+
+	plots := []*plot.Plot{latency}
+	for _, panel := range panels {
+		p, err := bp.style.newPlot("", "")
+		if err != nil {
+			return nil, err
+		}
+		p.Title.Text = ""
+		p.Y.Label.Text = panel.label
+		p.Y.Min = 0
+		p.Legend.Top = true
+		p.Add(bp.style.grid())
+		for i, l := range panel.lines {
+			pts := make(plotter.XYs, len(ends))
+			for j, end := range ends {
+				pts[j] = plotter.XY{X: end, Y: l.values[j]}
+			}
+			line, err := plotter.NewLine(pts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create %s line: %w", panel.label, err)
+			}
+			line.LineStyle.Color = bp.style.color(i)
+			p.Add(line)
+			if l.name != "" {
+				p.Legend.Add(l.name, line)
+			}
+		}
+		plots = append(plots, p)
+	}
+
+	for _, p := range plots {
+		p.X.Min, p.X.Max = 0, last
+	}
+	plots[len(plots)-1].X.Label.Text = "Elapsed (s)"
+
+	base := filepath.Join(outputDir, "ALL_"+name)
+	return bp.style.saveStacked(plots, base, formats)
+}
+
+// generateResourcesPlot draws the mean latency of every operation per
+// resource sample above the process' CPU, memory and disk IO on the same time
+// axis, so latency changes can be matched to the resource that limits them
+func (bp *BenchmarkPlots) generateResourcesPlot(operations []string, outputDir string, formats []string) ([]string, error) {
+	if len(bp.resources) == 0 {
+		return nil, nil
+	}
+	n := len(bp.resources)
+	ends, widths := make([]float64, n), make([]float64, n)
+	cpu, rss, read, write := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	for i, r := range bp.resources {
+		ends[i], widths[i] = r.Elapsed, r.Interval
+		cpu[i] = r.CPUPercent
+		rss[i] = float64(r.RSS) / (1 << 20)
+		read[i], write[i] = r.ReadRate/(1<<20), r.WriteRate/(1<<20)
+	}
+	panels := []timelinePanel{
+		{"CPU (%)", []timelineLine{{"", cpu}}},
+		{"RSS (MiB)", []timelineLine{{"", rss}}},
+		{"Disk (MiB/s)", []timelineLine{{"Read", read}, {"Write", write}}},
+	}
+	return bp.generateTimelinePlot("Resource Usage", "resources", operations, ends, widths, panels, outputDir, formats)
+}
@@ -0,0 +1,521 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Output formats for benchmark results. The table format is the pretty-printed
+// console output; the others additionally write files for tooling or for
+// pasting into PRs and issues.
+const (
+	FormatTable     = "table"
+	FormatJSON      = "json"
+	FormatCSV       = "csv"
+	FormatMarkdown  = "markdown"
+	FormatCriterion = "criterion"
+)
+
+// ValidateFormat returns an error if format is not a supported output format
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatTable, FormatJSON, FormatCSV, FormatMarkdown, FormatCriterion:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (expected %s, %s, %s, %s or %s)", format, FormatTable, FormatJSON, FormatCSV, FormatMarkdown, FormatCriterion)
+}
+
+// Results is the serializable outcome of a single run
+type Results struct {
+	Name          string                 `json:"name"`
+	ID            string                 `json:"id,omitempty"` // random, joins the run's exported metrics and spans
+	DB            string                 `json:"db"`
+	Properties    map[string]string      `json:"properties"`
+	Environment   Environment            `json:"environment"`
+	Operations    []OperationMetrics     `json:"operations"`
+	Statistics    []OperationStatistics  `json:"statistics,omitempty"`
+	Threads       []ThreadStatistics     `json:"thread_statistics,omitempty"`
+	Streaming     []StreamStatistics     `json:"streaming_statistics,omitempty"`
+	Series        []TimeSeries           `json:"time_series,omitempty"`
+	Distributions []Distribution         `json:"distributions,omitempty"`
+	HotSet        []HotSetStatistics     `json:"hot_set,omitempty"`
+	Ramp          []RampStatistics       `json:"ramp,omitempty"`
+	Precision     *Precision             `json:"precision,omitempty"`
+	Aborted       *ErrorRateAbort        `json:"aborted,omitempty"`
+	Retries       []RetryStatistics      `json:"retries,omitempty"`
+	SteadyState   *SteadyState           `json:"steady_state,omitempty"`
+	Bursts        []BurstStatistics      `json:"bursts,omitempty"`
+	ScanLengths   []ScanLengthStatistics `json:"scan_lengths,omitempty"`
+	Resources     []ResourceSample       `json:"resources,omitempty"`
+	Engine        []EngineSample         `json:"engine,omitempty"`
+	WriteAmp      *WriteAmplification    `json:"write_amplification,omitempty"`
+	BlockCache    *BlockCache            `json:"block_cache,omitempty"`
+	IOCalls       []IOCallStatistics     `json:"io_calls,omitempty"`
+	Disk          *DiskBaseline          `json:"disk_baseline,omitempty"`
+	Compaction    *Compaction            `json:"compaction,omitempty"`
+	Reopens       []Reopen               `json:"reopens,omitempty"`
+	Crash         *CrashRecovery         `json:"crash_recovery,omitempty"`
+	LSM           []LSMLevel             `json:"lsm_levels,omitempty"`
+	DBMetrics     string                 `json:"db_metrics,omitempty"` // the backend's own dump
+	Perf          *PerfCounters          `json:"perf_counters,omitempty"`
+	Verification  *Verification          `json:"verification,omitempty"`
+	Layout        *StorageLayout         `json:"storage_layout,omitempty"`
+	Capabilities  *Capabilities          `json:"capabilities,omitempty"`
+	Plots         []string               `json:"plots,omitempty"`
+
+	// Samples are the raw per-operation latencies, kept for the criterion
+	// format and too large to serialize with the rest
+	Samples map[string][]time.Duration `json:"-"`
+	// Bootstrap is the resampling the statistics were, or are to be,
+	// computed with
+	Bootstrap Bootstrap `json:"-"`
+}
+
+// workloadOf names the workload of run: its workload file, or the go-ycsb
+// workload when it has none
+func workloadOf(run Results) string {
+	if workload := run.Properties["workload_file"]; workload != "" {
+		return workload
+	}
+	return run.Properties["workload"]
+}
+
+// NewRunID returns a random run ID: 32 hex digits, so it can also serve as
+// an OpenTelemetry trace ID
+func NewRunID() string {
+	return randomID(16)
+}
+
+// ReportAggregate holds the cross-run summaries of a group of runs, such as
+// the repetitions of one config phase
+type ReportAggregate struct {
+	Name       string         `json:"name"`
+	Operations []RunAggregate `json:"operations"`
+}
+
+// Report is the document written by the file-based output formats
+type Report struct {
+	// RunID identifies the invocation that produced the report and names its
+	// run directory
+	RunID string `json:"run_id,omitempty"`
+
+	Runs       []Results         `json:"runs"`
+	Aggregates []ReportAggregate `json:"aggregates,omitempty"`
+
+	// Interleaved compares the sides of an interleaved A/B run
+	Interleaved *InterleavedComparison `json:"interleaved,omitempty"`
+
+	// Replay is the outcome of a block replay, which has no runs
+	Replay *BlockReplay `json:"block_replay,omitempty"`
+
+	// AccountUpdates is the outcome of an account update workload, which has
+	// no runs either
+	AccountUpdates *AccountUpdates `json:"account_updates,omitempty"`
+
+	// SelfDestructs is the outcome of an account deletion benchmark
+	SelfDestructs *SelfDestructs `json:"self_destructs,omitempty"`
+
+	// ReaderScaling is the outcome of a concurrent reader scaling run
+	ReaderScaling *ReaderScaling `json:"reader_scaling,omitempty"`
+
+	// CacheSweep is the outcome of a block cache sweep, whose runs are in
+	// Runs, one per cache size
+	CacheSweep *CacheSweep `json:"cache_sweep,omitempty"`
+}
+
+// AddAggregate summarizes runs across repetitions under the given name
+func (r *Report) AddAggregate(name string, runs [][]OperationMetrics) {
+	r.Aggregates = append(r.Aggregates, ReportAggregate{Name: name, Operations: AggregateRuns(runs)})
+}
+
+// Write stores the report at path in the given format. JSON and Markdown are
+// written to a single file; CSV and criterion are written as directories. The
+// table format writes nothing.
+func (r *Report) Write(format, path string) error {
+	switch format {
+	case FormatJSON:
+		return r.WriteJSON(path)
+	case FormatCSV:
+		return r.WriteCSV(path)
+	case FormatMarkdown:
+		return r.WriteMarkdown(path)
+	case FormatCriterion:
+		return r.WriteCriterion(path)
+	case FormatTable:
+		return nil
+	}
+	return ValidateFormat(format)
+}
+
+// EncodeJSON writes the report to w as indented JSON
+func (r *Report) EncodeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteJSON writes the report to path as indented JSON
+func (r *Report) WriteJSON(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.EncodeJSON(f)
+}
+
+// ReadReport loads a report written by the json output format
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// WriteCSV writes operations.csv, plus statistics.csv and aggregates.csv when
+// the report has them, into dir
+func (r *Report) WriteCSV(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var operations [][]string
+	for _, run := range r.Runs {
+		for _, m := range run.Operations {
+			operations = append(operations, []string{
+				run.Name, run.DB, m.Operation,
+				strconv.FormatInt(m.TotalTime.Nanoseconds(), 10),
+				strconv.FormatInt(m.Count, 10),
+				strconv.FormatFloat(m.OPS, 'f', 1, 64),
+				strconv.FormatInt(m.Avg, 10),
+				strconv.FormatInt(m.Min, 10),
+				strconv.FormatInt(m.Max, 10),
+				strconv.FormatInt(m.P50, 10),
+				strconv.FormatInt(m.P90, 10),
+				strconv.FormatInt(m.P95, 10),
+				strconv.FormatInt(m.P99, 10),
+				strconv.FormatInt(m.P999, 10),
+				strconv.FormatInt(m.Errors, 10),
+				strconv.FormatFloat(m.ErrorRate, 'f', -1, 64),
+				strconv.FormatInt(m.Misses, 10),
+			})
+		}
+	}
+	err := writeCSVFile(filepath.Join(dir, "operations.csv"),
+		[]string{"run", "db", "operation", "total_time_ns", "count", "ops", "avg_us", "min_us", "max_us", "p50_us", "p90_us", "p95_us", "p99_us", "p999_us", "errors", "error_rate", "misses"},
+		operations)
+	if err != nil {
+		return err
+	}
+
+	var statistics [][]string
+	for _, run := range r.Runs {
+		for _, s := range run.Statistics {
+			intervals := []struct {
+				name string
+				ci   ConfidenceInterval
+			}{
+				{"throughput", s.Throughput},
+				{"r2", s.R2},
+				{"mean_us", s.Mean},
+				{"stddev_us", s.StdDev},
+				{"median_us", s.Median},
+				{"mad_us", s.MAD},
+				{"slope_us", s.Slope},
+			}
+			for _, iv := range intervals {
+				statistics = append(statistics, []string{
+					run.Name, run.DB, s.Operation, iv.name,
+					formatCSVFloat(iv.ci.LowerBound),
+					formatCSVFloat(iv.ci.Estimate),
+					formatCSVFloat(iv.ci.UpperBound),
+				})
+			}
+		}
+	}
+	if len(statistics) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "statistics.csv"),
+			[]string{"run", "db", "operation", "statistic", "lower", "estimate", "upper"},
+			statistics)
+		if err != nil {
+			return err
+		}
+	}
+
+	var series [][]string
+	for _, run := range r.Runs {
+		for _, s := range run.Series {
+			for _, p := range s.Points {
+				series = append(series, []string{
+					run.Name, run.DB, s.Operation,
+					formatCSVFloat(p.Start),
+					strconv.FormatInt(p.Count, 10),
+					strconv.FormatInt(p.Errors, 10),
+					formatCSVFloat(p.OPS),
+					formatCSVFloat(p.Mean),
+					strconv.FormatInt(p.P50, 10),
+					strconv.FormatInt(p.P90, 10),
+					strconv.FormatInt(p.P99, 10),
+					strconv.FormatInt(p.Max, 10),
+				})
+			}
+		}
+	}
+	if len(series) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "series.csv"),
+			[]string{"run", "db", "operation", "start_s", "count", "errors", "ops", "mean_us", "p50_us", "p90_us", "p99_us", "max_us"},
+			series)
+		if err != nil {
+			return err
+		}
+	}
+
+	var distributions [][]string
+	for _, run := range r.Runs {
+		for _, d := range run.Distributions {
+			for _, p := range d.Points {
+				distributions = append(distributions, []string{
+					run.Name, run.DB, d.Operation, strconv.FormatBool(d.Corrected),
+					formatCSVFloat(p.Percentile),
+					strconv.FormatInt(p.Latency, 10),
+				})
+			}
+		}
+	}
+	if len(distributions) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "distribution.csv"),
+			[]string{"run", "db", "operation", "corrected", "percentile", "latency_us"},
+			distributions)
+		if err != nil {
+			return err
+		}
+	}
+
+	var writeAmp [][]string
+	for _, run := range r.Runs {
+		if w := run.WriteAmp; w != nil {
+			writeAmp = append(writeAmp, []string{
+				run.Name, run.DB,
+				strconv.FormatUint(w.UserBytes, 10),
+				strconv.FormatUint(w.WALBytes, 10),
+				strconv.FormatUint(w.FlushedBytes, 10),
+				strconv.FormatUint(w.CompactedBytes, 10),
+				formatCSVFloat(w.Factor),
+			})
+		}
+	}
+	if len(writeAmp) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "write_amplification.csv"),
+			[]string{"run", "db", "user_bytes", "wal_bytes", "flushed_bytes", "compacted_bytes", "write_amp"},
+			writeAmp)
+		if err != nil {
+			return err
+		}
+	}
+
+	var lsm [][]string
+	for _, run := range r.Runs {
+		for _, l := range run.LSM {
+			lsm = append(lsm, []string{
+				run.Name, run.DB,
+				strconv.Itoa(l.Level),
+				strconv.Itoa(l.Sublevels),
+				strconv.FormatInt(l.Files, 10),
+				strconv.FormatInt(l.Size, 10),
+				formatCSVFloat(l.Score),
+				strconv.FormatUint(l.BytesIn, 10),
+				strconv.FormatUint(l.BytesIngested, 10),
+				strconv.FormatUint(l.BytesRead, 10),
+				strconv.FormatUint(l.BytesWritten, 10),
+				formatCSVFloat(l.WriteAmp()),
+			})
+		}
+	}
+	if len(lsm) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "lsm_levels.csv"),
+			[]string{"run", "db", "level", "sublevels", "files", "size_bytes", "compaction_score", "bytes_in", "bytes_ingested", "bytes_read", "bytes_written", "write_amp"},
+			lsm)
+		if err != nil {
+			return err
+		}
+	}
+
+	var ioCalls [][]string
+	for _, run := range r.Runs {
+		for _, c := range run.IOCalls {
+			ioCalls = append(ioCalls, []string{
+				run.Name, run.DB, c.Call,
+				strconv.FormatInt(c.Count, 10),
+				strconv.FormatInt(c.Bytes, 10),
+				formatCSVFloat(c.Mean),
+				formatCSVFloat(c.P50),
+				formatCSVFloat(c.P90),
+				formatCSVFloat(c.P99),
+				formatCSVFloat(c.P999),
+				formatCSVFloat(c.Max),
+			})
+		}
+	}
+	if len(ioCalls) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "io_calls.csv"),
+			[]string{"run", "db", "call", "count", "bytes", "mean_us", "p50_us", "p90_us", "p99_us", "p999_us", "max_us"},
+			ioCalls)
+		if err != nil {
+			return err
+		}
+	}
+
+	var resources [][]string
+	for _, run := range r.Runs {
+		for _, s := range run.Resources {
+			resources = append(resources, []string{
+				run.Name, run.DB,
+				formatCSVFloat(s.Elapsed),
+				formatCSVFloat(s.Interval),
+				formatCSVFloat(s.CPUPercent),
+				strconv.FormatInt(s.RSS, 10),
+				strconv.Itoa(s.OpenFDs),
+				strconv.FormatInt(s.ReadBytes, 10),
+				strconv.FormatInt(s.WriteBytes, 10),
+				formatCSVFloat(s.ReadRate),
+				formatCSVFloat(s.WriteRate),
+			})
+		}
+	}
+	if len(resources) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "resources.csv"),
+			[]string{"run", "db", "elapsed_s", "interval_s", "cpu_percent", "rss_bytes", "open_fds", "read_bytes", "write_bytes", "read_bytes_per_s", "write_bytes_per_s"},
+			resources)
+		if err != nil {
+			return err
+		}
+	}
+
+	var engine [][]string
+	for _, run := range r.Runs {
+		for _, s := range run.Engine {
+			engine = append(engine, []string{
+				run.Name, run.DB,
+				formatCSVFloat(s.Elapsed),
+				formatCSVFloat(s.Interval),
+				strconv.FormatUint(s.CompactionDebt, 10),
+				strconv.FormatInt(s.L0Files, 10),
+				strconv.Itoa(s.L0Sublevels),
+				strconv.Itoa(s.ReadAmp),
+				strconv.FormatUint(s.MemtableBytes, 10),
+				strconv.FormatInt(s.Flushes, 10),
+				strconv.FormatInt(s.Compactions, 10),
+				strconv.FormatInt(s.WriteStalls, 10),
+				formatCSVFloat(s.Stalled),
+				strconv.FormatInt(s.L0WriteStalls, 10),
+				formatCSVFloat(s.L0Stalled),
+				strconv.Itoa(s.L0StopWrites),
+			})
+		}
+	}
+	if len(engine) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "engine.csv"),
+			[]string{"run", "db", "elapsed_s", "interval_s", "compaction_debt_bytes", "l0_files", "l0_sublevels", "read_amp", "memtable_bytes", "flushes", "compactions", "write_stalls", "stalled_s", "l0_write_stalls", "l0_stalled_s", "l0_stop_writes"},
+			engine)
+		if err != nil {
+			return err
+		}
+	}
+
+	var ramp [][]string
+	for _, run := range r.Runs {
+		for _, s := range run.Ramp {
+			ramp = append(ramp, []string{
+				run.Name, run.DB,
+				strconv.Itoa(s.Threads),
+				strconv.FormatInt(s.Count, 10),
+				formatCSVFloat(s.OPS),
+				formatCSVFloat(s.Scaling),
+				formatCSVFloat(s.Mean),
+				formatCSVFloat(s.P50),
+				formatCSVFloat(s.P99),
+				formatCSVFloat(s.Max),
+			})
+		}
+	}
+	if len(ramp) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "ramp.csv"),
+			[]string{"run", "db", "threads", "count", "ops", "scaling", "mean_us", "p50_us", "p99_us", "max_us"},
+			ramp)
+		if err != nil {
+			return err
+		}
+	}
+
+	var aggregates [][]string
+	for _, group := range r.Aggregates {
+		for _, agg := range group.Operations {
+			summaries := []struct {
+				name string
+				s    MetricSummary
+			}{
+				{"ops", agg.OPS},
+				{"avg_us", agg.Avg},
+				{"p50_us", agg.P50},
+				{"p99_us", agg.P99},
+				{"p999_us", agg.P999},
+			}
+			for _, sm := range summaries {
+				aggregates = append(aggregates, []string{
+					group.Name, agg.Operation, strconv.Itoa(agg.Runs), sm.name,
+					formatCSVFloat(sm.s.Mean),
+					formatCSVFloat(sm.s.StdDev),
+					formatCSVFloat(sm.s.Min),
+					formatCSVFloat(sm.s.Max),
+				})
+			}
+		}
+	}
+	if len(aggregates) > 0 {
+		err := writeCSVFile(filepath.Join(dir, "aggregates.csv"),
+			[]string{"group", "operation", "runs", "metric", "mean", "stddev", "min", "max"},
+			aggregates)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCSVFile writes a header and rows to path
+func writeCSVFile(path string, header []string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
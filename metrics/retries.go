@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RetryStatistics is how often an operation was retried or timed out under
+// the run's retry policy. Every attempt is also in the results table.
+type RetryStatistics struct {
+	Operation string `json:"operation"`
+	Retries   int64  `json:"retries"`
+	Timeouts  int64  `json:"timeouts"`
+	Recovered int64  `json:"recovered"` // succeeded on a retry
+	Exhausted int64  `json:"exhausted"` // still failed after the last retry
+}
+
+// PrintRetries prints the retries and timeouts of every operation that had
+// any
+func PrintRetries(retries []RetryStatistics) {
+	if len(retries) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "RETRIES AND TIMEOUTS"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-16s │ %24s │ %24s │ %23s │ %23s │\n", "Operation", "Retries", "Timeouts", "Recovered", "Exhausted")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, r := range retries {
+		fmt.Printf("│ %-16s │ %24d │ %24d │ %23d │ %23d │\n", r.Operation, r.Retries, r.Timeouts, r.Recovered, r.Exhausted)
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SamplesFile is the name of the raw samples file written next to the plots
+// of every run
+const SamplesFile = "samples.csv.gz"
+
+// samplesHeader is the header row of the samples file. Times are in
+// nanoseconds; start is relative to when tracking began.
+var samplesHeader = []string{"operation", "index", "thread", "start_ns", "latency_ns"}
+
+// WriteSamples writes every recorded sample as gzip-compressed CSV, one row
+// per sample ordered by operation and sample index
+func (bp *BenchmarkPlots) WriteSamples(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := bp.EncodeSamples(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// EncodeSamples writes every recorded sample to out in the format of
+// WriteSamples
+func (bp *BenchmarkPlots) EncodeSamples(out io.Writer) error {
+	bp.order()
+
+	zw := gzip.NewWriter(out)
+	bw := bufio.NewWriter(zw)
+	w := csv.NewWriter(bw)
+	if err := w.Write(samplesHeader); err != nil {
+		return err
+	}
+
+	operations := make([]string, 0, len(bp.samples))
+	for operation := range bp.samples {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	row := make([]string, len(samplesHeader))
+	for _, operation := range operations {
+		for _, s := range bp.samples[operation] {
+			row[0] = operation
+			row[1] = strconv.FormatInt(s.SampleIndex, 10)
+			row[2] = strconv.Itoa(s.Thread)
+			row[3] = strconv.FormatInt(s.Elapsed.Nanoseconds(), 10)
+			row[4] = strconv.FormatInt(s.TotalTime.Nanoseconds(), 10)
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadSamples loads a samples file written by WriteSamples, so plots and
+// statistics can be regenerated without running the benchmark again
+func ReadSamples(path string) (*BenchmarkPlots, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	bp, err := DecodeSamples(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bp, nil
+}
+
+// DecodeSamples reads samples written by EncodeSamples
+func DecodeSamples(in io.Reader) (*BenchmarkPlots, error) {
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(bufio.NewReader(zr))
+	r.FieldsPerRecord = len(samplesHeader)
+	r.ReuseRecord = true
+
+	if _, err := r.Read(); err != nil {
+		return nil, fmt.Errorf("missing header: %w", err)
+	}
+
+	bp := NewBenchmarkPlots()
+	for line := 2; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var values [4]int64
+		for i := range values {
+			if values[i], err = strconv.ParseInt(row[i+1], 10, 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s %q", line, samplesHeader[i+1], row[i+1])
+			}
+		}
+		operation := row[0]
+		bp.samples[operation] = append(bp.samples[operation], SampleData{
+			SampleIndex: values[0],
+			Thread:      int(values[1]),
+			Elapsed:     time.Duration(values[2]),
+			TotalTime:   time.Duration(values[3]),
+		})
+		bp.sampleCounters[operation] = max(bp.sampleCounters[operation], values[0])
+	}
+	return bp, nil
+}
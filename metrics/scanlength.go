@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+// ScanLengthStatistics summarizes the successful scans asking for between
+// MinLength and MaxLength records
+type ScanLengthStatistics struct {
+	MinLength int     `json:"min_length"`
+	MaxLength int     `json:"max_length"`
+	Count     int64   `json:"count"`
+	Records   float64 `json:"records"` // mean records returned
+	Mean      float64 `json:"mean_us"`
+	P50       float64 `json:"p50_us"`
+	P99       float64 `json:"p99_us"`
+	Max       float64 `json:"max_us"`
+	PerRecord float64 `json:"per_record_us"` // mean latency over mean records returned
+}
+
+// scanLengthBucket holds the scans of one power-of-two range of lengths
+type scanLengthBucket struct {
+	latencies *hdrhistogram.Histogram // microseconds
+	records   int64
+}
+
+// scanLengths splits the latencies of scans by the number of records they
+// ask for, in power-of-two ranges, after the warm-up
+type scanLengths struct {
+	mu      sync.Mutex
+	buckets []*scanLengthBucket // index i holds lengths 2^i to 2^(i+1)-1
+}
+
+// record adds one successful scan asking for length records and returning
+// records of them
+func (s *scanLengths) record(length, records int, latency time.Duration) {
+	if !measurement.IsWarmUpFinished() || length < 1 {
+		return
+	}
+	i := bits.Len(uint(length)) - 1
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.buckets) <= i {
+		s.buckets = append(s.buckets, nil)
+	}
+	if s.buckets[i] == nil {
+		s.buckets[i] = &scanLengthBucket{latencies: hdrhistogram.New(1, 24*60*60*1000*1000, 3)}
+	}
+	s.buckets[i].latencies.RecordValue(max(latency.Microseconds(), 1))
+	s.buckets[i].records += int64(records)
+}
+
+// statistics returns a row for every range of lengths scanned, shortest
+// first
+func (s *scanLengths) statistics() []ScanLengthStatistics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []ScanLengthStatistics
+	for i, b := range s.buckets {
+		if b == nil {
+			continue
+		}
+		count := b.latencies.TotalCount()
+		stats := ScanLengthStatistics{
+			MinLength: 1 << i,
+			MaxLength: 1<<(i+1) - 1,
+			Count:     count,
+			Records:   float64(b.records) / float64(count),
+			Mean:      b.latencies.Mean(),
+			P50:       float64(b.latencies.ValueAtPercentile(50)),
+			P99:       float64(b.latencies.ValueAtPercentile(99)),
+			Max:       float64(b.latencies.Max()),
+		}
+		if stats.Records > 0 {
+			stats.PerRecord = stats.Mean / stats.Records
+		}
+		result = append(result, stats)
+	}
+	return result
+}
+
+// ScanLengthStatistics returns the latencies of scans by the number of
+// records they asked for, or nil without scans
+func (ot *OperationTracker) ScanLengthStatistics() []ScanLengthStatistics {
+	return ot.scanLengths.statistics()
+}
+
+// PrintScanLengthStatistics prints a row for every range of scan lengths
+func PrintScanLengthStatistics(all []ScanLengthStatistics) {
+	if len(all) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "SCANS BY LENGTH"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-13s │ %12s │ %10s │ %13s │ %13s │ %13s │ %13s │ %14s │\n",
+		"Length", "Count", "Records", "Mean", "p50", "p99", "Max", "Per record")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, s := range all {
+		length := fmt.Sprintf("%d-%d", s.MinLength, s.MaxLength)
+		if s.MinLength == s.MaxLength {
+			length = fmt.Sprint(s.MinLength)
+		}
+		fmt.Printf("│ %-13s │ %12d │ %10.1f │ %13s │ %13s │ %13s │ %13s │ %14s │\n",
+			length, s.Count, s.Records, formatDuration(s.Mean), formatDuration(s.P50),
+			formatDuration(s.P99), formatDuration(s.Max), formatDuration(s.PerRecord))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
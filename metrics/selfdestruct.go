@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// SelfDestructLevel is the deletion of accounts that all had the same number
+// of storage slots
+type SelfDestructLevel struct {
+	Slots      int     `json:"slots"` // of every account
+	Accounts   int     `json:"accounts"`
+	CreateTime float64 `json:"create_sec"` // spent creating the accounts
+	DeleteTime float64 `json:"delete_sec"` // spent deleting them
+
+	// Deletion latency distribution, in microseconds, and the storage slots
+	// deleted per second of deletion
+	Mean        float64 `json:"delete_mean_us"`
+	P50         float64 `json:"delete_p50_us"`
+	P99         float64 `json:"delete_p99_us"`
+	Max         float64 `json:"delete_max_us"`
+	SlotsPerSec float64 `json:"slots_per_sec"`
+
+	// Size of the data directory before and after the deletions; Reclaimed
+	// is negative when deleting grew it
+	SizeBefore int64 `json:"size_before_bytes"`
+	SizeAfter  int64 `json:"size_after_bytes"`
+	Reclaimed  int64 `json:"reclaimed_bytes"`
+}
+
+// SelfDestructs is the outcome of deleting whole accounts with their
+// storage, a level per storage size
+type SelfDestructs struct {
+	DB     string              `json:"db"`
+	Levels []SelfDestructLevel `json:"levels"`
+}
+
+// NewSelfDestructLevel summarizes the deletions of accounts of slots storage
+// slots each, which took latencies after the accounts took create to create,
+// with the data directory sizeBefore bytes before and sizeAfter after
+func NewSelfDestructLevel(slots int, latencies []time.Duration, create time.Duration, sizeBefore, sizeAfter int64) SelfDestructLevel {
+	l := SelfDestructLevel{
+		Slots:      slots,
+		Accounts:   len(latencies),
+		CreateTime: create.Seconds(),
+		SizeBefore: sizeBefore,
+		SizeAfter:  sizeAfter,
+		Reclaimed:  sizeBefore - sizeAfter,
+	}
+	if len(latencies) == 0 {
+		return l
+	}
+
+	latency := hdrhistogram.New(1, int64(time.Hour), 3)
+	var total time.Duration
+	for _, d := range latencies {
+		total += d
+		latency.RecordValue(max(int64(d), 1))
+	}
+	l.DeleteTime = total.Seconds()
+	if l.DeleteTime > 0 {
+		l.SlotsPerSec = float64(l.Accounts*slots) / l.DeleteTime
+	}
+	us := func(ns int64) float64 { return float64(ns) / 1000 }
+	l.Mean = latency.Mean() / 1000
+	l.P50 = us(latency.ValueAtPercentile(50))
+	l.P99 = us(latency.ValueAtPercentile(99))
+	l.Max = us(latency.Max())
+	return l
+}
+
+// PrintSelfDestructs prints the deletion latencies and the space reclaimed
+// at every storage size
+func PrintSelfDestructs(r *SelfDestructs) {
+	if r == nil {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := fmt.Sprintf("ACCOUNT DELETION BY STORAGE SIZE (%s)", r.DB)
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	if len(r.Levels) == 0 {
+		fmt.Println("No accounts deleted")
+		return
+	}
+	fmt.Printf("│ %12s │ %8s │ %11s │ %11s │ %11s │ %11s │ %12s │ %12s │ %10s │\n",
+		"Slots", "Accounts", "Mean", "p50", "p99", "Max", "Slots/s", "Reclaimed", "Per slot")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, l := range r.Levels {
+		perSlot := "-"
+		if slots := int64(l.Accounts * l.Slots); slots > 0 {
+			perSlot = fmt.Sprintf("%.1f B", float64(l.Reclaimed)/float64(slots))
+		}
+		fmt.Printf("│ %12d │ %8d │ %11s │ %11s │ %11s │ %11s │ %12.0f │ %12s │ %10s │\n",
+			l.Slots, l.Accounts, formatDuration(l.Mean), formatDuration(l.P50), formatDuration(l.P99), formatDuration(l.Max),
+			l.SlotsPerSec, formatSignedBytes(l.Reclaimed), perSlot)
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
+
+// formatSignedBytes formats n bytes in binary units, negative ones too
+func formatSignedBytes(n int64) string {
+	if n < 0 {
+		return "-" + formatBytes(-n)
+	}
+	return formatBytes(n)
+}
@@ -0,0 +1,244 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// DefaultSeriesInterval is the width of each interval of the time series
+const DefaultSeriesInterval = time.Second
+
+// TimeSeries is the throughput and latency of one operation per interval of
+// the run, for plotting them over time and finding stalls after the fact
+type TimeSeries struct {
+	Operation string        `json:"operation"`
+	Start     time.Time     `json:"start,omitzero"` // the first measured operation, which point starts count from
+	Interval  time.Duration `json:"interval_ns"`
+	Points    []SeriesPoint `json:"points"`
+}
+
+// SeriesPoint summarizes the operations that completed in one interval.
+// Intervals without any are kept, with a count of 0, as they mark stalls.
+type SeriesPoint struct {
+	Start  float64 `json:"start_s"` // seconds from the first measured operation
+	Count  int64   `json:"count"`
+	Errors int64   `json:"errors,omitempty"`
+	OPS    float64 `json:"ops"`
+	Mean   float64 `json:"mean_us"`
+	P50    int64   `json:"p50_us"`
+	P90    int64   `json:"p90_us"`
+	P99    int64   `json:"p99_us"`
+	Max    int64   `json:"max_us"`
+}
+
+// seriesLag is how many of the latest intervals of a series stay open.
+// Threads hand their operations over to the series once per interval or
+// seriesBatch operations, so some reach it after others moved it on; they
+// are counted in the interval they completed in while it is open, and in the
+// oldest open one after.
+const seriesLag = 3
+
+// seriesBatch is the most operations a thread holds before handing them over
+// to the series
+const seriesBatch = 256
+
+// seriesEntry is one operation for the time series, in interval index
+type seriesEntry struct {
+	op      string
+	index   int
+	end     time.Time
+	latency time.Duration
+	failed  bool
+}
+
+// opSeries accumulates the time series of one operation: a histogram of each
+// open interval, summarized into a point once the series moves past it, so
+// memory grows by one point per interval rather than with the samples
+type opSeries struct {
+	open   []*seriesInterval // the intervals from base on
+	base   int
+	spare  []*seriesInterval // closed intervals, reset for reuse
+	points []SeriesPoint     // the intervals before base
+}
+
+// seriesInterval is the operations of one open interval
+type seriesInterval struct {
+	hist   *hdrhistogram.Histogram // microseconds
+	sum    time.Duration           // latencies at full precision
+	errors int64
+}
+
+// add records one operation that completed in interval index
+func (s *opSeries) add(index int, latency time.Duration, failed bool, interval time.Duration) {
+	for index >= s.base+seriesLag {
+		s.close(interval)
+	}
+	index = max(index, s.base)
+	for index >= s.base+len(s.open) {
+		if n := len(s.spare); n > 0 {
+			s.open = append(s.open, s.spare[n-1])
+			s.spare = s.spare[:n-1]
+		} else {
+			s.open = append(s.open, &seriesInterval{hist: hdrhistogram.New(1, 24*60*60*1000*1000, 3)})
+		}
+	}
+	i := s.open[index-s.base]
+	if failed {
+		i.errors++
+		return
+	}
+	i.hist.RecordValue(latency.Microseconds())
+	i.sum += latency
+}
+
+// close summarizes the oldest open interval into a point
+func (s *opSeries) close(interval time.Duration) {
+	if len(s.open) == 0 {
+		s.points = append(s.points, SeriesPoint{Start: (time.Duration(s.base) * interval).Seconds()})
+		s.base++
+		return
+	}
+	i := s.open[0]
+	s.points = append(s.points, i.point(s.base, interval, interval))
+	i.hist.Reset()
+	i.sum, i.errors = 0, 0
+	s.spare = append(s.spare, i)
+	s.open = s.open[1:]
+	s.base++
+}
+
+// last returns the latest interval the series reached
+func (s *opSeries) last() int {
+	return s.base + len(s.open) - 1
+}
+
+// point summarizes interval index, of which width has elapsed
+func (i *seriesInterval) point(index int, interval, width time.Duration) SeriesPoint {
+	p := SeriesPoint{
+		Start:  (time.Duration(index) * interval).Seconds(),
+		Count:  i.hist.TotalCount(),
+		Errors: i.errors,
+		OPS:    float64(i.hist.TotalCount()) / width.Seconds(),
+	}
+	if p.Count > 0 {
+		p.Mean = float64(i.sum.Nanoseconds()) / 1000 / float64(p.Count)
+		p.P50 = i.hist.ValueAtPercentile(50)
+		p.P90 = i.hist.ValueAtPercentile(90)
+		p.P99 = i.hist.ValueAtPercentile(99)
+		p.Max = i.hist.Max()
+	}
+	return p
+}
+
+// SetSeriesInterval sets the width of the intervals of the time series; 0
+// disables them
+func (c *Collector) SetSeriesInterval(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interval = interval
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.interval = interval
+		s.mu.Unlock()
+	}
+}
+
+// seriesOrigin returns the start of the time series, the completion of the
+// first operation measured, which is end if there was none before
+func (c *Collector) seriesOrigin(end time.Time) time.Time {
+	if o := c.origin.Load(); o != nil {
+		return *o
+	}
+	first := end
+	c.origin.CompareAndSwap(nil, &first)
+	return *c.origin.Load()
+}
+
+// seriesEntry returns the entry of one operation, in its interval of
+// interval wide ones
+func (c *Collector) seriesEntry(op string, start time.Time, latency time.Duration, failed bool, interval time.Duration) seriesEntry {
+	end := start.Add(latency)
+	index := max(0, int(end.Sub(c.seriesOrigin(end))/interval))
+	return seriesEntry{op: op, index: index, end: end, latency: latency, failed: failed}
+}
+
+// addSeries adds operations to their time series and that of TOTAL. Callers
+// hold the lock.
+func (c *Collector) addSeries(entries []seriesEntry) {
+	if c.interval <= 0 {
+		return
+	}
+	for _, e := range entries {
+		if e.end.After(c.seriesEnd) {
+			c.seriesEnd = e.end
+		}
+		for _, name := range []string{e.op, "TOTAL"} {
+			s, ok := c.series[name]
+			if !ok {
+				s = &opSeries{}
+				c.series[name] = s
+			}
+			s.add(e.index, e.latency, e.failed, c.interval)
+		}
+	}
+}
+
+// Series returns the time series of every operation ordered by name, with
+// TOTAL last, up to and including the interval in progress
+func (c *Collector) Series() []TimeSeries {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.shards {
+		s.mu.Lock()
+		pending := s.series
+		s.series = nil
+		s.mu.Unlock()
+		c.addSeries(pending)
+	}
+
+	operations := make([]string, 0, len(c.series))
+	for op := range c.series {
+		if op != "TOTAL" {
+			operations = append(operations, op)
+		}
+	}
+	sort.Strings(operations)
+
+	// Every series runs to the latest interval any of them reached, which is
+	// only partly over
+	last := 0
+	for _, s := range c.series {
+		last = max(last, s.last())
+	}
+	var origin time.Time
+	if o := c.origin.Load(); o != nil {
+		origin = *o
+	}
+	lastWidth := c.seriesEnd.Sub(origin) - time.Duration(last)*c.interval
+	if lastWidth <= 0 || lastWidth > c.interval {
+		lastWidth = c.interval
+	}
+
+	result := make([]TimeSeries, 0, len(c.series))
+	for _, op := range append(operations, "TOTAL") {
+		s, ok := c.series[op]
+		if !ok {
+			continue
+		}
+		points := append([]SeriesPoint(nil), s.points...)
+		for i, open := range s.open {
+			width := c.interval
+			if s.base+i == last {
+				width = lastWidth
+			}
+			points = append(points, open.point(s.base+i, c.interval, width))
+		}
+		for i := s.last() + 1; i <= last; i++ {
+			points = append(points, SeriesPoint{Start: (time.Duration(i) * c.interval).Seconds()})
+		}
+		result = append(result, TimeSeries{Operation: op, Start: origin, Interval: c.interval, Points: points})
+	}
+	return result
+}
@@ -4,36 +4,124 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Statistics holds statistical metrics for a benchmark
 type Statistics struct {
-	Mean       float64
-	StdDev     float64
-	Median     float64
-	MAD        float64 // Median Absolute Deviation
-	Min        float64
-	Max        float64
-	Count      int64
-	Throughput float64 // Operations per second
-	R2         float64 // R-squared from linear regression
+	Mean       float64  `json:"mean_us"`
+	StdDev     float64  `json:"stddev_us"`
+	Median     float64  `json:"median_us"`
+	MAD        float64  `json:"mad_us"` // Median Absolute Deviation
+	Min        float64  `json:"min_us"`
+	Max        float64  `json:"max_us"`
+	Count      int64    `json:"count"`
+	Throughput float64  `json:"throughput"` // Operations per second
+	R2         float64  `json:"r2"`         // R-squared from linear regression
+	Outliers   Outliers `json:"outliers"`
+}
+
+// Outliers counts the samples beyond the Tukey fences, classified as
+// criterion.rs does: mild beyond 1.5 IQR of the quartiles, severe beyond 3 IQR
+type Outliers struct {
+	LowSevere  int64 `json:"low_severe"`
+	LowMild    int64 `json:"low_mild"`
+	HighMild   int64 `json:"high_mild"`
+	HighSevere int64 `json:"high_severe"`
+}
+
+// Total returns the number of outliers of every class
+func (o Outliers) Total() int64 {
+	return o.LowSevere + o.LowMild + o.HighMild + o.HighSevere
+}
+
+// classifyOutliers counts the values of times outside the Tukey fences
+func classifyOutliers(times []float64) Outliers {
+	var o Outliers
+	if len(times) == 0 {
+		return o
+	}
+	fences := tukeyFences(times)
+	for _, t := range times {
+		switch {
+		case t < fences[0]:
+			o.LowSevere++
+		case t < fences[1]:
+			o.LowMild++
+		case t > fences[3]:
+			o.HighSevere++
+		case t > fences[2]:
+			o.HighMild++
+		}
+	}
+	return o
 }
 
 // ConfidenceInterval represents a confidence interval for a statistic
 type ConfidenceInterval struct {
-	LowerBound float64
-	Estimate   float64
-	UpperBound float64
+	LowerBound float64 `json:"lower"`
+	Estimate   float64 `json:"estimate"`
+	UpperBound float64 `json:"upper"`
+	StdError   float64 `json:"std_error"` // standard deviation of the bootstrap distribution
 }
 
+// Default bootstrap settings, the same as criterion.rs'
 const (
-	bootstrapSamples = 100000 // Number of bootstrap resamples (same as criterion.rs default)
-	confidenceLevel  = 0.95   // 95% confidence interval
+	DefaultBootstrapResamples = 100000 // Number of bootstrap resamples
+	DefaultConfidenceLevel    = 0.95   // 95% confidence interval
 )
 
+// Bootstrap holds the settings of the resampling behind the confidence
+// intervals of a run. Every tracker and sample set has its own, so those of
+// one run never carry over to the next.
+type Bootstrap struct {
+	Resamples int     // resamples per interval; fewer trade precision for speed, e.g. 1,000 for smoke runs
+	Level     float64 // confidence level of every interval
+	Seed      int64   // makes resampling reproducible across runs, if Seeded
+	Seeded    bool    // otherwise resampling is seeded by the current time
+}
+
+// DefaultBootstrap returns the default bootstrap settings
+func DefaultBootstrap() Bootstrap {
+	return Bootstrap{Resamples: DefaultBootstrapResamples, Level: DefaultConfidenceLevel}
+}
+
+// ValidateBootstrap returns an error if the resample count or confidence
+// level cannot be used
+func ValidateBootstrap(resamples int, level float64) error {
+	if resamples < 10 {
+		return fmt.Errorf("bootstrap resamples must be at least 10, got %d", resamples)
+	}
+	if level <= 0 || level >= 1 {
+		return fmt.Errorf("confidence level must be between 0 and 1, got %g", level)
+	}
+	return nil
+}
+
+// Validate returns an error if the settings cannot be used
+func (b Bootstrap) Validate() error {
+	return ValidateBootstrap(b.Resamples, b.Level)
+}
+
+// formatLevel formats a confidence level as a percentage, e.g. "95%" or "99.9%"
+func formatLevel(level float64) string {
+	return strconv.FormatFloat(100*level, 'f', -1, 64) + "%"
+}
+
+// newRand returns a generator seeded by b's seed, or by the current time
+func (b Bootstrap) newRand() *rand.Rand {
+	if b.Seeded {
+		return rand.New(rand.NewSource(b.Seed))
+	}
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
 // calculateStatistics computes statistical metrics from sample data
 func calculateStatistics(samples []SampleData) Statistics {
 	if len(samples) == 0 {
@@ -78,8 +166,7 @@ func calculateStatistics(samples []SampleData) Statistics {
 	mad := calculateMAD(sortedTimes, median)
 
 	// Calculate throughput (ops/sec)
-	meanSeconds := mean / 1_000_000 // Convert microseconds to seconds
-	throughput := 1.0 / meanSeconds
+	throughput := opsPerSecond(mean)
 
 	// Calculate R² (we don't do linear regression here since we're just tracking individual ops)
 	// For individual operations, R² isn't as meaningful, but we can calculate it if needed
@@ -95,9 +182,20 @@ func calculateStatistics(samples []SampleData) Statistics {
 		Count:      int64(len(samples)),
 		Throughput: throughput,
 		R2:         r2,
+		Outliers:   classifyOutliers(times),
 	}
 }
 
+// opsPerSecond returns the throughput of operations taking meanUs
+// microseconds on average, or 0 when they all took under a microsecond, as
+// times are whole microseconds and JSON has no infinity
+func opsPerSecond(meanUs float64) float64 {
+	if meanUs <= 0 {
+		return 0
+	}
+	return 1_000_000 / meanUs
+}
+
 // calculateMedian returns the median of a sorted slice
 func calculateMedian(sorted []float64) float64 {
 	n := len(sorted)
@@ -110,6 +208,70 @@ func calculateMedian(sorted []float64) float64 {
 	return sorted[n/2]
 }
 
+// selectMedian returns the median of values in linear time by quickselect,
+// reordering values
+func selectMedian(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	upper := selectKth(values, n/2)
+	if n%2 == 1 {
+		return upper
+	}
+	// Selection leaves the smaller half below n/2, so the lower middle value
+	// is its maximum
+	lower := values[0]
+	for _, v := range values[1 : n/2] {
+		lower = math.Max(lower, v)
+	}
+	return (lower + upper) / 2.0
+}
+
+// selectKth reorders values so that values[k] is the k-th smallest, with no
+// larger value before it and no smaller one after, and returns it
+func selectKth(values []float64, k int) float64 {
+	lo, hi := 0, len(values)-1
+	for lo < hi {
+		// Hoare partition around the median of three, which stays balanced on
+		// the many equal values of microsecond latencies
+		mid := lo + (hi-lo)/2
+		if values[mid] < values[lo] {
+			values[mid], values[lo] = values[lo], values[mid]
+		}
+		if values[hi] < values[lo] {
+			values[hi], values[lo] = values[lo], values[hi]
+		}
+		if values[hi] < values[mid] {
+			values[hi], values[mid] = values[mid], values[hi]
+		}
+		pivot := values[mid]
+		i, j := lo, hi
+		for i <= j {
+			for values[i] < pivot {
+				i++
+			}
+			for values[j] > pivot {
+				j--
+			}
+			if i <= j {
+				values[i], values[j] = values[j], values[i]
+				i++
+				j--
+			}
+		}
+		switch {
+		case k <= j:
+			hi = j
+		case k >= i:
+			lo = i
+		default:
+			return values[k]
+		}
+	}
+	return values[k]
+}
+
 // calculateMAD calculates the Median Absolute Deviation
 func calculateMAD(sorted []float64, median float64) float64 {
 	if len(sorted) == 0 {
@@ -182,94 +344,245 @@ func calculateR2(samples []SampleData) float64 {
 	return r2
 }
 
-// bootstrapResample performs bootstrap resampling to calculate confidence intervals
-func bootstrapResample(samples []SampleData, statFunc func([]float64) float64, numResamples int) ConfidenceInterval {
-	if len(samples) == 0 {
-		return ConfidenceInterval{}
+// bootstrapChunk is the number of resamples drawn from one generator. Work is
+// split into chunks rather than per worker, so a seeded run gives the same
+// intervals whatever the number of CPUs.
+const bootstrapChunk = 1000
+
+// parallel calls the resample function of a worker for every index in
+// [0, n), spread over GOMAXPROCS workers. newWorker is called once per
+// worker, so the function it returns can reuse its buffers across resamples.
+func (b Bootstrap) parallel(n int, newWorker func() func(i int, rng *rand.Rand)) {
+	base := b.newRand().Int63()
+	chunks := (n + bootstrapChunk - 1) / bootstrapChunk
+	workers := min(runtime.GOMAXPROCS(0), chunks)
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resample := newWorker()
+			for {
+				chunk := int(next.Add(1) - 1)
+				if chunk >= chunks {
+					return
+				}
+				rng := rand.New(rand.NewSource(base + int64(chunk)))
+				for i := chunk * bootstrapChunk; i < min(n, (chunk+1)*bootstrapChunk); i++ {
+					resample(i, rng)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// bootstrapStat computes a statistic of times. Statistics of one resample
+// share its buffer: they may reorder times but not change its values, and
+// scratch is a buffer of the same length they may overwrite.
+type bootstrapStat func(times, scratch []float64) float64
+
+// intervals resamples times with replacement b.Resamples times and returns
+// the confidence interval of each statistic. Every statistic is computed on
+// the same resamples, as criterion.rs does.
+func (b Bootstrap) intervals(times []float64, stats ...bootstrapStat) []ConfidenceInterval {
+	numResamples := b.Resamples
+	intervals := make([]ConfidenceInterval, len(stats))
+	if len(times) == 0 {
+		return intervals
+	}
+
+	values := make([][]float64, len(stats))
+	for s := range stats {
+		values[s] = make([]float64, numResamples)
+	}
+	b.parallel(numResamples, func() func(int, *rand.Rand) {
+		resample := make([]float64, len(times))
+		scratch := make([]float64, len(times))
+		return func(i int, rng *rand.Rand) {
+			for j := range resample {
+				resample[j] = times[rng.Intn(len(times))]
+			}
+			for s, stat := range stats {
+				values[s][i] = stat(resample, scratch)
+			}
+		}
+	})
+
+	// The estimates come from the original sample, on a copy as the
+	// statistics may reorder it
+	original := append([]float64(nil), times...)
+	scratch := make([]float64, len(times))
+	for s, stat := range stats {
+		intervals[s] = b.interval(stat(original, scratch), values[s])
 	}
+	return intervals
+}
+
+// resample performs bootstrap resampling to calculate the confidence
+// interval of one statistic
+func (b Bootstrap) resample(samples []SampleData, stat bootstrapStat) ConfidenceInterval {
+	return b.intervals(sampleTimes(samples), stat)[0]
+}
 
+// sampleTimes returns the latencies of samples in microseconds
+func sampleTimes(samples []SampleData) []float64 {
 	times := make([]float64, len(samples))
 	for i, sample := range samples {
 		times[i] = float64(sample.TotalTime.Microseconds())
 	}
+	return times
+}
 
-	// Calculate the actual statistic from the original sample
-	estimate := statFunc(times)
+// interval returns the confidence interval around estimate given by the
+// percentiles of the bootstrap distribution values, which it sorts
+func (b Bootstrap) interval(estimate float64, values []float64) ConfidenceInterval {
+	sort.Float64s(values)
+	alpha := 1.0 - b.Level
+	lowerIdx := int(float64(len(values)) * (alpha / 2.0))
+	upperIdx := min(int(float64(len(values))*(1.0-alpha/2.0)), len(values)-1)
+	return ConfidenceInterval{
+		LowerBound: values[lowerIdx],
+		Estimate:   estimate,
+		UpperBound: values[upperIdx],
+		StdError:   standardDeviation(values),
+	}
+}
 
-	// Perform bootstrap resampling
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	bootstrapStats := make([]float64, numResamples)
+// regressionBootstrapSamples is the most resamples behind the slope's and
+// R²'s confidence intervals. Each resample costs a pass over every sample, so
+// it is smaller than DefaultBootstrapResamples.
+const regressionBootstrapSamples = 1000
 
-	for i := 0; i < numResamples; i++ {
-		// Resample with replacement
-		resample := make([]float64, len(times))
-		for j := 0; j < len(times); j++ {
-			idx := rng.Intn(len(times))
-			resample[j] = times[idx]
-		}
-		bootstrapStats[i] = statFunc(resample)
+// regression returns the settings of the slope and R² intervals, whose
+// resamples are never more than b's
+func (b Bootstrap) regression() Bootstrap {
+	b.Resamples = min(regressionBootstrapSamples, b.Resamples)
+	return b
+}
+
+// regressionSlope fits the cumulative time after k samples against k with a
+// line through the origin, as criterion fits total time against iteration
+// count, and returns the slope: the time per iteration
+func regressionSlope(times []float64) float64 {
+	var cumulative, sumXY, sumX2 float64
+	for i, t := range times {
+		x := float64(i + 1)
+		cumulative += t
+		sumXY += x * cumulative
+		sumX2 += x * x
 	}
+	if sumX2 == 0 {
+		return 0
+	}
+	return sumXY / sumX2
+}
 
-	// Sort bootstrap statistics
-	sort.Float64s(bootstrapStats)
+// regressionSlope returns the slope of the cumulative time fit with a
+// bootstrap confidence interval
+func (b Bootstrap) regressionSlope(times []float64) ConfidenceInterval {
+	if len(times) == 0 {
+		return ConfidenceInterval{}
+	}
 
-	// Calculate confidence interval bounds (95% CI)
-	// Using percentile bootstrap method
-	alpha := 1.0 - confidenceLevel
-	lowerIdx := int(float64(numResamples) * (alpha / 2.0))
-	upperIdx := int(float64(numResamples) * (1.0 - alpha/2.0))
+	b = b.regression()
+	slopes := make([]float64, b.Resamples)
+	b.parallel(len(slopes), func() func(int, *rand.Rand) {
+		resample := make([]float64, len(times))
+		return func(i int, rng *rand.Rand) {
+			for j := range resample {
+				resample[j] = times[rng.Intn(len(times))]
+			}
+			slopes[i] = regressionSlope(resample)
+		}
+	})
+	return b.interval(regressionSlope(times), slopes)
+}
 
-	if lowerIdx < 0 {
-		lowerIdx = 0
+// standardDeviation returns the population standard deviation of values
+func standardDeviation(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
 	}
-	if upperIdx >= numResamples {
-		upperIdx = numResamples - 1
+	var mean float64
+	for _, v := range values {
+		mean += v
 	}
+	mean /= float64(len(values))
 
-	return ConfidenceInterval{
-		LowerBound: bootstrapStats[lowerIdx],
-		Estimate:   estimate,
-		UpperBound: bootstrapStats[upperIdx],
+	var variance float64
+	for _, v := range values {
+		diff := v - mean
+		variance += diff * diff
 	}
+	return math.Sqrt(variance / float64(len(values)))
 }
 
-// PrintStatistics outputs statistics in a criterion-style format
-func (bp *BenchmarkPlots) PrintStatistics() {
+// OperationStatistics holds the criterion-style statistics of one operation
+// with bootstrap confidence intervals. Times are in microseconds.
+type OperationStatistics struct {
+	Operation       string             `json:"operation"`
+	ConfidenceLevel float64            `json:"confidence_level"`
+	Statistics      Statistics         `json:"statistics"`
+	Throughput      ConfidenceInterval `json:"throughput"`
+	R2              ConfidenceInterval `json:"r2"`
+	Mean            ConfidenceInterval `json:"mean_us"`
+	StdDev          ConfidenceInterval `json:"stddev_us"`
+	Median          ConfidenceInterval `json:"median_us"`
+	MAD             ConfidenceInterval `json:"mad_us"`
+	// Slope is criterion's regression estimate of the time per operation.
+	// Samples are single operations rather than criterion's growing batches,
+	// so the fit through the origin makes it a weighted mean of the times
+	// that comes out about equal to Mean.
+	Slope ConfidenceInterval `json:"slope_us"`
+}
+
+// ComputeStatistics calculates statistics and confidence intervals for every
+// sampled operation, ordered by operation name
+func (bp *BenchmarkPlots) ComputeStatistics() []OperationStatistics {
+	bp.order()
+
+	operations := make([]string, 0, len(bp.samples))
 	for operation, samples := range bp.samples {
-		if len(samples) == 0 {
-			continue
+		if len(samples) > 0 {
+			operations = append(operations, operation)
 		}
+	}
+	sort.Strings(operations)
 
-		stats := calculateStatistics(samples)
-
-		fmt.Println("\n" + strings.Repeat("=", 80))
-		fmt.Printf("%s: Additional Statistics\n", operation)
-		fmt.Println(strings.Repeat("=", 80))
+	result := make([]OperationStatistics, 0, len(operations))
+	for _, operation := range operations {
+		result = append(result, bp.bootstrap.operationStatistics(operation, bp.samples[operation]))
+	}
+	return result
+}
 
-		// Calculate all confidence intervals using bootstrap resampling
+// operationStatistics calculates all confidence intervals for one operation
+// using bootstrap resampling
+func (b Bootstrap) operationStatistics(operation string, samples []SampleData) OperationStatistics {
+	stats := calculateStatistics(samples)
 
-		// Throughput CI (inverted from time)
-		throughputCI := bootstrapResample(samples, func(times []float64) float64 {
+	intervals := b.intervals(sampleTimes(samples),
+		// Throughput (inverted from time)
+		func(times, _ []float64) float64 {
 			sum := 0.0
 			for _, t := range times {
 				sum += t
 			}
-			meanTimeUs := sum / float64(len(times))
-			meanTimeSec := meanTimeUs / 1_000_000.0
-			return 1.0 / meanTimeSec // ops/sec
-		}, bootstrapSamples)
-
-		// Mean CI
-		meanCI := bootstrapResample(samples, func(times []float64) float64 {
+			return opsPerSecond(sum / float64(len(times)))
+		},
+		// Mean
+		func(times, _ []float64) float64 {
 			sum := 0.0
 			for _, t := range times {
 				sum += t
 			}
 			return sum / float64(len(times))
-		}, bootstrapSamples)
-
-		// Std. Dev CI
-		stdDevCI := bootstrapResample(samples, func(times []float64) float64 {
+		},
+		// Std. Dev
+		func(times, _ []float64) float64 {
 			mean := 0.0
 			for _, t := range times {
 				mean += t
@@ -282,62 +595,84 @@ func (bp *BenchmarkPlots) PrintStatistics() {
 				variance += diff * diff
 			}
 			return math.Sqrt(variance / float64(len(times)))
-		}, bootstrapSamples)
-
-		// Median CI
-		medianCI := bootstrapResample(samples, func(times []float64) float64 {
-			sorted := make([]float64, len(times))
-			copy(sorted, times)
-			sort.Float64s(sorted)
-			return calculateMedian(sorted)
-		}, bootstrapSamples)
-
-		// MAD CI
-		madCI := bootstrapResample(samples, func(times []float64) float64 {
-			sorted := make([]float64, len(times))
-			copy(sorted, times)
-			sort.Float64s(sorted)
-			median := calculateMedian(sorted)
-			return calculateMAD(sorted, median)
-		}, bootstrapSamples)
-
-		// R² CI - need to calculate R² for bootstrapped samples
-		r2CI := ConfidenceInterval{
-			LowerBound: stats.R2,
-			Estimate:   stats.R2,
-			UpperBound: stats.R2,
-		}
+		},
+		// Median
+		func(times, _ []float64) float64 {
+			return selectMedian(times)
+		},
+		// MAD
+		func(times, scratch []float64) float64 {
+			median := selectMedian(times)
+			for i, t := range times {
+				scratch[i] = math.Abs(t - median)
+			}
+			return selectMedian(scratch)
+		},
+	)
+	throughputCI, meanCI, stdDevCI, medianCI, madCI := intervals[0], intervals[1], intervals[2], intervals[3], intervals[4]
+
+	// R² CI - need to calculate R² for bootstrapped samples
+	r2CI := ConfidenceInterval{
+		LowerBound: stats.R2,
+		Estimate:   stats.R2,
+		UpperBound: stats.R2,
+	}
 
-		// For R², we'd need to bootstrap the entire sample set with indices
-		// This is more complex, so we'll use a simplified approach
-		// In criterion.rs, they bootstrap the linear regression slopes
-		if len(samples) > 10 {
-			r2Samples := make([]float64, 1000) // Reduced for R² calculation
-			rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-			for i := 0; i < 1000; i++ {
-				// Resample samples (not just times)
-				resampledData := make([]SampleData, len(samples))
-				for j := 0; j < len(samples); j++ {
-					idx := rng.Intn(len(samples))
-					resampledData[j] = samples[idx]
+	// For R², we'd need to bootstrap the entire sample set with indices
+	// This is more complex, so we'll use a simplified approach
+	// In criterion.rs, they bootstrap the linear regression slopes
+	if len(samples) > 10 {
+		r2Samples := make([]float64, b.regression().Resamples) // Reduced for R² calculation
+		b.parallel(len(r2Samples), func() func(int, *rand.Rand) {
+			// Resample samples (not just times)
+			resampledData := make([]SampleData, len(samples))
+			return func(i int, rng *rand.Rand) {
+				for j := range resampledData {
+					resampledData[j] = samples[rng.Intn(len(samples))]
 				}
 				r2Samples[i] = calculateR2(resampledData)
 			}
+		})
+		r2CI = b.interval(stats.R2, r2Samples)
+	}
 
-			sort.Float64s(r2Samples)
-			lowerIdx := int(float64(1000) * 0.025)
-			upperIdx := int(float64(1000) * 0.975)
-			if lowerIdx < 0 {
-				lowerIdx = 0
-			}
-			if upperIdx >= 1000 {
-				upperIdx = 999
-			}
+	times := sampleTimes(samples)
+	slopeCI := b.regressionSlope(times)
+
+	return OperationStatistics{
+		Operation:       operation,
+		ConfidenceLevel: b.Level,
+		Statistics:      stats,
+		Throughput:      throughputCI,
+		R2:              r2CI,
+		Mean:            meanCI,
+		StdDev:          stdDevCI,
+		Median:          medianCI,
+		MAD:             madCI,
+		Slope:           slopeCI,
+	}
+}
 
-			r2CI.LowerBound = r2Samples[lowerIdx]
-			r2CI.UpperBound = r2Samples[upperIdx]
-		}
+// level returns the confidence level of the intervals, which results
+// recorded before it was configurable leave unset
+func (s OperationStatistics) level() float64 {
+	if s.ConfidenceLevel == 0 {
+		return DefaultConfidenceLevel
+	}
+	return s.ConfidenceLevel
+}
+
+// PrintStatistics outputs statistics in a criterion-style format
+func (bp *BenchmarkPlots) PrintStatistics() {
+	PrintOperationStatistics(bp.ComputeStatistics())
+}
+
+// PrintOperationStatistics prints precomputed statistics in a criterion-style format
+func PrintOperationStatistics(all []OperationStatistics) {
+	for _, s := range all {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Printf("%s: Additional Statistics (%s confidence)\n", s.Operation, formatLevel(s.level()))
+		fmt.Println(strings.Repeat("=", 80))
 
 		// Print table header
 		fmt.Printf("%-15s %15s %15s %15s\n", "", "Lower bound", "Estimate", "Upper bound")
@@ -345,39 +680,60 @@ func (bp *BenchmarkPlots) PrintStatistics() {
 		// Print each statistic
 		fmt.Printf("%-15s %15s %15s %15s\n",
 			"Throughput",
-			formatThroughput(throughputCI.LowerBound),
-			formatThroughput(throughputCI.Estimate),
-			formatThroughput(throughputCI.UpperBound))
+			formatThroughput(s.Throughput.LowerBound),
+			formatThroughput(s.Throughput.Estimate),
+			formatThroughput(s.Throughput.UpperBound))
 
 		fmt.Printf("%-15s %15.7f %15.7f %15.7f\n",
 			"R²",
-			r2CI.LowerBound,
-			r2CI.Estimate,
-			r2CI.UpperBound)
-
-		fmt.Printf("%-15s %15s %15s %15s\n",
-			"Mean",
-			formatDuration(meanCI.LowerBound),
-			formatDuration(meanCI.Estimate),
-			formatDuration(meanCI.UpperBound))
-
-		fmt.Printf("%-15s %15s %15s %15s\n",
-			"Std. Dev.",
-			formatDuration(stdDevCI.LowerBound),
-			formatDuration(stdDevCI.Estimate),
-			formatDuration(stdDevCI.UpperBound))
+			s.R2.LowerBound,
+			s.R2.Estimate,
+			s.R2.UpperBound)
+
+		durations := []struct {
+			name string
+			ci   ConfidenceInterval
+		}{
+			{"Mean", s.Mean},
+			{"Std. Dev.", s.StdDev},
+			{"Median", s.Median},
+			{"MAD", s.MAD},
+			{"Slope", s.Slope},
+		}
+		for _, d := range durations {
+			fmt.Printf("%-15s %15s %15s %15s\n",
+				d.name,
+				formatDuration(d.ci.LowerBound),
+				formatDuration(d.ci.Estimate),
+				formatDuration(d.ci.UpperBound))
+		}
 
-		fmt.Printf("%-15s %15s %15s %15s\n",
-			"Median",
-			formatDuration(medianCI.LowerBound),
-			formatDuration(medianCI.Estimate),
-			formatDuration(medianCI.UpperBound))
+		printOutliers(s.Statistics.Outliers, s.Statistics.Count)
+	}
+}
 
-		fmt.Printf("%-15s %15s %15s %15s\n",
-			"MAD",
-			formatDuration(madCI.LowerBound),
-			formatDuration(madCI.Estimate),
-			formatDuration(madCI.UpperBound))
+// printOutliers reports the outliers among count samples the way criterion.rs
+// does, one line per class that has any
+func printOutliers(o Outliers, count int64) {
+	total := o.Total()
+	if total == 0 || count == 0 {
+		return
+	}
+	share := func(n int64) float64 { return 100 * float64(n) / float64(count) }
+	fmt.Printf("\nFound %d outliers among %d measurements (%.2f%%)\n", total, count, share(total))
+	classes := []struct {
+		name string
+		n    int64
+	}{
+		{"low severe", o.LowSevere},
+		{"low mild", o.LowMild},
+		{"high mild", o.HighMild},
+		{"high severe", o.HighSevere},
+	}
+	for _, c := range classes {
+		if c.n > 0 {
+			fmt.Printf("  %d (%.2f%%) %s\n", c.n, share(c.n), c.name)
+		}
 	}
 }
 
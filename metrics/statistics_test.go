@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestOperationStatisticsSubMicrosecond(t *testing.T) {
+	samples := make([]SampleData, 100)
+	for i := range samples {
+		samples[i] = SampleData{SampleIndex: int64(i + 1), TotalTime: 300 * time.Nanosecond, Elapsed: time.Duration(i) * time.Microsecond}
+	}
+	s := DefaultBootstrap().operationStatistics("SCAN", samples)
+	if s.Throughput.Estimate != 0 || s.Throughput.UpperBound != 0 {
+		t.Errorf("throughput = %+v, want 0 for operations under a microsecond", s.Throughput)
+	}
+	if _, err := json.Marshal(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBootstrapPerSampleSet(t *testing.T) {
+	a, b := NewBenchmarkPlots(), NewBenchmarkPlots()
+	a.SetBootstrap(Bootstrap{Resamples: 100, Level: 0.99})
+	for i := range 50 {
+		a.AddSample("READ", 0, a.start.Add(time.Duration(i)*time.Microsecond), time.Duration(i+1)*time.Microsecond)
+		b.AddSample("READ", 0, b.start.Add(time.Duration(i)*time.Microsecond), time.Duration(i+1)*time.Microsecond)
+	}
+	if got := a.ComputeStatistics()[0].ConfidenceLevel; got != 0.99 {
+		t.Errorf("level = %g, want the 0.99 set", got)
+	}
+	if got := b.ComputeStatistics()[0].ConfidenceLevel; got != DefaultConfidenceLevel {
+		t.Errorf("level = %g, want the default %g of a sample set without settings", got, DefaultConfidenceLevel)
+	}
+}
+
+func TestBootstrapSeed(t *testing.T) {
+	times := make([]float64, 200)
+	for i := range times {
+		times[i] = float64(i%17 + 1)
+	}
+	seeded := Bootstrap{Resamples: 1000, Level: 0.95, Seed: 42, Seeded: true}
+	mean := func(times, _ []float64) float64 {
+		var sum float64
+		for _, t := range times {
+			sum += t
+		}
+		return sum / float64(len(times))
+	}
+	a, b := seeded.intervals(times, mean)[0], seeded.intervals(times, mean)[0]
+	if a != b {
+		t.Errorf("seeded intervals %+v and %+v differ", a, b)
+	}
+	if c := DefaultBootstrap(); c.Seeded {
+		t.Errorf("default settings %+v are seeded", c)
+	}
+}
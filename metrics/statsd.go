@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStatsdInterval is how often the statsd emitter flushes
+const DefaultStatsdInterval = time.Second
+
+// statsdPacketSize keeps packets within a typical MTU once IP and UDP
+// headers are added
+const statsdPacketSize = 1432
+
+// StatsdEmitter sends every operation's throughput and latency to a statsd
+// server over UDP while the run is in progress, aggregated per interval
+// rather than per operation so it stays cheap at any throughput. Each
+// interval sends, under prefix.operation:
+//
+//	count, errors          counters of the operations in the interval
+//	ops                    gauge of operations per second
+//	mean_us, p50_us,
+//	p99_us, max_us         gauges of the interval's latencies
+//
+// Sends are fire-and-forget: an unreachable server loses metrics but never
+// slows or fails the run.
+type StatsdEmitter struct {
+	conn     net.Conn
+	prefix   string
+	window   *LiveWindow
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewStatsdEmitter creates an emitter sending the operations of window to
+// the statsd server at address (host:port) every interval
+func NewStatsdEmitter(address, prefix string, window *LiveWindow, interval time.Duration) (*StatsdEmitter, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("statsd interval must be positive, got %s", interval)
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd at %s: %w", address, err)
+	}
+	return &StatsdEmitter{
+		conn:     conn,
+		prefix:   strings.TrimSuffix(prefix, "."),
+		window:   window,
+		interval: interval,
+	}, nil
+}
+
+// Start begins sending in the background
+func (e *StatsdEmitter) Start() {
+	e.window.Take() // the interval starts now
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.flush()
+			case <-e.stop:
+				e.flush() // the partial interval at the end
+				return
+			}
+		}
+	}()
+}
+
+// Stop sends the last interval and closes the connection
+func (e *StatsdEmitter) Stop() {
+	if e.stop != nil {
+		close(e.stop)
+		<-e.done
+		e.stop = nil
+	}
+	e.conn.Close()
+}
+
+// flush sends the operations since the previous flush, packing as many
+// lines into each packet as fit
+func (e *StatsdEmitter) flush() {
+	var packet strings.Builder
+	send := func() {
+		if packet.Len() > 0 {
+			e.conn.Write([]byte(packet.String()))
+			packet.Reset()
+		}
+	}
+	for _, line := range e.lines(e.window.Take()) {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsdPacketSize {
+			send()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	send()
+}
+
+// lines returns the statsd lines of one interval. Latency gauges are left
+// out for operations without any in the interval, so they do not drop to 0.
+func (e *StatsdEmitter) lines(s LiveSnapshot) []string {
+	var lines []string
+	for _, op := range s.Operations {
+		name := e.prefix + "." + strings.ToLower(op.Operation) + "."
+		lines = append(lines,
+			name+"count:"+strconv.FormatInt(op.Count, 10)+"|c",
+			name+"errors:"+strconv.FormatInt(op.Errors, 10)+"|c",
+			name+"ops:"+strconv.FormatFloat(op.OPS, 'f', 2, 64)+"|g")
+		if op.Count > 0 {
+			lines = append(lines,
+				name+"mean_us:"+strconv.FormatFloat(op.Mean, 'f', 2, 64)+"|g",
+				name+"p50_us:"+strconv.FormatInt(op.P50, 10)+"|g",
+				name+"p99_us:"+strconv.FormatInt(op.P99, 10)+"|g",
+				name+"max_us:"+strconv.FormatInt(op.Max, 10)+"|g")
+		}
+	}
+	return lines
+}
@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// Steady-state detection defaults: the first window of 10 series intervals
+// over which throughput and mean latency vary by at most 10%
+const (
+	DefaultSteadyStateWindow = 10
+	DefaultSteadyStateCV     = 0.1
+)
+
+// SteadyState is where the run settled, found from the TOTAL time series:
+// the start of the first window of Window intervals over which the
+// coefficient of variation of both the throughput and the mean latency is at
+// most Threshold. Its operations cover only what completed from then on.
+type SteadyState struct {
+	Reached      bool                 `json:"reached"`
+	Window       int                  `json:"window"` // intervals
+	Interval     time.Duration        `json:"interval_ns"`
+	Threshold    float64              `json:"cv_threshold"`
+	Start        float64              `json:"start_s"`              // seconds from the first measured operation
+	Duration     float64              `json:"duration_s,omitempty"` // of the steady part
+	Measured     float64              `json:"measured_s"`           // of the whole measured run
+	ThroughputCV float64              `json:"throughput_cv,omitempty"`
+	LatencyCV    float64              `json:"latency_cv,omitempty"`
+	Operations   []SteadyStateMetrics `json:"operations,omitempty"`
+}
+
+// SteadyStateMetrics are one operation's metrics in steady state next to
+// those of the full run. Latency percentiles need the samples and are 0
+// without them.
+type SteadyStateMetrics struct {
+	Operation string  `json:"operation"`
+	Count     int64   `json:"count"`
+	OPS       float64 `json:"ops"`
+	Mean      float64 `json:"mean_us"`
+	P50       int64   `json:"p50_us,omitempty"`
+	P99       int64   `json:"p99_us,omitempty"`
+	P999      int64   `json:"p999_us,omitempty"`
+	Max       int64   `json:"max_us,omitempty"`
+	FullOPS   float64 `json:"full_ops"`
+	FullMean  float64 `json:"full_mean_us"`
+	FullP99   int64   `json:"full_p99_us"`
+}
+
+// detectSteadyState returns the index of the first point of total that
+// starts window points whose throughput and mean latency both have a
+// coefficient of variation of at most threshold, and those variations. The
+// last point is left out, as the run ends partway through it.
+func detectSteadyState(total []SeriesPoint, window int, threshold float64) (index int, throughputCV, latencyCV float64, ok bool) {
+	points := total[:max(len(total)-1, 0)]
+	ops := make([]float64, window)
+	means := make([]float64, window)
+	for i := 0; i+window <= len(points); i++ {
+		for j, p := range points[i : i+window] {
+			ops[j], means[j] = p.OPS, p.Mean
+		}
+		throughputCV, latencyCV = coefficientOfVariation(ops), coefficientOfVariation(means)
+		if throughputCV <= threshold && latencyCV <= threshold {
+			return i, throughputCV, latencyCV, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// coefficientOfVariation returns the sample standard deviation of values
+// over their mean, or +Inf when the mean is not positive
+func coefficientOfVariation(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if mean <= 0 {
+		return math.Inf(1)
+	}
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(squares/float64(len(values)-1)) / mean
+}
+
+// pointWidth returns how long the interval of p lasted: its full width
+// unless it is the last, partial one
+func pointWidth(p SeriesPoint, interval time.Duration) float64 {
+	if p.Count > 0 && p.OPS > 0 {
+		return float64(p.Count) / p.OPS
+	}
+	return interval.Seconds()
+}
+
+// SteadyState finds where the run settled in series, see SteadyState, and
+// summarizes every operation from then on, TOTAL last like in series, next
+// to its full-run row in operations. It returns nil without a TOTAL series.
+func (ot *OperationTracker) SteadyState(series []TimeSeries, operations []OperationMetrics, window int, threshold float64) *SteadyState {
+	var total *TimeSeries
+	for i := range series {
+		if series[i].Operation == "TOTAL" {
+			total = &series[i]
+		}
+	}
+	if total == nil || len(total.Points) == 0 {
+		return nil
+	}
+
+	steady := &SteadyState{Window: window, Interval: total.Interval, Threshold: threshold}
+	for _, p := range total.Points {
+		steady.Measured += pointWidth(p, total.Interval)
+	}
+	index, throughputCV, latencyCV, ok := detectSteadyState(total.Points, window, threshold)
+	if !ok {
+		return steady
+	}
+	steady.Reached = true
+	steady.Start = total.Points[index].Start
+	steady.Duration = steady.Measured - steady.Start
+	steady.ThroughputCV, steady.LatencyCV = throughputCV, latencyCV
+
+	full := make(map[string]OperationMetrics, len(operations))
+	for _, m := range operations {
+		full[m.Operation] = m
+	}
+	latencies := ot.steadyLatencies(total.Start.Add(time.Duration(steady.Start * float64(time.Second))))
+	for _, s := range series {
+		// Means come from the series on both sides, which keep latencies at
+		// full precision
+		m := SteadyStateMetrics{Operation: s.Operation}
+		var sum, fullSum float64
+		var fullCount int64
+		for i, p := range s.Points {
+			fullCount += p.Count
+			fullSum += p.Mean * float64(p.Count)
+			if i >= index {
+				m.Count += p.Count
+				sum += p.Mean * float64(p.Count)
+			}
+		}
+		if m.Count == 0 {
+			continue
+		}
+		m.OPS = float64(m.Count) / steady.Duration
+		m.Mean = sum / float64(m.Count)
+		m.FullMean = fullSum / float64(fullCount)
+		if hist, ok := latencies[s.Operation]; ok {
+			m.P50, m.P99, m.P999, m.Max = hist.ValueAtPercentile(50), hist.ValueAtPercentile(99), hist.ValueAtPercentile(99.9), hist.Max()
+		}
+		if f, ok := full[s.Operation]; ok {
+			m.FullOPS, m.FullP99 = f.OPS, f.P99
+		}
+		steady.Operations = append(steady.Operations, m)
+	}
+	return steady
+}
+
+// steadyLatencies returns histograms, in microseconds, of the retained
+// samples of every operation and of all of them under TOTAL that completed
+// at or after from, or nil when samples are not retained
+func (ot *OperationTracker) steadyLatencies(from time.Time) map[string]*hdrhistogram.Histogram {
+	if !ot.RetainsSamples() {
+		return nil
+	}
+	ot.flush()
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	cutoff := from.Sub(ot.plots.start)
+	newHist := func() *hdrhistogram.Histogram { return hdrhistogram.New(1, 24*60*60*1000*1000, 3) }
+	total := newHist()
+	result := map[string]*hdrhistogram.Histogram{"TOTAL": total}
+	for op, samples := range ot.plots.samples {
+		for _, s := range samples {
+			if s.Elapsed+s.TotalTime < cutoff {
+				continue
+			}
+			hist, ok := result[op]
+			if !ok {
+				hist = newHist()
+				result[op] = hist
+			}
+			v := max(s.TotalTime.Microseconds(), 1)
+			hist.RecordValue(v)
+			total.RecordValue(v)
+		}
+	}
+	return result
+}
+
+// PrintSteadyState prints where the run settled and every operation's
+// throughput and latency from then on next to the full run's
+func PrintSteadyState(steady *SteadyState) {
+	if steady == nil {
+		return
+	}
+	if !steady.Reached && steady.Measured <= float64(steady.Window)*steady.Interval.Seconds() {
+		fmt.Printf("\nSteady state: run too short to tell (needs more than %d intervals of %s)\n", steady.Window, steady.Interval)
+		return
+	}
+	if !steady.Reached {
+		fmt.Printf("\nSteady state: not reached (throughput and mean latency never within %g%% CV over %d intervals of %s)\n",
+			100*steady.Threshold, steady.Window, steady.Interval)
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "STEADY STATE VS FULL RUN"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("Steady from %.1fs of %.1fs measured (%.0f%% trimmed); throughput CV %.1f%%, latency CV %.1f%% over %d intervals of %s\n",
+		steady.Start, steady.Measured, 100*steady.Start/steady.Measured, 100*steady.ThroughputCV, 100*steady.LatencyCV, steady.Window, steady.Interval)
+	fmt.Println(strings.Repeat("─", tableWidth))
+	fmt.Printf("│ %-18s │ %11s │ %12s │ %12s │ %12s │ %12s │ %12s │ %12s │\n",
+		"Operation", "Count", "OPS", "Full OPS", "Mean", "Full Mean", "p99", "Full p99")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, m := range steady.Operations {
+		p99 := "-"
+		if m.P99 > 0 {
+			p99 = formatDuration(float64(m.P99))
+		}
+		fmt.Printf("│ %-18s │ %11d │ %12.2f │ %12.2f │ %12s │ %12s │ %12s │ %12s │\n",
+			m.Operation, m.Count, m.OPS, m.FullOPS, formatDuration(m.Mean), formatDuration(m.FullMean),
+			p99, formatDuration(float64(m.FullP99)))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
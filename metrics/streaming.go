@@ -0,0 +1,216 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StreamStatistics summarizes an operation in constant memory: mean and
+// standard deviation by Welford's method, quantiles by the P² estimator. They
+// are available even when the samples themselves are not retained.
+type StreamStatistics struct {
+	Operation string  `json:"operation"`
+	Count     int64   `json:"count"`
+	Mean      float64 `json:"mean_us"`
+	StdDev    float64 `json:"std_dev_us"`
+	Min       float64 `json:"min_us"`
+	Max       float64 `json:"max_us"`
+	P50       float64 `json:"p50_us"` // P² estimates
+	P90       float64 `json:"p90_us"`
+	P99       float64 `json:"p99_us"`
+}
+
+// operationStream accumulates the streaming statistics of one operation
+type operationStream struct {
+	count    int64
+	mean, m2 float64 // Welford's running mean and sum of squared deviations
+	min, max float64
+	p50      *p2Quantile
+	p90      *p2Quantile
+	p99      *p2Quantile
+}
+
+func newOperationStream() *operationStream {
+	return &operationStream{
+		min: math.Inf(1),
+		max: math.Inf(-1),
+		p50: newP2Quantile(0.50),
+		p90: newP2Quantile(0.90),
+		p99: newP2Quantile(0.99),
+	}
+}
+
+// add records one latency in microseconds
+func (s *operationStream) add(x float64) {
+	s.count++
+	delta := x - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (x - s.mean)
+	s.min = math.Min(s.min, x)
+	s.max = math.Max(s.max, x)
+	s.p50.add(x)
+	s.p90.add(x)
+	s.p99.add(x)
+}
+
+// streams keeps an operationStream per operation
+type streams map[string]*operationStream
+
+// add records latency for operation
+func (ss streams) add(operation string, latency time.Duration) {
+	s, ok := ss[operation]
+	if !ok {
+		s = newOperationStream()
+		ss[operation] = s
+	}
+	s.add(float64(latency.Nanoseconds()) / 1000)
+}
+
+// statistics returns the statistics of every operation ordered by name
+func (ss streams) statistics() []StreamStatistics {
+	operations := make([]string, 0, len(ss))
+	for operation := range ss {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	result := make([]StreamStatistics, 0, len(operations))
+	for _, operation := range operations {
+		s := ss[operation]
+		var stdDev float64
+		if s.count > 1 {
+			stdDev = math.Sqrt(s.m2 / float64(s.count-1))
+		}
+		result = append(result, StreamStatistics{
+			Operation: operation,
+			Count:     s.count,
+			Mean:      s.mean,
+			StdDev:    stdDev,
+			Min:       s.min,
+			Max:       s.max,
+			P50:       s.p50.value(),
+			P90:       s.p90.value(),
+			P99:       s.p99.value(),
+		})
+	}
+	return result
+}
+
+// p2Quantile estimates one quantile with five markers, after Jain and
+// Chlamtac, "The P² algorithm for dynamic calculation of quantiles and
+// histograms without storing observations" (1985)
+type p2Quantile struct {
+	p     float64
+	count int
+	q     [5]float64 // marker heights
+	n     [5]float64 // actual marker positions
+	np    [5]float64 // desired marker positions
+	dn    [5]float64 // desired position increments
+}
+
+func newP2Quantile(p float64) *p2Quantile {
+	return &p2Quantile{p: p, dn: [5]float64{0, p / 2, p, (1 + p) / 2, 1}}
+}
+
+// add records one observation
+func (e *p2Quantile) add(x float64) {
+	// The first five observations become the initial markers
+	if e.count < 5 {
+		e.q[e.count] = x
+		e.count++
+		if e.count == 5 {
+			sort.Float64s(e.q[:])
+			e.n = [5]float64{1, 2, 3, 4, 5}
+			e.np = [5]float64{1, 1 + 2*e.p, 1 + 4*e.p, 3 + 2*e.p, 5}
+		}
+		return
+	}
+	e.count++
+
+	// Find the cell x falls in, extending the extremes if needed
+	var k int
+	switch {
+	case x < e.q[0]:
+		e.q[0] = x
+	case x >= e.q[4]:
+		e.q[4] = x
+		k = 3
+	default:
+		for x >= e.q[k+1] {
+			k++
+		}
+	}
+	for i := k + 1; i < 5; i++ {
+		e.n[i]++
+	}
+	for i := range e.np {
+		e.np[i] += e.dn[i]
+	}
+
+	// Move the middle markers toward their desired positions
+	for i := 1; i <= 3; i++ {
+		d := e.np[i] - e.n[i]
+		if (d >= 1 && e.n[i+1]-e.n[i] > 1) || (d <= -1 && e.n[i-1]-e.n[i] < -1) {
+			step := math.Copysign(1, d)
+			q := e.parabolic(i, step)
+			if e.q[i-1] < q && q < e.q[i+1] {
+				e.q[i] = q
+			} else {
+				e.q[i] = e.linear(i, int(step))
+			}
+			e.n[i] += step
+		}
+	}
+}
+
+// parabolic is the piecewise-parabolic prediction of marker i moved by d
+func (e *p2Quantile) parabolic(i int, d float64) float64 {
+	return e.q[i] + d/(e.n[i+1]-e.n[i-1])*
+		((e.n[i]-e.n[i-1]+d)*(e.q[i+1]-e.q[i])/(e.n[i+1]-e.n[i])+
+			(e.n[i+1]-e.n[i]-d)*(e.q[i]-e.q[i-1])/(e.n[i]-e.n[i-1]))
+}
+
+// linear is the linear prediction of marker i moved by d
+func (e *p2Quantile) linear(i, d int) float64 {
+	return e.q[i] + float64(d)*(e.q[i+d]-e.q[i])/(e.n[i+d]-e.n[i])
+}
+
+// value returns the current estimate, exact while there are fewer than five
+// observations
+func (e *p2Quantile) value() float64 {
+	if e.count == 0 {
+		return 0
+	}
+	if e.count < 5 {
+		sorted := append([]float64(nil), e.q[:e.count]...)
+		sort.Float64s(sorted)
+		return percentile(sorted, 100*e.p)
+	}
+	return e.q[2]
+}
+
+// PrintStreamStatistics prints one row per operation of the streaming
+// statistics
+func PrintStreamStatistics(all []StreamStatistics) {
+	if len(all) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "STREAMING STATISTICS"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-16s │ %12s │ %10s │ %10s │ %10s │ %10s │ %10s │ %10s │ %10s │\n",
+		"Operation", "Count", "Mean", "Std. Dev.", "Min", "p50", "p90", "p99", "Max")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for _, s := range all {
+		fmt.Printf("│ %-16s │ %12d │ %10s │ %10s │ %10s │ %10s │ %10s │ %10s │ %10s │\n",
+			s.Operation, s.Count, formatDuration(s.Mean), formatDuration(s.StdDev), formatDuration(s.Min),
+			formatDuration(s.P50), formatDuration(s.P90), formatDuration(s.P99), formatDuration(s.Max))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
@@ -0,0 +1,272 @@
+package metrics
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// PlotStyle controls the size and look of every generated plot
+type PlotStyle struct {
+	Width       vg.Length
+	Height      vg.Length
+	PointRadius vg.Length     // scatter point radius
+	Palette     []color.Color // series colors, in order; reused when exhausted
+	Title       string        // title template; {op} and {chart} are replaced
+	Theme       string        // light or dark
+	LogY        bool          // draw the latency axis of scatter and line plots on a log scale
+	MaxPoints   int           // scatter points drawn per series before decimating; 0 draws every sample
+	Outliers    bool          // color mild and severe Tukey outliers in the sample times plot
+}
+
+// minLogLatency is the smallest latency in µs drawn on a log axis, which
+// cannot show zero
+const minLogLatency = 0.1
+
+// plotTheme is the colors a theme draws the plot itself in
+type plotTheme struct {
+	background color.Color
+	foreground color.Color // title, labels, axes and legend
+	grid       color.Color
+}
+
+// plotThemes are the themes a PlotStyle can use
+var plotThemes = map[string]plotTheme{
+	"light": {color.White, color.Black, color.Gray{Y: 128}},
+	"dark":  {color.RGBA{R: 30, G: 30, B: 30, A: 255}, color.RGBA{R: 220, G: 220, B: 220, A: 255}, color.Gray{Y: 80}},
+}
+
+// PlotPalettes are the named series palettes. The default palette starts with
+// steel blue, crimson and forest green; colorblind is Okabe-Ito.
+var PlotPalettes = map[string][]color.Color{
+	"default": {
+		color.RGBA{R: 70, G: 130, B: 180, A: 255}, // Steel blue
+		color.RGBA{R: 220, G: 20, B: 60, A: 255},  // Crimson
+		color.RGBA{R: 34, G: 139, B: 34, A: 255},  // Forest green
+		color.RGBA{R: 255, G: 140, B: 0, A: 255},  // Dark orange
+		color.RGBA{R: 128, G: 0, B: 128, A: 255},  // Purple
+		color.RGBA{R: 0, G: 139, B: 139, A: 255},  // Dark cyan
+	},
+	"soft": plotutil.SoftColors,
+	"bold": plotutil.DarkColors,
+	"colorblind": {
+		color.RGBA{R: 0, G: 114, B: 178, A: 255},
+		color.RGBA{R: 213, G: 94, B: 0, A: 255},
+		color.RGBA{R: 0, G: 158, B: 115, A: 255},
+		color.RGBA{R: 230, G: 159, B: 0, A: 255},
+		color.RGBA{R: 204, G: 121, B: 167, A: 255},
+		color.RGBA{R: 86, G: 180, B: 233, A: 255},
+		color.RGBA{R: 240, G: 228, B: 66, A: 255},
+	},
+}
+
+// DefaultPlotStyle is an 8x6 inch light plot in the default palette
+func DefaultPlotStyle() PlotStyle {
+	return PlotStyle{
+		Width:       8 * vg.Inch,
+		Height:      6 * vg.Inch,
+		PointRadius: vg.Points(1),
+		Palette:     PlotPalettes["default"],
+		Title:       "{op}: {chart}",
+		Theme:       "light",
+		MaxPoints:   20000,
+	}
+}
+
+// PlotThemeNames returns the names of the plot themes, sorted
+func PlotThemeNames() []string {
+	names := make([]string, 0, len(plotThemes))
+	for name := range plotThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PlotPaletteNames returns the names of the plot palettes, sorted
+func PlotPaletteNames() []string {
+	names := make([]string, 0, len(PlotPalettes))
+	for name := range PlotPalettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParsePalette returns the named palette, or the colors of a comma-separated
+// list of hex colors such as "#1f77b4,#ff7f0e"
+func ParsePalette(spec string) ([]color.Color, error) {
+	if palette, ok := PlotPalettes[spec]; ok {
+		return palette, nil
+	}
+	if !strings.HasPrefix(spec, "#") {
+		return nil, fmt.Errorf("unknown palette %q (expected one of %s, or a list of #rrggbb colors)",
+			spec, strings.Join(PlotPaletteNames(), ", "))
+	}
+
+	var palette []color.Color
+	for _, hex := range strings.Split(spec, ",") {
+		hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return nil, fmt.Errorf("invalid color %q in palette (expected #rrggbb)", hex)
+		}
+		palette = append(palette, color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255})
+	}
+	return palette, nil
+}
+
+// Validate returns an error if the style cannot be drawn
+func (s PlotStyle) Validate() error {
+	if s.Width <= 0 || s.Height <= 0 {
+		return fmt.Errorf("plot width and height must be positive")
+	}
+	if s.PointRadius <= 0 {
+		return fmt.Errorf("plot point radius must be positive")
+	}
+	if s.MaxPoints < 0 {
+		return fmt.Errorf("plot max points must not be negative")
+	}
+	if len(s.Palette) == 0 {
+		return fmt.Errorf("plot palette must have at least one color")
+	}
+	if _, ok := plotThemes[s.Theme]; !ok {
+		return fmt.Errorf("unknown plot theme %q (expected one of %s)", s.Theme, strings.Join(PlotThemeNames(), ", "))
+	}
+	return nil
+}
+
+// color returns the i-th series color
+func (s PlotStyle) color(i int) color.Color {
+	return s.Palette[i%len(s.Palette)]
+}
+
+// fill returns the i-th series color with the given opacity
+func (s PlotStyle) fill(i int, alpha uint8) color.Color {
+	r, g, b, _ := s.color(i).RGBA()
+	return color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: alpha}
+}
+
+// theme returns the colors of the style's theme, light if it is unknown
+func (s PlotStyle) theme() plotTheme {
+	if t, ok := plotThemes[s.Theme]; ok {
+		return t
+	}
+	return plotThemes["light"]
+}
+
+// newPlot creates a plot in the style's theme, titled from the template
+func (s PlotStyle) newPlot(operation, chart string) (*plot.Plot, error) {
+	p, err := plot.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plot: %w", err)
+	}
+
+	p.Title.Text = s.title(operation, chart)
+
+	t := s.theme()
+	p.BackgroundColor = t.background
+	p.Title.Color = t.foreground
+	p.Legend.Color = t.foreground
+	for _, axis := range []*plot.Axis{&p.X, &p.Y} {
+		axis.Color = t.foreground
+		axis.Label.Color = t.foreground
+		axis.Tick.Color = t.foreground
+		axis.Tick.Label.Color = t.foreground
+	}
+	return p, nil
+}
+
+// title fills in the title template
+func (s PlotStyle) title(operation, chart string) string {
+	return strings.NewReplacer("{op}", operation, "{chart}", chart).Replace(s.Title)
+}
+
+// grid returns grid lines in the style's theme
+func (s PlotStyle) grid() *plotter.Grid {
+	g := plotter.NewGrid()
+	g.Vertical.Color = s.theme().grid
+	g.Horizontal.Color = s.theme().grid
+	return g
+}
+
+// latencyAxis puts axis on a log scale when the style asks for it
+func (s PlotStyle) latencyAxis(axis *plot.Axis) {
+	if s.LogY {
+		axis.Scale = plot.LogScale{}
+		axis.Tick.Marker = plot.LogTicks{}
+	}
+}
+
+// latency returns the value drawn on a latency axis for us microseconds
+func (s PlotStyle) latency(us float64) float64 {
+	if s.LogY {
+		return math.Max(us, minLogLatency)
+	}
+	return us
+}
+
+// save writes p once per format as base.<format> and returns the files written
+func (s PlotStyle) save(p *plot.Plot, base string, formats []string) ([]string, error) {
+	var files []string
+	for _, format := range formats {
+		filename := base + "." + format
+		if err := p.Save(s.Width, s.Height, filename); err != nil {
+			return files, fmt.Errorf("failed to save plot: %w", err)
+		}
+		fmt.Printf("Generated plot: %s\n", filename)
+		files = append(files, filename)
+	}
+	return files, nil
+}
+
+// saveStacked draws plots one above the other with aligned axes in a single
+// image of the style's size, once per format as base.<format>
+func (s PlotStyle) saveStacked(plots []*plot.Plot, base string, formats []string) ([]string, error) {
+	rows := make([][]*plot.Plot, len(plots))
+	for i, p := range plots {
+		rows[i] = []*plot.Plot{p}
+	}
+	tiles := draw.Tiles{Rows: len(plots), Cols: 1, PadY: vg.Points(6)}
+
+	var files []string
+	for _, format := range formats {
+		filename := base + "." + format
+		c, err := draw.NewFormattedCanvas(s.Width, s.Height, format)
+		if err != nil {
+			return files, fmt.Errorf("failed to save plot: %w", err)
+		}
+		dc := draw.New(c)
+		dc.SetColor(s.theme().background)
+		dc.Fill(dc.Rectangle.Path())
+		canvases := plot.Align(rows, tiles, dc)
+		for i, p := range plots {
+			p.Draw(canvases[i][0])
+		}
+
+		f, err := os.Create(filename)
+		if err != nil {
+			return files, fmt.Errorf("failed to save plot: %w", err)
+		}
+		_, err = c.WriteTo(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return files, fmt.Errorf("failed to save plot: %w", err)
+		}
+		fmt.Printf("Generated plot: %s\n", filename)
+		files = append(files, filename)
+	}
+	return files, nil
+}
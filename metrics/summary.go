@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// meanErrors is the mean time of each operation with its confidence interval
+// as error bars
+type meanErrors struct {
+	plotter.XYs
+	plotter.YErrors
+}
+
+// generateSummaryPlot creates one chart of the whole run for slide decks: the
+// mean time of every operation with its bootstrap confidence interval on top,
+// and each operation's throughput below on the same operation axis
+func (bp *BenchmarkPlots) generateSummaryPlot(operations []string, outputDir string, formats []string) ([]string, error) {
+	if len(operations) == 0 {
+		return nil, nil
+	}
+
+	means := meanErrors{
+		XYs:     make(plotter.XYs, len(operations)),
+		YErrors: make(plotter.YErrors, len(operations)),
+	}
+	meanTops := make(plotter.XYs, len(operations))
+	meanLabels := make([]string, len(operations))
+	throughput := make(plotter.Values, len(operations))
+	throughputLabels := make([]string, len(operations))
+	for i, operation := range operations {
+		samples := bp.samples[operation]
+		ci := bp.bootstrap.regression().resample(samples, func(times, _ []float64) float64 {
+			var sum float64
+			for _, t := range times {
+				sum += t
+			}
+			return sum / float64(len(times))
+		})
+		means.XYs[i] = plotter.XY{X: float64(i), Y: ci.Estimate}
+		means.YErrors[i].Low = ci.Estimate - ci.LowerBound
+		means.YErrors[i].High = ci.UpperBound - ci.Estimate
+		meanTops[i] = plotter.XY{X: float64(i), Y: ci.UpperBound}
+		meanLabels[i] = formatDuration(ci.Estimate)
+
+		// Throughput over the span the operation was sampled in, counting the
+		// samples a reservoir dropped
+		first, last := samples[0], samples[len(samples)-1]
+		if span := last.Elapsed + last.TotalTime - first.Elapsed; span > 0 {
+			throughput[i] = float64(bp.sampleCounters[operation]) / span.Seconds()
+		}
+		throughputLabels[i] = formatThroughput(throughput[i])
+	}
+
+	top, err := bp.style.newPlot("All Operations", "Summary")
+	if err != nil {
+		return nil, err
+	}
+	top.Y.Label.Text = fmt.Sprintf("Mean time (µs, %s CI)", formatLevel(bp.bootstrap.Level))
+	top.Y.Min = 0
+	top.NominalX(operations...)
+
+	bars, err := plotter.NewYErrorBars(means)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create error bars: %w", err)
+	}
+	bars.LineStyle.Color = bp.style.color(0)
+	points, err := plotter.NewScatter(means)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mean points: %w", err)
+	}
+	points.GlyphStyle.Color = bp.style.color(0)
+	points.GlyphStyle.Radius = 2 * bp.style.PointRadius
+	labels, err := bp.style.valueLabels(meanTops, meanLabels)
+	if err != nil {
+		return nil, err
+	}
+	top.Add(bp.style.grid(), bars, points, labels)
+	summaryAxes(top, meanTops)
+
+	bottom, err := bp.style.newPlot("", "")
+	if err != nil {
+		return nil, err
+	}
+	bottom.Title.Text = ""
+	bottom.X.Label.Text = "Operation"
+	bottom.Y.Label.Text = "Throughput (ops/s)"
+	bottom.NominalX(operations...)
+
+	chart, err := plotter.NewBarChart(throughput, bp.style.Width/vg.Length(4*len(operations)+4))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create throughput bars: %w", err)
+	}
+	chart.Color = bp.style.color(1)
+	chart.LineStyle.Width = 0
+	tops := make(plotter.XYs, len(operations))
+	for i, v := range throughput {
+		tops[i] = plotter.XY{X: float64(i), Y: v}
+	}
+	labels, err = bp.style.valueLabels(tops, throughputLabels)
+	if err != nil {
+		return nil, err
+	}
+	bottom.Add(bp.style.grid(), chart, labels)
+	summaryAxes(bottom, tops)
+
+	base := filepath.Join(outputDir, "ALL_summary")
+	return bp.style.saveStacked([]*plot.Plot{top, bottom}, base, formats)
+}
+
+// summaryAxes pads the operation axis by half a slot on each side and leaves
+// headroom above the highest label
+func summaryAxes(p *plot.Plot, labelled plotter.XYs) {
+	p.X.Min, p.X.Max = -0.5, float64(len(labelled))-0.5
+	for _, pt := range labelled {
+		p.Y.Max = math.Max(p.Y.Max, 1.15*pt.Y)
+	}
+}
+
+// valueLabels writes text just above each point in the theme's foreground
+func (s PlotStyle) valueLabels(pts plotter.XYs, text []string) (*plotter.Labels, error) {
+	labels, err := plotter.NewLabels(plotter.XYLabels{XYs: pts, Labels: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create labels: %w", err)
+	}
+	for i := range labels.TextStyle {
+		labels.TextStyle[i].Color = s.theme().foreground
+		labels.TextStyle[i].XAlign = draw.XCenter
+	}
+	labels.YOffset = vg.Points(4)
+	return labels, nil
+}
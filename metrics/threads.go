@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+// threadPlotLegendLimit is the most threads a per-thread plot names in its
+// legend; beyond that the legend would cover the data
+const threadPlotLegendLimit = 16
+
+// ThreadStatistics summarizes the samples one YCSB thread took of one operation
+type ThreadStatistics struct {
+	Operation string  `json:"operation"`
+	Thread    int     `json:"thread"`
+	Count     int64   `json:"count"`
+	Share     float64 `json:"share"` // fraction of the operation's samples
+	Mean      float64 `json:"mean_us"`
+	P50       float64 `json:"p50_us"`
+	P99       float64 `json:"p99_us"`
+	Max       float64 `json:"max_us"`
+}
+
+// threadSamples splits the samples of an operation by thread, ordered by
+// thread ID
+func threadSamples(samples []SampleData) ([]int, map[int][]SampleData) {
+	byThread := make(map[int][]SampleData)
+	for _, s := range samples {
+		byThread[s.Thread] = append(byThread[s.Thread], s)
+	}
+	threads := make([]int, 0, len(byThread))
+	for thread := range byThread {
+		threads = append(threads, thread)
+	}
+	sort.Ints(threads)
+	return threads, byThread
+}
+
+// ThreadStatistics returns the statistics of every thread for every sampled
+// operation, ordered by operation and thread
+func (bp *BenchmarkPlots) ThreadStatistics() []ThreadStatistics {
+	bp.order()
+
+	operations := make([]string, 0, len(bp.samples))
+	for operation, samples := range bp.samples {
+		if len(samples) > 0 {
+			operations = append(operations, operation)
+		}
+	}
+	sort.Strings(operations)
+
+	var result []ThreadStatistics
+	for _, operation := range operations {
+		threads, byThread := threadSamples(bp.samples[operation])
+		for _, thread := range threads {
+			samples := byThread[thread]
+			times := make([]float64, len(samples))
+			var sum float64
+			for i, s := range samples {
+				times[i] = float64(s.TotalTime.Nanoseconds()) / 1000
+				sum += times[i]
+			}
+			sort.Float64s(times)
+			result = append(result, ThreadStatistics{
+				Operation: operation,
+				Thread:    thread,
+				Count:     int64(len(samples)),
+				Share:     float64(len(samples)) / float64(len(bp.samples[operation])),
+				Mean:      sum / float64(len(times)),
+				P50:       percentile(times, 50),
+				P99:       percentile(times, 99),
+				Max:       times[len(times)-1],
+			})
+		}
+	}
+	return result
+}
+
+// PrintThreadStatistics prints one row per operation and thread. Shares far
+// from 1/threads point at scheduler unfairness, means and tails far from the
+// other threads' at stragglers.
+func PrintThreadStatistics(all []ThreadStatistics) {
+	if len(all) == 0 {
+		return
+	}
+
+	const tableWidth = 126
+	fmt.Println("\n" + strings.Repeat("═", tableWidth))
+	title := "PER-THREAD STATISTICS"
+	fmt.Println(strings.Repeat(" ", (tableWidth-len(title))/2) + title)
+	fmt.Println(strings.Repeat("═", tableWidth))
+	fmt.Printf("│ %-16s │ %8s │ %12s │ %8s │ %14s │ %14s │ %14s │ %14s │\n",
+		"Operation", "Thread", "Count", "Share", "Mean", "p50", "p99", "Max")
+	fmt.Println(strings.Repeat("─", tableWidth))
+	for i, t := range all {
+		if i > 0 && all[i-1].Operation != t.Operation {
+			fmt.Println(strings.Repeat("─", tableWidth))
+		}
+		fmt.Printf("│ %-16s │ %8d │ %12d │ %7.2f%% │ %14s │ %14s │ %14s │ %14s │\n",
+			t.Operation, t.Thread, t.Count, 100*t.Share,
+			formatDuration(t.Mean), formatDuration(t.P50), formatDuration(t.P99), formatDuration(t.Max))
+	}
+	fmt.Println(strings.Repeat("═", tableWidth))
+}
+
+// GenerateThreadPlots creates a scatter plot per operation of every sample's
+// latency against elapsed time, one color per thread, so a thread that stalls
+// or falls behind stands out. Returns the files written, ordered by operation.
+func (bp *BenchmarkPlots) GenerateThreadPlots(outputDir string, formats []string) ([]string, error) {
+	bp.order()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	operations := make([]string, 0, len(bp.samples))
+	for operation, samples := range bp.samples {
+		if len(samples) > 0 {
+			operations = append(operations, operation)
+		}
+	}
+	sort.Strings(operations)
+
+	jobs := make([]plotJob, len(operations))
+	for i, operation := range operations {
+		jobs[i] = plotJob{"per-thread plot for " + operation, func() ([]string, error) {
+			return bp.generateThreadPlot(operation, bp.samples[operation], outputDir, formats)
+		}}
+	}
+	return drawPlots(jobs), nil
+}
+
+// generateThreadPlot creates the per-thread scatter plot of one operation
+func (bp *BenchmarkPlots) generateThreadPlot(operation string, samples []SampleData, outputDir string, formats []string) ([]string, error) {
+	threads, byThread := threadSamples(samples)
+
+	p, err := bp.style.newPlot(operation, fmt.Sprintf("Sample Times by Thread (%d threads)", len(threads)))
+	if err != nil {
+		return nil, err
+	}
+	p.X.Label.Text = "Elapsed (s)"
+	p.Y.Label.Text = "Time (µs)"
+	p.Legend.Top = true
+	bp.style.latencyAxis(&p.Y)
+
+	// Share the point budget between the threads
+	budget := 0
+	if bp.style.MaxPoints > 0 {
+		budget = max(2, bp.style.MaxPoints/len(threads))
+	}
+	for i, thread := range threads {
+		pts := make(plotter.XYs, len(byThread[thread]))
+		for j, s := range byThread[thread] {
+			pts[j].X = s.Elapsed.Seconds()
+			pts[j].Y = bp.style.latency(float64(s.TotalTime.Nanoseconds()) / 1000)
+		}
+		scatter, err := plotter.NewScatter(decimate(pts, budget))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create scatter plot: %w", err)
+		}
+		scatter.GlyphStyle.Color = bp.style.color(i)
+		scatter.GlyphStyle.Radius = bp.style.PointRadius
+		p.Add(scatter)
+		if len(threads) <= threadPlotLegendLimit {
+			p.Legend.Add(fmt.Sprintf("thread %d", thread), scatter)
+		}
+	}
+
+	p.Add(bp.style.grid())
+
+	base := filepath.Join(outputDir, operation+"_threads")
+	return bp.style.save(p, base, formats)
+}
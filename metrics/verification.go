@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verification is what checking the versioned values of a run found: reads
+// older than an acknowledged write, and writes lost once it was done
+type Verification struct {
+	Reads    int64    `json:"reads"`
+	Writes   int64    `json:"writes"`
+	Keys     int64    `json:"keys"`
+	Corrupt  int64    `json:"corrupt"`
+	Stale    int64    `json:"stale"`
+	Missing  int64    `json:"missing"`
+	Lost     int64    `json:"lost"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+// Anomalies is the number of anomalies found, of any kind
+func (v *Verification) Anomalies() int64 {
+	return v.Corrupt + v.Stale + v.Missing + v.Lost
+}
+
+// PrintVerification prints what checking a run found, if it was checked
+func PrintVerification(v *Verification) {
+	if v == nil {
+		return
+	}
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("Verification:")
+	fmt.Println(strings.Repeat("=", 80))
+
+	rows := [][2]string{
+		{"Checked", fmt.Sprintf("%d reads, %d writes, %d keys read back", v.Reads, v.Writes, v.Keys)},
+		{"Corrupt values", fmt.Sprintf("%d", v.Corrupt)},
+		{"Stale reads", fmt.Sprintf("%d", v.Stale)},
+		{"Missing reads", fmt.Sprintf("%d", v.Missing)},
+		{"Lost writes", fmt.Sprintf("%d", v.Lost)},
+	}
+	for _, row := range rows {
+		fmt.Printf("%-30s %s\n", row[0], row[1])
+	}
+	if len(v.Examples) > 0 {
+		fmt.Println("\nFirst anomalies:")
+		for _, e := range v.Examples {
+			fmt.Printf("  %s\n", e)
+		}
+	}
+}
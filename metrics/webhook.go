@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook payload formats
+const (
+	WebhookJSON  = "json"  // the event itself
+	WebhookSlack = "slack" // a Slack incoming-webhook message, which Mattermost and Discord's /slack endpoint also accept
+)
+
+// Webhook event statuses
+const (
+	WebhookCompleted = "completed"
+	WebhookFailed    = "failed"
+)
+
+// ValidateWebhookFormat returns an error if format is not a webhook payload
+// format
+func ValidateWebhookFormat(format string) error {
+	switch format {
+	case WebhookJSON, WebhookSlack:
+		return nil
+	}
+	return fmt.Errorf("unknown webhook format %q (expected %s or %s)", format, WebhookJSON, WebhookSlack)
+}
+
+// WebhookEvent is the summary a webhook receives when a run completes or a
+// command fails
+type WebhookEvent struct {
+	Status     string             `json:"status"`
+	Command    string             `json:"command"`
+	Run        string             `json:"run,omitempty"`
+	ID         string             `json:"id,omitempty"`
+	DB         string             `json:"db,omitempty"`
+	Workload   string             `json:"workload,omitempty"`
+	Host       string             `json:"host,omitempty"`
+	Start      *time.Time         `json:"start,omitempty"`
+	End        time.Time          `json:"end"`
+	Error      string             `json:"error,omitempty"`
+	Operations []OperationMetrics `json:"operations,omitempty"`
+}
+
+// CompletedEvent summarizes run, which command ran from start to end
+func CompletedEvent(command string, run Results, start, end time.Time) WebhookEvent {
+	return WebhookEvent{
+		Status:     WebhookCompleted,
+		Command:    command,
+		Run:        run.Name,
+		ID:         run.ID,
+		DB:         run.DB,
+		Workload:   workloadOf(run),
+		Host:       run.Environment.Hostname,
+		Start:      &start,
+		End:        end,
+		Operations: run.Operations,
+	}
+}
+
+// FailedEvent reports that command failed with err on host
+func FailedEvent(command, host string, err error) WebhookEvent {
+	return WebhookEvent{
+		Status:  WebhookFailed,
+		Command: command,
+		Host:    host,
+		End:     time.Now(),
+		Error:   err.Error(),
+	}
+}
+
+// Webhook posts events to an HTTP endpoint
+type Webhook struct {
+	URL    string
+	Format string // one of the Webhook* formats
+	Client *http.Client
+}
+
+// NewWebhook creates a webhook posting events in format to url
+func NewWebhook(url, format string) *Webhook {
+	return &Webhook{URL: url, Format: format, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify posts event
+func (w *Webhook) Notify(ctx context.Context, event WebhookEvent) error {
+	var payload any = event
+	if w.Format == WebhookSlack {
+		payload = map[string]string{"text": SlackMessage(event)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to post to %s: %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// SlackMessage renders event as Slack mrkdwn: a status line and, for a
+// completed run, its operations in a code block
+func SlackMessage(event WebhookEvent) string {
+	var b strings.Builder
+	if event.Status == WebhookFailed {
+		fmt.Fprintf(&b, ":x: *godb-bench %s* failed", event.Command)
+		if event.Host != "" {
+			fmt.Fprintf(&b, " on %s", event.Host)
+		}
+		fmt.Fprintf(&b, ": %s", event.Error)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, ":white_check_mark: *godb-bench %s* %s completed on %s", event.Command, event.Run, event.DB)
+	if event.Workload != "" {
+		fmt.Fprintf(&b, " (%s)", event.Workload)
+	}
+	if event.Host != "" {
+		fmt.Fprintf(&b, " on %s", event.Host)
+	}
+	if event.Start != nil {
+		elapsed := event.End.Sub(*event.Start)
+		if elapsed >= time.Second {
+			elapsed = elapsed.Round(time.Second)
+		} else {
+			elapsed = elapsed.Round(time.Millisecond)
+		}
+		fmt.Fprintf(&b, " in %s", elapsed)
+	}
+	if len(event.Operations) > 0 {
+		b.WriteString("\n```\n")
+		fmt.Fprintf(&b, "%-12s %10s %12s %10s %10s %8s\n", "Operation", "Count", "OPS", "Avg(us)", "P99(us)", "Errors")
+		for _, m := range event.Operations {
+			fmt.Fprintf(&b, "%-12s %10d %12.2f %10d %10d %8d\n", m.Operation, m.Count, m.OPS, m.Avg, m.P99, m.Errors)
+		}
+		b.WriteString("```")
+	}
+	return b.String()
+}
@@ -0,0 +1,22 @@
+// Package perf counts the CPU's hardware events, such as cycles and cache
+// misses, across every thread of the process with perf_event_open(2). It is
+// only available on Linux, where perf_event_paranoid and, in virtual
+// machines, the hypervisor decide which events can be counted.
+package perf
+
+// Events counted, by their perf(1) names
+const (
+	Cycles          = "cycles"
+	Instructions    = "instructions"
+	CacheReferences = "cache-references"
+	CacheMisses     = "cache-misses"
+	Branches        = "branches"
+	BranchMisses    = "branch-misses"
+)
+
+// Values are the counts of the events that could be counted, scaled up when
+// the kernel had to multiplex them onto fewer hardware counters
+type Values struct {
+	Counts   map[string]int64
+	UserOnly bool // kernel time is excluded, as perf_event_paranoid requires
+}
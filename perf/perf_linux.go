@@ -0,0 +1,134 @@
+package perf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// hardwareEvents maps the events to their perf_event_open configs
+var hardwareEvents = []struct {
+	name   string
+	config uint64
+}{
+	{Cycles, unix.PERF_COUNT_HW_CPU_CYCLES},
+	{Instructions, unix.PERF_COUNT_HW_INSTRUCTIONS},
+	{CacheReferences, unix.PERF_COUNT_HW_CACHE_REFERENCES},
+	{CacheMisses, unix.PERF_COUNT_HW_CACHE_MISSES},
+	{Branches, unix.PERF_COUNT_HW_BRANCH_INSTRUCTIONS},
+	{BranchMisses, unix.PERF_COUNT_HW_BRANCH_MISSES},
+}
+
+// Counters counts hardware events from Start until Stop
+type Counters struct {
+	fds      map[string][]int // per event, one per thread
+	userOnly bool
+}
+
+// Start counts every event the CPU and the kernel allow on every thread of
+// the process, including those it starts later. It fails only if no event
+// can be counted at all.
+func Start() (*Counters, error) {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return nil, err
+	}
+	var tids []int
+	for _, t := range tasks {
+		if tid, err := strconv.Atoi(t.Name()); err == nil {
+			tids = append(tids, tid)
+		}
+	}
+
+	c := &Counters{fds: make(map[string][]int)}
+	var firstErr error
+	for _, e := range hardwareEvents {
+		fds, err := c.open(e.config, tids)
+		if errors.Is(err, unix.EACCES) && !c.userOnly {
+			// perf_event_paranoid above 1 only allows counting user space
+			c.userOnly = true
+			fds, err = c.open(e.config, tids)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", e.name, err)
+			}
+			continue
+		}
+		c.fds[e.name] = fds
+	}
+	if len(c.fds) == 0 {
+		if errors.Is(firstErr, unix.ENOENT) {
+			return nil, fmt.Errorf("the CPU exposes no hardware events, as in virtual machines without a virtual PMU: %w", firstErr)
+		}
+		return nil, fmt.Errorf("no hardware event can be counted: %w", firstErr)
+	}
+	return c, nil
+}
+
+// open counts the event on each thread, closing what it opened on failure.
+// Threads that exit in between are skipped.
+func (c *Counters) open(config uint64, tids []int) ([]int, error) {
+	attr := unix.PerfEventAttr{
+		Type:        unix.PERF_TYPE_HARDWARE,
+		Size:        uint32(unsafe.Sizeof(unix.PerfEventAttr{})),
+		Config:      config,
+		Read_format: unix.PERF_FORMAT_TOTAL_TIME_ENABLED | unix.PERF_FORMAT_TOTAL_TIME_RUNNING,
+		Bits:        unix.PerfBitInherit | unix.PerfBitExcludeHv,
+	}
+	if c.userOnly {
+		attr.Bits |= unix.PerfBitExcludeKernel
+	}
+	var fds []int
+	for _, tid := range tids {
+		fd, err := unix.PerfEventOpen(&attr, tid, -1, -1, unix.PERF_FLAG_FD_CLOEXEC)
+		if errors.Is(err, unix.ESRCH) {
+			continue
+		}
+		if err != nil {
+			for _, fd := range fds {
+				unix.Close(fd)
+			}
+			return nil, err
+		}
+		fds = append(fds, fd)
+	}
+	return fds, nil
+}
+
+// Stop reads the counts of every event, summed over the threads, and stops
+// counting
+func (c *Counters) Stop() (Values, error) {
+	v := Values{Counts: make(map[string]int64), UserOnly: c.userOnly}
+	var firstErr error
+	buf := make([]byte, 24) // value, time enabled, time running
+	for name, fds := range c.fds {
+		var total float64
+		for _, fd := range fds {
+			n, err := unix.Read(fd, buf)
+			unix.Close(fd)
+			if err == nil && n != len(buf) {
+				err = fmt.Errorf("short read of %d bytes", n)
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s: %w", name, err)
+				}
+				continue
+			}
+			value := binary.NativeEndian.Uint64(buf[0:])
+			enabled := binary.NativeEndian.Uint64(buf[8:])
+			running := binary.NativeEndian.Uint64(buf[16:])
+			if running > 0 {
+				total += float64(value) * float64(enabled) / float64(running)
+			}
+		}
+		v.Counts[name] = int64(total)
+	}
+	return v, firstErr
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package perf
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Counters counts hardware events; Linux only
+type Counters struct{}
+
+// Start is not supported outside Linux
+func Start() (*Counters, error) {
+	return nil, fmt.Errorf("hardware counters need Linux, not available on %s", runtime.GOOS)
+}
+
+// Stop is not supported outside Linux
+func (c *Counters) Stop() (Values, error) {
+	return Values{}, fmt.Errorf("hardware counters need Linux")
+}
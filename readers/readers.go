@@ -0,0 +1,189 @@
+// Package readers measures how concurrent read-only transactions and a
+// single writer interfere: read throughput and commit latency as readers are
+// added
+package readers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// field is the single field values are read from
+const field = "field0"
+
+// loadChunk is how many keys every commit of the load writes
+const loadChunk = 1000
+
+// Config describes a reader scaling run
+type Config struct {
+	DB            string        // name of the backend, for the results
+	Keys          int64         // loaded first, then read and overwritten at random
+	KeysPerRead   int           // read by every read transaction
+	KeysPerCommit int           // written by every commit of the writer
+	Readers       []int         // concurrent readers of every step
+	Step          time.Duration // how long every step runs
+	Seed          int64         // of the keys and values drawn
+	Log           io.Writer     // receives progress messages; nil discards them
+}
+
+// key returns the name of key i
+func key(i int64) string {
+	return "user" + strconv.FormatInt(i, 10)
+}
+
+// Run loads cfg.Keys keys into db, then runs a step per reader count of
+// cfg.Readers: one writer commits cfg.KeysPerCommit random keys at a time
+// without pause while that many readers each read cfg.KeysPerRead random
+// keys per read-only transaction. A read transaction is a BatchRead and a
+// commit a WriteBlock. Cancelling ctx ends the run early with the steps
+// completed so far.
+func Run(ctx context.Context, db ycsb.DB, cfg Config) (*metrics.ReaderScaling, error) {
+	batch, ok := db.(ycsb.BatchDB)
+	if !ok {
+		return nil, fmt.Errorf("%s has no batch reads", cfg.DB)
+	}
+	writer, ok := db.(godbdb.BlockWriter)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot commit a set of writes atomically", cfg.DB)
+	}
+	log := cfg.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	result := &metrics.ReaderScaling{DB: cfg.DB, Keys: cfg.Keys, KeysPerRead: cfg.KeysPerRead, KeysPerCommit: cfg.KeysPerCommit}
+	fmt.Fprintf(log, "Loading %d keys...\n", cfg.Keys)
+	if err := load(ctx, writer, cfg); err != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintln(log, "Reader scaling cancelled")
+			return result, nil
+		}
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	for i, readers := range cfg.Readers {
+		fmt.Fprintf(log, "Running 1 writer and %d readers for %s...\n", readers, cfg.Step)
+		step, err := runStep(ctx, batch, writer, cfg, readers, cfg.Seed+int64(i))
+		if ctx.Err() != nil {
+			fmt.Fprintln(log, "Reader scaling cancelled")
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%d readers: %w", readers, err)
+		}
+		result.Steps = append(result.Steps, step)
+	}
+	return result, nil
+}
+
+// load writes every key, loadChunk keys per commit
+func load(ctx context.Context, writer godbdb.BlockWriter, cfg Config) error {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	for start := int64(0); start < cfg.Keys; start += loadChunk {
+		n := min(loadChunk, cfg.Keys-start)
+		keys := make([]string, n)
+		values := make([][]byte, n)
+		for i := range keys {
+			keys[i] = key(start + int64(i))
+			values[i] = value(rng)
+		}
+		if err := writer.WriteBlock(ctx, keys, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value returns a random value of a storage slot
+func value(rng *rand.Rand) []byte {
+	v := make([]byte, godbdb.SlotSize)
+	rng.Read(v)
+	return v
+}
+
+// runStep runs the writer and readers readers for cfg.Step
+func runStep(ctx context.Context, batch ycsb.BatchDB, writer godbdb.BlockWriter, cfg Config, readers int, seed int64) (metrics.ReaderStep, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Step)
+	defer cancel()
+
+	var mu sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	// Every goroutine records into a histogram of its own, merged at the end
+	commits := hdrhistogram.New(1, int64(time.Hour), 3)
+	reads := make([]*hdrhistogram.Histogram, readers)
+	start := time.Now()
+	var wg sync.WaitGroup
+	wg.Add(1 + readers)
+	go func() {
+		defer wg.Done()
+		rng := rand.New(rand.NewSource(seed))
+		keys := make([]string, cfg.KeysPerCommit)
+		values := make([][]byte, cfg.KeysPerCommit)
+		for ctx.Err() == nil {
+			for i := range keys {
+				keys[i] = key(rng.Int63n(cfg.Keys))
+				values[i] = value(rng)
+			}
+			t := time.Now()
+			if err := writer.WriteBlock(ctx, keys, values); err != nil {
+				if ctx.Err() == nil {
+					fail(fmt.Errorf("commit: %w", err))
+				}
+				return
+			}
+			commits.RecordValue(max(int64(time.Since(t)), 1))
+		}
+	}()
+	for r := range reads {
+		reads[r] = hdrhistogram.New(1, int64(time.Hour), 3)
+		go func(hist *hdrhistogram.Histogram, rng *rand.Rand) {
+			defer wg.Done()
+			keys := make([]string, cfg.KeysPerRead)
+			for ctx.Err() == nil {
+				for i := range keys {
+					keys[i] = key(rng.Int63n(cfg.Keys))
+				}
+				t := time.Now()
+				if _, err := batch.BatchRead(ctx, prop.TableNameDefault, keys, []string{field}); err != nil {
+					if ctx.Err() == nil {
+						fail(fmt.Errorf("read: %w", err))
+					}
+					return
+				}
+				hist.RecordValue(max(int64(time.Since(t)), 1))
+			}
+		}(reads[r], rand.New(rand.NewSource(seed+int64(r)+1)))
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if firstErr != nil {
+		return metrics.ReaderStep{}, firstErr
+	}
+	merged := hdrhistogram.New(1, int64(time.Hour), 3)
+	for _, hist := range reads {
+		merged.Merge(hist)
+	}
+	return metrics.NewReaderStep(readers, cfg.KeysPerRead, merged, commits, elapsed), nil
+}
@@ -0,0 +1,155 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+	"github.com/jihwankim/polygon-benchmarks/godb-bench/metrics"
+)
+
+// field is the single field values are written to on backends committing
+// through the YCSB interface
+const field = "field0"
+
+// Config describes a replay
+type Config struct {
+	Trace        string        // path of the trace file
+	DB           string        // name of the backend, for the results
+	Blocks       int64         // blocks measured after the warm-up; 0 replays the rest of the trace
+	WarmUpBlocks int64         // blocks committed first and left out of the results
+	Progress     time.Duration // how often progress is logged; 0 disables it
+	Log          io.Writer     // receives progress messages; nil discards them
+}
+
+// Run replays the blocks of the trace against db, committing each block
+// before reading the next, and summarizes the commits of the measured ones.
+// Cancelling ctx ends the replay early with the blocks committed so far.
+func Run(ctx context.Context, db ycsb.DB, cfg Config) (*metrics.BlockReplay, error) {
+	log := cfg.Log
+	if log == nil {
+		log = io.Discard
+	}
+	trace, err := OpenTrace(cfg.Trace)
+	if err != nil {
+		return nil, err
+	}
+	defer trace.Close()
+
+	commit := committer(db, log)
+	var samples []metrics.BlockSample
+	var warmedUp int64
+	var start time.Time
+	lastProgress := time.Now()
+	for cfg.Blocks == 0 || int64(len(samples)) < cfg.Blocks {
+		if ctx.Err() != nil {
+			fmt.Fprintln(log, "Replay cancelled")
+			break
+		}
+		block, err := trace.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		t := time.Now()
+		if err := commit(ctx, block); err != nil {
+			return nil, fmt.Errorf("block %d: %w", block.Number, err)
+		}
+		latency := time.Since(t)
+
+		if warmedUp < cfg.WarmUpBlocks {
+			warmedUp++
+			if warmedUp == cfg.WarmUpBlocks {
+				fmt.Fprintf(log, "Warm-up finished after block %d\n", block.Number)
+			}
+			continue
+		}
+		if start.IsZero() {
+			start = t
+		}
+		samples = append(samples, metrics.BlockSample{
+			Number:  block.Number,
+			Writes:  len(block.Keys),
+			Deletes: block.Deletes(),
+			Gas:     block.GasUsed,
+			Latency: latency,
+		})
+
+		if cfg.Progress > 0 && time.Since(lastProgress) >= cfg.Progress {
+			lastProgress = time.Now()
+			fmt.Fprintf(log, "%s: replayed %d blocks, at block %d\n",
+				time.Since(start).Round(time.Second), len(samples), block.Number)
+		}
+	}
+
+	var elapsed time.Duration
+	if !start.IsZero() {
+		elapsed = time.Since(start)
+	}
+	if warmedUp < cfg.WarmUpBlocks {
+		fmt.Fprintf(log, "Warning: the trace ended during the warm-up, after %d blocks\n", warmedUp)
+	}
+	return metrics.NewBlockReplay(cfg.Trace, cfg.DB, warmedUp, samples, elapsed), nil
+}
+
+// committer returns how a block is committed to db: atomically by backends
+// that can, otherwise its writes in one batch and its deletes in another, or
+// one key at a time for backends without batches
+func committer(db ycsb.DB, log io.Writer) func(ctx context.Context, b *Block) error {
+	if w, ok := db.(godbdb.BlockWriter); ok {
+		return func(ctx context.Context, b *Block) error {
+			return w.WriteBlock(ctx, b.Keys, b.Values)
+		}
+	}
+
+	table := prop.TableNameDefault
+	if batch, ok := db.(ycsb.BatchDB); ok {
+		fmt.Fprintln(log, "Warning: the backend cannot commit a block atomically: committing its writes and deletes as two batches")
+		return func(ctx context.Context, b *Block) error {
+			var keys, deletes []string
+			var values []map[string][]byte
+			for i, key := range b.Keys {
+				if b.Values[i] == nil {
+					deletes = append(deletes, key)
+				} else {
+					keys = append(keys, key)
+					values = append(values, map[string][]byte{field: b.Values[i]})
+				}
+			}
+			if len(keys) > 0 {
+				if err := batch.BatchInsert(ctx, table, keys, values); err != nil {
+					return err
+				}
+			}
+			if len(deletes) > 0 {
+				return batch.BatchDelete(ctx, table, deletes)
+			}
+			return nil
+		}
+	}
+
+	fmt.Fprintln(log, "Warning: the backend has no batches: committing each block one key at a time")
+	return func(ctx context.Context, b *Block) error {
+		for i, key := range b.Keys {
+			var err error
+			if b.Values[i] == nil {
+				err = db.Delete(ctx, table, key)
+			} else {
+				err = db.Insert(ctx, table, key, map[string][]byte{field: b.Values[i]})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,148 @@
+// Package replay commits the state writes of real blocks, exported to a
+// trace file, against a backend one block at a time, as a node importing the
+// chain would, and measures every block's commit
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxLine bounds a block's line in a trace; large blocks write a few MB
+const maxLine = 256 << 20
+
+// Block is the state a block wrote
+type Block struct {
+	Number  uint64
+	GasUsed uint64
+	Keys    []string
+	Values  [][]byte // nil deletes the key
+}
+
+// Deletes returns the number of keys the block deletes
+func (b *Block) Deletes() int {
+	n := 0
+	for _, v := range b.Values {
+		if v == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// traceBlock is a line of a trace file
+type traceBlock struct {
+	Number  uint64       `json:"number"`
+	GasUsed uint64       `json:"gas_used"`
+	Writes  []traceWrite `json:"writes"`
+}
+
+// traceWrite is one state write of a traceBlock. Keys and values are hex
+// with an optional 0x prefix; a missing or null value deletes the key.
+type traceWrite struct {
+	Key   string  `json:"key"`
+	Value *string `json:"value"`
+}
+
+// Trace reads the blocks of a trace file: one JSON object per line, in the
+// order the blocks are committed, optionally gzipped
+//
+//	{"number":19000000,"gas_used":14932207,"writes":[{"key":"0x01..","value":"0x2a"},{"key":"0x02.."}]}
+type Trace struct {
+	path    string
+	file    *os.File
+	gz      *gzip.Reader
+	scanner *bufio.Scanner
+	line    int
+}
+
+// OpenTrace opens the trace file at path. Gzipped traces are recognized by
+// their header, whatever their name.
+func OpenTrace(path string) (*Trace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace: %w", err)
+	}
+	t := &Trace{path: path, file: f}
+
+	r := bufio.NewReader(f)
+	var in io.Reader = r
+	if magic, err := r.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		if t.gz, err = gzip.NewReader(r); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read gzipped trace %s: %w", path, err)
+		}
+		in = t.gz
+	}
+	t.scanner = bufio.NewScanner(in)
+	t.scanner.Buffer(make([]byte, 64<<10), maxLine)
+	return t, nil
+}
+
+// Next returns the next block of the trace, or io.EOF after the last
+func (t *Trace) Next() (*Block, error) {
+	for t.scanner.Scan() {
+		t.line++
+		line := bytes.TrimSpace(t.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var tb traceBlock
+		if err := json.Unmarshal(line, &tb); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", t.path, t.line, err)
+		}
+		b := &Block{
+			Number:  tb.Number,
+			GasUsed: tb.GasUsed,
+			Keys:    make([]string, len(tb.Writes)),
+			Values:  make([][]byte, len(tb.Writes)),
+		}
+		for i, w := range tb.Writes {
+			key, err := decodeHex(w.Key)
+			if err != nil || len(key) == 0 {
+				return nil, fmt.Errorf("%s:%d: invalid key %q of block %d", t.path, t.line, w.Key, tb.Number)
+			}
+			b.Keys[i] = string(key)
+			if w.Value == nil {
+				continue
+			}
+			if b.Values[i], err = decodeHex(*w.Value); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid value %q of block %d", t.path, t.line, *w.Value, tb.Number)
+			}
+		}
+		return b, nil
+	}
+	if err := t.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%d: %w", t.path, t.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// Close closes the trace file
+func (t *Trace) Close() error {
+	if t.gz != nil {
+		t.gz.Close()
+	}
+	return t.file.Close()
+}
+
+// decodeHex decodes s, with or without a 0x prefix. An empty value is
+// returned as an empty, not nil, slice so it is written rather than deleted.
+func decodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if b == nil && err == nil {
+		b = []byte{}
+	}
+	return b, err
+}
@@ -0,0 +1,1031 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package workload is a fork of go-ycsb's core workload (pkg/workload/core.go
+// at v1.0.1), registered under the same names. It is kept in-tree so the
+// operation stream can be controlled in ways upstream does not expose, such as
+// seeding the per-thread random generators.
+package workload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+)
+
+type contextKey string
+
+const stateKey = contextKey("core")
+
+// SeedProperty seeds the per-thread random generators so two runs with the
+// same seed (and thread count) issue the same operation stream. Without it,
+// each thread is seeded from the current time.
+const SeedProperty = "seed"
+
+// TxnSizeProperty groups this many consecutive transaction operations into
+// one batch or transaction at the DB, committed once all are issued, as a
+// block groups its writes. 1, the default, issues each on its own.
+const TxnSizeProperty = "txnsize"
+
+// CompressionRatioProperty makes random values compress to about 1/ratio of
+// their size: each is a run of random bytes repeated to fill it, 1 being
+// incompressible. Unset, values are random letters, which compress little.
+const CompressionRatioProperty = "compressionratio"
+
+// InsertOrderMonotonic is an insertorder under which keys are numbered in
+// insert order and zero-padded to the width of any int64, so every inserted
+// key sorts after the ones before it, as block numbers and log indices do.
+// go-ycsb's "ordered" keys are not padded, so "user10" sorts before "user9".
+const InsertOrderMonotonic = "monotonic"
+
+// monotonicPadding is the digits of the largest int64
+const monotonicPadding = 19
+
+// HotSetKeysProperty and HotSetFractionProperty send that fraction of the
+// operations on existing keys to a hot set of that many keys, uniformly, and
+// the others to requestdistribution over every key, as a few contracts take
+// most of the state accesses of a chain
+const (
+	HotSetKeysProperty     = "hotset.keys"
+	HotSetFractionProperty = "hotset.fraction"
+)
+
+// PruneFractionProperty makes a load (dotransactions=false) delete that
+// fraction of the recordcount keys instead of inserting, from the first key
+// on, as pruning old state does; the runner sets insertcount to match. Runs
+// with it set go to the surviving keys only, from insertstart on, which the
+// runner sets to the first of them.
+const PruneFractionProperty = "prune.fraction"
+
+// PrefixScanProportionProperty is the share of the operation mix that scans
+// every key under one prefix, chosen by requestdistribution like the keys of
+// other operations. It needs PrefixCountProperty.
+const PrefixScanProportionProperty = "prefixscanproportion"
+
+// PrefixCountProperty and PrefixKeysProperty lay keys out under that many
+// shared prefixes, as storage slots are under accounts: key number n is slot
+// n/count under prefix n%count, so loads and inserts grow every prefix alike.
+// When keys per prefix are given, the runner sets recordcount to count*keys.
+const (
+	PrefixCountProperty = "prefix.count"
+	PrefixKeysProperty  = "prefix.keys"
+)
+
+// PartialReadProportionProperty is the share of the operation mix that reads
+// PartialReadLengthProperty bytes (default 4096) of one field of a record, at
+// a random offset within fieldlength, as reading a slice of a large blob
+// does. Backends that cannot read part of a value read all of it.
+const (
+	PartialReadProportionProperty = "partialreadproportion"
+	PartialReadLengthProperty     = "partialread.length"
+)
+
+type coreState struct {
+	r *rand.Rand
+	// fieldNames is a copy of core.fieldNames to be goroutine-local
+	fieldNames []string
+}
+
+type operationType int64
+
+const (
+	read operationType = iota + 1
+	update
+	insert
+	scan
+	readModifyWrite
+	prefixScan
+	partialRead
+)
+
+// Core is the core benchmark scenario. Represents a set of clients doing simple CRUD operations.
+type core struct {
+	p *properties.Properties
+
+	table      string
+	tables     []string // the first being table, see godbdb.TableCountProperty
+	fieldCount int64
+	fieldNames []string
+
+	fieldLengthGenerator ycsb.Generator
+	readAllFields        bool
+	writeAllFields       bool
+	dataIntegrity        bool
+
+	keySequence                  ycsb.Generator
+	operationChooser             *generator.Discrete
+	keyChooser                   ycsb.Generator
+	fieldChooser                 ycsb.Generator
+	transactionInsertKeySequence *generator.AcknowledgedCounter
+	scanLength                   ycsb.Generator
+	orderedInserts               bool
+	recordCount                  int64
+	zeroPadding                  int64
+	insertionRetryLimit          int64
+	insertionRetryInterval       int64
+	seed                         int64
+	seeded                       bool
+	txnSize                      int64
+	compressionRatio             float64 // 0 when unset
+	hotStart                     int64   // the hot set is hotKeys key numbers from hotStart
+	hotKeys                      int64
+	hotFraction                  float64
+	prune                        bool  // loads delete keys rather than insert them
+	prefixCount                  int64 // 0 unless keys are laid out under prefixes
+	fieldLength                  int64
+	partialReadLength            int64
+	verifier                     *verifier // nil unless VerifyProperty is set
+
+	valuePool sync.Pool
+}
+
+func getFieldLengthGenerator(p *properties.Properties) ycsb.Generator {
+	var fieldLengthGenerator ycsb.Generator
+	fieldLengthDistribution := p.GetString(prop.FieldLengthDistribution, prop.FieldLengthDistributionDefault)
+	fieldLength := p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+	fieldLengthHistogram := p.GetString(prop.FieldLengthHistogramFile, prop.FieldLengthHistogramFileDefault)
+
+	switch strings.ToLower(fieldLengthDistribution) {
+	case "constant":
+		fieldLengthGenerator = generator.NewConstant(fieldLength)
+	case "uniform":
+		fieldLengthGenerator = generator.NewUniform(1, fieldLength)
+	case "zipfian":
+		fieldLengthGenerator = generator.NewZipfianWithRange(1, fieldLength, generator.ZipfianConstant)
+	case "histogram":
+		fieldLengthGenerator = generator.NewHistogramFromFile(fieldLengthHistogram)
+	default:
+		util.Fatalf("unknown field length distribution %s", fieldLengthDistribution)
+	}
+
+	return fieldLengthGenerator
+}
+
+func createOperationGenerator(p *properties.Properties) *generator.Discrete {
+	readProportion := p.GetFloat64(prop.ReadProportion, prop.ReadProportionDefault)
+	updateProportion := p.GetFloat64(prop.UpdateProportion, prop.UpdateProportionDefault)
+	insertProportion := p.GetFloat64(prop.InsertProportion, prop.InsertProportionDefault)
+	scanProportion := p.GetFloat64(prop.ScanProportion, prop.ScanProportionDefault)
+	readModifyWriteProportion := p.GetFloat64(prop.ReadModifyWriteProportion, prop.ReadModifyWriteProportionDefault)
+	prefixScanProportion := p.GetFloat64(PrefixScanProportionProperty, 0)
+	partialReadProportion := p.GetFloat64(PartialReadProportionProperty, 0)
+
+	operationChooser := generator.NewDiscrete()
+	if readProportion > 0 {
+		operationChooser.Add(readProportion, int64(read))
+	}
+
+	if updateProportion > 0 {
+		operationChooser.Add(updateProportion, int64(update))
+	}
+
+	if insertProportion > 0 {
+		operationChooser.Add(insertProportion, int64(insert))
+	}
+
+	if scanProportion > 0 {
+		operationChooser.Add(scanProportion, int64(scan))
+	}
+
+	if readModifyWriteProportion > 0 {
+		operationChooser.Add(readModifyWriteProportion, int64(readModifyWrite))
+	}
+
+	if prefixScanProportion > 0 {
+		operationChooser.Add(prefixScanProportion, int64(prefixScan))
+	}
+
+	if partialReadProportion > 0 {
+		operationChooser.Add(partialReadProportion, int64(partialRead))
+	}
+
+	return operationChooser
+}
+
+// Load implements the Workload Load interface.
+func (c *core) Load(ctx context.Context, db ycsb.DB, totalCount int64) error {
+	return nil
+}
+
+// InitThread implements the Workload InitThread interface.
+func (c *core) InitThread(ctx context.Context, threadID int, _ int) context.Context {
+	seed := time.Now().UnixNano()
+	if c.seeded {
+		// Offset by thread so threads draw different, but reproducible, streams
+		seed = c.seed + int64(threadID)
+	}
+	r := rand.New(rand.NewSource(seed))
+	fieldNames := make([]string, len(c.fieldNames))
+	copy(fieldNames, c.fieldNames)
+	state := &coreState{
+		r:          r,
+		fieldNames: fieldNames,
+	}
+	return context.WithValue(ctx, stateKey, state)
+}
+
+// CleanupThread implements the Workload CleanupThread interface.
+func (c *core) CleanupThread(_ context.Context) {
+
+}
+
+// Close implements the Workload Close interface.
+func (c *core) Close() error {
+	return nil
+}
+
+// HotKeys returns the keys of the hot set of the core workload w, or nil if
+// it has none or w is another workload
+func HotKeys(w ycsb.Workload) []string {
+	c, ok := w.(*core)
+	if !ok || c.hotKeys == 0 || c.hotFraction == 0 {
+		return nil
+	}
+	keys := make([]string, c.hotKeys)
+	for i := range keys {
+		keys[i] = c.buildKeyName(c.hotStart + int64(i))
+	}
+	return keys
+}
+
+func (c *core) buildKeyName(keyNum int64) string {
+	if c.prefixCount > 0 {
+		return c.buildKeyPrefix(keyNum) + c.formatKeyNum(keyNum/c.prefixCount)
+	}
+
+	prefix := c.p.GetString(prop.KeyPrefix, prop.KeyPrefixDefault)
+	return prefix + c.formatKeyNum(keyNum)
+}
+
+// buildKeyPrefix returns the prefix the key of keyNum shares with the other
+// keys under it, when keys are laid out under prefixes
+func (c *core) buildKeyPrefix(keyNum int64) string {
+	prefix := c.p.GetString(prop.KeyPrefix, prop.KeyPrefixDefault)
+	return prefix + c.formatKeyNum(keyNum%c.prefixCount) + "/"
+}
+
+// tableOf returns the table holding the key of keyNum. Keys under one prefix
+// share a table, as the storage of an account does.
+func (c *core) tableOf(keyNum int64) string {
+	if len(c.tables) == 1 {
+		return c.table
+	}
+	if c.prefixCount > 0 {
+		keyNum %= c.prefixCount
+	}
+	return c.tables[uint64(util.Hash64(keyNum))%uint64(len(c.tables))]
+}
+
+// formatKeyNum hashes keyNum unless inserts are ordered, and pads it
+func (c *core) formatKeyNum(keyNum int64) string {
+	if !c.orderedInserts {
+		keyNum = util.Hash64(keyNum)
+	}
+	return fmt.Sprintf("%0[2]*[1]d", keyNum, c.zeroPadding)
+}
+
+func (c *core) buildSingleValue(state *coreState, key string) map[string][]byte {
+	values := make(map[string][]byte, 1)
+
+	r := state.r
+	fieldKey := state.fieldNames[c.fieldChooser.Next(r)]
+
+	var buf []byte
+	if c.dataIntegrity {
+		buf = c.buildDeterministicValue(state, key, fieldKey)
+	} else {
+		buf = c.buildRandomValue(state)
+	}
+
+	values[fieldKey] = buf
+
+	return values
+}
+
+func (c *core) buildValues(state *coreState, key string) map[string][]byte {
+	values := make(map[string][]byte, c.fieldCount)
+
+	for _, fieldKey := range state.fieldNames {
+		var buf []byte
+		if c.dataIntegrity {
+			buf = c.buildDeterministicValue(state, key, fieldKey)
+		} else {
+			buf = c.buildRandomValue(state)
+		}
+
+		values[fieldKey] = buf
+	}
+	return values
+}
+
+func (c *core) getValueBuffer(size int) []byte {
+	buf := c.valuePool.Get().([]byte)
+	if cap(buf) >= size {
+		return buf[0:size]
+	}
+
+	return make([]byte, size)
+}
+
+func (c *core) putValues(values map[string][]byte) {
+	for _, value := range values {
+		c.valuePool.Put(value)
+	}
+}
+
+func (c *core) buildRandomValue(state *coreState) []byte {
+	// TODO: use pool for the buffer
+	r := state.r
+	buf := c.getValueBuffer(int(c.fieldLengthGenerator.Next(r)))
+	switch {
+	case c.compressionRatio > 0:
+		fillCompressible(r, buf, c.compressionRatio)
+	case len(buf) >= largeValueSize:
+		fillLetters(r, buf)
+	default:
+		util.RandBytes(r, buf)
+	}
+	return buf
+}
+
+// largeValueSize is the size from which random values are filled by
+// fillLetters, as drawing each letter on its own makes building
+// multi-megabyte values slower than storing them
+const largeValueSize = 64 << 10
+
+// letters are those of go-ycsb's random values
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// fillLetters fills buf with random letters drawn from random bytes, several
+// times faster than util.RandBytes at the cost of a slight bias
+func fillLetters(r *rand.Rand, buf []byte) {
+	r.Read(buf)
+	for i, b := range buf {
+		buf[i] = letters[int(b)%len(letters)]
+	}
+}
+
+// fillCompressible fills buf with len(buf)/ratio random bytes repeated, the
+// way db_bench builds values of a target compression ratio
+func fillCompressible(r *rand.Rand, buf []byte, ratio float64) {
+	n := int(math.Ceil(float64(len(buf)) / ratio))
+	if n == 0 {
+		return
+	}
+	r.Read(buf[:n])
+	for i := n; i < len(buf); i += n {
+		copy(buf[i:], buf[:n])
+	}
+}
+
+func (c *core) buildDeterministicValue(state *coreState, key string, fieldKey string) []byte {
+	// TODO: use pool for the buffer
+	r := state.r
+	size := c.fieldLengthGenerator.Next(r)
+	buf := c.getValueBuffer(int(size + 21))
+	b := bytes.NewBuffer(buf[0:0])
+	b.WriteString(key)
+	b.WriteByte(':')
+	b.WriteString(strings.ToLower(fieldKey))
+	for int64(b.Len()) < size {
+		b.WriteByte(':')
+		n := util.BytesHash64(b.Bytes())
+		b.WriteString(strconv.FormatUint(uint64(n), 10))
+	}
+	b.Truncate(int(size))
+	return b.Bytes()
+}
+
+func (c *core) verifyRow(state *coreState, key string, values map[string][]byte) {
+	if len(values) == 0 {
+		// null data here, need panic?
+		return
+	}
+
+	for fieldKey, value := range values {
+		expected := c.buildDeterministicValue(state, key, fieldKey)
+		if !bytes.Equal(expected, value) {
+			util.Fatalf("unexpected deterministic value, expect %q, but got %q", expected, value)
+		}
+	}
+}
+
+// verifying wraps db to version the values written and check those read,
+// when VerifyProperty is set
+func (c *core) verifying(db ycsb.DB) ycsb.DB {
+	if c.verifier == nil {
+		return db
+	}
+	return verifyingDB{DB: db, v: c.verifier}
+}
+
+// DoInsert implements the Workload DoInsert interface.
+func (c *core) DoInsert(ctx context.Context, db ycsb.DB) error {
+	db = c.verifying(db)
+	state := ctx.Value(stateKey).(*coreState)
+	r := state.r
+	keyNum := c.keySequence.Next(r)
+	dbKey := c.buildKeyName(keyNum)
+	table := c.tableOf(keyNum)
+	if c.prune {
+		return db.Delete(ctx, table, dbKey)
+	}
+	values := c.buildValues(state, dbKey)
+	defer c.putValues(values)
+
+	numOfRetries := int64(0)
+
+	var err error
+	for {
+		err = db.Insert(ctx, table, dbKey, values)
+		if err == nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.Canceled {
+				return nil
+			}
+		default:
+		}
+
+		// Retry if configured. Without retrying, the load process will fail
+		// even if one single insertion fails. User can optionally configure
+		// an insertion retry limit (default is 0) to enable retry.
+		numOfRetries++
+		if numOfRetries > c.insertionRetryLimit {
+			break
+		}
+
+		// Sleep for a random time betweensz [0.8, 1.2)*insertionRetryInterval
+		sleepTimeMs := float64((c.insertionRetryInterval * 1000)) * (0.8 + 0.4*r.Float64())
+
+		time.Sleep(time.Duration(sleepTimeMs) * time.Millisecond)
+	}
+
+	return err
+}
+
+// DoBatchInsert implements the Workload DoBatchInsert interface.
+func (c *core) DoBatchInsert(ctx context.Context, batchSize int, db ycsb.DB) error {
+	batchDB, ok := db.(ycsb.BatchDB)
+	if !ok {
+		return fmt.Errorf("the %T does't implement the batchDB interface", db)
+	}
+	state := ctx.Value(stateKey).(*coreState)
+	r := state.r
+	var keys []string
+	var values []map[string][]byte
+	for i := 0; i < batchSize; i++ {
+		keyNum := c.keySequence.Next(r)
+		dbKey := c.buildKeyName(keyNum)
+		keys = append(keys, dbKey)
+		if !c.prune {
+			values = append(values, c.buildValues(state, dbKey))
+		}
+	}
+	if c.prune {
+		return batchDB.BatchDelete(ctx, c.table, keys)
+	}
+	defer func() {
+		for _, value := range values {
+			c.putValues(value)
+		}
+	}()
+
+	numOfRetries := int64(0)
+	var err error
+	for {
+		err = batchDB.BatchInsert(ctx, c.table, keys, values)
+		if err == nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.Canceled {
+				return nil
+			}
+		default:
+		}
+
+		// Retry if configured. Without retrying, the load process will fail
+		// even if one single insertion fails. User can optionally configure
+		// an insertion retry limit (default is 0) to enable retry.
+		numOfRetries++
+		if numOfRetries > c.insertionRetryLimit {
+			break
+		}
+
+		// Sleep for a random time betweensz [0.8, 1.2)*insertionRetryInterval
+		sleepTimeMs := float64((c.insertionRetryInterval * 1000)) * (0.8 + 0.4*r.Float64())
+
+		time.Sleep(time.Duration(sleepTimeMs) * time.Millisecond)
+	}
+	return err
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (c *core) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	db = c.verifying(db)
+	state := ctx.Value(stateKey).(*coreState)
+	if c.txnSize > 1 {
+		return c.doTransactionGroup(ctx, db, state)
+	}
+	return c.doTransactionOp(ctx, db, state)
+}
+
+// doTransactionGroup issues the next txnSize operations through one batch.
+// An operation failing does not stop the others, and the batch is committed
+// with those that succeeded, unless the run ended before it was complete.
+func (c *core) doTransactionGroup(ctx context.Context, db ycsb.DB, state *coreState) error {
+	batch, err := godbdb.NewBatch(ctx, db, int(c.txnSize))
+	if err != nil {
+		return err
+	}
+	inBatch := godbdb.InBatch(db, batch)
+
+	var opErr error
+	for i := int64(0); i < c.txnSize; i++ {
+		if err := c.doTransactionOp(ctx, inBatch, state); err != nil && opErr == nil {
+			opErr = err
+		}
+	}
+	if ctx.Err() != nil {
+		batch.Rollback()
+		return ctx.Err()
+	}
+	if err := batch.Commit(ctx); err != nil {
+		return err
+	}
+	return opErr
+}
+
+// doTransactionOp issues one operation of the mix
+func (c *core) doTransactionOp(ctx context.Context, db ycsb.DB, state *coreState) error {
+	r := state.r
+
+	operation := operationType(c.operationChooser.Next(r))
+	switch operation {
+	case read:
+		return c.doTransactionRead(ctx, db, state)
+	case update:
+		return c.doTransactionUpdate(ctx, db, state)
+	case insert:
+		return c.doTransactionInsert(ctx, db, state)
+	case scan:
+		return c.doTransactionScan(ctx, db, state)
+	case prefixScan:
+		return c.doTransactionPrefixScan(ctx, db, state)
+	case partialRead:
+		return c.doTransactionPartialRead(ctx, db, state)
+	default:
+		return c.doTransactionReadModifyWrite(ctx, db, state)
+	}
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface
+func (c *core) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	batchDB, ok := db.(ycsb.BatchDB)
+	if !ok {
+		return fmt.Errorf("the %T does't implement the batchDB interface", db)
+	}
+	state := ctx.Value(stateKey).(*coreState)
+	r := state.r
+
+	operation := operationType(c.operationChooser.Next(r))
+	switch operation {
+	case read:
+		return c.doBatchTransactionRead(ctx, batchSize, batchDB, state)
+	case insert:
+		return c.doBatchTransactionInsert(ctx, batchSize, batchDB, state)
+	case update:
+		return c.doBatchTransactionUpdate(ctx, batchSize, batchDB, state)
+	case scan, prefixScan:
+		panic("The batch mode don't support the scan operation")
+	case partialRead:
+		panic("The batch mode don't support the partial read operation")
+	default:
+		return nil
+	}
+}
+
+func (c *core) nextKeyNum(state *coreState) int64 {
+	r := state.r
+	if c.hotKeys > 0 && r.Float64() < c.hotFraction {
+		return c.hotStart + r.Int63n(c.hotKeys)
+	}
+	keyNum := int64(0)
+	if _, ok := c.keyChooser.(*generator.Exponential); ok {
+		keyNum = -1
+		for keyNum < 0 {
+			keyNum = c.transactionInsertKeySequence.Last() - c.keyChooser.Next(r)
+		}
+	} else {
+		keyNum = c.keyChooser.Next(r)
+	}
+	return keyNum
+}
+
+func (c *core) doTransactionRead(ctx context.Context, db ycsb.DB, state *coreState) error {
+	r := state.r
+	keyNum := c.nextKeyNum(state)
+	keyName := c.buildKeyName(keyNum)
+
+	var fields []string
+	if !c.readAllFields {
+		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
+		fields = append(fields, fieldName)
+	} else {
+		fields = state.fieldNames
+	}
+
+	values, err := db.Read(ctx, c.tableOf(keyNum), keyName, fields)
+	if err != nil {
+		return err
+	}
+
+	if c.dataIntegrity {
+		c.verifyRow(state, keyName, values)
+	}
+
+	return nil
+}
+
+func (c *core) doTransactionReadModifyWrite(ctx context.Context, db ycsb.DB, state *coreState) error {
+	start := time.Now()
+	defer func() {
+		measurement.Measure("READ_MODIFY_WRITE", start, time.Now().Sub(start))
+	}()
+
+	r := state.r
+	keyNum := c.nextKeyNum(state)
+	keyName := c.buildKeyName(keyNum)
+
+	var fields []string
+	if !c.readAllFields {
+		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
+		fields = append(fields, fieldName)
+	} else {
+		fields = state.fieldNames
+	}
+
+	var values map[string][]byte
+	if c.writeAllFields {
+		values = c.buildValues(state, keyName)
+	} else {
+		values = c.buildSingleValue(state, keyName)
+	}
+	defer c.putValues(values)
+
+	// One operation, in one transaction on backends that have them
+	return godbdb.ReadModifyWrite(ctx, db, c.tableOf(keyNum), keyName, fields, func(readValues map[string][]byte) map[string][]byte {
+		if c.dataIntegrity {
+			c.verifyRow(state, keyName, readValues)
+		}
+		return values
+	})
+}
+
+func (c *core) doTransactionInsert(ctx context.Context, db ycsb.DB, state *coreState) error {
+	r := state.r
+	keyNum := c.transactionInsertKeySequence.Next(r)
+	defer c.transactionInsertKeySequence.Acknowledge(keyNum)
+	dbKey := c.buildKeyName(keyNum)
+	values := c.buildValues(state, dbKey)
+	defer c.putValues(values)
+
+	return db.Insert(ctx, c.tableOf(keyNum), dbKey, values)
+}
+
+func (c *core) doTransactionScan(ctx context.Context, db ycsb.DB, state *coreState) error {
+	r := state.r
+	keyNum := c.nextKeyNum(state)
+	startKeyName := c.buildKeyName(keyNum)
+
+	scanLen := c.scanLength.Next(r)
+
+	var fields []string
+	if !c.readAllFields {
+		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
+		fields = append(fields, fieldName)
+	} else {
+		fields = state.fieldNames
+	}
+
+	_, err := db.Scan(ctx, c.tableOf(keyNum), startKeyName, int(scanLen), fields)
+
+	return err
+}
+
+func (c *core) doTransactionPartialRead(ctx context.Context, db ycsb.DB, state *coreState) error {
+	r := state.r
+	keyNum := c.nextKeyNum(state)
+	keyName := c.buildKeyName(keyNum)
+	fieldName := state.fieldNames[c.fieldChooser.Next(r)]
+
+	// Anywhere the range fits in the longest value
+	offset := int64(0)
+	if c.fieldLength > c.partialReadLength {
+		offset = r.Int63n(c.fieldLength - c.partialReadLength + 1)
+	}
+
+	_, err := godbdb.PartialRead(ctx, db, c.tableOf(keyNum), keyName, fieldName, int(offset), int(c.partialReadLength))
+
+	return err
+}
+
+func (c *core) doTransactionPrefixScan(ctx context.Context, db ycsb.DB, state *coreState) error {
+	r := state.r
+	keyNum := c.nextKeyNum(state)
+	prefix := c.buildKeyPrefix(keyNum)
+
+	var fields []string
+	if !c.readAllFields {
+		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
+		fields = append(fields, fieldName)
+	} else {
+		fields = state.fieldNames
+	}
+
+	_, err := godbdb.PrefixScan(ctx, db, c.tableOf(keyNum), prefix, fields)
+
+	return err
+}
+
+func (c *core) doTransactionUpdate(ctx context.Context, db ycsb.DB, state *coreState) error {
+	keyNum := c.nextKeyNum(state)
+	keyName := c.buildKeyName(keyNum)
+
+	var values map[string][]byte
+	if c.writeAllFields {
+		values = c.buildValues(state, keyName)
+	} else {
+		values = c.buildSingleValue(state, keyName)
+	}
+
+	defer c.putValues(values)
+
+	return db.Update(ctx, c.tableOf(keyNum), keyName, values)
+}
+
+func (c *core) doBatchTransactionRead(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
+	r := state.r
+	var fields []string
+
+	if !c.readAllFields {
+		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
+		fields = append(fields, fieldName)
+	} else {
+		fields = state.fieldNames
+	}
+
+	keys := make([]string, batchSize)
+	for i := 0; i < batchSize; i++ {
+		keys[i] = c.buildKeyName(c.nextKeyNum(state))
+	}
+
+	_, err := db.BatchRead(ctx, c.table, keys, fields)
+	if err != nil {
+		return err
+	}
+
+	// TODO should we verify the result?
+	return nil
+}
+
+func (c *core) doBatchTransactionInsert(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
+	r := state.r
+	keys := make([]string, batchSize)
+	values := make([]map[string][]byte, batchSize)
+	for i := 0; i < batchSize; i++ {
+		keyNum := c.transactionInsertKeySequence.Next(r)
+		keyName := c.buildKeyName(keyNum)
+		keys[i] = keyName
+		if c.writeAllFields {
+			values[i] = c.buildValues(state, keyName)
+		} else {
+			values[i] = c.buildSingleValue(state, keyName)
+		}
+		c.transactionInsertKeySequence.Acknowledge(keyNum)
+	}
+
+	defer func() {
+		for _, value := range values {
+			c.putValues(value)
+		}
+	}()
+
+	return db.BatchInsert(ctx, c.table, keys, values)
+}
+
+func (c *core) doBatchTransactionUpdate(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
+	keys := make([]string, batchSize)
+	values := make([]map[string][]byte, batchSize)
+	for i := 0; i < batchSize; i++ {
+		keyNum := c.nextKeyNum(state)
+		keyName := c.buildKeyName(keyNum)
+		keys[i] = keyName
+		if c.writeAllFields {
+			values[i] = c.buildValues(state, keyName)
+		} else {
+			values[i] = c.buildSingleValue(state, keyName)
+		}
+	}
+
+	defer func() {
+		for _, value := range values {
+			c.putValues(value)
+		}
+	}()
+
+	return db.BatchUpdate(ctx, c.table, keys, values)
+}
+
+// CoreCreator creates the Core workload.
+type coreCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	c := new(core)
+	c.p = p
+	c.table = p.GetString(prop.TableName, prop.TableNameDefault)
+	if tables := p.GetInt(godbdb.TableCountProperty, 1); tables < 1 {
+		util.Fatalf("%s must be at least 1, not %d", godbdb.TableCountProperty, tables)
+	} else if tables > 1 && p.GetInt(prop.BatchSize, prop.DefaultBatchSize) > 1 {
+		util.Fatalf("%s does not support %s above 1, as a batch is of one table: use %s", godbdb.TableCountProperty, prop.BatchSize, TxnSizeProperty)
+	}
+	c.tables = godbdb.Tables(p)
+	c.fieldCount = p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
+	c.fieldNames = make([]string, c.fieldCount)
+	for i := int64(0); i < c.fieldCount; i++ {
+		c.fieldNames[i] = fmt.Sprintf("field%d", i)
+	}
+	c.fieldLengthGenerator = getFieldLengthGenerator(p)
+	c.recordCount = p.GetInt64(prop.RecordCount, prop.RecordCountDefault)
+	if c.recordCount == 0 {
+		c.recordCount = int64(math.MaxInt32)
+	}
+
+	requestDistrib := p.GetString(prop.RequestDistribution, prop.RequestDistributionDefault)
+	maxScanLength := p.GetInt64(prop.MaxScanLength, prop.MaxScanLengthDefault)
+	scanLengthDistrib := p.GetString(prop.ScanLengthDistribution, prop.ScanLengthDistributionDefault)
+
+	insertStart := p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+	insertCount := p.GetInt64(prop.InsertCount, c.recordCount-insertStart)
+	if c.recordCount < insertStart+insertCount {
+		util.Fatalf("record count %d must be bigger than insert start %d + count %d",
+			c.recordCount, insertStart, insertCount)
+	}
+	c.zeroPadding = p.GetInt64(prop.ZeroPadding, prop.ZeroPaddingDefault)
+	c.readAllFields = p.GetBool(prop.ReadAllFields, prop.ReadALlFieldsDefault)
+	c.writeAllFields = p.GetBool(prop.WriteAllFields, prop.WriteAllFieldsDefault)
+	c.dataIntegrity = p.GetBool(prop.DataIntegrity, prop.DataIntegrityDefault)
+	fieldLengthDistribution := p.GetString(prop.FieldLengthDistribution, prop.FieldLengthDistributionDefault)
+	if c.dataIntegrity && fieldLengthDistribution != "constant" {
+		util.Fatal("must have constant field size to check data integrity")
+	}
+
+	switch p.GetString(prop.InsertOrder, prop.InsertOrderDefault) {
+	case "hashed":
+		c.orderedInserts = false
+	case InsertOrderMonotonic:
+		c.orderedInserts = true
+		c.zeroPadding = max(c.zeroPadding, monotonicPadding)
+	default:
+		c.orderedInserts = true
+	}
+
+	c.keySequence = generator.NewCounter(insertStart)
+	c.operationChooser = createOperationGenerator(p)
+	var keyrangeLowerBound int64 = insertStart
+	var keyrangeUpperBound int64 = insertStart + insertCount - 1
+
+	c.transactionInsertKeySequence = generator.NewAcknowledgedCounter(c.recordCount)
+	switch requestDistrib {
+	case "uniform":
+		c.keyChooser = generator.NewUniform(keyrangeLowerBound, keyrangeUpperBound)
+	case "sequential":
+		c.keyChooser = generator.NewSequential(keyrangeLowerBound, keyrangeUpperBound)
+	case "zipfian":
+		insertProportion := p.GetFloat64(prop.InsertProportion, prop.InsertProportionDefault)
+		opCount := p.GetInt64(prop.OperationCount, 0)
+		expectedNewKeys := int64(float64(opCount) * insertProportion * 2.0)
+		keyrangeUpperBound = insertStart + insertCount + expectedNewKeys
+		c.keyChooser = generator.NewScrambledZipfian(keyrangeLowerBound, keyrangeUpperBound, generator.ZipfianConstant)
+	case "latest":
+		c.keyChooser = generator.NewSkewedLatest(c.transactionInsertKeySequence)
+	case "hotspot":
+		hotsetFraction := p.GetFloat64(prop.HotspotDataFraction, prop.HotspotDataFractionDefault)
+		hotopnFraction := p.GetFloat64(prop.HotspotOpnFraction, prop.HotspotOpnFractionDefault)
+		c.keyChooser = generator.NewHotspot(keyrangeLowerBound, keyrangeUpperBound, hotsetFraction, hotopnFraction)
+	case "exponential":
+		percentile := p.GetFloat64(prop.ExponentialPercentile, prop.ExponentialPercentileDefault)
+		frac := p.GetFloat64(prop.ExponentialFrac, prop.ExponentialFracDefault)
+		c.keyChooser = generator.NewExponential(percentile, float64(c.recordCount)*frac)
+	default:
+		util.Fatalf("unknown request distribution %s", requestDistrib)
+	}
+	fmt.Println(fmt.Sprintf("Using request distribution '%s' a keyrange of [%d %d]", requestDistrib, keyrangeLowerBound, keyrangeUpperBound))
+
+	c.fieldChooser = generator.NewUniform(0, c.fieldCount-1)
+	switch scanLengthDistrib {
+	case "uniform":
+		c.scanLength = generator.NewUniform(1, maxScanLength)
+	case "zipfian":
+		c.scanLength = generator.NewZipfianWithRange(1, maxScanLength, generator.ZipfianConstant)
+	default:
+		util.Fatalf("distribution %s not allowed for scan length", scanLengthDistrib)
+	}
+
+	c.insertionRetryLimit = p.GetInt64(prop.InsertionRetryLimit, prop.InsertionRetryLimitDefault)
+	c.insertionRetryInterval = p.GetInt64(prop.InsertionRetryInterval, prop.InsertionRetryIntervalDefault)
+
+	if _, ok := p.Get(SeedProperty); ok {
+		c.seed = p.GetInt64(SeedProperty, 0)
+		c.seeded = true
+	}
+	c.txnSize = p.GetInt64(TxnSizeProperty, 1)
+	c.hotStart = insertStart
+	c.hotKeys = p.GetInt64(HotSetKeysProperty, 0)
+	c.hotFraction = p.GetFloat64(HotSetFractionProperty, 0)
+	if c.hotKeys < 0 || c.hotKeys > insertCount {
+		util.Fatalf("%s must be between 0 and the %d keys loaded, not %d", HotSetKeysProperty, insertCount, c.hotKeys)
+	}
+	if c.hotFraction < 0 || c.hotFraction > 1 {
+		util.Fatalf("%s must be between 0 and 1, not %g", HotSetFractionProperty, c.hotFraction)
+	}
+	c.fieldLength = p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+	c.partialReadLength = p.GetInt64(PartialReadLengthProperty, 4096)
+	if c.partialReadLength < 1 {
+		util.Fatalf("%s must be at least 1, not %d", PartialReadLengthProperty, c.partialReadLength)
+	}
+	c.prefixCount = p.GetInt64(PrefixCountProperty, 0)
+	if c.prefixCount < 0 {
+		util.Fatalf("%s must not be negative, not %d", PrefixCountProperty, c.prefixCount)
+	}
+	if c.prefixCount == 0 && p.GetFloat64(PrefixScanProportionProperty, 0) > 0 {
+		util.Fatalf("%s needs keys laid out under prefixes: set %s", PrefixScanProportionProperty, PrefixCountProperty)
+	}
+	pruneFraction := p.GetFloat64(PruneFractionProperty, 0)
+	if pruneFraction < 0 || pruneFraction > 1 {
+		util.Fatalf("%s must be between 0 and 1, not %g", PruneFractionProperty, pruneFraction)
+	}
+	c.prune = pruneFraction > 0 && !p.GetBool(prop.DoTransactions, true)
+	c.compressionRatio = p.GetFloat64(CompressionRatioProperty, 0)
+	if c.compressionRatio > 0 && c.compressionRatio < 1 {
+		util.Fatalf("%s must be at least 1, not %g", CompressionRatioProperty, c.compressionRatio)
+	}
+	if p.GetBool(VerifyProperty, false) {
+		switch {
+		case c.fieldCount != 1:
+			util.Fatalf("%s needs %s=1, as the backends keep one value per key", VerifyProperty, prop.FieldCount)
+		case p.GetInt(prop.BatchSize, prop.DefaultBatchSize) > 1 || c.txnSize > 1:
+			util.Fatalf("%s does not support %s or %s above 1", VerifyProperty, prop.BatchSize, TxnSizeProperty)
+		case c.dataIntegrity:
+			util.Fatalf("%s and %s both set the values written: set only one", VerifyProperty, prop.DataIntegrity)
+		}
+		c.verifier = newVerifier()
+	}
+
+	fieldLength := p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+	c.valuePool = sync.Pool{
+		New: func() interface{} {
+			return make([]byte, fieldLength)
+		},
+	}
+
+	return c, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("core", coreCreator{})
+	ycsb.RegisterWorkloadCreator("site.ycsb.workloads.CoreWorkload", coreCreator{})
+}
@@ -0,0 +1,188 @@
+package workload
+
+import (
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// Preset is a named set of workload properties
+type Preset struct {
+	Name        string
+	Description string
+	Properties  map[string]string
+}
+
+// Presets are the workloads `workload gen --preset` can start from: the
+// standard YCSB core workloads plus mixes modelled on Ethereum state access
+var Presets = []Preset{
+	{
+		Name:        "a",
+		Description: "YCSB A: update heavy, 50/50 reads and updates, zipfian",
+		Properties: map[string]string{
+			prop.ReadProportion:      "0.5",
+			prop.UpdateProportion:    "0.5",
+			prop.RequestDistribution: "zipfian",
+		},
+	},
+	{
+		Name:        "b",
+		Description: "YCSB B: read mostly, 95/5 reads and updates, zipfian",
+		Properties: map[string]string{
+			prop.ReadProportion:      "0.95",
+			prop.UpdateProportion:    "0.05",
+			prop.RequestDistribution: "zipfian",
+		},
+	},
+	{
+		Name:        "c",
+		Description: "YCSB C: read only, zipfian",
+		Properties: map[string]string{
+			prop.ReadProportion:      "1",
+			prop.UpdateProportion:    "0",
+			prop.RequestDistribution: "zipfian",
+		},
+	},
+	{
+		Name:        "d",
+		Description: "YCSB D: read latest, 95/5 reads and inserts",
+		Properties: map[string]string{
+			prop.ReadProportion:      "0.95",
+			prop.UpdateProportion:    "0",
+			prop.InsertProportion:    "0.05",
+			prop.RequestDistribution: "latest",
+		},
+	},
+	{
+		Name:        "e",
+		Description: "YCSB E: short ranges, 95/5 scans and inserts, zipfian",
+		Properties: map[string]string{
+			prop.ReadProportion:      "0",
+			prop.UpdateProportion:    "0",
+			prop.ScanProportion:      "0.95",
+			prop.InsertProportion:    "0.05",
+			prop.RequestDistribution: "zipfian",
+			prop.MaxScanLength:       "100",
+		},
+	},
+	{
+		Name:        "f",
+		Description: "YCSB F: read-modify-write, 50/50 reads and RMW, zipfian",
+		Properties: map[string]string{
+			prop.ReadProportion:            "0.5",
+			prop.UpdateProportion:          "0",
+			prop.ReadModifyWriteProportion: "0.5",
+			prop.RequestDistribution:       "zipfian",
+		},
+	},
+	{
+		// RPC nodes serving eth_call/eth_getStorageAt: mostly reads of
+		// single 32-byte storage slots, concentrated on popular contracts
+		Name:        "ethereum-state-like",
+		Description: "Ethereum state reads: 90/8/2 reads, updates, inserts of 32-byte slots, zipfian",
+		Properties: map[string]string{
+			prop.RecordCount:         "1000000",
+			prop.OperationCount:      "1000000",
+			prop.FieldCount:          "1",
+			prop.FieldLength:         "32",
+			prop.ReadProportion:      "0.9",
+			prop.UpdateProportion:    "0.08",
+			prop.InsertProportion:    "0.02",
+			prop.RequestDistribution: "zipfian",
+		},
+	},
+	{
+		// Block import: every transaction reads the slots it touches and
+		// writes most of them back, and new slots appear as contracts grow
+		Name:        "ethereum-sync-like",
+		Description: "Ethereum block import: 40/45/15 reads, updates, inserts of 32-byte slots, zipfian",
+		Properties: map[string]string{
+			prop.RecordCount:         "1000000",
+			prop.OperationCount:      "1000000",
+			prop.FieldCount:          "1",
+			prop.FieldLength:         "32",
+			prop.ReadProportion:      "0.4",
+			prop.UpdateProportion:    "0.45",
+			prop.InsertProportion:    "0.15",
+			prop.RequestDistribution: "zipfian",
+		},
+	},
+	{
+		// Receipts, logs and block bodies: appended under increasing block
+		// numbers and read back mostly while recent
+		Name:        "ethereum-append-like",
+		Description: "Ethereum history: 80/20 appends and reads of monotonic keys, latest",
+		Properties: map[string]string{
+			prop.RecordCount:         "1000000",
+			prop.OperationCount:      "1000000",
+			prop.FieldCount:          "1",
+			prop.FieldLength:         "256",
+			prop.ReadProportion:      "0.2",
+			prop.UpdateProportion:    "0",
+			prop.InsertProportion:    "0.8",
+			prop.InsertOrder:         InsertOrderMonotonic,
+			prop.RequestDistribution: "latest",
+		},
+	},
+	{
+		// eth_getLogs and receipt queries: ranges of recent blocks' logs,
+		// mostly short, while new blocks keep being appended
+		Name:        "ethereum-logs-like",
+		Description: "Ethereum log queries: 90/10 range scans and appends of monotonic keys, zipfian lengths, latest",
+		Properties: map[string]string{
+			prop.RecordCount:            "1000000",
+			prop.OperationCount:         "100000",
+			prop.FieldCount:             "1",
+			prop.FieldLength:            "256",
+			prop.ReadProportion:         "0",
+			prop.UpdateProportion:       "0",
+			prop.ScanProportion:         "0.9",
+			prop.InsertProportion:       "0.1",
+			prop.MaxScanLength:          "1000",
+			prop.ScanLengthDistribution: "zipfian",
+			prop.InsertOrder:            InsertOrderMonotonic,
+			prop.RequestDistribution:    "latest",
+		},
+	},
+	{
+		// Receipts, contract code and blobs: large values written once and
+		// read back whole or a slice at a time
+		Name:        "blob-like",
+		Description: "Large objects: 30/50/20 reads, 4 KiB partial reads and inserts of 4 MiB values, uniform",
+		Properties: map[string]string{
+			prop.RecordCount:              "1000",
+			prop.OperationCount:           "1000",
+			prop.FieldCount:               "1",
+			prop.FieldLength:              "4194304",
+			prop.ReadProportion:           "0.3",
+			prop.UpdateProportion:         "0",
+			prop.InsertProportion:         "0.2",
+			PartialReadProportionProperty: "0.5",
+			PartialReadLengthProperty:     "4096",
+			prop.RequestDistribution:      "uniform",
+		},
+	},
+	{
+		Name:        "write-only",
+		Description: "Sustained writes: inserts only of 1 KiB values in hashed order, for long --duration runs",
+		Properties: map[string]string{
+			prop.RecordCount:         "1000",
+			prop.OperationCount:      "100000000",
+			prop.FieldCount:          "1",
+			prop.FieldLength:         "1024",
+			prop.ReadProportion:      "0",
+			prop.UpdateProportion:    "0",
+			prop.InsertProportion:    "1",
+			prop.InsertOrder:         "hashed",
+			prop.RequestDistribution: "uniform",
+		},
+	},
+}
+
+// FindPreset returns the preset with the given name, if any
+func FindPreset(name string) (Preset, bool) {
+	for _, p := range Presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
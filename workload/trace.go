@@ -0,0 +1,417 @@
+package workload
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+)
+
+// TraceWorkload is the workload that re-issues a recorded operation trace
+const TraceWorkload = "trace"
+
+// TraceFileProperty is the trace TraceWorkload replays
+const TraceFileProperty = "trace.file"
+
+// traceHeader starts every trace file, so other files are not mistaken for one
+const traceHeader = "# godb-bench operation trace v1"
+
+// Operations of a trace, named as in the results table
+const (
+	OpRead        = "READ"
+	OpUpdate      = "UPDATE"
+	OpInsert      = "INSERT"
+	OpScan        = "SCAN"
+	OpPrefixScan  = "PREFIX_SCAN"
+	OpPartialRead = "PARTIAL_READ"
+	OpDelete      = "DELETE"
+	OpRMW         = "RMW"
+	OpBatchRead   = "BATCH_READ"
+	OpBatchUpdate = "BATCH_UPDATE"
+	OpBatchInsert = "BATCH_INSERT"
+	OpBatchDelete = "BATCH_DELETE"
+)
+
+// traceField is the field replayed values are written to and reads ask for
+const traceField = "field0"
+
+// TraceOp is one operation of a trace: its keys and, per key, the bytes
+// written, or the records asked for by a scan, or the bytes by a partial read.
+// Reads, deletes and prefix scans, whose key is the prefix, have no sizes.
+type TraceOp struct {
+	Op    string
+	Keys  []string
+	Sizes []int
+}
+
+// TraceWriter records operations to a trace file, one per line:
+//
+//	UPDATE "user6284781860667377211" 100
+//	BATCH_READ "user12" 0 "user98" 0
+//
+// Each key is quoted and followed by its size. It is safe for concurrent
+// use; the order of operations issued concurrently is the order they were
+// recorded in.
+type TraceWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	gz   *gzip.Writer
+	w    *bufio.Writer
+	err  error
+}
+
+// CreateTrace creates the trace file at path, gzipped if path ends in .gz
+func CreateTrace(path string) (*TraceWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace: %w", err)
+	}
+	t := &TraceWriter{file: f}
+	var out io.Writer = f
+	if strings.HasSuffix(path, ".gz") {
+		t.gz = gzip.NewWriter(f)
+		out = t.gz
+	}
+	t.w = bufio.NewWriterSize(out, 1<<20)
+	t.w.WriteString(traceHeader + "\n")
+	return t, nil
+}
+
+// Record appends op to the trace. Write errors are kept for Close.
+func (t *TraceWriter) Record(op string, keys []string, sizes []int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return
+	}
+	buf := t.w.AvailableBuffer()
+	buf = append(buf, op...)
+	for i, key := range keys {
+		buf = append(buf, ' ')
+		buf = strconv.AppendQuote(buf, key)
+		buf = append(buf, ' ')
+		size := 0
+		if i < len(sizes) {
+			size = sizes[i]
+		}
+		buf = strconv.AppendInt(buf, int64(size), 10)
+	}
+	buf = append(buf, '\n')
+	_, t.err = t.w.Write(buf)
+}
+
+// Close flushes and closes the trace file, returning the first write error
+func (t *TraceWriter) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.err
+	if ferr := t.w.Flush(); err == nil {
+		err = ferr
+	}
+	if t.gz != nil {
+		if gerr := t.gz.Close(); err == nil {
+			err = gerr
+		}
+	}
+	if cerr := t.file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write trace: %w", err)
+	}
+	return nil
+}
+
+// TraceReader reads the operations of a trace file, gzipped or not
+type TraceReader struct {
+	path    string
+	file    *os.File
+	gz      *gzip.Reader
+	scanner *bufio.Scanner
+	line    int
+}
+
+// OpenTrace opens the trace file at path and checks its header
+func OpenTrace(path string) (*TraceReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace: %w", err)
+	}
+	t := &TraceReader{path: path, file: f}
+	r := bufio.NewReader(f)
+	var in io.Reader = r
+	if magic, err := r.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		if t.gz, err = gzip.NewReader(r); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read gzipped trace %s: %w", path, err)
+		}
+		in = t.gz
+	}
+	t.scanner = bufio.NewScanner(in)
+	t.scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	if !t.scanner.Scan() || t.scanner.Text() != traceHeader {
+		t.Close()
+		return nil, fmt.Errorf("%s is not an operation trace (recorded with record_trace)", path)
+	}
+	t.line = 1
+	return t, nil
+}
+
+// Next returns the next operation of the trace, or io.EOF after the last
+func (t *TraceReader) Next() (TraceOp, error) {
+	for t.scanner.Scan() {
+		t.line++
+		line := t.scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		op, err := parseTraceOp(line)
+		if err != nil {
+			return TraceOp{}, fmt.Errorf("%s:%d: %w", t.path, t.line, err)
+		}
+		return op, nil
+	}
+	if err := t.scanner.Err(); err != nil {
+		return TraceOp{}, fmt.Errorf("%s:%d: %w", t.path, t.line+1, err)
+	}
+	return TraceOp{}, io.EOF
+}
+
+// Close closes the trace file
+func (t *TraceReader) Close() error {
+	if t.gz != nil {
+		t.gz.Close()
+	}
+	return t.file.Close()
+}
+
+// parseTraceOp parses a line written by TraceWriter.Record
+func parseTraceOp(line string) (TraceOp, error) {
+	name, rest, _ := strings.Cut(line, " ")
+	switch name {
+	case OpRead, OpUpdate, OpInsert, OpScan, OpPrefixScan, OpPartialRead, OpDelete, OpRMW, OpBatchRead, OpBatchUpdate, OpBatchInsert, OpBatchDelete:
+	default:
+		return TraceOp{}, fmt.Errorf("unknown operation %q", name)
+	}
+	op := TraceOp{Op: name}
+	for rest != "" {
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return TraceOp{}, fmt.Errorf("invalid key in %q", line)
+		}
+		key, _ := strconv.Unquote(quoted)
+		rest = strings.TrimPrefix(rest[len(quoted):], " ")
+		var size string
+		size, rest, _ = strings.Cut(rest, " ")
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 0 {
+			return TraceOp{}, fmt.Errorf("invalid size %q of key %q", size, key)
+		}
+		op.Keys = append(op.Keys, key)
+		op.Sizes = append(op.Sizes, n)
+	}
+	if len(op.Keys) == 0 {
+		return TraceOp{}, fmt.Errorf("%s without a key", name)
+	}
+	return op, nil
+}
+
+// traceReplay is a workload re-issuing the operations of a trace in the
+// order they were recorded, whether the run loads or runs transactions.
+// Threads take the next operation in turn, so the order is exact with one
+// thread. Values are random bytes of the recorded sizes, in one field.
+type traceReplay struct {
+	table string
+
+	mu    sync.Mutex
+	trace *TraceReader
+	done  chan struct{}
+	ended bool
+
+	valueMu sync.Mutex
+	rng     *rand.Rand
+}
+
+// TraceDone returns a channel closed once every operation of the trace the
+// workload w replays has been issued, for runs to end then. It returns nil
+// for any other workload.
+func TraceDone(w ycsb.Workload) <-chan struct{} {
+	if t, ok := w.(*traceReplay); ok {
+		return t.done
+	}
+	return nil
+}
+
+// next returns the next operation, or false once the trace is exhausted
+func (t *traceReplay) next() (TraceOp, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ended {
+		return TraceOp{}, false, nil
+	}
+	op, err := t.trace.Next()
+	if err == io.EOF {
+		t.ended = true
+		close(t.done)
+		return TraceOp{}, false, nil
+	}
+	if err != nil {
+		return TraceOp{}, false, err
+	}
+	return op, true, nil
+}
+
+// value returns random bytes of size
+func (t *traceReplay) value(size int) map[string][]byte {
+	buf := make([]byte, size)
+	t.valueMu.Lock()
+	t.rng.Read(buf)
+	t.valueMu.Unlock()
+	return map[string][]byte{traceField: buf}
+}
+
+// issue re-issues op against db
+func (t *traceReplay) issue(ctx context.Context, db ycsb.DB, op TraceOp) error {
+	fields := []string{traceField}
+	key := op.Keys[0]
+	switch op.Op {
+	case OpRead:
+		_, err := db.Read(ctx, t.table, key, fields)
+		return err
+	case OpUpdate:
+		return db.Update(ctx, t.table, key, t.value(op.Sizes[0]))
+	case OpInsert:
+		return db.Insert(ctx, t.table, key, t.value(op.Sizes[0]))
+	case OpScan:
+		_, err := db.Scan(ctx, t.table, key, op.Sizes[0], fields)
+		return err
+	case OpPrefixScan:
+		_, err := godbdb.PrefixScan(ctx, db, t.table, key, fields)
+		return err
+	case OpPartialRead:
+		// Traces do not keep the offset
+		_, err := godbdb.PartialRead(ctx, db, t.table, key, traceField, 0, op.Sizes[0])
+		return err
+	case OpDelete:
+		return db.Delete(ctx, t.table, key)
+	case OpRMW:
+		return godbdb.ReadModifyWrite(ctx, db, t.table, key, fields, func(map[string][]byte) map[string][]byte {
+			return t.value(op.Sizes[0])
+		})
+	}
+
+	batch, ok := db.(ycsb.BatchDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the batchDB interface", db)
+	}
+	values := func() []map[string][]byte {
+		vs := make([]map[string][]byte, len(op.Sizes))
+		for i, size := range op.Sizes {
+			vs[i] = t.value(size)
+		}
+		return vs
+	}
+	switch op.Op {
+	case OpBatchRead:
+		_, err := batch.BatchRead(ctx, t.table, op.Keys, fields)
+		return err
+	case OpBatchUpdate:
+		return batch.BatchUpdate(ctx, t.table, op.Keys, values())
+	case OpBatchInsert:
+		return batch.BatchInsert(ctx, t.table, op.Keys, values())
+	default:
+		return batch.BatchDelete(ctx, t.table, op.Keys)
+	}
+}
+
+// replayNext issues the next operation of the trace. Once it is exhausted,
+// callers wait for the run to end rather than failing every remaining call.
+func (t *traceReplay) replayNext(ctx context.Context, db ycsb.DB) error {
+	op, ok, err := t.next()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		<-ctx.Done()
+		return nil
+	}
+	return t.issue(ctx, db, op)
+}
+
+// Load implements the Workload Load interface.
+func (t *traceReplay) Load(ctx context.Context, db ycsb.DB, totalCount int64) error {
+	return nil
+}
+
+// InitThread implements the Workload InitThread interface.
+func (t *traceReplay) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+// CleanupThread implements the Workload CleanupThread interface.
+func (t *traceReplay) CleanupThread(_ context.Context) {
+}
+
+// Close implements the Workload Close interface.
+func (t *traceReplay) Close() error {
+	return t.trace.Close()
+}
+
+// DoInsert implements the Workload DoInsert interface.
+func (t *traceReplay) DoInsert(ctx context.Context, db ycsb.DB) error {
+	return t.replayNext(ctx, db)
+}
+
+// DoBatchInsert implements the Workload DoBatchInsert interface. Batches
+// are replayed as recorded, whatever the batch size.
+func (t *traceReplay) DoBatchInsert(ctx context.Context, _ int, db ycsb.DB) error {
+	return t.replayNext(ctx, db)
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (t *traceReplay) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	return t.replayNext(ctx, db)
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface.
+// Batches are replayed as recorded, whatever the batch size.
+func (t *traceReplay) DoBatchTransaction(ctx context.Context, _ int, db ycsb.DB) error {
+	return t.replayNext(ctx, db)
+}
+
+type traceCreator struct{}
+
+// Create implements the WorkloadCreator Create interface.
+func (traceCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	path := p.GetString(TraceFileProperty, "")
+	if path == "" {
+		return nil, fmt.Errorf("the %s workload needs %s", TraceWorkload, TraceFileProperty)
+	}
+	trace, err := OpenTrace(path)
+	if err != nil {
+		return nil, err
+	}
+	return &traceReplay{
+		table: p.GetString(prop.TableName, prop.TableNameDefault),
+		trace: trace,
+		done:  make(chan struct{}),
+		rng:   rand.New(rand.NewSource(p.GetInt64(SeedProperty, 0))),
+	}, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator(TraceWorkload, traceCreator{})
+}
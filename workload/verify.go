@@ -0,0 +1,410 @@
+package workload
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+
+	godbdb "github.com/jihwankim/polygon-benchmarks/godb-bench/db"
+)
+
+// VerifyProperty makes the core workload check what it reads, so a
+// performance run doubles as a correctness smoke test. Every value written
+// carries its key and a version, and reads check the value is intact and no
+// older than a version acknowledged before the read started. Writes to the
+// same key are serialized so its versions are ordered. Once the run is done,
+// Verify reads every key written back. It needs fieldcount=1, and a load
+// with it set too, as other values read as corrupt.
+const VerifyProperty = "verify"
+
+// verifyStripes is the number of locks writes to the same key serialize on,
+// and of the shards of the versions acknowledged
+const verifyStripes = 1024
+
+// verifyExamples is the number of anomalies described in Anomalies.Examples
+const verifyExamples = 10
+
+// Anomalies are what verification found
+type Anomalies struct {
+	Reads    int64    // records checked while the workload ran
+	Writes   int64    // versions acknowledged
+	Keys     int64    // read back once the workload was done
+	Corrupt  int64    // values that do not match their own header, or under another key
+	Stale    int64    // reads older than a version acknowledged before they started
+	Missing  int64    // reads finding no value although a version was acknowledged before they started
+	Lost     int64    // keys read back older than their last acknowledged version, or not at all
+	Examples []string // the first anomalies, described
+}
+
+// Total returns the number of anomalies of every kind
+func (a Anomalies) Total() int64 {
+	return a.Corrupt + a.Stale + a.Missing + a.Lost
+}
+
+// ackedVersions are the last two versions acknowledged for a key, newest
+// first, and when, and the table of the key
+type ackedVersions struct {
+	versions [2]uint64
+	at       [2]time.Time
+	table    string
+}
+
+// verifyShard holds the versions acknowledged for the keys of one stripe
+type verifyShard struct {
+	mu     sync.Mutex
+	acked  map[string]*ackedVersions
+	writes sync.Mutex // held for the whole of a write to one of its keys
+}
+
+// verifier stamps versions on the values written and checks the values read
+type verifier struct {
+	last   atomic.Uint64 // last version handed out
+	shards [verifyStripes]verifyShard
+
+	reads, writes atomic.Int64
+
+	mu        sync.Mutex
+	anomalies Anomalies
+}
+
+// newVerifier starts the versions at the current time, so they are newer
+// than those written by earlier runs on the same database
+func newVerifier() *verifier {
+	v := &verifier{}
+	v.last.Store(uint64(time.Now().UnixNano()))
+	for i := range v.shards {
+		v.shards[i].acked = make(map[string]*ackedVersions)
+	}
+	return v
+}
+
+func (v *verifier) shard(key string) *verifyShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &v.shards[h.Sum32()%verifyStripes]
+}
+
+// begin waits for the writes in flight to key and returns the version to
+// write. Every begin must be followed by end.
+func (v *verifier) begin(key string) uint64 {
+	v.shard(key).writes.Lock()
+	return v.last.Add(1)
+}
+
+// end records version as acknowledged for key of table if the write
+// succeeded and lets the next write to key start. Version 0 is a delete,
+// after which key may be missing.
+func (v *verifier) end(table, key string, version uint64, err error) {
+	s := v.shard(key)
+	if err == nil && version == 0 {
+		s.mu.Lock()
+		delete(s.acked, key)
+		s.mu.Unlock()
+	} else if err == nil {
+		now := time.Now()
+		s.mu.Lock()
+		a, ok := s.acked[key]
+		if !ok {
+			a = &ackedVersions{table: table}
+			s.acked[key] = a
+		}
+		a.versions[0], a.versions[1] = version, a.versions[0]
+		a.at[0], a.at[1] = now, a.at[0]
+		s.mu.Unlock()
+		v.writes.Add(1)
+	}
+	s.writes.Unlock()
+}
+
+// newest returns the newest version of key acknowledged before t, or 0
+func (v *verifier) newest(key string, t time.Time) uint64 {
+	s := v.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.acked[key]
+	if !ok {
+		return 0
+	}
+	for i, at := range a.at {
+		if !at.IsZero() && at.Before(t) {
+			return a.versions[i]
+		}
+	}
+	return 0
+}
+
+// report counts one anomaly of the run, described by the arguments, with
+// count
+func (v *verifier) report(count *int64, format string, args ...interface{}) {
+	v.reportTo(&v.anomalies, count, format, args...)
+}
+
+// reportTo counts one anomaly of a, described by the arguments, with count
+func (v *verifier) reportTo(a *Anomalies, count *int64, format string, args ...interface{}) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	*count++
+	if len(a.Examples) < verifyExamples {
+		a.Examples = append(a.Examples, fmt.Sprintf(format, args...))
+	}
+}
+
+// check verifies the value read for key by a read that started at start.
+// key is empty for scans, which take it from the value.
+func (v *verifier) check(key string, value []byte, start time.Time) {
+	v.reads.Add(1)
+	k, version, ok := parseVersionedValue(value)
+	if !ok {
+		v.report(&v.anomalies.Corrupt, "corrupt value under %s: %.64q", key, value)
+		return
+	}
+	if key != "" && k != key {
+		v.report(&v.anomalies.Corrupt, "value of %s read under %s", k, key)
+		return
+	}
+	if newest := v.newest(k, start); version < newest {
+		v.report(&v.anomalies.Stale, "stale read of %s: version %d, %d acknowledged before the read", k, version, newest)
+	}
+}
+
+// checkRead verifies the outcome of a read of key that started at start
+func (v *verifier) checkRead(key string, values map[string][]byte, err error, start time.Time) {
+	if godbdb.ErrorClass(err) == godbdb.ErrorClassNotFound {
+		v.reads.Add(1)
+		if newest := v.newest(key, start); newest > 0 {
+			v.report(&v.anomalies.Missing, "missing %s: version %d acknowledged before the read", key, newest)
+		}
+		return
+	}
+	if err != nil {
+		return
+	}
+	for _, value := range values {
+		v.check(key, value, start)
+	}
+}
+
+// checkScan verifies the records returned by a scan that started at start
+func (v *verifier) checkScan(records []map[string][]byte, err error, start time.Time) {
+	if err != nil {
+		return
+	}
+	for _, record := range records {
+		for _, value := range record {
+			v.check("", value, start)
+		}
+	}
+}
+
+// stamp returns values with each replaced by one of the same length carrying
+// key and version
+func (v *verifier) stamp(key string, version uint64, values map[string][]byte) map[string][]byte {
+	stamped := make(map[string][]byte, len(values))
+	for field, value := range values {
+		stamped[field] = versionedValue(key, version, len(value))
+	}
+	return stamped
+}
+
+// readBack reads every key with an acknowledged version from db, counting
+// those older than their last version or missing as lost in a
+func (v *verifier) readBack(ctx context.Context, db ycsb.DB, fields []string, a *Anomalies) error {
+	for i := range v.shards {
+		s := &v.shards[i]
+		s.mu.Lock()
+		acked := make(map[string]ackedVersions, len(s.acked))
+		for key, a := range s.acked {
+			acked[key] = *a
+		}
+		s.mu.Unlock()
+
+		for key, acks := range acked {
+			last := acks.versions[0]
+			values, err := db.Read(ctx, acks.table, key, fields)
+			v.mu.Lock()
+			a.Keys++
+			v.mu.Unlock()
+			if godbdb.ErrorClass(err) == godbdb.ErrorClassNotFound {
+				v.reportTo(a, &a.Lost, "lost %s: version %d acknowledged, none found", key, last)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read %s back: %w", key, err)
+			}
+			for _, value := range values {
+				k, version, ok := parseVersionedValue(value)
+				switch {
+				case !ok || k != key:
+					v.reportTo(a, &a.Corrupt, "corrupt value under %s: %.64q", key, value)
+				case version < last:
+					v.reportTo(a, &a.Lost, "lost update of %s: version %d found, %d acknowledged", key, version, last)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// result returns the anomalies found so far
+func (v *verifier) result() Anomalies {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	a := v.anomalies
+	a.Examples = append([]string(nil), a.Examples...)
+	a.Reads, a.Writes = v.reads.Load(), v.writes.Load()
+	return a
+}
+
+// Verify reads back every key the core workload w wrote with VerifyProperty
+// set and returns the anomalies found during the run and now. ok is false
+// if w does not verify.
+func Verify(ctx context.Context, w ycsb.Workload, db ycsb.DB) (anomalies Anomalies, ok bool, err error) {
+	c, ok := w.(*core)
+	if !ok || c.verifier == nil {
+		return Anomalies{}, false, nil
+	}
+	err = c.verifier.readBack(ctx, db, c.fieldNames, &c.verifier.anomalies)
+	return c.verifier.result(), true, err
+}
+
+// VerifyRecovered reads back every key the core workload w wrote with
+// VerifyProperty set from db, a copy of the database recovered from a crash,
+// and returns what it found there apart from the anomalies of the run: the
+// writes acknowledged, and the keys read back and lost or corrupt. ok is
+// false if w does not verify.
+func VerifyRecovered(ctx context.Context, w ycsb.Workload, db ycsb.DB) (anomalies Anomalies, ok bool, err error) {
+	c, ok := w.(*core)
+	if !ok || c.verifier == nil {
+		return Anomalies{}, false, nil
+	}
+	err = c.verifier.readBack(ctx, db, c.fieldNames, &anomalies)
+	anomalies.Writes = c.verifier.writes.Load()
+	return anomalies, true, err
+}
+
+// verifyingDB stamps a new version on every value written through it and
+// checks every value read, for the core workload with VerifyProperty set.
+// Batches are not supported.
+type verifyingDB struct {
+	ycsb.DB
+	v *verifier
+}
+
+func (d verifyingDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	start := time.Now()
+	values, err := d.DB.Read(ctx, table, key, fields)
+	d.v.checkRead(key, values, err, start)
+	return values, err
+}
+
+func (d verifyingDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	start := time.Now()
+	records, err := d.DB.Scan(ctx, table, startKey, count, fields)
+	d.v.checkScan(records, err, start)
+	return records, err
+}
+
+func (d verifyingDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	version := d.v.begin(key)
+	err := d.DB.Update(ctx, table, key, d.v.stamp(key, version, values))
+	d.v.end(table, key, version, err)
+	return err
+}
+
+func (d verifyingDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	version := d.v.begin(key)
+	err := d.DB.Insert(ctx, table, key, d.v.stamp(key, version, values))
+	d.v.end(table, key, version, err)
+	return err
+}
+
+func (d verifyingDB) Delete(ctx context.Context, table string, key string) error {
+	d.v.begin(key)
+	err := d.DB.Delete(ctx, table, key)
+	d.v.end(table, key, 0, err)
+	return err
+}
+
+// ReadModifyWrite checks the value read and stamps the one written, with no
+// other write to key in between
+func (d verifyingDB) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, modify func(map[string][]byte) map[string][]byte) error {
+	version := d.v.begin(key)
+	start := time.Now()
+	err := godbdb.ReadModifyWrite(ctx, d.DB, table, key, fields, func(values map[string][]byte) map[string][]byte {
+		d.v.checkRead(key, values, nil, start)
+		return d.v.stamp(key, version, modify(values))
+	})
+	d.v.end(table, key, version, err)
+	return err
+}
+
+// PartialRead is not checked, as part of a value cannot be
+func (d verifyingDB) PartialRead(ctx context.Context, table string, key string, field string, offset int, length int) ([]byte, error) {
+	return godbdb.PartialRead(ctx, d.DB, table, key, field, offset, length)
+}
+
+func (d verifyingDB) PrefixScan(ctx context.Context, table string, prefix string, fields []string) ([]map[string][]byte, error) {
+	start := time.Now()
+	records, err := godbdb.PrefixScan(ctx, d.DB, table, prefix, fields)
+	d.v.checkScan(records, err, start)
+	return records, err
+}
+
+// versionedHeader starts every value written with VerifyProperty set
+func versionedHeader(key string, version uint64) string {
+	return key + ":" + strconv.FormatUint(version, 16) + ":"
+}
+
+// fillVersioned fills buf after the header at its start with a hash chain of
+// the header, so any change to the value shows
+func fillVersioned(buf []byte, header int) {
+	n := header
+	for n < len(buf) {
+		sum := strconv.AppendUint(nil, uint64(util.BytesHash64(buf[:n])), 16)
+		n += copy(buf[n:], sum)
+		if n < len(buf) {
+			buf[n] = ':'
+			n++
+		}
+	}
+}
+
+// versionedValue returns a value of size bytes, or of the header if that is
+// longer, for version of key
+func versionedValue(key string, version uint64, size int) []byte {
+	header := versionedHeader(key, version)
+	buf := make([]byte, max(size, len(header)))
+	copy(buf, header)
+	fillVersioned(buf, len(header))
+	return buf
+}
+
+// parseVersionedValue returns the key and version of a value written with
+// VerifyProperty set, and whether it is intact
+func parseVersionedValue(value []byte) (string, uint64, bool) {
+	key, rest, ok := strings.Cut(string(value), ":")
+	if !ok {
+		return "", 0, false
+	}
+	hex, _, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", 0, false
+	}
+	version, err := strconv.ParseUint(hex, 16, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	expected := versionedValue(key, version, len(value))
+	if string(expected) != string(value) {
+		return "", 0, false
+	}
+	return key, version, true
+}